@@ -0,0 +1,125 @@
+// Command migrate applies, rolls back and inspects the embedded database
+// migrations, using the same DB_* environment as the server.
+//
+//	migrate up [n]     apply pending migrations (all, or the next n)
+//	migrate down [n]   roll back the latest migration (or the latest n)
+//	migrate redo       roll back the latest migration and apply it again
+//	migrate status     list migrations; exits 1 if any applied one has drifted
+//	migrate version    print the latest applied migration
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/db"
+)
+
+const usage = "usage: migrate up [n] | down [n] | redo | status | version"
+
+func main() {
+	if len(os.Args) < 2 {
+		fail(usage)
+	}
+	n := 0
+	if len(os.Args) > 2 {
+		parsed, err := strconv.Atoi(os.Args[2])
+		if err != nil || parsed < 1 {
+			fail("n must be a positive integer")
+		}
+		n = parsed
+	}
+
+	cfg := config.Load()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	pool, err := db.Connect(ctx, cfg.DatabaseURL())
+	if err != nil {
+		fail(err.Error())
+	}
+	defer pool.Close()
+
+	switch os.Args[1] {
+	case "up":
+		done, err := db.Up(ctx, pool, n)
+		printDone("applied", done)
+		check(err)
+	case "down":
+		done, err := db.Down(ctx, pool, n)
+		printDone("rolled back", done)
+		check(err)
+	case "redo":
+		name, err := db.Redo(ctx, pool)
+		check(err)
+		fmt.Println("redid", name)
+	case "status":
+		statuses, err := db.Status(ctx, pool)
+		check(err)
+		if printStatus(statuses) {
+			pool.Close()
+			os.Exit(1)
+		}
+	case "version":
+		version, err := db.Version(ctx, pool)
+		check(err)
+		if version == "" {
+			version = "none"
+		}
+		fmt.Println(version)
+	default:
+		fail(usage)
+	}
+}
+
+func printDone(verb string, names []string) {
+	if len(names) == 0 {
+		fmt.Println("nothing to do")
+	}
+	for _, name := range names {
+		fmt.Println(verb, name)
+	}
+}
+
+// printStatus writes a status table and reports whether anything drifted.
+func printStatus(statuses []db.MigrationStatus) bool {
+	drifted := false
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "MIGRATION\tSTATE\tAPPLIED AT\tDOWN")
+	for _, s := range statuses {
+		state, appliedAt, down := "pending", "", "no"
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		if s.Drifted {
+			state = "DRIFTED"
+			drifted = true
+		}
+		if s.Missing {
+			state = "not in build"
+		}
+		if s.Reversible {
+			down = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Filename, state, appliedAt, down)
+	}
+	tw.Flush()
+	return drifted
+}
+
+func check(err error) {
+	if err != nil {
+		fail(err.Error())
+	}
+}
+
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
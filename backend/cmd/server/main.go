@@ -11,7 +11,10 @@ import (
 
 	"github.com/izz-linux/budget-mgmt/backend/internal/config"
 	"github.com/izz-linux/budget-mgmt/backend/internal/db"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
 	"github.com/izz-linux/budget-mgmt/backend/internal/router"
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
@@ -32,13 +35,41 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The replica is optional: without one, or if it can't be reached at
+	// startup, reports read from the primary like everything else
+	var replica *pgxpool.Pool
+	if url := cfg.ReplicaDatabaseURL(); url != "" {
+		replica, err = db.Connect(ctx, url)
+		if err != nil {
+			slog.Warn("read replica unavailable, reading from the primary", "host", cfg.DBReplicaHost, "error", err)
+		} else {
+			defer replica.Close()
+			slog.Info("reporting queries use the read replica", "host", cfg.DBReplicaHost)
+		}
+	}
+
 	if cfg.AuthEnabled() {
 		slog.Info("authentication enabled", "username", cfg.AuthUsername)
 	} else {
 		slog.Warn("authentication disabled – set AUTH_USERNAME, AUTH_PASSWORD_HASH, and JWT_SECRET to enable")
 	}
 
-	handler := router.New(pool, cfg)
+	// Replicas sharing the database take an advisory lock per scheduled job
+	// run, so reminders and webhook retries run on one replica at a time
+	runner := jobs.NewRunner().WithLocker(jobs.NewPGLocker(pool, cfg.InstanceID))
+	if !cfg.SchedulerEnabled {
+		runner.DisableScheduler()
+		slog.Info("scheduler disabled on this instance", "instance", cfg.InstanceID)
+	}
+
+	// Uploads awaiting confirmation must be visible to every replica, so
+	// multi-replica deployments should use S3 or a shared directory
+	uploads, err := storage.New(cfg.UploadStorageConfig())
+	if err != nil {
+		slog.Error("failed to set up upload storage", "error", err)
+		os.Exit(1)
+	}
+	handler := router.New(pool, replica, cfg, runner, uploads)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.ServerPort,
@@ -63,12 +94,17 @@ func main() {
 	<-done
 	slog.Info("shutting down server")
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Requests and background jobs share one deadline. Jobs still running
+	// when it passes are cancelled and pick up where they left off on restart.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
 	defer shutdownCancel()
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("shutdown error", "error", err)
 	}
+	if err := runner.Shutdown(shutdownCtx); err != nil {
+		slog.Error("background jobs did not finish", "error", err)
+	}
 
 	slog.Info("server stopped")
 }
@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,17 +16,156 @@ import (
 
 const CookieName = "auth_token"
 
+// RefreshCookieName holds the long-lived refresh token. It is only sent to
+// the auth endpoints.
+const RefreshCookieName = "refresh_token"
+
+// FeedAudience marks tokens that only grant read access to the calendar feed.
+// Calendar apps cannot send the session cookie, so the feed URL carries one.
+const FeedAudience = "calendar-feed"
+
+// APIKeyHeader carries an API key in place of the session cookie.
+const APIKeyHeader = "X-API-Key"
+
+// Role is what a session may do. Each role can do everything the ones
+// before it can.
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // read-only
+	RoleEditor Role = "editor" // also manages bills, income and assignments
+	RoleAdmin  Role = "admin"  // also runs imports, backups, migrations and user management
+)
+
+var roleRanks = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// ParseRole reports whether s names a role.
+func ParseRole(s string) (Role, bool) {
+	role := Role(s)
+	_, ok := roleRanks[role]
+	return role, ok
+}
+
+// Allows reports whether r grants at least min. An unknown role allows nothing.
+func (r Role) Allows(min Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[min]
+}
+
+// Scope is a group of routes an API key may use. Keys reach nothing outside
+// their scopes; login sessions aren't limited by scope, only by role.
+type Scope string
+
+const (
+	ScopeReadBills        Scope = "read:bills"        // bills, pay periods, assignments, the budget grid and widgets
+	ScopeWriteAssignments Scope = "write:assignments" // create, edit, pay and delete assignments
+	ScopeRunAutoAssign    Scope = "run:autoassign"    // run auto-assign
+)
+
+var scopes = map[Scope]bool{ScopeReadBills: true, ScopeWriteAssignments: true, ScopeRunAutoAssign: true}
+
+// ParseScope reports whether s names a scope.
+func ParseScope(s string) (Scope, bool) {
+	scope := Scope(s)
+	return scope, scopes[scope]
+}
+
+// Session is who a session token was issued to. ID is the refresh token the
+// access token came from, or 0 for tokens issued before refresh tokens.
+// KeyID is set instead for requests made with an API key, which may only
+// use the routes their Scopes name.
+type Session struct {
+	ID       int
+	Username string
+	Role     Role
+	KeyID    int
+	Scopes   []Scope
+}
+
+// KeySession is the session for API key id. A key that can change anything
+// acts as an editor, otherwise as a viewer; the scopes narrow that further.
+func KeySession(id int, name string, keyScopes []Scope) Session {
+	role := RoleViewer
+	for _, s := range keyScopes {
+		if s != ScopeReadBills {
+			role = RoleEditor
+		}
+	}
+	return Session{Username: "api-key:" + name, Role: role, KeyID: id, Scopes: keyScopes}
+}
+
+// HasScope reports whether the session may use routes grouped under scope.
+// Login sessions may use them all.
+func (s Session) HasScope(scope Scope) bool {
+	if s.KeyID == 0 {
+		return true
+	}
+	for _, have := range s.Scopes {
+		if have == scope {
+			return true
+		}
+	}
+	return false
+}
+
 func VerifyPassword(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func CreateToken(secret, username string, expiry time.Duration) (string, time.Time, error) {
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// NewRefreshToken returns a random refresh token and the SHA-256 hash that
+// is stored in its place.
+func NewRefreshToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken is how a refresh token is looked up in the database.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKey returns a random API key and the SHA-256 hash that is stored in
+// its place. The prefix tells keys apart from session tokens in logs and
+// secret scanners.
+func NewAPIKey() (key, hash string, err error) {
+	token, _, err := NewRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+	key = "bmk_" + token
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey is how an API key is looked up in the database.
+func HashAPIKey(key string) string {
+	return HashRefreshToken(key)
+}
+
+func CreateToken(secret string, session Session, expiry time.Duration) (string, time.Time, error) {
 	exp := time.Now().Add(expiry)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": username,
-		"exp": jwt.NewNumericDate(exp),
-		"iat": jwt.NewNumericDate(time.Now()),
-	})
+	claims := jwt.MapClaims{
+		"sub":  session.Username,
+		"role": string(session.Role),
+		"exp":  jwt.NewNumericDate(exp),
+		"iat":  jwt.NewNumericDate(time.Now()),
+	}
+	if session.ID != 0 {
+		claims["sid"] = session.ID
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	signed, err := token.SignedString([]byte(secret))
 	if err != nil {
 		return "", time.Time{}, err
@@ -31,7 +173,7 @@ func CreateToken(secret, username string, expiry time.Duration) (string, time.Ti
 	return signed, exp, nil
 }
 
-func ValidateToken(secret, tokenStr string) (string, error) {
+func ValidateToken(secret, tokenStr string) (Session, error) {
 	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
@@ -39,13 +181,65 @@ func ValidateToken(secret, tokenStr string) (string, error) {
 		return []byte(secret), nil
 	})
 	if err != nil {
-		return "", err
+		return Session{}, err
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", fmt.Errorf("invalid token")
+		return Session{}, fmt.Errorf("invalid token")
+	}
+	// Feed tokens travel in URLs and must not open the rest of the API
+	if aud, _ := claims.GetAudience(); len(aud) > 0 {
+		return Session{}, fmt.Errorf("token is scoped to %s", aud[0])
 	}
 	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return Session{}, fmt.Errorf("missing subject")
+	}
+	// Sessions from before roles were all the configured admin's
+	role := RoleAdmin
+	if claim, ok := claims["role"]; ok {
+		name, _ := claim.(string)
+		if role, ok = ParseRole(name); !ok {
+			return Session{}, fmt.Errorf("unknown role %q", name)
+		}
+	}
+	// JSON numbers decode as float64
+	sid, _ := claims["sid"].(float64)
+	return Session{ID: int(sid), Username: sub, Role: role}, nil
+}
+
+// CreateFeedToken signs a non-expiring token for the calendar feed. Rotating
+// JWT_SECRET revokes every feed token along with the sessions.
+func CreateFeedToken(secret, username string) (string, error) {
+	return createReadToken(secret, username, FeedAudience)
+}
+
+// ValidateFeedToken checks a token made by CreateFeedToken and returns its subject.
+func ValidateFeedToken(secret, tokenStr string) (string, error) {
+	return validateReadToken(secret, tokenStr, FeedAudience)
+}
+
+// createReadToken signs a non-expiring token limited to audience.
+func createReadToken(secret, username, audience string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": username,
+		"aud": audience,
+		"iat": jwt.NewNumericDate(time.Now()),
+	})
+	return token.SignedString([]byte(secret))
+}
+
+func validateReadToken(secret, tokenStr, audience string) (string, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	}, jwt.WithAudience(audience))
+	if err != nil {
+		return "", err
+	}
+	sub, _ := token.Claims.GetSubject()
 	if sub == "" {
 		return "", fmt.Errorf("missing subject")
 	}
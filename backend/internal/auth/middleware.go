@@ -1,15 +1,72 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 )
 
-func RequireAuth(jwtSecret string, authEnabled bool) func(http.Handler) http.Handler {
+type contextKey struct{}
+
+// Username returns the authenticated user RequireAuth stored on the request
+// context, or "" when authentication is disabled.
+func Username(ctx context.Context) string {
+	session, _ := ctx.Value(contextKey{}).(Session)
+	return session.Username
+}
+
+// CurrentRole returns the role RequireAuth stored on the request context:
+// the session's role, RoleAdmin when authentication is disabled, or "" when
+// RequireAuth has not run.
+func CurrentRole(ctx context.Context) Role {
+	session, _ := ctx.Value(contextKey{}).(Session)
+	return session.Role
+}
+
+// IsAPIKey reports whether RequireAuth authenticated the request with an API
+// key rather than a signed-in user.
+func IsAPIKey(ctx context.Context) bool {
+	session, _ := ctx.Value(contextKey{}).(Session)
+	return session.KeyID != 0
+}
+
+// WithSession returns ctx carrying session, as RequireAuth leaves it.
+func WithSession(ctx context.Context, session Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, session)
+}
+
+// RevocationCheck reports whether the session an access token belongs to has
+// been revoked since the token was issued.
+type RevocationCheck func(ctx context.Context, sessionID int) (bool, error)
+
+// KeyLookup finds the session for an API key, reporting false for keys that
+// don't exist or have been revoked.
+type KeyLookup func(ctx context.Context, key string) (Session, bool, error)
+
+// RequireAuth accepts requests carrying a valid access token. When revoked
+// is not nil, tokens of revoked sessions are turned away too. When keys is
+// not nil, an X-API-Key header is accepted in place of the token; RequireScope
+// then decides which routes the key reaches.
+func RequireAuth(jwtSecret string, authEnabled bool, revoked RevocationCheck, keys KeyLookup) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if !authEnabled {
-				next.ServeHTTP(w, r)
+				// Without a login everyone is the administrator
+				next.ServeHTTP(w, r.WithContext(WithSession(r.Context(), Session{Role: RoleAdmin})))
+				return
+			}
+
+			if key := r.Header.Get(APIKeyHeader); key != "" && keys != nil {
+				session, ok, err := keys(r.Context(), key)
+				if err != nil {
+					slog.Error("looking up API key", "error", err)
+				}
+				if err != nil || !ok {
+					writeUnauthorized(w)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(WithSession(r.Context(), session)))
 				return
 			}
 
@@ -19,16 +76,86 @@ func RequireAuth(jwtSecret string, authEnabled bool) func(http.Handler) http.Han
 				return
 			}
 
-			if _, err := ValidateToken(jwtSecret, cookie.Value); err != nil {
+			session, err := ValidateToken(jwtSecret, cookie.Value)
+			if err != nil {
 				writeUnauthorized(w)
 				return
 			}
+			if revoked != nil && session.ID != 0 {
+				isRevoked, err := revoked(r.Context(), session.ID)
+				if err != nil {
+					slog.Error("checking session revocation", "session", session.ID, "error", err)
+					writeUnauthorized(w)
+					return
+				}
+				if isRevoked {
+					writeUnauthorized(w)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithSession(r.Context(), session)))
+		})
+	}
+}
+
+// RequireRole rejects requests whose session lacks min with 403. It must run
+// after RequireAuth.
+func RequireRole(min Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !CurrentRole(r.Context()).Allows(min) {
+				writeForbidden(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
+// RequireRoleForWrites is RequireRole for everything but GET, HEAD and
+// OPTIONS, which any session may make.
+func RequireRoleForWrites(min Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		guarded := RequireRole(min)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+			default:
+				guarded.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RequireScope rejects API keys without scope with 403. Login sessions pass.
+// It must run after RequireAuth.
+func RequireScope(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, _ := r.Context().Value(contextKey{}).(Session)
+			if !session.HasScope(scope) {
+				writeForbiddenMessage(w, "this API key lacks the "+string(scope)+" scope")
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// RejectAPIKeys turns every API key away with 403, for routes no scope
+// covers. It must run after RequireAuth.
+func RejectAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if session, _ := r.Context().Value(contextKey{}).(Session); session.KeyID != 0 {
+			writeForbiddenMessage(w, "API keys can't use this route")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func writeUnauthorized(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
@@ -36,3 +163,15 @@ func writeUnauthorized(w http.ResponseWriter) {
 		"error": map[string]string{"message": "unauthorized"},
 	})
 }
+
+func writeForbidden(w http.ResponseWriter) {
+	writeForbiddenMessage(w, "your role does not allow this")
+}
+
+func writeForbiddenMessage(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"code": "FORBIDDEN", "message": msg},
+	})
+}
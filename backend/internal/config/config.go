@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
 )
 
 type Config struct {
@@ -15,12 +18,80 @@ type Config struct {
 	DBPassword string
 	DBSSLMode  string
 
+	// Optional read replica for reports, forecasts and dashboards. It uses
+	// the primary's database name and credentials.
+	DBReplicaHost string
+	DBReplicaPort int
+
 	AuthUsername        string
 	AuthPasswordHash   string
 	JWTSecret          string
 	TurnstileSecretKey string
+
+	ContentSecurityPolicy string
+	HSTSMaxAge            int // seconds; 0 disables Strict-Transport-Security
+	ReferrerPolicy        string
+
+	ImportSessionTTLMinutes int // 0 keeps an import preview until confirmed
+
+	// Token-bucket rate limits per signed-in user, or per IP before login.
+	// A per-minute rate of 0 turns that limit off.
+	RateLimitPerMinute      int
+	RateLimitBurst          int
+	LoginRateLimitPerMinute int // stricter, for /auth/login
+	LoginRateLimitBurst     int
+	ImportRateLimitPerMinute int // for the import endpoints
+	ImportRateLimitBurst     int
+
+	// Reverse proxies (addresses or CIDR ranges) whose X-Forwarded-For and
+	// X-Real-IP headers name the client. Empty trusts none, so clients are
+	// told apart by their socket address.
+	TrustedProxies []string
+
+	// Where pending uploads live: local (UploadDir), s3 or memory. Replicas
+	// must share it for an upload on one to be confirmed on another.
+	UploadStorage       string
+	UploadDir           string
+	UploadS3Endpoint    string
+	UploadS3Region      string
+	UploadS3Bucket      string
+	UploadS3Prefix      string
+	UploadS3AccessKeyID string
+	UploadS3SecretKey   string
+
+	ShutdownTimeoutSeconds int // how long to drain requests and background jobs
+
+	InstanceID       string // identifies this replica as a scheduler lock holder
+	SchedulerEnabled bool   // run scheduled jobs (reminders, webhook retries) on this replica
+
+	SlowQueryMS    int  // queries at least this slow are logged; 0 disables
+	MetricsEnabled bool // serve query metrics at /metrics
+
+	// TestFixturesEnabled serves endpoints that wipe and seed the database
+	// for end-to-end tests. Never enable it outside a test environment.
+	TestFixturesEnabled bool
+
+	// ObligationAlertPercent is the rise in monthly bills within 90 days that
+	// publishes an obligations alert; 0 turns the alert off.
+	ObligationAlertPercent int
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
 }
 
+// DefaultContentSecurityPolicy allows the app's own assets plus the Cloudflare
+// Turnstile widget used on the login page.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' https://challenges.cloudflare.com; " +
+	"frame-src https://challenges.cloudflare.com; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"connect-src 'self'; " +
+	"base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
 func (c *Config) AuthEnabled() bool {
 	return c.AuthUsername != "" && c.AuthPasswordHash != "" && c.JWTSecret != ""
 }
@@ -35,11 +106,84 @@ func Load() *Config {
 		DBPassword: getEnv("DB_PASSWORD", "budget_local_dev"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 
+		DBReplicaHost: getEnv("DB_REPLICA_HOST", ""),
+		DBReplicaPort: getEnvInt("DB_REPLICA_PORT", getEnvInt("DB_PORT", 5432)),
+
 		AuthUsername:        getEnv("AUTH_USERNAME", ""),
 		AuthPasswordHash:   getEnv("AUTH_PASSWORD_HASH", ""),
 		JWTSecret:          getEnv("JWT_SECRET", ""),
 		TurnstileSecretKey: getEnv("TURNSTILE_SECRET_KEY", ""),
+
+		ContentSecurityPolicy: getEnv("CONTENT_SECURITY_POLICY", DefaultContentSecurityPolicy),
+		HSTSMaxAge:            getEnvInt("HSTS_MAX_AGE", 0),
+		ReferrerPolicy:        getEnv("REFERRER_POLICY", "strict-origin-when-cross-origin"),
+
+		ImportSessionTTLMinutes: getEnvInt("IMPORT_SESSION_TTL_MINUTES", 30),
+
+		RateLimitPerMinute:       getEnvInt("RATE_LIMIT_PER_MINUTE", 300),
+		RateLimitBurst:           getEnvInt("RATE_LIMIT_BURST", 60),
+		LoginRateLimitPerMinute:  getEnvInt("LOGIN_RATE_LIMIT_PER_MINUTE", 5),
+		LoginRateLimitBurst:      getEnvInt("LOGIN_RATE_LIMIT_BURST", 5),
+		ImportRateLimitPerMinute: getEnvInt("IMPORT_RATE_LIMIT_PER_MINUTE", 20),
+		ImportRateLimitBurst:     getEnvInt("IMPORT_RATE_LIMIT_BURST", 10),
+		TrustedProxies:           getEnvList("TRUSTED_PROXIES"),
+
+		UploadStorage:       getEnv("UPLOAD_STORAGE", "local"),
+		UploadDir:           getEnv("UPLOAD_DIR", os.TempDir()),
+		UploadS3Endpoint:    getEnv("UPLOAD_S3_ENDPOINT", ""),
+		UploadS3Region:      getEnv("UPLOAD_S3_REGION", "us-east-1"),
+		UploadS3Bucket:      getEnv("UPLOAD_S3_BUCKET", ""),
+		UploadS3Prefix:      getEnv("UPLOAD_S3_PREFIX", ""),
+		UploadS3AccessKeyID: getEnv("UPLOAD_S3_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+		UploadS3SecretKey:   getEnv("UPLOAD_S3_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+
+		ShutdownTimeoutSeconds: getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+
+		InstanceID:       getEnv("INSTANCE_ID", defaultInstanceID()),
+		SchedulerEnabled: getEnv("SCHEDULER_ENABLED", "true") == "true",
+
+		SlowQueryMS:    getEnvInt("SLOW_QUERY_MS", 250),
+		MetricsEnabled: getEnv("METRICS_ENABLED", "false") == "true",
+
+		TestFixturesEnabled: getEnv("TEST_FIXTURES_ENABLED", "false") == "true",
+
+		ObligationAlertPercent: getEnvInt("OBLIGATION_ALERT_PERCENT", 10),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", ""),
+	}
+}
+
+// UploadStorageConfig describes the store for pending uploads.
+func (c *Config) UploadStorageConfig() storage.Config {
+	return storage.Config{
+		Kind: c.UploadStorage,
+		Dir:  c.UploadDir,
+		S3: storage.S3Config{
+			Endpoint:        c.UploadS3Endpoint,
+			Region:          c.UploadS3Region,
+			Bucket:          c.UploadS3Bucket,
+			Prefix:          c.UploadS3Prefix,
+			AccessKeyID:     c.UploadS3AccessKeyID,
+			SecretAccessKey: c.UploadS3SecretKey,
+		},
+	}
+}
+
+// SMTPEnabled reports whether email reminders can be sent.
+func (c *Config) SMTPEnabled() bool {
+	return c.SMTPHost != "" && c.SMTPFrom != ""
+}
+
+// defaultInstanceID is the hostname, which is unique per container or pod.
+func defaultInstanceID() string {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
 	}
+	return fmt.Sprintf("pid-%d", os.Getpid())
 }
 
 func (c *Config) DatabaseURL() string {
@@ -47,6 +191,16 @@ func (c *Config) DatabaseURL() string {
 		c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName, c.DBSSLMode)
 }
 
+// ReplicaDatabaseURL is the read replica's connection URL, or "" when no
+// replica is configured.
+func (c *Config) ReplicaDatabaseURL() string {
+	if c.DBReplicaHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		c.DBUser, c.DBPassword, c.DBReplicaHost, c.DBReplicaPort, c.DBName, c.DBSSLMode)
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -54,6 +208,17 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
 func getEnvInt(key string, fallback int) int {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.Atoi(v); err == nil {
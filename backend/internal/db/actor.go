@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/jackc/pgx/v5"
+)
+
+// requestScope is what the audit_log triggers read from the session settings
+// budget.actor and budget.request_id: the signed-in user, and the request
+// whose changes an undo reverts together. scenario is the what-if schema put
+// ahead of the live tables on the search path, if any.
+type requestScope struct {
+	actor     string
+	requestID string
+	scenario  string
+}
+
+type scenarioKey struct{}
+
+// WithScenario makes queries run with ctx find a what-if scenario's copies of
+// the budget tables in schema before the live ones. Tables the scenario has
+// no copy of are still the live ones.
+func WithScenario(ctx context.Context, schema string) context.Context {
+	return context.WithValue(ctx, scenarioKey{}, schema)
+}
+
+func scenarioFrom(ctx context.Context) string {
+	s, _ := ctx.Value(scenarioKey{}).(string)
+	return s
+}
+
+// actorTracker keeps each pooled connection's settings in step with the
+// request acquiring it. They are only written when they change, so a
+// connection reused within the same request costs nothing.
+type actorTracker struct {
+	mu     sync.Mutex
+	scopes map[*pgx.Conn]requestScope
+}
+
+func newActorTracker() *actorTracker {
+	return &actorTracker{scopes: make(map[*pgx.Conn]requestScope)}
+}
+
+// prepare is the pool's PrepareConn hook. Background jobs have neither a
+// user nor a request id, and requests with auth disabled have no user.
+func (t *actorTracker) prepare(ctx context.Context, conn *pgx.Conn) (bool, error) {
+	scope := requestScope{actor: auth.Username(ctx), requestID: middleware.GetReqID(ctx), scenario: scenarioFrom(ctx)}
+
+	t.mu.Lock()
+	current := t.scopes[conn]
+	t.mu.Unlock()
+	if current == scope {
+		return true, nil
+	}
+
+	if _, err := conn.Exec(ctx, `SELECT set_config('budget.actor', $1, false), set_config('budget.request_id', $2, false)`,
+		scope.actor, scope.requestID); err != nil {
+		return false, err
+	}
+	if scope.scenario != current.scenario {
+		// Start from the session default so one scenario never stacks on another
+		if _, err := conn.Exec(ctx, `RESET search_path`); err != nil {
+			return false, err
+		}
+		if scope.scenario != "" {
+			if _, err := conn.Exec(ctx, `SELECT set_config('search_path', $1 || ', ' || current_setting('search_path'), false)`,
+				pgx.Identifier{scope.scenario}.Sanitize()); err != nil {
+				return false, err
+			}
+		}
+	}
+	t.mu.Lock()
+	t.scopes[conn] = scope
+	t.mu.Unlock()
+	return true, nil
+}
+
+// forget is the pool's BeforeClose hook.
+func (t *actorTracker) forget(conn *pgx.Conn) {
+	t.mu.Lock()
+	delete(t.scopes, conn)
+	t.mu.Unlock()
+}
@@ -5,7 +5,6 @@ import (
 	"embed"
 	"fmt"
 	"log/slog"
-	"strings"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -13,8 +12,19 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// Connect opens a pool whose connections carry the signed-in user and the id
+// of the request using them, for the audit log, and look in a what-if
+// scenario's schema first when the request works on one.
 func Connect(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(ctx, databaseURL)
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing database url: %w", err)
+	}
+	actors := newActorTracker()
+	config.PrepareConn = actors.prepare
+	config.BeforeClose = actors.forget
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("creating pool: %w", err)
 	}
@@ -27,73 +37,3 @@ func Connect(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 	slog.Info("connected to database")
 	return pool, nil
 }
-
-func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
-	entries, err := migrationsFS.ReadDir("migrations")
-	if err != nil {
-		return fmt.Errorf("reading migrations dir: %w", err)
-	}
-
-	// Create migrations tracking table
-	_, err = pool.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			filename VARCHAR(255) PRIMARY KEY,
-			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("creating migrations table: %w", err)
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
-			continue
-		}
-
-		// Check if already applied
-		var exists bool
-		err := pool.QueryRow(ctx,
-			"SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE filename = $1)",
-			entry.Name(),
-		).Scan(&exists)
-		if err != nil {
-			return fmt.Errorf("checking migration %s: %w", entry.Name(), err)
-		}
-		if exists {
-			slog.Info("migration already applied", "file", entry.Name())
-			continue
-		}
-
-		// Read and execute
-		content, err := migrationsFS.ReadFile("migrations/" + entry.Name())
-		if err != nil {
-			return fmt.Errorf("reading migration %s: %w", entry.Name(), err)
-		}
-
-		tx, err := pool.Begin(ctx)
-		if err != nil {
-			return fmt.Errorf("beginning transaction for %s: %w", entry.Name(), err)
-		}
-
-		if _, err := tx.Exec(ctx, string(content)); err != nil {
-			tx.Rollback(ctx)
-			return fmt.Errorf("executing migration %s: %w", entry.Name(), err)
-		}
-
-		if _, err := tx.Exec(ctx,
-			"INSERT INTO schema_migrations (filename) VALUES ($1)",
-			entry.Name(),
-		); err != nil {
-			tx.Rollback(ctx)
-			return fmt.Errorf("recording migration %s: %w", entry.Name(), err)
-		}
-
-		if err := tx.Commit(ctx); err != nil {
-			return fmt.Errorf("committing migration %s: %w", entry.Name(), err)
-		}
-
-		slog.Info("applied migration", "file", entry.Name())
-	}
-
-	return nil
-}
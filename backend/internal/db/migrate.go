@@ -0,0 +1,335 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Conn is what migrations run against; *pgxpool.Pool satisfies it, as does
+// the handlers' instrumented pool.
+type Conn interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// downSuffix marks a migration's rollback script: 027_payroll_calendars.sql
+// is undone by 027_payroll_calendars.down.sql.
+const downSuffix = ".down.sql"
+
+// Migration is one embedded migration. Filename identifies it in
+// schema_migrations; Checksum is the SHA-256 of its up script.
+type Migration struct {
+	Filename string
+	Up       string
+	Down     string // empty when the migration can't be rolled back
+	Checksum string
+}
+
+// MigrationStatus is a migration as the database sees it. Drifted means the
+// script was edited after it was applied; Missing means the database has a
+// migration this build doesn't know.
+type MigrationStatus struct {
+	Filename   string     `json:"filename"`
+	Applied    bool       `json:"applied"`
+	AppliedAt  *time.Time `json:"applied_at"`
+	Checksum   string     `json:"checksum"`
+	Drifted    bool       `json:"drifted"`
+	Missing    bool       `json:"missing"`
+	Reversible bool       `json:"reversible"`
+}
+
+// ErrIrreversible is returned by Down for a migration without a down script.
+var ErrIrreversible = errors.New("migration has no down script")
+
+// LoadMigrations reads the embedded migrations in filename order.
+func LoadMigrations() ([]Migration, error) {
+	return loadMigrations(migrationsFS, "migrations")
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations dir: %w", err)
+	}
+
+	downs := map[string]string{}
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, dir+"/"+name)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", name, err)
+		}
+		if strings.HasSuffix(name, downSuffix) {
+			downs[strings.TrimSuffix(name, downSuffix)+".sql"] = string(content)
+			continue
+		}
+		sum := sha256.Sum256(content)
+		migrations = append(migrations, Migration{Filename: name, Up: string(content), Checksum: hex.EncodeToString(sum[:])})
+	}
+
+	for i := range migrations {
+		migrations[i].Down = downs[migrations[i].Filename]
+		delete(downs, migrations[i].Filename)
+	}
+	for name := range downs {
+		return nil, fmt.Errorf("down script for unknown migration %s", name)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Filename < migrations[j].Filename })
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates schema_migrations, adding the checksum
+// column to tables created before checksums were recorded.
+func ensureMigrationsTable(ctx context.Context, conn Conn) error {
+	_, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			filename VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64) NOT NULL DEFAULT ''
+	`)
+	if err != nil {
+		return fmt.Errorf("creating migrations table: %w", err)
+	}
+	return nil
+}
+
+type appliedMigration struct {
+	appliedAt time.Time
+	checksum  string
+}
+
+func appliedMigrations(ctx context.Context, conn Conn) (map[string]appliedMigration, error) {
+	rows, err := conn.Query(ctx, `SELECT filename, applied_at, checksum FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("reading applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]appliedMigration{}
+	for rows.Next() {
+		var name string
+		var a appliedMigration
+		if err := rows.Scan(&name, &a.appliedAt, &a.checksum); err != nil {
+			return nil, fmt.Errorf("reading applied migrations: %w", err)
+		}
+		applied[name] = a
+	}
+	return applied, rows.Err()
+}
+
+// migrationStatus merges the embedded migrations with what the database has
+// applied, in filename order.
+func migrationStatus(migrations []Migration, applied map[string]appliedMigration) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	known := make(map[string]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Filename] = true
+		s := MigrationStatus{Filename: m.Filename, Checksum: m.Checksum, Reversible: m.Down != ""}
+		if a, ok := applied[m.Filename]; ok {
+			appliedAt := a.appliedAt
+			s.Applied = true
+			s.AppliedAt = &appliedAt
+			// Migrations applied before checksums were kept have none to compare
+			s.Drifted = a.checksum != "" && a.checksum != m.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	for name, a := range applied {
+		if known[name] {
+			continue
+		}
+		appliedAt := a.appliedAt
+		statuses = append(statuses, MigrationStatus{Filename: name, Applied: true, AppliedAt: &appliedAt, Checksum: a.checksum, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Filename < statuses[j].Filename })
+	return statuses
+}
+
+// Status reports every migration, applied or pending.
+func Status(ctx context.Context, conn Conn) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(migrations, applied), nil
+}
+
+// Version is the filename of the latest applied migration, or "" for none.
+func Version(ctx context.Context, conn Conn) (string, error) {
+	statuses, err := Status(ctx, conn)
+	if err != nil {
+		return "", err
+	}
+	version := ""
+	for _, s := range statuses {
+		if s.Applied {
+			version = s.Filename
+		}
+	}
+	return version, nil
+}
+
+// Up applies up to n pending migrations in order, all of them when n <= 0,
+// each in its own transaction. Drifted migrations are logged but not re-run.
+// It returns the filenames applied.
+func Up(ctx context.Context, conn Conn, n int) ([]string, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for _, m := range migrations {
+		if a, ok := applied[m.Filename]; ok {
+			switch {
+			case a.checksum == "":
+				// Adopt the current script as the baseline for drift checks
+				if _, err := conn.Exec(ctx, `UPDATE schema_migrations SET checksum = $2 WHERE filename = $1`, m.Filename, m.Checksum); err != nil {
+					return done, fmt.Errorf("recording checksum of %s: %w", m.Filename, err)
+				}
+			case a.checksum != m.Checksum:
+				slog.Warn("applied migration has changed since it ran", "file", m.Filename)
+			}
+			continue
+		}
+		if n > 0 && len(done) == n {
+			break
+		}
+
+		err := runInTx(ctx, conn, m.Up, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (filename, checksum) VALUES ($1, $2)`, m.Filename, m.Checksum)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("applying migration %s: %w", m.Filename, err)
+		}
+		slog.Info("applied migration", "file", m.Filename)
+		done = append(done, m.Filename)
+	}
+	return done, nil
+}
+
+// Down rolls back the n most recently applied migrations (at least one),
+// newest first, each in its own transaction. It stops at the first one
+// without a down script and returns the filenames rolled back.
+func Down(ctx context.Context, conn Conn, n int) ([]string, error) {
+	if n <= 0 {
+		n = 1
+	}
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]Migration, len(migrations))
+	for _, m := range migrations {
+		byName[m.Filename] = m
+	}
+	names := make([]string, 0, len(applied))
+	for name := range applied {
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	var done []string
+	for _, name := range names {
+		if len(done) == n {
+			break
+		}
+		m, ok := byName[name]
+		if !ok {
+			return done, fmt.Errorf("migration %s is applied but not in this build", name)
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("rolling back %s: %w", name, ErrIrreversible)
+		}
+
+		err := runInTx(ctx, conn, m.Down, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE filename = $1`, name)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("rolling back migration %s: %w", name, err)
+		}
+		slog.Info("rolled back migration", "file", name)
+		done = append(done, name)
+	}
+	return done, nil
+}
+
+// Redo rolls back the latest migration and applies it again.
+func Redo(ctx context.Context, conn Conn) (string, error) {
+	done, err := Down(ctx, conn, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(done) == 0 {
+		return "", errors.New("no migrations applied")
+	}
+	if _, err := Up(ctx, conn, 1); err != nil {
+		return "", err
+	}
+	return done[0], nil
+}
+
+// RunMigrations applies every pending migration; the server runs it at startup.
+func RunMigrations(ctx context.Context, conn Conn) error {
+	_, err := Up(ctx, conn, 0)
+	return err
+}
+
+// runInTx executes script and then record in one transaction.
+func runInTx(ctx context.Context, conn Conn, script string, record func(pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadMigrations_PairsDownScripts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/002_b.sql":      {Data: []byte("ALTER TABLE t ADD COLUMN c INT;")},
+		"m/001_a.sql":      {Data: []byte("CREATE TABLE t (id INT);")},
+		"m/002_b.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN c;")},
+		"m/README.md":      {Data: []byte("not a migration")},
+	}
+
+	migrations, err := loadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Filename != "001_a.sql" || migrations[1].Filename != "002_b.sql" {
+		t.Fatalf("expected 001 and 002 in order, got %+v", migrations)
+	}
+	if migrations[0].Down != "" || migrations[1].Down != "ALTER TABLE t DROP COLUMN c;" {
+		t.Errorf("down scripts paired wrongly: %q, %q", migrations[0].Down, migrations[1].Down)
+	}
+	if len(migrations[0].Checksum) != 64 || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("expected distinct SHA-256 checksums, got %q and %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+}
+
+func TestLoadMigrations_RejectsOrphanDownScript(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/001_a.sql":      {Data: []byte("SELECT 1;")},
+		"m/003_c.down.sql": {Data: []byte("SELECT 1;")},
+	}
+	if _, err := loadMigrations(fsys, "m"); err == nil {
+		t.Fatal("expected an error for a down script without its migration")
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Filename != "001_initial_schema.sql" {
+		t.Fatalf("expected the embedded migrations starting at 001, got %d", len(migrations))
+	}
+}
+
+// Everything after the original five schema migrations can be rolled back.
+func TestLoadMigrations_EmbeddedDownScripts(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		if m.Filename >= "006" && m.Down == "" {
+			t.Errorf("%s has no down script", m.Filename)
+		}
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	migrations := []Migration{
+		{Filename: "001_a.sql", Checksum: "aaa"},
+		{Filename: "002_b.sql", Checksum: "bbb", Down: "DROP TABLE b;"},
+		{Filename: "003_c.sql", Checksum: "ccc"},
+		{Filename: "004_d.sql", Checksum: "ddd"},
+	}
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	applied := map[string]appliedMigration{
+		"001_a.sql":    {appliedAt: at, checksum: ""}, // from before checksums
+		"002_b.sql":    {appliedAt: at, checksum: "bbb"},
+		"003_c.sql":    {appliedAt: at, checksum: "old"},
+		"000_gone.sql": {appliedAt: at, checksum: "zzz"},
+	}
+
+	statuses := migrationStatus(migrations, applied)
+	if len(statuses) != 5 {
+		t.Fatalf("expected 5 statuses, got %+v", statuses)
+	}
+	byName := map[string]MigrationStatus{}
+	for _, s := range statuses {
+		byName[s.Filename] = s
+	}
+	if statuses[0].Filename != "000_gone.sql" || !byName["000_gone.sql"].Missing {
+		t.Errorf("expected the unknown migration first and marked missing, got %+v", statuses[0])
+	}
+	if s := byName["001_a.sql"]; !s.Applied || s.Drifted {
+		t.Errorf("a migration without a recorded checksum can't have drifted: %+v", s)
+	}
+	if s := byName["002_b.sql"]; !s.Applied || s.Drifted || !s.Reversible {
+		t.Errorf("unexpected status for 002: %+v", s)
+	}
+	if s := byName["003_c.sql"]; !s.Drifted {
+		t.Errorf("expected 003 drifted: %+v", s)
+	}
+	if s := byName["004_d.sql"]; s.Applied || s.AppliedAt != nil {
+		t.Errorf("expected 004 pending: %+v", s)
+	}
+}
@@ -0,0 +1,4 @@
+-- 006_bill_cost_sharing.down.sql
+
+ALTER TABLE bills DROP COLUMN IF EXISTS shared_percent;
+ALTER TABLE bills DROP COLUMN IF EXISTS shared_with;
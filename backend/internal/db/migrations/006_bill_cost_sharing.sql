@@ -0,0 +1,8 @@
+-- 006_bill_cost_sharing.sql
+-- Part of a bill can be reimbursed by someone outside the household
+-- (e.g. a roommate paying half the internet). Balances and the optimizer
+-- use the net amount; the remainder is reported as owed.
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS shared_with VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS shared_percent DECIMAL(5,2)
+    CHECK (shared_percent IS NULL OR (shared_percent >= 0 AND shared_percent <= 100));
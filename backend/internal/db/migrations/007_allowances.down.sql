@@ -0,0 +1,5 @@
+-- 007_allowances.down.sql
+
+DROP INDEX IF EXISTS idx_bills_type;
+ALTER TABLE bills DROP COLUMN IF EXISTS dependent;
+ALTER TABLE bills DROP COLUMN IF EXISTS bill_type;
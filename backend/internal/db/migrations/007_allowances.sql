@@ -0,0 +1,9 @@
+-- 007_allowances.sql
+-- Allowances for kids/dependents are tracked as a lightweight bill type so they
+-- flow through assignments like any other obligation but can be reported on
+-- separately, grouped by dependent.
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS bill_type VARCHAR(20) NOT NULL DEFAULT 'bill';
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS dependent VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_bills_type ON bills(bill_type);
@@ -0,0 +1,4 @@
+-- 008_tax_deductible.down.sql
+
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS tax_deductible;
+ALTER TABLE bills DROP COLUMN IF EXISTS tax_deductible;
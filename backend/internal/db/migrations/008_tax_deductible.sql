@@ -0,0 +1,6 @@
+-- 008_tax_deductible.sql
+-- Flag charitable giving and other deductible payments. Bills carry the flag
+-- for every occurrence; one-off extras carry it on the assignment itself.
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS tax_deductible BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS tax_deductible BOOLEAN NOT NULL DEFAULT FALSE;
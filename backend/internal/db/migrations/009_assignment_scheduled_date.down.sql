@@ -0,0 +1,3 @@
+-- 009_assignment_scheduled_date.down.sql
+
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS scheduled_date;
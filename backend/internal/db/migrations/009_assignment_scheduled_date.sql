@@ -0,0 +1,5 @@
+-- 009_assignment_scheduled_date.sql
+-- The day the user actually intends to pay an assignment, when it differs
+-- from the pay period's pay date (e.g. payday is the 10th, pay on the 12th).
+
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS scheduled_date DATE;
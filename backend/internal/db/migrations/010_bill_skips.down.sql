@@ -0,0 +1,3 @@
+-- 010_bill_skips.down.sql
+
+DROP TABLE IF EXISTS bill_skips;
@@ -0,0 +1,14 @@
+-- 010_bill_skips.sql
+-- Months in which a bill is intentionally not paid. Auto-assign never creates
+-- an assignment for a skipped bill+month, and reports can show the skip.
+
+CREATE TABLE IF NOT EXISTS bill_skips (
+    id         SERIAL PRIMARY KEY,
+    bill_id    INTEGER NOT NULL REFERENCES bills(id) ON DELETE CASCADE,
+    month      DATE NOT NULL, -- first day of the skipped month
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(bill_id, month)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bill_skips_month ON bill_skips(month);
@@ -0,0 +1,3 @@
+-- 011_bill_active_months.down.sql
+
+ALTER TABLE bills DROP COLUMN IF EXISTS active_months;
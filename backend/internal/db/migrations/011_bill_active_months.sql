@@ -0,0 +1,6 @@
+-- 011_bill_active_months.sql
+-- Seasonal bills (lawn care, snow removal, pool service) only occur in part of
+-- the year. active_months lists the calendar months (1-12) a bill is due in;
+-- NULL or an empty list means every month. AutoAssign skips the off-season.
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS active_months INTEGER[];
@@ -0,0 +1,3 @@
+-- 012_bill_monthly_amounts.down.sql
+
+ALTER TABLE bills DROP COLUMN IF EXISTS monthly_amounts;
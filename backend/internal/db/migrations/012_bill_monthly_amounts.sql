@@ -0,0 +1,6 @@
+-- 012_bill_monthly_amounts.sql
+-- Some bills vary predictably by month (budget-billed gas, summer electric).
+-- monthly_amounts maps a calendar month (1-12) to the amount expected that
+-- month; AutoAssign falls back to default_amount for months not listed.
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS monthly_amounts JSONB;
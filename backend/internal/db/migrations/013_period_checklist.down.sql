@@ -0,0 +1,3 @@
+-- 013_period_checklist.down.sql
+
+DROP TABLE IF EXISTS period_checklist_items;
@@ -0,0 +1,15 @@
+-- 013_period_checklist.sql
+-- Free-form payday tasks ("transfer $100 to savings", "call about water bill
+-- credit") attached to a pay period, since not every payday task is a bill.
+
+CREATE TABLE IF NOT EXISTS period_checklist_items (
+    id            SERIAL PRIMARY KEY,
+    pay_period_id INTEGER NOT NULL REFERENCES pay_periods(id) ON DELETE CASCADE,
+    label         TEXT NOT NULL,
+    is_done       BOOLEAN NOT NULL DEFAULT false,
+    sort_order    INTEGER NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_period_checklist_items_period ON period_checklist_items(pay_period_id);
@@ -0,0 +1,9 @@
+-- 014_assignment_due_date.down.sql
+-- Lossy: one assignment per bill and period is all the old schema allows, so
+-- later occurrences in a period are deleted, keeping the first created.
+
+DROP INDEX IF EXISTS idx_bill_assignments_occurrence;
+DELETE FROM bill_assignments a USING bill_assignments o
+    WHERE a.bill_id = o.bill_id AND a.pay_period_id = o.pay_period_id AND a.id > o.id;
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS due_date;
+ALTER TABLE bill_assignments ADD CONSTRAINT bill_assignments_bill_id_pay_period_id_key UNIQUE (bill_id, pay_period_id);
@@ -0,0 +1,15 @@
+-- 014_assignment_due_date.sql
+-- Store the occurrence due date an assignment was created for. Biweekly bills
+-- can fall due twice before the next paycheck; each occurrence now gets its own
+-- assignment in the period instead of being summed into one, so reporting by
+-- month follows the due date rather than whichever period paid it.
+--
+-- One assignment per bill+period becomes one per bill+period+due date. NULLS
+-- NOT DISTINCT keeps rows without a due date (manual entries, older rows)
+-- unique per bill+period as before.
+
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS due_date DATE;
+
+ALTER TABLE bill_assignments DROP CONSTRAINT IF EXISTS bill_assignments_bill_id_pay_period_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bill_assignments_occurrence
+    ON bill_assignments (bill_id, pay_period_id, due_date) NULLS NOT DISTINCT;
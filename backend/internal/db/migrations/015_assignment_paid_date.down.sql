@@ -0,0 +1,3 @@
+-- 015_assignment_paid_date.down.sql
+
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS paid_date;
@@ -0,0 +1,5 @@
+-- 015_assignment_paid_date.sql
+-- The day an assignment was actually paid, captured by quick-pay. Differs from
+-- scheduled_date, which is when the user planned to pay it.
+
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS paid_date DATE;
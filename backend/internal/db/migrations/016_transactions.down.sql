@@ -0,0 +1,3 @@
+-- 016_transactions.down.sql
+
+DROP TABLE IF EXISTS transactions;
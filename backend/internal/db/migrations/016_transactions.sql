@@ -0,0 +1,19 @@
+-- 016_transactions.sql
+-- Ledger of money actually spent, entered by hand or imported from bank
+-- statements. A transaction reconciled against a bill assignment points at it;
+-- deleting the assignment leaves the transaction unreconciled.
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id            SERIAL PRIMARY KEY,
+    txn_date      DATE NOT NULL,
+    amount        DECIMAL(10,2) NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    source        VARCHAR(20) NOT NULL DEFAULT 'manual', -- manual, csv
+    assignment_id INTEGER REFERENCES bill_assignments(id) ON DELETE SET NULL,
+    notes         TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_date ON transactions(txn_date);
+CREATE INDEX IF NOT EXISTS idx_transactions_assignment ON transactions(assignment_id);
@@ -0,0 +1,3 @@
+-- 017_category_budgets.down.sql
+
+DROP TABLE IF EXISTS category_budgets;
@@ -0,0 +1,12 @@
+-- 017_category_budgets.sql
+-- Monthly spending targets per bill category. alerted_month remembers the
+-- month an alert for crossing 90% of the target was last sent, so it is sent
+-- once per month.
+
+CREATE TABLE IF NOT EXISTS category_budgets (
+    category      VARCHAR(100) PRIMARY KEY,
+    monthly_limit DECIMAL(10,2) NOT NULL CHECK (monthly_limit > 0),
+    alerted_month DATE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- 018_category_corrections.down.sql
+
+DROP TABLE IF EXISTS category_corrections;
@@ -0,0 +1,10 @@
+-- 018_category_corrections.sql
+-- Categories the user assigned to bills by hand, keyed by normalized bill
+-- name. Imports and quick-adds consult these before the built-in keyword table.
+
+CREATE TABLE IF NOT EXISTS category_corrections (
+    name_key   VARCHAR(200) PRIMARY KEY,
+    category   VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,18 @@
+-- 019_categories.down.sql
+-- Rebuilds category_budgets from the categories that have a limit. Categories
+-- without one are dropped; bills keep their category names.
+
+CREATE TABLE IF NOT EXISTS category_budgets (
+    category      VARCHAR(100) PRIMARY KEY,
+    monthly_limit DECIMAL(10,2) NOT NULL CHECK (monthly_limit > 0),
+    alerted_month DATE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO category_budgets (category, monthly_limit, alerted_month, created_at, updated_at)
+SELECT name, monthly_limit, alerted_month, created_at, updated_at FROM categories
+WHERE monthly_limit IS NOT NULL
+ON CONFLICT (category) DO NOTHING;
+
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,23 @@
+-- 019_categories.sql
+-- Managed bill categories with optional monthly limits. Bills still store the
+-- category name as text. Replaces category_budgets, whose targets become the
+-- limits of the matching categories.
+
+CREATE TABLE IF NOT EXISTS categories (
+    id            SERIAL PRIMARY KEY,
+    name          VARCHAR(100) NOT NULL UNIQUE,
+    monthly_limit DECIMAL(10,2) CHECK (monthly_limit IS NULL OR monthly_limit > 0),
+    alerted_month DATE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO categories (name, monthly_limit, alerted_month, created_at, updated_at)
+SELECT category, monthly_limit, alerted_month, created_at, updated_at FROM category_budgets
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO categories (name)
+SELECT DISTINCT category FROM bills WHERE category IS NOT NULL AND category <> ''
+ON CONFLICT (name) DO NOTHING;
+
+DROP TABLE IF EXISTS category_budgets;
@@ -0,0 +1,4 @@
+-- 020_credit_card_promos.down.sql
+
+DROP TABLE IF EXISTS credit_card_promos;
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS apr;
@@ -0,0 +1,17 @@
+-- 020_credit_card_promos.sql
+-- Promotional APR windows on credit cards (e.g. 0% on a balance transfer until
+-- a date), and the card's standard APR that applies once a promo expires.
+
+ALTER TABLE credit_cards ADD COLUMN IF NOT EXISTS apr DECIMAL(6,3);
+
+CREATE TABLE IF NOT EXISTS credit_card_promos (
+    id             SERIAL PRIMARY KEY,
+    credit_card_id INTEGER NOT NULL REFERENCES credit_cards(id) ON DELETE CASCADE,
+    rate           DECIMAL(6,3) NOT NULL DEFAULT 0 CHECK (rate >= 0),
+    balance        DECIMAL(10,2) CHECK (balance > 0), -- NULL covers the whole card balance
+    expires_on     DATE NOT NULL,
+    description    TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_card_promos_card ON credit_card_promos(credit_card_id);
@@ -0,0 +1,3 @@
+-- 021_notification_preferences.down.sql
+
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,16 @@
+-- 021_notification_preferences.sql
+-- Per-user email reminder settings. The reminder scheduler emails each enabled
+-- user a digest of upcoming and overdue bills once a day, at send_hour.
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id              SERIAL PRIMARY KEY,
+    username        VARCHAR(255) NOT NULL UNIQUE, -- "default" when auth is disabled
+    email           VARCHAR(255) NOT NULL DEFAULT '',
+    enabled         BOOLEAN NOT NULL DEFAULT FALSE,
+    days_ahead      INTEGER NOT NULL DEFAULT 3 CHECK (days_ahead BETWEEN 1 AND 31),
+    include_overdue BOOLEAN NOT NULL DEFAULT TRUE,
+    send_hour       INTEGER NOT NULL DEFAULT 8 CHECK (send_hour BETWEEN 0 AND 23), -- server local time
+    last_sent_on    DATE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- 023_income_source_archive.down.sql
+
+ALTER TABLE income_sources DROP COLUMN IF EXISTS archived_totals;
@@ -0,0 +1,5 @@
+-- 023_income_source_archive.sql
+-- Deleting an income source removes its pay periods, so their lifetime
+-- totals are snapshotted here first for the source's summary.
+
+ALTER TABLE income_sources ADD COLUMN IF NOT EXISTS archived_totals JSONB;
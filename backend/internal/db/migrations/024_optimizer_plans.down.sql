@@ -0,0 +1,3 @@
+-- 024_optimizer_plans.down.sql
+
+DROP TABLE IF EXISTS optimizer_plans;
@@ -0,0 +1,10 @@
+-- 024_optimizer_plans.sql
+-- Suggestion sets returned by the optimizer, so a plan can be applied later
+-- by id exactly as it was shown.
+
+CREATE TABLE IF NOT EXISTS optimizer_plans (
+    id          SERIAL PRIMARY KEY,
+    suggestions JSONB NOT NULL,
+    applied_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+-- 025_credit_card_accounts.down.sql
+-- Fails while a card account has no bill: delete or link those cards first
+-- rather than have the rollback drop them.
+
+ALTER TABLE credit_cards DROP COLUMN IF EXISTS credit_limit;
+ALTER TABLE credit_cards ALTER COLUMN bill_id SET NOT NULL;
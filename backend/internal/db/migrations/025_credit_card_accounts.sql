@@ -0,0 +1,6 @@
+-- Credit cards become records of their own: a card can exist before it is
+-- linked to the bill that pays it, or after being unlinked from one, and
+-- records its credit limit.
+
+ALTER TABLE credit_cards ALTER COLUMN bill_id DROP NOT NULL;
+ALTER TABLE credit_cards ADD COLUMN IF NOT EXISTS credit_limit DECIMAL(10,2) CHECK (credit_limit >= 0);
@@ -0,0 +1,3 @@
+-- 026_income_events.down.sql
+
+DROP TABLE IF EXISTS income_events;
@@ -0,0 +1,19 @@
+-- 026_income_events.sql
+-- One-off inflows such as a bonus or a tax refund, on a known date. Unlike
+-- income sources they have no schedule. Generating pay periods attaches each
+-- event to the paycheck it lands after, and the forecast adds it on its date.
+
+CREATE TABLE IF NOT EXISTS income_events (
+    id               SERIAL PRIMARY KEY,
+    name             VARCHAR(255) NOT NULL,
+    amount           DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    event_date       DATE NOT NULL,
+    income_source_id INTEGER REFERENCES income_sources(id) ON DELETE SET NULL, -- only that source's paychecks receive it
+    pay_period_id    INTEGER REFERENCES pay_periods(id) ON DELETE SET NULL,
+    notes            TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_income_events_date ON income_events(event_date);
+CREATE INDEX IF NOT EXISTS idx_income_events_period ON income_events(pay_period_id);
@@ -0,0 +1,3 @@
+-- 027_payroll_calendars.down.sql
+
+DROP TABLE IF EXISTS payroll_calendar_dates;
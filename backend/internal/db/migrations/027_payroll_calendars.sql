@@ -0,0 +1,12 @@
+-- 027_payroll_calendars.sql
+-- An employer's official pay dates for a year. When a source has any for a
+-- year, generating pay periods uses them for that year instead of the
+-- schedule math, which drifts from real payroll around holidays.
+
+CREATE TABLE IF NOT EXISTS payroll_calendar_dates (
+    id               SERIAL PRIMARY KEY,
+    income_source_id INTEGER NOT NULL REFERENCES income_sources(id) ON DELETE CASCADE,
+    pay_date         DATE NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (income_source_id, pay_date)
+);
@@ -0,0 +1,6 @@
+-- 028_assignment_currency.down.sql
+
+ALTER TABLE bill_assignments DROP CONSTRAINT IF EXISTS bill_assignments_original_pair;
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS fx_note;
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS original_amount;
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS original_currency;
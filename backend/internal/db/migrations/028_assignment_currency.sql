@@ -0,0 +1,13 @@
+-- 028_assignment_currency.sql
+-- The currency of record for an occasional foreign bill: what was actually
+-- billed, alongside the converted planned amount the budget works in. Keeping
+-- the original lets the planned amount be repriced when rates move.
+
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS original_currency VARCHAR(3)
+    CHECK (original_currency ~ '^[A-Z]{3}$');
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS original_amount DECIMAL(12,2);
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS fx_note TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE bill_assignments DROP CONSTRAINT IF EXISTS bill_assignments_original_pair;
+ALTER TABLE bill_assignments ADD CONSTRAINT bill_assignments_original_pair
+    CHECK ((original_currency IS NULL) = (original_amount IS NULL));
@@ -0,0 +1,4 @@
+-- 029_member_ownership.down.sql
+
+ALTER TABLE bills DROP COLUMN IF EXISTS owner;
+ALTER TABLE income_sources DROP COLUMN IF EXISTS owner;
@@ -0,0 +1,7 @@
+-- 029_member_ownership.sql
+-- The household member an income source or bill belongs to: whose paycheck
+-- it is, or who pays the bill. Empty means shared by the household. It lets
+-- one member's data be exported on its own when a household splits up.
+
+ALTER TABLE income_sources ADD COLUMN IF NOT EXISTS owner VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS owner VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- 030_users.down.sql
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,13 @@
+-- 030_users.sql
+-- Accounts besides the administrator configured by AUTH_USERNAME, each with
+-- a role: viewers only read, editors also manage bills, income and
+-- assignments, and admins also run imports, backups, migrations and users.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            SERIAL PRIMARY KEY,
+    username      VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role          VARCHAR(20) NOT NULL DEFAULT 'viewer' CHECK (role IN ('viewer', 'editor', 'admin')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+-- 031_assignment_paid_by.down.sql
+
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS paid_from;
+ALTER TABLE bill_assignments DROP COLUMN IF EXISTS paid_by;
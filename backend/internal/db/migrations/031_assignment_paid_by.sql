@@ -0,0 +1,7 @@
+-- 031_assignment_paid_by.sql
+-- Who in the household actually paid an assignment, and from which account,
+-- for couples splitting the bills. Both are free text; empty means not
+-- recorded.
+
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS paid_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE bill_assignments ADD COLUMN IF NOT EXISTS paid_from VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+-- 032_refresh_tokens.sql
+-- Long-lived refresh tokens, stored as SHA-256 hashes. Each login starts one;
+-- /auth/refresh swaps it for a new one and a short-lived access token. An
+-- access token names the refresh token it came from, so revoking the refresh
+-- token ends the session at once.
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          SERIAL PRIMARY KEY,
+    token_hash  CHAR(64) NOT NULL UNIQUE,
+    username    VARCHAR(255) NOT NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    revoked_at  TIMESTAMPTZ,
+    replaced_by INTEGER REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_username ON refresh_tokens (username) WHERE revoked_at IS NULL;
@@ -0,0 +1,3 @@
+-- 033_optimizer_plan_result.down.sql
+
+ALTER TABLE optimizer_plans DROP COLUMN IF EXISTS result;
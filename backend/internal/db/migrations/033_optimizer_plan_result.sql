@@ -0,0 +1,6 @@
+-- 033_optimizer_plan_result.sql
+-- The whole optimizer result behind a saved plan, so it can be exported
+-- later with its before/after balances. Plans saved earlier have only their
+-- suggestions.
+
+ALTER TABLE optimizer_plans ADD COLUMN IF NOT EXISTS result JSONB;
@@ -0,0 +1,5 @@
+-- 034_bill_vendor_login.down.sql
+
+ALTER TABLE bills DROP COLUMN IF EXISTS password_rotated_at;
+ALTER TABLE bills DROP COLUMN IF EXISTS username_hint;
+ALTER TABLE bills DROP COLUMN IF EXISTS portal_url;
@@ -0,0 +1,8 @@
+-- 034_bill_vendor_login.sql
+-- Where and how to log in to pay a bill by hand: the vendor's portal, a hint
+-- at the username, and when the password was last changed. Never the
+-- password itself.
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS portal_url VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS username_hint VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS password_rotated_at DATE;
@@ -0,0 +1,8 @@
+-- 035_audit_log.down.sql
+
+DROP TRIGGER IF EXISTS audit_income_sources ON income_sources;
+DROP TRIGGER IF EXISTS audit_pay_periods ON pay_periods;
+DROP TRIGGER IF EXISTS audit_bill_assignments ON bill_assignments;
+DROP TRIGGER IF EXISTS audit_bills ON bills;
+DROP FUNCTION IF EXISTS audit_row_change();
+DROP TABLE IF EXISTS audit_log;
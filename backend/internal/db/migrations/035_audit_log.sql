@@ -0,0 +1,72 @@
+-- 035_audit_log.sql
+-- Who changed what: every insert, update and delete on bills, assignments,
+-- pay periods and income sources, written by triggers so changes made by
+-- auto-assign, generation, imports and the optimizer are caught too. The
+-- actor is the session setting budget.actor, which the server sets to the
+-- signed-in user on each connection it hands out. Updates keep only the
+-- columns that changed; updated_at alone is not a change.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    entity     VARCHAR(30) NOT NULL, -- bill, assignment, period, income_source
+    entity_id  INTEGER NOT NULL,
+    action     VARCHAR(10) NOT NULL, -- create, update, delete
+    actor      VARCHAR(100),         -- NULL for background jobs and with auth disabled
+    before     JSONB,                -- NULL on create
+    after      JSONB,                -- NULL on delete
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+
+CREATE OR REPLACE FUNCTION audit_row_change() RETURNS trigger AS $$
+DECLARE
+    acting_user    VARCHAR(100) := NULLIF(current_setting('budget.actor', true), '');
+    old_row        JSONB;
+    new_row        JSONB;
+    changed_before JSONB := '{}';
+    changed_after  JSONB := '{}';
+    k              TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, after)
+        VALUES (TG_ARGV[0], NEW.id, 'create', acting_user, to_jsonb(NEW));
+        RETURN NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, before)
+        VALUES (TG_ARGV[0], OLD.id, 'delete', acting_user, to_jsonb(OLD));
+        RETURN OLD;
+    END IF;
+
+    old_row := to_jsonb(OLD) - 'updated_at';
+    new_row := to_jsonb(NEW) - 'updated_at';
+    FOR k IN SELECT jsonb_object_keys(new_row) LOOP
+        IF old_row -> k IS DISTINCT FROM new_row -> k THEN
+            changed_before := changed_before || jsonb_build_object(k, old_row -> k);
+            changed_after := changed_after || jsonb_build_object(k, new_row -> k);
+        END IF;
+    END LOOP;
+    IF changed_after <> '{}'::jsonb THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, before, after)
+        VALUES (TG_ARGV[0], NEW.id, 'update', acting_user, changed_before, changed_after);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_bills ON bills;
+CREATE TRIGGER audit_bills AFTER INSERT OR UPDATE OR DELETE ON bills
+    FOR EACH ROW EXECUTE FUNCTION audit_row_change('bill');
+
+DROP TRIGGER IF EXISTS audit_bill_assignments ON bill_assignments;
+CREATE TRIGGER audit_bill_assignments AFTER INSERT OR UPDATE OR DELETE ON bill_assignments
+    FOR EACH ROW EXECUTE FUNCTION audit_row_change('assignment');
+
+DROP TRIGGER IF EXISTS audit_pay_periods ON pay_periods;
+CREATE TRIGGER audit_pay_periods AFTER INSERT OR UPDATE OR DELETE ON pay_periods
+    FOR EACH ROW EXECUTE FUNCTION audit_row_change('period');
+
+DROP TRIGGER IF EXISTS audit_income_sources ON income_sources;
+CREATE TRIGGER audit_income_sources AFTER INSERT OR UPDATE OR DELETE ON income_sources
+    FOR EACH ROW EXECUTE FUNCTION audit_row_change('income_source');
@@ -0,0 +1,43 @@
+-- 036_audit_undo.down.sql
+
+CREATE OR REPLACE FUNCTION audit_row_change() RETURNS trigger AS $$
+DECLARE
+    acting_user    VARCHAR(100) := NULLIF(current_setting('budget.actor', true), '');
+    old_row        JSONB;
+    new_row        JSONB;
+    changed_before JSONB := '{}';
+    changed_after  JSONB := '{}';
+    k              TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, after)
+        VALUES (TG_ARGV[0], NEW.id, 'create', acting_user, to_jsonb(NEW));
+        RETURN NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, before)
+        VALUES (TG_ARGV[0], OLD.id, 'delete', acting_user, to_jsonb(OLD));
+        RETURN OLD;
+    END IF;
+
+    old_row := to_jsonb(OLD) - 'updated_at';
+    new_row := to_jsonb(NEW) - 'updated_at';
+    FOR k IN SELECT jsonb_object_keys(new_row) LOOP
+        IF old_row -> k IS DISTINCT FROM new_row -> k THEN
+            changed_before := changed_before || jsonb_build_object(k, old_row -> k);
+            changed_after := changed_after || jsonb_build_object(k, new_row -> k);
+        END IF;
+    END LOOP;
+    IF changed_after <> '{}'::jsonb THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, before, after)
+        VALUES (TG_ARGV[0], NEW.id, 'update', acting_user, changed_before, changed_after);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_audit_log_txid;
+DROP INDEX IF EXISTS idx_audit_log_request;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS undone_at;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS origin;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS txid;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS request_id;
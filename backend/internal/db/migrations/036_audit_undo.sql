@@ -0,0 +1,51 @@
+-- 036_audit_undo.sql
+-- Groups audit entries by the request that made them, so everything one
+-- auto-assign or optimizer apply touched can be undone together. request_id
+-- comes from the budget.request_id session setting, like budget.actor;
+-- changes made outside a request fall back to grouping by transaction.
+-- origin marks the entries an undo or redo wrote, and undone_at the entries
+-- that have been reverted.
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS request_id VARCHAR(100);
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS txid BIGINT;
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS origin VARCHAR(10) NOT NULL DEFAULT 'change'; -- change, undo, redo
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS undone_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_request ON audit_log(request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_txid ON audit_log(txid);
+
+CREATE OR REPLACE FUNCTION audit_row_change() RETURNS trigger AS $$
+DECLARE
+    acting_user    VARCHAR(100) := NULLIF(current_setting('budget.actor', true), '');
+    req_id         VARCHAR(100) := NULLIF(current_setting('budget.request_id', true), '');
+    old_row        JSONB;
+    new_row        JSONB;
+    changed_before JSONB := '{}';
+    changed_after  JSONB := '{}';
+    k              TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, after, request_id, txid)
+        VALUES (TG_ARGV[0], NEW.id, 'create', acting_user, to_jsonb(NEW), req_id, txid_current());
+        RETURN NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, before, request_id, txid)
+        VALUES (TG_ARGV[0], OLD.id, 'delete', acting_user, to_jsonb(OLD), req_id, txid_current());
+        RETURN OLD;
+    END IF;
+
+    old_row := to_jsonb(OLD) - 'updated_at';
+    new_row := to_jsonb(NEW) - 'updated_at';
+    FOR k IN SELECT jsonb_object_keys(new_row) LOOP
+        IF old_row -> k IS DISTINCT FROM new_row -> k THEN
+            changed_before := changed_before || jsonb_build_object(k, old_row -> k);
+            changed_after := changed_after || jsonb_build_object(k, new_row -> k);
+        END IF;
+    END LOOP;
+    IF changed_after <> '{}'::jsonb THEN
+        INSERT INTO audit_log (entity, entity_id, action, actor, before, after, request_id, txid)
+        VALUES (TG_ARGV[0], NEW.id, 'update', acting_user, changed_before, changed_after, req_id, txid_current());
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
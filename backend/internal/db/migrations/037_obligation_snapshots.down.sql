@@ -0,0 +1,3 @@
+-- 037_obligation_snapshots.down.sql
+
+DROP TABLE IF EXISTS obligation_snapshots;
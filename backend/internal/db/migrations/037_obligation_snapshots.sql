@@ -0,0 +1,13 @@
+-- 037_obligation_snapshots.sql
+-- The total of active bills per month, recorded once a day so a rise in fixed
+-- costs can be measured over time. alerted marks the snapshot an alert was
+-- sent for; later rises are measured from it.
+
+CREATE TABLE IF NOT EXISTS obligation_snapshots (
+    snapshot_date DATE PRIMARY KEY,
+    total         DECIMAL(12,2) NOT NULL,
+    bill_count    INTEGER NOT NULL,
+    alerted       BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
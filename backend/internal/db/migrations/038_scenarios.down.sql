@@ -0,0 +1,12 @@
+-- 038_scenarios.down.sql
+
+DO $$
+DECLARE
+    s RECORD;
+BEGIN
+    FOR s IN SELECT id FROM scenarios LOOP
+        EXECUTE format('DROP SCHEMA IF EXISTS %I CASCADE', 'scenario_' || s.id);
+    END LOOP;
+END $$;
+
+DROP TABLE IF EXISTS scenarios;
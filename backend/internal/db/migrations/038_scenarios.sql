@@ -0,0 +1,18 @@
+-- 038_scenarios.sql
+-- What-if scenarios. Each open scenario has a schema, scenario_<id>, holding
+-- its own copies of bills, pay_periods and bill_assignments, plus empty
+-- optimizer_plans and category_corrections, made with LIKE so ids still come
+-- from the live sequences.
+-- base_audit_id is the newest audit_log entry when it was cloned, so a
+-- promote can tell whether the live budget has changed since.
+
+CREATE TABLE IF NOT EXISTS scenarios (
+    id            SERIAL PRIMARY KEY,
+    name          VARCHAR(255) NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    status        VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'promoted')),
+    base_audit_id BIGINT NOT NULL DEFAULT 0,
+    created_by    VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    promoted_at   TIMESTAMPTZ
+);
@@ -0,0 +1,4 @@
+-- 039_assignment_search.down.sql
+
+DROP INDEX IF EXISTS idx_assignments_paid_date;
+DROP INDEX IF EXISTS idx_assignments_amount;
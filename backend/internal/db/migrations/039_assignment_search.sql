@@ -0,0 +1,7 @@
+-- 039_assignment_search.sql
+-- Indexes for finding an assignment by amount and paid date ("what was that
+-- ~$84 charge in March"). The amount index is on the same expression the
+-- assignment list filters by, the actual amount falling back to the planned.
+
+CREATE INDEX IF NOT EXISTS idx_assignments_amount ON bill_assignments ((COALESCE(actual_amount, planned_amount)));
+CREATE INDEX IF NOT EXISTS idx_assignments_paid_date ON bill_assignments (paid_date) WHERE paid_date IS NOT NULL;
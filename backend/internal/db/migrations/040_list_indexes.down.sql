@@ -0,0 +1,6 @@
+-- 040_list_indexes.down.sql
+
+DROP INDEX IF EXISTS idx_bills_active_sort;
+DROP INDEX IF EXISTS idx_bills_sort;
+CREATE INDEX IF NOT EXISTS idx_assignments_period ON bill_assignments(pay_period_id);
+DROP INDEX IF EXISTS idx_assignments_period_status;
@@ -0,0 +1,15 @@
+-- 040_list_indexes.sql
+-- Indexes for the filters the list endpoints run on every page load, which
+-- fell back to sequential scans on large budgets. Periods by source and pay
+-- date range are already served by the UNIQUE (income_source_id, pay_date)
+-- index from 001.
+
+-- Assignments of a period, optionally by status (the grid, sweeps, the
+-- period detail). It leads with pay_period_id, so it replaces the
+-- single-column index.
+CREATE INDEX IF NOT EXISTS idx_assignments_period_status ON bill_assignments(pay_period_id, status);
+DROP INDEX IF EXISTS idx_assignments_period;
+
+-- Bills in their default list order, and the active ones alone
+CREATE INDEX IF NOT EXISTS idx_bills_sort ON bills(sort_order, id);
+CREATE INDEX IF NOT EXISTS idx_bills_active_sort ON bills(sort_order, id) WHERE is_active = true;
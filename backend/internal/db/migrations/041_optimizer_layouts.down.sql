@@ -0,0 +1,3 @@
+-- 041_optimizer_layouts.down.sql
+
+DROP TABLE IF EXISTS optimizer_layouts;
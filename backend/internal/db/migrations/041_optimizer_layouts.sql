@@ -0,0 +1,14 @@
+-- 041_optimizer_layouts.sql
+-- Where each assignment sat when an optimizer plan was last applied to its
+-- month, so later runs start from that layout instead of from scratch.
+-- Applying a plan replaces the layout of every month it touched.
+
+CREATE TABLE IF NOT EXISTS optimizer_layouts (
+    assignment_id INTEGER PRIMARY KEY REFERENCES bill_assignments(id) ON DELETE CASCADE,
+    pay_period_id INTEGER NOT NULL REFERENCES pay_periods(id) ON DELETE CASCADE,
+    month         DATE NOT NULL, -- first of the pay period's month
+    plan_id       INTEGER REFERENCES optimizer_plans(id) ON DELETE SET NULL, -- NULL for moves applied directly
+    accepted_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_optimizer_layouts_month ON optimizer_layouts(month);
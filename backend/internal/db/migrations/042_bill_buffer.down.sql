@@ -0,0 +1,3 @@
+-- 042_bill_buffer.down.sql
+
+ALTER TABLE bills DROP COLUMN IF EXISTS buffer_percent;
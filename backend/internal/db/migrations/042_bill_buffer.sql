@@ -0,0 +1,6 @@
+-- 042_bill_buffer.sql
+-- Headroom added on top of a variable bill's planned amount when it is
+-- auto-assigned, e.g. 15 to plan the electric bill 15% high.
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS buffer_percent NUMERIC(5,2) NOT NULL DEFAULT 0
+    CHECK (buffer_percent >= 0 AND buffer_percent <= 100);
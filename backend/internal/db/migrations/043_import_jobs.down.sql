@@ -0,0 +1,3 @@
+-- 043_import_jobs.down.sql
+
+DROP TABLE IF EXISTS import_jobs;
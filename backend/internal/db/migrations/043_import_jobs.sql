@@ -0,0 +1,23 @@
+-- 043_import_jobs.sql
+-- XLSX uploads are parsed in the background. Each upload is a job that a
+-- worker on any replica claims, reporting progress as it reads the workbook
+-- and leaving the preview to confirm when it is done.
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id          SERIAL PRIMARY KEY,
+    filename    VARCHAR(255) NOT NULL,
+    file        TEXT NOT NULL, -- upload store key of the workbook
+    status      VARCHAR(20) NOT NULL DEFAULT 'queued'
+                CHECK (status IN ('queued', 'parsing', 'done', 'failed')),
+    progress    INTEGER NOT NULL DEFAULT 0 CHECK (progress >= 0 AND progress <= 100),
+    attempts    INTEGER NOT NULL DEFAULT 0,
+    warnings    JSONB NOT NULL DEFAULT '[]',
+    preview     JSONB,
+    error       TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at  TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_pending
+    ON import_jobs (id) WHERE status IN ('queued', 'parsing');
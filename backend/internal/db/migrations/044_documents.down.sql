@@ -0,0 +1,4 @@
+-- 044_documents.down.sql
+
+DROP TABLE IF EXISTS document_bills;
+DROP TABLE IF EXISTS documents;
@@ -0,0 +1,31 @@
+-- 044_documents.sql
+-- A small vault for the household paperwork behind the bills: insurance
+-- policies, the lease, loan agreements. Files are kept in the database so
+-- they stay with the instance, can be tagged to the bills they cover, and
+-- are listed in reminder emails as their expiry date nears.
+
+CREATE TABLE IF NOT EXISTS documents (
+    id           SERIAL PRIMARY KEY,
+    title        VARCHAR(255) NOT NULL,
+    kind         VARCHAR(20) NOT NULL DEFAULT 'other',
+    filename     VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT 'application/octet-stream',
+    size_bytes   INTEGER NOT NULL,
+    content      BYTEA NOT NULL,
+    expires_on   DATE,
+    remind_days  INTEGER NOT NULL DEFAULT 30 CHECK (remind_days >= 0 AND remind_days <= 365),
+    notes        TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_documents_expires_on
+    ON documents (expires_on) WHERE expires_on IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS document_bills (
+    document_id INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    bill_id     INTEGER NOT NULL REFERENCES bills(id) ON DELETE CASCADE,
+    PRIMARY KEY (document_id, bill_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_bills_bill ON document_bills (bill_id);
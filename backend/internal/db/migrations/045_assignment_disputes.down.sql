@@ -0,0 +1,3 @@
+-- 045_assignment_disputes.down.sql
+
+DROP TABLE IF EXISTS assignment_disputes;
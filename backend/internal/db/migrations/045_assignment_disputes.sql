@@ -0,0 +1,20 @@
+-- 045_assignment_disputes.sql
+-- A bill being disputed with the company that sent it. While a dispute is
+-- open its assignment is left out of overdue and due-soon alerts; resolving
+-- it records the credit that actually came back.
+
+CREATE TABLE IF NOT EXISTS assignment_disputes (
+    id              SERIAL PRIMARY KEY,
+    assignment_id   INTEGER NOT NULL UNIQUE REFERENCES bill_assignments(id) ON DELETE CASCADE,
+    opened_on       DATE NOT NULL,
+    reference       VARCHAR(100) NOT NULL DEFAULT '', -- the company's case or ticket number
+    expected_credit NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (expected_credit >= 0),
+    resolved_on     DATE,
+    credit_received NUMERIC(10,2) CHECK (credit_received >= 0),
+    notes           TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_disputes_open
+    ON assignment_disputes (assignment_id) WHERE resolved_on IS NULL;
@@ -0,0 +1,14 @@
+-- 046_api_keys.sql
+-- API keys for scripts and dashboards. Only the key's SHA-256 hash is kept;
+-- scopes name the route groups it opens, and revoking it takes effect on the
+-- next request.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           SERIAL PRIMARY KEY,
+    name         VARCHAR(100) NOT NULL,
+    key_hash     CHAR(64) NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL,
+    created_by   TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at   TIMESTAMPTZ
+);
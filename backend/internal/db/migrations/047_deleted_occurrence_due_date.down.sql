@@ -0,0 +1,7 @@
+-- 047_deleted_occurrence_due_date.down.sql
+
+DROP INDEX IF EXISTS idx_deleted_bill_periods_occurrence;
+DELETE FROM deleted_bill_periods d USING deleted_bill_periods o
+    WHERE d.bill_id = o.bill_id AND d.pay_period_id = o.pay_period_id AND d.id > o.id;
+ALTER TABLE deleted_bill_periods DROP COLUMN IF EXISTS due_date;
+ALTER TABLE deleted_bill_periods ADD CONSTRAINT deleted_bill_periods_bill_id_pay_period_id_key UNIQUE (bill_id, pay_period_id);
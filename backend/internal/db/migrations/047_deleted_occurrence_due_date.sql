@@ -0,0 +1,11 @@
+-- 047_deleted_occurrence_due_date.sql
+-- Record which occurrence a deleted assignment was for. A biweekly bill can
+-- fall due twice in one period; deleting one of those assignments should not
+-- stop auto-assign from creating the other. Rows recorded before this have no
+-- due date and keep blocking the whole bill+period.
+
+ALTER TABLE deleted_bill_periods ADD COLUMN IF NOT EXISTS due_date DATE;
+
+ALTER TABLE deleted_bill_periods DROP CONSTRAINT IF EXISTS deleted_bill_periods_bill_id_pay_period_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deleted_bill_periods_occurrence
+    ON deleted_bill_periods (bill_id, pay_period_id, due_date) NULLS NOT DISTINCT;
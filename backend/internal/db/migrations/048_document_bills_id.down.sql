@@ -0,0 +1,3 @@
+-- 048_document_bills_id.down.sql
+
+ALTER TABLE document_bills DROP COLUMN IF EXISTS id;
@@ -0,0 +1,13 @@
+-- 048_document_bills_id.sql
+-- Give document bill tags an id so backups can carry them like every other
+-- table, and restores can map them to the documents and bills they join.
+--
+-- Document files stay in documents.content rather than the upload store.
+-- That store holds pending imports: it may be memory or a temp directory and
+-- is swept of anything old, while a lease or policy has to last as long as
+-- the budget does. In the database a document is deleted, restored and
+-- backed up along with its bill tags in one transaction, and a backup is the
+-- whole vault. Files are capped at 10MB to keep that affordable.
+
+ALTER TABLE document_bills ADD COLUMN IF NOT EXISTS id SERIAL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_document_bills_id ON document_bills (id);
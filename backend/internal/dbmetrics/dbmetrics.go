@@ -0,0 +1,277 @@
+// Package dbmetrics instruments database access: it wraps the pool handlers
+// query through, records query durations per route in histograms served in
+// the Prometheus text format, and logs queries slower than a threshold.
+package dbmetrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Buckets are the histogram upper bounds, in seconds.
+var Buckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// maxLoggedSQL truncates statements in the slow query log.
+const maxLoggedSQL = 500
+
+type series struct {
+	counts []uint64 // per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+	errors uint64
+}
+
+type seriesKey struct {
+	route string // METHOD /pattern, or "background" outside a request
+	op    string // query, query_row, exec, begin
+}
+
+// Recorder collects query durations and logs slow queries.
+type Recorder struct {
+	slow time.Duration // 0 disables the slow query log
+
+	mu     sync.Mutex
+	series map[seriesKey]*series
+}
+
+// NewRecorder returns a Recorder that logs queries taking at least slow.
+func NewRecorder(slow time.Duration) *Recorder {
+	return &Recorder{slow: slow, series: make(map[seriesKey]*series)}
+}
+
+// routeOf names the chi route a query runs under, e.g. "GET /api/v1/bills/{id}".
+func routeOf(ctx context.Context) string {
+	rctx := chi.RouteContext(ctx)
+	if rctx == nil {
+		return "background"
+	}
+	pattern := rctx.RoutePattern()
+	if pattern == "" {
+		pattern = "unmatched"
+	}
+	return rctx.RouteMethod + " " + pattern
+}
+
+func (rec *Recorder) observe(ctx context.Context, op, sql string, d time.Duration, err error) {
+	key := seriesKey{route: routeOf(ctx), op: op}
+
+	rec.mu.Lock()
+	s, ok := rec.series[key]
+	if !ok {
+		s = &series{counts: make([]uint64, len(Buckets)+1)}
+		rec.series[key] = s
+	}
+	secs := d.Seconds()
+	i := sort.SearchFloat64s(Buckets, secs)
+	s.counts[i]++
+	s.sum += secs
+	s.count++
+	if err != nil && err != pgx.ErrNoRows {
+		s.errors++
+	}
+	rec.mu.Unlock()
+
+	if rec.slow > 0 && d >= rec.slow {
+		slog.Warn("slow query",
+			"duration_ms", d.Milliseconds(),
+			"route", key.route,
+			"op", op,
+			"request_id", middleware.GetReqID(ctx),
+			"sql", compactSQL(sql),
+		)
+	}
+}
+
+// compactSQL collapses whitespace so a statement logs on one line.
+func compactSQL(sql string) string {
+	s := strings.Join(strings.Fields(sql), " ")
+	if len(s) > maxLoggedSQL {
+		s = s[:maxLoggedSQL] + "..."
+	}
+	return s
+}
+
+// WriteTo writes the collected metrics in the Prometheus text format.
+func (rec *Recorder) WriteTo(w io.Writer) (int64, error) {
+	rec.mu.Lock()
+	keys := make([]seriesKey, 0, len(rec.series))
+	for k := range rec.series {
+		keys = append(keys, k)
+	}
+	snapshot := make(map[seriesKey]series, len(keys))
+	for _, k := range keys {
+		s := *rec.series[k]
+		s.counts = append([]uint64(nil), s.counts...)
+		snapshot[k] = s
+	}
+	rec.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].op < keys[j].op
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP db_query_duration_seconds Database query duration by route and operation.\n")
+	b.WriteString("# TYPE db_query_duration_seconds histogram\n")
+	for _, k := range keys {
+		s := snapshot[k]
+		labels := fmt.Sprintf(`route=%q,op=%q`, k.route, k.op)
+		var cumulative uint64
+		for i, le := range Buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(&b, "db_query_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, le, cumulative)
+		}
+		fmt.Fprintf(&b, "db_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, s.count)
+		fmt.Fprintf(&b, "db_query_duration_seconds_sum{%s} %g\n", labels, s.sum)
+		fmt.Fprintf(&b, "db_query_duration_seconds_count{%s} %d\n", labels, s.count)
+	}
+	b.WriteString("# HELP db_query_errors_total Failed database queries by route and operation.\n")
+	b.WriteString("# TYPE db_query_errors_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "db_query_errors_total{route=%q,op=%q} %d\n", k.route, k.op, snapshot[k].errors)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler serves the metrics for scraping.
+func (rec *Recorder) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		rec.WriteTo(w)
+	}
+}
+
+// Querier is the query surface of a pool or transaction; it matches
+// handlers.DBTX.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// DB times every statement run through the wrapped Querier.
+type DB struct {
+	db  Querier
+	rec *Recorder
+}
+
+// Wrap instruments db with rec.
+func Wrap(db Querier, rec *Recorder) *DB {
+	return &DB{db: db, rec: rec}
+}
+
+// Query is timed until the rows are closed, so reading them counts.
+func (d *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	start := time.Now()
+	rows, err := d.db.Query(ctx, sql, args...)
+	if err != nil {
+		d.rec.observe(ctx, "query", sql, time.Since(start), err)
+		return nil, err
+	}
+	return &timedRows{Rows: rows, ctx: ctx, sql: sql, start: start, rec: d.rec}, nil
+}
+
+// QueryRow is timed until the row is scanned.
+func (d *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &timedRow{row: d.db.QueryRow(ctx, sql, args...), ctx: ctx, sql: sql, start: time.Now(), rec: d.rec}
+}
+
+func (d *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	start := time.Now()
+	tag, err := d.db.Exec(ctx, sql, args...)
+	d.rec.observe(ctx, "exec", sql, time.Since(start), err)
+	return tag, err
+}
+
+// Begin starts a transaction whose statements are timed the same way.
+func (d *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	start := time.Now()
+	tx, err := d.db.Begin(ctx)
+	d.rec.observe(ctx, "begin", "BEGIN", time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return &timedTx{Tx: tx, db: DB{db: tx, rec: d.rec}}, nil
+}
+
+type timedRows struct {
+	pgx.Rows
+	ctx    context.Context
+	sql    string
+	start  time.Time
+	rec    *Recorder
+	closed bool
+}
+
+func (r *timedRows) Close() {
+	r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.rec.observe(r.ctx, "query", r.sql, time.Since(r.start), r.Rows.Err())
+	}
+}
+
+// Next closes the rows after the last one, as pgx does, so iterating to the
+// end is timed even when the caller's deferred Close runs much later.
+func (r *timedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.Close()
+	return false
+}
+
+type timedRow struct {
+	row   pgx.Row
+	ctx   context.Context
+	sql   string
+	start time.Time
+	rec   *Recorder
+}
+
+func (r *timedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.rec.observe(r.ctx, "query_row", r.sql, time.Since(r.start), err)
+	return err
+}
+
+// timedTx is a pgx.Tx whose statements go through the Recorder; commit,
+// rollback and the rest pass straight through.
+type timedTx struct {
+	pgx.Tx
+	db DB
+}
+
+func (t *timedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return t.db.Query(ctx, sql, args...)
+}
+
+func (t *timedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return t.db.QueryRow(ctx, sql, args...)
+}
+
+func (t *timedTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return t.db.Exec(ctx, sql, args...)
+}
+
+func (t *timedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return t.db.Begin(ctx)
+}
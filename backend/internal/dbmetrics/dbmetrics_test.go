@@ -0,0 +1,58 @@
+package dbmetrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestWrapRecordsQueriesByRoute(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT name FROM bills").WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"name"}).AddRow("Rent"))
+	mock.ExpectExec("DELETE FROM bills").WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	rctx := chi.NewRouteContext()
+	rctx.RouteMethod = "GET"
+	rctx.RoutePatterns = []string{"/api/v1/bills/{id}"}
+	ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+	rec := NewRecorder(0)
+	db := Wrap(mock, rec)
+	var name string
+	if err := db.QueryRow(ctx, "SELECT name FROM bills WHERE id = $1", 1).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(context.Background(), "DELETE FROM bills WHERE id = $1", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	rec.WriteTo(&out)
+	for _, want := range []string{
+		`db_query_duration_seconds_count{route="GET /api/v1/bills/{id}",op="query_row"} 1`,
+		`db_query_duration_seconds_bucket{route="GET /api/v1/bills/{id}",op="query_row",le="+Inf"} 1`,
+		`db_query_duration_seconds_count{route="background",op="exec"} 1`,
+		`db_query_errors_total{route="background",op="exec"} 0`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCompactSQL(t *testing.T) {
+	got := compactSQL("\n\t\tSELECT id\n\t\tFROM bills\n\t")
+	if got != "SELECT id FROM bills" {
+		t.Errorf("compactSQL = %q", got)
+	}
+}
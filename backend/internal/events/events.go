@@ -0,0 +1,57 @@
+// Package events is an in-process publish/subscribe bus for domain events
+// (e.g. an assignment being paid) that notification and audit features
+// listen to without handlers knowing about them.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	AssignmentPaid          = "assignment.paid"
+	AssignmentStatusChanged = "assignment.status_changed"
+	CategoryBudgetAlert     = "category.budget_alert"
+	PeriodsGenerated        = "periods.generated"
+	ForecastNegative        = "forecast.negative"
+	ObligationsIncreased    = "obligations.increased"
+)
+
+type Event struct {
+	Type string    `json:"type"`
+	At   time.Time `json:"at"`
+	Data any       `json:"data"`
+}
+
+type Bus struct {
+	mu   sync.RWMutex
+	subs []func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn to receive every published event. Subscribers run
+// synchronously in the publisher's goroutine and should not block.
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, fn)
+}
+
+// Publish delivers an event to all subscribers. A nil Bus discards it, so
+// handlers built without one (e.g. in tests) need no checks.
+func (b *Bus) Publish(eventType string, data any) {
+	if b == nil {
+		return
+	}
+	e := Event{Type: eventType, At: time.Now(), Data: data}
+
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	for _, fn := range subs {
+		fn(e)
+	}
+}
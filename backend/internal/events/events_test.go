@@ -0,0 +1,24 @@
+package events
+
+import "testing"
+
+func TestBusPublishDeliversToSubscribers(t *testing.T) {
+	b := NewBus()
+	var got []Event
+	b.Subscribe(func(e Event) { got = append(got, e) })
+	b.Subscribe(func(e Event) { got = append(got, e) })
+
+	b.Publish(AssignmentPaid, 42)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(got))
+	}
+	if got[0].Type != AssignmentPaid || got[0].Data != 42 || got[0].At.IsZero() {
+		t.Errorf("unexpected event: %+v", got[0])
+	}
+}
+
+func TestNilBusPublishIsNoop(t *testing.T) {
+	var b *Bus
+	b.Publish(AssignmentPaid, nil)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/db"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// AdminHandler reports on the running deployment.
+type AdminHandler struct {
+	db       DBTX
+	runner   *jobs.Runner
+	instance string
+}
+
+func NewAdminHandler(db DBTX, runner *jobs.Runner, instance string) *AdminHandler {
+	return &AdminHandler{db: db, runner: runner, instance: instance}
+}
+
+// LockHolder is the database session holding a scheduled job's lock.
+type LockHolder struct {
+	PID             int       `json:"pid"`
+	ApplicationName string    `json:"application_name"` // budget-mgmt:<instance>
+	ClientAddr      string    `json:"client_addr"`
+	BackendStart    time.Time `json:"backend_start"`
+	ThisInstance    bool      `json:"this_instance"`
+}
+
+type ScheduledJobStats struct {
+	jobs.JobStatus
+	IntervalSeconds int         `json:"interval_seconds"`
+	LockHolder      *LockHolder `json:"lock_holder"` // nil when no replica is running it
+}
+
+type AdminStats struct {
+	Instance         string              `json:"instance"`
+	SchedulerEnabled bool                `json:"scheduler_enabled"`
+	Jobs             []ScheduledJobStats `json:"jobs"`
+	Counts           map[string]int      `json:"counts"`
+}
+
+// Stats lists this instance's scheduled jobs with whichever replica currently
+// holds each job's advisory lock, and row counts for the main tables.
+// GET /api/v1/admin/stats
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	stats := AdminStats{
+		Instance:         h.instance,
+		SchedulerEnabled: h.runner.SchedulerEnabled(),
+		Jobs:             []ScheduledJobStats{},
+		Counts:           map[string]int{},
+	}
+
+	scheduled := h.runner.Scheduled()
+	keys := make([]int64, len(scheduled))
+	for i, s := range scheduled {
+		keys[i] = s.LockKey
+	}
+
+	// A bigint advisory lock shows in pg_locks split into classid (high 32
+	// bits) and objid (low 32 bits), with objsubid 1
+	holders := map[int64]*LockHolder{}
+	if len(keys) > 0 {
+		rows, err := h.db.Query(ctx, `
+			SELECT (l.classid::bigint << 32) | l.objid::bigint, a.pid,
+			       COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), ''), a.backend_start
+			FROM pg_locks l
+			JOIN pg_stat_activity a ON a.pid = l.pid
+			WHERE l.locktype = 'advisory' AND l.objsubid = 1 AND l.granted
+			  AND ((l.classid::bigint << 32) | l.objid::bigint) = ANY($1)
+		`, keys)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var key int64
+			var lh LockHolder
+			if err := rows.Scan(&key, &lh.PID, &lh.ApplicationName, &lh.ClientAddr, &lh.BackendStart); err != nil {
+				models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+				return
+			}
+			lh.ThisInstance = lh.ApplicationName == jobs.ApplicationName(h.instance)
+			holders[key] = &lh
+		}
+		rows.Close()
+	}
+
+	for _, s := range scheduled {
+		stats.Jobs = append(stats.Jobs, ScheduledJobStats{
+			JobStatus:       s,
+			IntervalSeconds: int(s.Interval.Seconds()),
+			LockHolder:      holders[s.LockKey],
+		})
+	}
+
+	var bills, sources, periods, assignments int
+	err := h.db.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM bills),
+		       (SELECT COUNT(*) FROM income_sources),
+		       (SELECT COUNT(*) FROM pay_periods),
+		       (SELECT COUNT(*) FROM bill_assignments)
+	`).Scan(&bills, &sources, &periods, &assignments)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	stats.Counts["bills"] = bills
+	stats.Counts["income_sources"] = sources
+	stats.Counts["pay_periods"] = periods
+	stats.Counts["bill_assignments"] = assignments
+
+	models.WriteJSON(w, http.StatusOK, stats)
+}
+
+// MigrationsReport is the schema's migration state.
+type MigrationsReport struct {
+	Version    string               `json:"version"` // latest applied migration
+	Pending    int                  `json:"pending"`
+	Drifted    int                  `json:"drifted"` // applied migrations edited since they ran
+	Migrations []db.MigrationStatus `json:"migrations"`
+}
+
+// Migrations lists every migration with whether it has been applied, and
+// flags applied ones whose script no longer matches the recorded checksum.
+// GET /api/v1/admin/migrations
+func (h *AdminHandler) Migrations(w http.ResponseWriter, r *http.Request) {
+	statuses, err := db.Status(r.Context(), h.db)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	report := MigrationsReport{Migrations: statuses}
+	for _, s := range statuses {
+		switch {
+		case s.Drifted:
+			report.Drifted++
+		case !s.Applied:
+			report.Pending++
+		}
+		if s.Applied {
+			report.Version = s.Filename
+		}
+	}
+	models.WriteJSON(w, http.StatusOK, report)
+}
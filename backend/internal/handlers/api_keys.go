@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// APIKeyHandler manages the scoped API keys scripts and dashboards use
+// instead of logging in.
+type APIKeyHandler struct {
+	db  DBTX
+	cfg *config.Config
+}
+
+func NewAPIKeyHandler(db DBTX, cfg *config.Config) *APIKeyHandler {
+	return &APIKeyHandler{db: db, cfg: cfg}
+}
+
+const apiKeyCols = `id, name, scopes, created_by, created_at, revoked_at`
+
+func apiKeyScanDest(k *models.APIKey) []interface{} {
+	return []interface{}{&k.ID, &k.Name, &k.Scopes, &k.CreatedBy, &k.CreatedAt, &k.RevokedAt}
+}
+
+// List returns every API key, revoked ones included, newest first. The keys
+// themselves are never included.
+// GET /api/v1/api-keys
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), `SELECT `+apiKeyCols+` FROM api_keys ORDER BY id DESC`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(apiKeyScanDest(&k)...); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		keys = append(keys, k)
+	}
+	models.WriteJSON(w, http.StatusOK, keys)
+}
+
+// Create issues a key with the given scopes. The response is the only time
+// the key is shown.
+// POST /api/v1/api-keys
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name is required and must be at most 100 characters")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "at least one scope is required")
+		return
+	}
+	for _, s := range req.Scopes {
+		if _, ok := auth.ParseScope(s); !ok {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "scopes must be read:bills, write:assignments or run:autoassign")
+			return
+		}
+	}
+
+	created, err := createAPIKey(r.Context(), h.db, req.Name, req.Scopes)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusCreated, created)
+}
+
+// Revoke stops a key from working from the next request on.
+// DELETE /api/v1/api-keys/{id}
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "no active API key with that id")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WidgetKey issues a read:bills API key for a dashboard to send as X-API-Key. Each
+// call makes a new key, revoked like any other under /api-keys. Without
+// authentication the widgets need no key.
+// POST /api/v1/widgets/key
+func (h *APIKeyHandler) WidgetKey(w http.ResponseWriter, r *http.Request) {
+	if !h.cfg.AuthEnabled() {
+		models.WriteJSON(w, http.StatusOK, map[string]string{"key": ""})
+		return
+	}
+
+	created, err := createAPIKey(r.Context(), h.db, "Dashboard widgets", []string{string(auth.ScopeReadBills)})
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusCreated, created)
+}
+
+// Lookup is the auth.KeyLookup RequireAuth checks X-API-Key headers with.
+func (h *APIKeyHandler) Lookup(ctx context.Context, key string) (auth.Session, bool, error) {
+	return lookupAPIKey(ctx, h.db, key)
+}
+
+func createAPIKey(ctx context.Context, db DBTX, name string, scopes []string) (models.CreatedAPIKey, error) {
+	key, hash, err := auth.NewAPIKey()
+	if err != nil {
+		return models.CreatedAPIKey{}, err
+	}
+	created := models.CreatedAPIKey{Key: key}
+	err = db.QueryRow(ctx, `
+		INSERT INTO api_keys (name, key_hash, scopes, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+apiKeyCols,
+		name, hash, scopes, auth.Username(ctx)).Scan(apiKeyScanDest(&created.APIKey)...)
+	return created, err
+}
+
+func lookupAPIKey(ctx context.Context, db DBTX, key string) (auth.Session, bool, error) {
+	var id int
+	var name string
+	var names []string
+	err := db.QueryRow(ctx, `
+		SELECT id, name, scopes FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL
+	`, auth.HashAPIKey(key)).Scan(&id, &name, &names)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.Session{}, false, nil
+	}
+	if err != nil {
+		return auth.Session{}, false, err
+	}
+	// Scopes since dropped from the code grant nothing
+	scopes := make([]auth.Scope, 0, len(names))
+	for _, n := range names {
+		if s, ok := auth.ParseScope(n); ok {
+			scopes = append(scopes, s)
+		}
+	}
+	return auth.KeySession(id, name, scopes), true, nil
+}
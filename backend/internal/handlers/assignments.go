@@ -1,77 +1,291 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
 	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type AssignmentHandler struct {
-	db DBTX
+	db     DBTX
+	events *events.Bus
 }
 
 func NewAssignmentHandler(db DBTX) *AssignmentHandler {
 	return &AssignmentHandler{db: db}
 }
 
-// scanCols is the standard set of columns returned by assignment queries.
+// WithEvents sets the bus that assignment events such as payments are published to.
+func (h *AssignmentHandler) WithEvents(bus *events.Bus) *AssignmentHandler {
+	h.events = bus
+	return h
+}
+
+// assignmentSelectCols is the standard set of columns returned by assignment queries.
 const assignmentSelectCols = `ba.id, ba.bill_id, ba.pay_period_id, ba.planned_amount,
 		       ba.forecast_amount, ba.actual_amount, ba.status, ba.deferred_to_id,
 		       ba.is_extra, COALESCE(ba.extra_name, ''), COALESCE(ba.notes, ''),
 		       ba.manually_moved, ba.is_sinking_fund, ba.sinking_fund_for_period_id,
-		       ba.created_at, ba.updated_at`
+		       ba.tax_deductible, ba.scheduled_date, ba.due_date, ba.paid_date,
+		       ba.original_currency, ba.original_amount, ba.fx_note,
+		       ba.paid_by, ba.paid_from, ba.created_at, ba.updated_at`
 
 const assignmentReturnCols = `id, bill_id, pay_period_id, planned_amount, forecast_amount, actual_amount,
 		          status, deferred_to_id, is_extra, COALESCE(extra_name, ''), COALESCE(notes, ''),
-		          manually_moved, is_sinking_fund, sinking_fund_for_period_id, created_at, updated_at`
+		          manually_moved, is_sinking_fund, sinking_fund_for_period_id, tax_deductible,
+		          scheduled_date, due_date, paid_date, original_currency, original_amount, fx_note,
+		          paid_by, paid_from, created_at, updated_at`
+
+// netPlannedAmount is an assignment's planned amount less the share paid by an
+// external party (bills.shared_percent). Queries using it must join bills as b.
+const netPlannedAmount = `ba.planned_amount * (1 - COALESCE(b.shared_percent, 0) / 100)`
+
+// amountModeColumns is the assignment amount each models.AmountMode* counts.
+var amountModeColumns = map[string]string{
+	models.AmountModePlanned:         "ba.planned_amount",
+	models.AmountModeForecast:        "COALESCE(ba.forecast_amount, ba.planned_amount)",
+	models.AmountModeActualPreferred: "COALESCE(ba.actual_amount, ba.forecast_amount, ba.planned_amount)",
+}
 
-func scanAssignment(scanner interface{ Scan(dest ...interface{}) error }, a *models.BillAssignment) error {
-	return scanner.Scan(&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount,
+// netAssignmentAmount is netPlannedAmount for any amount mode.
+func netAssignmentAmount(mode string) string {
+	return amountModeColumns[mode] + " * (1 - COALESCE(b.shared_percent, 0) / 100)"
+}
+
+// validAmountMode normalizes an amount mode, accepting "actual-preferred" as
+// well; "" becomes def.
+func validAmountMode(mode, def string) (string, bool) {
+	if mode == "" {
+		return def, true
+	}
+	mode = strings.ReplaceAll(mode, "-", "_")
+	_, ok := amountModeColumns[mode]
+	return mode, ok
+}
+
+// amountModeParam reads ?mode=, writing a 400 for an unknown mode.
+func amountModeParam(w http.ResponseWriter, r *http.Request, def string) (string, bool) {
+	mode, ok := validAmountMode(r.URL.Query().Get("mode"), def)
+	if !ok {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "mode must be planned, forecast or actual_preferred")
+	}
+	return mode, ok
+}
+
+// assignmentScanDest returns scan destinations matching assignmentSelectCols /
+// assignmentReturnCols, so callers can append joined columns before scanning.
+func assignmentScanDest(a *models.BillAssignment) []interface{} {
+	return []interface{}{
+		&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount,
 		&a.ForecastAmount, &a.ActualAmount, &a.Status, &a.DeferredToID,
 		&a.IsExtra, &a.ExtraName, &a.Notes,
 		&a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-		&a.CreatedAt, &a.UpdatedAt)
+		&a.TaxDeductible, &a.ScheduledDate, &a.DueDate, &a.PaidDate,
+		&a.OriginalCurrency, &a.OriginalAmount, &a.FXNote,
+		&a.PaidBy, &a.PaidFrom, &a.CreatedAt, &a.UpdatedAt,
+	}
+}
+
+// dueDateIn returns dueDay in the given month, clamped to the month's last day.
+func dueDateIn(year int, month time.Month, dueDay int) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if dueDay > lastDay {
+		dueDay = lastDay
+	}
+	return time.Date(year, month, dueDay, 0, 0, 0, 0, time.UTC)
+}
+
+// nextDueDate returns the first occurrence of dueDay on or after from.
+func nextDueDate(from time.Time, dueDay int) time.Time {
+	d := dueDateIn(from.Year(), from.Month(), dueDay)
+	if d.Before(time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)) {
+		d = dueDateIn(from.Year(), from.Month()+1, dueDay)
+	}
+	return d
+}
+
+func scanAssignment(scanner interface{ Scan(dest ...interface{}) error }, a *models.BillAssignment) error {
+	return scanner.Scan(assignmentScanDest(a)...)
+}
+
+// validateOriginalAmount checks an assignment's currency of record and
+// upper-cases the currency code in place. An empty code is left for the
+// caller, as it means "clear" on update.
+func validateOriginalAmount(currency *string, amount, rate *float64) string {
+	if currency != nil && *currency != "" {
+		code := strings.ToUpper(strings.TrimSpace(*currency))
+		if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return "original_currency must be a three-letter ISO 4217 code"
+		}
+		*currency = code
+	}
+	if amount != nil && *amount < 0 {
+		return "original_amount must not be negative"
+	}
+	if rate != nil && *rate <= 0 {
+		return "fx_rate must be positive"
+	}
+	return ""
+}
+
+// convertOriginal is amount at rate, rounded to cents.
+func convertOriginal(amount, rate float64) float64 {
+	return math.Round(amount*rate*100) / 100
+}
+
+// writeAssignmentSaveError maps errors from saving an assignment's currency
+// of record, which the schema requires together.
+func writeAssignmentSaveError(w http.ResponseWriter, status int, code, msg string, err error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23514" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "original_currency and original_amount must be set together")
+		return
+	}
+	models.WriteError(w, status, code, msg)
+}
+
+// assignmentSortFields are the fields Assignments List accepts in ?sort=.
+var assignmentSortFields = map[string]string{
+	"due_date":       "ba.due_date",
+	"pay_date":       "pp.pay_date",
+	"planned_amount": "ba.planned_amount",
+	"status":         "ba.status",
+	"bill":           "b.name",
 }
 
+// amountQueryParam reads an optional non-negative amount from the query.
+func amountQueryParam(w http.ResponseWriter, r *http.Request, name string) (*float64, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, true
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", name+" must be a non-negative number")
+		return nil, false
+	}
+	return &f, true
+}
+
+// List returns assignments filtered by ?period_id, ?bill_id, ?status,
+// ?overdue=true, ?category, ?autopay=true|false, a ?from/?to range on the
+// due date (the pay date when there is none), an ?amount_min/?amount_max
+// range on the actual amount (the planned one until it is set) and a
+// ?paid_after/?paid_before range on the paid date, sorted and paged per
+// parseListParams. The ranges include their ends.
 func (h *AssignmentHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	q := r.URL.Query()
+
+	params, ok := parseListParams(w, r, assignmentSortFields, "b.sort_order, b.id, ba.due_date NULLS FIRST")
+	if !ok {
+		return
+	}
+	from, ok := dateQueryParam(w, r, "from", time.Time{})
+	if !ok {
+		return
+	}
+	to, ok := dateQueryParam(w, r, "to", time.Time{})
+	if !ok {
+		return
+	}
+	amountMin, ok := amountQueryParam(w, r, "amount_min")
+	if !ok {
+		return
+	}
+	amountMax, ok := amountQueryParam(w, r, "amount_max")
+	if !ok {
+		return
+	}
+	if amountMin != nil && amountMax != nil && *amountMin > *amountMax {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "amount_min must not be more than amount_max")
+		return
+	}
+	paidAfter, ok := dateQueryParam(w, r, "paid_after", time.Time{})
+	if !ok {
+		return
+	}
+	paidBefore, ok := dateQueryParam(w, r, "paid_before", time.Time{})
+	if !ok {
+		return
+	}
 
 	query := `
 		SELECT ` + assignmentSelectCols + `,
-		       b.name
+		       b.name, COUNT(*) OVER ()
 		FROM bill_assignments ba
 		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
 		WHERE 1=1
 	`
 	args := []interface{}{}
-	argIdx := 1
 
-	if periodID := r.URL.Query().Get("period_id"); periodID != "" {
-		query += " AND ba.pay_period_id = $" + strconv.Itoa(argIdx)
+	if periodID := q.Get("period_id"); periodID != "" {
 		id, _ := strconv.Atoi(periodID)
 		args = append(args, id)
-		argIdx++
+		query += " AND ba.pay_period_id = $" + strconv.Itoa(len(args))
 	}
-	if billID := r.URL.Query().Get("bill_id"); billID != "" {
-		query += " AND ba.bill_id = $" + strconv.Itoa(argIdx)
+	if billID := q.Get("bill_id"); billID != "" {
 		id, _ := strconv.Atoi(billID)
 		args = append(args, id)
-		argIdx++
+		query += " AND ba.bill_id = $" + strconv.Itoa(len(args))
 	}
-	if status := r.URL.Query().Get("status"); status != "" {
-		query += " AND ba.status = $" + strconv.Itoa(argIdx)
+	if status := q.Get("status"); status != "" {
 		args = append(args, status)
-		argIdx++
+		query += " AND ba.status = $" + strconv.Itoa(len(args))
+	}
+	if category := q.Get("category"); category != "" {
+		args = append(args, category)
+		query += " AND b.category = $" + strconv.Itoa(len(args))
+	}
+	if autopay := q.Get("autopay"); autopay != "" {
+		args = append(args, autopay == "true")
+		query += " AND b.is_autopay = $" + strconv.Itoa(len(args))
+	}
+	if !from.IsZero() {
+		args = append(args, from)
+		query += " AND COALESCE(ba.due_date, pp.pay_date) >= $" + strconv.Itoa(len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		query += " AND COALESCE(ba.due_date, pp.pay_date) <= $" + strconv.Itoa(len(args))
+	}
+	// Matches the expression index idx_assignments_amount
+	if amountMin != nil {
+		args = append(args, *amountMin)
+		query += " AND COALESCE(ba.actual_amount, ba.planned_amount) >= $" + strconv.Itoa(len(args))
+	}
+	if amountMax != nil {
+		args = append(args, *amountMax)
+		query += " AND COALESCE(ba.actual_amount, ba.planned_amount) <= $" + strconv.Itoa(len(args))
+	}
+	if !paidAfter.IsZero() {
+		args = append(args, paidAfter)
+		query += " AND ba.paid_date >= $" + strconv.Itoa(len(args))
+	}
+	if !paidBefore.IsZero() {
+		args = append(args, paidBefore)
+		query += " AND ba.paid_date <= $" + strconv.Itoa(len(args))
 	}
 
-	query += " ORDER BY b.sort_order, b.id"
+	// Overdue: still unpaid after the occurrence's due date
+	if q.Get("overdue") == "true" {
+		query += " AND ba.due_date < CURRENT_DATE AND ba.status IN ('pending', 'uncertain')"
+	}
 
-	rows, err := h.db.Query(ctx, query, args...)
+	pageQuery, pageArgs := params.apply(query, args)
+	rows, err := h.db.Query(ctx, pageQuery, pageArgs...)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
@@ -79,25 +293,28 @@ func (h *AssignmentHandler) List(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	var assignments []models.BillAssignment
+	var total int
 	for rows.Next() {
 		var a models.BillAssignment
-		err := rows.Scan(&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount,
-			&a.ForecastAmount, &a.ActualAmount, &a.Status, &a.DeferredToID,
-			&a.IsExtra, &a.ExtraName, &a.Notes,
-			&a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-			&a.CreatedAt, &a.UpdatedAt,
-			&a.BillName)
+		err := rows.Scan(append(assignmentScanDest(&a), &a.BillName, &total)...)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
 			return
 		}
 		assignments = append(assignments, a)
 	}
+	rows.Close()
+
+	total, err = listTotal(ctx, h.db, params, query, args, total, len(assignments))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
 
 	if assignments == nil {
 		assignments = []models.BillAssignment{}
 	}
-	models.WriteJSON(w, http.StatusOK, assignments)
+	models.WriteJSONList(w, assignments, total, params.Limit, params.Offset)
 }
 
 func (h *AssignmentHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -111,19 +328,76 @@ func (h *AssignmentHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if req.Status == "" {
 		req.Status = "pending"
 	}
+	if msg := validateOriginalAmount(req.OriginalCurrency, req.OriginalAmount, req.FXRate); msg != "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+	if req.OriginalCurrency != nil && *req.OriginalCurrency == "" {
+		req.OriginalCurrency = nil
+	}
+	if (req.OriginalCurrency == nil) != (req.OriginalAmount == nil) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "original_currency and original_amount must be set together")
+		return
+	}
+	if req.FXRate != nil {
+		if req.OriginalAmount == nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "fx_rate needs an original_amount to convert")
+			return
+		}
+		if req.PlannedAmount == nil {
+			planned := convertOriginal(*req.OriginalAmount, *req.FXRate)
+			req.PlannedAmount = &planned
+		}
+	}
+
+	var scheduledDate *time.Time
+	if req.ScheduledDate != nil && *req.ScheduledDate != "" {
+		parsed, err := time.Parse("2006-01-02", *req.ScheduledDate)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "scheduled_date must be in YYYY-MM-DD format")
+			return
+		}
+		scheduledDate = &parsed
+	}
+
+	var dueDate *time.Time
+	if req.DueDate != nil && *req.DueDate != "" {
+		parsed, err := time.Parse("2006-01-02", *req.DueDate)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "due_date must be in YYYY-MM-DD format")
+			return
+		}
+		dueDate = &parsed
+	} else if !req.IsExtra {
+		// Default to the bill's next due day on or after the pay date
+		var dueDay *int
+		var payDate time.Time
+		err := h.db.QueryRow(ctx, `
+			SELECT b.due_day, pp.pay_date FROM bills b, pay_periods pp
+			WHERE b.id = $1 AND pp.id = $2
+		`, req.BillID, req.PayPeriodID).Scan(&dueDay, &payDate)
+		if err != nil {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "bill or pay period not found")
+			return
+		}
+		if dueDay != nil {
+			d := nextDueDate(payDate, *dueDay)
+			dueDate = &d
+		}
+	}
 
 	var a models.BillAssignment
 	err := h.db.QueryRow(ctx, `
 		INSERT INTO bill_assignments (bill_id, pay_period_id, planned_amount, forecast_amount,
-		                              actual_amount, status, is_extra, extra_name, notes, manually_moved)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, true)
+		                              actual_amount, status, is_extra, extra_name, notes, manually_moved,
+		                              tax_deductible, scheduled_date, due_date,
+		                              original_currency, original_amount, fx_note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, true, $10, $11, $12, $13, $14, $15)
 		RETURNING `+assignmentReturnCols+`
 	`, req.BillID, req.PayPeriodID, req.PlannedAmount, req.ForecastAmount,
-		req.ActualAmount, req.Status, req.IsExtra, req.ExtraName, req.Notes,
-	).Scan(&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount, &a.ForecastAmount,
-		&a.ActualAmount, &a.Status, &a.DeferredToID, &a.IsExtra, &a.ExtraName,
-		&a.Notes, &a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-		&a.CreatedAt, &a.UpdatedAt)
+		req.ActualAmount, req.Status, req.IsExtra, req.ExtraName, req.Notes, req.TaxDeductible,
+		scheduledDate, dueDate, req.OriginalCurrency, req.OriginalAmount, req.FXNote,
+	).Scan(assignmentScanDest(&a)...)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
@@ -146,26 +420,68 @@ func (h *AssignmentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// scheduled_date is only touched when present; an empty string clears it
+	setScheduled := req.ScheduledDate != nil
+	var scheduledDate *time.Time
+	if setScheduled && *req.ScheduledDate != "" {
+		parsed, err := time.Parse("2006-01-02", *req.ScheduledDate)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "scheduled_date must be in YYYY-MM-DD format")
+			return
+		}
+		scheduledDate = &parsed
+	}
+
+	// original_currency is only touched when present; an empty string clears
+	// it together with original_amount
+	if msg := validateOriginalAmount(req.OriginalCurrency, req.OriginalAmount, req.FXRate); msg != "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+	clearOriginal := req.OriginalCurrency != nil && *req.OriginalCurrency == ""
+	if clearOriginal && (req.OriginalAmount != nil || req.FXRate != nil) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "original_amount and fx_rate can't be set while clearing original_currency")
+		return
+	}
+	if req.FXRate != nil && req.OriginalAmount == nil {
+		var original *float64
+		if err := h.db.QueryRow(ctx, `SELECT original_amount FROM bill_assignments WHERE id = $1`, id).Scan(&original); err != nil {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found")
+			return
+		}
+		if original == nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "fx_rate needs an original_amount to convert")
+			return
+		}
+	}
+
 	var a models.BillAssignment
 	err = h.db.QueryRow(ctx, `
 		UPDATE bill_assignments SET
-			planned_amount = COALESCE($2, planned_amount),
+			planned_amount = COALESCE($2, ROUND(COALESCE($12, original_amount) * $14::numeric, 2), planned_amount),
 			forecast_amount = COALESCE($3, forecast_amount),
 			actual_amount = COALESCE($4, actual_amount),
 			status = COALESCE($5, status),
 			deferred_to_id = COALESCE($6, deferred_to_id),
 			notes = COALESCE($7, notes),
+			tax_deductible = COALESCE($8, tax_deductible),
+			scheduled_date = CASE WHEN $9 THEN $10::date ELSE scheduled_date END,
+			original_currency = CASE WHEN $11::text = '' THEN NULL ELSE COALESCE($11, original_currency) END,
+			original_amount = CASE WHEN $11::text = '' THEN NULL ELSE COALESCE($12, original_amount) END,
+			fx_note = COALESCE($13, fx_note),
+			paid_by = COALESCE($15, paid_by),
+			paid_from = COALESCE($16, paid_from),
 			updated_at = NOW()
 		WHERE id = $1
 		RETURNING `+assignmentReturnCols+`
 	`, id, req.PlannedAmount, req.ForecastAmount, req.ActualAmount,
-		req.Status, req.DeferredToID, req.Notes,
-	).Scan(&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount, &a.ForecastAmount,
-		&a.ActualAmount, &a.Status, &a.DeferredToID, &a.IsExtra, &a.ExtraName,
-		&a.Notes, &a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-		&a.CreatedAt, &a.UpdatedAt)
+		req.Status, req.DeferredToID, req.Notes, req.TaxDeductible,
+		setScheduled, scheduledDate,
+		req.OriginalCurrency, req.OriginalAmount, req.FXNote, req.FXRate,
+		req.PaidBy, req.PaidFrom,
+	).Scan(assignmentScanDest(&a)...)
 	if err != nil {
-		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found")
+		writeAssignmentSaveError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found", err)
 		return
 	}
 
@@ -203,15 +519,268 @@ func (h *AssignmentHandler) UpdateStatus(w http.ResponseWriter, r *http.Request)
 		WHERE id = $1
 		RETURNING `+assignmentReturnCols+`
 	`, id, req.Status, req.DeferredToID,
-	).Scan(&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount, &a.ForecastAmount,
-		&a.ActualAmount, &a.Status, &a.DeferredToID, &a.IsExtra, &a.ExtraName,
-		&a.Notes, &a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-		&a.CreatedAt, &a.UpdatedAt)
+	).Scan(assignmentScanDest(&a)...)
 	if err != nil {
 		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found")
 		return
 	}
 
+	h.events.Publish(events.AssignmentStatusChanged, a)
+
+	models.WriteJSON(w, http.StatusOK, a)
+}
+
+// DueSoon returns unpaid assignments that are overdue or due within a week,
+// bucketed by urgency.
+// GET /api/v1/assignments/due-soon
+func (h *AssignmentHandler) DueSoon(w http.ResponseWriter, r *http.Request) {
+	report, err := loadDueSoon(r.Context(), h.db, time.Now())
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+// loadDueSoon buckets pending and uncertain assignments due up to a week after
+// now. Assignments without a due date count as due on their pay date, and
+// disputed ones are left out until the dispute is resolved.
+func loadDueSoon(ctx context.Context, db DBTX, now time.Time) (models.DueSoon, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	report := models.DueSoon{
+		AsOf:       today.Format("2006-01-02"),
+		Overdue:    models.DueSoonBucket{Assignments: []models.BillAssignment{}},
+		DueIn3Days: models.DueSoonBucket{Assignments: []models.BillAssignment{}},
+		DueIn7Days: models.DueSoonBucket{Assignments: []models.BillAssignment{}},
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT `+assignmentSelectCols+`,
+		       b.name, COALESCE(ba.due_date, pp.pay_date) AS due,
+		       COALESCE(`+netPlannedAmount+`, 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status IN ('pending', 'uncertain') AND NOT `+openDispute+`
+		  AND COALESCE(ba.due_date, pp.pay_date) <= $1
+		ORDER BY due, b.sort_order, b.id
+	`, today.AddDate(0, 0, 7))
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.BillAssignment
+		var due time.Time
+		var amount float64
+		if err := rows.Scan(append(assignmentScanDest(&a), &a.BillName, &due, &amount)...); err != nil {
+			return report, err
+		}
+
+		bucket := &report.DueIn7Days
+		switch {
+		case due.Before(today):
+			bucket = &report.Overdue
+		case !due.After(today.AddDate(0, 0, 3)):
+			bucket = &report.DueIn3Days
+		}
+		bucket.Assignments = append(bucket.Assignments, a)
+		bucket.Total += amount
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	report.Overdue.Total = roundCents(report.Overdue.Total)
+	report.DueIn3Days.Total = roundCents(report.DueIn3Days.Total)
+	report.DueIn7Days.Total = roundCents(report.DueIn7Days.Total)
+	return report, nil
+}
+
+// maxDeferOptions caps how many future periods DeferOptions considers.
+const maxDeferOptions = 8
+
+// DeferOptions suggests pay periods to defer an assignment to. Periods that
+// pay before the bill's next due date and stay non-negative after taking on
+// the amount come first; within each group the largest projected balance wins.
+// GET /api/v1/assignments/{id}/defer-options
+func (h *AssignmentHandler) DeferOptions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var billID int
+	var payDate time.Time
+	var dueDate *time.Time
+	var dueDay *int
+	var amount float64
+	err = h.db.QueryRow(ctx, `
+		SELECT ba.bill_id, pp.pay_date, ba.due_date, b.due_day,
+		       COALESCE(ba.forecast_amount, ba.planned_amount, 0) * (1 - COALESCE(b.shared_percent, 0) / 100)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.id = $1
+	`, id).Scan(&billID, &payDate, &dueDate, &dueDay, &amount)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found")
+		return
+	}
+
+	// The occurrence's own due date is the deadline while it is still ahead of
+	// the pay date; otherwise the bill's next occurrence is.
+	var deadline *time.Time
+	switch {
+	case dueDate != nil && dueDate.After(payDate):
+		deadline = dueDate
+	case dueDay != nil:
+		d := nextDueDate(payDate.AddDate(0, 0, 1), *dueDay)
+		deadline = &d
+	}
+
+	// Deferring a card payment past a promo expiry leaves the balance accruing
+	// at the standard APR
+	promos, err := loadPromos(ctx, h.db, billID)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT pp.id, pp.pay_date, inc.name,
+		       COALESCE(pp.actual_amount, pp.expected_amount, 0) - COALESCE(SUM(`+netPlannedAmount+`), 0)
+		FROM pay_periods pp
+		JOIN income_sources inc ON inc.id = pp.income_source_id
+		LEFT JOIN bill_assignments ba ON ba.pay_period_id = pp.id
+		LEFT JOIN bills b ON b.id = ba.bill_id
+		WHERE pp.pay_date > $1 AND inc.is_active = true
+		GROUP BY pp.id, pp.pay_date, pp.actual_amount, pp.expected_amount, inc.name
+		ORDER BY pp.pay_date, pp.id
+		LIMIT $2
+	`, payDate, maxDeferOptions)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	options := []models.DeferOption{}
+	for rows.Next() {
+		var o models.DeferOption
+		var pd time.Time
+		if err := rows.Scan(&o.PeriodID, &pd, &o.SourceName, &o.Remaining); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		o.PayDate = pd.Format("2006-01-02")
+		o.Remaining = roundCents(o.Remaining)
+		o.ProjectedRemaining = roundCents(o.Remaining - amount)
+		o.PaysBeforeDue = deadline == nil || !pd.After(*deadline)
+		o.PromoWarning = promoExpiryWarning(promos, payDate, pd)
+		options = append(options, o)
+	}
+
+	rankDeferOptions(options)
+	if len(options) > 0 {
+		options[0].Recommended = true
+	}
+
+	result := models.DeferOptions{AssignmentID: id, Amount: roundCents(amount), Options: options}
+	if deadline != nil {
+		s := deadline.Format("2006-01-02")
+		result.NextDueDate = &s
+	}
+	models.WriteJSON(w, http.StatusOK, result)
+}
+
+// rankDeferOptions orders on-time, affordable periods first, then affordable
+// late ones, then the rest; ties go to the larger projected balance, then the
+// earlier pay date.
+func rankDeferOptions(options []models.DeferOption) {
+	tier := func(o models.DeferOption) int {
+		affordable := o.ProjectedRemaining >= 0
+		switch {
+		case affordable && o.PaysBeforeDue:
+			return 0
+		case affordable:
+			return 1
+		case o.PaysBeforeDue:
+			return 2
+		default:
+			return 3
+		}
+	}
+	sort.SliceStable(options, func(i, j int) bool {
+		ti, tj := tier(options[i]), tier(options[j])
+		if ti != tj {
+			return ti < tj
+		}
+		if options[i].ProjectedRemaining != options[j].ProjectedRemaining {
+			return options[i].ProjectedRemaining > options[j].ProjectedRemaining
+		}
+		return options[i].PayDate < options[j].PayDate
+	})
+}
+
+// Pay marks an assignment paid in one call, recording the amount actually paid
+// (defaulting to the planned amount) and the payment date (defaulting to today).
+func (h *AssignmentHandler) Pay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.PayAssignmentRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+			return
+		}
+	}
+
+	if req.ActualAmount != nil && *req.ActualAmount < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "actual_amount must not be negative")
+		return
+	}
+
+	paidDate := time.Now()
+	if req.PaidDate != nil && *req.PaidDate != "" {
+		paidDate, err = time.Parse("2006-01-02", *req.PaidDate)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "paid_date must be in YYYY-MM-DD format")
+			return
+		}
+	}
+	paidDate = time.Date(paidDate.Year(), paidDate.Month(), paidDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Unless told otherwise, the bill's owner is taken to have paid it
+	var a models.BillAssignment
+	err = h.db.QueryRow(ctx, `
+		UPDATE bill_assignments SET
+			status = 'paid',
+			actual_amount = COALESCE($2, actual_amount, planned_amount),
+			paid_date = $3,
+			deferred_to_id = NULL,
+			paid_by = COALESCE($4, NULLIF(paid_by, ''), (SELECT owner FROM bills WHERE id = bill_id), ''),
+			paid_from = COALESCE($5, paid_from),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+assignmentReturnCols+`
+	`, id, req.ActualAmount, paidDate, req.PaidBy, req.PaidFrom,
+	).Scan(assignmentScanDest(&a)...)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found")
+		return
+	}
+
+	h.events.Publish(events.AssignmentPaid, a)
+
 	models.WriteJSON(w, http.StatusOK, a)
 }
 
@@ -223,9 +792,10 @@ func (h *AssignmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get bill_id and pay_period_id before deleting so we can track the deletion
+	// Get the occurrence before deleting so we can track the deletion
 	var billID, periodID int
-	err = h.db.QueryRow(ctx, `SELECT bill_id, pay_period_id FROM bill_assignments WHERE id = $1`, id).Scan(&billID, &periodID)
+	var dueDate *time.Time
+	err = h.db.QueryRow(ctx, `SELECT bill_id, pay_period_id, due_date FROM bill_assignments WHERE id = $1`, id).Scan(&billID, &periodID, &dueDate)
 	if err != nil {
 		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found")
 		return
@@ -240,10 +810,10 @@ func (h *AssignmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	// Track this deletion to prevent auto-assign from recreating it
 	_, _ = h.db.Exec(ctx, `
-		INSERT INTO deleted_bill_periods (bill_id, pay_period_id)
-		VALUES ($1, $2)
-		ON CONFLICT (bill_id, pay_period_id) DO NOTHING
-	`, billID, periodID)
+		INSERT INTO deleted_bill_periods (bill_id, pay_period_id, due_date)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (bill_id, pay_period_id, due_date) DO NOTHING
+	`, billID, periodID, dueDate)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -312,7 +882,8 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 
 	// Get active bills with due_day set
 	billRows, err := h.db.Query(ctx, `
-		SELECT id, name, default_amount, due_day, recurrence, recurrence_detail
+		SELECT id, name, default_amount, due_day, recurrence, recurrence_detail, active_months, monthly_amounts,
+		       buffer_percent
 		FROM bills
 		WHERE is_active = true AND due_day IS NOT NULL
 		ORDER BY id
@@ -329,12 +900,15 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 		DueDay           int
 		Recurrence       string
 		RecurrenceDetail json.RawMessage
+		ActiveMonths     []int
+		MonthlyAmounts   map[int]float64
+		BufferPercent    float64
 	}
 	var bills []billInfo
 	for billRows.Next() {
 		var b billInfo
 		var name string
-		if err := billRows.Scan(&b.ID, &name, &b.DefaultAmount, &b.DueDay, &b.Recurrence, &b.RecurrenceDetail); err != nil {
+		if err := billRows.Scan(&b.ID, &name, &b.DefaultAmount, &b.DueDay, &b.Recurrence, &b.RecurrenceDetail, &b.ActiveMonths, &b.MonthlyAmounts, &b.BufferPercent); err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
 			return
 		}
@@ -397,14 +971,32 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 	// Track which bills have been manually moved (skip them unless force=true)
 	manuallyMovedBills := make(map[billMonth]bool)
 
-	// Track explicitly deleted bill+period combos (never recreate these)
+	// Track occurrences already assigned, and bill+period pairs holding an
+	// assignment with no due date (manual entries or older summed rows)
+	type billDate struct {
+		BillID int
+		Due    time.Time
+	}
+	existingOccurrences := make(map[billDate]bool)
+	undatedPairs := make(map[billPeriod]bool)
+
+	// Track explicitly deleted occurrences (never recreate these). Deletions
+	// recorded without a due date block the whole bill+period.
+	type deletedOccurrence struct {
+		billPeriod
+		Due time.Time
+	}
 	deletedPairs := make(map[billPeriod]bool)
+	deletedOccurrences := make(map[deletedOccurrence]bool)
+	wasDeleted := func(bp billPeriod, due time.Time) bool {
+		return deletedPairs[bp] || deletedOccurrences[deletedOccurrence{bp, due}]
+	}
 
 	// Get today's date for skipping past periods
 	today := time.Now().Truncate(24 * time.Hour)
 
 	existRows, err := h.db.Query(ctx, `
-		SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved
+		SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved, ba.due_date
 		FROM bill_assignments ba
 		JOIN pay_periods pp ON pp.id = ba.pay_period_id
 		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2
@@ -419,10 +1011,16 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 		var billID, periodID int
 		var payDate time.Time
 		var manuallyMoved bool
-		if err := existRows.Scan(&billID, &periodID, &payDate, &manuallyMoved); err != nil {
+		var dueDate *time.Time
+		if err := existRows.Scan(&billID, &periodID, &payDate, &manuallyMoved, &dueDate); err != nil {
 			continue
 		}
 		existingPairs[billPeriod{billID, periodID}] = true
+		if dueDate != nil {
+			existingOccurrences[billDate{billID, *dueDate}] = true
+		} else {
+			undatedPairs[billPeriod{billID, periodID}] = true
+		}
 		bm := billMonth{billID, payDate.Year(), payDate.Month()}
 		existingBillMonths[bm] = true
 		if manuallyMoved {
@@ -430,9 +1028,9 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Fetch deleted bill+period combos in range
+	// Fetch deleted occurrences in range
 	deletedRows, err := h.db.Query(ctx, `
-		SELECT dbp.bill_id, dbp.pay_period_id
+		SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date
 		FROM deleted_bill_periods dbp
 		JOIN pay_periods pp ON pp.id = dbp.pay_period_id
 		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2
@@ -445,10 +1043,70 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 
 	for deletedRows.Next() {
 		var billID, periodID int
-		if err := deletedRows.Scan(&billID, &periodID); err != nil {
+		var dueDate *time.Time
+		if err := deletedRows.Scan(&billID, &periodID, &dueDate); err != nil {
 			continue
 		}
-		deletedPairs[billPeriod{billID, periodID}] = true
+		if dueDate != nil {
+			deletedOccurrences[deletedOccurrence{billPeriod{billID, periodID}, *dueDate}] = true
+		} else {
+			deletedPairs[billPeriod{billID, periodID}] = true
+		}
+	}
+
+	// Fetch months the user explicitly skipped for a bill; nothing is assigned in them
+	skippedMonths := make(map[billMonth]bool)
+	skipRows, err := h.db.Query(ctx, `
+		SELECT bs.bill_id, bs.month FROM bill_skips bs
+		WHERE bs.month >= date_trunc('month', $1::date) AND bs.month <= $2
+	`, req.From, req.To)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer skipRows.Close()
+
+	for skipRows.Next() {
+		var billID int
+		var month time.Time
+		if err := skipRows.Scan(&billID, &month); err != nil {
+			continue
+		}
+		skippedMonths[billMonth{billID, month.Year(), month.Month()}] = true
+	}
+
+	// isSkipped reports whether a bill should not be assigned for the month of due,
+	// either because the user skipped it or because it is out of season.
+	isSkipped := func(bill billInfo, due time.Time) bool {
+		if skippedMonths[billMonth{bill.ID, due.Year(), due.Month()}] {
+			return true
+		}
+		if len(bill.ActiveMonths) == 0 {
+			return false
+		}
+		for _, m := range bill.ActiveMonths {
+			if time.Month(m) == due.Month() {
+				return false
+			}
+		}
+		return true
+	}
+
+	// amountFor returns the amount to plan for a bill in the month of due,
+	// preferring a per-month override over the default amount, plus the
+	// bill's buffer for variable bills
+	amountFor := func(bill billInfo, due time.Time) *float64 {
+		amt, ok := bill.MonthlyAmounts[int(due.Month())]
+		if !ok {
+			if bill.DefaultAmount == nil {
+				return nil
+			}
+			amt = *bill.DefaultAmount
+		}
+		if bill.BufferPercent > 0 {
+			amt = roundCents(amt * (1 + bill.BufferPercent/100))
+		}
+		return &amt
 	}
 
 	// Helper: find the best period for a due date (last period on or before it)
@@ -483,20 +1141,15 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 		return best
 	}
 
-	// Helper: insert a single assignment
-	insertAssignment := func(billID int, periodID int, amount *float64) *models.BillAssignment {
+	// Helper: insert a single assignment for the occurrence due on dueDate
+	insertAssignment := func(billID int, periodID int, amount *float64, dueDate time.Time) *models.BillAssignment {
 		var a models.BillAssignment
 		err := h.db.QueryRow(ctx, `
-			INSERT INTO bill_assignments (bill_id, pay_period_id, planned_amount, status)
-			VALUES ($1, $2, $3, 'pending')
-			ON CONFLICT (bill_id, pay_period_id) DO NOTHING
+			INSERT INTO bill_assignments (bill_id, pay_period_id, planned_amount, status, due_date)
+			VALUES ($1, $2, $3, 'pending', $4)
+			ON CONFLICT (bill_id, pay_period_id, due_date) DO NOTHING
 			RETURNING `+assignmentReturnCols+`
-		`, billID, periodID, amount).Scan(
-			&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount, &a.ForecastAmount,
-			&a.ActualAmount, &a.Status, &a.DeferredToID, &a.IsExtra, &a.ExtraName,
-			&a.Notes, &a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-		&a.CreatedAt, &a.UpdatedAt,
-		)
+		`, billID, periodID, amount, dueDate).Scan(assignmentScanDest(&a)...)
 		if err != nil {
 			return nil // ON CONFLICT DO NOTHING or other error
 		}
@@ -541,35 +1194,23 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 			cur = cur.AddDate(0, 0, 14)
 		}
 
-		// Aggregate amounts per period (multiple occurrences may map to same period)
-		periodAmounts := make(map[int]float64)
-
+		// Each occurrence gets its own assignment, even when two fall in one period
 		for !cur.After(toDate) {
-			idx := findBestPeriod(cur)
+			idx := -1
+			if !isSkipped(bill, cur) {
+				idx = findBestPeriod(cur)
+			}
 			if idx >= 0 {
 				pid := periods[idx].ID
 				bp := billPeriod{bill.ID, pid}
-				if !existingPairs[bp] && !deletedPairs[bp] {
-					amt := 0.0
-					if bill.DefaultAmount != nil {
-						amt = *bill.DefaultAmount
+				if !existingOccurrences[billDate{bill.ID, cur}] && !undatedPairs[bp] && !wasDeleted(bp, cur) {
+					if a := insertAssignment(bill.ID, pid, amountFor(bill, cur), cur); a != nil {
+						created = append(created, *a)
 					}
-					periodAmounts[pid] += amt
 				}
 			}
 			cur = cur.AddDate(0, 0, 14)
 		}
-
-		for pid, amount := range periodAmounts {
-			bp := billPeriod{bill.ID, pid}
-			if deletedPairs[bp] {
-				continue
-			}
-			a := amount
-			if result := insertAssignment(bill.ID, pid, &a); result != nil {
-				created = append(created, *result)
-			}
-		}
 		return true
 	}
 
@@ -592,13 +1233,13 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for !cur.After(toDate) {
-			if !cur.Before(fromDate) {
+			if !cur.Before(fromDate) && !isSkipped(bill, cur) {
 				idx := findBestPeriod(cur)
 				if idx >= 0 {
 					pid := periods[idx].ID
 					bp := billPeriod{bill.ID, pid}
-					if !existingPairs[bp] && !deletedPairs[bp] {
-						if a := insertAssignment(bill.ID, pid, bill.DefaultAmount); a != nil {
+					if !existingPairs[bp] && !wasDeleted(bp, cur) {
+						if a := insertAssignment(bill.ID, pid, amountFor(bill, cur), cur); a != nil {
 							created = append(created, *a)
 						}
 					}
@@ -628,13 +1269,13 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for !cur.After(toDate) {
-			if !cur.Before(fromDate) {
+			if !cur.Before(fromDate) && !isSkipped(bill, cur) {
 				idx := findBestPeriod(cur)
 				if idx >= 0 {
 					pid := periods[idx].ID
 					bp := billPeriod{bill.ID, pid}
-					if !existingPairs[bp] && !deletedPairs[bp] {
-						if a := insertAssignment(bill.ID, pid, bill.DefaultAmount); a != nil {
+					if !existingPairs[bp] && !wasDeleted(bp, cur) {
+						if a := insertAssignment(bill.ID, pid, amountFor(bill, cur), cur); a != nil {
 							created = append(created, *a)
 						}
 					}
@@ -660,17 +1301,19 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
+			// Skip if the user explicitly skipped this bill for the month or it is out of season
+			if isSkipped(bill, current) {
+				current = current.AddDate(0, 1, 0)
+				continue
+			}
+
 			// Skip if this bill was manually moved in this month (unless force)
 			if !req.Force && manuallyMovedBills[bm] {
 				current = current.AddDate(0, 1, 0)
 				continue
 			}
 
-			dueDate := time.Date(year, month, bill.DueDay, 0, 0, 0, 0, time.UTC)
-			lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
-			if bill.DueDay > lastDay {
-				dueDate = time.Date(year, month, lastDay, 0, 0, 0, 0, time.UTC)
-			}
+			dueDate := dueDateIn(year, month, bill.DueDay)
 
 			if dueDate.Before(fromDate) || dueDate.After(toDate) {
 				current = current.AddDate(0, 1, 0)
@@ -681,9 +1324,9 @@ func (h *AssignmentHandler) AutoAssign(w http.ResponseWriter, r *http.Request) {
 			if idx >= 0 {
 				pid := periods[idx].ID
 				bp := billPeriod{bill.ID, pid}
-				// Skip if this bill+period was explicitly deleted
-				if !deletedPairs[bp] {
-					if a := insertAssignment(bill.ID, pid, bill.DefaultAmount); a != nil {
+				// Skip if this occurrence was explicitly deleted
+				if !wasDeleted(bp, dueDate) {
+					if a := insertAssignment(bill.ID, pid, amountFor(bill, dueDate), dueDate); a != nil {
 						created = append(created, *a)
 					}
 				}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// defaultAuditLimit pages the audit log when no ?limit is given; unlike
+// other lists it grows with every change, so it is never returned whole.
+const defaultAuditLimit = 100
+
+// auditEntities are the entity names the audit_log triggers record.
+var auditEntities = map[string]bool{"bill": true, "assignment": true, "period": true, "income_source": true}
+
+// AuditHandler serves the audit log the database triggers write.
+type AuditHandler struct {
+	db DBTX
+}
+
+func NewAuditHandler(db DBTX) *AuditHandler {
+	return &AuditHandler{db: db}
+}
+
+var auditSortFields = map[string]string{
+	"created_at": "created_at",
+	"entity":     "entity",
+	"actor":      "actor",
+}
+
+const auditCols = `id, entity, entity_id, action, actor, before, after, request_id, origin, undone_at, created_at`
+
+// List returns audit entries newest first, filtered by ?entity=, ?entity_id=,
+// ?action=, ?actor= and ?from/?to on the day of the change, paged per
+// parseListParams with a default limit of defaultAuditLimit.
+// GET /api/v1/audit?entity=bill&entity_id=4
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	params, ok := parseListParams(w, r, auditSortFields, "created_at DESC, id DESC")
+	if !ok {
+		return
+	}
+	if q.Get("limit") == "" {
+		params.Limit = defaultAuditLimit
+	}
+
+	where, args, ok := auditFilter(w, r)
+	if !ok {
+		return
+	}
+	query := `
+		SELECT ` + auditCols + `, COUNT(*) OVER ()
+		FROM audit_log
+		WHERE 1=1` + where
+
+	pageQuery, pageArgs := params.apply(query, args)
+	rows, err := h.db.Query(ctx, pageQuery, pageArgs...)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	var total int
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.Actor, &e.Before, &e.After, &e.RequestID, &e.Origin, &e.UndoneAt, &e.CreatedAt, &total); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	total, err = listTotal(ctx, h.db, params, query, args, total, len(entries))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSONList(w, entries, total, params.Limit, params.Offset)
+}
+
+// Export downloads every audit entry matching List's filters, oldest first,
+// as CSV or (the default) a JSON array. Rows are written as they are read
+// rather than paged, so a whole history can be archived in one request.
+// GET /api/v1/audit/export?from=YYYY-MM-DD&to=YYYY-MM-DD&format=csv
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "format must be csv or json")
+		return
+	}
+	where, args, ok := auditFilter(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), `
+		SELECT `+auditCols+`
+		FROM audit_log
+		WHERE 1=1`+where+`
+		ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	filename := "audit-" + time.Now().Format("2006-01-02") + "." + format
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	var write func(models.AuditEntry) error
+	var done func() error
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "created_at", "entity", "entity_id", "action", "actor", "origin", "request_id", "undone_at", "before", "after"})
+		write = func(e models.AuditEntry) error {
+			return cw.Write(auditCSVRecord(e))
+		}
+		done = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(w)
+		sep := "["
+		write = func(e models.AuditEntry) error {
+			if _, err := io.WriteString(w, sep); err != nil {
+				return err
+			}
+			sep = ","
+			return enc.Encode(e)
+		}
+		done = func() error {
+			if sep == "[" {
+				io.WriteString(w, "[")
+			}
+			_, err := io.WriteString(w, "]\n")
+			return err
+		}
+	}
+
+	// The status is already sent, so a failure part way through can only
+	// be logged; the truncated file won't parse
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.Actor, &e.Before, &e.After, &e.RequestID, &e.Origin, &e.UndoneAt, &e.CreatedAt); err != nil {
+			slog.Error("exporting audit log", "error", err)
+			return
+		}
+		if err := write(e); err != nil {
+			slog.Error("exporting audit log", "error", err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("exporting audit log", "error", err)
+		return
+	}
+	if err := done(); err != nil {
+		slog.Error("exporting audit log", "error", err)
+	}
+}
+
+func auditCSVRecord(e models.AuditEntry) []string {
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	undone := ""
+	if e.UndoneAt != nil {
+		undone = e.UndoneAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(e.ID, 10), e.CreatedAt.UTC().Format(time.RFC3339), e.Entity, strconv.Itoa(e.EntityID),
+		e.Action, optional(e.Actor), e.Origin, optional(e.RequestID), undone, string(e.Before), string(e.After),
+	}
+}
+
+// auditFilter turns the ?entity=, ?entity_id=, ?action=, ?actor= and
+// ?from/?to filters List and Export share into AND clauses, writing a
+// validation error and returning false when one is malformed.
+func auditFilter(w http.ResponseWriter, r *http.Request) (string, []interface{}, bool) {
+	q := r.URL.Query()
+	where := ""
+	args := []interface{}{}
+
+	if entity := q.Get("entity"); entity != "" {
+		if !auditEntities[entity] {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "entity must be bill, assignment, period or income_source")
+			return "", nil, false
+		}
+		args = append(args, entity)
+		where += " AND entity = $" + strconv.Itoa(len(args))
+	}
+	if v := q.Get("entity_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "entity_id must be an integer")
+			return "", nil, false
+		}
+		args = append(args, id)
+		where += " AND entity_id = $" + strconv.Itoa(len(args))
+	}
+	if action := q.Get("action"); action != "" {
+		if action != "create" && action != "update" && action != "delete" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "action must be create, update or delete")
+			return "", nil, false
+		}
+		args = append(args, action)
+		where += " AND action = $" + strconv.Itoa(len(args))
+	}
+	if actor := q.Get("actor"); actor != "" {
+		args = append(args, actor)
+		where += " AND actor = $" + strconv.Itoa(len(args))
+	}
+	if q.Get("from") != "" {
+		from, ok := dateQueryParam(w, r, "from", time.Time{})
+		if !ok {
+			return "", nil, false
+		}
+		args = append(args, from)
+		where += " AND created_at >= $" + strconv.Itoa(len(args))
+	}
+	if q.Get("to") != "" {
+		to, ok := dateQueryParam(w, r, "to", time.Time{})
+		if !ok {
+			return "", nil, false
+		}
+		// Through the end of that day
+		args = append(args, to.AddDate(0, 0, 1))
+		where += " AND created_at < $" + strconv.Itoa(len(args))
+	}
+	return where, args, true
+}
@@ -1,22 +1,149 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
 	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/jackc/pgx/v5"
 )
 
-const tokenExpiry = 24 * time.Hour
+const (
+	// accessTokenExpiry bounds how long a revoked role or deleted user keeps
+	// working for stateless checks; the refresh token renews it.
+	accessTokenExpiry  = 15 * time.Minute
+	refreshTokenExpiry = 30 * 24 * time.Hour
+)
 
 type AuthHandler struct {
+	db  DBTX
 	cfg *config.Config
 }
 
-func NewAuthHandler(cfg *config.Config) *AuthHandler {
-	return &AuthHandler{cfg: cfg}
+func NewAuthHandler(db DBTX, cfg *config.Config) *AuthHandler {
+	return &AuthHandler{db: db, cfg: cfg}
+}
+
+// errInvalidCredentials is returned by authenticate for an unknown user or a
+// wrong password, which are not told apart.
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// authenticate checks a username and password and returns the user's role.
+// The administrator configured by AUTH_USERNAME is checked first, then the
+// users table.
+func (h *AuthHandler) authenticate(ctx context.Context, username, password string) (auth.Role, error) {
+	if username == h.cfg.AuthUsername {
+		if err := auth.VerifyPassword(h.cfg.AuthPasswordHash, password); err != nil {
+			return "", errInvalidCredentials
+		}
+		return auth.RoleAdmin, nil
+	}
+
+	var hash, role string
+	err := h.db.QueryRow(ctx, `SELECT password_hash, role FROM users WHERE username = $1`, username).Scan(&hash, &role)
+	if err == pgx.ErrNoRows {
+		return "", errInvalidCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := auth.VerifyPassword(hash, password); err != nil {
+		return "", errInvalidCredentials
+	}
+	parsed, ok := auth.ParseRole(role)
+	if !ok {
+		return "", errInvalidCredentials
+	}
+	return parsed, nil
+}
+
+// currentRole looks up a user's role again, so a refresh picks up role
+// changes and fails for deleted users.
+func currentRole(ctx context.Context, db DBTX, cfg *config.Config, username string) (auth.Role, error) {
+	if username == cfg.AuthUsername {
+		return auth.RoleAdmin, nil
+	}
+	var role string
+	err := db.QueryRow(ctx, `SELECT role FROM users WHERE username = $1`, username).Scan(&role)
+	if err == pgx.ErrNoRows {
+		return "", errInvalidCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+	parsed, ok := auth.ParseRole(role)
+	if !ok {
+		return "", errInvalidCredentials
+	}
+	return parsed, nil
+}
+
+// insertRefreshToken stores a new refresh token for username and returns it
+// with its id.
+func insertRefreshToken(ctx context.Context, db DBTX, username string) (string, int, time.Time, error) {
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return "", 0, time.Time{}, err
+	}
+	exp := time.Now().Add(refreshTokenExpiry)
+	var id int
+	err = db.QueryRow(ctx, `
+		INSERT INTO refresh_tokens (token_hash, username, expires_at) VALUES ($1, $2, $3) RETURNING id
+	`, hash, username, exp).Scan(&id)
+	if err != nil {
+		return "", 0, time.Time{}, err
+	}
+	return token, id, exp, nil
+}
+
+// setSessionCookies hands out an access token for session and the refresh
+// token it came from.
+func (h *AuthHandler) setSessionCookies(w http.ResponseWriter, r *http.Request, session auth.Session, refresh string, refreshExp time.Time) error {
+	token, exp, err := auth.CreateToken(h.cfg.JWTSecret, session, accessTokenExpiry)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  exp,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   r.TLS != nil,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.RefreshCookieName,
+		Value:    refresh,
+		Path:     "/api/v1/auth",
+		Expires:  refreshExp,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   r.TLS != nil,
+	})
+	return nil
+}
+
+func clearSessionCookies(w http.ResponseWriter, r *http.Request) {
+	for _, c := range []struct{ name, path string }{
+		{auth.CookieName, "/"},
+		{auth.RefreshCookieName, "/api/v1/auth"},
+	} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     c.name,
+			Value:    "",
+			Path:     c.path,
+			MaxAge:   -1,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   r.TLS != nil,
+		})
+	}
 }
 
 type loginRequest struct {
@@ -51,21 +178,26 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify credentials
-	if req.Username != h.cfg.AuthUsername {
+	role, err := h.authenticate(r.Context(), req.Username, req.Password)
+	if err == errInvalidCredentials {
 		writeJSON(w, http.StatusUnauthorized, map[string]any{
 			"error": map[string]string{"message": "invalid credentials"},
 		})
 		return
 	}
-	if err := auth.VerifyPassword(h.cfg.AuthPasswordHash, req.Password); err != nil {
-		writeJSON(w, http.StatusUnauthorized, map[string]any{
-			"error": map[string]string{"message": "invalid credentials"},
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": map[string]string{"message": "failed to check credentials"},
 		})
 		return
 	}
 
-	// Create JWT
-	token, exp, err := auth.CreateToken(h.cfg.JWTSecret, req.Username, tokenExpiry)
+	// Start a session: a refresh token in the database and an access token
+	// naming it
+	refresh, sessionID, refreshExp, err := insertRefreshToken(r.Context(), h.db, req.Username)
+	if err == nil {
+		err = h.setSessionCookies(w, r, auth.Session{ID: sessionID, Username: req.Username, Role: role}, refresh, refreshExp)
+	}
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]any{
 			"error": map[string]string{"message": "failed to create token"},
@@ -73,31 +205,161 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     auth.CookieName,
-		Value:    token,
-		Path:     "/",
-		Expires:  exp,
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-		Secure:   r.TLS != nil,
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data": map[string]any{"authenticated": true, "role": role},
 	})
+}
 
+// Refresh swaps the refresh token cookie for a new one and a fresh access
+// token. Each refresh token works once: presenting one that was already
+// swapped means it was stolen or replayed, so every session of that user is
+// revoked.
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	unauthorized := func() {
+		clearSessionCookies(w, r)
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"error": map[string]string{"message": "session expired"},
+		})
+	}
+	failed := func(err error) {
+		slog.Error("refreshing session", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": map[string]string{"message": "failed to refresh session"},
+		})
+	}
+
+	cookie, err := r.Cookie(auth.RefreshCookieName)
+	if err != nil || cookie.Value == "" {
+		unauthorized()
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		failed(err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	var username string
+	var expiresAt time.Time
+	var revokedAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT id, username, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE
+	`, auth.HashRefreshToken(cookie.Value)).Scan(&id, &username, &expiresAt, &revokedAt)
+	if err == pgx.ErrNoRows {
+		unauthorized()
+		return
+	}
+	if err != nil {
+		failed(err)
+		return
+	}
+	if revokedAt != nil {
+		slog.Warn("revoked refresh token reused; revoking every session of the user", "user", username)
+		if err := revokeUserSessions(ctx, tx, username); err != nil {
+			failed(err)
+			return
+		}
+		if err := tx.Commit(ctx); err != nil {
+			failed(err)
+			return
+		}
+		unauthorized()
+		return
+	}
+	if time.Now().After(expiresAt) {
+		unauthorized()
+		return
+	}
+
+	role, err := currentRole(ctx, tx, h.cfg, username)
+	if err == errInvalidCredentials {
+		// The user was deleted
+		if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1`, id); err == nil {
+			tx.Commit(ctx)
+		}
+		unauthorized()
+		return
+	}
+	if err != nil {
+		failed(err)
+		return
+	}
+
+	refresh, newID, refreshExp, err := insertRefreshToken(ctx, tx, username)
+	if err != nil {
+		failed(err)
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = $2 WHERE id = $1`, id, newID); err != nil {
+		failed(err)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		failed(err)
+		return
+	}
+
+	if err := h.setSessionCookies(w, r, auth.Session{ID: newID, Username: username, Role: role}, refresh, refreshExp); err != nil {
+		failed(err)
+		return
+	}
 	writeJSON(w, http.StatusOK, map[string]any{
-		"data": map[string]any{"authenticated": true},
+		"data": map[string]any{"authenticated": true, "role": role},
 	})
 }
 
+// revokeUserSessions ends every session of username, including access
+// tokens from refresh tokens that were already swapped.
+func revokeUserSessions(ctx context.Context, db DBTX, username string) error {
+	_, err := db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = COALESCE(revoked_at, NOW()), replaced_by = NULL WHERE username = $1
+	`, username)
+	return err
+}
+
+// SessionRevoked is the auth middleware's revocation check. A session is
+// revoked when its refresh token was revoked outright (logout, reuse, or the
+// user being deleted); access tokens from a token that was merely swapped
+// for a newer one run out on their own. A missing token counts as revoked.
+func (h *AuthHandler) SessionRevoked(ctx context.Context, sessionID int) (bool, error) {
+	var revoked bool
+	err := h.db.QueryRow(ctx, `
+		SELECT revoked_at IS NOT NULL AND replaced_by IS NULL FROM refresh_tokens WHERE id = $1
+	`, sessionID).Scan(&revoked)
+	if err == pgx.ErrNoRows {
+		return true, nil
+	}
+	return revoked, err
+}
+
+// Logout revokes the session's refresh token, so neither it nor the access
+// tokens issued from it work any more, and clears the cookies.
+// POST /api/v1/auth/logout
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     auth.CookieName,
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-		Secure:   r.TLS != nil,
-	})
+	ctx := r.Context()
+	if cookie, err := r.Cookie(auth.RefreshCookieName); err == nil && cookie.Value != "" {
+		if _, err := h.db.Exec(ctx, `
+			UPDATE refresh_tokens SET revoked_at = NOW() WHERE token_hash = $1 AND revoked_at IS NULL
+		`, auth.HashRefreshToken(cookie.Value)); err != nil {
+			slog.Error("revoking refresh token", "error", err)
+		}
+	}
+	// The access token may outlive a refresh cookie the browser dropped
+	if cookie, err := r.Cookie(auth.CookieName); err == nil {
+		if session, err := auth.ValidateToken(h.cfg.JWTSecret, cookie.Value); err == nil && session.ID != 0 {
+			if _, err := h.db.Exec(ctx, `
+				UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = NULL WHERE id = $1
+			`, session.ID); err != nil {
+				slog.Error("revoking session", "session", session.ID, "error", err)
+			}
+		}
+	}
+	clearSessionCookies(w, r)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"data": map[string]any{"authenticated": false},
@@ -107,7 +369,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Status(w http.ResponseWriter, r *http.Request) {
 	if !h.cfg.AuthEnabled() {
 		writeJSON(w, http.StatusOK, map[string]any{
-			"data": map[string]any{"authenticated": true, "authRequired": false},
+			"data": map[string]any{"authenticated": true, "authRequired": false, "role": auth.RoleAdmin},
 		})
 		return
 	}
@@ -120,7 +382,8 @@ func (h *AuthHandler) Status(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := auth.ValidateToken(h.cfg.JWTSecret, cookie.Value); err != nil {
+	session, err := auth.ValidateToken(h.cfg.JWTSecret, cookie.Value)
+	if err != nil {
 		writeJSON(w, http.StatusOK, map[string]any{
 			"data": map[string]any{"authenticated": false, "authRequired": true},
 		})
@@ -128,7 +391,7 @@ func (h *AuthHandler) Status(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
-		"data": map[string]any{"authenticated": true, "authRequired": true},
+		"data": map[string]any{"authenticated": true, "authRequired": true, "username": session.Username, "role": session.Role},
 	})
 }
 
@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// backupTable is one table in a backup. Tables are listed parents first, so
+// restoring in order never inserts a row before the rows it references.
+type backupTable struct {
+	name     string
+	refs     map[string]string // foreign key column -> referenced table
+	match    []string          // columns identifying an existing row when merging
+	optional []string          // refs a member export clears, rather than dropping the row, when their target is left out
+}
+
+var backupTables = []backupTable{
+	{name: "categories", match: []string{"name"}},
+	{name: "income_sources", match: []string{"name"}},
+	{name: "payroll_calendar_dates", refs: map[string]string{"income_source_id": "income_sources"}, match: []string{"income_source_id", "pay_date"}},
+	{name: "bills", match: []string{"name"}},
+	{name: "credit_cards", refs: map[string]string{"bill_id": "bills"}, match: []string{"bill_id", "card_label", "issuer"}},
+	{name: "credit_card_promos", refs: map[string]string{"credit_card_id": "credit_cards"}, match: []string{"credit_card_id", "expires_on", "description"}},
+	{name: "bill_skips", refs: map[string]string{"bill_id": "bills"}, match: []string{"bill_id", "month"}},
+	{name: "pay_periods", refs: map[string]string{"income_source_id": "income_sources"}, match: []string{"income_source_id", "pay_date"}},
+	{name: "income_events", refs: map[string]string{"income_source_id": "income_sources", "pay_period_id": "pay_periods"}, match: []string{"name", "event_date"}, optional: []string{"pay_period_id"}},
+	{name: "period_checklist_items", refs: map[string]string{"pay_period_id": "pay_periods"}, match: []string{"pay_period_id", "label"}},
+	{name: "deleted_bill_periods", refs: map[string]string{"bill_id": "bills", "pay_period_id": "pay_periods"}, match: []string{"bill_id", "pay_period_id", "due_date"}},
+	{name: "bill_assignments", refs: map[string]string{
+		"bill_id":                    "bills",
+		"pay_period_id":              "pay_periods",
+		"deferred_to_id":             "pay_periods",
+		"sinking_fund_for_period_id": "pay_periods",
+	}, match: []string{"bill_id", "pay_period_id", "due_date"}, optional: []string{"deferred_to_id", "sinking_fund_for_period_id"}},
+	{name: "assignment_disputes", refs: map[string]string{"assignment_id": "bill_assignments"}, match: []string{"assignment_id"}},
+	{name: "transactions", refs: map[string]string{"assignment_id": "bill_assignments"}, match: []string{"txn_date", "amount", "description"}},
+	{name: "documents", match: []string{"title", "filename", "size_bytes"}},
+	{name: "document_bills", refs: map[string]string{"document_id": "documents", "bill_id": "bills"}, match: []string{"document_id", "bill_id"}},
+}
+
+const (
+	backupMerge   = "merge"
+	backupReplace = "replace"
+)
+
+type BackupHandler struct {
+	db DBTX
+}
+
+func NewBackupHandler(db DBTX) *BackupHandler {
+	return &BackupHandler{db: db}
+}
+
+// Export returns every budget table as one JSON document. With ?member= it
+// returns only that household member's part: see partitionBackup.
+// GET /api/v1/export
+func (h *BackupHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	member := strings.TrimSpace(r.URL.Query().Get("member"))
+
+	parts := make([]string, len(backupTables))
+	for i, t := range backupTables {
+		ident := pgx.Identifier{t.name}.Sanitize()
+		parts[i] = fmt.Sprintf(`'%s', (SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.id), '[]'::jsonb) FROM %s t)`, t.name, ident)
+	}
+
+	var raw []byte
+	if err := h.db.QueryRow(ctx, `SELECT jsonb_build_object(`+strings.Join(parts, ", ")+`)`).Scan(&raw); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	backup := models.Backup{Version: models.BackupVersion, ExportedAt: time.Now().UTC()}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&backup.Tables); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("budget-backup-%s.json", backup.ExportedAt.Format("2006-01-02"))
+	if member != "" {
+		var owned bool
+		backup.Tables, owned = partitionBackup(backup.Tables, member)
+		if !owned {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "no income sources or bills belong to "+member)
+			return
+		}
+		filename = fmt.Sprintf("budget-backup-%s-%s.json", memberSlug(member), backup.ExportedAt.Format("2006-01-02"))
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	models.WriteJSON(w, http.StatusOK, backup)
+}
+
+// memberOwnedTables are the tables with an owner column. Everything else in a
+// member export hangs off their rows.
+var memberOwnedTables = map[string]bool{"income_sources": true, "bills": true}
+
+// partitionBackup keeps only the rows belonging to member: the income sources
+// and bills they own, and the rows whose required references all lead back to
+// those (pay periods, assignments, transactions and so on). Categories are
+// kept whole, and documents only when tagged to one of the member's bills. An optional reference to a row that was left out is cleared, so
+// the result still restores cleanly. The bool reports whether member owns
+// anything at all.
+func partitionBackup(tables map[string][]models.BackupRow, member string) (map[string][]models.BackupRow, bool) {
+	out := make(map[string][]models.BackupRow, len(tables))
+	kept := make(map[string]map[string]bool, len(backupTables))
+	owned := false
+
+	for _, t := range backupTables {
+		optional := make(map[string]bool, len(t.optional))
+		for _, col := range t.optional {
+			optional[col] = true
+		}
+
+		ids := map[string]bool{}
+		rows := []models.BackupRow{}
+	rowLoop:
+		for _, row := range tables[t.name] {
+			if memberOwnedTables[t.name] && row["owner"] != member {
+				continue
+			}
+			row = maps.Clone(row)
+			for col, ref := range t.refs {
+				if row[col] == nil {
+					if !optional[col] {
+						continue rowLoop
+					}
+					continue
+				}
+				if kept[ref][fmt.Sprint(row[col])] {
+					continue
+				}
+				if !optional[col] {
+					continue rowLoop
+				}
+				row[col] = nil
+			}
+			ids[fmt.Sprint(row["id"])] = true
+			rows = append(rows, row)
+		}
+
+		if memberOwnedTables[t.name] && len(rows) > 0 {
+			owned = true
+		}
+		kept[t.name] = ids
+		out[t.name] = rows
+	}
+
+	tagged := make(map[string]bool)
+	for _, row := range out["document_bills"] {
+		tagged[fmt.Sprint(row["document_id"])] = true
+	}
+	docs := []models.BackupRow{}
+	for _, row := range out["documents"] {
+		if tagged[fmt.Sprint(row["id"])] {
+			docs = append(docs, row)
+		}
+	}
+	out["documents"] = docs
+	return out, owned
+}
+
+// memberSlug makes a member name safe to put in a download filename.
+func memberSlug(member string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, member)
+}
+
+// invalidBackupError is a problem with the backup's contents rather than the
+// database, reported as a 400.
+type invalidBackupError struct {
+	msg string
+}
+
+func (e *invalidBackupError) Error() string { return e.msg }
+
+// Restore loads a backup in a single transaction. strategy=merge (the
+// default) keeps existing data, matches backup rows to existing ones by
+// natural key and inserts the rest under new ids. strategy=replace deletes
+// everything first and restores the backup's ids as-is.
+// POST /api/v1/import/backup
+func (h *BackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	strategy := r.URL.Query().Get("strategy")
+	if strategy == "" {
+		strategy = backupMerge
+	}
+	if strategy != backupMerge && strategy != backupReplace {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "strategy must be merge or replace")
+		return
+	}
+
+	var req models.Backup
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Version != models.BackupVersion {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR",
+			fmt.Sprintf("unsupported backup version %d, expected %d", req.Version, models.BackupVersion))
+		return
+	}
+	known := make(map[string]bool, len(backupTables))
+	for _, t := range backupTables {
+		known[t.name] = true
+	}
+	for name := range req.Tables {
+		if !known[name] {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("unknown table %q", name))
+			return
+		}
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := restoreBackup(ctx, tx, req, strategy)
+	if err != nil {
+		var invalid *invalidBackupError
+		if errors.As(err, &invalid) {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", invalid.msg)
+			return
+		}
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
+
+func restoreBackup(ctx context.Context, tx pgx.Tx, req models.Backup, strategy string) (models.BackupRestoreResult, error) {
+	result := models.BackupRestoreResult{Strategy: strategy, Tables: map[string]models.BackupTableResult{}}
+
+	names := make([]string, len(backupTables))
+	for i, t := range backupTables {
+		names[i] = t.name
+	}
+
+	// Only columns that exist here are restored, which also keeps the
+	// identifiers spliced into the SQL below to real column names
+	columns := make(map[string]map[string]bool)
+	rows, err := tx.Query(ctx, `
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ANY($1)
+	`, names)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return result, err
+		}
+		if columns[table] == nil {
+			columns[table] = map[string]bool{}
+		}
+		columns[table][column] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	// A backup from before the document vault has no documents. Replacing
+	// from one keeps the vault; deleting the bills drops its bill tags, so
+	// they are noted first and put back on the restored bills of the same
+	// name.
+	_, hasDocuments := req.Tables["documents"]
+	keepVault := strategy == backupReplace && !hasDocuments
+	var taggedDocs []int
+	var taggedBills []string
+	if keepVault {
+		rows, err := tx.Query(ctx, `SELECT db.document_id, b.name FROM document_bills db JOIN bills b ON b.id = db.bill_id`)
+		if err != nil {
+			return result, err
+		}
+		for rows.Next() {
+			var docID int
+			var bill string
+			if err := rows.Scan(&docID, &bill); err != nil {
+				rows.Close()
+				return result, err
+			}
+			taggedDocs = append(taggedDocs, docID)
+			taggedBills = append(taggedBills, bill)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return result, err
+		}
+	}
+
+	if strategy == backupReplace {
+		for i := len(backupTables) - 1; i >= 0; i-- {
+			name := backupTables[i].name
+			if keepVault && (name == "documents" || name == "document_bills") {
+				continue
+			}
+			if _, err := tx.Exec(ctx, `DELETE FROM `+pgx.Identifier{name}.Sanitize()); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	// ids maps each table's backup ids to the ids the rows ended up with
+	ids := make(map[string]map[string]int64, len(backupTables))
+	for _, t := range backupTables {
+		ids[t.name] = map[string]int64{}
+		counts := models.BackupTableResult{}
+		ident := pgx.Identifier{t.name}.Sanitize()
+
+		for i, row := range req.Tables[t.name] {
+			oldID := fmt.Sprint(row["id"])
+
+			cols := make([]string, 0, len(row))
+			values := make(map[string]any, len(row))
+			for col, v := range row {
+				if !columns[t.name][col] || (col == "id" && strategy == backupMerge) {
+					continue
+				}
+				if parent, ok := t.refs[col]; ok && v != nil && strategy == backupMerge {
+					newID, ok := ids[parent][fmt.Sprint(v)]
+					if !ok {
+						return result, &invalidBackupError{fmt.Sprintf("%s[%d]: %s %v is not in the backup's %s", t.name, i, col, v, parent)}
+					}
+					v = newID
+				}
+				cols = append(cols, col)
+				values[col] = v
+			}
+			if len(cols) == 0 {
+				return result, &invalidBackupError{fmt.Sprintf("%s[%d]: no known columns", t.name, i)}
+			}
+			sort.Strings(cols)
+
+			record, err := json.Marshal(values)
+			if err != nil {
+				return result, err
+			}
+
+			if strategy == backupMerge {
+				conds := make([]string, len(t.match))
+				for j, col := range t.match {
+					c := pgx.Identifier{col}.Sanitize()
+					conds[j] = fmt.Sprintf("t.%s IS NOT DISTINCT FROM r.%s", c, c)
+				}
+				var existing int64
+				err := tx.QueryRow(ctx, fmt.Sprintf(`
+					SELECT t.id FROM %s t, jsonb_populate_record(NULL::%s, $1::jsonb) r
+					WHERE %s ORDER BY t.id LIMIT 1
+				`, ident, ident, strings.Join(conds, " AND ")), string(record)).Scan(&existing)
+				if err == nil {
+					ids[t.name][oldID] = existing
+					counts.Skipped++
+					continue
+				}
+				if !errors.Is(err, pgx.ErrNoRows) {
+					return result, err
+				}
+			}
+
+			quoted := make([]string, len(cols))
+			for j, col := range cols {
+				quoted[j] = pgx.Identifier{col}.Sanitize()
+			}
+			colList := strings.Join(quoted, ", ")
+			var newID int64
+			err = tx.QueryRow(ctx, fmt.Sprintf(`
+				INSERT INTO %s (%s) SELECT %s FROM jsonb_populate_record(NULL::%s, $1::jsonb)
+				RETURNING id
+			`, ident, colList, colList, ident), string(record)).Scan(&newID)
+			if err != nil {
+				return result, fmt.Errorf("%s[%d]: %w", t.name, i, err)
+			}
+			ids[t.name][oldID] = newID
+			counts.Imported++
+		}
+
+		if strategy == backupReplace {
+			// Restored ids bypass the sequence, so move it past them
+			_, err := tx.Exec(ctx, fmt.Sprintf(`
+				SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)
+			`, t.name, ident))
+			if err != nil {
+				return result, err
+			}
+		}
+		result.Tables[t.name] = counts
+	}
+
+	if len(taggedDocs) > 0 {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO document_bills (document_id, bill_id)
+			SELECT t.document_id, b.id FROM unnest($1::int[], $2::text[]) AS t(document_id, name)
+			JOIN bills b ON b.name = t.name
+			ON CONFLICT DO NOTHING
+		`, taggedDocs, taggedBills)
+		if err != nil {
+			return result, err
+		}
+		result.Tables["document_bills"] = models.BackupTableResult{Imported: int(tag.RowsAffected())}
+	}
+
+	return result, nil
+}
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
 	"github.com/izz-linux/budget-mgmt/backend/internal/models"
 )
 
@@ -17,25 +23,155 @@ func NewBillHandler(db DBTX) *BillHandler {
 	return &BillHandler{db: db}
 }
 
+// billSelectCols is the standard set of bill columns, prefixed for queries that alias bills as b.
+const billSelectCols = `b.id, b.name, b.default_amount, b.due_day, b.recurrence,
+		       b.recurrence_detail, b.is_autopay, COALESCE(b.category, ''), COALESCE(b.notes, ''),
+		       b.is_active, b.sort_order, b.sinking_fund_enabled, b.sinking_fund_periods,
+		       COALESCE(b.shared_with, ''), b.shared_percent,
+		       b.bill_type, COALESCE(b.dependent, ''), b.tax_deductible, b.active_months, b.monthly_amounts,
+		       b.owner, b.portal_url, b.username_hint, b.password_rotated_at, b.buffer_percent,
+		       b.created_at, b.updated_at`
+
+const billReturnCols = `id, name, default_amount, due_day, recurrence, recurrence_detail,
+		          is_autopay, COALESCE(category, ''), COALESCE(notes, ''), is_active, sort_order,
+		          sinking_fund_enabled, sinking_fund_periods,
+		          COALESCE(shared_with, ''), shared_percent,
+		          bill_type, COALESCE(dependent, ''), tax_deductible, active_months, monthly_amounts,
+		          owner, portal_url, username_hint, password_rotated_at, buffer_percent,
+		          created_at, updated_at`
+
+// billScanDest returns scan destinations matching billSelectCols / billReturnCols,
+// so callers can append joined columns before scanning.
+func billScanDest(b *models.Bill) []interface{} {
+	return []interface{}{
+		&b.ID, &b.Name, &b.DefaultAmount, &b.DueDay, &b.Recurrence,
+		&b.RecurrenceDetail, &b.IsAutopay, &b.Category, &b.Notes,
+		&b.IsActive, &b.SortOrder, &b.SinkingFundEnabled, &b.SinkingFundPeriods,
+		&b.SharedWith, &b.SharedPercent,
+		&b.BillType, &b.Dependent, &b.TaxDeductible, &b.ActiveMonths, &b.MonthlyAmounts,
+		&b.Owner, &b.PortalURL, &b.UsernameHint, &b.PasswordRotatedAt, &b.BufferPercent, &b.CreatedAt, &b.UpdatedAt,
+	}
+}
+
+func scanBill(scanner interface{ Scan(dest ...interface{}) error }, b *models.Bill) error {
+	return scanner.Scan(billScanDest(b)...)
+}
+
+var validBillTypes = map[string]bool{"bill": true, "allowance": true}
+
+// validateSharedPercent checks that a cost-sharing percentage is within 0-100.
+func validateSharedPercent(pct *float64) bool {
+	return pct == nil || (*pct >= 0 && *pct <= 100)
+}
+
+// validateBufferPercent checks a planning buffer is a percentage, 0-100.
+func validateBufferPercent(pct *float64) bool {
+	return pct == nil || (*pct >= 0 && *pct <= 100)
+}
+
+// validateActiveMonths checks that a seasonal months mask only holds months 1-12.
+func validateActiveMonths(months []int) bool {
+	for _, m := range months {
+		if m < 1 || m > 12 {
+			return false
+		}
+	}
+	return true
+}
+
+// validateMonthlyAmounts checks that per-month amounts are keyed by months 1-12
+// and are not negative.
+func validateMonthlyAmounts(amounts map[int]float64) bool {
+	for m, amt := range amounts {
+		if m < 1 || m > 12 || amt < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// validatePortalURL checks that a vendor portal is an http or https link, so
+// it is safe to render as one.
+func validatePortalURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// parseRotationDate parses an optional YYYY-MM-DD password rotation date,
+// returning nil for nil or "".
+func parseRotationDate(s *string) (*time.Time, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	d, err := time.Parse("2006-01-02", *s)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// hideVendorLogin clears a bill's vendor login details unless the caller is
+// a signed-in user who may edit bills. Viewers have no use for them, and API
+// keys are editors only in what their scopes let them change.
+func hideVendorLogin(ctx context.Context, b *models.Bill) {
+	if auth.CurrentRole(ctx).Allows(auth.RoleEditor) && !auth.IsAPIKey(ctx) {
+		return
+	}
+	b.PortalURL = ""
+	b.UsernameHint = ""
+	b.PasswordRotatedAt = nil
+}
+
+// billSortFields are the fields Bills List accepts in ?sort=.
+var billSortFields = map[string]string{
+	"name":           "b.name",
+	"due_day":        "b.due_day",
+	"default_amount": "b.default_amount",
+	"category":       "b.category",
+	"created_at":     "b.created_at",
+}
+
+// List returns bills, optionally filtered by ?active=true, ?category= and
+// ?autopay=true|false, sorted and paged per parseListParams.
 func (h *BillHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	activeOnly := r.URL.Query().Get("active") == "true"
+	q := r.URL.Query()
+
+	params, ok := parseListParams(w, r, billSortFields, "b.sort_order, b.id")
+	if !ok {
+		return
+	}
 
 	query := `
-		SELECT b.id, b.name, b.default_amount, b.due_day, b.recurrence,
-		       b.recurrence_detail, b.is_autopay, COALESCE(b.category, ''), COALESCE(b.notes, ''),
-		       b.is_active, b.sort_order, b.sinking_fund_enabled, b.sinking_fund_periods,
-		       b.created_at, b.updated_at,
-		       cc.id, cc.card_label, cc.statement_day, cc.due_day, cc.issuer, cc.created_at
+		SELECT ` + billSelectCols + `,
+		       cc.id, cc.card_label, cc.statement_day, cc.due_day, cc.issuer, cc.created_at,
+		       COUNT(*) OVER ()
 		FROM bills b
 		LEFT JOIN credit_cards cc ON cc.bill_id = b.id
 	`
-	if activeOnly {
-		query += " WHERE b.is_active = true"
+	var where []string
+	args := []interface{}{}
+
+	if q.Get("active") == "true" {
+		where = append(where, "b.is_active = true")
+	}
+	if category := q.Get("category"); category != "" {
+		args = append(args, category)
+		where = append(where, "b.category = $"+strconv.Itoa(len(args)))
+	}
+	if autopay := q.Get("autopay"); autopay != "" {
+		args = append(args, autopay == "true")
+		where = append(where, "b.is_autopay = $"+strconv.Itoa(len(args)))
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
 	}
-	query += " ORDER BY b.sort_order, b.id"
 
-	rows, err := h.db.Query(ctx, query)
+	pageQuery, pageArgs := params.apply(query, args)
+	rows, err := h.db.Query(ctx, pageQuery, pageArgs...)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
@@ -43,6 +179,7 @@ func (h *BillHandler) List(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	var bills []models.Bill
+	var total int
 	for rows.Next() {
 		var b models.Bill
 		var ccID *int
@@ -50,13 +187,9 @@ func (h *BillHandler) List(w http.ResponseWriter, r *http.Request) {
 		var ccStatementDay, ccDueDay *int
 		var ccCreatedAt *interface{}
 
-		err := rows.Scan(
-			&b.ID, &b.Name, &b.DefaultAmount, &b.DueDay, &b.Recurrence,
-			&b.RecurrenceDetail, &b.IsAutopay, &b.Category, &b.Notes,
-			&b.IsActive, &b.SortOrder, &b.SinkingFundEnabled, &b.SinkingFundPeriods,
-			&b.CreatedAt, &b.UpdatedAt,
-			&ccID, &ccLabel, &ccStatementDay, &ccDueDay, &ccIssuer, &ccCreatedAt,
-		)
+		err := rows.Scan(append(billScanDest(&b),
+			&ccID, &ccLabel, &ccStatementDay, &ccDueDay, &ccIssuer, &ccCreatedAt, &total,
+		)...)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
 			return
@@ -64,7 +197,7 @@ func (h *BillHandler) List(w http.ResponseWriter, r *http.Request) {
 		if ccID != nil {
 			b.CreditCard = &models.CreditCard{
 				ID:           *ccID,
-				BillID:       b.ID,
+				BillID:       &b.ID,
 				StatementDay: *ccStatementDay,
 				DueDay:       *ccDueDay,
 			}
@@ -75,13 +208,21 @@ func (h *BillHandler) List(w http.ResponseWriter, r *http.Request) {
 				b.CreditCard.Issuer = *ccIssuer
 			}
 		}
+		hideVendorLogin(ctx, &b)
 		bills = append(bills, b)
 	}
+	rows.Close()
+
+	total, err = listTotal(ctx, h.db, params, query, args, total, len(bills))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
 
 	if bills == nil {
 		bills = []models.Bill{}
 	}
-	models.WriteJSON(w, http.StatusOK, bills)
+	models.WriteJSONList(w, bills, total, params.Limit, params.Offset)
 }
 
 func (h *BillHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -93,17 +234,10 @@ func (h *BillHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var b models.Bill
-	err = h.db.QueryRow(ctx, `
-		SELECT id, name, default_amount, due_day, recurrence, recurrence_detail,
-		       is_autopay, COALESCE(category, ''), COALESCE(notes, ''), is_active, sort_order,
-		       sinking_fund_enabled, sinking_fund_periods, created_at, updated_at
+	err = scanBill(h.db.QueryRow(ctx, `
+		SELECT `+billReturnCols+`
 		FROM bills WHERE id = $1
-	`, id).Scan(
-		&b.ID, &b.Name, &b.DefaultAmount, &b.DueDay, &b.Recurrence,
-		&b.RecurrenceDetail, &b.IsAutopay, &b.Category, &b.Notes,
-		&b.IsActive, &b.SortOrder, &b.SinkingFundEnabled, &b.SinkingFundPeriods,
-		&b.CreatedAt, &b.UpdatedAt,
-	)
+	`, id), &b)
 	if err != nil {
 		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "bill not found")
 		return
@@ -111,13 +245,14 @@ func (h *BillHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	// Check for credit card
 	var cc models.CreditCard
-	err = h.db.QueryRow(ctx, `
-		SELECT id, bill_id, card_label, statement_day, due_day, issuer, created_at
+	err = scanCreditCard(h.db.QueryRow(ctx, `
+		SELECT `+creditCardReturnCols+`
 		FROM credit_cards WHERE bill_id = $1
-	`, id).Scan(&cc.ID, &cc.BillID, &cc.CardLabel, &cc.StatementDay, &cc.DueDay, &cc.Issuer, &cc.CreatedAt)
+	`, id), &cc)
 	if err == nil {
 		b.CreditCard = &cc
 	}
+	hideVendorLogin(ctx, &b)
 
 	models.WriteJSON(w, http.StatusOK, b)
 }
@@ -130,53 +265,109 @@ func (h *BillHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" {
-		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name is required")
+	if msg := prepareCreateBill(&req); msg != "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
 		return
 	}
+
+	// Quick-adds leave the category blank; fill it from what was learned
+	if req.Category == "" {
+		category, err := guessBillCategory(ctx, h.db, req.Name)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		req.Category = category
+	}
+
+	b, err := insertBill(ctx, h.db, req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	hideVendorLogin(ctx, &b)
+	models.WriteJSON(w, http.StatusCreated, b)
+}
+
+// prepareCreateBill fills defaults on a create request and validates it,
+// returning a message describing the first problem or "" if it is valid.
+func prepareCreateBill(req *models.CreateBillRequest) string {
+	if req.Name == "" {
+		return "name is required"
+	}
 	if req.Recurrence == "" {
 		req.Recurrence = "monthly"
 	}
+	if !validateSharedPercent(req.SharedPercent) {
+		return "shared_percent must be between 0 and 100"
+	}
+	if req.BillType == "" {
+		req.BillType = "bill"
+	}
+	if !validBillTypes[req.BillType] {
+		return "bill_type must be bill or allowance"
+	}
+	if req.BillType == "allowance" && req.Dependent == "" {
+		return "dependent is required for allowances"
+	}
+	if !validateActiveMonths(req.ActiveMonths) {
+		return "active_months must contain months 1-12"
+	}
+	if !validateMonthlyAmounts(req.MonthlyAmounts) {
+		return "monthly_amounts must map months 1-12 to non-negative amounts"
+	}
+	if !validatePortalURL(req.PortalURL) {
+		return "portal_url must be an http or https URL"
+	}
+	if _, err := parseRotationDate(req.PasswordRotatedAt); err != nil {
+		return "password_rotated_at must be in YYYY-MM-DD format"
+	}
+	if !validateBufferPercent(&req.BufferPercent) {
+		return "buffer_percent must be between 0 and 100"
+	}
+	return ""
+}
 
+// insertBill creates a bill and its credit card, if any, from a validated request.
+func insertBill(ctx context.Context, db DBTX, req models.CreateBillRequest) (models.Bill, error) {
 	var b models.Bill
-	err := h.db.QueryRow(ctx, `
+	rotated, err := parseRotationDate(req.PasswordRotatedAt)
+	if err != nil {
+		return b, err
+	}
+	err = scanBill(db.QueryRow(ctx, `
 		INSERT INTO bills (name, default_amount, due_day, recurrence, recurrence_detail,
-		                   is_autopay, category, notes, sort_order)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, name, default_amount, due_day, recurrence, recurrence_detail,
-		          is_autopay, COALESCE(category, ''), COALESCE(notes, ''), is_active, sort_order,
-		          sinking_fund_enabled, sinking_fund_periods, created_at, updated_at
+		                   is_autopay, category, notes, sort_order, shared_with, shared_percent,
+		                   bill_type, dependent, tax_deductible, active_months, monthly_amounts, owner,
+		                   portal_url, username_hint, password_rotated_at, buffer_percent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+		RETURNING `+billReturnCols+`
 	`, req.Name, req.DefaultAmount, req.DueDay, req.Recurrence, req.RecurrenceDetail,
-		req.IsAutopay, req.Category, req.Notes, req.SortOrder,
-	).Scan(
-		&b.ID, &b.Name, &b.DefaultAmount, &b.DueDay, &b.Recurrence,
-		&b.RecurrenceDetail, &b.IsAutopay, &b.Category, &b.Notes,
-		&b.IsActive, &b.SortOrder, &b.SinkingFundEnabled, &b.SinkingFundPeriods,
-		&b.CreatedAt, &b.UpdatedAt,
-	)
+		req.IsAutopay, req.Category, req.Notes, req.SortOrder, req.SharedWith, req.SharedPercent,
+		req.BillType, req.Dependent, req.TaxDeductible, req.ActiveMonths, req.MonthlyAmounts, req.Owner,
+		req.PortalURL, req.UsernameHint, rotated, req.BufferPercent,
+	), &b)
 	if err != nil {
-		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
-		return
+		return b, err
 	}
 
 	// Create credit card if provided
 	if req.CreditCard != nil {
 		var cc models.CreditCard
-		err := h.db.QueryRow(ctx, `
-			INSERT INTO credit_cards (bill_id, card_label, statement_day, due_day, issuer)
-			VALUES ($1, $2, $3, $4, $5)
-			RETURNING id, bill_id, card_label, statement_day, due_day, issuer, created_at
+		err := scanCreditCard(db.QueryRow(ctx, `
+			INSERT INTO credit_cards (bill_id, card_label, statement_day, due_day, issuer, apr, credit_limit)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING `+creditCardReturnCols+`
 		`, b.ID, req.CreditCard.CardLabel, req.CreditCard.StatementDay,
-			req.CreditCard.DueDay, req.CreditCard.Issuer,
-		).Scan(&cc.ID, &cc.BillID, &cc.CardLabel, &cc.StatementDay, &cc.DueDay, &cc.Issuer, &cc.CreatedAt)
+			req.CreditCard.DueDay, req.CreditCard.Issuer, req.CreditCard.APR, req.CreditCard.CreditLimit,
+		), &cc)
 		if err != nil {
-			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
-			return
+			return b, err
 		}
 		b.CreditCard = &cc
 	}
-
-	models.WriteJSON(w, http.StatusCreated, b)
+	return b, nil
 }
 
 func (h *BillHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -193,8 +384,40 @@ func (h *BillHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !validateSharedPercent(req.SharedPercent) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "shared_percent must be between 0 and 100")
+		return
+	}
+	if req.BillType != nil && !validBillTypes[*req.BillType] {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "bill_type must be bill or allowance")
+		return
+	}
+	if !validateActiveMonths(req.ActiveMonths) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "active_months must contain months 1-12")
+		return
+	}
+	if !validateMonthlyAmounts(req.MonthlyAmounts) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "monthly_amounts must map months 1-12 to non-negative amounts")
+		return
+	}
+	if req.PortalURL != nil && !validatePortalURL(*req.PortalURL) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "portal_url must be an http or https URL")
+		return
+	}
+	if !validateBufferPercent(req.BufferPercent) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "buffer_percent must be between 0 and 100")
+		return
+	}
+	// password_rotated_at is only touched when present; an empty string clears it
+	setRotated := req.PasswordRotatedAt != nil
+	rotated, err := parseRotationDate(req.PasswordRotatedAt)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "password_rotated_at must be in YYYY-MM-DD format")
+		return
+	}
+
 	var b models.Bill
-	err = h.db.QueryRow(ctx, `
+	err = scanBill(h.db.QueryRow(ctx, `
 		UPDATE bills SET
 			name = COALESCE($2, name),
 			default_amount = COALESCE($3, default_amount),
@@ -208,25 +431,42 @@ func (h *BillHandler) Update(w http.ResponseWriter, r *http.Request) {
 			sort_order = COALESCE($11, sort_order),
 			sinking_fund_enabled = COALESCE($12, sinking_fund_enabled),
 			sinking_fund_periods = COALESCE($13, sinking_fund_periods),
+			shared_with = COALESCE($14, shared_with),
+			shared_percent = COALESCE($15, shared_percent),
+			bill_type = COALESCE($16, bill_type),
+			dependent = COALESCE($17, dependent),
+			tax_deductible = COALESCE($18, tax_deductible),
+			active_months = COALESCE($19, active_months),
+			monthly_amounts = COALESCE($20, monthly_amounts),
+			owner = COALESCE($21, owner),
+			portal_url = COALESCE($22, portal_url),
+			username_hint = COALESCE($23, username_hint),
+			password_rotated_at = CASE WHEN $24 THEN $25::date ELSE password_rotated_at END,
+			buffer_percent = COALESCE($26, buffer_percent),
 			updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, default_amount, due_day, recurrence, recurrence_detail,
-		          is_autopay, COALESCE(category, ''), COALESCE(notes, ''), is_active, sort_order,
-		          sinking_fund_enabled, sinking_fund_periods, created_at, updated_at
+		RETURNING `+billReturnCols+`
 	`, id, req.Name, req.DefaultAmount, req.DueDay, req.Recurrence,
 		req.RecurrenceDetail, req.IsAutopay, req.Category, req.Notes,
 		req.IsActive, req.SortOrder, req.SinkingFundEnabled, req.SinkingFundPeriods,
-	).Scan(
-		&b.ID, &b.Name, &b.DefaultAmount, &b.DueDay, &b.Recurrence,
-		&b.RecurrenceDetail, &b.IsAutopay, &b.Category, &b.Notes,
-		&b.IsActive, &b.SortOrder, &b.SinkingFundEnabled, &b.SinkingFundPeriods,
-		&b.CreatedAt, &b.UpdatedAt,
-	)
+		req.SharedWith, req.SharedPercent, req.BillType, req.Dependent, req.TaxDeductible,
+		req.ActiveMonths, req.MonthlyAmounts, req.Owner,
+		req.PortalURL, req.UsernameHint, setRotated, rotated, req.BufferPercent,
+	), &b)
 	if err != nil {
 		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "bill not found")
 		return
 	}
 
+	// A category set by hand teaches future imports and quick-adds. The bill
+	// is already saved, so failing to learn from it is not an error.
+	if req.Category != nil && *req.Category != "" {
+		if err := recordCategoryCorrection(ctx, h.db, b.Name, *req.Category); err != nil {
+			slog.Warn("recording category correction", "bill_id", b.ID, "error", err)
+		}
+	}
+
+	hideVendorLogin(ctx, &b)
 	models.WriteJSON(w, http.StatusOK, b)
 }
 
@@ -281,3 +521,130 @@ func (h *BillHandler) Reorder(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// Skip records that a bill is intentionally not paid in a month. Pending
+// assignments for the bill in that month are marked skipped, and auto-assign
+// will not recreate them.
+// POST /api/v1/bills/{id}/skip
+func (h *BillHandler) Skip(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.SkipBillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	month, err := time.Parse("2006-01", req.Month)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "month must be in YYYY-MM format")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var skip models.BillSkip
+	var skipMonth time.Time
+	err = tx.QueryRow(ctx, `
+		INSERT INTO bill_skips (bill_id, month, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (bill_id, month) DO UPDATE SET reason = EXCLUDED.reason
+		RETURNING id, bill_id, month, reason, created_at
+	`, id, month, req.Reason).Scan(&skip.ID, &skip.BillID, &skipMonth, &skip.Reason, &skip.CreatedAt)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "bill not found")
+		return
+	}
+	skip.Month = skipMonth.Format("2006-01")
+
+	_, err = tx.Exec(ctx, `
+		UPDATE bill_assignments SET status = 'skipped', updated_at = NOW()
+		WHERE bill_id = $1 AND status = 'pending'
+		  AND pay_period_id IN (SELECT id FROM pay_periods WHERE pay_date >= $2 AND pay_date <= $3)
+	`, id, month, month.AddDate(0, 1, -1))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, skip)
+}
+
+// Unskip removes a skip record. Assignments already marked skipped keep their
+// status; the next auto-assign may create new ones for the month.
+// DELETE /api/v1/bills/{id}/skip?month=YYYY-MM
+func (h *BillHandler) Unskip(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+	month, err := time.Parse("2006-01", r.URL.Query().Get("month"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "month query param must be in YYYY-MM format")
+		return
+	}
+
+	tag, err := h.db.Exec(ctx, `DELETE FROM bill_skips WHERE bill_id = $1 AND month = $2`, id, month)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "skip not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Skips lists the months a bill was intentionally skipped.
+// GET /api/v1/bills/{id}/skips
+func (h *BillHandler) Skips(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT id, bill_id, month, reason, created_at
+		FROM bill_skips WHERE bill_id = $1
+		ORDER BY month
+	`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	skips := []models.BillSkip{}
+	for rows.Next() {
+		var skip models.BillSkip
+		var month time.Time
+		if err := rows.Scan(&skip.ID, &skip.BillID, &month, &skip.Reason, &skip.CreatedAt); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		skip.Month = month.Format("2006-01")
+		skips = append(skips, skip)
+	}
+
+	models.WriteJSON(w, http.StatusOK, skips)
+}
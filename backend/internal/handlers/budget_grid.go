@@ -35,10 +35,7 @@ func (h *GridHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch bills
 	billRows, err := h.db.Query(ctx, `
-		SELECT b.id, b.name, b.default_amount, b.due_day, b.recurrence,
-		       b.recurrence_detail, b.is_autopay, COALESCE(b.category, ''), COALESCE(b.notes, ''),
-		       b.is_active, b.sort_order, b.sinking_fund_enabled, b.sinking_fund_periods,
-		       b.created_at, b.updated_at,
+		SELECT `+billSelectCols+`,
 		       cc.id, cc.card_label, cc.statement_day, cc.due_day, cc.issuer
 		FROM bills b
 		LEFT JOIN credit_cards cc ON cc.bill_id = b.id
@@ -58,13 +55,9 @@ func (h *GridHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
 		var ccLabel, ccIssuer *string
 		var ccStatementDay, ccDueDay *int
 
-		err := billRows.Scan(
-			&b.ID, &b.Name, &b.DefaultAmount, &b.DueDay, &b.Recurrence,
-			&b.RecurrenceDetail, &b.IsAutopay, &b.Category, &b.Notes,
-			&b.IsActive, &b.SortOrder, &b.SinkingFundEnabled, &b.SinkingFundPeriods,
-			&b.CreatedAt, &b.UpdatedAt,
+		err := billRows.Scan(append(billScanDest(&b),
 			&ccID, &ccLabel, &ccStatementDay, &ccDueDay, &ccIssuer,
-		)
+		)...)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
 			return
@@ -72,7 +65,7 @@ func (h *GridHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
 		if ccID != nil {
 			b.CreditCard = &models.CreditCard{
 				ID:           *ccID,
-				BillID:       b.ID,
+				BillID:       &b.ID,
 				StatementDay: *ccStatementDay,
 				DueDay:       *ccDueDay,
 			}
@@ -83,6 +76,7 @@ func (h *GridHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
 				b.CreditCard.Issuer = *ccIssuer
 			}
 		}
+		hideVendorLogin(ctx, &b)
 		bills = append(bills, b)
 	}
 
@@ -90,10 +84,11 @@ func (h *GridHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
 	periodRows, err := h.db.Query(ctx, `
 		SELECT pp.id, pp.income_source_id, pp.pay_date, pp.expected_amount,
 		       pp.actual_amount, COALESCE(pp.notes, ''), pp.created_at, inc.name,
-		       COALESCE(SUM(ba.planned_amount), 0) as total_bills
+		       COALESCE(SUM(`+netPlannedAmount+`), 0) as total_bills
 		FROM pay_periods pp
 		JOIN income_sources inc ON inc.id = pp.income_source_id
 		LEFT JOIN bill_assignments ba ON ba.pay_period_id = pp.id
+		LEFT JOIN bills b ON b.id = ba.bill_id
 		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2 AND inc.is_active = true
 		GROUP BY pp.id, pp.income_source_id, pp.pay_date, pp.expected_amount,
 		         pp.actual_amount, pp.notes, pp.created_at, inc.name
@@ -126,16 +121,12 @@ func (h *GridHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
 	assignments := make(map[string]models.BillAssignment)
 	if len(periodIDs) > 0 {
 		assignRows, err := h.db.Query(ctx, `
-			SELECT ba.id, ba.bill_id, ba.pay_period_id, ba.planned_amount,
-			       ba.forecast_amount, ba.actual_amount, ba.status, ba.deferred_to_id,
-			       ba.is_extra, COALESCE(ba.extra_name, ''), COALESCE(ba.notes, ''),
-			       ba.manually_moved, ba.is_sinking_fund, ba.sinking_fund_for_period_id,
-			       ba.created_at, ba.updated_at,
+			SELECT `+assignmentSelectCols+`,
 			       b.name
 			FROM bill_assignments ba
 			JOIN bills b ON b.id = ba.bill_id
 			WHERE ba.pay_period_id = ANY($1)
-			ORDER BY b.sort_order, b.id
+			ORDER BY b.sort_order, b.id, ba.due_date NULLS FIRST, ba.id
 		`, periodIDs)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
@@ -145,17 +136,22 @@ func (h *GridHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
 
 		for assignRows.Next() {
 			var a models.BillAssignment
-			err := assignRows.Scan(&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount,
-				&a.ForecastAmount, &a.ActualAmount, &a.Status, &a.DeferredToID,
-				&a.IsExtra, &a.ExtraName, &a.Notes,
-				&a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-				&a.CreatedAt, &a.UpdatedAt,
-				&a.BillName)
+			err := assignRows.Scan(append(assignmentScanDest(&a), &a.BillName)...)
 			if err != nil {
 				models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
 				return
 			}
 			key := strconv.Itoa(a.BillID) + "-" + strconv.Itoa(a.PayPeriodID)
+			// A bill due twice before the next paycheck has one assignment per
+			// occurrence; the cell shows the first and lists them all
+			if first, ok := assignments[key]; ok {
+				if first.Occurrences == nil {
+					first.Occurrences = []models.BillAssignment{first}
+				}
+				first.Occurrences = append(first.Occurrences, a)
+				assignments[key] = first
+				continue
+			}
 			assignments[key] = a
 		}
 	}
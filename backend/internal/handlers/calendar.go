@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// maxCalendarDays bounds how far ahead the calendar feed reaches.
+const maxCalendarDays = 366
+
+// CalendarHandler serves paydays and bill due dates as an iCalendar feed.
+type CalendarHandler struct {
+	db  DBTX
+	cfg *config.Config
+}
+
+func NewCalendarHandler(db DBTX, cfg *config.Config) *CalendarHandler {
+	return &CalendarHandler{db: db, cfg: cfg}
+}
+
+// CalendarEvent is one all-day entry in the feed.
+type CalendarEvent struct {
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+}
+
+// Token issues the signed token a calendar app subscribes with, and the feed
+// URL that carries it. Without authentication the feed needs no token.
+// GET /api/v1/calendar/token
+func (h *CalendarHandler) Token(w http.ResponseWriter, r *http.Request) {
+	path := "/api/v1/calendar.ics"
+	if !h.cfg.AuthEnabled() {
+		models.WriteJSON(w, http.StatusOK, map[string]string{"token": "", "path": path})
+		return
+	}
+
+	token, err := auth.CreateFeedToken(h.cfg.JWTSecret, h.cfg.AuthUsername)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "TOKEN_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, map[string]string{"token": token, "path": path + "?token=" + token})
+}
+
+// Feed lists upcoming paydays and bill due dates, with amounts in each
+// event's description. It is public so calendar apps can poll it; when
+// authentication is enabled it requires ?token from Token.
+// GET /api/v1/calendar.ics?token=...&days=90
+func (h *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.cfg.AuthEnabled() {
+		if _, err := auth.ValidateFeedToken(h.cfg.JWTSecret, r.URL.Query().Get("token")); err != nil {
+			models.WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", "a valid calendar token is required")
+			return
+		}
+	}
+
+	days := 90
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxCalendarDays {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "days must be between 1 and 366")
+			return
+		}
+		days = n
+	}
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, days)
+
+	var events []CalendarEvent
+
+	rows, err := h.db.Query(ctx, `
+		SELECT pp.id, pp.pay_date, inc.name, COALESCE(pp.actual_amount, pp.expected_amount)
+		FROM pay_periods pp
+		JOIN income_sources inc ON inc.id = pp.income_source_id
+		WHERE pp.pay_date BETWEEN $1 AND $2 AND inc.is_active = true
+		ORDER BY pp.pay_date, pp.id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var date time.Time
+		var source string
+		var amount *float64
+		if err := rows.Scan(&id, &date, &source, &amount); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		e := CalendarEvent{UID: fmt.Sprintf("payday-%d@budget-mgmt", id), Date: date, Summary: "Payday: " + source}
+		if amount != nil {
+			e.Description = fmt.Sprintf("Expected $%.2f", *amount)
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+
+	rows, err = h.db.Query(ctx, `
+		SELECT ba.id, ba.due_date,
+		       CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       COALESCE(ba.actual_amount, ba.forecast_amount, ba.planned_amount), ba.status, b.is_autopay
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		WHERE ba.due_date BETWEEN $1 AND $2 AND ba.status <> 'skipped'
+		ORDER BY ba.due_date, b.sort_order, ba.id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var date time.Time
+		var name, status string
+		var amount *float64
+		var autopay bool
+		if err := rows.Scan(&id, &date, &name, &amount, &status, &autopay); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		var desc []string
+		if amount != nil {
+			desc = append(desc, fmt.Sprintf("Amount $%.2f", *amount))
+		}
+		desc = append(desc, "Status: "+status)
+		if autopay {
+			desc = append(desc, "Autopay")
+		}
+		events = append(events, CalendarEvent{
+			UID:         fmt.Sprintf("bill-%d@budget-mgmt", id),
+			Date:        date,
+			Summary:     "Due: " + name,
+			Description: strings.Join(desc, "\n"),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="budget.ics"`)
+	w.Write([]byte(icsCalendar(events, now)))
+}
+
+// icsCalendar renders events as an RFC 5545 calendar with all-day entries.
+func icsCalendar(events []CalendarEvent, stamp time.Time) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(icsFold(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//budget-mgmt//Bills and paydays//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:Bills and paydays")
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:" + e.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + e.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + icsText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + icsText(e.Description))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// icsText escapes a TEXT property value.
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsFold splits a content line longer than 75 octets into continuation
+// lines, without breaking a UTF-8 sequence.
+func icsFold(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		width = limit - 1 // the leading space counts
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type CategoryHandler struct {
+	db DBTX
+}
+
+func NewCategoryHandler(db DBTX) *CategoryHandler {
+	return &CategoryHandler{db: db}
+}
+
+const categoryReturnCols = `id, name, monthly_limit, created_at, updated_at`
+
+func scanCategory(scanner interface{ Scan(dest ...interface{}) error }, c *models.Category) error {
+	return scanner.Scan(&c.ID, &c.Name, &c.MonthlyLimit, &c.CreatedAt, &c.UpdatedAt)
+}
+
+// List returns every category.
+// GET /api/v1/categories
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rows, err := h.db.Query(ctx, `SELECT `+categoryReturnCols+` FROM categories ORDER BY name`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var c models.Category
+		if err := scanCategory(rows, &c); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		categories = append(categories, c)
+	}
+
+	models.WriteJSON(w, http.StatusOK, categories)
+}
+
+// Create adds a category.
+// POST /api/v1/categories
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.CreateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name is required")
+		return
+	}
+	if req.MonthlyLimit != nil && *req.MonthlyLimit <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "monthly_limit must be positive")
+		return
+	}
+
+	var c models.Category
+	err := scanCategory(h.db.QueryRow(ctx, `
+		INSERT INTO categories (name, monthly_limit)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING `+categoryReturnCols+`
+	`, req.Name, req.MonthlyLimit), &c)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "category already exists")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, c)
+}
+
+// Update renames a category or changes its limit. Renaming moves the
+// category's bills to the new name.
+// PUT /api/v1/categories/{id}
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name must not be empty")
+			return
+		}
+		req.Name = &trimmed
+	}
+	if req.MonthlyLimit != nil && *req.MonthlyLimit < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "monthly_limit must not be negative")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var oldName string
+	if err := tx.QueryRow(ctx, `SELECT name FROM categories WHERE id = $1 FOR UPDATE`, id).Scan(&oldName); err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "category not found")
+		return
+	}
+
+	var c models.Category
+	err = scanCategory(tx.QueryRow(ctx, `
+		UPDATE categories SET
+			name = COALESCE($2, name),
+			monthly_limit = CASE WHEN $3::numeric IS NULL THEN monthly_limit
+			                     WHEN $3::numeric = 0 THEN NULL ELSE $3::numeric END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+categoryReturnCols+`
+	`, id, req.Name, req.MonthlyLimit), &c)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "a category with that name already exists")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if c.Name != oldName {
+		if _, err := tx.Exec(ctx, `UPDATE bills SET category = $2, updated_at = NOW() WHERE category = $1`, oldName, c.Name); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, c)
+}
+
+// Delete removes a category. Its bills keep the name as free text.
+// DELETE /api/v1/categories/{id}
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "category not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Spending totals each category's assignments in a month against its limit.
+// GET /api/v1/categories/spending?month=YYYY-MM (defaults to the current month)
+func (h *CategoryHandler) Spending(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "month must be in YYYY-MM format")
+		return
+	}
+
+	totals, err := categoryTotals(ctx, h.db, start, false)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	spending := []models.CategorySpending{}
+	for _, t := range totals {
+		s := models.CategorySpending{
+			Category: t.name,
+			Month:    month,
+			Limit:    t.limit,
+			Planned:  roundCents(t.planned),
+			Actual:   roundCents(t.actual),
+		}
+		if t.limit != nil {
+			remaining := roundCents(*t.limit - s.Planned)
+			s.Remaining = &remaining
+			s.OverLimit = remaining < 0
+		}
+		spending = append(spending, s)
+	}
+
+	models.WriteJSON(w, http.StatusOK, spending)
+}
+
+type categoryTotal struct {
+	name    string
+	limit   *float64
+	planned float64
+	actual  float64
+}
+
+// categoryTotals sums planned and paid assignment amounts per category for the
+// month starting at start, optionally only for categories with a limit. Bills
+// count in the month their occurrence is due, net of any external share.
+func categoryTotals(ctx context.Context, db DBTX, start time.Time, limitedOnly bool) ([]categoryTotal, error) {
+	rows, err := db.Query(ctx, `
+		SELECT c.name, c.monthly_limit,
+		       COALESCE(SUM(`+netPlannedAmount+`) FILTER (WHERE ba.status <> 'skipped'), 0),
+		       COALESCE(SUM(ba.actual_amount * (1 - COALESCE(b.shared_percent, 0) / 100))
+		                FILTER (WHERE ba.status = 'paid'), 0)
+		FROM categories c
+		LEFT JOIN (bills b
+		     JOIN bill_assignments ba ON ba.bill_id = b.id
+		     JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		          AND COALESCE(ba.due_date, pp.pay_date) BETWEEN $1 AND $2)
+		     ON b.category = c.name
+		WHERE NOT $3 OR c.monthly_limit IS NOT NULL
+		GROUP BY c.name, c.monthly_limit
+		ORDER BY c.name
+	`, start, start.AddDate(0, 1, -1), limitedOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []categoryTotal
+	for rows.Next() {
+		var t categoryTotal
+		if err := rows.Scan(&t.name, &t.limit, &t.planned, &t.actual); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
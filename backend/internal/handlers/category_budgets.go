@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// categoryAlertPercent is the share of a category budget that, once paid,
+// triggers an alert for the month.
+const categoryAlertPercent = 90.0
+
+type CategoryBudgetHandler struct {
+	db     DBTX
+	events *events.Bus
+}
+
+func NewCategoryBudgetHandler(db DBTX) *CategoryBudgetHandler {
+	return &CategoryBudgetHandler{db: db}
+}
+
+// WithEvents sets the bus category budget alerts are published to.
+func (h *CategoryBudgetHandler) WithEvents(bus *events.Bus) *CategoryBudgetHandler {
+	h.events = bus
+	return h
+}
+
+// List returns every category budget.
+// GET /api/v1/category-budgets
+func (h *CategoryBudgetHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rows, err := h.db.Query(ctx, `
+		SELECT name, monthly_limit, created_at, updated_at
+		FROM categories WHERE monthly_limit IS NOT NULL ORDER BY name
+	`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	budgets := []models.CategoryBudget{}
+	for rows.Next() {
+		var b models.CategoryBudget
+		if err := rows.Scan(&b.Category, &b.MonthlyLimit, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		budgets = append(budgets, b)
+	}
+
+	models.WriteJSON(w, http.StatusOK, budgets)
+}
+
+// Set creates or replaces a category's monthly budget.
+// PUT /api/v1/category-budgets/{category}
+func (h *CategoryBudgetHandler) Set(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	category := strings.TrimSpace(chi.URLParam(r, "category"))
+	if category == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "category is required")
+		return
+	}
+
+	var req models.SetCategoryBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.MonthlyLimit <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "monthly_limit must be positive")
+		return
+	}
+
+	var b models.CategoryBudget
+	err := h.db.QueryRow(ctx, `
+		INSERT INTO categories (name, monthly_limit)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET monthly_limit = EXCLUDED.monthly_limit, updated_at = NOW()
+		RETURNING name, monthly_limit, created_at, updated_at
+	`, category, req.MonthlyLimit).Scan(&b.Category, &b.MonthlyLimit, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, b)
+}
+
+// Delete removes a category's budget; the category itself is kept.
+// DELETE /api/v1/category-budgets/{category}
+func (h *CategoryBudgetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tag, err := h.db.Exec(ctx, `
+		UPDATE categories SET monthly_limit = NULL, alerted_month = NULL, updated_at = NOW()
+		WHERE name = $1 AND monthly_limit IS NOT NULL
+	`, chi.URLParam(r, "category"))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "category budget not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Status reports each budgeted category's spending against its target.
+// GET /api/v1/reports/category-budgets?month=YYYY-MM (defaults to the current month)
+func (h *CategoryBudgetHandler) Status(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "month must be in YYYY-MM format")
+		return
+	}
+
+	statuses, err := h.statuses(ctx, start)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, statuses)
+}
+
+// statuses computes budget consumption for the budgeted categories in the
+// month starting at start.
+func (h *CategoryBudgetHandler) statuses(ctx context.Context, start time.Time) ([]models.CategoryBudgetStatus, error) {
+	totals, err := categoryTotals(ctx, h.db, start, true)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []models.CategoryBudgetStatus{}
+	for _, t := range totals {
+		s := models.CategoryBudgetStatus{
+			Category: t.name,
+			Month:    start.Format("2006-01"),
+			Budget:   *t.limit,
+			Planned:  roundCents(t.planned),
+			Actual:   roundCents(t.actual),
+		}
+		if s.Budget > 0 {
+			s.PercentConsumed = roundCents(s.Actual / s.Budget * 100)
+		}
+		s.Alert = s.PercentConsumed >= categoryAlertPercent
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// CheckAlerts publishes a category budget alert for every category that has
+// crossed the alert threshold in the month of now, once per category per month.
+func (h *CategoryBudgetHandler) CheckAlerts(ctx context.Context, now time.Time) error {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	statuses, err := h.statuses(ctx, start)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if !s.Alert {
+			continue
+		}
+		// Claim the alert so concurrent checks send it once
+		tag, err := h.db.Exec(ctx, `
+			UPDATE categories SET alerted_month = $2
+			WHERE name = $1 AND alerted_month IS DISTINCT FROM $2
+		`, s.Category, start)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+		slog.Info("category budget alert", "category", s.Category, "month", s.Month, "percent", s.PercentConsumed)
+		h.events.Publish(events.CategoryBudgetAlert, s)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/jackc/pgx/v5"
+)
+
+// recordCategoryCorrection remembers the category the user gave a bill so
+// later imports and quick-adds of the same name pick it up.
+func recordCategoryCorrection(ctx context.Context, db DBTX, name, category string) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO category_corrections (name_key, category)
+		VALUES ($1, $2)
+		ON CONFLICT (name_key) DO UPDATE SET category = EXCLUDED.category, updated_at = NOW()
+	`, services.CategoryKey(name), category)
+	return err
+}
+
+// loadLearnedCategories returns every recorded correction keyed by
+// services.CategoryKey.
+func loadLearnedCategories(ctx context.Context, db DBTX) (map[string]string, error) {
+	rows, err := db.Query(ctx, `SELECT name_key, category FROM category_corrections`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	learned := make(map[string]string)
+	for rows.Next() {
+		var key, category string
+		if err := rows.Scan(&key, &category); err != nil {
+			return nil, err
+		}
+		learned[key] = category
+	}
+	return learned, rows.Err()
+}
+
+// guessBillCategory categorizes a bill added without one, preferring a
+// correction recorded for its name over the keyword table.
+func guessBillCategory(ctx context.Context, db DBTX, name string) (string, error) {
+	var category string
+	err := db.QueryRow(ctx, `SELECT category FROM category_corrections WHERE name_key = $1`,
+		services.CategoryKey(name)).Scan(&category)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return services.GuessCategory(name, nil), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return category, nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+type ChecklistHandler struct {
+	db DBTX
+}
+
+func NewChecklistHandler(db DBTX) *ChecklistHandler {
+	return &ChecklistHandler{db: db}
+}
+
+const checklistCols = `id, pay_period_id, label, is_done, sort_order, created_at, updated_at`
+
+func checklistScanDest(c *models.ChecklistItem) []interface{} {
+	return []interface{}{&c.ID, &c.PayPeriodID, &c.Label, &c.IsDone, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt}
+}
+
+// List returns the checklist items for a pay period.
+// GET /api/v1/pay-periods/{id}/checklist
+func (h *ChecklistHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	periodID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT `+checklistCols+`
+		FROM period_checklist_items WHERE pay_period_id = $1
+		ORDER BY sort_order, id
+	`, periodID)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		var c models.ChecklistItem
+		if err := rows.Scan(checklistScanDest(&c)...); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		items = append(items, c)
+	}
+
+	models.WriteJSON(w, http.StatusOK, items)
+}
+
+// Create adds a checklist item to a pay period.
+// POST /api/v1/pay-periods/{id}/checklist
+func (h *ChecklistHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	periodID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.CreateChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	req.Label = strings.TrimSpace(req.Label)
+	if req.Label == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "label is required")
+		return
+	}
+
+	var c models.ChecklistItem
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO period_checklist_items (pay_period_id, label, sort_order)
+		SELECT id, $2, $3 FROM pay_periods WHERE id = $1
+		RETURNING `+checklistCols+`
+	`, periodID, req.Label, req.SortOrder).Scan(checklistScanDest(&c)...)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "pay period not found")
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, c)
+}
+
+// Update edits a checklist item or toggles its done state.
+// PUT /api/v1/checklist-items/{id}
+func (h *ChecklistHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Label != nil && strings.TrimSpace(*req.Label) == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "label cannot be empty")
+		return
+	}
+
+	var c models.ChecklistItem
+	err = h.db.QueryRow(ctx, `
+		UPDATE period_checklist_items SET
+			label = COALESCE($2, label),
+			is_done = COALESCE($3, is_done),
+			sort_order = COALESCE($4, sort_order),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+checklistCols+`
+	`, id, req.Label, req.IsDone, req.SortOrder).Scan(checklistScanDest(&c)...)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "checklist item not found")
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, c)
+}
+
+// Delete removes a checklist item.
+// DELETE /api/v1/checklist-items/{id}
+func (h *ChecklistHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(ctx, `DELETE FROM period_checklist_items WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "checklist item not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+type ConfigHandler struct {
+	db        DBTX
+	generator *services.PeriodGenerator
+}
+
+func NewConfigHandler(db DBTX) *ConfigHandler {
+	return &ConfigHandler{
+		db:        db,
+		generator: services.NewPeriodGenerator(),
+	}
+}
+
+// Export returns the active bills and income sources as a portable configuration.
+// GET /api/v1/config/export
+func (h *ConfigHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	export := models.ConfigExport{
+		Version:       models.ConfigExportVersion,
+		ExportedAt:    time.Now().UTC(),
+		Categories:    []string{},
+		Bills:         []models.BillConfig{},
+		IncomeSources: []models.IncomeSourceConfig{},
+	}
+
+	billRows, err := h.db.Query(ctx, `
+		SELECT `+billSelectCols+`,
+		       cc.card_label, cc.statement_day, cc.due_day, cc.issuer
+		FROM bills b
+		LEFT JOIN credit_cards cc ON cc.bill_id = b.id
+		WHERE b.is_active = true
+		ORDER BY b.sort_order, b.id
+	`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer billRows.Close()
+
+	seenCategories := make(map[string]bool)
+	for billRows.Next() {
+		var b models.Bill
+		var ccLabel, ccIssuer *string
+		var ccStatementDay, ccDueDay *int
+		if err := billRows.Scan(append(billScanDest(&b), &ccLabel, &ccStatementDay, &ccDueDay, &ccIssuer)...); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+
+		bc := models.BillConfig{
+			CreateBillRequest: models.CreateBillRequest{
+				Name:             b.Name,
+				DefaultAmount:    b.DefaultAmount,
+				DueDay:           b.DueDay,
+				Recurrence:       b.Recurrence,
+				RecurrenceDetail: b.RecurrenceDetail,
+				IsAutopay:        b.IsAutopay,
+				Category:         b.Category,
+				Notes:            b.Notes,
+				SortOrder:        b.SortOrder,
+				SharedWith:       b.SharedWith,
+				SharedPercent:    b.SharedPercent,
+				BillType:         b.BillType,
+				Dependent:        b.Dependent,
+				TaxDeductible:    b.TaxDeductible,
+				ActiveMonths:     b.ActiveMonths,
+				MonthlyAmounts:   b.MonthlyAmounts,
+			},
+			SinkingFundEnabled: b.SinkingFundEnabled,
+			SinkingFundPeriods: b.SinkingFundPeriods,
+		}
+		if ccLabel != nil && ccStatementDay != nil && ccDueDay != nil {
+			bc.CreditCard = &models.CreateCreditCardRequest{
+				CardLabel:    *ccLabel,
+				StatementDay: *ccStatementDay,
+				DueDay:       *ccDueDay,
+			}
+			if ccIssuer != nil {
+				bc.CreditCard.Issuer = *ccIssuer
+			}
+		}
+		export.Bills = append(export.Bills, bc)
+
+		if b.Category != "" && !seenCategories[b.Category] {
+			seenCategories[b.Category] = true
+			export.Categories = append(export.Categories, b.Category)
+		}
+	}
+
+	incomeRows, err := h.db.Query(ctx, `
+		SELECT name, pay_schedule, schedule_detail, default_amount, effective_from
+		FROM income_sources WHERE is_active = true
+		ORDER BY name
+	`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer incomeRows.Close()
+
+	for incomeRows.Next() {
+		var ic models.IncomeSourceConfig
+		var effectiveFrom *time.Time
+		if err := incomeRows.Scan(&ic.Name, &ic.PaySchedule, &ic.ScheduleDetail, &ic.DefaultAmount, &effectiveFrom); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if effectiveFrom != nil {
+			formatted := effectiveFrom.Format("2006-01-02")
+			ic.EffectiveFrom = &formatted
+		}
+		export.IncomeSources = append(export.IncomeSources, ic)
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="budget-config-%s.json"`, export.ExportedAt.Format("2006-01-02")))
+	models.WriteJSON(w, http.StatusOK, export)
+}
+
+// Import creates the bills and income sources from a configuration export in a
+// single transaction. Anything named like an existing active item is skipped.
+// POST /api/v1/config/import
+func (h *ConfigHandler) Import(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.ConfigExport
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Version != models.ConfigExportVersion {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR",
+			fmt.Sprintf("unsupported config version %d, expected %d", req.Version, models.ConfigExportVersion))
+		return
+	}
+
+	// Validate everything up front so a bad entry doesn't leave a partial import
+	effectiveFroms := make([]*time.Time, len(req.IncomeSources))
+	for i := range req.Bills {
+		if msg := prepareCreateBill(&req.Bills[i].CreateBillRequest); msg != "" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("bills[%d]: %s", i, msg))
+			return
+		}
+	}
+	for i, ic := range req.IncomeSources {
+		if ic.Name == "" || !validPaySchedules[ic.PaySchedule] {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("income_sources[%d]: name and a valid pay_schedule are required", i))
+			return
+		}
+		if err := h.generator.Validate(models.IncomeSource{PaySchedule: ic.PaySchedule, ScheduleDetail: ic.ScheduleDetail}); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_SCHEDULE", fmt.Sprintf("income_sources[%d]: %v", i, err))
+			return
+		}
+		effectiveFrom, err := parseEffectiveFrom(ic.EffectiveFrom)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("income_sources[%d]: effective_from must be in YYYY-MM-DD format", i))
+			return
+		}
+		effectiveFroms[i] = effectiveFrom
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	result := models.ConfigImportResult{Skipped: []string{}}
+
+	for _, bc := range req.Bills {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM bills WHERE name = $1 AND is_active = true)`, bc.Name).Scan(&exists); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if exists {
+			result.Skipped = append(result.Skipped, "bill: "+bc.Name)
+			continue
+		}
+
+		b, err := insertBill(ctx, tx, bc.CreateBillRequest)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if bc.SinkingFundEnabled {
+			_, err := tx.Exec(ctx, `
+				UPDATE bills SET sinking_fund_enabled = true, sinking_fund_periods = $2 WHERE id = $1
+			`, b.ID, bc.SinkingFundPeriods)
+			if err != nil {
+				models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+				return
+			}
+		}
+		result.ImportedBills++
+	}
+
+	for i, ic := range req.IncomeSources {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM income_sources WHERE name = $1 AND is_active = true)`, ic.Name).Scan(&exists); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if exists {
+			result.Skipped = append(result.Skipped, "income source: "+ic.Name)
+			continue
+		}
+
+		if _, err := insertIncomeSource(ctx, tx, ic.CreateIncomeSourceRequest, effectiveFroms[i]); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		result.ImportedIncomeSources++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
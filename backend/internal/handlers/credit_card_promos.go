@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// loadPromos returns the promo APR windows on a bill's credit card, soonest
+// expiry first.
+func loadPromos(ctx context.Context, db DBTX, billID int) ([]models.CreditCardPromo, error) {
+	rows, err := db.Query(ctx, `
+		SELECT p.id, p.credit_card_id, p.rate, p.balance, p.expires_on, p.description, p.created_at
+		FROM credit_card_promos p
+		JOIN credit_cards cc ON cc.id = p.credit_card_id
+		WHERE cc.bill_id = $1
+		ORDER BY p.expires_on, p.id
+	`, billID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promos := []models.CreditCardPromo{}
+	for rows.Next() {
+		var p models.CreditCardPromo
+		var expires time.Time
+		if err := rows.Scan(&p.ID, &p.CreditCardID, &p.Rate, &p.Balance, &expires, &p.Description, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.ExpiresOn = expires.Format("2006-01-02")
+		promos = append(promos, p)
+	}
+	return promos, rows.Err()
+}
+
+// promoExpiryWarning describes the first promo that is active on from but has
+// expired by to, or returns "" when moving a payment from one to the other
+// crosses no expiry.
+func promoExpiryWarning(promos []models.CreditCardPromo, from, to time.Time) string {
+	for _, p := range promos {
+		expires, err := time.Parse("2006-01-02", p.ExpiresOn)
+		if err != nil {
+			continue
+		}
+		if !expires.Before(from) && expires.Before(to) {
+			return fmt.Sprintf("pays after the %.2f%% promo expires on %s", p.Rate, p.ExpiresOn)
+		}
+	}
+	return ""
+}
+
+// Promos lists the promo APR windows on a credit card bill.
+// GET /api/v1/bills/{id}/promos
+func (h *BillHandler) Promos(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	promos, err := loadPromos(r.Context(), h.db, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, promos)
+}
+
+// AddPromo records a promo APR window on a credit card bill.
+// POST /api/v1/bills/{id}/promos
+func (h *BillHandler) AddPromo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.CreateCreditCardPromoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	expires, err := time.Parse("2006-01-02", req.ExpiresOn)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "expires_on must be in YYYY-MM-DD format")
+		return
+	}
+	if req.Rate < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "rate must not be negative")
+		return
+	}
+	if req.Balance != nil && *req.Balance <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "balance must be positive")
+		return
+	}
+
+	var p models.CreditCardPromo
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO credit_card_promos (credit_card_id, rate, balance, expires_on, description)
+		SELECT id, $2, $3, $4, $5 FROM credit_cards WHERE bill_id = $1
+		RETURNING id, credit_card_id, rate, balance, description, created_at
+	`, id, req.Rate, req.Balance, expires, req.Description,
+	).Scan(&p.ID, &p.CreditCardID, &p.Rate, &p.Balance, &p.Description, &p.CreatedAt)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "bill has no credit card")
+		return
+	}
+	p.ExpiresOn = expires.Format("2006-01-02")
+
+	models.WriteJSON(w, http.StatusCreated, p)
+}
+
+// DeletePromo removes a promo APR window from a credit card bill.
+// DELETE /api/v1/bills/{id}/promos/{promo_id}
+func (h *BillHandler) DeletePromo(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+	promoID, err := strconv.Atoi(chi.URLParam(r, "promo_id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "promo_id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `
+		DELETE FROM credit_card_promos
+		WHERE id = $1 AND credit_card_id IN (SELECT id FROM credit_cards WHERE bill_id = $2)
+	`, promoID, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "promo not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Payoff plans paying a credit card balance down at a fixed monthly payment,
+// honoring the card's promo windows and its standard APR after they expire.
+// GET /api/v1/bills/{id}/payoff?balance=2400&payment=200
+func (h *BillHandler) Payoff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	balance, err := strconv.ParseFloat(r.URL.Query().Get("balance"), 64)
+	if err != nil || balance <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "balance must be a positive number")
+		return
+	}
+	payment, err := strconv.ParseFloat(r.URL.Query().Get("payment"), 64)
+	if err != nil || payment <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "payment must be a positive number")
+		return
+	}
+
+	var apr *float64
+	err = h.db.QueryRow(ctx, `SELECT apr FROM credit_cards WHERE bill_id = $1`, id).Scan(&apr)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "bill has no credit card")
+		return
+	}
+	standardAPR := 0.0
+	if apr != nil {
+		standardAPR = *apr
+	}
+
+	promos, err := loadPromos(ctx, h.db, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	var windows []services.PromoWindow
+	for _, p := range promos {
+		expires, _ := time.Parse("2006-01-02", p.ExpiresOn)
+		windows = append(windows, services.PromoWindow{Rate: p.Rate, ExpiresOn: expires, Balance: p.Balance})
+	}
+
+	models.WriteJSON(w, http.StatusOK, services.PlanPayoff(balance, standardAPR, payment, windows, time.Now()))
+}
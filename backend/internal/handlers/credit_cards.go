@@ -0,0 +1,535 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	defaultCardCycles = 6
+	maxCardCycles     = 24
+)
+
+type CreditCardHandler struct {
+	db DBTX
+}
+
+func NewCreditCardHandler(db DBTX) *CreditCardHandler {
+	return &CreditCardHandler{db: db}
+}
+
+const creditCardReturnCols = `id, bill_id, card_label, statement_day, due_day, issuer, apr, credit_limit, created_at`
+
+func scanCreditCard(scanner interface{ Scan(dest ...interface{}) error }, cc *models.CreditCard) error {
+	return scanner.Scan(&cc.ID, &cc.BillID, &cc.CardLabel, &cc.StatementDay, &cc.DueDay, &cc.Issuer, &cc.APR, &cc.CreditLimit, &cc.CreatedAt)
+}
+
+// validateCreditCard returns a message describing the first invalid field of
+// a card, or "" if it is valid.
+func validateCreditCard(statementDay, dueDay int, apr, creditLimit *float64) string {
+	if statementDay < 1 || statementDay > 31 {
+		return "statement_day must be between 1 and 31"
+	}
+	if dueDay < 1 || dueDay > 31 {
+		return "due_day must be between 1 and 31"
+	}
+	if apr != nil && *apr < 0 {
+		return "apr must not be negative"
+	}
+	if creditLimit != nil && *creditLimit < 0 {
+		return "credit_limit must not be negative"
+	}
+	return ""
+}
+
+// billHasCard reports whether a card other than exceptID is linked to billID.
+func billHasCard(ctx context.Context, db DBTX, billID, exceptID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM credit_cards WHERE bill_id = $1 AND id <> $2)
+	`, billID, exceptID).Scan(&exists)
+	return exists, err
+}
+
+// writeCardSaveError maps errors from saving a card, which may name a bill.
+func writeCardSaveError(w http.ResponseWriter, err error) {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "credit card not found")
+	case errors.As(err, &pgErr) && pgErr.Code == "23503":
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "bill_id does not exist")
+	default:
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+	}
+}
+
+// List returns every credit card, linked or not.
+// GET /api/v1/credit-cards
+func (h *CreditCardHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rows, err := h.db.Query(ctx, `SELECT `+creditCardReturnCols+` FROM credit_cards ORDER BY id`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	cards := []models.CreditCard{}
+	for rows.Next() {
+		var cc models.CreditCard
+		if err := scanCreditCard(rows, &cc); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		cards = append(cards, cc)
+	}
+
+	models.WriteJSON(w, http.StatusOK, cards)
+}
+
+// Get returns one credit card.
+// GET /api/v1/credit-cards/{id}
+func (h *CreditCardHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var cc models.CreditCard
+	err = scanCreditCard(h.db.QueryRow(r.Context(), `
+		SELECT `+creditCardReturnCols+` FROM credit_cards WHERE id = $1
+	`, id), &cc)
+	if err != nil {
+		writeCardSaveError(w, err)
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, cc)
+}
+
+// Create adds a credit card, linked to the bill in bill_id if given.
+// POST /api/v1/credit-cards
+func (h *CreditCardHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.CreateCreditCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if msg := validateCreditCard(req.StatementDay, req.DueDay, req.APR, req.CreditLimit); msg != "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+	if req.BillID != nil {
+		taken, err := billHasCard(ctx, h.db, *req.BillID, 0)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if taken {
+			models.WriteError(w, http.StatusConflict, "CONFLICT", "bill already has a credit card")
+			return
+		}
+	}
+
+	var cc models.CreditCard
+	err := scanCreditCard(h.db.QueryRow(ctx, `
+		INSERT INTO credit_cards (bill_id, card_label, statement_day, due_day, issuer, apr, credit_limit)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+creditCardReturnCols+`
+	`, req.BillID, req.CardLabel, req.StatementDay, req.DueDay, req.Issuer, req.APR, req.CreditLimit), &cc)
+	if err != nil {
+		writeCardSaveError(w, err)
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, cc)
+}
+
+// Update changes a credit card's details; omitted fields are left alone.
+// PUT /api/v1/credit-cards/{id}
+func (h *CreditCardHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateCreditCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	// Unset days stand in as valid so only the ones sent are checked
+	statementDay, dueDay := 1, 1
+	if req.StatementDay != nil {
+		statementDay = *req.StatementDay
+	}
+	if req.DueDay != nil {
+		dueDay = *req.DueDay
+	}
+	if msg := validateCreditCard(statementDay, dueDay, req.APR, req.CreditLimit); msg != "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+
+	var cc models.CreditCard
+	err = scanCreditCard(h.db.QueryRow(ctx, `
+		UPDATE credit_cards SET
+			card_label = COALESCE($2, card_label),
+			statement_day = COALESCE($3, statement_day),
+			due_day = COALESCE($4, due_day),
+			issuer = COALESCE($5, issuer),
+			apr = COALESCE($6, apr),
+			credit_limit = COALESCE($7, credit_limit)
+		WHERE id = $1
+		RETURNING `+creditCardReturnCols+`
+	`, id, req.CardLabel, req.StatementDay, req.DueDay, req.Issuer, req.APR, req.CreditLimit), &cc)
+	if err != nil {
+		writeCardSaveError(w, err)
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, cc)
+}
+
+// Delete removes a credit card and its promos. The bill it was linked to
+// is kept.
+// DELETE /api/v1/credit-cards/{id}
+func (h *CreditCardHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `DELETE FROM credit_cards WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "credit card not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Link makes a bill the one that pays a credit card, replacing any bill it
+// was linked to. A bill pays at most one card.
+// PUT /api/v1/credit-cards/{id}/bill
+func (h *CreditCardHandler) Link(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.LinkCreditCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.BillID <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "bill_id is required")
+		return
+	}
+
+	taken, err := billHasCard(ctx, h.db, req.BillID, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if taken {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "bill already has a credit card")
+		return
+	}
+
+	var cc models.CreditCard
+	err = scanCreditCard(h.db.QueryRow(ctx, `
+		UPDATE credit_cards SET bill_id = $2 WHERE id = $1
+		RETURNING `+creditCardReturnCols+`
+	`, id, req.BillID), &cc)
+	if err != nil {
+		writeCardSaveError(w, err)
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, cc)
+}
+
+// Unlink detaches a credit card from its bill. Both are kept; the bill's
+// existing assignments are not changed.
+// DELETE /api/v1/credit-cards/{id}/bill
+func (h *CreditCardHandler) Unlink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var cc models.CreditCard
+	err = scanCreditCard(h.db.QueryRow(r.Context(), `
+		UPDATE credit_cards SET bill_id = NULL WHERE id = $1
+		RETURNING `+creditCardReturnCols+`
+	`, id), &cc)
+	if err != nil {
+		writeCardSaveError(w, err)
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, cc)
+}
+
+// errNoStatementCycle marks a card missing a statement or due day.
+var errNoStatementCycle = errors.New("credit card needs a statement_day and due_day between 1 and 31")
+
+// errCardNotLinked marks a card with no bill to pay its statements.
+var errCardNotLinked = errors.New("credit card is not linked to a bill")
+
+// loadCardForecast projects a card's next count statement cycles from from,
+// with the active paycheck that would pay each and any assignment already
+// due that day. It also returns the card bill's default amount.
+func loadCardForecast(ctx context.Context, db DBTX, id int, from time.Time, count int) (models.CreditCardForecast, *float64, error) {
+	f := models.CreditCardForecast{CreditCardID: id, Cycles: []models.CreditCardCycle{}}
+	var billID *int
+	var billName *string
+	var amount *float64
+	err := db.QueryRow(ctx, `
+		SELECT b.id, b.name, cc.statement_day, cc.due_day, b.default_amount
+		FROM credit_cards cc LEFT JOIN bills b ON b.id = cc.bill_id
+		WHERE cc.id = $1
+	`, id).Scan(&billID, &billName, &f.StatementDay, &f.DueDay, &amount)
+	if err != nil {
+		return f, nil, err
+	}
+	if billID == nil {
+		return f, nil, errCardNotLinked
+	}
+	f.BillID, f.BillName = *billID, *billName
+	if f.StatementDay < 1 || f.StatementDay > 31 || f.DueDay < 1 || f.DueDay > 31 {
+		return f, nil, errNoStatementCycle
+	}
+
+	cycles := services.ForecastStatementCycles(f.StatementDay, f.DueDay, from, count)
+	last := cycles[len(cycles)-1].Due
+
+	rows, err := db.Query(ctx, `
+		SELECT pp.id, pp.pay_date FROM pay_periods pp
+		JOIN income_sources inc ON inc.id = pp.income_source_id
+		WHERE inc.is_active AND pp.pay_date >= $1 AND pp.pay_date <= $2
+		ORDER BY pp.pay_date, pp.id
+	`, from, last)
+	if err != nil {
+		return f, nil, err
+	}
+	var periodIDs []int
+	var payDates []time.Time
+	for rows.Next() {
+		var pid int
+		var d time.Time
+		if err := rows.Scan(&pid, &d); err != nil {
+			rows.Close()
+			return f, nil, err
+		}
+		periodIDs = append(periodIDs, pid)
+		payDates = append(payDates, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, nil, err
+	}
+
+	dues := make([]time.Time, len(cycles))
+	for i, c := range cycles {
+		dues[i] = c.Due
+	}
+	existing := make(map[string]int)
+	rows, err = db.Query(ctx, `
+		SELECT id, due_date FROM bill_assignments
+		WHERE bill_id = $1 AND due_date = ANY($2)
+		ORDER BY id
+	`, f.BillID, dues)
+	if err != nil {
+		return f, nil, err
+	}
+	for rows.Next() {
+		var aid int
+		var d time.Time
+		if err := rows.Scan(&aid, &d); err != nil {
+			rows.Close()
+			return f, nil, err
+		}
+		if _, ok := existing[d.Format("2006-01-02")]; !ok {
+			existing[d.Format("2006-01-02")] = aid
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return f, nil, err
+	}
+
+	for _, c := range cycles {
+		cycle := models.CreditCardCycle{
+			StatementDate: c.Statement.Format("2006-01-02"),
+			DueDate:       c.Due.Format("2006-01-02"),
+			GraceDays:     c.GraceDays(),
+		}
+		if i := services.StatementPayDate(c, payDates, from); i >= 0 {
+			pid, pay := periodIDs[i], payDates[i].Format("2006-01-02")
+			cycle.PayPeriodID, cycle.PayDate = &pid, &pay
+		}
+		if aid, ok := existing[cycle.DueDate]; ok {
+			cycle.AssignmentID = &aid
+		}
+		f.Cycles = append(f.Cycles, cycle)
+	}
+	return f, amount, nil
+}
+
+// parseCardForecastParams reads ?from= (default today) and ?count= (default
+// 6), writing a 400 and returning false when either is invalid.
+func parseCardForecastParams(w http.ResponseWriter, r *http.Request) (int, time.Time, int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return 0, time.Time{}, 0, false
+	}
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "from must be in YYYY-MM-DD format")
+			return 0, time.Time{}, 0, false
+		}
+	}
+	count := defaultCardCycles
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxCardCycles {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "count must be between 1 and 24")
+			return 0, time.Time{}, 0, false
+		}
+		count = n
+	}
+	return id, from, count, true
+}
+
+func writeCardForecastError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "credit card not found")
+	case errors.Is(err, errNoStatementCycle), errors.Is(err, errCardNotLinked):
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+	default:
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+	}
+}
+
+// Forecast projects a card's next statement close and payment due dates,
+// with the paycheck that would pay each.
+// GET /api/v1/credit-cards/{id}/forecast
+func (h *CreditCardHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	id, from, count, ok := parseCardForecastParams(w, r)
+	if !ok {
+		return
+	}
+
+	f, _, err := loadCardForecast(r.Context(), h.db, id, from, count)
+	if err != nil {
+		writeCardForecastError(w, err)
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, f)
+}
+
+// Assign creates the card bill's assignments from its statement cycles
+// instead of a flat due day: each forecast cycle without one gets an
+// assignment due on the cycle's due date, in the paycheck Forecast picks.
+// Cycles with no paycheck early enough, or whose paycheck the bill was
+// deleted from, are left alone.
+// POST /api/v1/credit-cards/{id}/assign
+func (h *CreditCardHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, from, count, ok := parseCardForecastParams(w, r)
+	if !ok {
+		return
+	}
+
+	f, amount, err := loadCardForecast(ctx, h.db, id, from, count)
+	if err != nil {
+		writeCardForecastError(w, err)
+		return
+	}
+
+	// Deleted cycles stay deleted; deletions recorded without a due date cover
+	// the whole period
+	deletedPeriods := make(map[int]bool)
+	type periodDue struct {
+		PeriodID int
+		Due      string
+	}
+	deletedDues := make(map[periodDue]bool)
+	rows, err := h.db.Query(ctx, `SELECT pay_period_id, due_date FROM deleted_bill_periods WHERE bill_id = $1`, f.BillID)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	for rows.Next() {
+		var pid int
+		var due *time.Time
+		if err := rows.Scan(&pid, &due); err != nil {
+			rows.Close()
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if due != nil {
+			deletedDues[periodDue{pid, due.Format("2006-01-02")}] = true
+		} else {
+			deletedPeriods[pid] = true
+		}
+	}
+	rows.Close()
+
+	created := []models.BillAssignment{}
+	for _, c := range f.Cycles {
+		if c.AssignmentID != nil || c.PayPeriodID == nil || deletedPeriods[*c.PayPeriodID] ||
+			deletedDues[periodDue{*c.PayPeriodID, c.DueDate}] {
+			continue
+		}
+		due, _ := time.Parse("2006-01-02", c.DueDate)
+		var a models.BillAssignment
+		err := h.db.QueryRow(ctx, `
+			INSERT INTO bill_assignments (bill_id, pay_period_id, planned_amount, status, due_date)
+			VALUES ($1, $2, $3, 'pending', $4)
+			ON CONFLICT (bill_id, pay_period_id, due_date) DO NOTHING
+			RETURNING `+assignmentReturnCols+`
+		`, f.BillID, *c.PayPeriodID, amount, due).Scan(assignmentScanDest(&a)...)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		created = append(created, a)
+	}
+
+	models.WriteJSON(w, http.StatusCreated, created)
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// BankCSVResult reports what a bank CSV matched against assignments. It is
+// both the bank-csv backfill response and the CSV import preview.
+type BankCSVResult struct {
+	Transactions int                        `json:"transactions"`
+	Matched      []services.BackfillMatch   `json:"matched"`
+	Unmatched    []services.BankTransaction `json:"unmatched"`
+	Warnings     []string                   `json:"warnings"`
+	DryRun       bool                       `json:"dry_run"`
+	Format       string                     `json:"format,omitempty"` // wallet export, empty for a mapped bank CSV
+}
+
+// parseCSVUpload reads a multipart CSV upload. Form fields date_column,
+// amount_column and description_column name the columns (header text or
+// 0-based index); date_format is an optional Go layout and window_days
+// (default 5) how far a transaction may be from an assignment's due date.
+// format=apple_card or google_pay reads that wallet's statement export instead,
+// ignoring the column fields.
+func (h *ImportHandler) parseCSVUpload(w http.ResponseWriter, r *http.Request) (*BankCSVResult, []services.BankTransaction, string, int, bool) {
+	// Max 10MB file
+	r.ParseMultipartForm(10 << 20)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "NO_FILE", "no file uploaded")
+		return nil, nil, "", 0, false
+	}
+	defer file.Close()
+
+	mapping := services.CSVColumnMapping{
+		Date:        r.FormValue("date_column"),
+		Amount:      r.FormValue("amount_column"),
+		Description: r.FormValue("description_column"),
+		DateFormat:  r.FormValue("date_format"),
+	}
+	windowDays := 5
+	if v := r.FormValue("window_days"); v != "" {
+		windowDays, err = strconv.Atoi(v)
+		if err != nil || windowDays < 0 {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "window_days must be a non-negative integer")
+			return nil, nil, "", 0, false
+		}
+	}
+
+	format := r.FormValue("format")
+	if format != "" && !services.ValidWalletFormat(format) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "format must be apple_card or google_pay")
+		return nil, nil, "", 0, false
+	}
+
+	var txns []services.BankTransaction
+	var warnings []string
+	if format != "" {
+		txns, warnings, err = h.csvImporter.ParseWallet(file, format)
+	} else {
+		txns, warnings, err = h.csvImporter.Parse(file, mapping)
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "PARSE_ERROR", err.Error())
+		return nil, nil, "", 0, false
+	}
+
+	result := &BankCSVResult{
+		Transactions: len(txns),
+		Matched:      []services.BackfillMatch{},
+		Unmatched:    []services.BankTransaction{},
+		Warnings:     warnings,
+		Format:       format,
+	}
+	if result.Warnings == nil {
+		result.Warnings = []string{}
+	}
+	return result, txns, filepath.Base(header.Filename), windowDays, true
+}
+
+// matchTransactions fills result with the assignments the transactions settle:
+// past assignments still missing an actual amount, near the transaction date.
+func (h *ImportHandler) matchTransactions(ctx context.Context, result *BankCSVResult, txns []services.BankTransaction, windowDays int) error {
+	if len(txns) == 0 {
+		return nil
+	}
+
+	from, to := txns[0].Date, txns[0].Date
+	for _, t := range txns {
+		if t.Date.Before(from) {
+			from = t.Date
+		}
+		if t.Date.After(to) {
+			to = t.Date
+		}
+	}
+	from = from.AddDate(0, 0, -windowDays)
+	to = to.AddDate(0, 0, windowDays)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT ba.id, b.name, COALESCE(ba.due_date, pp.pay_date)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.actual_amount IS NULL AND ba.status <> 'skipped'
+		  AND pp.pay_date <= CURRENT_DATE
+		  AND COALESCE(ba.due_date, pp.pay_date) >= $1 AND COALESCE(ba.due_date, pp.pay_date) <= $2
+		ORDER BY COALESCE(ba.due_date, pp.pay_date), ba.id
+	`, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var candidates []services.BackfillCandidate
+	for rows.Next() {
+		var c services.BackfillCandidate
+		if err := rows.Scan(&c.AssignmentID, &c.BillName, &c.Date); err != nil {
+			return err
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+
+	matched, unmatched := services.MatchBackfill(txns, candidates, windowDays)
+	if matched != nil {
+		result.Matched = matched
+	}
+	if unmatched != nil {
+		result.Unmatched = unmatched
+	}
+	return nil
+}
+
+// applyBackfill records matched amounts on their assignments, marking pending
+// ones paid, adds every transaction to the ledger and logs the import. Wallet
+// imports are recorded with their format as the transaction source.
+func (h *ImportHandler) applyBackfill(ctx context.Context, filename string, result *BankCSVResult) error {
+	source := "csv"
+	if result.Format != "" {
+		source = result.Format
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, m := range result.Matched {
+		_, err := tx.Exec(ctx, `
+			UPDATE bill_assignments SET
+				actual_amount = $2,
+				status = CASE WHEN status IN ('pending', 'uncertain') THEN 'paid' ELSE status END,
+				updated_at = NOW()
+			WHERE id = $1 AND actual_amount IS NULL
+		`, m.AssignmentID, m.Amount)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO transactions (txn_date, amount, description, source, assignment_id)
+			VALUES ($1, $2, $3, $4, $5)
+		`, m.Date, m.Amount, m.Description, source, m.AssignmentID)
+		if err != nil {
+			return err
+		}
+	}
+	for _, t := range result.Unmatched {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO transactions (txn_date, amount, description, source)
+			VALUES ($1, $2, $3, $4)
+		`, t.Date, t.Amount, t.Description, source)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO import_history (filename, row_count, period_count, status)
+		VALUES ($1, $2, 0, 'completed')
+	`, filename, len(result.Matched))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// BankCSV backfills actual_amount on past assignments from a bank export in
+// one step; dry_run=true reports matches without saving them.
+func (h *ImportHandler) BankCSV(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, txns, filename, windowDays, ok := h.parseCSVUpload(w, r)
+	if !ok {
+		return
+	}
+	result.DryRun = r.FormValue("dry_run") == "true"
+
+	if err := h.matchTransactions(ctx, result, txns, windowDays); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if result.DryRun || len(txns) == 0 {
+		models.WriteJSON(w, http.StatusOK, result)
+		return
+	}
+
+	if err := h.applyBackfill(ctx, filename, result); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
+
+// UploadCSV parses a CSV bank statement and previews the assignments its
+// transactions would settle. Nothing is saved until ConfirmCSV.
+func (h *ImportHandler) UploadCSV(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, txns, filename, windowDays, ok := h.parseCSVUpload(w, r)
+	if !ok {
+		return
+	}
+	result.DryRun = true
+
+	if err := h.matchTransactions(ctx, result, txns, windowDays); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	h.csvPreview = result
+	h.csvFilename = filename
+	h.csvUploadedAt = time.Now()
+	h.mu.Unlock()
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
+
+// ConfirmCSV applies the pending CSV statement preview.
+func (h *ImportHandler) ConfirmCSV(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Claim the preview so a concurrent confirm cannot apply it twice
+	h.mu.Lock()
+	preview, filename := h.csvPreview, h.csvFilename
+	expired := h.ttl > 0 && time.Since(h.csvUploadedAt) > h.ttl
+	h.csvPreview = nil
+	h.csvFilename = ""
+	h.mu.Unlock()
+
+	if preview == nil || expired {
+		models.WriteError(w, http.StatusBadRequest, "NO_PREVIEW", "no pending import to confirm. Upload a file first.")
+		return
+	}
+
+	if err := h.applyBackfill(ctx, filename, preview); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	preview.DryRun = false
+	models.WriteJSON(w, http.StatusOK, preview)
+}
+
+// DeleteCSVSession abandons the pending CSV statement preview.
+func (h *ImportHandler) DeleteCSVSession(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.csvPreview = nil
+	h.csvFilename = ""
+	h.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
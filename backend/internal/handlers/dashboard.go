@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"math"
 	"net/http"
 	"time"
 
@@ -42,8 +43,14 @@ type PeriodSummaryItem struct {
 	Remaining      float64 `json:"remaining"`
 }
 
+// Summary totals the next two months of pay periods against their bills.
+// ?mode= picks the assignment amount counted, default planned.
 func (h *DashboardHandler) Summary(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	mode, ok := amountModeParam(w, r, models.AmountModePlanned)
+	if !ok {
+		return
+	}
 
 	now := time.Now()
 	from := now.Format("2006-01-02")
@@ -52,10 +59,11 @@ func (h *DashboardHandler) Summary(w http.ResponseWriter, r *http.Request) {
 	// Periods
 	periodRows, err := h.db.Query(ctx, `
 		SELECT pp.id, pp.pay_date, COALESCE(pp.expected_amount, 0), inc.name,
-		       COALESCE(SUM(ba.planned_amount), 0) as total_bills
+		       COALESCE(SUM(`+netAssignmentAmount(mode)+`), 0) as total_bills
 		FROM pay_periods pp
 		JOIN income_sources inc ON inc.id = pp.income_source_id
 		LEFT JOIN bill_assignments ba ON ba.pay_period_id = pp.id
+		LEFT JOIN bills b ON b.id = ba.bill_id
 		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2
 		GROUP BY pp.id, inc.name
 		ORDER BY pp.pay_date
@@ -118,3 +126,96 @@ func (h *DashboardHandler) Summary(w http.ResponseWriter, r *http.Request) {
 
 	models.WriteJSON(w, http.StatusOK, summary)
 }
+
+// Runway is how much of the current paycheck is left until the next one.
+type Runway struct {
+	PeriodIDs         []int   `json:"period_ids"` // every period paid on the current pay date
+	PayDate           string  `json:"pay_date"`
+	NextPayDate       *string `json:"next_pay_date"`
+	DaysUntilNextPay  int     `json:"days_until_next_pay"`
+	Income            float64 `json:"income"`
+	Allocated         float64 `json:"allocated"`
+	Remaining         float64 `json:"remaining"`
+	SafeToSpendPerDay float64 `json:"safe_to_spend_per_day"`
+}
+
+// Runway reports the current period's unallocated money, the days until the
+// next pay date and what can be spent per day until then. Periods from several
+// sources paid on the current date count as one paycheck. ?mode= picks the
+// assignment amount counted, default planned.
+func (h *DashboardHandler) Runway(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	mode, ok := amountModeParam(w, r, models.AmountModePlanned)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT pp.id, pp.pay_date, COALESCE(pp.actual_amount, pp.expected_amount, 0),
+		       COALESCE(SUM(`+netAssignmentAmount(mode)+`), 0)
+		FROM pay_periods pp
+		LEFT JOIN bill_assignments ba ON ba.pay_period_id = pp.id AND ba.status <> 'skipped'
+		LEFT JOIN bills b ON b.id = ba.bill_id
+		WHERE pp.pay_date = (
+			SELECT MAX(p2.pay_date) FROM pay_periods p2
+			JOIN income_sources inc ON inc.id = p2.income_source_id
+			WHERE p2.pay_date <= $1 AND inc.is_active = true
+		)
+		  AND pp.income_source_id IN (SELECT id FROM income_sources WHERE is_active = true)
+		GROUP BY pp.id
+		ORDER BY pp.id
+	`, today)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	runway := Runway{PeriodIDs: []int{}}
+	var payDate time.Time
+	for rows.Next() {
+		var id int
+		var income, allocated float64
+		if err := rows.Scan(&id, &payDate, &income, &allocated); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		runway.PeriodIDs = append(runway.PeriodIDs, id)
+		runway.Income += income
+		runway.Allocated += allocated
+	}
+	rows.Close()
+
+	if len(runway.PeriodIDs) == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "no pay period on or before today")
+		return
+	}
+	runway.PayDate = payDate.Format("2006-01-02")
+	runway.Remaining = runway.Income - runway.Allocated
+
+	var next *time.Time
+	err = h.db.QueryRow(ctx, `
+		SELECT MIN(pp.pay_date) FROM pay_periods pp
+		JOIN income_sources inc ON inc.id = pp.income_source_id
+		WHERE pp.pay_date > $1 AND inc.is_active = true
+	`, today).Scan(&next)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if next != nil {
+		s := next.Format("2006-01-02")
+		runway.NextPayDate = &s
+		runway.DaysUntilNextPay = int(next.Sub(today).Hours() / 24)
+	}
+
+	// Spread what is left over the days until the next paycheck, today included
+	if runway.DaysUntilNextPay > 0 && runway.Remaining > 0 {
+		runway.SafeToSpendPerDay = math.Floor(runway.Remaining/float64(runway.DaysUntilNextPay)*100) / 100
+	}
+
+	models.WriteJSON(w, http.StatusOK, runway)
+}
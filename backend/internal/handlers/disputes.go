@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// openDispute matches an assignment ba with an open dispute. Those are left
+// out of overdue and due-soon alerts until the dispute is resolved.
+const openDispute = `EXISTS (SELECT 1 FROM assignment_disputes ad WHERE ad.assignment_id = ba.id AND ad.resolved_on IS NULL)`
+
+const disputeCols = `id, assignment_id, opened_on, reference, expected_credit, resolved_on, credit_received, notes,
+		          created_at, updated_at`
+
+func scanDispute(scanner interface{ Scan(dest ...interface{}) error }, d *models.AssignmentDispute) error {
+	if err := scanner.Scan(&d.ID, &d.AssignmentID, &d.OpenedOn, &d.Reference, &d.ExpectedCredit, &d.ResolvedOn,
+		&d.CreditReceived, &d.Notes, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return err
+	}
+	d.Status = "open"
+	if d.ResolvedOn != nil {
+		d.Status = "resolved"
+	}
+	return nil
+}
+
+// Dispute returns the dispute on an assignment.
+// GET /api/v1/assignments/{id}/dispute
+func (h *AssignmentHandler) Dispute(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var d models.AssignmentDispute
+	err = scanDispute(h.db.QueryRow(r.Context(), `
+		SELECT `+disputeCols+` FROM assignment_disputes WHERE assignment_id = $1
+	`, id), &d)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment has no dispute")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, d)
+}
+
+// OpenDispute opens a dispute on an assignment, or edits the one it already
+// has. A resolved dispute stays resolved.
+// PUT /api/v1/assignments/{id}/dispute
+func (h *AssignmentHandler) OpenDispute(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.OpenDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	var openedOn *time.Time
+	if req.OpenedOn != nil {
+		parsed, err := time.Parse("2006-01-02", *req.OpenedOn)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "opened_on must be in YYYY-MM-DD format")
+			return
+		}
+		openedOn = &parsed
+	}
+	if req.ExpectedCredit != nil && *req.ExpectedCredit < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "expected_credit cannot be negative")
+		return
+	}
+	if req.Reference != nil && len(*req.Reference) > 100 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "reference must be at most 100 characters")
+		return
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var d models.AssignmentDispute
+	err = scanDispute(h.db.QueryRow(ctx, `
+		INSERT INTO assignment_disputes (assignment_id, opened_on, reference, expected_credit, notes)
+		SELECT id, COALESCE($2, $6::date), COALESCE($3, ''), COALESCE($4, 0), COALESCE($5, '')
+		FROM bill_assignments WHERE id = $1
+		ON CONFLICT (assignment_id) DO UPDATE SET
+			opened_on = COALESCE($2, assignment_disputes.opened_on),
+			reference = COALESCE($3, assignment_disputes.reference),
+			expected_credit = COALESCE($4, assignment_disputes.expected_credit),
+			notes = COALESCE($5, assignment_disputes.notes),
+			updated_at = NOW()
+		RETURNING `+disputeCols+`
+	`, id, openedOn, req.Reference, req.ExpectedCredit, req.Notes, today), &d)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, d)
+}
+
+// ResolveDispute closes an assignment's dispute with the credit received,
+// putting the assignment back into overdue alerts if it is still unpaid.
+// POST /api/v1/assignments/{id}/dispute/resolve
+func (h *AssignmentHandler) ResolveDispute(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.ResolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	credit := 0.0
+	if req.CreditReceived != nil {
+		credit = *req.CreditReceived
+	}
+	if credit < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "credit_received cannot be negative")
+		return
+	}
+	now := time.Now()
+	resolvedOn := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if req.ResolvedOn != nil {
+		resolvedOn, err = time.Parse("2006-01-02", *req.ResolvedOn)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "resolved_on must be in YYYY-MM-DD format")
+			return
+		}
+	}
+
+	var d models.AssignmentDispute
+	err = scanDispute(h.db.QueryRow(ctx, `
+		UPDATE assignment_disputes SET resolved_on = $2, credit_received = $3, updated_at = NOW()
+		WHERE assignment_id = $1
+		RETURNING `+disputeCols+`
+	`, id, resolvedOn, credit), &d)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment has no dispute")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, d)
+}
+
+// DeleteDispute drops an assignment's dispute, e.g. one opened by mistake.
+// DELETE /api/v1/assignments/{id}/dispute
+func (h *AssignmentHandler) DeleteDispute(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `DELETE FROM assignment_disputes WHERE assignment_id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "assignment has no dispute")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxDocumentBytes caps a stored document. Files live in the database and
+// in every backup, so this keeps the vault to scans and PDFs rather than
+// archives.
+const maxDocumentBytes = 10 << 20
+
+var documentKinds = map[string]bool{
+	"insurance": true,
+	"lease":     true,
+	"loan":      true,
+	"warranty":  true,
+	"tax":       true,
+	"other":     true,
+}
+
+type DocumentHandler struct {
+	db DBTX
+}
+
+func NewDocumentHandler(db DBTX) *DocumentHandler {
+	return &DocumentHandler{db: db}
+}
+
+const documentCols = `d.id, d.title, d.kind, d.filename, d.content_type, d.size_bytes, d.expires_on, d.remind_days,
+		       d.notes, ARRAY(SELECT db.bill_id FROM document_bills db WHERE db.document_id = d.id ORDER BY db.bill_id),
+		       d.created_at, d.updated_at`
+
+func scanDocument(scanner interface{ Scan(dest ...interface{}) error }, d *models.Document) error {
+	err := scanner.Scan(&d.ID, &d.Title, &d.Kind, &d.Filename, &d.ContentType, &d.SizeBytes, &d.ExpiresOn, &d.RemindDays,
+		&d.Notes, &d.BillIDs, &d.CreatedAt, &d.UpdatedAt)
+	if d.BillIDs == nil {
+		d.BillIDs = []int{}
+	}
+	return err
+}
+
+// List returns stored documents, soonest to expire first. bill_id limits it
+// to documents tagged to that bill, and expiring_within to those expiring in
+// the next N days.
+// GET /api/v1/documents?bill_id=&expiring_within=
+func (h *DocumentHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	var billID, within *int
+	if v := q.Get("bill_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "bill_id must be an integer")
+			return
+		}
+		billID = &n
+	}
+	if v := q.Get("expiring_within"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "expiring_within must be a number of days")
+			return
+		}
+		within = &n
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT `+documentCols+`
+		FROM documents d
+		WHERE ($1::int IS NULL OR EXISTS (
+				SELECT 1 FROM document_bills db WHERE db.document_id = d.id AND db.bill_id = $1))
+		  AND ($2::int IS NULL OR d.expires_on BETWEEN $3::date AND $3::date + $2::int)
+		ORDER BY d.expires_on NULLS LAST, d.title, d.id
+	`, billID, within, today)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	docs := []models.Document{}
+	for rows.Next() {
+		var d models.Document
+		if err := scanDocument(rows, &d); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		docs = append(docs, d)
+	}
+
+	models.WriteJSON(w, http.StatusOK, docs)
+}
+
+// Get returns a document's details and bill tags.
+// GET /api/v1/documents/{id}
+func (h *DocumentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var d models.Document
+	err = scanDocument(h.db.QueryRow(r.Context(), `SELECT `+documentCols+` FROM documents d WHERE d.id = $1`, id), &d)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "document not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, d)
+}
+
+// Create stores an uploaded document. The multipart form carries the file
+// with title, kind, expires_on (YYYY-MM-DD), remind_days, notes and bill_ids
+// (comma-separated) fields; all but the file are optional.
+// POST /api/v1/documents
+func (h *DocumentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDocumentBytes+1<<20)
+	r.ParseMultipartForm(maxDocumentBytes)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "NO_FILE", "no file uploaded")
+		return
+	}
+	defer file.Close()
+	if header.Size > maxDocumentBytes {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "documents are limited to 10MB")
+		return
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "FILE_ERROR", err.Error())
+		return
+	}
+
+	filename := filepath.Base(header.Filename)
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		title = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	kind := r.FormValue("kind")
+	if kind == "" {
+		kind = "other"
+	}
+	if !documentKinds[kind] {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "kind must be insurance, lease, loan, warranty, tax or other")
+		return
+	}
+	var expiresOn *time.Time
+	if v := r.FormValue("expires_on"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "expires_on must be in YYYY-MM-DD format")
+			return
+		}
+		expiresOn = &parsed
+	}
+	remindDays := 30
+	if v := r.FormValue("remind_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "remind_days must be an integer")
+			return
+		}
+		remindDays = n
+	}
+	if msg := validateRemindDays(remindDays); msg != "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+		return
+	}
+	billIDs, err := parseBillIDs(r.FormValue("bill_ids"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "bill_ids must be comma-separated integers")
+		return
+	}
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO documents (title, kind, filename, content_type, size_bytes, content, expires_on, remind_days, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, title, kind, filename, contentType, len(content), content, expiresOn, remindDays, r.FormValue("notes")).Scan(&id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if status, code, msg := tagDocument(ctx, tx, id, billIDs); status != 0 {
+		models.WriteError(w, status, code, msg)
+		return
+	}
+	var d models.Document
+	if err := scanDocument(tx.QueryRow(ctx, `SELECT `+documentCols+` FROM documents d WHERE d.id = $1`, id), &d); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, d)
+}
+
+// Update edits a document's details. bill_ids, when present, replaces its
+// bill tags, and an empty expires_on clears the expiry date. The file itself
+// can't be replaced; upload a new document instead.
+// PUT /api/v1/documents/{id}
+func (h *DocumentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "title cannot be empty")
+		return
+	}
+	if req.Kind != nil && !documentKinds[*req.Kind] {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "kind must be insurance, lease, loan, warranty, tax or other")
+		return
+	}
+	if req.RemindDays != nil {
+		if msg := validateRemindDays(*req.RemindDays); msg != "" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+			return
+		}
+	}
+	// expires_on is only touched when present; an empty string clears it
+	setExpires := req.ExpiresOn != nil
+	var expiresOn *time.Time
+	if setExpires && *req.ExpiresOn != "" {
+		parsed, err := time.Parse("2006-01-02", *req.ExpiresOn)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "expires_on must be in YYYY-MM-DD format")
+			return
+		}
+		expiresOn = &parsed
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE documents SET
+			title = COALESCE($2, title),
+			kind = COALESCE($3, kind),
+			expires_on = CASE WHEN $4 THEN $5::date ELSE expires_on END,
+			remind_days = COALESCE($6, remind_days),
+			notes = COALESCE($7, notes),
+			updated_at = NOW()
+		WHERE id = $1
+	`, id, req.Title, req.Kind, setExpires, expiresOn, req.RemindDays, req.Notes)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "document not found")
+		return
+	}
+	if req.BillIDs != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM document_bills WHERE document_id = $1`, id); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if status, code, msg := tagDocument(ctx, tx, id, *req.BillIDs); status != 0 {
+			models.WriteError(w, status, code, msg)
+			return
+		}
+	}
+	var d models.Document
+	if err := scanDocument(tx.QueryRow(ctx, `SELECT `+documentCols+` FROM documents d WHERE d.id = $1`, id), &d); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, d)
+}
+
+// Delete removes a document and its bill tags.
+// DELETE /api/v1/documents/{id}
+func (h *DocumentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `DELETE FROM documents WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "document not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// File downloads a document as it was uploaded. It is always sent as an
+// attachment so an uploaded HTML or SVG file isn't rendered by the app.
+// GET /api/v1/documents/{id}/file
+func (h *DocumentHandler) File(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var filename, contentType string
+	var content []byte
+	err = h.db.QueryRow(r.Context(), `
+		SELECT filename, content_type, content FROM documents WHERE id = $1
+	`, id).Scan(&filename, &contentType, &content)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "document not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(content)
+}
+
+// tagDocument tags a document to bills, returning a non-zero status when one
+// of them doesn't exist.
+func tagDocument(ctx context.Context, tx pgx.Tx, documentID int, billIDs []int) (status int, code, msg string) {
+	ids := uniqueInts(billIDs)
+	if len(ids) == 0 {
+		return 0, "", ""
+	}
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO document_bills (document_id, bill_id)
+		SELECT $1, id FROM bills WHERE id = ANY($2)
+	`, documentID, ids)
+	if err != nil {
+		return http.StatusInternalServerError, "DB_ERROR", err.Error()
+	}
+	if int(tag.RowsAffected()) != len(ids) {
+		return http.StatusBadRequest, "VALIDATION_ERROR", "bill_ids contains a bill that doesn't exist"
+	}
+	return 0, "", ""
+}
+
+func validateRemindDays(days int) string {
+	if days < 0 || days > 365 {
+		return "remind_days must be between 0 and 365"
+	}
+	return ""
+}
+
+// parseBillIDs reads a comma-separated list of bill ids from a form field.
+func parseBillIDs(s string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func uniqueInts(xs []int) []int {
+	seen := make(map[int]bool, len(xs))
+	out := make([]int, 0, len(xs))
+	for _, x := range xs {
+		if !seen[x] {
+			seen[x] = true
+			out = append(out, x)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// Duplicates finds pending assignments that cover the same occurrence of a
+// bill in the same pay period, left behind by older bugs and imports, and
+// proposes keeping one of each set: a manually moved row first, then one with
+// a due date, then the oldest. Rows are the same occurrence when their due
+// dates match or one has none; a bill falling due twice in a period, as
+// biweekly and custom-period bills can, is not a duplicate. Extras and sinking
+// fund installments are left out.
+// GET /api/v1/admin/duplicates
+func (h *AdminHandler) Duplicates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rows, err := h.db.Query(ctx, `
+		SELECT `+assignmentSelectCols+`, b.name, pp.pay_date
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status = 'pending' AND NOT ba.is_extra AND NOT ba.is_sinking_fund
+		  AND EXISTS (
+			SELECT 1 FROM bill_assignments o
+			WHERE o.bill_id = ba.bill_id AND o.pay_period_id = ba.pay_period_id AND o.id <> ba.id
+			  AND o.status = 'pending' AND NOT o.is_extra AND NOT o.is_sinking_fund
+			  AND (o.due_date IS NULL OR ba.due_date IS NULL OR o.due_date = ba.due_date)
+		  )
+		ORDER BY pp.pay_date, ba.pay_period_id, ba.bill_id,
+		         ba.manually_moved DESC, ba.due_date IS NULL, ba.id
+	`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	// Rows arrive by bill+period and best-first, so the first of a group is
+	// kept. Within a bill+period each due date is its own group; rows without
+	// one join the group of the best dated row, or form one if none is dated.
+	type dupRow struct {
+		a       models.BillAssignment
+		payDate time.Time
+	}
+	groups := []models.DuplicateAssignmentGroup{}
+	var period []dupRow
+	flush := func() {
+		undated := ""
+		for _, r := range period {
+			if r.a.DueDate != nil {
+				undated = r.a.DueDate.Format("2006-01-02")
+				break
+			}
+		}
+		var set []models.DuplicateAssignmentGroup
+		index := make(map[string]int)
+		for _, r := range period {
+			key := undated
+			if r.a.DueDate != nil {
+				key = r.a.DueDate.Format("2006-01-02")
+			}
+			i, ok := index[key]
+			if !ok {
+				set = append(set, models.DuplicateAssignmentGroup{
+					BillID:      r.a.BillID,
+					BillName:    r.a.BillName,
+					PayPeriodID: r.a.PayPeriodID,
+					PayDate:     r.payDate.Format("2006-01-02"),
+					KeepID:      r.a.ID,
+					MergeIDs:    []int{},
+				})
+				i = len(set) - 1
+				index[key] = i
+			} else {
+				set[i].MergeIDs = append(set[i].MergeIDs, r.a.ID)
+			}
+			set[i].Assignments = append(set[i].Assignments, r.a)
+		}
+		for _, g := range set {
+			if len(g.MergeIDs) > 0 {
+				groups = append(groups, g)
+			}
+		}
+		period = period[:0]
+	}
+	for rows.Next() {
+		var r dupRow
+		if err := rows.Scan(append(assignmentScanDest(&r.a), &r.a.BillName, &r.payDate)...); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if len(period) > 0 && (period[0].a.BillID != r.a.BillID || period[0].a.PayPeriodID != r.a.PayPeriodID) {
+			flush()
+		}
+		period = append(period, r)
+	}
+	flush()
+
+	models.WriteJSON(w, http.StatusOK, groups)
+}
+
+// MergeDuplicates folds each set of merge_ids into its keep_id in a single
+// transaction: linked transactions move to the kept assignment, which takes
+// a planned amount or due date it lacks from the others and gains their
+// notes, and the others are deleted. Every assignment must still be pending
+// and share the kept one's bill, period and, where both have one, due date.
+// POST /api/v1/admin/duplicates/merge
+func (h *AdminHandler) MergeDuplicates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.MergeDuplicatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if len(req.Merges) == 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "no merges specified")
+		return
+	}
+	seen := make(map[int]bool)
+	for i, m := range req.Merges {
+		if m.KeepID <= 0 || len(m.MergeIDs) == 0 {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("merges[%d]: keep_id and merge_ids are required", i))
+			return
+		}
+		for _, id := range append([]int{m.KeepID}, m.MergeIDs...) {
+			if seen[id] {
+				models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("merges[%d]: assignment %d appears more than once", i, id))
+				return
+			}
+			seen[id] = true
+		}
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	type row struct {
+		billID, periodID int
+		status           string
+		planned          *float64
+		notes            string
+		dueDate          *time.Time
+	}
+
+	result := models.MergeDuplicatesResult{}
+	for _, m := range req.Merges {
+		ids := append([]int{m.KeepID}, m.MergeIDs...)
+		rows, err := tx.Query(ctx, `
+			SELECT id, bill_id, pay_period_id, status, planned_amount, COALESCE(notes, ''), due_date
+			FROM bill_assignments WHERE id = ANY($1)
+			FOR UPDATE
+		`, ids)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		found := make(map[int]row, len(ids))
+		for rows.Next() {
+			var id int
+			var rw row
+			if err := rows.Scan(&id, &rw.billID, &rw.periodID, &rw.status, &rw.planned, &rw.notes, &rw.dueDate); err != nil {
+				rows.Close()
+				models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+				return
+			}
+			found[id] = rw
+		}
+		rows.Close()
+
+		keep, ok := found[m.KeepID]
+		if !ok {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("assignment %d not found", m.KeepID))
+			return
+		}
+		notes := []string{}
+		if keep.notes != "" {
+			notes = append(notes, keep.notes)
+		}
+		for _, id := range ids {
+			rw, ok := found[id]
+			if !ok {
+				models.WriteError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("assignment %d not found", id))
+				return
+			}
+			if rw.status != "pending" || rw.billID != keep.billID || rw.periodID != keep.periodID {
+				models.WriteError(w, http.StatusConflict, "CONFLICT",
+					fmt.Sprintf("assignment %d is no longer a pending duplicate of %d", id, m.KeepID))
+				return
+			}
+			if id == m.KeepID {
+				continue
+			}
+			// Two due dates are two occurrences of the bill, not duplicates
+			if rw.dueDate != nil && keep.dueDate != nil && !rw.dueDate.Equal(*keep.dueDate) {
+				models.WriteError(w, http.StatusConflict, "CONFLICT",
+					fmt.Sprintf("assignment %d is due on a different date than %d", id, m.KeepID))
+				return
+			}
+			if keep.planned == nil {
+				keep.planned = rw.planned
+			}
+			if keep.dueDate == nil {
+				keep.dueDate = rw.dueDate
+			}
+			if rw.notes != "" && !strings.Contains(strings.Join(notes, "; "), rw.notes) {
+				notes = append(notes, rw.notes)
+			}
+		}
+
+		tag, err := tx.Exec(ctx, `UPDATE transactions SET assignment_id = $1, updated_at = NOW() WHERE assignment_id = ANY($2)`, m.KeepID, m.MergeIDs)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		result.Relinked += int(tag.RowsAffected())
+
+		// Delete before updating, so a due date taken from a merged row
+		// doesn't collide with it on the occurrence index
+		tag, err = tx.Exec(ctx, `DELETE FROM bill_assignments WHERE id = ANY($1)`, m.MergeIDs)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		result.Merged += int(tag.RowsAffected())
+
+		_, err = tx.Exec(ctx, `
+			UPDATE bill_assignments SET planned_amount = $2, notes = $3, due_date = $4, updated_at = NOW()
+			WHERE id = $1
+		`, m.KeepID, keep.planned, strings.Join(notes, "; "), keep.dueDate)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// ExportHandler serves paid assignments in formats other accounting tools import.
+type ExportHandler struct {
+	db DBTX
+}
+
+func NewExportHandler(db DBTX) *ExportHandler {
+	return &ExportHandler{db: db}
+}
+
+// ExportRecord is one paid assignment as written to an export file.
+type ExportRecord struct {
+	AssignmentID int
+	Date         time.Time
+	Payee        string
+	Category     string
+	Amount       float64
+}
+
+// IncomeRecord is one received paycheck as written to an export file.
+type IncomeRecord struct {
+	PeriodID int
+	Date     time.Time
+	Source   string
+	Amount   float64
+}
+
+// Accounts used by the double-entry export. Expenses and income get one
+// sub-account per bill category and income source.
+const (
+	gnucashCheckingAccount = "Assets:Checking"
+	gnucashExpenseAccount  = "Expenses"
+	gnucashIncomeAccount   = "Income"
+)
+
+// QIF exports paid assignments as a Quicken Interchange Format bank register.
+// GET /api/v1/export/qif?from=YYYY-MM-DD&to=YYYY-MM-DD (both optional)
+func (h *ExportHandler) QIF(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := exportRange(w, r)
+	if !ok {
+		return
+	}
+	records, err := h.paidRecords(r.Context(), from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/qif")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="budget-%s.qif"`, time.Now().Format("2006-01-02")))
+	w.WriteHeader(http.StatusOK)
+	writeQIF(w, records)
+}
+
+// GnuCash exports paid assignments and received paychecks as a double-entry
+// CSV in GnuCash's multi-split layout: two rows per transaction sharing a
+// transaction ID, one per account, with amounts that balance to zero.
+// GET /api/v1/export/gnucash?from=YYYY-MM-DD&to=YYYY-MM-DD (both optional)
+func (h *ExportHandler) GnuCash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	from, to, ok := exportRange(w, r)
+	if !ok {
+		return
+	}
+	records, err := h.paidRecords(ctx, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	income, err := h.incomeRecords(ctx, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="budget-gnucash-%s.csv"`, time.Now().Format("2006-01-02")))
+	w.WriteHeader(http.StatusOK)
+	writeGnuCashCSV(w, records, income)
+}
+
+// exportRange parses the optional from/to query parameters, writing a
+// validation error and returning false when either is malformed.
+func exportRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	from, ok := dateQueryParam(w, r, "from", time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to, ok := dateQueryParam(w, r, "to", time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// dateQueryParam parses an optional YYYY-MM-DD query parameter, writing a
+// validation error and returning false when it is malformed.
+func dateQueryParam(w http.ResponseWriter, r *http.Request, name string, def time.Time) (time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, true
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", name+" must be in YYYY-MM-DD format")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// paidRecords loads paid assignments dated between from and to. A record is
+// dated when it was paid, or by its pay date for payments recorded without one.
+func (h *ExportHandler) paidRecords(ctx context.Context, from, to time.Time) ([]ExportRecord, error) {
+	rows, err := h.db.Query(ctx, `
+		SELECT ba.id, COALESCE(ba.paid_date, pp.pay_date) AS paid_on,
+		       CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       COALESCE(b.category, ''),
+		       COALESCE(ba.actual_amount, ba.planned_amount, 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status = 'paid'
+		  AND COALESCE(ba.paid_date, pp.pay_date) BETWEEN $1 AND $2
+		ORDER BY paid_on, ba.id
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []ExportRecord
+	for rows.Next() {
+		var rec ExportRecord
+		if err := rows.Scan(&rec.AssignmentID, &rec.Date, &rec.Payee, &rec.Category, &rec.Amount); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// incomeRecords loads paychecks dated between from and to that have already
+// arrived, valued at the actual amount when recorded and the expected one otherwise.
+func (h *ExportHandler) incomeRecords(ctx context.Context, from, to time.Time) ([]IncomeRecord, error) {
+	rows, err := h.db.Query(ctx, `
+		SELECT pp.id, pp.pay_date, i.name, COALESCE(pp.actual_amount, pp.expected_amount)
+		FROM pay_periods pp
+		JOIN income_sources i ON i.id = pp.income_source_id
+		WHERE pp.pay_date BETWEEN $1 AND $2
+		  AND pp.pay_date <= CURRENT_DATE
+		  AND COALESCE(pp.actual_amount, pp.expected_amount, 0) > 0
+		ORDER BY pp.pay_date, pp.id
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []IncomeRecord
+	for rows.Next() {
+		var rec IncomeRecord
+		if err := rows.Scan(&rec.PeriodID, &rec.Date, &rec.Source, &rec.Amount); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// writeQIF writes records as a QIF bank register. Payments are negative
+// amounts; the category line is omitted for uncategorized bills.
+func writeQIF(w io.Writer, records []ExportRecord) {
+	fmt.Fprint(w, "!Type:Bank\n")
+	for _, rec := range records {
+		fmt.Fprintf(w, "D%s\n", rec.Date.Format("01/02/2006"))
+		fmt.Fprintf(w, "T%s\n", strconv.FormatFloat(-rec.Amount, 'f', 2, 64))
+		fmt.Fprintf(w, "P%s\n", qifText(rec.Payee))
+		if rec.Category != "" {
+			fmt.Fprintf(w, "L%s\n", qifText(rec.Category))
+		}
+		fmt.Fprint(w, "^\n")
+	}
+}
+
+// qifText keeps a field on one line, since QIF is line-oriented.
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writeGnuCashCSV writes paychecks (checking debited, income credited) and
+// payments (expense debited, checking credited) in date order.
+func writeGnuCashCSV(w io.Writer, records []ExportRecord, income []IncomeRecord) {
+	type split struct {
+		account string
+		amount  float64
+	}
+	type txn struct {
+		date        time.Time
+		id          string
+		description string
+		splits      [2]split
+	}
+
+	txns := make([]txn, 0, len(records)+len(income))
+	for _, rec := range income {
+		txns = append(txns, txn{
+			date:        rec.Date,
+			id:          "pp-" + strconv.Itoa(rec.PeriodID),
+			description: rec.Source + " paycheck",
+			splits: [2]split{
+				{gnucashCheckingAccount, rec.Amount},
+				{gnucashAccount(gnucashIncomeAccount, rec.Source), -rec.Amount},
+			},
+		})
+	}
+	for _, rec := range records {
+		category := rec.Category
+		if category == "" {
+			category = "Uncategorized"
+		}
+		txns = append(txns, txn{
+			date:        rec.Date,
+			id:          "ba-" + strconv.Itoa(rec.AssignmentID),
+			description: rec.Payee,
+			splits: [2]split{
+				{gnucashAccount(gnucashExpenseAccount, category), rec.Amount},
+				{gnucashCheckingAccount, -rec.Amount},
+			},
+		})
+	}
+	// Paychecks sort ahead of same-day payments
+	sort.SliceStable(txns, func(i, j int) bool { return txns[i].date.Before(txns[j].date) })
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Date", "Transaction ID", "Description", "Account", "Amount"})
+	for _, t := range txns {
+		for _, s := range t.splits {
+			cw.Write([]string{t.date.Format("2006-01-02"), t.id, t.description, s.account,
+				strconv.FormatFloat(s.amount, 'f', 2, 64)})
+		}
+	}
+	cw.Flush()
+}
+
+// gnucashAccount names a sub-account of parent. GnuCash separates account
+// levels with ':', so it cannot appear in the name itself.
+func gnucashAccount(parent, name string) string {
+	return parent + ":" + strings.ReplaceAll(strings.TrimSpace(name), ":", "-")
+}
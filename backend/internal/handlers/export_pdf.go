@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// maxSheetExportDays bounds the pay dates a single budget sheet export covers.
+const maxSheetExportDays = 366
+
+// SheetLine is one assignment on a pay period's sheet.
+type SheetLine struct {
+	AssignmentID int
+	BillID       int
+	Name         string
+	DueDate      *time.Time
+	Amount       float64 // net of shares others pay
+	Status       string
+	Notes        string
+}
+
+// PeriodSheet is a paycheck and the bills paid from it, laid out like a
+// column of the original budget spreadsheet.
+type PeriodSheet struct {
+	PeriodID int
+	PayDate  time.Time
+	Source   string
+	Income   float64 // the actual amount once received, else the expected
+	Lines    []SheetLine
+	Spent    float64 // every line but skipped and deferred ones
+	Leftover float64
+}
+
+// periodSheets loads the pay periods dated from through to with their
+// assignments in bill order. Amounts prefer the actual, then the forecast,
+// then the planned amount.
+func (h *ExportHandler) periodSheets(ctx context.Context, from, to time.Time) ([]PeriodSheet, error) {
+	rows, err := h.db.Query(ctx, `
+		SELECT pp.id, pp.pay_date, COALESCE(inc.name, ''), COALESCE(pp.actual_amount, pp.expected_amount, 0),
+		       ba.id, ba.bill_id, CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       ba.due_date, `+netAssignmentAmount(models.AmountModeActualPreferred)+`, ba.status, COALESCE(ba.notes, '')
+		FROM pay_periods pp
+		LEFT JOIN income_sources inc ON inc.id = pp.income_source_id
+		LEFT JOIN (bill_assignments ba JOIN bills b ON b.id = ba.bill_id) ON ba.pay_period_id = pp.id
+		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2
+		ORDER BY pp.pay_date, pp.id, b.sort_order, ba.due_date NULLS FIRST, ba.id
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sheets []PeriodSheet
+	for rows.Next() {
+		var s PeriodSheet
+		var assignmentID, billID *int
+		var name, status, notes *string
+		var dueDate *time.Time
+		var amount *float64
+		if err := rows.Scan(&s.PeriodID, &s.PayDate, &s.Source, &s.Income,
+			&assignmentID, &billID, &name, &dueDate, &amount, &status, &notes); err != nil {
+			return nil, err
+		}
+		if len(sheets) == 0 || sheets[len(sheets)-1].PeriodID != s.PeriodID {
+			sheets = append(sheets, s)
+		}
+		if assignmentID == nil {
+			continue
+		}
+		line := SheetLine{AssignmentID: *assignmentID, DueDate: dueDate, Status: *status}
+		if billID != nil {
+			line.BillID = *billID
+		}
+		if notes != nil {
+			line.Notes = *notes
+		}
+		if name != nil {
+			line.Name = *name
+		}
+		if amount != nil {
+			line.Amount = roundCents(*amount)
+		}
+		sheet := &sheets[len(sheets)-1]
+		sheet.Lines = append(sheet.Lines, line)
+		if line.Status != "skipped" && line.Status != "deferred" {
+			sheet.Spent = roundCents(sheet.Spent + line.Amount)
+		}
+	}
+	for i := range sheets {
+		sheets[i].Leftover = roundCents(sheets[i].Income - sheets[i].Spent)
+	}
+	return sheets, rows.Err()
+}
+
+// PDF exports a printable budget sheet: one block per pay period with its
+// bills, amounts and statuses, and what is left of the paycheck.
+// GET /api/v1/export/pdf?from=YYYY-MM-DD&to=YYYY-MM-DD (defaults to today
+// through three months from now, at most a year)
+func (h *ExportHandler) PDF(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	from, to, ok := sheetRange(w, r, now)
+	if !ok {
+		return
+	}
+
+	sheets, err := h.periodSheets(r.Context(), from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	var doc bytes.Buffer
+	if err := writeSheetPDF(&doc, sheets, from, to, now); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="budget-sheet-%s-to-%s.pdf"`,
+		from.Format("2006-01-02"), to.Format("2006-01-02")))
+	doc.WriteTo(w)
+}
+
+// sheetRange parses the from/to pay dates of a budget sheet export,
+// defaulting to today through three months on, and writes a validation
+// error and returns false when they are malformed or span a year or more.
+func sheetRange(w http.ResponseWriter, r *http.Request, now time.Time) (time.Time, time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from, ok := dateQueryParam(w, r, "from", today)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	to, ok := dateQueryParam(w, r, "to", from.AddDate(0, 3, 0))
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Sub(from).Hours()/24 >= maxSheetExportDays {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("range must be under %d days", maxSheetExportDays))
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// Column widths of a sheet block in millimetres, filling a Letter page
+// between 15 mm margins.
+const (
+	sheetNameWidth   = 92.0
+	sheetDueWidth    = 30.0
+	sheetAmountWidth = 32.0
+	sheetStatusWidth = 31.9
+	sheetRowHeight   = 6.0
+)
+
+// writeSheetPDF renders sheets as a Letter-size PDF. A pay period's block is
+// moved to a new page rather than split when it won't fit on the current one.
+func writeSheetPDF(w io.Writer, sheets []PeriodSheet, from, to, generated time.Time) error {
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetTitle("Budget sheet", true)
+	pdf.SetCreationDate(generated)
+	pdf.AliasNbPages("")
+	// Bill names are UTF-8; the core fonts are cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Generated %s - page %d of {nb}", generated.Format("January 2, 2006"), pdf.PageNo()),
+			"", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 9, "Budget sheet", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Pay dates %s to %s", from.Format("Jan 2, 2006"), to.Format("Jan 2, 2006")),
+		"", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	if len(sheets) == 0 {
+		pdf.CellFormat(0, 6, "No pay periods in this range.", "", 1, "L", false, 0, "")
+	}
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	for _, s := range sheets {
+		rows := len(s.Lines)
+		if rows == 0 {
+			rows = 1
+		}
+		// Title, column headings, lines and the two total rows
+		need := 8 + sheetRowHeight*float64(rows+3) + 6
+		if pdf.GetY()+need > pageHeight-bottom {
+			pdf.AddPage()
+		}
+
+		pdf.SetFont("Helvetica", "B", 12)
+		title := s.PayDate.Format("Monday, January 2, 2006")
+		if s.Source != "" {
+			title += " - " + s.Source
+		}
+		pdf.CellFormat(sheetNameWidth+sheetDueWidth, 8, tr(title), "", 0, "L", false, 0, "")
+		pdf.CellFormat(sheetAmountWidth+sheetStatusWidth, 8, "Income "+sheetMoney(s.Income), "", 1, "R", false, 0, "")
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(sheetNameWidth, sheetRowHeight, "Bill", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(sheetDueWidth, sheetRowHeight, "Due", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(sheetAmountWidth, sheetRowHeight, "Amount", "1", 0, "R", true, 0, "")
+		pdf.CellFormat(sheetStatusWidth, sheetRowHeight, "Status", "1", 1, "L", true, 0, "")
+
+		pdf.SetFont("Helvetica", "", 9)
+		if len(s.Lines) == 0 {
+			pdf.CellFormat(0, sheetRowHeight, "No bills assigned", "1", 1, "L", false, 0, "")
+		}
+		for _, line := range s.Lines {
+			due := ""
+			if line.DueDate != nil {
+				due = line.DueDate.Format("Jan 2")
+			}
+			// Lines that don't count against the paycheck are greyed out
+			if line.Status == "skipped" || line.Status == "deferred" {
+				pdf.SetTextColor(140, 140, 140)
+			}
+			pdf.CellFormat(sheetNameWidth, sheetRowHeight, tr(line.Name), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(sheetDueWidth, sheetRowHeight, due, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(sheetAmountWidth, sheetRowHeight, sheetMoney(line.Amount), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(sheetStatusWidth, sheetRowHeight, line.Status, "1", 1, "L", false, 0, "")
+			pdf.SetTextColor(0, 0, 0)
+		}
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(sheetNameWidth+sheetDueWidth, sheetRowHeight, "Total bills", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(sheetAmountWidth, sheetRowHeight, sheetMoney(s.Spent), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(sheetStatusWidth, sheetRowHeight, "", "1", 1, "L", false, 0, "")
+		pdf.CellFormat(sheetNameWidth+sheetDueWidth, sheetRowHeight, "Leftover", "1", 0, "R", false, 0, "")
+		if s.Leftover < 0 {
+			pdf.SetTextColor(180, 0, 0)
+		}
+		pdf.CellFormat(sheetAmountWidth, sheetRowHeight, sheetMoney(s.Leftover), "1", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(sheetStatusWidth, sheetRowHeight, "", "1", 1, "L", false, 0, "")
+		pdf.Ln(6)
+	}
+
+	return pdf.Output(w)
+}
+
+// sheetMoney formats an amount the way the spreadsheet did, negatives with a
+// leading minus.
+func sheetMoney(v float64) string {
+	if v < 0 {
+		return fmt.Sprintf("-$%.2f", -v)
+	}
+	return fmt.Sprintf("$%.2f", v)
+}
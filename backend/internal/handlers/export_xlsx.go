@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// XLSX exports the budget as a workbook in the legacy spreadsheet layout the
+// importer reads, so it can be edited in Excel and imported again: bills down
+// column A, one group of columns per pay period with amounts and **paid and
+// |--> markers.
+// GET /api/v1/export/xlsx?from=YYYY-MM-DD&to=YYYY-MM-DD (defaults to today
+// through three months from now, at most a year)
+func (h *ExportHandler) XLSX(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+	from, to, ok := sheetRange(w, r, now)
+	if !ok {
+		return
+	}
+
+	sheets, err := h.periodSheets(ctx, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	ids, bills, err := h.sheetBills(ctx, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	var doc bytes.Buffer
+	if err := services.WriteXLSX(&doc, bills, xlsxPeriods(sheets, ids)); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="budget-%s-to-%s.xlsx"`,
+		from.Format("2006-01-02"), to.Format("2006-01-02")))
+	doc.WriteTo(w)
+}
+
+// sheetBills loads the active bills, and any inactive ones still assigned to
+// a pay period dated from through to, in bill order. ids holds each bill's id
+// at its row.
+func (h *ExportHandler) sheetBills(ctx context.Context, from, to time.Time) ([]int, []services.XLSXBill, error) {
+	rows, err := h.db.Query(ctx, `
+		SELECT b.id, b.name, b.due_day, b.is_autopay, b.default_amount, b.recurrence,
+		       cc.card_label, cc.statement_day
+		FROM bills b
+		LEFT JOIN credit_cards cc ON cc.bill_id = b.id
+		WHERE b.is_active OR EXISTS (
+			SELECT 1 FROM bill_assignments ba
+			JOIN pay_periods pp ON pp.id = ba.pay_period_id
+			WHERE ba.bill_id = b.id AND pp.pay_date >= $1 AND pp.pay_date <= $2
+		)
+		ORDER BY b.sort_order, b.id
+	`, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	var bills []services.XLSXBill
+	for rows.Next() {
+		var id int
+		var b services.XLSXBill
+		var recurrence string
+		var cardLabel *string
+		var statementDay *int
+		if err := rows.Scan(&id, &b.Name, &b.DueDay, &b.IsAutopay, &b.DefaultAmt, &recurrence,
+			&cardLabel, &statementDay); err != nil {
+			return nil, nil, err
+		}
+		b.Biweekly = recurrence == "biweekly"
+		if statementDay != nil {
+			b.CreditCard = &services.ParsedCreditCard{StatementDay: *statementDay, Issuer: b.Name}
+			if cardLabel != nil {
+				b.CreditCard.CardLabel = *cardLabel
+			}
+		}
+		ids = append(ids, id)
+		bills = append(bills, b)
+	}
+	return ids, bills, rows.Err()
+}
+
+// xlsxPeriods lays each sheet's lines out against the bill rows. A bill
+// assigned twice in one period gets one cell with the amounts summed, paid
+// only when both are.
+func xlsxPeriods(sheets []PeriodSheet, billIDs []int) []services.XLSXPeriod {
+	row := make(map[int]int, len(billIDs))
+	for i, id := range billIDs {
+		row[id] = i
+	}
+	periods := make([]services.XLSXPeriod, 0, len(sheets))
+	for _, s := range sheets {
+		p := services.XLSXPeriod{
+			PayDate:  s.PayDate,
+			Source:   s.Source,
+			Income:   s.Income,
+			Spent:    s.Spent,
+			Leftover: s.Leftover,
+			Cells:    make([]*services.XLSXCell, len(billIDs)),
+		}
+		for _, line := range s.Lines {
+			i, ok := row[line.BillID]
+			if !ok {
+				continue
+			}
+			amount := line.Amount
+			c := p.Cells[i]
+			if c == nil {
+				p.Cells[i] = &services.XLSXCell{Amount: &amount, Status: line.Status, Due: line.DueDate, Note: line.Notes}
+				continue
+			}
+			total := roundCents(*c.Amount + amount)
+			c.Amount = &total
+			if c.Status != line.Status {
+				c.Status = "pending"
+			}
+			if c.Note == "" {
+				c.Note = line.Notes
+			}
+		}
+		periods = append(periods, p)
+	}
+	return periods
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Fixture sets are backups restored over an emptied database, so end-to-end
+// suites can start every test from known data.
+//
+//go:embed fixtures/*.json
+var fixtureFS embed.FS
+
+type FixtureHandler struct {
+	db DBTX
+}
+
+// NewFixtureHandler serves the fixture endpoints. They wipe the database, so
+// the router only mounts them when TEST_FIXTURES_ENABLED is set.
+func NewFixtureHandler(db DBTX) *FixtureHandler {
+	return &FixtureHandler{db: db}
+}
+
+// fixtureNames lists the embedded fixture sets.
+func fixtureNames() []string {
+	entries, _ := fixtureFS.ReadDir("fixtures")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadFixture decodes a fixture set, returning fs.ErrNotExist for unknown names.
+func loadFixture(name string) (models.Backup, error) {
+	var b models.Backup
+	raw, err := fixtureFS.ReadFile(path.Join("fixtures", path.Base(name)+".json"))
+	if err != nil {
+		return b, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	err = dec.Decode(&b)
+	return b, err
+}
+
+// resetDatabase empties every table but the migration log and restarts their
+// id sequences.
+func resetDatabase(ctx context.Context, tx pgx.Tx) error {
+	rows, err := tx.Query(ctx, `
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+		  AND table_name <> 'schema_migrations'
+		ORDER BY table_name
+	`)
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, pgx.Identifier{name}.Sanitize())
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	_, err = tx.Exec(ctx, `TRUNCATE `+strings.Join(tables, ", ")+` RESTART IDENTITY CASCADE`)
+	return err
+}
+
+// List returns the names of the fixture sets that can be loaded.
+// GET /api/v1/test/fixtures
+func (h *FixtureHandler) List(w http.ResponseWriter, r *http.Request) {
+	models.WriteJSON(w, http.StatusOK, fixtureNames())
+}
+
+// Load empties the database and seeds it with a fixture set, keeping the
+// fixture's ids so tests can refer to rows directly.
+// POST /api/v1/test/fixtures/{name}
+func (h *FixtureHandler) Load(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "name")
+
+	fixture, err := loadFixture(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "unknown fixture "+name+"; have "+strings.Join(fixtureNames(), ", "))
+			return
+		}
+		models.WriteError(w, http.StatusInternalServerError, "FIXTURE_ERROR", err.Error())
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if err := resetDatabase(ctx, tx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	result, err := restoreBackup(ctx, tx, fixture, backupReplace)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
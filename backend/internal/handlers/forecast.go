@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// maxForecastDays bounds the range of a single forecast.
+const maxForecastDays = 366
+
+type ForecastHandler struct {
+	db     DBTX
+	events *events.Bus
+
+	mu           sync.Mutex
+	lastNegative string // first negative date last published; "" while the forecast stays positive
+}
+
+func NewForecastHandler(db DBTX) *ForecastHandler {
+	return &ForecastHandler{db: db}
+}
+
+// WithEvents sets the bus that negative forecasts are published to.
+func (h *ForecastHandler) WithEvents(bus *events.Bus) *ForecastHandler {
+	h.events = bus
+	return h
+}
+
+// ForecastAlert is published when a forecast first dips below zero, and again
+// whenever the first negative date moves.
+type ForecastAlert struct {
+	From              string  `json:"from"`
+	To                string  `json:"to"`
+	StartingBalance   float64 `json:"starting_balance"`
+	FirstNegativeDate string  `json:"first_negative_date"`
+	LowestBalance     float64 `json:"lowest_balance"`
+	LowestDate        string  `json:"lowest_date"`
+}
+
+// publishNegative publishes result if it goes negative on a different first
+// date than last time, so repeatedly viewing the same forecast alerts once.
+func (h *ForecastHandler) publishNegative(result services.ForecastResult, from, to time.Time) {
+	first := ""
+	if result.FirstNegativeDate != nil {
+		first = *result.FirstNegativeDate
+	}
+
+	h.mu.Lock()
+	changed := first != h.lastNegative
+	h.lastNegative = first
+	h.mu.Unlock()
+
+	if !changed || first == "" {
+		return
+	}
+	h.events.Publish(events.ForecastNegative, ForecastAlert{
+		From:              from.Format("2006-01-02"),
+		To:                to.Format("2006-01-02"),
+		StartingBalance:   result.StartingBalance,
+		FirstNegativeDate: first,
+		LowestBalance:     result.LowestBalance,
+		LowestDate:        result.LowestDate,
+	})
+}
+
+// Forecast projects the daily balance from a starting balance, adding
+// paychecks on their pay dates and income events on theirs, and subtracting bill assignments on the day
+// they are paid, scheduled, or planned (the pay date), in that order.
+// GET /api/v1/forecast?starting_balance=1500&from=YYYY-MM-DD&to=YYYY-MM-DD
+// (from defaults to today, to to 60 days later). ?mode= picks the assignment
+// amount counted, default actual_preferred.
+func (h *ForecastHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	startingBalance := 0.0
+	if v := q.Get("starting_balance"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "starting_balance must be a number")
+			return
+		}
+		startingBalance = f
+	}
+
+	now := time.Now()
+	from, ok := dateQueryParam(w, r, "from", time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return
+	}
+	to, ok := dateQueryParam(w, r, "to", from.AddDate(0, 0, 60))
+	if !ok {
+		return
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return
+	}
+	if to.Sub(from).Hours()/24 >= maxForecastDays {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "forecast range must be at most 366 days")
+		return
+	}
+	mode, ok := amountModeParam(w, r, models.AmountModeActualPreferred)
+	if !ok {
+		return
+	}
+
+	// Income: same-date paydays from several sources arrive as one deposit
+	rows, err := h.db.Query(ctx, `
+		SELECT pp.id, pp.pay_date, COALESCE(pp.actual_amount, pp.expected_amount), inc.name
+		FROM pay_periods pp
+		JOIN income_sources inc ON inc.id = pp.income_source_id
+		WHERE pp.pay_date BETWEEN $1 AND $2 AND inc.is_active = true
+		ORDER BY pp.pay_date, pp.id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	var periods []models.PayPeriod
+	for rows.Next() {
+		var p models.PayPeriod
+		if err := rows.Scan(&p.ID, &p.PayDate, &p.ExpectedAmount, &p.SourceName); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		periods = append(periods, p)
+	}
+	rows.Close()
+
+	var flows []services.ForecastFlow
+	for _, p := range services.AggregatePeriods(periods) {
+		if p.ExpectedAmount == nil {
+			continue
+		}
+		flows = append(flows, services.ForecastFlow{
+			Date: p.PayDate, Amount: *p.ExpectedAmount, Kind: "income", Label: p.SourceName, RefID: p.ID,
+		})
+	}
+
+	// One-off income such as bonuses arrives on its own date
+	rows, err = h.db.Query(ctx, `
+		SELECT id, event_date, name, amount FROM income_events
+		WHERE event_date BETWEEN $1 AND $2
+		ORDER BY event_date, id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f := services.ForecastFlow{Kind: "income_event"}
+		if err := rows.Scan(&f.RefID, &f.Date, &f.Label, &f.Amount); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		flows = append(flows, f)
+	}
+	rows.Close()
+
+	// Outflows: deferred assignments are counted where they were moved to
+	rows, err = h.db.Query(ctx, `
+		SELECT ba.id, COALESCE(ba.paid_date, ba.scheduled_date, pp.pay_date) AS out_date,
+		       CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       COALESCE(`+netAssignmentAmount(mode)+`, 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status NOT IN ('skipped', 'deferred')
+		  AND COALESCE(ba.paid_date, ba.scheduled_date, pp.pay_date) BETWEEN $1 AND $2
+		ORDER BY out_date, ba.id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f := services.ForecastFlow{Kind: "bill"}
+		var amount float64
+		if err := rows.Scan(&f.RefID, &f.Date, &f.Label, &amount); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		f.Amount = -amount
+		flows = append(flows, f)
+	}
+
+	result := services.Forecast(startingBalance, from, to, flows)
+	h.publishNegative(result, from, to)
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
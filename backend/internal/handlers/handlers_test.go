@@ -2,14 +2,29 @@ package handlers
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
+	"github.com/jackc/pgx/v5"
 	pgxmock "github.com/pashagolub/pgxmock/v4"
 )
 
@@ -85,11 +100,11 @@ func TestIncomeCreate_Success(t *testing.T) {
 	defer mock.Close()
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "created_at", "updated_at"}).
-		AddRow(1, "My Job", "biweekly", json.RawMessage(`{"weekday":5,"anchor_date":"2025-01-10"}`), float64Ptr(2500.0), true, (*time.Time)(nil), now, now)
+	rows := pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "owner", "created_at", "updated_at"}).
+		AddRow(1, "My Job", "biweekly", json.RawMessage(`{"weekday":5,"anchor_date":"2025-01-10"}`), float64Ptr(2500.0), true, (*time.Time)(nil), "", now, now)
 
 	mock.ExpectQuery("INSERT INTO income_sources").
-		WithArgs("My Job", "biweekly", json.RawMessage(`{"weekday":5,"anchor_date":"2025-01-10"}`), float64Ptr(2500.0), (*time.Time)(nil)).
+		WithArgs("My Job", "biweekly", json.RawMessage(`{"weekday":5,"anchor_date":"2025-01-10"}`), float64Ptr(2500.0), (*time.Time)(nil), "").
 		WillReturnRows(rows)
 
 	h := NewIncomeHandler(mock)
@@ -107,6 +122,86 @@ func TestIncomeCreate_Success(t *testing.T) {
 	}
 }
 
+func TestIncomeCreate_BiweeklyAnchorWeekdayMismatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewIncomeHandler(mock)
+	// 2025-01-10 is a Friday, but weekday 1 is Monday
+	body := bytes.NewBufferString(`{"name":"My Job","pay_schedule":"biweekly","schedule_detail":{"weekday":1,"anchor_date":"2025-01-10"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources", body)
+	rr := httptest.NewRecorder()
+
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "INVALID_SCHEDULE")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestIncomeCreate_CustomScheduleInvalidDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewIncomeHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Invoices","pay_schedule":"custom","schedule_detail":{"dates":["2025-02-03","Feb 20"]}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources", body)
+	rr := httptest.NewRecorder()
+
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "INVALID_SCHEDULE")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestIncomeUpdate_BiweeklyAnchorWeekdayMismatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{"pay_schedule", "schedule_detail"}).
+		AddRow("biweekly", json.RawMessage(`{"weekday":5,"anchor_date":"2025-01-10"}`))
+	mock.ExpectQuery("SELECT pay_schedule, schedule_detail FROM income_sources").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	h := NewIncomeHandler(mock)
+	body := bytes.NewBufferString(`{"schedule_detail":{"weekday":5,"anchor_date":"2025-01-13"}}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/income-sources/1", body)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "INVALID_SCHEDULE")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Income: Get with invalid ID
 // ---------------------------------------------------------------------------
@@ -207,6 +302,98 @@ func TestIncomeDelete_InvalidID(t *testing.T) {
 	}
 }
 
+func TestIncomeDelete_SnapshotsTotalsFirst(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("UPDATE income_sources SET archived_totals").WithArgs(3).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM bill_assignments").WithArgs(3).WillReturnResult(pgxmock.NewResult("DELETE", 4))
+	mock.ExpectExec("DELETE FROM pay_periods").WithArgs(3).WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.ExpectExec("UPDATE income_sources SET is_active = false").WithArgs(3).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	h := NewIncomeHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/income-sources/3", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestIncomeSummary(t *testing.T) {
+	live := `{"periods_generated":6,"periods_received":4,"first_pay_date":"2025-01-03","last_pay_date":"2025-03-14",
+		"total_expected":9000,"total_actual":5800,"expected_received":6000}`
+	none := `{"periods_generated":0,"periods_received":0,"first_pay_date":null,"last_pay_date":null,
+		"total_expected":0,"total_actual":0,"expected_received":0}`
+	archived := `{"periods_generated":6,"periods_received":6,"first_pay_date":"2024-01-05","last_pay_date":"2024-03-15",
+		"total_expected":9000,"total_actual":9100,"expected_received":9000,"archived_at":"2024-03-20T12:00:00+00:00"}`
+
+	tests := []struct {
+		name         string
+		active       bool
+		archived     []byte
+		live         string
+		wantArchived bool
+		wantPeriods  int
+		wantDiff     float64
+	}{
+		{"active source", true, nil, live, false, 6, -200},
+		{"deleted source", false, []byte(archived), none, true, 6, 100},
+		{"deleted before snapshots", false, nil, none, false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mock.Close()
+
+			mock.ExpectQuery("SELECT name, pay_schedule, is_active, archived_totals FROM income_sources").WithArgs(3).
+				WillReturnRows(pgxmock.NewRows([]string{"name", "pay_schedule", "is_active", "archived_totals"}).
+					AddRow("Old Job", "weekly", tt.active, tt.archived))
+			mock.ExpectQuery("FROM pay_periods WHERE income_source_id").WithArgs(3).
+				WillReturnRows(pgxmock.NewRows([]string{"jsonb_build_object"}).AddRow([]byte(tt.live)))
+
+			h := NewIncomeHandler(mock)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/income-sources/3/summary", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "3")
+			req = req.WithContext(withChiContext(req.Context(), rctx))
+
+			rr := httptest.NewRecorder()
+			h.Summary(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+			}
+			var resp struct {
+				Data models.IncomeSourceSummary `json:"data"`
+			}
+			json.Unmarshal(rr.Body.Bytes(), &resp)
+			got := resp.Data
+			if got.Archived != tt.wantArchived || got.PeriodsGenerated != tt.wantPeriods || got.Difference != tt.wantDiff {
+				t.Errorf("unexpected summary: %+v", got)
+			}
+			if tt.wantArchived && (got.ArchivedAt == nil || got.LastPayDate == nil || *got.LastPayDate != "2024-03-15") {
+				t.Errorf("expected the archived snapshot, got %+v", got)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Income: List returns empty array
 // ---------------------------------------------------------------------------
@@ -240,6 +427,96 @@ func TestIncomeList_Empty(t *testing.T) {
 	}
 }
 
+func TestIncomeList_PagedWithTotal(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount",
+		"is_active", "effective_from", "owner", "created_at", "updated_at", "total"}).
+		AddRow(3, "Side gig", "weekly", json.RawMessage(`{}`), float64Ptr(200.0), true, (*time.Time)(nil), "", now, now, 3)
+	mock.ExpectQuery(`WHERE pay_schedule = \$1 ORDER BY default_amount DESC NULLS LAST, name, id LIMIT \$2 OFFSET \$3`).
+		WithArgs("weekly", 2, 2).
+		WillReturnRows(rows)
+
+	h := NewIncomeHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/income-sources?pay_schedule=weekly&sort=-default_amount&limit=2&offset=2", nil)
+	rr := httptest.NewRecorder()
+	h.List(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.IncomeSource `json:"data"`
+		Meta models.Meta           `json:"meta"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Data) != 1 {
+		t.Fatalf("expected 1 source, got %d", len(resp.Data))
+	}
+	if resp.Meta.Total == nil || *resp.Meta.Total != 3 {
+		t.Errorf("total = %v, want 3", resp.Meta.Total)
+	}
+	if resp.Meta.Limit == nil || *resp.Meta.Limit != 2 || resp.Meta.Offset == nil || *resp.Meta.Offset != 2 {
+		t.Errorf("limit/offset = %v/%v, want 2/2", resp.Meta.Limit, resp.Meta.Offset)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestIncomeList_EmptyPageCountsSeparately(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery(`FROM income_sources ORDER BY name, id OFFSET \$1`).
+		WithArgs(50).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount",
+			"is_active", "effective_from", "owner", "created_at", "updated_at", "total"}))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM \(`).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(4))
+
+	h := NewIncomeHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/income-sources?offset=50", nil)
+	rr := httptest.NewRecorder()
+	h.List(rr, req)
+
+	var resp struct {
+		Meta models.Meta `json:"meta"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if resp.Meta.Total == nil || *resp.Meta.Total != 4 {
+		t.Errorf("total = %v, want 4", resp.Meta.Total)
+	}
+	if resp.Meta.Limit != nil {
+		t.Errorf("limit = %v, want omitted", *resp.Meta.Limit)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestIncomeList_InvalidPaging(t *testing.T) {
+	h := NewIncomeHandler(nil)
+	for _, q := range []string{"limit=0", "limit=501", "offset=-1", "sort=salary"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/income-sources?"+q, nil)
+		rr := httptest.NewRecorder()
+		h.List(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Bills: Create validation
 // ---------------------------------------------------------------------------
@@ -363,6 +640,98 @@ func TestBillReorder_InvalidJSON(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Income events
+// ---------------------------------------------------------------------------
+
+func TestIncomeEventCreate_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing name", `{"amount": 3000, "event_date": "2026-03-13"}`},
+		{"zero amount", `{"name": "Bonus", "amount": 0, "event_date": "2026-03-13"}`},
+		{"bad date", `{"name": "Bonus", "amount": 3000, "event_date": "03/13/2026"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewIncomeEventHandler(nil)
+			rr := httptest.NewRecorder()
+			h.Create(rr, httptest.NewRequest(http.MethodPost, "/api/v1/income-events", strings.NewReader(tt.body)))
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestIncomeEventCreate_AttachesToPaycheck(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mar13 := time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO income_events").
+		WithArgs("Bonus", 3000.0, mar13, (*int)(nil), "").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "amount", "event_date", "income_source_id", "pay_period_id", "notes", "created_at", "updated_at"}).
+			AddRow(7, "Bonus", 3000.0, mar13, (*int)(nil), (*int)(nil), "", time.Now(), time.Now()))
+	mock.ExpectExec("UPDATE income_events e SET pay_period_id").WithArgs(mar13, mar13).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("SELECT pay_period_id FROM income_events").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_period_id"}).AddRow(intPtr(31)))
+	mock.ExpectCommit()
+
+	h := NewIncomeEventHandler(mock)
+	body := `{"name": " Bonus ", "amount": 3000, "event_date": "2026-03-13"}`
+	rr := httptest.NewRecorder()
+	h.Create(rr, httptest.NewRequest(http.MethodPost, "/api/v1/income-events", strings.NewReader(body)))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.IncomeEvent `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.EventDate != "2026-03-13" || resp.Data.PayPeriodID == nil || *resp.Data.PayPeriodID != 31 {
+		t.Errorf("expected the event attached to period 31, got %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestIncomeEventDelete_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("DELETE FROM income_events").WithArgs(7).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	h := NewIncomeEventHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/income-events/7", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "7")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
 // ---------------------------------------------------------------------------
 // Periods: Generate validation
 // ---------------------------------------------------------------------------
@@ -422,80 +791,276 @@ func TestPeriodGenerate_InvalidToDate(t *testing.T) {
 	}
 }
 
-func TestPeriodUpdate_InvalidID(t *testing.T) {
+func TestPeriodPreview_ReportsDatesWithoutWriting(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewPeriodHandler(mock)
-	body := bytes.NewBufferString(`{"expected_amount":1500}`)
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/abc", body)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "abc")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	now := time.Now()
+	mock.ExpectQuery("FROM income_sources WHERE is_active = true").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "created_at", "updated_at"}).
+			AddRow(3, "Acme", "monthly", json.RawMessage(`{"day":10}`), (*float64)(nil), true, (*time.Time)(nil), now, now))
+	mock.ExpectQuery("FROM payroll_calendar_dates").WithArgs([]int{3}, 2025, 2025).
+		WillReturnRows(pgxmock.NewRows([]string{"income_source_id", "pay_date"}))
+	mock.ExpectQuery("FROM pay_periods").WithArgs(3, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at"}).
+			AddRow(40, 3, time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), float64Ptr(1900), (*float64)(nil), "", now))
 
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2025-01-01","to":"2025-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/generate/preview", body)
 	rr := httptest.NewRecorder()
-	h.Update(rr, req)
+	h.Preview(rr, req)
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.GeneratePreview `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].IncomeSourceID != 3 || len(resp.Data[0].Periods) != 3 {
+		t.Fatalf("unexpected preview: %+v", resp.Data)
+	}
+	periods := resp.Data[0].Periods
+	if periods[0].PayDate != "2025-01-10" || periods[0].Exists || periods[0].Action != models.GenerateActionCreate || periods[0].ExpectedAmount != nil {
+		t.Errorf("unexpected new period: %+v", periods[0])
+	}
+	// Without a default amount, the existing period's amount is what stays
+	if periods[1].PayDate != "2025-02-10" || !periods[1].Exists || periods[1].Action != models.GenerateActionSkip || periods[1].ExpectedAmount == nil || *periods[1].ExpectedAmount != 1900 {
+		t.Errorf("unexpected existing period: %+v", periods[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
 	}
 }
 
-func TestPeriodUpdate_InvalidJSON(t *testing.T) {
+func TestPeriodPreview_PayrollCalendarOverridesSchedule(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewPeriodHandler(mock)
-	body := bytes.NewBufferString(`{bad`)
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/1", body)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	now := time.Now()
+	mock.ExpectQuery("FROM income_sources WHERE is_active = true").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "created_at", "updated_at"}).
+			AddRow(3, "Acme", "monthly", json.RawMessage(`{"day":10}`), float64Ptr(2000), true, (*time.Time)(nil), now, now))
+	// Payroll pays January on the 9th and February on the 11th
+	mock.ExpectQuery("FROM payroll_calendar_dates").WithArgs([]int{3}, 2026, 2026).
+		WillReturnRows(pgxmock.NewRows([]string{"income_source_id", "pay_date"}).
+			AddRow(3, time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)).
+			AddRow(3, time.Date(2026, 2, 11, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery("FROM pay_periods").WithArgs(3, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at"}))
 
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-02-28"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/generate/preview", body)
 	rr := httptest.NewRecorder()
-	h.Update(rr, req)
+	h.Preview(rr, req)
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.GeneratePreview `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	periods := resp.Data[0].Periods
+	if len(periods) != 2 || periods[0].PayDate != "2026-01-09" || periods[1].PayDate != "2026-02-11" {
+		t.Errorf("expected the calendar's dates, got %+v", periods)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Assignments: validation
-// ---------------------------------------------------------------------------
+// expectGenerationPlan mocks the reads shared by Generate and Preview for a
+// monthly source paid on the 10th with three periods in range: Jan 10 on
+// file at the default amount, Feb 10 on file at another amount, Mar 10 new.
+func expectGenerationPlan(mock pgxmock.PgxPoolIface) {
+	now := time.Now()
+	mock.ExpectQuery("FROM income_sources WHERE is_active = true").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "created_at", "updated_at"}).
+			AddRow(3, "Acme", "monthly", json.RawMessage(`{"day":10}`), float64Ptr(2000), true, (*time.Time)(nil), now, now))
+	mock.ExpectQuery("FROM payroll_calendar_dates").WithArgs([]int{3}, 2025, 2025).
+		WillReturnRows(pgxmock.NewRows([]string{"income_source_id", "pay_date"}))
+	mock.ExpectQuery("FROM pay_periods").WithArgs(3, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at"}).
+			AddRow(40, 3, time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), float64Ptr(2000), (*float64)(nil), "", now).
+			AddRow(41, 3, time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), float64Ptr(1900), (*float64)(nil), "", now))
+}
 
-func TestAssignmentCreate_InvalidJSON(t *testing.T) {
+func TestPeriodGenerate_ReportsSkippedAndConflicting(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`not valid json`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments", body)
+	expectGenerationPlan(mock)
+	mock.ExpectQuery("INSERT INTO pay_periods").WithArgs(3, pgxmock.AnyArg(), float64Ptr(2000)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at"}).
+			AddRow(42, 3, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), float64Ptr(2000), (*float64)(nil), "", time.Now()))
+	mock.ExpectExec("UPDATE income_events").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectQuery("FROM income_events").WithArgs([]int{42}).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_period_id", "sum"}))
+	mock.ExpectQuery("FROM income_events").WithArgs([]int{40}).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_period_id", "sum"}))
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2025-01-01","to":"2025-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/generate", body)
 	rr := httptest.NewRecorder()
-	h.Create(rr, req)
+	h.Generate(rr, req)
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
 	}
-}
-
-func TestAssignmentUpdate_InvalidID(t *testing.T) {
-	mock, err := pgxmock.NewPool()
-	if err != nil {
+	var resp struct {
+		Data models.GenerateReport `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
 		t.Fatal(err)
 	}
-	defer mock.Close()
-
-	h := NewAssignmentHandler(mock)
+	report := resp.Data
+	if len(report.Created) != 1 || report.Created[0].ID != 42 {
+		t.Errorf("expected Mar 10 created, got %+v", report.Created)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].ID != 40 {
+		t.Errorf("expected Jan 10 skipped, got %+v", report.Skipped)
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0].PayPeriod.ID != 41 || report.Conflicts[0].ScheduledAmount != 2000 {
+		t.Errorf("expected Feb 10 to conflict, got %+v", report.Conflicts)
+	}
+	if len(report.Updated) != 0 {
+		t.Errorf("expected nothing updated without update_amounts, got %+v", report.Updated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPeriodGenerate_UpdateAmounts(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	expectGenerationPlan(mock)
+	mock.ExpectQuery("UPDATE pay_periods SET expected_amount").WithArgs(41, float64Ptr(2000)).
+		WillReturnRows(pgxmock.NewRows([]string{"expected_amount"}).AddRow(float64Ptr(2000)))
+	mock.ExpectQuery("INSERT INTO pay_periods").WithArgs(3, pgxmock.AnyArg(), float64Ptr(2000)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at"}).
+			AddRow(42, 3, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), float64Ptr(2000), (*float64)(nil), "", time.Now()))
+	mock.ExpectExec("UPDATE income_events").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	for _, ids := range [][]int{{42}, {41}, {40}} {
+		mock.ExpectQuery("FROM income_events").WithArgs(ids).
+			WillReturnRows(pgxmock.NewRows([]string{"pay_period_id", "sum"}))
+	}
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2025-01-01","to":"2025-03-31","update_amounts":true}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/generate", body)
+	rr := httptest.NewRecorder()
+	h.Generate(rr, req)
+
+	var resp struct {
+		Data models.GenerateReport `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Updated) != 1 || *resp.Data.Updated[0].ExpectedAmount != 2000 || len(resp.Data.Conflicts) != 0 {
+		t.Errorf("expected Feb 10 updated, got %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPeriodUpdate_InvalidID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"expected_amount":1500}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/abc", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "abc")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestPeriodUpdate_InvalidJSON(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{bad`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/1", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Assignments: validation
+// ---------------------------------------------------------------------------
+
+func TestAssignmentCreate_InvalidJSON(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`not valid json`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestAssignmentUpdate_InvalidID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
 	body := bytes.NewBufferString(`{"status":"paid"}`)
 	req := httptest.NewRequest(http.MethodPut, "/api/v1/assignments/abc", body)
 	rctx := chi.NewRouteContext()
@@ -628,924 +1193,8404 @@ func TestOptimizerSuggest_InvalidJSON(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Import: Confirm without upload
-// ---------------------------------------------------------------------------
-
-func TestImportConfirm_NoPreview(t *testing.T) {
+func TestOptimizerSuggest_NegativeFloor(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewImportHandler(mock)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx/confirm", nil)
+	h := NewOptimizerHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2025-01-01","to":"2025-01-31","min_balance":-50}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/optimizer/suggest", body)
 	rr := httptest.NewRecorder()
-	h.Confirm(rr, req)
+	h.Suggest(rr, req)
 
 	if rr.Code != http.StatusBadRequest {
 		t.Errorf("expected 400, got %d", rr.Code)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "NO_PREVIEW")
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
 }
 
-// ---------------------------------------------------------------------------
-// Import: Upload with no file
-// ---------------------------------------------------------------------------
-
-func TestImportUpload_NoFile(t *testing.T) {
+func TestOptimizerSuggest_NegativeWeight(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewImportHandler(mock)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx", nil)
+	h := NewOptimizerHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2025-01-01","to":"2025-01-31","weights":{"min_balance":1,"moves":-5}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/optimizer/suggest", body)
 	rr := httptest.NewRecorder()
-	h.Upload(rr, req)
+	h.Suggest(rr, req)
 
 	if rr.Code != http.StatusBadRequest {
 		t.Errorf("expected 400, got %d", rr.Code)
 	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
 }
 
-// ---------------------------------------------------------------------------
-// AutoAssign: validation
-// ---------------------------------------------------------------------------
-
-func TestAutoAssign_InvalidJSON(t *testing.T) {
+func TestOptimizerApply_Plan(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{bad json`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	now := time.Now()
+	plan := `[{"assignment_id":5,"bill_id":1,"from_period_id":10,"to_period_id":20,"amount":900}]`
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM optimizer_plans WHERE id").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"suggestions", "applied_at"}).AddRow([]byte(plan), (*time.Time)(nil)))
+	mock.ExpectQuery("SELECT pay_period_id, status FROM bill_assignments").WithArgs(5).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_period_id", "status"}).AddRow(10, "pending"))
+	mock.ExpectQuery("UPDATE bill_assignments SET pay_period_id").WithArgs(5, 20).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(5, 1, 20, float64Ptr(900.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			false, "", "", false, false, (*int)(nil), false, (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now))
+	// The months of both periods become the accepted layout
+	mock.ExpectExec("DELETE FROM optimizer_layouts").WithArgs([]int{10, 20}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	planID := 3
+	mock.ExpectExec("INSERT INTO optimizer_layouts").WithArgs([]int{10, 20}, &planID).
+		WillReturnResult(pgxmock.NewResult("INSERT", 4))
+	mock.ExpectExec("UPDATE optimizer_plans SET applied_at").WithArgs(3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewOptimizerHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/optimizer/apply", bytes.NewBufferString(`{"plan_id":3}`))
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Apply(rr, req)
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.BillAssignment `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].PayPeriodID != 20 || resp.Data[0].ManuallyMoved {
+		t.Errorf("unexpected applied assignments: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "INVALID_JSON")
 }
 
-func TestAutoAssign_InvalidFromDate(t *testing.T) {
+func TestOptimizerApply_StalePlan(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"not-a-date","to":"2026-03-01"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	plan := `[{"assignment_id":5,"bill_id":1,"from_period_id":10,"to_period_id":20,"amount":900}]`
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM optimizer_plans WHERE id").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"suggestions", "applied_at"}).AddRow([]byte(plan), (*time.Time)(nil)))
+	// Moved by hand since the plan was suggested
+	mock.ExpectQuery("SELECT pay_period_id, status FROM bill_assignments").WithArgs(5).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_period_id", "status"}).AddRow(30, "pending"))
+	mock.ExpectRollback()
+
+	h := NewOptimizerHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/optimizer/apply", bytes.NewBufferString(`{"plan_id":3}`))
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Apply(rr, req)
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
 }
 
-func TestAutoAssign_InvalidToDate(t *testing.T) {
+func TestOptimizerApply_PlanAlreadyApplied(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"bad"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	applied := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM optimizer_plans WHERE id").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"suggestions", "applied_at"}).AddRow([]byte(`[]`), &applied))
+	mock.ExpectRollback()
+
+	h := NewOptimizerHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/optimizer/apply", bytes.NewBufferString(`{"plan_id":3}`))
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Apply(rr, req)
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected 400, got %d", rr.Code)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body: %s", rr.Code, rr.Body.String())
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
 }
 
-func TestAutoAssign_NoBills(t *testing.T) {
+func TestOptimizerApply_Validation(t *testing.T) {
+	for _, body := range []string{
+		`{}`,
+		`{"moves":[]}`,
+		`{"plan_id":3,"moves":[{"assignment_id":5,"to_period_id":20}]}`,
+	} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := NewOptimizerHandler(mock)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/optimizer/apply", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.Apply(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		mock.Close()
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Optimizer: ExportPlan
+// ---------------------------------------------------------------------------
+
+func TestOptimizerExportPlan_LatestJSON(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"})
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+	created := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	result := `{"suggestions":[{"assignment_id":5,"bill_id":1,"bill_name":"Rent","from_period_id":10,"to_period_id":20,"from_period":"2025-01-01","to_period":"2025-01-15","amount":900}],` +
+		`"current_min_balance":100,"optimized_min_balance":550,"improvement":450,` +
+		`"balances":[{"period_id":10,"pay_date":"2025-01-01","before":100,"after":1000},{"period_id":20,"pay_date":"2025-01-15","before":1450,"after":550}]}`
+	mock.ExpectQuery("FROM optimizer_plans ORDER BY id DESC LIMIT 1").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "suggestions", "result", "applied_at", "created_at"}).
+			AddRow(3, []byte(`[]`), []byte(result), (*time.Time)(nil), created))
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	h := NewOptimizerHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/optimizer/plans/latest/export", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "latest")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.ExportPlan(rr, req)
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
 	}
-	// Should return empty array
 	var resp struct {
-		Data []interface{} `json:"data"`
+		Data PlanDocument `json:"data"`
 	}
 	json.Unmarshal(rr.Body.Bytes(), &resp)
-	if len(resp.Data) != 0 {
-		t.Errorf("expected empty data, got %d items", len(resp.Data))
+	doc := resp.Data
+	if doc.PlanID != 3 || len(doc.Moves) != 1 || doc.Moves[0].BillName != "Rent" || doc.Improvement != 450 {
+		t.Errorf("unexpected plan document: %+v", doc)
+	}
+	if len(doc.Balances) != 2 || doc.Balances[1].Before != 1450 || doc.Balances[1].After != 550 {
+		t.Errorf("expected before/after balances, got %+v", doc.Balances)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_NoPeriods(t *testing.T) {
+func TestOptimizerExportPlan_HTMLFromSuggestionsOnly(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"})
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+	// Saved before the whole result was kept
+	plan := `[{"assignment_id":5,"bill_id":1,"bill_name":"Rent <main>","from_period":"2025-01-01","to_period":"2025-01-15","amount":900}]`
+	mock.ExpectQuery("FROM optimizer_plans WHERE id").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "suggestions", "result", "applied_at", "created_at"}).
+			AddRow(3, []byte(plan), []byte(nil), (*time.Time)(nil), time.Now()))
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	h := NewOptimizerHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/optimizer/plans/3/export?format=html", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.ExportPlan(rr, req)
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
 	}
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unmet expectations: %v", err)
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected an HTML document, got %q", ct)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "Rent &lt;main&gt;") || !strings.Contains(body, "$900.00") {
+		t.Errorf("expected the escaped move in the document, got:\n%s", body)
+	}
+	if strings.Contains(body, "<h2>Balances</h2>") {
+		t.Error("a plan without saved balances should not show a balances table")
 	}
 }
 
-func TestAutoAssign_MatchesBillsToPeriods(t *testing.T) {
+func TestOptimizerExportPlan_NotFoundAndBadInput(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	// Two periods: Mar 7 and Mar 21 (use future dates)
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)).
-		AddRow(11, time.Date(2026, 3, 21, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+	mock.ExpectQuery("FROM optimizer_plans WHERE id").WithArgs(99).WillReturnError(pgx.ErrNoRows)
 
-	// No existing assignments for the pre-fetch check
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"})
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+	h := NewOptimizerHandler(mock)
+	for _, tc := range []struct {
+		id, query, code string
+		status          int
+	}{
+		{"99", "", "NOT_FOUND", http.StatusNotFound},
+		{"abc", "", "INVALID_ID", http.StatusBadRequest},
+		{"3", "?format=pdf", "VALIDATION_ERROR", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/optimizer/plans/"+tc.id+"/export"+tc.query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", tc.id)
+		req = req.WithContext(withChiContext(req.Context(), rctx))
+		rr := httptest.NewRecorder()
+		h.ExportPlan(rr, req)
 
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+		if rr.Code != tc.status {
+			t.Errorf("%s%s: expected %d, got %d", tc.id, tc.query, tc.status, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), tc.code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
 
-	// Bill due on 15th should be assigned to period 10 (Mar 7, last period on or before 15th)
-	now := time.Now()
-	assignRow := pgxmock.NewRows([]string{
-		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
-		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
-		"notes", "manually_moved", "created_at", "updated_at",
-	}).AddRow(1, 1, 10, float64Ptr(100.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+// ---------------------------------------------------------------------------
+// Import: Confirm without upload
+// ---------------------------------------------------------------------------
 
-	mock.ExpectQuery("INSERT INTO bill_assignments").
-		WithArgs(1, 10, float64Ptr(100.0)).
-		WillReturnRows(assignRow)
+func TestImportConfirm_NoPreview(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx/confirm", nil)
+	rr := httptest.NewRecorder()
+	h.Confirm(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NO_PREVIEW")
+}
+
+// seedImportSession stores a pending XLSX upload as Upload would.
+func seedImportSession(t *testing.T, h *ImportHandler, file string, uploadedAt time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	if err := h.uploads.Put(ctx, file, []byte("xlsx")); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.saveSession(ctx, importSession{File: file, UploadedAt: uploadedAt, Preview: &services.ImportPreview{}}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportConfirm_ExpiredPreview(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+	h.ttl = time.Minute
+	file := importFilePrefix + "budget.xlsx"
+	seedImportSession(t, h, file, time.Now().Add(-2*time.Minute))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx/confirm", nil)
+	rr := httptest.NewRecorder()
+	h.Confirm(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NO_PREVIEW")
+	if _, err := h.uploads.Get(context.Background(), file); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected expired upload to be removed, got err: %v", err)
+	}
+}
+
+func TestImportConfirm_DuplicatesNeedResolution(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	ctx := context.Background()
+	h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+	file := importFilePrefix + "budget.xlsx"
+	h.uploads.Put(ctx, file, []byte("xlsx"))
+	h.saveSession(ctx, importSession{File: file, UploadedAt: time.Now(), Preview: &services.ImportPreview{
+		Bills: []services.ParsedBill{{Name: "Netflix"}, {Name: "Piano lessons"}},
+	}})
+	mock.ExpectQuery("SELECT id, name FROM bills WHERE is_active").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(4, "netflix").AddRow(5, "Water"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx/confirm", nil)
+	rr := httptest.NewRecorder()
+	h.Confirm(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "DUPLICATES_FOUND")
+	if !strings.Contains(rr.Body.String(), `"bill_id":4`) {
+		t.Errorf("expected the matching bill in the details, got %s", rr.Body.String())
+	}
+	// The preview stays pending so the confirm can be retried with resolutions
+	if s, _ := h.loadSession(ctx); s == nil || s.File != file {
+		t.Errorf("expected the preview still pending, got %+v", s)
+	}
+	if _, err := h.uploads.Get(ctx, file); err != nil {
+		t.Errorf("expected the upload kept, got err: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportConfirm_ResolvesDuplicates(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	ctx := context.Background()
+	h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+	file := importFilePrefix + "budget.xlsx"
+	h.uploads.Put(ctx, file, []byte("xlsx"))
+	h.saveSession(ctx, importSession{File: file, UploadedAt: time.Now(), Preview: &services.ImportPreview{
+		PeriodCount: 4,
+		Bills: []services.ParsedBill{
+			{Name: "Netflix", DefaultAmt: float64Ptr(17.99), DueDay: intPtr(3)},
+			{Name: "Verizon", DueDay: intPtr(16)},
+			{Name: "Gym", DefaultAmt: float64Ptr(25), Category: "health"},
+		},
+	}})
+	mock.ExpectQuery("SELECT id, name FROM bills WHERE is_active").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(4, "NETFLIX").AddRow(7, "Verizon Wireless"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE bills SET default_amount = COALESCE").WithArgs(4, float64Ptr(17.99), intPtr(3)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("INSERT INTO bills").
+		WithArgs("Gym", float64Ptr(25), (*int)(nil), "monthly", false, "health", 2).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectExec("INSERT INTO import_history").WithArgs(file, 1, 4).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	body := bytes.NewBufferString(`{"resolutions":[{"row":0,"action":"merge"},{"row":1,"action":"skip"}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx/confirm", body)
+	rr := httptest.NewRecorder()
+	h.Confirm(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data["imported_bills"] != 1.0 || resp.Data["merged_bills"] != 1.0 || resp.Data["skipped_bills"] != 1.0 {
+		t.Errorf("unexpected result: %v", resp.Data)
+	}
+	if s, _ := h.loadSession(ctx); s != nil {
+		t.Error("expected the preview consumed")
+	}
+	if _, err := h.uploads.Get(ctx, file); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected the upload removed, got err: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportConfirm_FailureKeepsPreview(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(mock pgxmock.PgxPoolIface)
+	}{
+		{"begin fails", func(mock pgxmock.PgxPoolIface) {
+			mock.ExpectBegin().WillReturnError(fmt.Errorf("connection refused"))
+		}},
+		{"insert fails", func(mock pgxmock.PgxPoolIface) {
+			mock.ExpectBegin()
+			mock.ExpectQuery("INSERT INTO bills").
+				WithArgs("Gym", (*float64)(nil), (*int)(nil), "monthly", false, "", 0).
+				WillReturnError(fmt.Errorf("connection lost"))
+			mock.ExpectRollback()
+		}},
+		{"commit fails", func(mock pgxmock.PgxPoolIface) {
+			mock.ExpectBegin()
+			mock.ExpectQuery("INSERT INTO bills").
+				WithArgs("Gym", (*float64)(nil), (*int)(nil), "monthly", false, "", 0).
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(12))
+			mock.ExpectExec("INSERT INTO import_history").WithArgs(importFilePrefix+"budget.xlsx", 1, 0).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			mock.ExpectCommit().WillReturnError(fmt.Errorf("serialization failure"))
+			mock.ExpectRollback()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mock.Close()
+
+			ctx := context.Background()
+			h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+			file := importFilePrefix + "budget.xlsx"
+			h.uploads.Put(ctx, file, []byte("xlsx"))
+			h.saveSession(ctx, importSession{File: file, UploadedAt: time.Now(), Preview: &services.ImportPreview{
+				Bills: []services.ParsedBill{{Name: "Gym"}},
+			}})
+			mock.ExpectQuery("SELECT id, name FROM bills WHERE is_active").
+				WillReturnRows(pgxmock.NewRows([]string{"id", "name"}))
+			tt.expect(mock)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx/confirm", nil)
+			rr := httptest.NewRecorder()
+			h.Confirm(rr, req)
+
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("expected 500, got %d: %s", rr.Code, rr.Body.String())
+			}
+			// Nothing was imported, so the confirm can be retried
+			if s, _ := h.loadSession(ctx); s == nil || s.File != file {
+				t.Errorf("expected the preview still pending, got %+v", s)
+			}
+			if _, err := h.uploads.Get(ctx, file); err != nil {
+				t.Errorf("expected the upload kept, got err: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestImportConfirm_RejectsBadResolutions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown action", `{"resolutions":[{"row":0,"action":"replace"}]}`},
+		{"row resolved twice", `{"resolutions":[{"row":0,"action":"skip"},{"row":0,"action":"create"}]}`},
+		{"row out of range", `{"resolutions":[{"row":5,"action":"skip"}]}`},
+		{"merge without a target", `{"resolutions":[{"row":0,"action":"merge"}]}`},
+		{"merge into an inactive bill", `{"resolutions":[{"row":0,"action":"merge","bill_id":99}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mock.Close()
+
+			ctx := context.Background()
+			h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+			h.saveSession(ctx, importSession{File: importFilePrefix + "budget.xlsx", UploadedAt: time.Now(), Preview: &services.ImportPreview{
+				Bills: []services.ParsedBill{{Name: "Piano lessons"}},
+			}})
+			mock.ExpectQuery("SELECT id, name FROM bills WHERE is_active").
+				WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(4, "Netflix"))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx/confirm", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			h.Confirm(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+			if s, _ := h.loadSession(ctx); s == nil {
+				t.Error("expected the preview still pending")
+			}
+		})
+	}
+}
+
+func TestImportSweep_KeepsFreshPreview(t *testing.T) {
+	ctx := context.Background()
+	h := NewImportHandler(nil).WithStorage(storage.NewMemory())
+	h.ttl = time.Minute
+	seedImportSession(t, h, importFilePrefix+"budget.xlsx", time.Now())
+
+	h.sweep(ctx, time.Now())
+	if s, _ := h.loadSession(ctx); s == nil {
+		t.Fatal("fresh preview should survive a sweep")
+	}
+
+	h.sweep(ctx, time.Now().Add(2*time.Minute))
+	if s, _ := h.loadSession(ctx); s != nil {
+		t.Error("expected preview older than the TTL to be discarded")
+	}
+	if objects, _ := h.uploads.List(ctx, importFilePrefix); len(objects) != 0 {
+		t.Errorf("expected no stored uploads left, got %d", len(objects))
+	}
+}
+
+func TestImportUpload_SessionSharedAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	shared := storage.NewMemory()
+	first := NewImportHandler(nil).WithStorage(shared)
+	second := NewImportHandler(nil).WithStorage(shared)
+	seedImportSession(t, first, importFilePrefix+"budget.xlsx", time.Now())
+
+	s, err := second.takeSession(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s == nil || s.File != importFilePrefix+"budget.xlsx" {
+		t.Fatalf("expected the other replica's upload, got %+v", s)
+	}
+	if s, _ := first.loadSession(ctx); s != nil {
+		t.Error("expected the session to be claimed")
+	}
+}
+
+func TestImportDeleteSession(t *testing.T) {
+	h := NewImportHandler(nil).WithStorage(storage.NewMemory())
+	seedImportSession(t, h, importFilePrefix+"budget.xlsx", time.Now())
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/import/xlsx/session", nil)
+	rr := httptest.NewRecorder()
+	h.DeleteSession(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	if s, _ := h.loadSession(context.Background()); s != nil {
+		t.Error("expected pending preview to be cleared")
+	}
+	if objects, _ := h.uploads.List(context.Background(), importFilePrefix); len(objects) != 0 {
+		t.Errorf("expected the upload to be deleted, got %d objects", len(objects))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Import: Upload with no file
+// ---------------------------------------------------------------------------
+
+func TestImportUpload_NoFile(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewImportHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/xlsx", nil)
+	rr := httptest.NewRecorder()
+	h.Upload(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Import: background parsing jobs
+// ---------------------------------------------------------------------------
+
+var importJobColumns = []string{"id", "filename", "status", "progress", "attempts", "warnings", "preview", "error",
+	"created_at", "started_at", "finished_at"}
+
+// budgetWorkbook builds a small workbook in the layout the importer reads.
+func budgetWorkbook(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	bills := []services.XLSXBill{{Name: "Rent", DueDay: intPtr(1)}, {Name: "Mystery"}}
+	periods := []services.XLSXPeriod{{PayDate: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Cells: []*services.XLSXCell{nil, nil}}}
+	if err := services.WriteXLSX(&buf, bills, periods); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImportUpload_QueuesJob(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("INSERT INTO import_jobs").
+		WithArgs("budget.xlsx", pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(importJobColumns).
+			AddRow(5, "budget.xlsx", "queued", 0, 0, []string{}, []byte(nil), "", time.Now(), (*time.Time)(nil), (*time.Time)(nil)))
+
+	h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+	req := newMultipartRequest(t, "/api/v1/import/xlsx", "budget.xlsx", string(budgetWorkbook(t)), nil)
+	rr := httptest.NewRecorder()
+	h.Upload(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.ImportJob `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.ID != 5 || resp.Data.Status != "queued" {
+		t.Errorf("expected queued job 5, got %+v", resp.Data)
+	}
+	if objects, _ := h.uploads.List(context.Background(), importFilePrefix); len(objects) != 1 {
+		t.Errorf("expected the upload stored, got %d objects", len(objects))
+	}
+	if s, _ := h.loadSession(context.Background()); s != nil {
+		t.Error("expected no pending preview until the job is done")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportJobs_ParsesQueuedUpload(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	ctx := context.Background()
+	h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+	file := importFilePrefix + "abc-budget.xlsx"
+	if err := h.uploads.Put(ctx, file, budgetWorkbook(t)); err != nil {
+		t.Fatal(err)
+	}
+	seedImportSession(t, h, importFilePrefix+"older.xlsx", time.Now())
+
+	mock.ExpectQuery("UPDATE import_jobs SET status = 'parsing'").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "file", "attempts"}).AddRow(5, file, 1))
+	mock.ExpectQuery("SELECT name_key, category FROM category_corrections").
+		WillReturnRows(pgxmock.NewRows([]string{"name_key", "category"}))
+	mock.ExpectQuery("SELECT id, name FROM bills WHERE is_active").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(3, "RENT"))
+	mock.ExpectExec("UPDATE import_jobs SET status = 'done'").
+		WithArgs(5, []string{`row 4: no due day or amount found in "Mystery"`}, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("UPDATE import_jobs SET status = 'parsing'").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+
+	h.processJobs(ctx)
+
+	s, err := h.loadSession(ctx)
+	if err != nil || s == nil {
+		t.Fatalf("expected the job's preview to be pending, got %+v, %v", s, err)
+	}
+	if s.File != file || len(s.Preview.Bills) != 2 || s.Preview.Bills[0].Name != "Rent" {
+		t.Errorf("unexpected pending preview: %+v", s)
+	}
+	if d := s.Preview.Duplicates; len(d) != 1 || d[0].Row != 0 || d[0].BillID != 3 || d[0].Similarity != 1 {
+		t.Errorf("expected Rent flagged as a duplicate of bill 3, got %+v", d)
+	}
+	if _, err := h.uploads.Get(ctx, importFilePrefix+"older.xlsx"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected the replaced upload removed, got err: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportJobs_FailsUnreadableWorkbook(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	ctx := context.Background()
+	h := NewImportHandler(mock).WithStorage(storage.NewMemory())
+	file := importFilePrefix + "abc-notes.xlsx"
+	if err := h.uploads.Put(ctx, file, []byte("not a workbook")); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("UPDATE import_jobs SET status = 'parsing'").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "file", "attempts"}).AddRow(6, file, 1))
+	mock.ExpectExec("UPDATE import_jobs SET status = 'failed'").
+		WithArgs(6, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("UPDATE import_jobs SET status = 'parsing'").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+
+	h.processJobs(ctx)
+
+	if s, _ := h.loadSession(ctx); s != nil {
+		t.Error("expected no pending preview from a failed job")
+	}
+	if _, err := h.uploads.Get(ctx, file); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected the unreadable upload removed, got err: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportJob_ReportsProgress(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	started := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM import_jobs WHERE id").WithArgs(5).
+		WillReturnRows(pgxmock.NewRows(importJobColumns).
+			AddRow(5, "budget.xlsx", "parsing", 40, 1, []string{}, []byte(nil), "", started, &started, (*time.Time)(nil)))
+	mock.ExpectQuery("SELECT (.+) FROM import_jobs WHERE id").WithArgs(9).
+		WillReturnError(pgx.ErrNoRows)
+
+	h := NewImportHandler(mock)
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/import/jobs/"+id, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(withChiContext(req.Context(), rctx))
+		rr := httptest.NewRecorder()
+		h.Job(rr, req)
+		return rr
+	}
+
+	rr := get("5")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.ImportJob `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Status != "parsing" || resp.Data.Progress != 40 || resp.Data.Preview != nil {
+		t.Errorf("unexpected job: %+v", resp.Data)
+	}
+
+	rr = get("9")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Import: bank CSV backfill
+// ---------------------------------------------------------------------------
+
+func newMultipartRequest(t *testing.T, target, filename, content string, fields map[string]string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, target, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestImportBankCSV_DryRunReportsMatches(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT ba.id, b.name, COALESCE\\(ba.due_date, pp.pay_date\\)").
+		WithArgs(time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "date"}).
+			AddRow(9, "Electric", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+	h := NewImportHandler(mock)
+	req := newMultipartRequest(t, "/api/v1/import/bank-csv", "bank.csv",
+		"Date,Description,Amount\n2026-03-02,ELECTRIC CO,-118.40\n",
+		map[string]string{"dry_run": "true"})
+	rr := httptest.NewRecorder()
+	h.BankCSV(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data BankCSVResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data.Matched) != 1 || resp.Data.Matched[0].AssignmentID != 9 || resp.Data.Matched[0].Amount != 118.40 {
+		t.Errorf("unexpected matches: %+v", resp.Data.Matched)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportBankCSV_UnknownColumn(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewImportHandler(mock)
+	req := newMultipartRequest(t, "/api/v1/import/bank-csv", "bank.csv",
+		"Posted,Payee,Debit\n", map[string]string{"date_column": "Posted", "amount_column": "Debit"})
+	rr := httptest.NewRecorder()
+	h.BankCSV(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "PARSE_ERROR")
+}
+
+func TestImportCSV_UploadThenConfirmAppliesMatches(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT ba.id, b.name").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "date"}).
+			AddRow(9, "Water", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE bill_assignments SET").WithArgs(9, 41.5).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("INSERT INTO transactions").WithArgs("2026-03-11", 41.5, "CITY WATER", "csv", 9).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("INSERT INTO import_history").WithArgs("march.csv", 1).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewImportHandler(mock)
+	req := newMultipartRequest(t, "/api/v1/import/csv", "march.csv",
+		"When,What,How Much\n03/11/2026,CITY WATER,41.50\n",
+		map[string]string{"date_column": "When", "description_column": "What", "amount_column": "How Much"})
+	rr := httptest.NewRecorder()
+	h.UploadCSV(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("upload: expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ConfirmCSV(rr, httptest.NewRequest(http.MethodPost, "/api/v1/import/csv/confirm", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("confirm: expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	// The preview is consumed by the first confirm
+	rr = httptest.NewRecorder()
+	h.ConfirmCSV(rr, httptest.NewRequest(http.MethodPost, "/api/v1/import/csv/confirm", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("second confirm: expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NO_PREVIEW")
+}
+
+func TestImportBankCSV_AppleCardMatchesByMerchant(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT ba.id, b.name").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "date"}).
+			AddRow(7, "Netflix", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE bill_assignments SET").WithArgs(7, 15.49).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("INSERT INTO transactions").WithArgs("2026-03-02", 15.49, "NFLX DIGITAL 866-579-7172", "apple_card", 7).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("INSERT INTO import_history").WithArgs("apple.csv", 1).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewImportHandler(mock)
+	req := newMultipartRequest(t, "/api/v1/import/bank-csv", "apple.csv",
+		"Transaction Date,Clearing Date,Description,Merchant,Category,Type,Amount (USD),Purchased By\n"+
+			"03/02/2026,03/03/2026,NFLX DIGITAL 866-579-7172,Netflix,Other,Purchase,15.49,Sam\n",
+		map[string]string{"format": "apple_card"})
+	rr := httptest.NewRecorder()
+	h.BankCSV(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportBankCSV_UnknownFormat(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewImportHandler(mock)
+	req := newMultipartRequest(t, "/api/v1/import/bank-csv", "venmo.csv", "Date\n", map[string]string{"format": "venmo"})
+	rr := httptest.NewRecorder()
+	h.BankCSV(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// AutoAssign: validation
+// ---------------------------------------------------------------------------
+
+func TestAutoAssign_InvalidJSON(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{bad json`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "INVALID_JSON")
+}
+
+func TestAutoAssign_InvalidFromDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"not-a-date","to":"2026-03-01"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestAutoAssign_InvalidToDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"bad"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestAutoAssign_NoBills(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"})
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-01-01","to":"2036-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	// Should return empty array
+	var resp struct {
+		Data []interface{} `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 0 {
+		t.Errorf("expected empty data, got %d items", len(resp.Data))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_NoPeriods(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"})
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-01-01","to":"2036-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_MatchesBillsToPeriods(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// Two periods: Mar 7 and Mar 21 (use future dates)
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 3, 7, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 3, 21, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// No existing assignments for the pre-fetch check
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Bill due on 15th should be assigned to period 10 (Mar 7, last period on or before 15th)
+	now := time.Now()
+	assignRow := pgxmock.NewRows([]string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "created_at", "updated_at",
+	}).AddRow(1, 1, 10, float64Ptr(100.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(100.0), time.Date(2036, 3, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(assignRow)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-03-01","to":"2036-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_UsesFirstPeriodWhenNoneBeforeDueDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Bill due on the 3rd
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Internet", float64Ptr(50.0), 3, "monthly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// Only period is on the 7th (after due date)
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 3, 7, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// No existing assignments for the pre-fetch check
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Should still assign to period 10 (first available in that month)
+	now := time.Now()
+	assignRow := pgxmock.NewRows([]string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "created_at", "updated_at",
+	}).AddRow(1, 1, 10, float64Ptr(50.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(50.0), time.Date(2036, 3, 3, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(assignRow)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-03-01","to":"2036-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_SkipsExistingAssignments(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 2, 7, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// Bill already has an assignment for Feb (on period 10) - pre-fetch returns it
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"}).
+		AddRow(1, 10, time.Date(2036, 2, 7, 0, 0, 0, 0, time.UTC), false, nil)
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// No INSERT expected - the bill/month combo is already covered
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-02-01","to":"2036-02-28"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	// Should return 201 with empty array (no new assignments created)
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_SkipsWhenBillMovedToDifferentPeriod(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Bill due on the 15th
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// Two periods: Feb 7 and Feb 21
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 2, 7, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 2, 21, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// User moved bill from period 10 (Feb 7) to period 11 (Feb 21) — existing assignment on 21st
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"}).
+		AddRow(1, 11, time.Date(2036, 2, 21, 0, 0, 0, 0, time.UTC), false, nil)
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// No INSERT expected — bill already has an assignment for Feb, even though it's on a different period
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-02-01","to":"2036-02-28"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_BiweeklyBillWithAnchorDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Biweekly bill with anchor date Jan 15
+	anchorJSON := []byte(`{"anchor_date":"2036-01-15"}`)
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Loan", float64Ptr(200.0), 15, "biweekly", anchorJSON, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// 4 semi-monthly periods: Jan 1, Jan 15, Feb 1, Feb 15
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 1, 1, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 1, 15, 0, 0, 0, 0, time.UTC)).
+		AddRow(12, time.Date(2036, 2, 1, 0, 0, 0, 0, time.UTC)).
+		AddRow(13, time.Date(2036, 2, 15, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// No existing assignments
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Biweekly from Jan 15: Jan 15, Jan 29, Feb 12, Feb 26
+	// Jan 15 -> period 11 (Jan 15), Jan 29 -> period 11 (Jan 15, last on or before Jan 29)
+	// Feb 12 -> period 12 (Feb 1, last on or before Feb 12), Feb 26 -> period 13 (Feb 15, last on or before Feb 26)
+	// Each occurrence gets its own assignment, so period 11 holds two $200 rows
+	now := time.Now()
+	occurrences := []struct {
+		periodID int
+		due      time.Time
+	}{
+		{11, time.Date(2036, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{11, time.Date(2036, 1, 29, 0, 0, 0, 0, time.UTC)},
+		{12, time.Date(2036, 2, 12, 0, 0, 0, 0, time.UTC)},
+		{13, time.Date(2036, 2, 26, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, occ := range occurrences {
+		assignRow := pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "created_at", "updated_at",
+		}).AddRow(i+1, 1, occ.periodID, float64Ptr(200.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+
+		mock.ExpectQuery("INSERT INTO bill_assignments").
+			WithArgs(1, occ.periodID, float64Ptr(200.0), occ.due).
+			WillReturnRows(assignRow)
+	}
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-01-01","to":"2036-02-28"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_DeletedOccurrenceKeepsItsSibling(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	anchorJSON := []byte(`{"anchor_date":"2036-01-15"}`)
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Loan", float64Ptr(200.0), 15, "biweekly", anchorJSON, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(11, time.Date(2036, 1, 15, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// The Jan 15 occurrence was deleted; Jan 29 falls in the same period
+	jan15 := time.Date(2036, 1, 15, 0, 0, 0, 0, time.UTC)
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"}).AddRow(1, 11, &jan15)
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	now := time.Now()
+	jan29 := time.Date(2036, 1, 29, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 11, float64Ptr(200.0), jan29).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "created_at", "updated_at",
+		}).AddRow(2, 1, 11, float64Ptr(200.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-01-15","to":"2036-01-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_UndatedDeletionBlocksPeriod(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	anchorJSON := []byte(`{"anchor_date":"2036-01-15"}`)
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Loan", float64Ptr(200.0), 15, "biweekly", anchorJSON, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(11, time.Date(2036, 1, 15, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// Recorded before deletions carried a due date: nothing goes in the period
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"}).AddRow(1, 11, (*time.Time)(nil))
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-01-15","to":"2036-01-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_BiweeklyFallsBackWithoutAnchor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Biweekly bill WITHOUT anchor date — should fall back to monthly
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Loan", float64Ptr(200.0), 15, "biweekly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// One period: Mar 7 (use future date)
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 3, 7, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// No existing assignments
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Falls back to monthly: assigns to period 10
+	now := time.Now()
+	assignRow := pgxmock.NewRows([]string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "created_at", "updated_at",
+	}).AddRow(1, 1, 10, float64Ptr(200.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(200.0), time.Date(2036, 3, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(assignRow)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-03-01","to":"2036-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_QuarterlyBillWithAnchorDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Quarterly bill with anchor date Jan 15
+	anchorJSON := []byte(`{"anchor_date":"2036-01-15"}`)
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Insurance", float64Ptr(300.0), 15, "quarterly", anchorJSON, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// Periods: Jan 1, Jan 15, Apr 1, Apr 15
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 1, 1, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 1, 15, 0, 0, 0, 0, time.UTC)).
+		AddRow(12, time.Date(2036, 4, 1, 0, 0, 0, 0, time.UTC)).
+		AddRow(13, time.Date(2036, 4, 15, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// No existing assignments
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Quarterly from Jan 15: Jan 15, Apr 15
+	// Jan 15 -> period 11, Apr 15 -> period 13
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		assignRow := pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "created_at", "updated_at",
+		}).AddRow(i+1, 1, 11, float64Ptr(300.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+
+		mock.ExpectQuery("INSERT INTO bill_assignments").
+			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnRows(assignRow)
+	}
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-01-01","to":"2036-06-30"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestAutoAssign_AnnualBillWithAnchorDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Annual bill with anchor date March 1
+	anchorJSON := []byte(`{"anchor_date":"2036-03-01"}`)
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Car Registration", float64Ptr(500.0), 1, "annual", anchorJSON, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// Periods: Feb 15, Mar 1, Mar 15
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 2, 15, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 3, 1, 0, 0, 0, 0, time.UTC)).
+		AddRow(12, time.Date(2036, 3, 15, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// No existing assignments
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Annual on Mar 1 -> period 11 (Mar 1)
+	now := time.Now()
+	assignRow := pgxmock.NewRows([]string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "created_at", "updated_at",
+	}).AddRow(1, 1, 11, float64Ptr(500.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(assignRow)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-01-01","to":"2036-12-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestAutoAssign_QuarterlyFallsBackWithoutAnchor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Quarterly bill WITHOUT anchor date — should fall back to monthly
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Insurance", float64Ptr(300.0), 15, "quarterly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	// One period: Mar 7 (use future date)
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 3, 7, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	// No existing assignments
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	// No deleted bill+period combos
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// No skipped months
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Falls back to monthly: assigns to period 10
+	now := time.Now()
+	assignRow := pgxmock.NewRows([]string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "created_at", "updated_at",
+	}).AddRow(1, 1, 10, float64Ptr(300.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(300.0), time.Date(2036, 3, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(assignRow)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-03-01","to":"2036-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_BillQueryError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnError(fmt.Errorf("db connection lost"))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
+}
+
+func TestAutoAssign_PeriodQueryError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(fmt.Errorf("db error"))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Bill Delete: Success cases
+// ---------------------------------------------------------------------------
+
+func TestBillDelete_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("UPDATE bills SET is_active = false").
+		WithArgs(1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/bills/1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBillDelete_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("UPDATE bills SET is_active = false").
+		WithArgs(999).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/bills/999", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "999")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func TestBillDelete_DBError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("UPDATE bills SET is_active = false").
+		WithArgs(1).
+		WillReturnError(fmt.Errorf("connection lost"))
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/bills/1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Assignment Delete: Success cases
+// ---------------------------------------------------------------------------
+
+func TestAssignmentDelete_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// First query to get bill_id and pay_period_id
+	mock.ExpectQuery("SELECT bill_id, pay_period_id, due_date FROM bill_assignments").
+		WithArgs(5).
+		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"}).AddRow(1, 10, (*time.Time)(nil)))
+
+	// Then delete
+	mock.ExpectExec("DELETE FROM bill_assignments").
+		WithArgs(5).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	// Then track the deletion
+	mock.ExpectExec("INSERT INTO deleted_bill_periods").
+		WithArgs(1, 10, (*time.Time)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/assignments/5", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentDelete_RecordsOccurrenceDueDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	due := time.Date(2036, 1, 29, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT bill_id, pay_period_id, due_date FROM bill_assignments").
+		WithArgs(6).
+		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"}).AddRow(1, 11, &due))
+	mock.ExpectExec("DELETE FROM bill_assignments").
+		WithArgs(6).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`INSERT INTO deleted_bill_periods \(bill_id, pay_period_id, due_date\)`).
+		WithArgs(1, 11, &due).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/assignments/6", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "6")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentDelete_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Query returns no rows (assignment not found)
+	mock.ExpectQuery("SELECT bill_id, pay_period_id, due_date FROM bill_assignments").
+		WithArgs(999).
+		WillReturnError(fmt.Errorf("no rows in result set"))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/assignments/999", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "999")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func TestAssignmentDelete_DBError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// First query succeeds
+	mock.ExpectQuery("SELECT bill_id, pay_period_id, due_date FROM bill_assignments").
+		WithArgs(1).
+		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"}).AddRow(1, 10, (*time.Time)(nil)))
+
+	// Delete fails
+	mock.ExpectExec("DELETE FROM bill_assignments").
+		WithArgs(1).
+		WillReturnError(fmt.Errorf("connection lost"))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/assignments/1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Period Update: Success and error cases
+// ---------------------------------------------------------------------------
+
+func TestPeriodUpdate_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	payDate := time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC)
+	rows := pgxmock.NewRows([]string{
+		"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at",
+	}).AddRow(1, 1, payDate, float64Ptr(2000.0), (*float64)(nil), "", now)
+
+	mock.ExpectQuery("UPDATE pay_periods SET").
+		WithArgs(1, float64Ptr(2000.0), (*float64)(nil), (*string)(nil)).
+		WillReturnRows(rows)
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"expected_amount":2000}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/1", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPeriodUpdate_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("UPDATE pay_periods SET").
+		WithArgs(999, float64Ptr(1500.0), (*float64)(nil), (*string)(nil)).
+		WillReturnError(fmt.Errorf("no rows in result set"))
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"expected_amount":1500}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/999", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "999")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestPeriodBulkUpdate_SetsFutureAmountsAndDefault(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT name FROM income_sources").WithArgs(2).
+		WillReturnRows(pgxmock.NewRows([]string{"name"}).AddRow("Day Job"))
+	mock.ExpectExec("UPDATE income_sources SET default_amount").WithArgs(2, 2300.0).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("UPDATE pay_periods SET expected_amount").WithArgs(2, 2300.0, from).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at",
+		}).
+			AddRow(12, 2, time.Date(2026, 3, 27, 0, 0, 0, 0, time.UTC), float64Ptr(2300.0), (*float64)(nil), "", now).
+			AddRow(11, 2, time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC), float64Ptr(2300.0), (*float64)(nil), "", now))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"income_source_id":2,"expected_amount":2300,"from":"2026-03-01","update_default":true}`)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/pay-periods/bulk", body)
+	rr := httptest.NewRecorder()
+	h.BulkUpdate(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.PayPeriod `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 2 || resp.Data[0].ID != 11 || resp.Data[0].SourceName != "Day Job" {
+		t.Errorf("expected both periods in pay date order, got %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPeriodBulkUpdate_UnknownSource(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT name FROM income_sources").WithArgs(99).WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	h := NewPeriodHandler(mock)
+	body := bytes.NewBufferString(`{"income_source_id":99,"expected_amount":2300}`)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/pay-periods/bulk", body)
+	rr := httptest.NewRecorder()
+	h.BulkUpdate(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func TestPeriodBulkUpdate_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing source", `{"expected_amount":2300}`},
+		{"missing amount", `{"income_source_id":2}`},
+		{"negative amount", `{"income_source_id":2,"expected_amount":-1}`},
+		{"bad from", `{"income_source_id":2,"expected_amount":2300,"from":"03/01/2026"}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mock.Close()
+
+			h := NewPeriodHandler(mock)
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/pay-periods/bulk", bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+			h.BulkUpdate(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d; body: %s", rr.Code, rr.Body.String())
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Income: Create one_time schedule
+// ---------------------------------------------------------------------------
+
+func TestIncomeCreate_OneTime_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "owner", "created_at", "updated_at"}).
+		AddRow(1, "Year-End Bonus", "one_time", json.RawMessage(`{"date":"2026-03-15"}`), float64Ptr(5000.0), true, (*time.Time)(nil), "", now, now)
+
+	mock.ExpectQuery("INSERT INTO income_sources").
+		WithArgs("Year-End Bonus", "one_time", json.RawMessage(`{"date":"2026-03-15"}`), float64Ptr(5000.0), (*time.Time)(nil), "").
+		WillReturnRows(rows)
+
+	h := NewIncomeHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Year-End Bonus","pay_schedule":"one_time","schedule_detail":{"date":"2026-03-15"},"default_amount":5000}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources", body)
+	rr := httptest.NewRecorder()
+
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected status 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestIncomeCreate_InvalidSchedule_StillRejects(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewIncomeHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Test","pay_schedule":"daily","schedule_detail":{}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources", body)
+	rr := httptest.NewRecorder()
+
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Bill List: active filter
+// ---------------------------------------------------------------------------
+
+func TestBillList_ActiveFilter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{
+		"id", "name", "default_amount", "due_day", "recurrence",
+		"recurrence_detail", "is_autopay", "category", "notes",
+		"is_active", "sort_order", "created_at", "updated_at",
+		"cc_id", "cc_label", "cc_statement_day", "cc_due_day", "cc_issuer",
+	})
+	mock.ExpectQuery("SELECT (.+) FROM bills (.+) WHERE b.is_active = true").WillReturnRows(rows)
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/bills?active=true", nil)
+	rr := httptest.NewRecorder()
+	h.List(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBillList_NoFilter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{
+		"id", "name", "default_amount", "due_day", "recurrence",
+		"recurrence_detail", "is_autopay", "category", "notes",
+		"is_active", "sort_order", "created_at", "updated_at",
+		"cc_id", "cc_label", "cc_statement_day", "cc_due_day", "cc_issuer",
+	})
+	// Should NOT have WHERE is_active in query
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(rows)
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/bills", nil)
+	rr := httptest.NewRecorder()
+	h.List(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rr.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Assignment search by amount and paid date
+// ---------------------------------------------------------------------------
+
+func TestAssignmentList_AmountAndPaidDateRange(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery(`COALESCE\(ba.actual_amount, ba.planned_amount\) >= \$1 AND COALESCE\(ba.actual_amount, ba.planned_amount\) <= \$2 AND ba.paid_date >= \$3 AND ba.paid_date <= \$4`).
+		WithArgs(80.0, 90.0, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	h := NewAssignmentHandler(mock)
+	rr := httptest.NewRecorder()
+	h.List(rr, httptest.NewRequest(http.MethodGet,
+		"/api/v1/assignments?amount_min=80&amount_max=90&paid_after=2026-03-01&paid_before=2026-03-31", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentList_InvalidSearchParams(t *testing.T) {
+	h := NewAssignmentHandler(nil)
+	for _, q := range []string{"amount_min=abc", "amount_max=-5", "amount_min=90&amount_max=80", "paid_after=March"} {
+		rr := httptest.NewRecorder()
+		h.List(rr, httptest.NewRequest(http.MethodGet, "/api/v1/assignments?"+q, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Bill cost-sharing
+// ---------------------------------------------------------------------------
+
+func TestBillCreate_SharedPercentOutOfRange(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewBillHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Internet","shared_with":"Roommate","shared_percent":150}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestBillCreate_BufferPercentOutOfRange(t *testing.T) {
+	h := NewBillHandler(nil)
+	body := bytes.NewBufferString(`{"name":"Electric","buffer_percent":-5}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestReportOwedToMe_InvalidMonth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/owed-to-me?month=March", nil)
+	rr := httptest.NewRecorder()
+	h.OwedToMe(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestReportOwedToMe_SumsByParty(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{"id", "bill_id", "name", "shared_with", "pay_date", "amount", "shared_percent"}).
+		AddRow(1, 3, "Internet", "Roommate", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC), 80.0, 50.0).
+		AddRow(2, 4, "Electric", "Roommate", time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), 120.0, 25.0)
+	mock.ExpectQuery("SELECT (.+) FROM bill_assignments ba").
+		WithArgs(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/owed-to-me?month=2026-03", nil)
+	rr := httptest.NewRecorder()
+	h.OwedToMe(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data OwedStatement `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(resp.Data.Items))
+	}
+	if resp.Data.TotalOwed != 70 {
+		t.Errorf("expected total owed 70, got %v", resp.Data.TotalOwed)
+	}
+	if resp.Data.ByParty["Roommate"] != 70 {
+		t.Errorf("expected 70 owed by Roommate, got %v", resp.Data.ByParty["Roommate"])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Allowances
+// ---------------------------------------------------------------------------
+
+func TestBillCreate_AllowanceRequiresDependent(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewBillHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Weekly allowance","bill_type":"allowance"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestBillCreate_InvalidBillType(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewBillHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Lunch money","bill_type":"stipend"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestReportAllowances_GroupsByDependent(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{"dependent", "month", "planned", "given"}).
+		AddRow("Ava", "2026-01", 40.0, 40.0).
+		AddRow("Ava", "2026-02", 40.0, 20.0).
+		AddRow("Max", "2026-01", 25.0, 25.0)
+	mock.ExpectQuery("SELECT b.dependent").
+		WithArgs(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/allowances?from=2026-01&to=2026-02", nil)
+	rr := httptest.NewRecorder()
+	h.Allowances(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data AllowanceReport `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Months) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(resp.Data.Months))
+	}
+	if resp.Data.GivenTotal["Ava"] != 60 {
+		t.Errorf("expected Ava given total 60, got %v", resp.Data.GivenTotal["Ava"])
+	}
+	if resp.Data.GivenTotal["Max"] != 25 {
+		t.Errorf("expected Max given total 25, got %v", resp.Data.GivenTotal["Max"])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Contributions report
+// ---------------------------------------------------------------------------
+
+func TestReportContributions_TotalsByMember(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{"paid_by", "paid_from", "month", "paid", "count"}).
+		AddRow("Alex", "Joint checking", "2026-03", 1200.0, 2).
+		AddRow("Alex", "Visa", "2026-03", 300.0, 1).
+		AddRow("Sam", "Joint checking", "2026-03", 500.0, 3)
+	mock.ExpectQuery("SELECT ba.paid_by, ba.paid_from").
+		WithArgs(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/contributions?from=2026-03&to=2026-03", nil)
+	rr := httptest.NewRecorder()
+	h.Contributions(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data ContributionReport `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Total != 2000 || resp.Data.ByMember["Alex"] != 1500 || resp.Data.ByMember["Sam"] != 500 {
+		t.Errorf("unexpected totals: %+v", resp.Data)
+	}
+	if resp.Data.Share["Alex"] != 75 || resp.Data.Share["Sam"] != 25 {
+		t.Errorf("expected a 75/25 split, got %v", resp.Data.Share)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestReportContributions_RejectsReversedRange(t *testing.T) {
+	h := NewReportHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/contributions?from=2026-05&to=2026-01", nil)
+	rr := httptest.NewRecorder()
+	h.Contributions(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestAssignmentPay_RecordsPaidBy(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	paid := time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE bill_assignments SET").
+		WithArgs(5, (*float64)(nil), paid, stringPtr("Sam"), stringPtr("Joint checking")).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(5, 1, 10, float64Ptr(100.0), (*float64)(nil), float64Ptr(100.0), "paid", (*int)(nil),
+			false, "", "", false, false, (*int)(nil), false, (*time.Time)(nil), (*time.Time)(nil), &paid, (*string)(nil), (*float64)(nil), "", "Sam", "Joint checking", now, now))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/5/pay",
+		bytes.NewBufferString(`{"paid_date":"2026-03-06","paid_by":"Sam","paid_from":"Joint checking"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.Pay(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.BillAssignment `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.PaidBy != "Sam" || resp.Data.PaidFrom != "Joint checking" {
+		t.Errorf("expected Sam from Joint checking, got %q from %q", resp.Data.PaidBy, resp.Data.PaidFrom)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Monthly summary report
+// ---------------------------------------------------------------------------
+
+func TestReportMonthly_TotalsPerMonth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	biweekly := "biweekly"
+	monthly := "monthly"
+	mock.ExpectQuery("SELECT to_char\\(pp.pay_date, 'YYYY-MM'\\) AS month, inc.pay_schedule").
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"month", "pay_schedule", "schedule_detail", "count", "expected"}).
+			AddRow("2025-01", &biweekly, []byte(`{}`), 3, 4500.0).
+			AddRow("2025-01", &monthly, []byte(`{}`), 1, 800.0).
+			AddRow("2025-02", &biweekly, []byte(`{}`), 2, 3000.0))
+	mock.ExpectQuery("FROM bill_assignments ba").
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"month", "planned", "paid"}).
+			AddRow("2025-01", 4000.0, 3500.0).
+			AddRow("2025-02", 2500.0, 0.0))
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/monthly?year=2025", nil)
+	rr := httptest.NewRecorder()
+	h.Monthly(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data MonthlyReport `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Months) != 12 || resp.Data.Months[11].Month != "2025-12" {
+		t.Fatalf("expected all twelve months, got %+v", resp.Data.Months)
+	}
+	jan := resp.Data.Months[0]
+	if jan.Paychecks != 4 || jan.ExpectedIncome != 5300 || jan.PlannedBills != 4000 ||
+		jan.ActualPaid != 3500 || jan.Leftover != 1300 || jan.SurplusPaychecks != 1 {
+		t.Errorf("unexpected January: %+v", jan)
+	}
+	if feb := resp.Data.Months[1]; feb.SurplusPaychecks != 0 || feb.Leftover != 500 {
+		t.Errorf("unexpected February: %+v", feb)
+	}
+	if tot := resp.Data.Totals; tot.ExpectedIncome != 8300 || tot.Leftover != 1800 || tot.SurplusPaychecks != 1 {
+		t.Errorf("unexpected totals: %+v", tot)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestReportMonthly_InvalidYear(t *testing.T) {
+	h := NewReportHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/monthly?year=25x", nil)
+	rr := httptest.NewRecorder()
+	h.Monthly(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Category trends report
+// ---------------------------------------------------------------------------
+
+func TestReportCategories_TrendsAndCaches(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Only one query: the second request is served from the cache
+	mock.ExpectQuery("SELECT b.category, to_char").
+		WithArgs(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows([]string{"category", "month", "total"}).
+			AddRow("Groceries", "2026-01", 400.0).
+			AddRow("Groceries", "2026-02", 500.0).
+			AddRow("Utilities", "2026-03", 120.0))
+
+	h := NewReportHandler(mock)
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/categories?from=2026-01&to=2026-03", nil)
+		rr := httptest.NewRecorder()
+		h.Categories(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Data CategoryTrendReport `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Data.Categories) != 2 {
+			t.Fatalf("expected 2 categories, got %+v", resp.Data.Categories)
+		}
+		groceries := resp.Data.Categories[0]
+		if groceries.Total != 900 || len(groceries.Months) != 3 {
+			t.Fatalf("unexpected groceries trend: %+v", groceries)
+		}
+		if groceries.Months[0].PercentChange != nil {
+			t.Error("expected no change for the first month")
+		}
+		if pc := groceries.Months[1].PercentChange; pc == nil || *pc != 25 {
+			t.Errorf("expected a 25%% rise in February, got %v", pc)
+		}
+		if pc := groceries.Months[2].PercentChange; pc == nil || *pc != -100 {
+			t.Errorf("expected a 100%% drop in March, got %v", pc)
+		}
+		if pc := resp.Data.Categories[1].Months[2].PercentChange; pc != nil {
+			t.Errorf("expected no change from an empty month, got %v", *pc)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestReportCategories_RejectsReversedRange(t *testing.T) {
+	h := NewReportHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/categories?from=2026-05&to=2026-01", nil)
+	rr := httptest.NewRecorder()
+	h.Categories(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Planned vs actual report
+// ---------------------------------------------------------------------------
+
+func TestReportPlannedVsActual_UnusedBuffer(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Electric was planned at 3 x $115 (15% buffer) and came in at $310;
+	// internet has no buffer and ran $5 over
+	rows := pgxmock.NewRows([]string{"id", "name", "buffer_percent", "count", "planned", "actual", "buffer", "unused", "overrun"}).
+		AddRow(1, "Electric", 15.0, 3, 345.0, 310.0, 45.0, 35.0, 0.0).
+		AddRow(2, "Internet", 0.0, 3, 150.0, 155.0, 0.0, 0.0, 5.0)
+	mock.ExpectQuery("WITH paid AS").
+		WithArgs(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/planned-vs-actual?from=2026-01&to=2026-03", nil)
+	rr := httptest.NewRecorder()
+	h.PlannedVsActual(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data PlannedVsActualReport `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Bills) != 2 || resp.Data.Bills[0].UnusedBuffer != 35 || resp.Data.Bills[1].Overrun != 5 {
+		t.Errorf("unexpected bills: %+v", resp.Data.Bills)
+	}
+	if resp.Data.Planned != 495 || resp.Data.Actual != 465 || resp.Data.Buffer != 45 || resp.Data.UnusedBuffer != 35 {
+		t.Errorf("unexpected totals: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestReportPlannedVsActual_RejectsReversedRange(t *testing.T) {
+	h := NewReportHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/planned-vs-actual?from=2026-05&to=2026-01", nil)
+	rr := httptest.NewRecorder()
+	h.PlannedVsActual(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Bill disputes
+// ---------------------------------------------------------------------------
+
+var disputeTestCols = []string{"id", "assignment_id", "opened_on", "reference", "expected_credit", "resolved_on",
+	"credit_received", "notes", "created_at", "updated_at"}
+
+func TestAssignmentOpenDispute_DefaultsToToday(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO assignment_disputes").
+		WithArgs(5, (*time.Time)(nil), stringPtr("CASE-881"), float64Ptr(42.5), (*string)(nil), today).
+		WillReturnRows(pgxmock.NewRows(disputeTestCols).
+			AddRow(1, 5, today, "CASE-881", 42.5, (*time.Time)(nil), (*float64)(nil), "", now, now))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/assignments/5/dispute",
+		bytes.NewBufferString(`{"reference":"CASE-881","expected_credit":42.5}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.OpenDispute(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.AssignmentDispute `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Status != "open" || resp.Data.Reference != "CASE-881" {
+		t.Errorf("unexpected dispute: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentOpenDispute_AssignmentNotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("INSERT INTO assignment_disputes").
+		WithArgs(404, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/assignments/404/dispute", bytes.NewBufferString(`{}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "404")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.OpenDispute(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func TestAssignmentOpenDispute_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"negative credit", `{"expected_credit":-5}`},
+		{"bad date", `{"opened_on":"3/2/2026"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewAssignmentHandler(nil)
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/assignments/5/dispute", bytes.NewBufferString(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "5")
+			req = req.WithContext(withChiContext(req.Context(), rctx))
+			rr := httptest.NewRecorder()
+			h.OpenDispute(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestAssignmentResolveDispute_RecordsCredit(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	opened := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	resolved := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE assignment_disputes SET resolved_on").WithArgs(5, resolved, 30.0).
+		WillReturnRows(pgxmock.NewRows(disputeTestCols).
+			AddRow(1, 5, opened, "CASE-881", 42.5, &resolved, float64Ptr(30), "", now, now))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/5/dispute/resolve",
+		bytes.NewBufferString(`{"credit_received":30,"resolved_on":"2026-04-10"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.ResolveDispute(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.AssignmentDispute `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Status != "resolved" || resp.Data.CreditReceived == nil || *resp.Data.CreditReceived != 30 {
+		t.Errorf("unexpected dispute: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestReportDisputes_OutstandingAndResolved(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	opened := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	resolved := time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC)
+	payDate := time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC)
+	rows := pgxmock.NewRows([]string{"id", "bill_id", "name", "pay_date", "amount", "opened_on", "reference",
+		"expected_credit", "resolved_on", "credit_received"}).
+		AddRow(11, 2, "Internet", payDate, 90.0, opened, "T-19", 40.0, &resolved, float64Ptr(25)).
+		AddRow(12, 3, "Electric", payDate, 180.0, opened, "", 60.0, (*time.Time)(nil), (*float64)(nil))
+	mock.ExpectQuery("FROM assignment_disputes ad").
+		WithArgs(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/disputes?from=2026-01&to=2026-03", nil)
+	rr := httptest.NewRecorder()
+	h.Disputes(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data DisputeReport `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Outstanding) != 1 || resp.Data.Outstanding[0].Name != "Electric" || resp.Data.Outstanding[0].DaysOpen == 0 {
+		t.Errorf("unexpected outstanding: %+v", resp.Data.Outstanding)
+	}
+	if resp.Data.ExpectedCredit != 60 || resp.Data.CreditsReceived != 25 || resp.Data.Shortfall != 15 {
+		t.Errorf("unexpected totals: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestLoadReminderDigest_SkipsOpenDisputes(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`NOT EXISTS \(SELECT 1 FROM assignment_disputes ad WHERE ad.assignment_id = ba.id AND ad.resolved_on IS NULL\)`).
+		WithArgs(today, today.AddDate(0, 0, 3)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "due_date", "pay_date", "amount"}))
+	mock.ExpectQuery("FROM documents").WithArgs(today).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "title", "kind", "expires_on"}))
+
+	digest, err := loadReminderDigest(context.Background(), mock, today.Add(9*time.Hour), 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !digest.Empty() {
+		t.Errorf("expected an empty digest, got %+v", digest)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tax-deductible report
+// ---------------------------------------------------------------------------
+
+func TestReportTaxDeductible_InvalidYear(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/tax-deductible/abc", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("year", "abc")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.TaxDeductible(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestReportTaxDeductible_CSV(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{"id", "pay_date", "payee", "category", "amount"}).
+		AddRow(1, time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC), "Food Bank", "giving", 50.0).
+		AddRow(2, time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC), "Church", "giving", 125.5)
+	mock.ExpectQuery("SELECT (.+) FROM bill_assignments ba").
+		WithArgs(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	h := NewReportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/tax-deductible/2025?format=csv", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("year", "2025")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.TaxDeductible(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("expected text/csv, got %q", ct)
+	}
+	expected := "date,payee,category,amount\n" +
+		"2025-03-07,Food Bank,giving,50.00\n" +
+		"2025-06-20,Church,giving,125.50\n" +
+		",TOTAL,,175.50\n"
+	if rr.Body.String() != expected {
+		t.Errorf("unexpected csv:\n%s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Assignment scheduled_date
+// ---------------------------------------------------------------------------
+
+func TestAssignmentCreate_InvalidScheduledDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"bill_id":1,"pay_period_id":10,"scheduled_date":"03/12/2026"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestAssignmentUpdate_ClearsScheduledDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+		"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+	}).AddRow(5, 1, 10, float64Ptr(100.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+		false, "", "", false, false, (*int)(nil), false, (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now)
+
+	mock.ExpectQuery("UPDATE bill_assignments SET").
+		WithArgs(5, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), true, (*time.Time)(nil),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(rows)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"scheduled_date":""}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/assignments/5", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentCreate_DefaultsDueDateFromBill(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Paid on the 20th, due on the 5th: the occurrence is next month's
+	dueDay := 5
+	mock.ExpectQuery("SELECT b.due_day, pp.pay_date FROM bills b, pay_periods pp").
+		WithArgs(1, 10).
+		WillReturnRows(pgxmock.NewRows([]string{"due_day", "pay_date"}).
+			AddRow(&dueDay, time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)))
+
+	now := time.Now()
+	due := time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(80.0), (*float64)(nil), (*float64)(nil), "pending", false, "", "", false,
+			(*time.Time)(nil), &due, (*string)(nil), (*float64)(nil), "").
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(7, 1, 10, float64Ptr(80.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			false, "", "", true, false, (*int)(nil), false, (*time.Time)(nil), &due, (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"bill_id":1,"pay_period_id":10,"planned_amount":80}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentCreate_InvalidDueDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"bill_id":1,"pay_period_id":10,"due_date":"April 5"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Assignment currency of record
+// ---------------------------------------------------------------------------
+
+func TestAssignmentCreate_ConvertsOriginalAmount(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	due := time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(54.3), (*float64)(nil), (*float64)(nil), "pending", true, "Hotel deposit", "", false,
+			(*time.Time)(nil), &due, stringPtr("EUR"), float64Ptr(50.0), "ECB rate 1.086").
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(7, 1, 10, float64Ptr(54.3), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			true, "Hotel deposit", "", true, false, (*int)(nil), false, (*time.Time)(nil), &due, (*time.Time)(nil),
+			stringPtr("EUR"), float64Ptr(50.0), "ECB rate 1.086", "", "", now, now))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"bill_id":1,"pay_period_id":10,"is_extra":true,"extra_name":"Hotel deposit","due_date":"2026-04-05",
+		"original_currency":"eur","original_amount":50,"fx_rate":1.086,"fx_note":"ECB rate 1.086"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentCreate_OriginalAmountValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"bad code", `{"bill_id":1,"pay_period_id":10,"original_currency":"EURO","original_amount":50}`},
+		{"currency without amount", `{"bill_id":1,"pay_period_id":10,"original_currency":"EUR"}`},
+		{"amount without currency", `{"bill_id":1,"pay_period_id":10,"original_amount":50}`},
+		{"rate without amount", `{"bill_id":1,"pay_period_id":10,"fx_rate":1.1}`},
+		{"zero rate", `{"bill_id":1,"pay_period_id":10,"original_currency":"EUR","original_amount":50,"fx_rate":0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewAssignmentHandler(nil)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			h.Create(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestAssignmentUpdate_RateNeedsOriginalAmount(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT original_amount FROM bill_assignments").WithArgs(5).
+		WillReturnRows(pgxmock.NewRows([]string{"original_amount"}).AddRow((*float64)(nil)))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/assignments/5", bytes.NewBufferString(`{"fx_rate":1.1}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Assignment quick-pay
+// ---------------------------------------------------------------------------
+
+func TestAssignmentPay_RecordsAmountDateAndPublishes(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	paid := time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE bill_assignments SET").
+		WithArgs(5, float64Ptr(97.25), paid, (*string)(nil), (*string)(nil)).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(5, 1, 10, float64Ptr(100.0), (*float64)(nil), float64Ptr(97.25), "paid", (*int)(nil),
+			false, "", "", false, false, (*int)(nil), false, (*time.Time)(nil), (*time.Time)(nil), &paid, (*string)(nil), (*float64)(nil), "", "", "", now, now))
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(e events.Event) { published = append(published, e) })
+
+	h := NewAssignmentHandler(mock).WithEvents(bus)
+	body := bytes.NewBufferString(`{"actual_amount":97.25,"paid_date":"2026-03-12"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/5/pay", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Pay(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if len(published) != 1 || published[0].Type != events.AssignmentPaid {
+		t.Errorf("expected one assignment.paid event, got %+v", published)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentPay_InvalidDate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"paid_date":"yesterday"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/5/pay", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Pay(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Bill skip-a-month
+// ---------------------------------------------------------------------------
+
+func TestLoadDueSoon_Buckets(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	cols := []string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+		"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		"name", "due", "amount",
+	}
+	row := func(id int, name string, due time.Time, amount float64) []any {
+		return []any{id, id, 10, float64Ptr(amount), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			false, "", "", false, false, (*int)(nil), false, (*time.Time)(nil), &due, (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now,
+			name, due, amount}
+	}
+	mock.ExpectQuery("FROM bill_assignments ba").
+		WithArgs(today.AddDate(0, 0, 7)).
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow(row(1, "Rent", today.AddDate(0, 0, -2), 1200)...).
+			AddRow(row(2, "Phone", today, 45.5)...).
+			AddRow(row(3, "Power", today.AddDate(0, 0, 3), 80.25)...).
+			AddRow(row(4, "Gym", today.AddDate(0, 0, 6), 30)...))
+
+	report, err := loadDueSoon(context.Background(), mock, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.AsOf != "2026-03-10" {
+		t.Errorf("as_of = %q, want 2026-03-10", report.AsOf)
+	}
+	if len(report.Overdue.Assignments) != 1 || report.Overdue.Total != 1200 {
+		t.Errorf("overdue = %+v, want Rent totalling 1200", report.Overdue)
+	}
+	if len(report.DueIn3Days.Assignments) != 2 || report.DueIn3Days.Total != 125.75 {
+		t.Errorf("due in 3 days = %+v, want Phone and Power totalling 125.75", report.DueIn3Days)
+	}
+	if len(report.DueIn7Days.Assignments) != 1 || report.DueIn7Days.Assignments[0].BillName != "Gym" {
+		t.Errorf("due in 7 days = %+v, want Gym", report.DueIn7Days)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAssignmentDeferOptions_RanksOnTimeAffordableFirst(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	payDate := time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM bill_assignments ba").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "pay_date", "due_date", "due_day", "amount"}).
+			AddRow(4, payDate, (*time.Time)(nil), intPtr(25), 200.0))
+	mock.ExpectQuery("FROM credit_card_promos p").WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "credit_card_id", "rate", "balance", "expires_on", "description", "created_at"}).
+			AddRow(1, 2, 0.0, (*float64)(nil), time.Date(2026, 3, 22, 0, 0, 0, 0, time.UTC), "balance transfer", time.Now()))
+	mock.ExpectQuery("FROM pay_periods pp").WithArgs(payDate, maxDeferOptions).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "name", "remaining"}).
+			AddRow(11, time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC), "Job", 150.0).
+			AddRow(12, time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), "Job", 400.0).
+			AddRow(13, time.Date(2026, 3, 27, 0, 0, 0, 0, time.UTC), "Job", 900.0))
+
+	h := NewAssignmentHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/assignments/7/defer-options", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "7")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.DeferOptions(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.DeferOptions `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+
+	if resp.Data.NextDueDate == nil || *resp.Data.NextDueDate != "2026-03-25" {
+		t.Errorf("next_due_date = %v, want 2026-03-25", resp.Data.NextDueDate)
+	}
+	var order []int
+	for _, o := range resp.Data.Options {
+		order = append(order, o.PeriodID)
+	}
+	// 12 pays before the 25th and stays positive; 13 is richer but late; 11 goes negative
+	if len(order) != 3 || order[0] != 12 || order[1] != 13 || order[2] != 11 {
+		t.Errorf("order = %v, want [12 13 11]", order)
+	}
+	if !resp.Data.Options[0].Recommended || resp.Data.Options[1].Recommended {
+		t.Error("expected only the first option to be recommended")
+	}
+	if resp.Data.Options[2].ProjectedRemaining != -50 {
+		t.Errorf("projected remaining = %v, want -50", resp.Data.Options[2].ProjectedRemaining)
+	}
+	// The 0% promo ends on the 22nd, so only the periods after it warn
+	if resp.Data.Options[0].PromoWarning != "" || resp.Data.Options[1].PromoWarning == "" || resp.Data.Options[2].PromoWarning != "" {
+		t.Errorf("unexpected promo warnings: %+v", resp.Data.Options)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBillAddPromo_NoCreditCard(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("INSERT INTO credit_card_promos").
+		WithArgs(4, 0.0, (*float64)(nil), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), "").
+		WillReturnError(pgx.ErrNoRows)
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills/4/promos", bytes.NewBufferString(`{"rate":0,"expires_on":"2026-12-31"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "4")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.AddPromo(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func TestBillAddPromo_InvalidExpiry(t *testing.T) {
+	h := NewBillHandler(nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills/4/promos", bytes.NewBufferString(`{"rate":0,"expires_on":"12/31/2026"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "4")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.AddPromo(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestBillPayoff_UsesPromosAndStandardAPR(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT apr FROM credit_cards").WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"apr"}).AddRow(float64Ptr(24.0)))
+	mock.ExpectQuery("FROM credit_card_promos p").WithArgs(4).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "credit_card_id", "rate", "balance", "expires_on", "description", "created_at"}).
+			AddRow(1, 2, 0.0, (*float64)(nil), time.Now().AddDate(2, 0, 0), "", time.Now()))
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/bills/4/payoff?balance=1000&payment=250", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "4")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Payoff(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data services.PayoffPlan `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if !resp.Data.PaidOff || len(resp.Data.Months) != 4 || resp.Data.TotalInterest != 0 {
+		t.Errorf("expected interest-free payoff in 4 months, got %+v", resp.Data)
+	}
+}
+
+func TestBillPayoff_InvalidPayment(t *testing.T) {
+	h := NewBillHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/bills/4/payoff?balance=1000", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "4")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Payoff(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestBillSkip_InvalidMonth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewBillHandler(mock)
+	body := bytes.NewBufferString(`{"month":"2026-13"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills/1/skip", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Skip(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestBillSkip_RecordsSkipAndMarksAssignments(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	month := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO bill_skips").
+		WithArgs(1, month, "on vacation").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "bill_id", "month", "reason", "created_at"}).
+			AddRow(3, 1, month, "on vacation", time.Now()))
+	mock.ExpectExec("UPDATE bill_assignments SET status = 'skipped'").
+		WithArgs(1, month, time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewBillHandler(mock)
+	body := bytes.NewBufferString(`{"month":"2026-07","reason":"on vacation"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills/1/skip", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Skip(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Month string `json:"month"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Month != "2026-07" {
+		t.Errorf("expected month 2026-07, got %q", resp.Data.Month)
+	}
+}
+
+func TestAutoAssign_HonorsSkippedMonth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Lawn Care", float64Ptr(60.0), 15, "monthly", nil, nil, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 3, 7, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	// March is skipped, so no INSERT is expected
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"}).
+		AddRow(1, time.Date(2036, 3, 1, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-03-01","to":"2036-03-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Seasonal bills
+// ---------------------------------------------------------------------------
+
+func TestCreateBill_InvalidActiveMonths(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewBillHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Lawn Care","active_months":[4,5,13]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestAutoAssign_SeasonalBillOnlyInActiveMonths(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Lawn care runs April through October
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Lawn Care", float64Ptr(60.0), 15, "monthly", nil, []int{4, 5, 6, 7, 8, 9, 10}, nil, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 3, 7, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 4, 4, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	// Only April is in season, so only the April period gets an assignment
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 11, float64Ptr(60.0), time.Date(2036, 4, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount", "actual_amount",
+			"status", "deferred_to_id", "is_extra", "extra_name", "notes", "manually_moved",
+			"is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(100, 1, 11, float64Ptr(60.0), nil, nil, "pending", nil, false, "", "", false,
+			false, nil, false, nil, nil, nil, nil, nil, "", "", "", time.Now(), time.Now()))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-03-01","to":"2036-04-30"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Variable bill amounts by month
+// ---------------------------------------------------------------------------
+
+func TestCreateBill_InvalidMonthlyAmounts(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewBillHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Gas","monthly_amounts":{"1":180,"13":90}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestAutoAssign_UsesMonthlyAmountOverride(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Summer electric runs higher in July; other months use the default
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil, nil, map[int]float64{7: 240}, 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 6, 6, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 7, 4, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	assignCols := []string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount", "actual_amount",
+		"status", "deferred_to_id", "is_extra", "extra_name", "notes", "manually_moved",
+		"is_sinking_fund", "sinking_fund_for_period_id",
+		"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+	}
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(100.0), time.Date(2036, 6, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows(assignCols).AddRow(100, 1, 10, float64Ptr(100.0), nil, nil, "pending", nil, false, "", "", false,
+			false, nil, false, nil, nil, nil, nil, nil, "", "", "", time.Now(), time.Now()))
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 11, float64Ptr(240.0), time.Date(2036, 7, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows(assignCols).AddRow(101, 1, 11, float64Ptr(240.0), nil, nil, "pending", nil, false, "", "", false,
+			false, nil, false, nil, nil, nil, nil, nil, "", "", "", time.Now(), time.Now()))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-06-01","to":"2036-07-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAutoAssign_AddsBufferPercent(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// Electric is planned 15% high, on top of the July override too
+	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail", "active_months", "monthly_amounts", "buffer_percent"}).
+		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil, nil, map[int]float64{7: 240}, 15.0)
+	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+
+	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
+		AddRow(10, time.Date(2036, 6, 6, 0, 0, 0, 0, time.UTC)).
+		AddRow(11, time.Date(2036, 7, 4, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+
+	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved", "due_date"})
+	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+
+	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "due_date"})
+	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id, dbp.due_date FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+
+	skipRows := pgxmock.NewRows([]string{"bill_id", "month"})
+	mock.ExpectQuery("SELECT bs.bill_id, bs.month FROM bill_skips").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(skipRows)
+
+	assignCols := []string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount", "actual_amount",
+		"status", "deferred_to_id", "is_extra", "extra_name", "notes", "manually_moved",
+		"is_sinking_fund", "sinking_fund_for_period_id",
+		"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+	}
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 10, float64Ptr(115.0), time.Date(2036, 6, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows(assignCols).AddRow(100, 1, 10, float64Ptr(115.0), nil, nil, "pending", nil, false, "", "", false,
+			false, nil, false, nil, nil, nil, nil, nil, "", "", "", time.Now(), time.Now()))
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 11, float64Ptr(276.0), time.Date(2036, 7, 15, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows(assignCols).AddRow(101, 1, 11, float64Ptr(276.0), nil, nil, "pending", nil, false, "", "", false,
+			false, nil, false, nil, nil, nil, nil, nil, "", "", "", time.Now(), time.Now()))
+
+	h := NewAssignmentHandler(mock)
+	body := bytes.NewBufferString(`{"from":"2036-06-01","to":"2036-07-31"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	rr := httptest.NewRecorder()
+	h.AutoAssign(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Income source templates and duplication
+// ---------------------------------------------------------------------------
+
+func TestDuplicateIncome_DefaultName(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "owner", "created_at", "updated_at"}).
+		AddRow(8, "Day Job (copy)", "biweekly", json.RawMessage(`{"weekday":5,"anchor_date":"2026-01-02"}`), float64Ptr(2100.0), true, nil, "", now, now)
+	mock.ExpectQuery("INSERT INTO income_sources").WithArgs(3, "").WillReturnRows(rows)
+
+	h := NewIncomeHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources/3/duplicate", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Duplicate(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.IncomeSource `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.ID != 8 || resp.Data.Name != "Day Job (copy)" {
+		t.Errorf("unexpected duplicate: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDuplicateIncome_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("INSERT INTO income_sources").WithArgs(99, "Spouse Job").WillReturnError(fmt.Errorf("no rows in result set"))
+
+	h := NewIncomeHandler(mock)
+	body := bytes.NewBufferString(`{"name":"Spouse Job"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources/99/duplicate", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "99")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Duplicate(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func TestIncomeTemplates(t *testing.T) {
+	h := NewIncomeHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/income-sources/templates", nil)
+	rr := httptest.NewRecorder()
+	h.Templates(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp struct {
+		Data []models.IncomeSourceTemplate `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != len(models.IncomeSourceTemplates) {
+		t.Fatalf("expected %d templates, got %d", len(models.IncomeSourceTemplates), len(resp.Data))
+	}
+
+	// Templates that need no user input must already be valid schedules
+	for _, tmpl := range models.IncomeSourceTemplates {
+		if tmpl.PaySchedule != "semimonthly" {
+			continue
+		}
+		source := models.IncomeSource{PaySchedule: tmpl.PaySchedule, ScheduleDetail: tmpl.ScheduleDetail}
+		if err := h.generator.Validate(source); err != nil {
+			t.Errorf("template %s: %v", tmpl.Key, err)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Payroll calendars
+// ---------------------------------------------------------------------------
+
+func TestSetPayrollCalendar_Validation(t *testing.T) {
+	h := NewIncomeHandler(nil)
+
+	tests := []struct {
+		name string
+		year string
+		body string
+	}{
+		{"bad year", "26", `{"dates":["2026-01-09"]}`},
+		{"no dates", "2026", `{"dates":[]}`},
+		{"bad date", "2026", `{"dates":["01/09/2026"]}`},
+		{"other year", "2026", `{"dates":["2025-12-26"]}`},
+		{"duplicate", "2026", `{"dates":["2026-01-09","2026-01-09"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/income-sources/3/payroll-calendars/"+tt.year, bytes.NewBufferString(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "3")
+			rctx.URLParams.Add("year", tt.year)
+			req = req.WithContext(withChiContext(req.Context(), rctx))
+			rr := httptest.NewRecorder()
+			h.SetPayrollCalendar(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestSetPayrollCalendar_ReportsStalePeriods(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT 1 FROM income_sources").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"?column?"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM payroll_calendar_dates").WithArgs(3, 2026).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec("INSERT INTO payroll_calendar_dates").WithArgs(3, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mock.ExpectQuery("FROM pay_periods").WithArgs(3, 2026, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_date"}).AddRow(time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewIncomeHandler(mock)
+	body := bytes.NewBufferString(`{"dates":["2026-02-11","2026-01-09"]}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/income-sources/3/payroll-calendars/2026", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	rctx.URLParams.Add("year", "2026")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.SetPayrollCalendar(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.PayrollCalendar `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Dates) != 2 || resp.Data.Dates[0] != "2026-01-09" {
+		t.Errorf("expected sorted dates, got %v", resp.Data.Dates)
+	}
+	if len(resp.Data.StalePayDates) != 1 || resp.Data.StalePayDates[0] != "2026-01-10" {
+		t.Errorf("expected the Jan 10 period to be stale, got %v", resp.Data.StalePayDates)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetPayrollCalendar_SourceNotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT 1 FROM income_sources").WithArgs(99).WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	h := NewIncomeHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/income-sources/99/payroll-calendars/2026", bytes.NewBufferString(`{"dates":["2026-01-09"]}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "99")
+	rctx.URLParams.Add("year", "2026")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.SetPayrollCalendar(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestPayrollCalendars_GroupsByYear(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("FROM payroll_calendar_dates").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_date"}).
+			AddRow(time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC)).
+			AddRow(time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)).
+			AddRow(time.Date(2026, 1, 23, 0, 0, 0, 0, time.UTC)))
+
+	h := NewIncomeHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/income-sources/3/payroll-calendars", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.PayrollCalendars(rr, req)
+
+	var resp struct {
+		Data []models.PayrollCalendar `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].Year != 2025 || resp.Data[1].Year != 2026 || len(resp.Data[1].Dates) != 2 {
+		t.Errorf("unexpected calendars: %+v", resp.Data)
+	}
+}
+
+func TestDeletePayrollCalendar_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("DELETE FROM payroll_calendar_dates").WithArgs(3, 2027).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	h := NewIncomeHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/income-sources/3/payroll-calendars/2027", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	rctx.URLParams.Add("year", "2027")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.DeletePayrollCalendar(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Period checklist
+// ---------------------------------------------------------------------------
+
+var checklistColumns = []string{"id", "pay_period_id", "label", "is_done", "sort_order", "created_at", "updated_at"}
+
+func TestChecklistCreate_EmptyLabel(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewChecklistHandler(mock)
+	body := bytes.NewBufferString(`{"label":"   "}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/5/checklist", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestChecklistCreate_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO period_checklist_items").
+		WithArgs(5, "Transfer $100 to savings", 0).
+		WillReturnRows(pgxmock.NewRows(checklistColumns).AddRow(1, 5, "Transfer $100 to savings", false, 0, now, now))
+
+	h := NewChecklistHandler(mock)
+	body := bytes.NewBufferString(`{"label":"Transfer $100 to savings"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/5/checklist", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "5")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestChecklistUpdate_MarkDone(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	done := true
+	mock.ExpectQuery("UPDATE period_checklist_items").
+		WithArgs(1, (*string)(nil), &done, (*int)(nil)).
+		WillReturnRows(pgxmock.NewRows(checklistColumns).AddRow(1, 5, "Call about water bill credit", true, 0, now, now))
+
+	h := NewChecklistHandler(mock)
+	body := bytes.NewBufferString(`{"is_done":true}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/checklist-items/1", body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.ChecklistItem `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if !resp.Data.IsDone {
+		t.Error("expected item to be done")
+	}
+}
+
+func TestChecklistDelete_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("DELETE FROM period_checklist_items").WithArgs(42).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	h := NewChecklistHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/checklist-items/42", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "42")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+// ---------------------------------------------------------------------------
+// Configuration export/import
+// ---------------------------------------------------------------------------
+
+func TestConfigImport_UnsupportedVersion(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewConfigHandler(mock)
+	body := bytes.NewBufferString(`{"version":99,"bills":[],"income_sources":[]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/config/import", body)
+	rr := httptest.NewRecorder()
+	h.Import(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestConfigImport_InvalidBillRejectsWholeImport(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	// No transaction is expected: validation fails before anything is written
+	h := NewConfigHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,"bills":[{"name":"Rent"},{"name":"Allowance","bill_type":"allowance"}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/config/import", body)
+	rr := httptest.NewRecorder()
+	h.Import(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestConfigImport_SkipsExistingAndCreatesNew(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM bills").WithArgs("Rent").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM income_sources").WithArgs("Spouse Job").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("INSERT INTO income_sources").
+		WithArgs("Spouse Job", "semimonthly", pgxmock.AnyArg(), float64Ptr(1800.0), (*time.Time)(nil), "").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "owner", "created_at", "updated_at"}).
+			AddRow(4, "Spouse Job", "semimonthly", json.RawMessage(`{"days":[15,"last"]}`), float64Ptr(1800.0), true, nil, "", now, now))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewConfigHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,
+		"bills":[{"name":"Rent","default_amount":1500,"due_day":1}],
+		"income_sources":[{"name":"Spouse Job","pay_schedule":"semimonthly","schedule_detail":{"days":[15,"last"]},"default_amount":1800}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/config/import", body)
+	rr := httptest.NewRecorder()
+	h.Import(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.ConfigImportResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.ImportedBills != 0 || resp.Data.ImportedIncomeSources != 1 {
+		t.Errorf("unexpected counts: %+v", resp.Data)
+	}
+	if len(resp.Data.Skipped) != 1 || resp.Data.Skipped[0] != "bill: Rent" {
+		t.Errorf("expected Rent to be skipped, got %v", resp.Data.Skipped)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Full backup and restore
+// ---------------------------------------------------------------------------
+
+func TestBackupExport_AllTables(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT jsonb_build_object").
+		WillReturnRows(pgxmock.NewRows([]string{"jsonb_build_object"}).
+			AddRow([]byte(`{"bills":[{"id":3,"name":"Rent","default_amount":1500.00}],"income_sources":[]}`)))
+
+	h := NewBackupHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export", nil)
+	rr := httptest.NewRecorder()
+	h.Export(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Header().Get("Content-Disposition"), "budget-backup-") {
+		t.Errorf("expected a download filename, got %q", rr.Header().Get("Content-Disposition"))
+	}
+	// Amounts keep their exact decimal text rather than passing through float64
+	if !strings.Contains(rr.Body.String(), `"default_amount":1500.00`) {
+		t.Errorf("expected the amount verbatim, got %s", rr.Body.String())
+	}
+	var resp struct {
+		Data models.Backup `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Version != models.BackupVersion || len(resp.Data.Tables["bills"]) != 1 {
+		t.Errorf("unexpected backup: %+v", resp.Data)
+	}
+}
+
+func TestBackupExport_MemberPartition(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT jsonb_build_object").
+		WillReturnRows(pgxmock.NewRows([]string{"jsonb_build_object"}).
+			AddRow([]byte(`{
+				"categories":[{"id":1,"name":"housing"}],
+				"income_sources":[{"id":1,"name":"Alex Job","owner":"Alex"},{"id":2,"name":"Sam Job","owner":"Sam"}],
+				"bills":[{"id":10,"name":"Gym","owner":"Alex"},{"id":11,"name":"Rent","owner":""}],
+				"pay_periods":[{"id":100,"income_source_id":1},{"id":200,"income_source_id":2}],
+				"bill_assignments":[
+					{"id":1000,"bill_id":10,"pay_period_id":100,"deferred_to_id":200},
+					{"id":1001,"bill_id":11,"pay_period_id":100},
+					{"id":1002,"bill_id":10,"pay_period_id":200}
+				],
+				"transactions":[{"id":5,"assignment_id":1000},{"id":6,"assignment_id":1001},{"id":7,"assignment_id":null}],
+				"documents":[{"id":1,"title":"Gym contract"},{"id":2,"title":"Lease"},{"id":3,"title":"Passport scan"}],
+				"document_bills":[{"id":1,"document_id":1,"bill_id":10},{"id":2,"document_id":2,"bill_id":11}]
+			}`)))
+
+	h := NewBackupHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export?member=Alex", nil)
+	rr := httptest.NewRecorder()
+	h.Export(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Header().Get("Content-Disposition"), "budget-backup-Alex-") {
+		t.Errorf("expected the member in the filename, got %q", rr.Header().Get("Content-Disposition"))
+	}
+	var resp struct {
+		Data models.Backup `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	tables := resp.Data.Tables
+
+	ids := func(table string) []string {
+		var out []string
+		for _, row := range tables[table] {
+			out = append(out, fmt.Sprint(row["id"]))
+		}
+		return out
+	}
+	want := map[string][]string{
+		"categories":       {"1"},
+		"income_sources":   {"1"},
+		"bills":            {"10"},
+		"pay_periods":      {"100"},
+		"bill_assignments": {"1000"},
+		"transactions":     {"5"},
+		"documents":        {"1"},
+		"document_bills":   {"1"},
+	}
+	for table, expected := range want {
+		if got := ids(table); strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Errorf("%s: expected ids %v, got %v", table, expected, got)
+		}
+	}
+	// The deferral points at Sam's pay period, which isn't exported
+	if a := tables["bill_assignments"]; len(a) == 1 && a[0]["deferred_to_id"] != nil {
+		t.Errorf("expected deferred_to_id cleared, got %v", a[0]["deferred_to_id"])
+	}
+}
+
+func TestBackupExport_UnknownMember(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT jsonb_build_object").
+		WillReturnRows(pgxmock.NewRows([]string{"jsonb_build_object"}).
+			AddRow([]byte(`{"bills":[{"id":3,"name":"Rent","owner":""}],"income_sources":[]}`)))
+
+	h := NewBackupHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export?member=Jordan", nil)
+	rr := httptest.NewRecorder()
+	h.Export(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func backupColumnRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"table_name", "column_name"}).
+		AddRow("income_sources", "id").AddRow("income_sources", "name").
+		AddRow("pay_periods", "id").AddRow("pay_periods", "income_source_id").AddRow("pay_periods", "pay_date")
+}
+
+func TestBackupRestore_MergeRemapsIDs(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns").WithArgs(pgxmock.AnyArg()).WillReturnRows(backupColumnRows())
+	// The income source already exists here as id 2; unknown columns are dropped
+	mock.ExpectQuery(`SELECT t.id FROM "income_sources" t`).WithArgs(`{"name":"Job"}`).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(2)))
+	mock.ExpectQuery(`SELECT t.id FROM "pay_periods" t`).WithArgs(`{"income_source_id":2,"pay_date":"2026-01-02"}`).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO "pay_periods" \("income_source_id", "pay_date"\)`).
+		WithArgs(`{"income_source_id":2,"pay_date":"2026-01-02"}`).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(41)))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewBackupHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,"tables":{
+		"income_sources":[{"id":7,"name":"Job","legacy_column":true}],
+		"pay_periods":[{"id":30,"income_source_id":7,"pay_date":"2026-01-02"}]}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/backup", body)
+	rr := httptest.NewRecorder()
+	h.Restore(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.BackupRestoreResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Strategy != "merge" ||
+		resp.Data.Tables["income_sources"] != (models.BackupTableResult{Skipped: 1}) ||
+		resp.Data.Tables["pay_periods"] != (models.BackupTableResult{Imported: 1}) {
+		t.Errorf("unexpected result: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackupRestore_MergeRejectsDanglingReference(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns").WithArgs(pgxmock.AnyArg()).WillReturnRows(backupColumnRows())
+	mock.ExpectRollback()
+
+	h := NewBackupHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,"tables":{"pay_periods":[{"id":30,"income_source_id":7,"pay_date":"2026-01-02"}]}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/backup", body)
+	rr := httptest.NewRecorder()
+	h.Restore(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackupRestore_ReplaceKeepsIDsAndResetsSequences(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns").WithArgs(pgxmock.AnyArg()).WillReturnRows(backupColumnRows())
+	for i := len(backupTables) - 1; i >= 0; i-- {
+		mock.ExpectExec(`DELETE FROM "` + backupTables[i].name + `"`).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	}
+	for _, table := range backupTables {
+		if table.name == "income_sources" {
+			mock.ExpectQuery(`INSERT INTO "income_sources" \("id", "name"\)`).WithArgs(`{"id":7,"name":"Job"}`).
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(7)))
+		}
+		mock.ExpectExec("SELECT setval\\(pg_get_serial_sequence\\('" + table.name + "'").WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	}
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewBackupHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,"tables":{"income_sources":[{"id":7,"name":"Job"}],"documents":[],"document_bills":[]}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/backup?strategy=replace", body)
+	rr := httptest.NewRecorder()
+	h.Restore(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackupRestore_ReplaceMapsDocumentTags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	columns := pgxmock.NewRows([]string{"table_name", "column_name"}).
+		AddRow("bills", "id").AddRow("bills", "name").
+		AddRow("documents", "id").AddRow("documents", "title").AddRow("documents", "content").
+		AddRow("document_bills", "id").AddRow("document_bills", "document_id").AddRow("document_bills", "bill_id")
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns").WithArgs(pgxmock.AnyArg()).WillReturnRows(columns)
+	for i := len(backupTables) - 1; i >= 0; i-- {
+		mock.ExpectExec(`DELETE FROM "` + backupTables[i].name + `"`).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	}
+	for _, table := range backupTables {
+		switch table.name {
+		case "bills":
+			mock.ExpectQuery(`INSERT INTO "bills" \("id", "name"\)`).WithArgs(`{"id":4,"name":"Rent"}`).
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(4)))
+		case "documents":
+			// bytea travels as its hex text
+			mock.ExpectQuery(`INSERT INTO "documents" \("content", "id", "title"\)`).
+				WithArgs(`{"content":"\\x255044462d312e37","id":9,"title":"Lease"}`).
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(9)))
+		case "document_bills":
+			mock.ExpectQuery(`INSERT INTO "document_bills" \("bill_id", "document_id", "id"\)`).
+				WithArgs(`{"bill_id":4,"document_id":9,"id":2}`).
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(2)))
+		}
+		mock.ExpectExec("SELECT setval\\(pg_get_serial_sequence\\('" + table.name + "'").WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	}
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewBackupHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,"tables":{
+		"bills":[{"id":4,"name":"Rent"}],
+		"documents":[{"id":9,"title":"Lease","content":"\\x255044462d312e37"}],
+		"document_bills":[{"id":2,"document_id":9,"bill_id":4}]}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/backup?strategy=replace", body)
+	rr := httptest.NewRecorder()
+	h.Restore(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.BackupRestoreResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Tables["documents"].Imported != 1 || resp.Data.Tables["document_bills"].Imported != 1 {
+		t.Errorf("unexpected result: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackupRestore_MergeRemapsDocumentTags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	columns := pgxmock.NewRows([]string{"table_name", "column_name"}).
+		AddRow("bills", "id").AddRow("bills", "name").
+		AddRow("documents", "id").AddRow("documents", "title").AddRow("documents", "filename").AddRow("documents", "size_bytes").
+		AddRow("document_bills", "id").AddRow("document_bills", "document_id").AddRow("document_bills", "bill_id")
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns").WithArgs(pgxmock.AnyArg()).WillReturnRows(columns)
+	// Rent is already here as bill 12; the lease is new and lands as 30
+	mock.ExpectQuery(`SELECT t.id FROM "bills" t`).WithArgs(`{"name":"Rent"}`).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(12)))
+	mock.ExpectQuery(`SELECT t.id FROM "documents" t`).WithArgs(`{"filename":"lease.pdf","size_bytes":8,"title":"Lease"}`).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO "documents"`).WithArgs(`{"filename":"lease.pdf","size_bytes":8,"title":"Lease"}`).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(30)))
+	mock.ExpectQuery(`SELECT t.id FROM "document_bills" t`).WithArgs(`{"bill_id":12,"document_id":30}`).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO "document_bills" \("bill_id", "document_id"\)`).WithArgs(`{"bill_id":12,"document_id":30}`).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(5)))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewBackupHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,"tables":{
+		"bills":[{"id":4,"name":"Rent"}],
+		"documents":[{"id":9,"title":"Lease","filename":"lease.pdf","size_bytes":8}],
+		"document_bills":[{"id":2,"document_id":9,"bill_id":4}]}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/backup", body)
+	rr := httptest.NewRecorder()
+	h.Restore(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackupRestore_ReplaceFromOlderBackupKeepsVault(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	columns := pgxmock.NewRows([]string{"table_name", "column_name"}).
+		AddRow("bills", "id").AddRow("bills", "name")
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM information_schema.columns").WithArgs(pgxmock.AnyArg()).WillReturnRows(columns)
+	// The backup predates the vault: its tags are noted before the bills go
+	mock.ExpectQuery("SELECT db.document_id, b.name FROM document_bills").
+		WillReturnRows(pgxmock.NewRows([]string{"document_id", "name"}).AddRow(9, "Rent").AddRow(9, "Renters insurance"))
+	for i := len(backupTables) - 1; i >= 0; i-- {
+		if name := backupTables[i].name; name != "documents" && name != "document_bills" {
+			mock.ExpectExec(`DELETE FROM "` + name + `"`).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		}
+	}
+	for _, table := range backupTables {
+		if table.name == "bills" {
+			mock.ExpectQuery(`INSERT INTO "bills" \("id", "name"\)`).WithArgs(`{"id":1,"name":"Rent"}`).
+				WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+		}
+		mock.ExpectExec("SELECT setval\\(pg_get_serial_sequence\\('" + table.name + "'").WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	}
+	// and put back on the restored bills by name
+	mock.ExpectExec("INSERT INTO document_bills").WithArgs([]int{9, 9}, []string{"Rent", "Renters insurance"}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewBackupHandler(mock)
+	body := bytes.NewBufferString(`{"version":1,"tables":{"bills":[{"id":1,"name":"Rent"}]}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/import/backup?strategy=replace", body)
+	rr := httptest.NewRecorder()
+	h.Restore(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.BackupRestoreResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Tables["document_bills"].Imported != 1 {
+		t.Errorf("expected one tag restored, got %+v", resp.Data.Tables["document_bills"])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBackupRestore_RejectsBadRequests(t *testing.T) {
+	cases := map[string]struct{ query, body string }{
+		"strategy": {"?strategy=overwrite", `{"version":1,"tables":{}}`},
+		"version":  {"", `{"version":99,"tables":{}}`},
+		"table":    {"", `{"version":1,"tables":{"webhooks":[]}}`},
+	}
+	for name, tc := range cases {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := NewBackupHandler(mock)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/import/backup"+tc.query, bytes.NewBufferString(tc.body))
+		rr := httptest.NewRecorder()
+		h.Restore(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: unmet expectations: %v", name, err)
+		}
+		mock.Close()
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Test fixtures
+// ---------------------------------------------------------------------------
+
+func TestFixtures_AreValidBackups(t *testing.T) {
+	known := make(map[string]bool, len(backupTables))
+	for _, bt := range backupTables {
+		known[bt.name] = true
+	}
+	names := fixtureNames()
+	if len(names) == 0 {
+		t.Fatal("no fixtures embedded")
+	}
+	for _, name := range names {
+		b, err := loadFixture(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if b.Version != models.BackupVersion {
+			t.Errorf("%s: version %d, want %d", name, b.Version, models.BackupVersion)
+		}
+		for table := range b.Tables {
+			if !known[table] {
+				t.Errorf("%s: unknown table %q", name, table)
+			}
+		}
+	}
+}
+
+func TestFixtureLoad_Unknown(t *testing.T) {
+	h := NewFixtureHandler(nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/test/fixtures/../secrets", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("name", "../secrets")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.Load(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+// ---------------------------------------------------------------------------
+// Runway
+// ---------------------------------------------------------------------------
+
+func TestDashboardRunway_CombinesSameDayPaychecks(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	payDate := today.AddDate(0, 0, -4)
+	next := today.AddDate(0, 0, 10)
+
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date, COALESCE\\(pp.actual_amount, pp.expected_amount, 0\\)").
+		WithArgs(today).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "income", "allocated"}).
+			AddRow(1, payDate, 2000.0, 1200.0).
+			AddRow(2, payDate, 500.0, 100.0))
+	mock.ExpectQuery("SELECT MIN\\(pp.pay_date\\) FROM pay_periods").
+		WithArgs(today).
+		WillReturnRows(pgxmock.NewRows([]string{"min"}).AddRow(&next))
+
+	h := NewDashboardHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/runway", nil)
+	rr := httptest.NewRecorder()
+	h.Runway(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data Runway `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	rw := resp.Data
+	if len(rw.PeriodIDs) != 2 || rw.Income != 2500 || rw.Remaining != 1200 {
+		t.Errorf("unexpected totals: %+v", rw)
+	}
+	if rw.DaysUntilNextPay != 10 || rw.SafeToSpendPerDay != 120 {
+		t.Errorf("expected 10 days at $120/day, got %d days at %v", rw.DaysUntilNextPay, rw.SafeToSpendPerDay)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDashboardRunway_NoCurrentPeriod(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT pp.id, pp.pay_date").
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "income", "allocated"}))
+
+	h := NewDashboardHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/runway", nil)
+	rr := httptest.NewRecorder()
+	h.Runway(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+// ---------------------------------------------------------------------------
+// Transactions ledger
+// ---------------------------------------------------------------------------
+
+func TestTransactionCreate_InvalidAmount(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewTransactionHandler(mock)
+	body := bytes.NewBufferString(`{"date":"2026-03-02","amount":0}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestTransactionCreate_ReconcilesAgainstAssignment(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	date := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO transactions").
+		WithArgs(date, 1500.0, "RENT PMT", "").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "txn_date", "amount", "description", "source", "assignment_id", "notes", "created_at", "updated_at"}).
+			AddRow(3, date, 1500.0, "RENT PMT", "manual", (*int)(nil), "", now, now))
+	mock.ExpectQuery("SELECT id, txn_date, amount FROM transactions WHERE assignment_id IS NULL AND id = ANY").
+		WithArgs([]int{3}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "txn_date", "amount"}).AddRow(3, date, 1500.0))
+	mock.ExpectQuery("SELECT ba.id, b.name, COALESCE\\(ba.due_date, pp.pay_date\\), ba.planned_amount").
+		WithArgs(date.AddDate(0, 0, -5), date.AddDate(0, 0, 5)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "date", "planned_amount"}).
+			AddRow(40, "Rent", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), 1500.0))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transactions SET assignment_id").WithArgs(3, 40).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE bill_assignments SET").WithArgs(40, 1500.0, 3).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewTransactionHandler(mock)
+	body := bytes.NewBufferString(`{"date":"2026-03-02","amount":1500,"description":"RENT PMT"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", body)
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.Transaction `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.AssignmentID == nil || *resp.Data.AssignmentID != 40 {
+		t.Errorf("expected transaction linked to assignment 40, got %v", resp.Data.AssignmentID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTransactionDelete_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("DELETE FROM transactions").WithArgs(9).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	h := NewTransactionHandler(mock)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/transactions/9", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "9")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Categories
+// ---------------------------------------------------------------------------
+
+func TestCategoryCreate_Duplicate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("INSERT INTO categories").
+		WithArgs("utilities", (*float64)(nil)).
+		WillReturnError(pgx.ErrNoRows)
+
+	h := NewCategoryHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/categories", bytes.NewBufferString(`{"name":" utilities "}`))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
+}
+
+func TestCategoryCreate_InvalidLimit(t *testing.T) {
+	h := NewCategoryHandler(nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/categories", bytes.NewBufferString(`{"name":"utilities","monthly_limit":-5}`))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestCategoryUpdate_RenameMovesBills(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT name FROM categories").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"name"}).AddRow("utils"))
+	mock.ExpectQuery("UPDATE categories SET").
+		WithArgs(3, pgxmock.AnyArg(), (*float64)(nil)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "monthly_limit", "created_at", "updated_at"}).
+			AddRow(3, "utilities", float64Ptr(250.0), now, now))
+	mock.ExpectExec("UPDATE bills SET category").
+		WithArgs("utils", "utilities").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 4))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewCategoryHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/categories/3", bytes.NewBufferString(`{"name":"utilities"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCategorySpending_ComparesAgainstLimit(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("FROM categories c").
+		WithArgs(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), false).
+		WillReturnRows(pgxmock.NewRows([]string{"name", "monthly_limit", "planned", "actual"}).
+			AddRow("groceries", (*float64)(nil), 320.0, 100.0).
+			AddRow("utilities", float64Ptr(200.0), 250.0, 185.0))
+
+	h := NewCategoryHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/categories/spending?month=2026-03", nil)
+	rr := httptest.NewRecorder()
+	h.Spending(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.CategorySpending `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Data) != 2 {
+		t.Fatalf("expected 2 categories, got %d", len(resp.Data))
+	}
+	if got := resp.Data[0]; got.Limit != nil || got.Remaining != nil || got.OverLimit {
+		t.Errorf("expected groceries without a limit, got %+v", got)
+	}
+	if got := resp.Data[1]; got.Remaining == nil || *got.Remaining != -50 || !got.OverLimit {
+		t.Errorf("expected utilities 50 over its limit, got %+v", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Category budget tests
+// ---------------------------------------------------------------------------
+
+func TestCategoryBudgetSet_InvalidLimit(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	h := NewCategoryBudgetHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/category-budgets/utilities", bytes.NewBufferString(`{"monthly_limit":0}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("category", "utilities")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Set(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestCategoryBudgetStatus_PercentConsumed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM categories c").
+		WithArgs(start, time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), true).
+		WillReturnRows(pgxmock.NewRows([]string{"category", "monthly_limit", "planned", "actual"}).
+			AddRow("utilities", float64Ptr(200.0), 250.0, 185.0).
+			AddRow("groceries", float64Ptr(400.0), 300.0, 100.0))
+
+	h := NewCategoryBudgetHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/category-budgets?month=2026-03", nil)
+	rr := httptest.NewRecorder()
+	h.Status(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.CategoryBudgetStatus `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Data) != 2 {
+		t.Fatalf("expected 2 categories, got %d", len(resp.Data))
+	}
+	if got := resp.Data[0]; got.PercentConsumed != 92.5 || !got.Alert {
+		t.Errorf("expected utilities at 92.5%% with alert, got %+v", got)
+	}
+	if got := resp.Data[1]; got.PercentConsumed != 25 || got.Alert {
+		t.Errorf("expected groceries at 25%% without alert, got %+v", got)
+	}
+}
+
+func TestCategoryBudgetStatus_InvalidMonth(t *testing.T) {
+	h := NewCategoryBudgetHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/category-budgets?month=March", nil)
+	rr := httptest.NewRecorder()
+	h.Status(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestCategoryBudgetCheckAlerts_OncePerMonth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM categories c").
+		WithArgs(start, time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), true).
+		WillReturnRows(pgxmock.NewRows([]string{"category", "monthly_limit", "planned", "actual"}).
+			AddRow("utilities", float64Ptr(200.0), 250.0, 185.0).
+			AddRow("insurance", float64Ptr(100.0), 100.0, 95.0).
+			AddRow("groceries", float64Ptr(400.0), 300.0, 100.0))
+	mock.ExpectExec("UPDATE categories SET alerted_month").
+		WithArgs("utilities", start).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	// Already alerted this month
+	mock.ExpectExec("UPDATE categories SET alerted_month").
+		WithArgs("insurance", start).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(e events.Event) { published = append(published, e) })
+
+	h := NewCategoryBudgetHandler(mock).WithEvents(bus)
+	if err := h.CheckAlerts(context.Background(), time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(published) != 1 || published[0].Type != events.CategoryBudgetAlert {
+		t.Fatalf("expected one category.budget_alert event, got %+v", published)
+	}
+	if s, ok := published[0].Data.(models.CategoryBudgetStatus); !ok || s.Category != "utilities" {
+		t.Errorf("expected alert for utilities, got %+v", published[0].Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Monthly obligations
+// ---------------------------------------------------------------------------
+
+func obligationBillRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"recurrence", "default_amount", "active_months", "monthly_amounts"}).
+		AddRow("monthly", float64Ptr(1500.0), []int(nil), map[int]float64(nil)).
+		AddRow("annual", float64Ptr(1200.0), []int(nil), map[int]float64(nil)).
+		AddRow("monthly", float64Ptr(140.0), []int{6, 7, 8, 9, 10, 11}, map[int]float64(nil))
+}
+
+func TestObligationRecord_AlertsOnce(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	today := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM bills WHERE is_active = true").WillReturnRows(obligationBillRows())
+	mock.ExpectQuery("FROM obligation_snapshots").
+		WithArgs(today.AddDate(0, 0, -90), today.AddDate(0, 0, -1)).
+		WillReturnRows(pgxmock.NewRows([]string{"snapshot_date", "total", "bill_count", "alerted"}).
+			AddRow(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), 1400.0, 2, false).
+			AddRow(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), 1500.0, 2, false))
+	// 1500 + 100 + 70 = 1670, up 19.29% on 1400
+	mock.ExpectExec("INSERT INTO obligation_snapshots").
+		WithArgs(today, 1670.0, 3).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("UPDATE obligation_snapshots SET alerted = true").
+		WithArgs(today).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(e events.Event) { published = append(published, e) })
+
+	h := NewObligationHandler(mock, 10).WithEvents(bus)
+	if err := h.Record(context.Background(), time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(published) != 1 || published[0].Type != events.ObligationsIncreased {
+		t.Fatalf("expected one obligations.increased event, got %+v", published)
+	}
+	a, ok := published[0].Data.(models.ObligationAlert)
+	if !ok || a.BaselineDate != "2026-01-02" || a.PercentChange != 19.29 || a.Message != "Your fixed costs rose 19% this quarter" {
+		t.Errorf("unexpected alert: %+v", published[0].Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestObligationRecord_AlreadyAlertedToday(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("FROM bills WHERE is_active = true").WillReturnRows(obligationBillRows())
+	mock.ExpectQuery("FROM obligation_snapshots").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"snapshot_date", "total", "bill_count", "alerted"}).
+			AddRow(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), 1400.0, 2, false))
+	mock.ExpectExec("INSERT INTO obligation_snapshots").
+		WithArgs(pgxmock.AnyArg(), 1670.0, 3).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	// An earlier run today sent it
+	mock.ExpectExec("UPDATE obligation_snapshots SET alerted = true").
+		WithArgs(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(e events.Event) { published = append(published, e) })
+
+	h := NewObligationHandler(mock, 10).WithEvents(bus)
+	if err := h.Record(context.Background(), time.Date(2026, 4, 1, 10, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 0 {
+		t.Errorf("expected no second alert, got %+v", published)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestObligationReport(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	snapshotRows := func() *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"snapshot_date", "total", "bill_count", "alerted"}).
+			AddRow(today.AddDate(0, 0, -30), 1600.0, 3, false)
+	}
+	mock.ExpectQuery("FROM bills WHERE is_active = true").WillReturnRows(obligationBillRows())
+	mock.ExpectQuery("FROM obligation_snapshots").
+		WithArgs(today.AddDate(0, 0, -90), today.AddDate(0, 0, -1)).
+		WillReturnRows(snapshotRows())
+	mock.ExpectQuery("FROM obligation_snapshots").
+		WithArgs(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), today).
+		WillReturnRows(snapshotRows())
+
+	h := NewObligationHandler(mock, 10)
+	rr := httptest.NewRecorder()
+	h.Report(rr, httptest.NewRequest(http.MethodGet, "/api/v1/reports/obligations?from=2026-01-01", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.ObligationReport `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	rep := resp.Data
+	if rep.Total != 1670 || rep.BillCount != 3 || rep.Baseline == nil || rep.Baseline.Total != 1600 {
+		t.Errorf("unexpected report: %+v", rep)
+	}
+	// 4.38% is under the 10% threshold
+	if rep.PercentChange != 4.38 || rep.Alert || rep.Message != "" || len(rep.History) != 1 {
+		t.Errorf("expected no alert for a 4.38%% rise, got %+v", rep)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestObligationReport_InvalidDate(t *testing.T) {
+	h := NewObligationHandler(nil, 10)
+	rr := httptest.NewRecorder()
+	h.Report(rr, httptest.NewRequest(http.MethodGet, "/api/v1/reports/obligations?from=January", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Exports
+// ---------------------------------------------------------------------------
+
+func TestExportQIF(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	rows := pgxmock.NewRows([]string{"id", "paid_on", "payee", "category", "amount"}).
+		AddRow(1, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), "Rent", "housing", 1200.0).
+		AddRow(2, time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), "Coffee\nbeans", "", 12.5)
+	mock.ExpectQuery("SELECT (.+) FROM bill_assignments ba").
+		WithArgs(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	h := NewExportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export/qif?from=2026-03-01", nil)
+	rr := httptest.NewRecorder()
+	h.QIF(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	expected := "!Type:Bank\n" +
+		"D03/02/2026\nT-1200.00\nPRent\nLhousing\n^\n" +
+		"D03/14/2026\nT-12.50\nPCoffee beans\n^\n"
+	if rr.Body.String() != expected {
+		t.Errorf("unexpected qif:\n%s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExportPDF_SheetPerPeriod(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	jan2 := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	jan16 := time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	cols := []string{"id", "pay_date", "source", "income", "assignment_id", "bill_id", "name", "due_date", "amount", "status", "notes"}
+	mock.ExpectQuery("FROM pay_periods pp").
+		WithArgs(jan2, time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow(10, jan2, "Acme", 2000.0, intPtr(1), intPtr(1), stringPtr("Rent"), &due, float64Ptr(1200.0), stringPtr("paid"), stringPtr("")).
+			AddRow(10, jan2, "Acme", 2000.0, intPtr(2), intPtr(2), stringPtr("Café"), (*time.Time)(nil), float64Ptr(50.0), stringPtr("skipped"), stringPtr("")).
+			AddRow(10, jan2, "Acme", 2000.0, intPtr(3), intPtr(3), stringPtr("Power"), (*time.Time)(nil), float64Ptr(150.5), stringPtr("pending"), stringPtr("")).
+			AddRow(11, jan16, "Acme", 2000.0, (*int)(nil), (*int)(nil), (*string)(nil), (*time.Time)(nil), (*float64)(nil), (*string)(nil), (*string)(nil)))
+
+	h := NewExportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export/pdf?from=2026-01-02&to=2026-01-31", nil)
+	rr := httptest.NewRecorder()
+	h.PDF(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("expected application/pdf, got %q", ct)
+	}
+	if !bytes.HasPrefix(rr.Body.Bytes(), []byte("%PDF-")) {
+		t.Errorf("expected a PDF, got %q", rr.Body.Bytes()[:min(20, rr.Body.Len())])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPeriodSheets_TotalsLeftover(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	jan2 := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	jan16 := time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)
+	cols := []string{"id", "pay_date", "source", "income", "assignment_id", "bill_id", "name", "due_date", "amount", "status", "notes"}
+	mock.ExpectQuery("FROM pay_periods pp").WithArgs(jan2, jan16).
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow(10, jan2, "Acme", 2000.0, intPtr(1), intPtr(1), stringPtr("Rent"), (*time.Time)(nil), float64Ptr(1200.0), stringPtr("paid"), stringPtr("")).
+			AddRow(10, jan2, "Acme", 2000.0, intPtr(2), intPtr(2), stringPtr("Gym"), (*time.Time)(nil), float64Ptr(50.0), stringPtr("deferred"), stringPtr("")).
+			AddRow(11, jan16, "", 0.0, (*int)(nil), (*int)(nil), (*string)(nil), (*time.Time)(nil), (*float64)(nil), (*string)(nil), (*string)(nil)))
+
+	h := NewExportHandler(mock)
+	sheets, err := h.periodSheets(context.Background(), jan2, jan16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheets) != 2 {
+		t.Fatalf("expected 2 sheets, got %+v", sheets)
+	}
+	if s := sheets[0]; len(s.Lines) != 2 || s.Spent != 1200 || s.Leftover != 800 {
+		t.Errorf("expected the deferred line listed but not spent, got %+v", s)
+	}
+	if s := sheets[1]; len(s.Lines) != 0 || s.Leftover != 0 {
+		t.Errorf("expected an empty period, got %+v", s)
+	}
+}
+
+func TestExportXLSX_BillsAndPeriods(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	jan2 := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	jan31 := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	cols := []string{"id", "pay_date", "source", "income", "assignment_id", "bill_id", "name", "due_date", "amount", "status", "notes"}
+	mock.ExpectQuery("FROM pay_periods pp").WithArgs(jan2, jan31).
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow(10, jan2, "Acme", 2000.0, intPtr(1), intPtr(1), stringPtr("Rent"), (*time.Time)(nil), float64Ptr(1200.0), stringPtr("paid"), stringPtr("")).
+			AddRow(10, jan2, "Acme", 2000.0, intPtr(2), intPtr(2), stringPtr("Chase"), (*time.Time)(nil), float64Ptr(80.0), stringPtr("deferred"), stringPtr("")))
+	mock.ExpectQuery("SELECT b.id, b.name, b.due_day").WithArgs(jan2, jan31).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "due_day", "is_autopay", "default_amount", "recurrence", "card_label", "statement_day"}).
+			AddRow(1, "Rent", intPtr(1), false, float64Ptr(1200.0), "monthly", (*string)(nil), (*int)(nil)).
+			AddRow(2, "Chase", intPtr(17), false, (*float64)(nil), "monthly", stringPtr(""), intPtr(20)))
+
+	h := NewExportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export/xlsx?from=2026-01-02&to=2026-01-31", nil)
+	rr := httptest.NewRecorder()
+	h.XLSX(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	preview, err := services.NewXLSXImporter().ParseReader(bytes.NewReader(rr.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Bills) != 2 || preview.Bills[1].CreditCard == nil || preview.PeriodCount != 1 {
+		t.Errorf("expected the export to import back, got %+v", preview)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExportXLSX_RejectsLongRange(t *testing.T) {
+	h := NewExportHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export/xlsx?from=2026-01-01&to=2027-06-01", nil)
+	rr := httptest.NewRecorder()
+	h.XLSX(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestExportGnuCash_DoubleEntry(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM bill_assignments ba").
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "paid_on", "payee", "category", "amount"}).
+			AddRow(7, time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC), "Rent", "housing", 1200.0).
+			AddRow(8, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), "Gift", "", 25.0))
+	mock.ExpectQuery("SELECT (.+) FROM pay_periods pp").
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "name", "amount"}).
+			AddRow(3, time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC), "Acme: Payroll", 2000.0))
+
+	h := NewExportHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export/gnucash?from=2026-03-01&to=2026-03-31", nil)
+	rr := httptest.NewRecorder()
+	h.GnuCash(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	expected := "Date,Transaction ID,Description,Account,Amount\n" +
+		"2026-03-06,pp-3,Acme: Payroll paycheck,Assets:Checking,2000.00\n" +
+		"2026-03-06,pp-3,Acme: Payroll paycheck,Income:Acme- Payroll,-2000.00\n" +
+		"2026-03-06,ba-7,Rent,Expenses:housing,1200.00\n" +
+		"2026-03-06,ba-7,Rent,Assets:Checking,-1200.00\n" +
+		"2026-03-09,ba-8,Gift,Expenses:Uncategorized,25.00\n" +
+		"2026-03-09,ba-8,Gift,Assets:Checking,-25.00\n"
+	if rr.Body.String() != expected {
+		t.Errorf("unexpected csv:\n%s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExportQIF_InvalidDate(t *testing.T) {
+	h := NewExportHandler(nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/export/qif?to=03/31/2026", nil)
+	rr := httptest.NewRecorder()
+	h.QIF(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Calendar feed
+// ---------------------------------------------------------------------------
+
+func TestCalendarFeed_RequiresFeedToken(t *testing.T) {
+	cfg := &config.Config{AuthUsername: "me", AuthPasswordHash: "hash", JWTSecret: "secret"}
+	h := NewCalendarHandler(nil, cfg)
+
+	session, _, err := auth.CreateToken(cfg.JWTSecret, auth.Session{Username: "me", Role: auth.RoleAdmin}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{"", "garbage", session} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/calendar.ics?token="+token, nil)
+		rr := httptest.NewRecorder()
+		h.Feed(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: expected 401, got %d", token, rr.Code)
+		}
+	}
+
+	// A feed token must not pass as a session
+	feed, err := auth.CreateFeedToken(cfg.JWTSecret, "me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.ValidateToken(cfg.JWTSecret, feed); err == nil {
+		t.Error("expected a feed token to be rejected as a session token")
+	}
+}
+
+func TestCalendarFeed_PaydaysAndDueDates(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	payDate := time.Now().AddDate(0, 0, 3)
+	dueDate := time.Now().AddDate(0, 0, 5)
+	mock.ExpectQuery("FROM pay_periods pp").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "name", "amount"}).
+			AddRow(10, payDate, "Acme", float64Ptr(2100.0)))
+	mock.ExpectQuery("FROM bill_assignments ba").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "due_date", "name", "amount", "status", "is_autopay"}).
+			AddRow(7, dueDate, "Water, Sewer", float64Ptr(64.5), "pending", true))
+
+	cfg := &config.Config{AuthUsername: "me", AuthPasswordHash: "hash", JWTSecret: "secret"}
+	token, err := auth.CreateFeedToken(cfg.JWTSecret, "me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewCalendarHandler(mock, cfg)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/calendar.ics?token="+token, nil)
+	rr := httptest.NewRecorder()
+	h.Feed(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:payday-10@budget-mgmt\r\n",
+		"DTSTART;VALUE=DATE:" + payDate.Format("20060102") + "\r\n",
+		"SUMMARY:Payday: Acme\r\n",
+		"DESCRIPTION:Expected $2100.00\r\n",
+		"SUMMARY:Due: Water\\, Sewer\r\n",
+		"DESCRIPTION:Amount $64.50\\nStatus: pending\\nAutopay\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("feed missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestICSFold(t *testing.T) {
+	long := "DESCRIPTION:" + strings.Repeat("x", 150)
+	folded := icsFold(long)
+	for _, line := range strings.Split(folded, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != long {
+		t.Error("unfolding did not restore the original line")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// API keys
+// ---------------------------------------------------------------------------
+
+func TestAPIKeyCreate_StoresOnlyTheHash(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	var hash string
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs("Home Assistant", capturedArg{&hash}, []string{"read:bills", "run:autoassign"}, "sam").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "scopes", "created_by", "created_at", "revoked_at"}).
+			AddRow(4, "Home Assistant", []string{"read:bills", "run:autoassign"}, "sam", now, (*time.Time)(nil)))
+
+	h := NewAPIKeyHandler(mock, &config.Config{})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys",
+		strings.NewReader(`{"name": " Home Assistant ", "scopes": ["read:bills", "run:autoassign"]}`))
+	req = req.WithContext(auth.WithSession(req.Context(), auth.Session{Username: "sam", Role: auth.RoleAdmin}))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.CreatedAPIKey `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if !strings.HasPrefix(resp.Data.Key, "bmk_") || resp.Data.ID != 4 {
+		t.Errorf("unexpected key: %+v", resp.Data)
+	}
+	if hash != auth.HashAPIKey(resp.Data.Key) {
+		t.Errorf("stored %q, want the hash of the issued key", hash)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// capturedArg matches any argument and keeps it, for values made inside the
+// handler such as a generated key's hash.
+type capturedArg struct{ value *string }
+
+func (c capturedArg) Match(v interface{}) bool {
+	*c.value, _ = v.(string)
+	return true
+}
+
+func TestAPIKeyCreate_Validation(t *testing.T) {
+	for _, body := range []string{
+		`{"name": "", "scopes": ["read:bills"]}`,
+		`{"name": "script", "scopes": []}`,
+		`{"name": "script", "scopes": ["delete:everything"]}`,
+	} {
+		h := NewAPIKeyHandler(nil, &config.Config{})
+		rr := httptest.NewRecorder()
+		h.Create(rr, httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	}
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("UPDATE api_keys SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND revoked_at IS NULL").
+		WithArgs(4).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE api_keys").WithArgs(4).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	h := NewAPIKeyHandler(mock, &config.Config{})
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/api-keys/4", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "4")
+		req = req.WithContext(withChiContext(req.Context(), rctx))
+		rr := httptest.NewRecorder()
+		h.Revoke(rr, req)
+
+		if rr.Code != want {
+			t.Errorf("expected %d, got %d", want, rr.Code)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAPIKeyLookup_SessionFromScopes(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("FROM api_keys").WithArgs(auth.HashAPIKey("bmk_read")).
+		WillReturnRows(apiKeyRows().AddRow(1, "mirror", []string{"read:bills", "retired:scope"}))
+	mock.ExpectQuery("FROM api_keys").WithArgs(auth.HashAPIKey("bmk_write")).
+		WillReturnRows(apiKeyRows().AddRow(2, "script", []string{"read:bills", "write:assignments"}))
+	mock.ExpectQuery("FROM api_keys").WithArgs(auth.HashAPIKey("bmk_gone")).WillReturnRows(apiKeyRows())
+
+	h := NewAPIKeyHandler(mock, &config.Config{})
+	ctx := context.Background()
+	session, ok, err := h.Lookup(ctx, "bmk_read")
+	if err != nil || !ok || session.Role != auth.RoleViewer || session.KeyID != 1 || len(session.Scopes) != 1 ||
+		session.Username != "api-key:mirror" {
+		t.Errorf("read key: got %+v, %v, %v", session, ok, err)
+	}
+	session, ok, err = h.Lookup(ctx, "bmk_write")
+	if err != nil || !ok || session.Role != auth.RoleEditor || !session.HasScope(auth.ScopeWriteAssignments) ||
+		session.HasScope(auth.ScopeRunAutoAssign) {
+		t.Errorf("write key: got %+v, %v, %v", session, ok, err)
+	}
+	if _, ok, err := h.Lookup(ctx, "bmk_gone"); ok || err != nil {
+		t.Errorf("revoked key: got %v, %v", ok, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Dashboard widgets
+// ---------------------------------------------------------------------------
+
+func apiKeyRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "name", "scopes"})
+}
+
+func TestWidgets_RequireReadBillsKey(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	cfg := &config.Config{AuthUsername: "me", AuthPasswordHash: "hash", JWTSecret: "secret"}
+	h := NewWidgetHandler(mock, mock, cfg)
+
+	// Unknown and revoked keys aren't found; a key without read:bills is
+	mock.ExpectQuery("FROM api_keys WHERE key_hash = \\$1 AND revoked_at IS NULL").
+		WithArgs(auth.HashAPIKey("garbage")).WillReturnRows(apiKeyRows())
+	mock.ExpectQuery("FROM api_keys").
+		WithArgs(auth.HashAPIKey("bmk_writer")).WillReturnRows(apiKeyRows().AddRow(3, "script", []string{"write:assignments"}))
+	for _, key := range []string{"garbage", "bmk_writer"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets/next-bills", nil)
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		h.NextBills(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("key %q: expected 401, got %d", key, rr.Code)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWidgets_KeyCheckedOnPrimaryOnly(t *testing.T) {
+	primary, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+	replica, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+
+	cfg := &config.Config{AuthUsername: "me", AuthPasswordHash: "hash", JWTSecret: "secret"}
+	h := NewWidgetHandler(primary, replica, cfg)
+
+	// A query-string key is ignored, without a lookup
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets/next-bills?api_key=bmk_dashboard", nil)
+	rr := httptest.NewRecorder()
+	h.NextBills(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("?api_key: expected 401, got %d", rr.Code)
+	}
+
+	// The key comes from the primary and the bills from the replica
+	primary.ExpectQuery("FROM api_keys").WithArgs(auth.HashAPIKey("bmk_dashboard")).
+		WillReturnRows(apiKeyRows().AddRow(1, "Dashboard widgets", []string{"read:bills"}))
+	replica.ExpectQuery("FROM bill_assignments ba").WithArgs(defaultWidgetBills).
+		WillReturnRows(pgxmock.NewRows([]string{"name", "due_date", "amount", "is_autopay"}))
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/widgets/next-bills", nil)
+	req.Header.Set("X-API-Key", "bmk_dashboard")
+	rr = httptest.NewRecorder()
+	h.NextBills(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if err := primary.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if err := replica.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWidgets_NextBillsLimitValidation(t *testing.T) {
+	h := NewWidgetHandler(nil, nil, &config.Config{})
+
+	for _, limit := range []string{"0", "30", "abc"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets/next-bills?limit="+limit, nil)
+		rr := httptest.NewRecorder()
+		h.NextBills(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("limit %q: expected 400, got %d", limit, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	}
+}
+
+func TestWidgets_NextBillsCachedWithETag(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	due := time.Now().AddDate(0, 0, 2)
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("FROM api_keys").WithArgs(auth.HashAPIKey("bmk_dashboard")).
+			WillReturnRows(apiKeyRows().AddRow(1, "Dashboard widgets", []string{"read:bills"}))
+		mock.ExpectQuery("FROM bill_assignments ba").WithArgs(5).
+			WillReturnRows(pgxmock.NewRows([]string{"name", "due_date", "amount", "is_autopay"}).
+				AddRow("Water", due, 64.5, true))
+	}
+
+	cfg := &config.Config{AuthUsername: "me", AuthPasswordHash: "hash", JWTSecret: "secret"}
+	key := "bmk_dashboard"
+	h := NewWidgetHandler(mock, mock, cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/widgets/next-bills", nil)
+	req.Header.Set("X-API-Key", key)
+	rr := httptest.NewRecorder()
+	h.NextBills(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if cc := rr.Header().Get("Cache-Control"); !strings.Contains(cc, "max-age=300") {
+		t.Errorf("expected a 5 minute Cache-Control, got %q", cc)
+	}
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	var resp struct {
+		Data []WidgetBill `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Name != "Water" || resp.Data[0].Due != due.Format("2006-01-02") || resp.Data[0].Overdue {
+		t.Errorf("unexpected bills: %+v", resp.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/widgets/next-bills", nil)
+	req.Header.Set("X-API-Key", key)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	h.NextBills(rr, req)
+
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rr.Code)
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("expected an empty 304 body, got %q", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Household documents
+// ---------------------------------------------------------------------------
+
+var documentTestCols = []string{"id", "title", "kind", "filename", "content_type", "size_bytes", "expires_on", "remind_days",
+	"notes", "bill_ids", "created_at", "updated_at"}
+
+func TestDocumentCreate_TagsBills(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	expires := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO documents").
+		WithArgs("Apartment lease", "lease", "lease-2026.pdf", "application/octet-stream", 8, []byte("%PDF-1.7"), &expires, 60, "").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("INSERT INTO document_bills").WithArgs(7, []int{2, 3}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mock.ExpectQuery("SELECT (.+) FROM documents d WHERE d.id").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows(documentTestCols).
+			AddRow(7, "Apartment lease", "lease", "lease-2026.pdf", "application/octet-stream", 8, &expires, 60, "", []int{2, 3}, now, now))
+	mock.ExpectCommit()
+
+	h := NewDocumentHandler(mock)
+	req := newMultipartRequest(t, "/api/v1/documents", "lease-2026.pdf", "%PDF-1.7", map[string]string{
+		"title":       "Apartment lease",
+		"kind":        "lease",
+		"expires_on":  "2027-01-31",
+		"remind_days": "60",
+		"bill_ids":    "3, 2,3",
+	})
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.Document `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if ids := resp.Data.BillIDs; len(ids) != 2 || ids[0] != 2 {
+		t.Errorf("bill_ids = %v, want [2 3]", ids)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDocumentCreate_UnknownBill(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO documents").
+		WithArgs("policy", "other", "policy.pdf", pgxmock.AnyArg(), 4, pgxmock.AnyArg(), (*time.Time)(nil), 30, "").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectExec("INSERT INTO document_bills").WithArgs(8, []int{4, 99}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectRollback()
+
+	h := NewDocumentHandler(mock)
+	req := newMultipartRequest(t, "/api/v1/documents", "policy.pdf", "%PDF", map[string]string{"bill_ids": "4,99"})
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDocumentCreate_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+	}{
+		{"unknown kind", map[string]string{"kind": "receipt"}},
+		{"bad expiry", map[string]string{"expires_on": "01/31/2027"}},
+		{"remind too far ahead", map[string]string{"remind_days": "400"}},
+		{"bad bill ids", map[string]string{"bill_ids": "rent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewDocumentHandler(nil)
+			req := newMultipartRequest(t, "/api/v1/documents", "policy.pdf", "%PDF", tt.fields)
+			rr := httptest.NewRecorder()
+			h.Create(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestDocumentUpdate_ClearsExpiryAndTags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE documents SET").
+		WithArgs(7, (*string)(nil), (*string)(nil), true, (*time.Time)(nil), (*int)(nil), (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM document_bills").WithArgs(7).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.ExpectQuery("SELECT (.+) FROM documents d WHERE d.id").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows(documentTestCols).
+			AddRow(7, "Apartment lease", "lease", "lease-2026.pdf", "application/pdf", 8, (*time.Time)(nil), 60, "", []int{}, now, now))
+	mock.ExpectCommit()
+
+	h := NewDocumentHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/documents/7", bytes.NewBufferString(`{"expires_on":"","bill_ids":[]}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "7")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDocumentFile_DownloadsAsAttachment(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT filename, content_type, content FROM documents").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"filename", "content_type", "content"}).
+			AddRow(`auto "policy".html`, "text/html", []byte("<script>")))
+
+	h := NewDocumentHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/documents/7/file", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "7")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.File(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Content-Disposition"); got != `attachment; filename="auto \"policy\".html"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("expected nosniff on a downloaded document")
+	}
+	if rr.Body.String() != "<script>" {
+		t.Errorf("body = %q", rr.Body.String())
+	}
+}
+
+func TestDocumentGet_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM documents d WHERE d.id").WithArgs(404).
+		WillReturnError(pgx.ErrNoRows)
+
+	h := NewDocumentHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/documents/404", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "404")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+	rr := httptest.NewRecorder()
+	h.Get(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+// ---------------------------------------------------------------------------
+// Email reminders
+// ---------------------------------------------------------------------------
+
+type fakeMailer struct {
+	sent []string // recipient|subject
+}
+
+func (m *fakeMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, to+"|"+subject)
+	return nil
+}
+
+func TestNotificationSendDigests_EmailsOncePerDay(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM notification_preferences").WithArgs(9, today).
+		WillReturnRows(pgxmock.NewRows([]string{"username", "email", "days_ahead", "include_overdue"}).
+			AddRow("me", "me@example.com", 3, true).
+			AddRow("quiet", "quiet@example.com", 3, false))
+	mock.ExpectQuery("FROM bill_assignments ba").WithArgs(today, today.AddDate(0, 0, 3)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "due_date", "pay_date", "amount"}).
+			AddRow(1, "Rent", (*time.Time)(nil), today.AddDate(0, 0, -5), 1200.0).
+			AddRow(2, "Phone", &today, today.AddDate(0, 0, -2), 45.5))
+	mock.ExpectQuery("FROM documents").WithArgs(today).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "title", "kind", "expires_on"}).
+			AddRow(4, "Renter's insurance", "insurance", today.AddDate(0, 0, 20)))
+	mock.ExpectExec("UPDATE notification_preferences SET last_sent_on").WithArgs("me", today).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("FROM bill_assignments ba").WithArgs(today, today.AddDate(0, 0, 3)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "due_date", "pay_date", "amount"}).
+			AddRow(1, "Rent", (*time.Time)(nil), today.AddDate(0, 0, -5), 1200.0))
+	mock.ExpectQuery("FROM documents").WithArgs(today).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "title", "kind", "expires_on"}))
+	mock.ExpectExec("UPDATE notification_preferences SET last_sent_on").WithArgs("quiet", today).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	mailer := &fakeMailer{}
+	h := NewNotificationHandler(mock, mailer)
+	if err := h.sendDigests(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+
+	// "quiet" skips overdue bills, so only Rent would be listed and nothing is sent
+	if len(mailer.sent) != 1 || mailer.sent[0] != "me@example.com|Bills: 1 due in the next 3 days, 1 overdue, 1 documents expiring" {
+		t.Errorf("sent = %v", mailer.sent)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestNotificationUpdatePreferences_EnableRequiresEmail(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("FROM notification_preferences").WithArgs("default").
+		WillReturnError(pgx.ErrNoRows)
+
+	h := NewNotificationHandler(mock, nil)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/notifications/preferences", bytes.NewBufferString(`{"enabled":true}`))
+	rr := httptest.NewRecorder()
+	h.UpdatePreferences(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestNotificationSendTest_WithoutSMTP(t *testing.T) {
+	h := NewNotificationHandler(nil, nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications/test", nil)
+	rr := httptest.NewRecorder()
+	h.SendTest(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_CONFIGURED")
+}
+
+// ---------------------------------------------------------------------------
+// Webhooks
+// ---------------------------------------------------------------------------
+
+func TestWebhookCreate_Validation(t *testing.T) {
+	h := NewWebhookHandler(nil)
+	for _, body := range []string{
+		`{"url":"ftp://example.com/hook"}`,
+		`{"url":"/relative"}`,
+		`{"url":"https://example.com/hook","events":["bill.created"]}`,
+		`{"url":"https://example.com/hook","secret":"short"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.Create(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	}
+}
+
+func TestWebhookCreate_GeneratesSecret(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO webhooks").
+		WithArgs("https://example.com/hook", pgxmock.AnyArg(), []string{events.AssignmentPaid}, "").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "url", "events", "description", "is_active", "created_at", "updated_at"}).
+			AddRow(1, "https://example.com/hook", []string{events.AssignmentPaid}, "", true, now, now))
+
+	h := NewWebhookHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks",
+		bytes.NewBufferString(`{"url":" https://example.com/hook ","events":["assignment.paid"]}`))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.Webhook `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Data.Secret) != 64 {
+		t.Errorf("expected a generated 64-character secret, got %q", resp.Data.Secret)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func webhookDueRows(id, attempts int, url string) *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "event_type", "payload", "attempts", "url", "secret", "is_active"}).
+		AddRow(id, events.AssignmentPaid, []byte(`{"type":"assignment.paid"}`), attempts, url, "0123456789abcdef", true)
+}
+
+func TestWebhookDeliverDue_SignsAndMarksDelivered(t *testing.T) {
+	var gotSig, gotTS, gotEvent string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get("X-Budget-Signature")
+		gotTS = r.Header.Get("X-Budget-Timestamp")
+		gotEvent = r.Header.Get("X-Budget-Event")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET next_attempt_at").
+		WithArgs(now, now.Add(webhookLease), webhookBatchSize).
+		WillReturnRows(webhookDueRows(7, 0, srv.URL))
+	mock.ExpectExec("status = 'delivered'").
+		WithArgs(7, 1, intPtr(http.StatusNoContent), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	h := NewWebhookHandler(mock)
+	h.client = srv.Client()
+	if err := h.deliverDue(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+
+	ts, _ := strconv.ParseInt(gotTS, 10, 64)
+	if want := services.SignWebhook("0123456789abcdef", time.Unix(ts, 0), gotBody); gotSig != want {
+		t.Errorf("signature = %q, want %q", gotSig, want)
+	}
+	if gotEvent != events.AssignmentPaid || string(gotBody) != `{"type":"assignment.paid"}` {
+		t.Errorf("unexpected request: event %q body %s", gotEvent, gotBody)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestWebhookDeliverDue_RetriesThenFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET next_attempt_at").
+		WithArgs(now, now.Add(webhookLease), webhookBatchSize).
+		WillReturnRows(webhookDueRows(7, 0, srv.URL))
+	mock.ExpectExec("UPDATE webhook_deliveries SET").
+		WithArgs(7, "pending", 1, intPtr(http.StatusServiceUnavailable), "HTTP 503", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET next_attempt_at").
+		WithArgs(now, now.Add(webhookLease), webhookBatchSize).
+		WillReturnRows(webhookDueRows(8, services.WebhookMaxAttempts-1, srv.URL))
+	mock.ExpectExec("UPDATE webhook_deliveries SET").
+		WithArgs(8, "failed", services.WebhookMaxAttempts, intPtr(http.StatusServiceUnavailable), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	h := NewWebhookHandler(mock)
+	h.client = srv.Client()
+	for i := 0; i < 2; i++ {
+		if err := h.deliverDue(context.Background(), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestWebhookDeliverDue_RefusesPrivateDestinations(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE webhook_deliveries d SET next_attempt_at").
+		WithArgs(now, now.Add(webhookLease), webhookBatchSize).
+		WillReturnRows(webhookDueRows(7, 0, srv.URL))
+	mock.ExpectExec("UPDATE webhook_deliveries SET").
+		WithArgs(7, "pending", 1, (*int)(nil), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	h := NewWebhookHandler(mock)
+	if err := h.deliverDue(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	if hit {
+		t.Error("webhook was delivered to a loopback address")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	for _, addr := range []string{"127.0.0.1:80", "10.0.0.5:443", "192.168.1.1:80", "169.254.169.254:80", "[::1]:80", "[fe80::1]:80", "0.0.0.0:80"} {
+		if refusePrivateAddr("tcp", addr, nil) == nil {
+			t.Errorf("%s was allowed", addr)
+		}
+	}
+	if err := refusePrivateAddr("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("public address refused: %v", err)
+	}
+}
+
+func TestWebhookSend_DoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	h := NewWebhookHandler(nil)
+	client := srv.Client()
+	client.CheckRedirect = h.client.CheckRedirect
+	h.client = client
+
+	status, err := h.send(context.Background(), pendingDelivery{id: 1, url: srv.URL, payload: []byte(`{}`), secret: "0123456789abcdef"}, time.Now())
+	if followed {
+		t.Error("redirect was followed")
+	}
+	if status == nil || *status != http.StatusTemporaryRedirect || err == nil || err.Error() != "HTTP 307" {
+		t.Errorf("status = %v, err = %v", status, err)
+	}
+}
+
+func TestForecastPublishNegative_OncePerFirstNegativeDate(t *testing.T) {
+	bus := events.NewBus()
+	var got []ForecastAlert
+	bus.Subscribe(func(e events.Event) {
+		if e.Type == events.ForecastNegative {
+			got = append(got, e.Data.(ForecastAlert))
+		}
+	})
+	h := NewForecastHandler(nil).WithEvents(bus)
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 30)
+	mar5, mar9 := "2026-03-05", "2026-03-09"
+	for _, first := range []*string{&mar5, &mar5, nil, &mar5, &mar9} {
+		h.publishNegative(services.ForecastResult{FirstNegativeDate: first, LowestBalance: -50}, from, to)
+	}
+
+	if len(got) != 3 || got[0].FirstNegativeDate != mar5 || got[1].FirstNegativeDate != mar5 || got[2].FirstNegativeDate != mar9 {
+		t.Errorf("alerts = %+v", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Admin stats
+// ---------------------------------------------------------------------------
+
+func TestAdminStats_ReportsLockHolders(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	runner := jobs.NewRunner()
+	runner.Schedule("reminder-digests", time.Hour, func(ctx context.Context, now time.Time) {})
+	runner.Schedule("webhook-deliveries", time.Hour, func(ctx context.Context, now time.Time) {})
+	defer runner.Shutdown(context.Background())
+
+	started := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM pg_locks").
+		WithArgs([]int64{jobs.LockKey("reminder-digests"), jobs.LockKey("webhook-deliveries")}).
+		WillReturnRows(pgxmock.NewRows([]string{"key", "pid", "application_name", "client_addr", "backend_start"}).
+			AddRow(jobs.LockKey("reminder-digests"), 4242, "budget-mgmt:replica-b", "10.0.0.7", started))
+	mock.ExpectQuery("SELECT COUNT").
+		WillReturnRows(pgxmock.NewRows([]string{"bills", "sources", "periods", "assignments"}).AddRow(12, 2, 26, 300))
+
+	h := NewAdminHandler(mock, runner, "replica-a")
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil)
+	rr := httptest.NewRecorder()
+	h.Stats(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data AdminStats `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Data.Jobs) != 2 || resp.Data.Jobs[0].IntervalSeconds != 3600 {
+		t.Fatalf("unexpected jobs: %+v", resp.Data.Jobs)
+	}
+	holder := resp.Data.Jobs[0].LockHolder
+	if holder == nil || holder.PID != 4242 || holder.ThisInstance {
+		t.Errorf("digest lock holder = %+v, want pid 4242 on another instance", holder)
+	}
+	if resp.Data.Jobs[1].LockHolder != nil {
+		t.Errorf("webhook job should have no holder, got %+v", resp.Data.Jobs[1].LockHolder)
+	}
+	if resp.Data.Counts["bill_assignments"] != 300 {
+		t.Errorf("counts = %v", resp.Data.Counts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAdminDuplicates_GroupsAndProposesKeeper(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	payDate := time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	cols := []string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+		"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		"name", "pay_date",
+	}
+	row := func(id, billID int, name string, dueDate *time.Time) []interface{} {
+		return []interface{}{id, billID, 10, float64Ptr(100.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			false, "", "", false, false, (*int)(nil), false, (*time.Time)(nil), dueDate, (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now,
+			name, payDate}
+	}
+	// The query orders each group best-first: rows with a due date ahead of those without
+	mock.ExpectQuery("FROM bill_assignments ba").
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow(row(21, 1, "Rent", &due)...).
+			AddRow(row(7, 1, "Rent", nil)...).
+			AddRow(row(30, 2, "Internet", nil)...).
+			AddRow(row(31, 2, "Internet", nil)...).
+			AddRow(row(32, 2, "Internet", nil)...))
+
+	h := NewAdminHandler(mock, jobs.NewRunner(), "test")
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/duplicates", nil)
+	rr := httptest.NewRecorder()
+	h.Duplicates(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.DuplicateAssignmentGroup `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", resp.Data)
+	}
+	rent, internet := resp.Data[0], resp.Data[1]
+	if rent.KeepID != 21 || len(rent.MergeIDs) != 1 || rent.MergeIDs[0] != 7 || rent.PayDate != "2026-03-06" {
+		t.Errorf("unexpected rent group: %+v", rent)
+	}
+	if internet.KeepID != 30 || len(internet.MergeIDs) != 2 || len(internet.Assignments) != 3 {
+		t.Errorf("unexpected internet group: %+v", internet)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAdminDuplicates_SeparateOccurrencesInOnePeriod(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	// A custom six-week period holds two monthly occurrences of each bill
+	payDate := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mar5 := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	apr5 := time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)
+	cols := []string{
+		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+		"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+		"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		"name", "pay_date",
+	}
+	row := func(id, billID int, name string, moved bool, dueDate *time.Time) []interface{} {
+		return []interface{}{id, billID, 10, float64Ptr(60.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			false, "", "", moved, false, (*int)(nil), false, (*time.Time)(nil), dueDate, (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now,
+			name, payDate}
+	}
+	// Only rows sharing a due date, or lacking one, count as the same occurrence
+	mock.ExpectQuery(`o\.due_date IS NULL OR ba\.due_date IS NULL OR o\.due_date = ba\.due_date`).
+		WillReturnRows(pgxmock.NewRows(cols).
+			AddRow(row(40, 3, "Phone", false, &mar5)...).
+			AddRow(row(41, 3, "Phone", false, &apr5)...).
+			AddRow(row(52, 4, "Gym", true, nil)...).
+			AddRow(row(50, 4, "Gym", false, &mar5)...).
+			AddRow(row(51, 4, "Gym", false, &apr5)...))
+
+	h := NewAdminHandler(mock, jobs.NewRunner(), "test")
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/duplicates", nil)
+	rr := httptest.NewRecorder()
+	h.Duplicates(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.DuplicateAssignmentGroup `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	// Phone's two occurrences are not duplicates; Gym's undated row is one of
+	// the March occurrence, and stays the keeper for being moved by hand
+	if len(resp.Data) != 1 {
+		t.Fatalf("expected 1 group, got %+v", resp.Data)
+	}
+	gym := resp.Data[0]
+	if gym.BillID != 4 || gym.KeepID != 52 || len(gym.MergeIDs) != 1 || gym.MergeIDs[0] != 50 {
+		t.Errorf("unexpected gym group: %+v", gym)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAdminMergeDuplicates_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	due := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM bill_assignments WHERE id = ANY").WithArgs([]int{7, 21}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "bill_id", "pay_period_id", "status", "planned_amount", "notes", "due_date"}).
+			AddRow(7, 1, 10, "pending", (*float64)(nil), "from import", (*time.Time)(nil)).
+			AddRow(21, 1, 10, "pending", float64Ptr(1500.0), "autopay", &due))
+	mock.ExpectExec("UPDATE transactions SET assignment_id").WithArgs(7, []int{21}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM bill_assignments").WithArgs([]int{21}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	// The kept row takes the planned amount and due date it lacked, and both notes
+	mock.ExpectExec("UPDATE bill_assignments SET planned_amount").
+		WithArgs(7, float64Ptr(1500.0), "from import; autopay", &due).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewAdminHandler(mock, jobs.NewRunner(), "test")
+	body := bytes.NewBufferString(`{"merges":[{"keep_id":7,"merge_ids":[21]}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/duplicates/merge", body)
+	rr := httptest.NewRecorder()
+	h.MergeDuplicates(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.MergeDuplicatesResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Merged != 1 || resp.Data.Relinked != 1 {
+		t.Errorf("unexpected result: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAdminMergeDuplicates_RejectsNoLongerPending(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM bill_assignments WHERE id = ANY").WithArgs([]int{7, 21}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "bill_id", "pay_period_id", "status", "planned_amount", "notes", "due_date"}).
+			AddRow(7, 1, 10, "pending", (*float64)(nil), "", (*time.Time)(nil)).
+			AddRow(21, 1, 10, "paid", float64Ptr(1500.0), "", (*time.Time)(nil)))
+	mock.ExpectRollback()
+
+	h := NewAdminHandler(mock, jobs.NewRunner(), "test")
+	body := bytes.NewBufferString(`{"merges":[{"keep_id":7,"merge_ids":[21]}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/duplicates/merge", body)
+	rr := httptest.NewRecorder()
+	h.MergeDuplicates(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAdminMergeDuplicates_RejectsDifferentDueDates(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mar5 := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	apr5 := time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM bill_assignments WHERE id = ANY").WithArgs([]int{40, 41}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "bill_id", "pay_period_id", "status", "planned_amount", "notes", "due_date"}).
+			AddRow(40, 3, 10, "pending", float64Ptr(60.0), "", &mar5).
+			AddRow(41, 3, 10, "pending", float64Ptr(60.0), "", &apr5))
+	mock.ExpectRollback()
+
+	h := NewAdminHandler(mock, jobs.NewRunner(), "test")
+	body := bytes.NewBufferString(`{"merges":[{"keep_id":40,"merge_ids":[41]}]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/duplicates/merge", body)
+	rr := httptest.NewRecorder()
+	h.MergeDuplicates(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAdminMergeDuplicates_Validation(t *testing.T) {
+	for _, body := range []string{
+		`{"merges":[]}`,
+		`{"merges":[{"keep_id":7,"merge_ids":[]}]}`,
+		`{"merges":[{"keep_id":7,"merge_ids":[7]}]}`,
+		`{"merges":[{"keep_id":7,"merge_ids":[8]},{"keep_id":9,"merge_ids":[8]}]}`,
+	} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := NewAdminHandler(mock, jobs.NewRunner(), "test")
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/duplicates/merge", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.MergeDuplicates(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		mock.Close()
+	}
+}
+
+func TestAdminIntegrity_ReportsIssues(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	cols := []string{"id", "detail"}
+	mock.ExpectQuery("WHERE b.id IS NULL OR pp.id IS NULL").
+		WillReturnRows(pgxmock.NewRows(cols).AddRow(12, "pay period 40 no longer exists"))
+	mock.ExpectQuery("WHERE NOT inc.is_active").WillReturnRows(pgxmock.NewRows(cols))
+	mock.ExpectQuery("WHERE ba.status = 'deferred'").
+		WillReturnRows(pgxmock.NewRows(cols).AddRow(15, "deferred without a target period"))
+	mock.ExpectQuery("WHERE ba.is_sinking_fund").WillReturnRows(pgxmock.NewRows(cols))
+	mock.ExpectQuery("FROM bills WHERE default_amount < 0").WillReturnRows(pgxmock.NewRows(cols))
+	mock.ExpectQuery("FROM bill_assignments\\s+WHERE planned_amount < 0").
+		WillReturnRows(pgxmock.NewRows(cols).AddRow(18, "planned -50.00"))
+	mock.ExpectQuery("FROM pay_periods\\s+WHERE expected_amount < 0").WillReturnRows(pgxmock.NewRows(cols))
+
+	h := NewAdminHandler(mock, jobs.NewRunner(), "test")
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/integrity", nil)
+	rr := httptest.NewRecorder()
+	h.Integrity(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data IntegrityReport `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	want := map[string]int{
+		"orphaned_assignment": 1, "inactive_source_period": 0, "broken_deferral": 1,
+		"broken_sinking_fund": 0, "negative_amount": 1,
+	}
+	if len(resp.Data.Counts) != len(want) {
+		t.Errorf("expected counts %v, got %v", want, resp.Data.Counts)
+	}
+	for check, n := range want {
+		if resp.Data.Counts[check] != n {
+			t.Errorf("expected %d %s issues, got %d", n, check, resp.Data.Counts[check])
+		}
+	}
+	if len(resp.Data.Issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", resp.Data.Issues)
+	}
+	last := resp.Data.Issues[2]
+	if last.Check != "negative_amount" || last.Table != "bill_assignments" || last.ID != 18 || last.Fix == "" {
+		t.Errorf("unexpected issue: %+v", last)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAdminMigrations_ReportsPendingAndDrift(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").
+		WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	mock.ExpectQuery("SELECT filename, applied_at, checksum FROM schema_migrations").
+		WillReturnRows(pgxmock.NewRows([]string{"filename", "applied_at", "checksum"}).
+			AddRow("001_initial_schema.sql", at, "").
+			AddRow("002_add_manually_moved.sql", at, "edited"))
+
+	h := NewAdminHandler(mock, jobs.NewRunner(), "api-1")
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/migrations", nil)
+	rr := httptest.NewRecorder()
+	h.Migrations(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data MigrationsReport `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Version != "002_add_manually_moved.sql" || resp.Data.Drifted != 1 {
+		t.Errorf("expected version 002 with one drifted migration, got %+v", resp.Data)
+	}
+	if resp.Data.Pending != len(resp.Data.Migrations)-2 {
+		t.Errorf("expected every other migration pending, got %d of %d", resp.Data.Pending, len(resp.Data.Migrations))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Credit cards
+// ---------------------------------------------------------------------------
+
+var creditCardCols = []string{"id", "bill_id", "card_label", "statement_day", "due_day", "issuer", "apr", "credit_limit", "created_at"}
+
+func cardRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	return req.WithContext(withChiContext(req.Context(), rctx))
+}
+
+func TestCreditCardCreate_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing statement day", `{"due_day": 20}`},
+		{"due day out of range", `{"statement_day": 25, "due_day": 32}`},
+		{"negative apr", `{"statement_day": 25, "due_day": 20, "apr": -1}`},
+		{"negative credit limit", `{"statement_day": 25, "due_day": 20, "credit_limit": -500}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewCreditCardHandler(nil)
+			rr := httptest.NewRecorder()
+			h.Create(rr, httptest.NewRequest(http.MethodPost, "/api/v1/credit-cards", strings.NewReader(tt.body)))
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rr.Code)
+			}
+			assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestCreditCardCreate_Unlinked(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("INSERT INTO credit_cards").
+		WithArgs((*int)(nil), "Travel", 25, 20, "Chase", float64Ptr(21.99), float64Ptr(5000)).
+		WillReturnRows(pgxmock.NewRows(creditCardCols).
+			AddRow(3, (*int)(nil), "Travel", 25, 20, "Chase", float64Ptr(21.99), float64Ptr(5000), time.Now()))
+
+	h := NewCreditCardHandler(mock)
+	body := `{"card_label": "Travel", "statement_day": 25, "due_day": 20, "issuer": "Chase", "apr": 21.99, "credit_limit": 5000}`
+	rr := httptest.NewRecorder()
+	h.Create(rr, httptest.NewRequest(http.MethodPost, "/api/v1/credit-cards", strings.NewReader(body)))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.CreditCard `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.BillID != nil || resp.Data.CreditLimit == nil || *resp.Data.CreditLimit != 5000 {
+		t.Errorf("expected an unlinked card with a 5000 limit, got %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreditCardLink_BillAlreadyHasCard(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(9, 3).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+	h := NewCreditCardHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Link(rr, cardRequest(http.MethodPut, "/api/v1/credit-cards/3/bill", `{"bill_id": 9}`))
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreditCardLink_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(9, 3).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("UPDATE credit_cards SET bill_id = \\$2").WithArgs(3, 9).
+		WillReturnRows(pgxmock.NewRows(creditCardCols).
+			AddRow(3, intPtr(9), "Travel", 25, 20, "Chase", (*float64)(nil), (*float64)(nil), time.Now()))
+
+	h := NewCreditCardHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Link(rr, cardRequest(http.MethodPut, "/api/v1/credit-cards/3/bill", `{"bill_id": 9}`))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreditCardUnlink_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("UPDATE credit_cards SET bill_id = NULL").WithArgs(3).WillReturnError(pgx.ErrNoRows)
+
+	h := NewCreditCardHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Unlink(rr, cardRequest(http.MethodDelete, "/api/v1/credit-cards/3/bill", ""))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+// ---------------------------------------------------------------------------
+// Credit card statement cycles
+// ---------------------------------------------------------------------------
+
+func cardForecastRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "3")
+	return req.WithContext(withChiContext(req.Context(), rctx))
+}
+
+// expectCardForecast mocks the lookups for card 3 (bill 9, closing on the
+// 25th and due on the 20th) from 2026-01-26 over two cycles. Paychecks fall on
+// March 6 and April 17, and the March 20 payment already has assignment 50.
+func expectCardForecast(mock pgxmock.PgxPoolIface) {
+	mock.ExpectQuery("FROM credit_cards cc LEFT JOIN bills b").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "name", "statement_day", "due_day", "default_amount"}).
+			AddRow(intPtr(9), stringPtr("Visa"), 25, 20, float64Ptr(400.0)))
+	from := time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM pay_periods pp").WithArgs(from, time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date"}).
+			AddRow(31, time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)).
+			AddRow(32, time.Date(2026, 4, 17, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery("SELECT id, due_date FROM bill_assignments").
+		WithArgs(9, []time.Time{time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC)}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "due_date"}).AddRow(50, time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestCreditCardForecast_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	expectCardForecast(mock)
+
+	h := NewCreditCardHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Forecast(rr, cardForecastRequest(http.MethodGet, "/api/v1/credit-cards/3/forecast?from=2026-01-26&count=2"))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.CreditCardForecast `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.BillID != 9 || len(resp.Data.Cycles) != 2 {
+		t.Fatalf("unexpected forecast: %+v", resp.Data)
+	}
+	first, second := resp.Data.Cycles[0], resp.Data.Cycles[1]
+	if first.StatementDate != "2026-02-25" || first.DueDate != "2026-03-20" || first.GraceDays != 23 {
+		t.Errorf("unexpected first cycle: %+v", first)
+	}
+	if first.PayPeriodID == nil || *first.PayPeriodID != 31 || first.AssignmentID == nil || *first.AssignmentID != 50 {
+		t.Errorf("expected the March 6 paycheck and assignment 50, got %+v", first)
+	}
+	if second.PayPeriodID == nil || *second.PayPeriodID != 32 || *second.PayDate != "2026-04-17" || second.AssignmentID != nil {
+		t.Errorf("expected the April 17 paycheck and no assignment, got %+v", second)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreditCardAssign_CreatesMissingCycles(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	due := time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC)
+	expectCardForecast(mock)
+	mock.ExpectQuery("FROM deleted_bill_periods").WithArgs(9).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_period_id", "due_date"}))
+	// Only April's cycle lacks an assignment
+	mock.ExpectQuery("INSERT INTO bill_assignments").WithArgs(9, 32, float64Ptr(400.0), due).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(51, 9, 32, float64Ptr(400.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			false, "", "", false, false, (*int)(nil), false, (*time.Time)(nil), &due, (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now))
+
+	h := NewCreditCardHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Assign(rr, cardForecastRequest(http.MethodPost, "/api/v1/credit-cards/3/assign?from=2026-01-26&count=2"))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.BillAssignment `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].ID != 51 {
+		t.Errorf("expected assignment 51, got %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreditCardForecast_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("FROM credit_cards cc LEFT JOIN bills b").WithArgs(3).WillReturnError(pgx.ErrNoRows)
+
+	h := NewCreditCardHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Forecast(rr, cardForecastRequest(http.MethodGet, "/api/v1/credit-cards/3/forecast"))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+}
+
+func TestCreditCardForecast_NotLinked(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("FROM credit_cards cc LEFT JOIN bills b").WithArgs(3).
+		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "name", "statement_day", "due_day", "default_amount"}).
+			AddRow((*int)(nil), (*string)(nil), 25, 20, (*float64)(nil)))
+
+	h := NewCreditCardHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Forecast(rr, cardForecastRequest(http.MethodGet, "/api/v1/credit-cards/3/forecast"))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestCreditCardForecast_InvalidCount(t *testing.T) {
+	h := NewCreditCardHandler(nil)
+	rr := httptest.NewRecorder()
+	h.Forecast(rr, cardForecastRequest(http.MethodGet, "/api/v1/credit-cards/3/forecast?count=30"))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Learned categories
+// ---------------------------------------------------------------------------
+
+func billRow(name, category string) *pgxmock.Rows {
+	now := time.Now()
+	return pgxmock.NewRows([]string{
+		"id", "name", "default_amount", "due_day", "recurrence",
+		"recurrence_detail", "is_autopay", "category", "notes",
+		"is_active", "sort_order", "sinking_fund_enabled", "sinking_fund_periods",
+		"shared_with", "shared_percent",
+		"bill_type", "dependent", "tax_deductible", "active_months", "monthly_amounts",
+		"owner", "portal_url", "username_hint", "password_rotated_at", "buffer_percent", "created_at", "updated_at",
+	}).AddRow(4, name, float64Ptr(15.99), (*int)(nil), "monthly",
+		json.RawMessage(nil), false, category, "",
+		true, 0, false, (*int)(nil),
+		"", (*float64)(nil),
+		"bill", "", false, []int(nil), map[int]float64(nil),
+		"", "https://example.com/login", "me@example.com", (*time.Time)(nil), 0.0, now, now)
+}
+
+func TestBillUpdate_RecordsCategoryCorrection(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	args := make([]any, 26)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+	mock.ExpectQuery("UPDATE bills SET").WithArgs(args...).
+		WillReturnRows(billRow("Netflix  Premium", "entertainment"))
+	mock.ExpectExec("INSERT INTO category_corrections").
+		WithArgs("netflix premium", "entertainment").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/bills/4", bytes.NewBufferString(`{"category":"entertainment"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "4")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.Update(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-03-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+func TestBillGet_VendorLoginOnlyForEditors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		session auth.Session
+		shown   bool
+	}{
+		{"viewer", auth.Session{Username: "sam", Role: auth.RoleViewer}, false},
+		{"editor", auth.Session{Username: "sam", Role: auth.RoleEditor}, true},
+		{"write key", auth.KeySession(3, "script", []auth.Scope{auth.ScopeWriteAssignments}), false},
+	} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectQuery("FROM bills WHERE id").WithArgs(4).WillReturnRows(billRow("Power", "utilities"))
+		mock.ExpectQuery("FROM credit_cards WHERE bill_id").WithArgs(4).WillReturnError(pgx.ErrNoRows)
+
+		h := NewBillHandler(mock)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/bills/4", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "4")
+		ctx := auth.WithSession(req.Context(), tc.session)
+		req = req.WithContext(withChiContext(ctx, rctx))
+		rr := httptest.NewRecorder()
+		h.Get(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d; body: %s", tc.name, rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Data models.Bill `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if shown := resp.Data.PortalURL != "" && resp.Data.UsernameHint != ""; shown != tc.shown {
+			t.Errorf("%s: expected vendor login shown=%v, got %+v", tc.name, tc.shown, resp.Data)
+		}
+		mock.Close()
+	}
+}
+
+func TestBillUpdate_VendorLoginValidation(t *testing.T) {
+	for _, body := range []string{
+		`{"portal_url":"javascript:alert(1)"}`,
+		`{"portal_url":"example.com/login"}`,
+		`{"password_rotated_at":"03/01/2025"}`,
+	} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := NewBillHandler(mock)
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/bills/4", bytes.NewBufferString(body))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "4")
+		req = req.WithContext(withChiContext(req.Context(), rctx))
+		rr := httptest.NewRecorder()
+		h.Update(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		mock.Close()
+	}
+}
+
+func TestBillCreate_QuickAddUsesLearnedCategory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery("SELECT category FROM category_corrections").
+		WithArgs("netflix").
+		WillReturnRows(pgxmock.NewRows([]string{"category"}).AddRow("entertainment"))
+	mock.ExpectQuery("INSERT INTO bills").
+		WithArgs("Netflix", pgxmock.AnyArg(), pgxmock.AnyArg(), "monthly", pgxmock.AnyArg(),
+			false, "entertainment", "", 0, "", pgxmock.AnyArg(),
+			"bill", "", false, pgxmock.AnyArg(), pgxmock.AnyArg(), "",
+			"", "", (*time.Time)(nil), 0.0).
+		WillReturnRows(billRow("Netflix", "entertainment"))
+
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", bytes.NewBufferString(`{"name":"Netflix"}`))
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Create(rr, req)
 
 	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_UsesFirstPeriodWhenNoneBeforeDueDate(t *testing.T) {
+func TestBillCreate_QuickAddFallsBackToKeywords(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Bill due on the 3rd
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Internet", float64Ptr(50.0), 3, "monthly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	// Only period is on the 7th (after due date)
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+	mock.ExpectQuery("SELECT category FROM category_corrections").
+		WithArgs("netflix").
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery("INSERT INTO bills").
+		WithArgs("Netflix", pgxmock.AnyArg(), pgxmock.AnyArg(), "monthly", pgxmock.AnyArg(),
+			false, "subscriptions", "", 0, "", pgxmock.AnyArg(),
+			"bill", "", false, pgxmock.AnyArg(), pgxmock.AnyArg(), "",
+			"", "", (*time.Time)(nil), 0.0).
+		WillReturnRows(billRow("Netflix", "subscriptions"))
 
-	// No existing assignments for the pre-fetch check
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"})
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+	h := NewBillHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bills", bytes.NewBufferString(`{"name":"Netflix"}`))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
 
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
 
-	// Should still assign to period 10 (first available in that month)
-	now := time.Now()
-	assignRow := pgxmock.NewRows([]string{
-		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
-		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
-		"notes", "manually_moved", "created_at", "updated_at",
-	}).AddRow(1, 1, 10, float64Ptr(50.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+// ---------------------------------------------------------------------------
+// Forecast
+// ---------------------------------------------------------------------------
 
-	mock.ExpectQuery("INSERT INTO bill_assignments").
-		WithArgs(1, 10, float64Ptr(50.0)).
-		WillReturnRows(assignRow)
+func TestForecast_CombinesIncomeAndBills(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-03-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	mar2 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM pay_periods pp").
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "amount", "name"}).
+			AddRow(10, mar2, float64Ptr(1500.0), "Acme").
+			AddRow(11, mar2, float64Ptr(500.0), "Side gig"))
+	mock.ExpectQuery("FROM income_events").
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "event_date", "name", "amount"}).
+			AddRow(7, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), "Bonus", 300.0))
+	mock.ExpectQuery("FROM bill_assignments ba").
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "out_date", "name", "amount"}).
+			AddRow(1, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "Rent", 1200.0).
+			AddRow(2, mar2, "Power", 80.0))
+
+	h := NewForecastHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/forecast?starting_balance=1000&from=2026-03-01&to=2026-03-03", nil)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Forecast(rr, req)
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data services.ForecastResult `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+	if len(resp.Data.Days) != 3 {
+		t.Fatalf("expected 3 days, got %d", len(resp.Data.Days))
+	}
+	if got := resp.Data.Days[0].Balance; got != -200 {
+		t.Errorf("2026-03-01 balance = %.2f, want -200", got)
+	}
+	// Both paydays on 03-02 arrive as one aggregated deposit
+	if d := resp.Data.Days[1]; d.Balance != 1720 || d.Income != 2000 || len(d.Items) != 2 {
+		t.Errorf("2026-03-02 = %+v, want balance 1720 from one 2000 deposit and one bill", d)
+	}
+	if d := resp.Data.Days[2]; d.Balance != 2020 || d.Items[0].Kind != "income_event" {
+		t.Errorf("2026-03-03 = %+v, want balance 2020 from the bonus", d)
+	}
+	if resp.Data.NegativeDays != 1 {
+		t.Errorf("negative days = %d, want 1", resp.Data.NegativeDays)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_SkipsExistingAssignments(t *testing.T) {
+func TestForecast_PlannedMode(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM pay_periods pp").WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "amount", "name"}))
+	mock.ExpectQuery("FROM income_events").WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "event_date", "name", "amount"}))
+	// Only the planned amount is counted, never the forecast or actual
+	mock.ExpectQuery(`COALESCE\(ba\.planned_amount \* \(1 - COALESCE\(b\.shared_percent, 0\) / 100\), 0\)\s+FROM bill_assignments ba`).
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "out_date", "name", "amount"}))
+
+	h := NewForecastHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/forecast?from=2026-03-01&to=2026-03-03&mode=planned", nil)
+	rr := httptest.NewRecorder()
+	h.Forecast(rr, req)
 
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 2, 7, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
 
-	// Bill already has an assignment for Feb (on period 10) - pre-fetch returns it
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"}).
-		AddRow(1, 10, time.Date(2026, 2, 7, 0, 0, 0, 0, time.UTC), false)
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+func TestAmountModes(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"", models.AmountModePlanned, true},
+		{"forecast", models.AmountModeForecast, true},
+		{"actual_preferred", models.AmountModeActualPreferred, true},
+		{"actual-preferred", models.AmountModeActualPreferred, true},
+		{"optimistic", "", false},
+	}
+	for _, tc := range tests {
+		got, ok := validAmountMode(tc.in, models.AmountModePlanned)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("validAmountMode(%q) = %q, %v; want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
 
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+	handlers := map[string]http.HandlerFunc{
+		"forecast":  NewForecastHandler(nil).Forecast,
+		"summary":   NewDashboardHandler(nil).Summary,
+		"runway":    NewDashboardHandler(nil).Runway,
+		"optimizer": NewOptimizerHandler(nil).Suggest,
+	}
+	for name, handle := range handlers {
+		req := httptest.NewRequest(http.MethodGet, "/?mode=optimistic", bytes.NewBufferString(`{"mode":"optimistic"}`))
+		rr := httptest.NewRecorder()
+		handle(rr, req)
 
-	// No INSERT expected - the bill/month combo is already covered
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 for an unknown mode, got %d", name, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	}
+}
+
+func TestForecast_InvalidRange(t *testing.T) {
+	h := NewForecastHandler(nil)
+	for _, q := range []string{"from=2026-03-10&to=2026-03-01", "from=2026-01-01&to=2027-06-01", "starting_balance=lots"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/forecast?"+q, nil)
+		rr := httptest.NewRecorder()
+		h.Forecast(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Period copy-from
+// ---------------------------------------------------------------------------
+
+func TestPeriodCopyFrom_ClonesAssignments(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	now := time.Now()
+	payDate := time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pay_date FROM pay_periods").WithArgs(20).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_date"}).AddRow(payDate))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(10).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("FROM bill_assignments ba").WithArgs(10, 20).
+		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "planned_amount", "forecast_amount", "is_extra",
+			"extra_name", "notes", "tax_deductible", "due_day"}).
+			AddRow(1, float64Ptr(120.0), (*float64)(nil), false, "", "", false, intPtr(15)))
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(1, 20, float64Ptr(120.0), (*float64)(nil), false, "", "", false, &due).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
+			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(30, 1, 20, float64Ptr(120.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			false, "", "", true, false, (*int)(nil), false, (*time.Time)(nil), &due, (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewPeriodHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/20/copy-from/10", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "20")
+	rctx.URLParams.Add("other_id", "10")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-02-01","to":"2026-02-28"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.CopyFrom(rr, req)
 
-	// Should return 201 with empty array (no new assignments created)
 	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.BillAssignment `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].PayPeriodID != 20 {
+		t.Errorf("expected one assignment in period 20, got %+v", resp.Data)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_SkipsWhenBillMovedToDifferentPeriod(t *testing.T) {
+func TestPeriodCopyFrom_SamePeriod(t *testing.T) {
+	h := NewPeriodHandler(nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pay-periods/20/copy-from/20", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "20")
+	rctx.URLParams.Add("other_id", "20")
+	req = req.WithContext(withChiContext(req.Context(), rctx))
+
+	rr := httptest.NewRecorder()
+	h.CopyFrom(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+// ---------------------------------------------------------------------------
+// Period risk
+// ---------------------------------------------------------------------------
+
+func TestPeriodRisk_UsesSamePaycheckHistory(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Bill due on the 15th
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+	date := func(s string) time.Time { d, _ := time.Parse("2006-01-02", s); return d }
+	// The mid-month paycheck has fallen behind twice in four bills; the
+	// 1st-of-month one never has
+	mock.ExpectQuery("FROM bill_assignments ba").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"income_source_id", "pay_date", "late"}).
+			AddRow(1, date("2025-01-15"), true).
+			AddRow(1, date("2025-01-15"), false).
+			AddRow(1, date("2025-02-14"), true).
+			AddRow(1, date("2025-02-14"), false).
+			AddRow(1, date("2025-02-01"), false))
+	mock.ExpectQuery("FROM pay_periods pp").
+		WithArgs(date("2025-03-01"), date("2025-03-31")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pay_date", "income_source_id", "name", "expected_amount"}).
+			AddRow(10, date("2025-03-01"), 1, "Job", 2000.0).
+			AddRow(11, date("2025-03-15"), 1, "Job", 2000.0))
+	mock.ExpectQuery("SELECT ba.pay_period_id").
+		WithArgs(date("2025-03-01"), date("2025-03-31")).
+		WillReturnRows(pgxmock.NewRows([]string{"pay_period_id", "amount", "due_date"}).
+			AddRow(10, 500.0, (*time.Time)(nil)).
+			AddRow(11, 500.0, (*time.Time)(nil)))
 
-	// Two periods: Feb 7 and Feb 21
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 2, 7, 0, 0, 0, 0, time.UTC)).
-		AddRow(11, time.Date(2026, 2, 21, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+	h := NewPeriodHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pay-periods/risk?from=2025-03-01&to=2025-03-31", nil)
+	rr := httptest.NewRecorder()
+	h.Risk(rr, req)
 
-	// User moved bill from period 10 (Feb 7) to period 11 (Feb 21) — existing assignment on 21st
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"}).
-		AddRow(1, 11, time.Date(2026, 2, 21, 0, 0, 0, 0, time.UTC), false)
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []services.PeriodRisk `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 2 {
+		t.Fatalf("expected two periods, got %+v", resp.Data)
+	}
+	if first, second := resp.Data[0], resp.Data[1]; first.LateRate != 0 || second.LateRate != 0.5 || second.Score <= first.Score {
+		t.Errorf("expected only the mid-month paycheck to carry late history, got %+v and %+v", first, second)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
 
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+func TestPeriodRisk_Validation(t *testing.T) {
+	for _, query := range []string{"?from=03/01/2025", "?from=2025-03-31&to=2025-03-01", "?mode=guess"} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	// No INSERT expected — bill already has an assignment for Feb, even though it's on a different period
+		h := NewPeriodHandler(mock)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/pay-periods/risk"+query, nil)
+		rr := httptest.NewRecorder()
+		h.Risk(rr, req)
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-02-01","to":"2026-02-28"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		mock.Close()
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Savings sweeps
+// ---------------------------------------------------------------------------
+
+func sweepRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "pay_date", "name", "income", "spent", "closed"})
+}
+
+func TestSweepSuggestions_OnlyClosedPeriodsAboveThreshold(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	jan1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan15 := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	feb1 := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM pay_periods pp").
+		WithArgs(pgxmock.AnyArg(), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sweepRows().
+			AddRow(1, jan1, "Job", 2000.0, 1500.0, true).  // $300 above the threshold
+			AddRow(2, jan15, "Job", 2000.0, 1900.0, true). // under it
+			AddRow(3, feb1, "Job", 2000.0, 100.0, false))  // not closed yet
+
+	h := NewSweepHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sweeps?from=2025-01-01&to=2025-02-28&threshold=200", nil)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Suggestions(rr, req)
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.SweepSuggestion `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].PeriodID != 1 || resp.Data[0].Leftover != 500 || resp.Data[0].Amount != 300 {
+		t.Errorf("expected only period 1 with $300 to sweep, got %+v", resp.Data)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_BiweeklyBillWithAnchorDate(t *testing.T) {
+func TestSweepCreate_AddsExtraAssignment(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Biweekly bill with anchor date Jan 15
-	anchorJSON := []byte(`{"anchor_date":"2026-01-15"}`)
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Loan", float64Ptr(200.0), 15, "biweekly", anchorJSON)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	// 4 semi-monthly periods: Jan 1, Jan 15, Feb 1, Feb 15
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)).
-		AddRow(11, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)).
-		AddRow(12, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)).
-		AddRow(13, time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
-
-	// No existing assignments
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"})
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
-
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
-
-	// Biweekly from Jan 15: Jan 15, Jan 29, Feb 12, Feb 26
-	// Jan 15 -> period 11 (Jan 15), Jan 29 -> period 11 (Jan 15, last on or before Jan 29)
-	// Feb 12 -> period 12 (Feb 1, last on or before Feb 12), Feb 26 -> period 13 (Feb 15, last on or before Feb 26)
-	// Period 11 gets 2 occurrences = $400, period 12 gets 1 = $200, period 13 gets 1 = $200
-	// Note: map iteration order is non-deterministic, so we expect 3 INSERTs in any order
 	now := time.Now()
-	for i := 0; i < 3; i++ {
-		assignRow := pgxmock.NewRows([]string{
+	mock.ExpectBegin()
+	mock.ExpectQuery("WHERE pp.id = \\$2 FOR UPDATE OF pp").WithArgs(pgxmock.AnyArg(), 1).
+		WillReturnRows(sweepRows().AddRow(1, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "Job", 2000.0, 1500.0, true))
+	mock.ExpectQuery("INSERT INTO bill_assignments").
+		WithArgs(9, 1, 400.0, "Savings sweep", "Leftover above $100.00 from the 2025-01-01 paycheck").
+		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
 			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
-			"notes", "manually_moved", "created_at", "updated_at",
-		}).AddRow(i+1, 1, 11, float64Ptr(200.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+			"notes", "manually_moved", "is_sinking_fund", "sinking_fund_for_period_id",
+			"tax_deductible", "scheduled_date", "due_date", "paid_date", "original_currency", "original_amount", "fx_note", "paid_by", "paid_from", "created_at", "updated_at",
+		}).AddRow(50, 9, 1, float64Ptr(400.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil),
+			true, "Savings sweep", "", true, false, (*int)(nil), false, (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*string)(nil), (*float64)(nil), "", "", "", now, now))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewSweepHandler(mock)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/sweeps", bytes.NewBufferString(`{"period_id":1,"bill_id":9,"threshold":100}`))
+	rr := httptest.NewRecorder()
+	h.Create(rr, req)
 
-		mock.ExpectQuery("INSERT INTO bill_assignments").
-			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
-			WillReturnRows(assignRow)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
+}
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-02-28"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
-	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+func TestSweepCreate_RejectsOpenOrEmptyPeriod(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		spent  float64
+		closed bool
+	}{
+		{"open", 100, false},
+		{"nothing left", 1950, true},
+	} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectBegin()
+		mock.ExpectQuery("FOR UPDATE OF pp").WithArgs(pgxmock.AnyArg(), 1).
+			WillReturnRows(sweepRows().AddRow(1, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "Job", 2000.0, tc.spent, tc.closed))
+		mock.ExpectRollback()
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+		h := NewSweepHandler(mock)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/sweeps", bytes.NewBufferString(`{"period_id":1,"bill_id":9,"threshold":100}`))
+		rr := httptest.NewRecorder()
+		h.Create(rr, req)
+
+		if rr.Code != http.StatusConflict {
+			t.Errorf("%s: expected 409, got %d", tc.name, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: unmet expectations: %v", tc.name, err)
+		}
+		mock.Close()
 	}
 }
 
-func TestAutoAssign_BiweeklyFallsBackWithoutAnchor(t *testing.T) {
+// ---------------------------------------------------------------------------
+// Audit log
+// ---------------------------------------------------------------------------
+
+func TestAuditList_FiltersAndDefaultLimit(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Biweekly bill WITHOUT anchor date — should fall back to monthly
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Loan", float64Ptr(200.0), 15, "biweekly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
+	actor := "sam"
+	at := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM audit_log").
+		WithArgs("bill", 4, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), defaultAuditLimit).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "entity", "entity_id", "action", "actor", "before", "after", "request_id", "origin", "undone_at", "created_at", "total"}).
+			AddRow(int64(12), "bill", 4, "update", &actor, []byte(`{"default_amount": 80}`), []byte(`{"default_amount": 95}`), (*string)(nil), "change", (*time.Time)(nil), at, 1))
 
-	// One period: Mar 7 (use future date)
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+	h := NewAuditHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?entity=bill&entity_id=4&to=2026-03-02", nil)
+	rr := httptest.NewRecorder()
+	h.List(rr, req)
 
-	// No existing assignments
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"})
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data []models.AuditEntry `json:"data"`
+		Meta models.Meta         `json:"meta"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Data) != 1 || resp.Data[0].Actor == nil || *resp.Data[0].Actor != "sam" ||
+		string(resp.Data[0].After) != `{"default_amount":95}` {
+		t.Errorf("unexpected entries: %+v", resp.Data)
+	}
+	if resp.Meta.Limit == nil || *resp.Meta.Limit != defaultAuditLimit {
+		t.Errorf("expected the default limit in meta, got %+v", resp.Meta)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
 
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+func TestAuditList_Validation(t *testing.T) {
+	for _, q := range []string{"entity=users", "entity_id=abc", "action=rename", "from=03/01/2026"} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := NewAuditHandler(mock)
+		rr := httptest.NewRecorder()
+		h.List(rr, httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+q, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		mock.Close()
+	}
+}
 
-	// Falls back to monthly: assigns to period 10
-	now := time.Now()
-	assignRow := pgxmock.NewRows([]string{
-		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
-		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
-		"notes", "manually_moved", "created_at", "updated_at",
-	}).AddRow(1, 1, 10, float64Ptr(200.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+func auditExportRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "entity", "entity_id", "action", "actor", "before", "after", "request_id", "origin", "undone_at", "created_at"})
+}
 
-	mock.ExpectQuery("INSERT INTO bill_assignments").
-		WithArgs(1, 10, float64Ptr(200.0)).
-		WillReturnRows(assignRow)
+func TestAuditExport_StreamsCSV(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-03-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
+	actor := "sam"
+	at := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM audit_log\\s+WHERE 1=1 AND created_at >= \\$1 AND created_at < \\$2\\s+ORDER BY created_at, id").
+		WithArgs(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(auditExportRows().
+			AddRow(int64(11), "bill", 4, "create", (*string)(nil), []byte(nil), []byte(`{"name": "Rent"}`), (*string)(nil), "change", (*time.Time)(nil), at).
+			AddRow(int64(12), "bill", 4, "update", &actor, []byte(`{"default_amount": 80}`), []byte(`{"default_amount": 95}`), (*string)(nil), "change", (*time.Time)(nil), at))
+
+	h := NewAuditHandler(mock)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Export(rr, httptest.NewRequest(http.MethodGet, "/api/v1/audit/export?from=2026-03-01&to=2026-03-31&format=csv", nil))
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %v", records)
+	}
+	if got := records[2]; got[0] != "12" || got[1] != "2026-03-02T14:00:00Z" || got[5] != "sam" || got[10] != `{"default_amount": 95}` {
+		t.Errorf("unexpected row: %v", got)
+	}
+	if got := records[1]; got[5] != "" || got[9] != "" {
+		t.Errorf("expected no actor or before on a create, got %v", got)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_QuarterlyBillWithAnchorDate(t *testing.T) {
+func TestAuditExport_JSONArray(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Quarterly bill with anchor date Jan 15
-	anchorJSON := []byte(`{"anchor_date":"2026-01-15"}`)
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Insurance", float64Ptr(300.0), 15, "quarterly", anchorJSON)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	// Periods: Jan 1, Jan 15, Apr 1, Apr 15
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)).
-		AddRow(11, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)).
-		AddRow(12, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)).
-		AddRow(13, time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
+	at := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM audit_log").
+		WithArgs("assignment").
+		WillReturnRows(auditExportRows().
+			AddRow(int64(1), "assignment", 7, "delete", (*string)(nil), []byte(`{"id": 7}`), []byte(nil), (*string)(nil), "change", (*time.Time)(nil), at).
+			AddRow(int64(2), "assignment", 8, "create", (*string)(nil), []byte(nil), []byte(`{"id": 8}`), (*string)(nil), "change", (*time.Time)(nil), at))
 
-	// No existing assignments
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"})
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
+	h := NewAuditHandler(mock)
+	rr := httptest.NewRecorder()
+	h.Export(rr, httptest.NewRequest(http.MethodGet, "/api/v1/audit/export?entity=assignment", nil))
 
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var entries []models.AuditEntry
+	if err := json.Unmarshal(rr.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("expected a JSON array: %v; body: %s", err, rr.Body.String())
+	}
+	if len(entries) != 2 || entries[0].EntityID != 7 || entries[1].EntityID != 8 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
 
-	// Quarterly from Jan 15: Jan 15, Apr 15
-	// Jan 15 -> period 11, Apr 15 -> period 13
-	now := time.Now()
-	for i := 0; i < 2; i++ {
-		assignRow := pgxmock.NewRows([]string{
-			"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
-			"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
-			"notes", "manually_moved", "created_at", "updated_at",
-		}).AddRow(i+1, 1, 11, float64Ptr(300.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
+	// No matches is still a valid file
+	mock.ExpectQuery("FROM audit_log").WillReturnRows(auditExportRows())
+	rr = httptest.NewRecorder()
+	h.Export(rr, httptest.NewRequest(http.MethodGet, "/api/v1/audit/export", nil))
+	if strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("expected an empty array, got %q", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
 
-		mock.ExpectQuery("INSERT INTO bill_assignments").
-			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
-			WillReturnRows(assignRow)
+func TestAuditExport_Validation(t *testing.T) {
+	for _, q := range []string{"format=xml", "entity=users", "to=2026-13-01"} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := NewAuditHandler(mock)
+		rr := httptest.NewRecorder()
+		h.Export(rr, httptest.NewRequest(http.MethodGet, "/api/v1/audit/export?"+q, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+		mock.Close()
 	}
+}
 
-	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-06-30"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
-	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+// ---------------------------------------------------------------------------
+// Undo and redo of assignment changes
+// ---------------------------------------------------------------------------
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
-	}
+func auditTargetRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "entity", "request_id", "txid", "origin", "undone_at"})
 }
 
-func TestAutoAssign_AnnualBillWithAnchorDate(t *testing.T) {
+func auditGroupRows() *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "entity_id", "action", "before", "after"})
+}
+
+func TestAssignmentUndo_RevertsWholeRequest(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Annual bill with anchor date March 1
-	anchorJSON := []byte(`{"anchor_date":"2026-03-01"}`)
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Car Registration", float64Ptr(500.0), 1, "annual", anchorJSON)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	// Periods: Feb 15, Mar 1, Mar 15
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)).
-		AddRow(11, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)).
-		AddRow(12, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
-
-	// No existing assignments
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"})
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
-
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
-
-	// Annual on Mar 1 -> period 11 (Mar 1)
-	now := time.Now()
-	assignRow := pgxmock.NewRows([]string{
-		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
-		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
-		"notes", "manually_moved", "created_at", "updated_at",
-	}).AddRow(1, 1, 11, float64Ptr(500.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
-
-	mock.ExpectQuery("INSERT INTO bill_assignments").
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
-		WillReturnRows(assignRow)
+	reqID := "host/abc-000042"
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM audit_log\\s+WHERE entity = 'assignment' AND undone_at IS NULL AND origin <> 'undo'").
+		WillReturnRows(auditTargetRows().AddRow(int64(21), "assignment", &reqID, int64Ptr(900), "change", (*time.Time)(nil)))
+	// An optimizer apply moved assignment 7, and an auto-assign in the same request created 8
+	mock.ExpectQuery("WHERE entity = 'assignment' AND undone_at IS NULL AND request_id = \\$1").WithArgs(reqID).
+		WillReturnRows(auditGroupRows().
+			AddRow(int64(21), 7, "update", json.RawMessage(`{"pay_period_id": 3}`), json.RawMessage(`{"pay_period_id": 4}`)).
+			AddRow(int64(20), 8, "create", json.RawMessage(nil), json.RawMessage(`{"id": 8}`)))
+	mock.ExpectQuery("SELECT to_jsonb\\(ba\\) FROM bill_assignments").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"to_jsonb"}).AddRow(json.RawMessage(`{"id": 7, "pay_period_id": 4, "status": "pending"}`)))
+	mock.ExpectExec(`UPDATE bill_assignments SET \("pay_period_id"\) = \(SELECT "pay_period_id" FROM jsonb_populate_record`).
+		WithArgs(7, json.RawMessage(`{"pay_period_id": 3}`)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM bill_assignments WHERE id = \\$1").WithArgs(8).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec("UPDATE audit_log SET undone_at").WithArgs([]int64{21, 20}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mock.ExpectExec("UPDATE audit_log SET origin").WithArgs("undo").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
 
 	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-12-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Undo(rr, httptest.NewRequest(http.MethodPost, "/api/v1/assignments/undo", nil))
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.UndoResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Reverted != 2 || len(resp.Data.Assignments) != 2 {
+		t.Errorf("unexpected result: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_QuarterlyFallsBackWithoutAnchor(t *testing.T) {
+func TestAssignmentUndo_ChangedSinceIsConflict(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Quarterly bill WITHOUT anchor date — should fall back to monthly
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Insurance", float64Ptr(300.0), 15, "quarterly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	// One period: Mar 7 (use future date)
-	periodRows := pgxmock.NewRows([]string{"id", "pay_date"}).
-		AddRow(10, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC))
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(periodRows)
-
-	// No existing assignments
-	existingRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id", "pay_date", "manually_moved"})
-	mock.ExpectQuery("SELECT ba.bill_id, ba.pay_period_id, pp.pay_date, ba.manually_moved").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(existingRows)
-
-	// No deleted bill+period combos
-	deletedRows := pgxmock.NewRows([]string{"bill_id", "pay_period_id"})
-	mock.ExpectQuery("SELECT dbp.bill_id, dbp.pay_period_id FROM deleted_bill_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(deletedRows)
-
-	// Falls back to monthly: assigns to period 10
-	now := time.Now()
-	assignRow := pgxmock.NewRows([]string{
-		"id", "bill_id", "pay_period_id", "planned_amount", "forecast_amount",
-		"actual_amount", "status", "deferred_to_id", "is_extra", "extra_name",
-		"notes", "manually_moved", "created_at", "updated_at",
-	}).AddRow(1, 1, 10, float64Ptr(300.0), (*float64)(nil), (*float64)(nil), "pending", (*int)(nil), false, "", "", false, now, now)
-
-	mock.ExpectQuery("INSERT INTO bill_assignments").
-		WithArgs(1, 10, float64Ptr(300.0)).
-		WillReturnRows(assignRow)
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM audit_log WHERE id = \\$1").WithArgs(int64(21)).
+		WillReturnRows(auditTargetRows().AddRow(int64(21), "assignment", (*string)(nil), int64Ptr(900), "change", (*time.Time)(nil)))
+	mock.ExpectQuery("request_id IS NULL AND txid = \\$1").WithArgs(int64(900)).
+		WillReturnRows(auditGroupRows().
+			AddRow(int64(21), 7, "update", json.RawMessage(`{"status": "pending"}`), json.RawMessage(`{"status": "paid"}`)))
+	// Marked paid, then deferred by hand since
+	mock.ExpectQuery("SELECT to_jsonb\\(ba\\) FROM bill_assignments").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"to_jsonb"}).AddRow(json.RawMessage(`{"id": 7, "status": "deferred"}`)))
+	mock.ExpectRollback()
 
 	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-03-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Undo(rr, httptest.NewRequest(http.MethodPost, "/api/v1/assignments/undo", bytes.NewBufferString(`{"audit_id": 21}`)))
 
-	if rr.Code != http.StatusCreated {
-		t.Errorf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body: %s", rr.Code, rr.Body.String())
 	}
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAutoAssign_BillQueryError(t *testing.T) {
+func TestAssignmentUndo_OnlyAssignments(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnError(fmt.Errorf("db connection lost"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM audit_log WHERE id = \\$1").WithArgs(int64(5)).
+		WillReturnRows(auditTargetRows().AddRow(int64(5), "bill", (*string)(nil), int64Ptr(10), "change", (*time.Time)(nil)))
+	mock.ExpectRollback()
 
 	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Undo(rr, httptest.NewRequest(http.MethodPost, "/api/v1/assignments/undo", bytes.NewBufferString(`{"audit_id": 5}`)))
 
-	if rr.Code != http.StatusInternalServerError {
-		t.Errorf("expected 500, got %d", rr.Code)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
 }
 
-func TestAutoAssign_PeriodQueryError(t *testing.T) {
+func TestAssignmentRedo_NothingAfterNewChange(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	billRows := pgxmock.NewRows([]string{"id", "name", "default_amount", "due_day", "recurrence", "recurrence_detail"}).
-		AddRow(1, "Electric", float64Ptr(100.0), 15, "monthly", nil)
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(billRows)
-
-	mock.ExpectQuery("SELECT pp.id, pp.pay_date FROM pay_periods").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(fmt.Errorf("db error"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("origin = 'undo' AND undone_at IS NULL").WillReturnRows(auditTargetRows())
+	mock.ExpectRollback()
 
 	h := NewAssignmentHandler(mock)
-	body := bytes.NewBufferString(`{"from":"2026-01-01","to":"2026-03-31"}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/assignments/auto-assign", body)
 	rr := httptest.NewRecorder()
-	h.AutoAssign(rr, req)
+	h.Redo(rr, httptest.NewRequest(http.MethodPost, "/api/v1/assignments/redo", nil))
 
-	if rr.Code != http.StatusInternalServerError {
-		t.Errorf("expected 500, got %d", rr.Code)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
 }
 
 // ---------------------------------------------------------------------------
-// Bill Delete: Success cases
+// Starter budget templates
 // ---------------------------------------------------------------------------
 
-func TestBillDelete_Success(t *testing.T) {
+func TestTemplateExport_AnonymizedAndHashed(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	mock.ExpectExec("UPDATE bills SET is_active = false").
-		WithArgs(1).
-		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
-
-	h := NewBillHandler(mock)
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/bills/1", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	mock.ExpectQuery("FROM bills").
+		WillReturnRows(pgxmock.NewRows([]string{"name", "category", "due_day", "recurrence", "recurrence_detail", "is_autopay", "active_months"}).
+			AddRow("Rent", "housing", intPtr(1), "monthly", json.RawMessage(nil), false, []int(nil)).
+			AddRow("Water", "utilities", intPtr(20), "monthly", json.RawMessage(nil), true, []int(nil)))
+	mock.ExpectQuery("FROM income_sources").
+		WillReturnRows(pgxmock.NewRows([]string{"pay_schedule", "schedule_detail"}).
+			AddRow("semimonthly", json.RawMessage(`{"days":[15,"last"]}`)))
 
+	h := NewTemplateHandler(mock)
 	rr := httptest.NewRecorder()
-	h.Delete(rr, req)
+	h.Export(rr, httptest.NewRequest(http.MethodGet, "/api/v1/templates/export?name=Renters", nil))
 
-	if rr.Code != http.StatusNoContent {
-		t.Errorf("expected 204, got %d; body: %s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "amount") {
+		t.Errorf("template should carry no amounts: %s", rr.Body.String())
+	}
+	var resp struct {
+		Data models.BudgetTemplate `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Name != "Renters" || len(resp.Data.Bills) != 2 || len(resp.Data.Categories) != 2 {
+		t.Errorf("unexpected template: %+v", resp.Data)
+	}
+	if len(resp.Data.IncomeSources) != 1 || resp.Data.IncomeSources[0].Name != "Paycheck 1" {
+		t.Errorf("expected income sources numbered, got %+v", resp.Data.IncomeSources)
+	}
+	// What was exported hashes the same once decoded
+	if hash, _ := templateHash(resp.Data); hash != resp.Data.Hash || len(hash) != 64 {
+		t.Errorf("hash %q does not match content hash %q", resp.Data.Hash, hash)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestBillDelete_NotFound(t *testing.T) {
+func TestTemplateImport_RejectsTamperedTemplate(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	mock.ExpectExec("UPDATE bills SET is_active = false").
-		WithArgs(999).
-		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
-
-	h := NewBillHandler(mock)
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/bills/999", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "999")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	tmpl := models.BudgetTemplate{Version: 1, Name: "Starter", Bills: []models.TemplateBill{{Name: "Rent", Recurrence: "monthly"}}}
+	tmpl.Hash, _ = templateHash(tmpl)
+	tmpl.Bills[0].Name = "Rent (edited)"
+	body, _ := json.Marshal(tmpl)
 
+	h := NewTemplateHandler(mock)
 	rr := httptest.NewRecorder()
-	h.Delete(rr, req)
+	h.Import(rr, httptest.NewRequest(http.MethodPost, "/api/v1/templates/import", bytes.NewReader(body)))
 
-	if rr.Code != http.StatusNotFound {
-		t.Errorf("expected 404, got %d", rr.Code)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
+	assertErrorCode(t, rr.Body.Bytes(), "HASH_MISMATCH")
 }
 
-func TestBillDelete_DBError(t *testing.T) {
+func TestTemplateImport_CreatesBillsWithoutAmounts(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	mock.ExpectExec("UPDATE bills SET is_active = false").
-		WithArgs(1).
-		WillReturnError(fmt.Errorf("connection lost"))
+	tmpl := models.BudgetTemplate{
+		Version: 1, Name: "Starter",
+		Bills:         []models.TemplateBill{{Name: "Rent", Category: "housing", DueDay: intPtr(1), Recurrence: "monthly"}},
+		IncomeSources: []models.TemplateIncomeSource{{Name: "Paycheck 1", PaySchedule: "semimonthly", ScheduleDetail: json.RawMessage(`{"days": [15, "last"]}`)}},
+	}
+	tmpl.Hash, _ = templateHash(tmpl)
+	body, _ := json.MarshalIndent(tmpl, "", "  ")
 
-	h := NewBillHandler(mock)
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/bills/1", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM bills").WithArgs("Rent").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM income_sources").WithArgs("Paycheck 1").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("INSERT INTO income_sources").
+		WithArgs("Paycheck 1", "semimonthly", pgxmock.AnyArg(), (*float64)(nil), (*time.Time)(nil), "").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "owner", "created_at", "updated_at"}).
+			AddRow(4, "Paycheck 1", "semimonthly", json.RawMessage(`{"days":[15,"last"]}`), (*float64)(nil), true, nil, "", now, now))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
 
+	h := NewTemplateHandler(mock)
 	rr := httptest.NewRecorder()
-	h.Delete(rr, req)
+	h.Import(rr, httptest.NewRequest(http.MethodPost, "/api/v1/templates/import", bytes.NewReader(body)))
 
-	if rr.Code != http.StatusInternalServerError {
-		t.Errorf("expected 500, got %d", rr.Code)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.TemplateImportResult `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.ImportedIncomeSources != 1 || resp.Data.ImportedBills != 0 || resp.Data.Hash != tmpl.Hash {
+		t.Errorf("unexpected result: %+v", resp.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
 }
 
 // ---------------------------------------------------------------------------
-// Assignment Delete: Success cases
+// What-if scenarios
 // ---------------------------------------------------------------------------
 
-func TestAssignmentDelete_Success(t *testing.T) {
+func scenarioRows(status string) *pgxmock.Rows {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return pgxmock.NewRows([]string{"id", "name", "description", "status", "created_by", "created_at", "promoted_at"}).
+		AddRow(7, "New car", "", status, "", now, (*time.Time)(nil))
+}
+
+func scenarioRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("scenario_id", "7")
+	return req.WithContext(withChiContext(req.Context(), rctx))
+}
+
+func TestScenarioCreate_ClonesBudgetTables(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// First query to get bill_id and pay_period_id
-	mock.ExpectQuery("SELECT bill_id, pay_period_id FROM bill_assignments").
-		WithArgs(5).
-		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "pay_period_id"}).AddRow(1, 10))
-
-	// Then delete
-	mock.ExpectExec("DELETE FROM bill_assignments").
-		WithArgs(5).
-		WillReturnResult(pgxmock.NewResult("DELETE", 1))
-
-	// Then track the deletion
-	mock.ExpectExec("INSERT INTO deleted_bill_periods").
-		WithArgs(1, 10).
-		WillReturnResult(pgxmock.NewResult("INSERT", 1))
-
-	h := NewAssignmentHandler(mock)
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/assignments/5", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "5")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
-
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO scenarios").
+		WithArgs("New car", "Trade in the old one", "").
+		WillReturnRows(scenarioRows("open"))
+	mock.ExpectExec(`CREATE SCHEMA "scenario_7"`).WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	for _, table := range []string{"bills", "pay_periods", "bill_assignments"} {
+		mock.ExpectExec(`CREATE TABLE "scenario_7"."` + table + `" \(LIKE "` + table + `" INCLUDING ALL\)`).
+			WillReturnResult(pgxmock.NewResult("CREATE", 0))
+		mock.ExpectExec(`INSERT INTO "scenario_7"."` + table + `" SELECT \* FROM "` + table + `"`).
+			WillReturnResult(pgxmock.NewResult("INSERT", 3))
+	}
+	mock.ExpectExec(`CREATE TABLE "scenario_7"."optimizer_plans"`).WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectExec(`CREATE TABLE "scenario_7"."optimizer_layouts"`).WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectExec(`CREATE TABLE "scenario_7"."category_corrections"`).WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectExec(`ALTER TABLE "scenario_7".bill_assignments`).WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	mock.ExpectCommit()
+
+	h := NewScenarioHandler(mock)
 	rr := httptest.NewRecorder()
-	h.Delete(rr, req)
+	body := strings.NewReader(`{"name": " New car ", "description": "Trade in the old one"}`)
+	h.Create(rr, httptest.NewRequest(http.MethodPost, "/api/v1/scenarios", body))
 
-	if rr.Code != http.StatusNoContent {
-		t.Errorf("expected 204, got %d; body: %s", rr.Code, rr.Body.String())
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestAssignmentDelete_NotFound(t *testing.T) {
+func TestScenarioCreate_NameRequired(t *testing.T) {
+	h := NewScenarioHandler(nil)
+	rr := httptest.NewRecorder()
+	h.Create(rr, httptest.NewRequest(http.MethodPost, "/api/v1/scenarios", strings.NewReader(`{"name": "  "}`)))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
+}
+
+func TestScenarioSandbox(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// Query returns no rows (assignment not found)
-	mock.ExpectQuery("SELECT bill_id, pay_period_id FROM bill_assignments").
-		WithArgs(999).
-		WillReturnError(fmt.Errorf("no rows in result set"))
+	mock.ExpectQuery("SELECT status FROM scenarios").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"status"}).AddRow("open"))
+	mock.ExpectQuery("SELECT status FROM scenarios").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"status"}).AddRow("promoted"))
 
-	h := NewAssignmentHandler(mock)
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/assignments/999", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "999")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	called := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		w.WriteHeader(http.StatusOK)
+	})
+	sandbox := NewScenarioHandler(mock).Sandbox(next)
 
 	rr := httptest.NewRecorder()
-	h.Delete(rr, req)
+	sandbox.ServeHTTP(rr, scenarioRequest(http.MethodGet, "/api/v1/scenarios/7/bills", nil))
+	if rr.Code != http.StatusOK || called != 1 {
+		t.Errorf("expected an open scenario to reach the handler, got %d", rr.Code)
+	}
 
-	if rr.Code != http.StatusNotFound {
-		t.Errorf("expected 404, got %d", rr.Code)
+	rr = httptest.NewRecorder()
+	sandbox.ServeHTTP(rr, scenarioRequest(http.MethodGet, "/api/v1/scenarios/7/bills", nil))
+	if rr.Code != http.StatusConflict || called != 1 {
+		t.Errorf("expected 409 for a promoted scenario, got %d", rr.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "NOT_FOUND")
 }
 
-func TestAssignmentDelete_DBError(t *testing.T) {
+func TestScenarioPromote_LiveBudgetChanged(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	// First query succeeds
-	mock.ExpectQuery("SELECT bill_id, pay_period_id FROM bill_assignments").
-		WithArgs(1).
-		WillReturnRows(pgxmock.NewRows([]string{"bill_id", "pay_period_id"}).AddRow(1, 10))
-
-	// Delete fails
-	mock.ExpectExec("DELETE FROM bill_assignments").
-		WithArgs(1).
-		WillReturnError(fmt.Errorf("connection lost"))
-
-	h := NewAssignmentHandler(mock)
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/assignments/1", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status, base_audit_id FROM scenarios").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"status", "base_audit_id"}).AddRow("open", int64(120)))
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(120)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectRollback()
 
+	h := NewScenarioHandler(mock)
 	rr := httptest.NewRecorder()
-	h.Delete(rr, req)
+	h.Promote(rr, scenarioRequest(http.MethodPost, "/api/v1/scenarios/7/promote", nil))
 
-	if rr.Code != http.StatusInternalServerError {
-		t.Errorf("expected 500, got %d", rr.Code)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	assertErrorCode(t, rr.Body.Bytes(), "CONFLICT")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "DB_ERROR")
 }
 
-// ---------------------------------------------------------------------------
-// Period Update: Success and error cases
-// ---------------------------------------------------------------------------
-
-func TestPeriodUpdate_Success(t *testing.T) {
+func TestScenarioPromote(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	now := time.Now()
-	payDate := time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC)
-	rows := pgxmock.NewRows([]string{
-		"id", "income_source_id", "pay_date", "expected_amount", "actual_amount", "notes", "created_at",
-	}).AddRow(1, 1, payDate, float64Ptr(2000.0), (*float64)(nil), "", now)
-
-	mock.ExpectQuery("UPDATE pay_periods SET").
-		WithArgs(1, float64Ptr(2000.0), (*float64)(nil), (*string)(nil)).
-		WillReturnRows(rows)
-
-	h := NewPeriodHandler(mock)
-	body := bytes.NewBufferString(`{"expected_amount":2000}`)
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/1", body)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status, base_audit_id FROM scenarios").WithArgs(7).
+		WillReturnRows(pgxmock.NewRows([]string{"status", "base_audit_id"}).AddRow("open", int64(120)))
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(120)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("FROM information_schema.columns").
+		WithArgs([]string{"bills", "pay_periods", "bill_assignments"}, "scenario_7").
+		WillReturnRows(pgxmock.NewRows([]string{"table_name", "column_name"}).
+			AddRow("bill_assignments", "pay_period_id").
+			AddRow("bill_assignments", "planned_amount").
+			AddRow("bills", "default_amount").
+			AddRow("pay_periods", "expected_amount"))
+	mock.ExpectExec(`DELETE FROM bill_assignments WHERE id NOT IN \(SELECT id FROM "scenario_7".bill_assignments\)`).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`UPDATE "bills" AS l SET \("default_amount"\) = ROW\(s."default_amount"\)`).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mock.ExpectExec(`INSERT INTO "bills"`).WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mock.ExpectExec(`UPDATE "pay_periods" AS l`).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectExec(`INSERT INTO "pay_periods"`).WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mock.ExpectExec(`UPDATE "bill_assignments" AS l SET \("pay_period_id", "planned_amount"\)`).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 4))
+	mock.ExpectExec(`INSERT INTO "bill_assignments" \(id, "pay_period_id", "planned_amount"\)`).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("UPDATE scenarios SET status = 'promoted'").WithArgs(7).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`DROP SCHEMA "scenario_7" CASCADE`).WillReturnResult(pgxmock.NewResult("DROP", 0))
+	mock.ExpectCommit()
 
+	h := NewScenarioHandler(mock)
 	rr := httptest.NewRecorder()
-	h.Update(rr, req)
+	h.Promote(rr, scenarioRequest(http.MethodPost, "/api/v1/scenarios/7/promote", nil))
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data models.ScenarioPromoteResult `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := models.ScenarioTableResult{Updated: 4, Added: 1, Removed: 1}
+	if resp.Data.Tables["bill_assignments"] != want || resp.Data.Tables["bills"].Updated != 2 {
+		t.Errorf("unexpected result: %+v", resp.Data)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestPeriodUpdate_NotFound(t *testing.T) {
+func TestScenarioDiscard_NotFound(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	mock.ExpectQuery("UPDATE pay_periods SET").
-		WithArgs(999, float64Ptr(1500.0), (*float64)(nil), (*string)(nil)).
-		WillReturnError(fmt.Errorf("no rows in result set"))
-
-	h := NewPeriodHandler(mock)
-	body := bytes.NewBufferString(`{"expected_amount":1500}`)
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/pay-periods/999", body)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "999")
-	req = req.WithContext(withChiContext(req.Context(), rctx))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM scenarios").WithArgs(7).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectRollback()
 
+	h := NewScenarioHandler(mock)
 	rr := httptest.NewRecorder()
-	h.Update(rr, req)
+	h.Discard(rr, scenarioRequest(http.MethodDelete, "/api/v1/scenarios/7", nil))
 
 	if rr.Code != http.StatusNotFound {
 		t.Errorf("expected 404, got %d", rr.Code)
 	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
 }
 
 // ---------------------------------------------------------------------------
-// Income: Create one_time schedule
+// Users and roles
 // ---------------------------------------------------------------------------
 
-func TestIncomeCreate_OneTime_Success(t *testing.T) {
+func TestUserCreate_Validation(t *testing.T) {
+	h := NewUserHandler(nil, &config.Config{AuthUsername: "admin"})
+	for _, tc := range []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`{"username":" ","password":"long enough"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{`{"username":"sam","password":"long enough","role":"owner"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{`{"username":"sam","password":"short"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{`{"username":"admin","password":"long enough"}`, http.StatusConflict, "CONFLICT"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBufferString(tc.body))
+		rr := httptest.NewRecorder()
+		h.Create(rr, req)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.body, tc.status, rr.Code)
+		}
+		assertErrorCode(t, rr.Body.Bytes(), tc.code)
+	}
+}
+
+func TestUserCreate_StoresHashAndRole(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
@@ -1553,103 +9598,231 @@ func TestIncomeCreate_OneTime_Success(t *testing.T) {
 	defer mock.Close()
 
 	now := time.Now()
-	rows := pgxmock.NewRows([]string{"id", "name", "pay_schedule", "schedule_detail", "default_amount", "is_active", "effective_from", "created_at", "updated_at"}).
-		AddRow(1, "Year-End Bonus", "one_time", json.RawMessage(`{"date":"2026-03-15"}`), float64Ptr(5000.0), true, (*time.Time)(nil), now, now)
-
-	mock.ExpectQuery("INSERT INTO income_sources").
-		WithArgs("Year-End Bonus", "one_time", json.RawMessage(`{"date":"2026-03-15"}`), float64Ptr(5000.0), (*time.Time)(nil)).
-		WillReturnRows(rows)
-
-	h := NewIncomeHandler(mock)
-	body := bytes.NewBufferString(`{"name":"Year-End Bonus","pay_schedule":"one_time","schedule_detail":{"date":"2026-03-15"},"default_amount":5000}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources", body)
+	mock.ExpectQuery("INSERT INTO users").
+		WithArgs("sam", pgxmock.AnyArg(), "editor").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "username", "role", "created_at", "updated_at"}).
+			AddRow(2, "sam", "editor", now, now))
+
+	h := NewUserHandler(mock, &config.Config{AuthUsername: "admin"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users",
+		bytes.NewBufferString(`{"username":" sam ","password":"correct horse","role":"editor"}`))
 	rr := httptest.NewRecorder()
-
 	h.Create(rr, req)
 
 	if rr.Code != http.StatusCreated {
-		t.Errorf("expected status 201, got %d; body: %s", rr.Code, rr.Body.String())
+		t.Fatalf("expected 201, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "correct horse") || strings.Contains(rr.Body.String(), "password") {
+		t.Errorf("response leaks the password: %s", rr.Body.String())
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestIncomeCreate_InvalidSchedule_StillRejects(t *testing.T) {
+func TestAuthLogin_RoleInSession(t *testing.T) {
+	adminHash, err := auth.HashPassword("admin pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewerHash, err := auth.HashPassword("viewer pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{AuthUsername: "admin", AuthPasswordHash: adminHash, JWTSecret: "secret"}
+
+	for _, tc := range []struct {
+		username, password string
+		stored             bool
+		want               auth.Role
+	}{
+		{"admin", "admin pass", false, auth.RoleAdmin},
+		{"kid", "viewer pass", true, auth.RoleViewer},
+	} {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.stored {
+			mock.ExpectQuery("SELECT password_hash, role FROM users").
+				WithArgs(tc.username).
+				WillReturnRows(pgxmock.NewRows([]string{"password_hash", "role"}).AddRow(viewerHash, "viewer"))
+		}
+		mock.ExpectQuery("INSERT INTO refresh_tokens").
+			WithArgs(pgxmock.AnyArg(), tc.username, pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(42))
+
+		h := NewAuthHandler(mock, cfg)
+		body, _ := json.Marshal(map[string]string{"username": tc.username, "password": tc.password})
+		rr := httptest.NewRecorder()
+		h.Login(rr, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body)))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d; body: %s", tc.username, rr.Code, rr.Body.String())
+		}
+		cookies := map[string]string{}
+		for _, c := range rr.Result().Cookies() {
+			cookies[c.Name] = c.Value
+		}
+		if len(cookies[auth.RefreshCookieName]) != 64 {
+			t.Errorf("%s: expected a refresh token cookie, got %q", tc.username, cookies[auth.RefreshCookieName])
+		}
+		session, err := auth.ValidateToken(cfg.JWTSecret, cookies[auth.CookieName])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.ID != 42 || session.Username != tc.username || session.Role != tc.want {
+			t.Errorf("session = %+v, want session 42 for %s as %s", session, tc.username, tc.want)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+		mock.Close()
+	}
+}
+
+func TestAuthLogin_WrongPasswordForStoredUser(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	h := NewIncomeHandler(mock)
-	body := bytes.NewBufferString(`{"name":"Test","pay_schedule":"daily","schedule_detail":{}}`)
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/income-sources", body)
-	rr := httptest.NewRecorder()
+	hash, err := auth.HashPassword("right pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("SELECT password_hash, role FROM users").
+		WithArgs("kid").
+		WillReturnRows(pgxmock.NewRows([]string{"password_hash", "role"}).AddRow(hash, "viewer"))
 
-	h.Create(rr, req)
+	h := NewAuthHandler(mock, &config.Config{AuthUsername: "admin", AuthPasswordHash: "x", JWTSecret: "secret"})
+	rr := httptest.NewRecorder()
+	h.Login(rr, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		bytes.NewBufferString(`{"username":"kid","password":"wrong pass"}`)))
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected status 400, got %d", rr.Code)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rr.Code)
 	}
-	assertErrorCode(t, rr.Body.Bytes(), "VALIDATION_ERROR")
 }
 
-// ---------------------------------------------------------------------------
-// Bill List: active filter
-// ---------------------------------------------------------------------------
-
-func TestBillList_ActiveFilter(t *testing.T) {
+func TestAuthRefresh_RotatesToken(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	rows := pgxmock.NewRows([]string{
-		"id", "name", "default_amount", "due_day", "recurrence",
-		"recurrence_detail", "is_autopay", "category", "notes",
-		"is_active", "sort_order", "created_at", "updated_at",
-		"cc_id", "cc_label", "cc_statement_day", "cc_due_day", "cc_issuer",
-	})
-	mock.ExpectQuery("SELECT (.+) FROM bills (.+) WHERE b.is_active = true").WillReturnRows(rows)
+	old := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, username, expires_at, revoked_at FROM refresh_tokens").
+		WithArgs(auth.HashRefreshToken(old)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "username", "expires_at", "revoked_at"}).
+			AddRow(7, "kid", time.Now().Add(time.Hour), (*time.Time)(nil)))
+	mock.ExpectQuery("SELECT role FROM users").WithArgs("kid").
+		WillReturnRows(pgxmock.NewRows([]string{"role"}).AddRow("editor"))
+	mock.ExpectQuery("INSERT INTO refresh_tokens").
+		WithArgs(pgxmock.AnyArg(), "kid", pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\), replaced_by").WithArgs(7, 8).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
 
-	h := NewBillHandler(mock)
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/bills?active=true", nil)
+	cfg := &config.Config{AuthUsername: "admin", AuthPasswordHash: "x", JWTSecret: "secret"}
+	h := NewAuthHandler(mock, cfg)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: auth.RefreshCookieName, Value: old})
 	rr := httptest.NewRecorder()
-	h.List(rr, req)
+	h.Refresh(rr, req)
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+		t.Fatalf("expected 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	cookies := map[string]string{}
+	for _, c := range rr.Result().Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	if cookies[auth.RefreshCookieName] == "" || cookies[auth.RefreshCookieName] == old {
+		t.Errorf("expected a new refresh token, got %q", cookies[auth.RefreshCookieName])
+	}
+	session, err := auth.ValidateToken(cfg.JWTSecret, cookies[auth.CookieName])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ID != 8 || session.Role != auth.RoleEditor {
+		t.Errorf("expected session 8 as editor, got %+v", session)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
 
-func TestBillList_NoFilter(t *testing.T) {
+func TestAuthRefresh_ReusedTokenRevokesEverySession(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer mock.Close()
 
-	rows := pgxmock.NewRows([]string{
-		"id", "name", "default_amount", "due_day", "recurrence",
-		"recurrence_detail", "is_autopay", "category", "notes",
-		"is_active", "sort_order", "created_at", "updated_at",
-		"cc_id", "cc_label", "cc_statement_day", "cc_due_day", "cc_issuer",
-	})
-	// Should NOT have WHERE is_active in query
-	mock.ExpectQuery("SELECT (.+) FROM bills").WillReturnRows(rows)
+	revokedAt := time.Now().Add(-time.Minute)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, username, expires_at, revoked_at FROM refresh_tokens").
+		WithArgs(auth.HashRefreshToken("stolen")).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "username", "expires_at", "revoked_at"}).
+			AddRow(7, "kid", time.Now().Add(time.Hour), &revokedAt))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = COALESCE").WithArgs("kid").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	h := NewAuthHandler(mock, &config.Config{AuthUsername: "admin", AuthPasswordHash: "x", JWTSecret: "secret"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: auth.RefreshCookieName, Value: "stolen"})
+	rr := httptest.NewRecorder()
+	h.Refresh(rr, req)
 
-	h := NewBillHandler(mock)
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/bills", nil)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rr.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAuthLogout_RevokesSession(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	cfg := &config.Config{AuthUsername: "admin", AuthPasswordHash: "x", JWTSecret: "secret"}
+	access, _, err := auth.CreateToken(cfg.JWTSecret, auth.Session{ID: 9, Username: "admin", Role: auth.RoleAdmin}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\) WHERE token_hash").
+		WithArgs(auth.HashRefreshToken("refresh")).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = NOW\\(\\), replaced_by = NULL WHERE id").
+		WithArgs(9).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	h := NewAuthHandler(mock, cfg)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: auth.RefreshCookieName, Value: "refresh"})
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: access})
 	rr := httptest.NewRecorder()
-	h.List(rr, req)
+	h.Logout(rr, req)
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200, got %d", rr.Code)
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	for _, c := range rr.Result().Cookies() {
+		if c.MaxAge >= 0 {
+			t.Errorf("expected cookie %s cleared", c.Name)
+		}
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
@@ -1697,3 +9870,15 @@ func assertErrorCode(t *testing.T, body []byte, expectedCode string) {
 func float64Ptr(f float64) *float64 {
 	return &f
 }
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -1,32 +1,83 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
 	"github.com/izz-linux/budget-mgmt/backend/internal/models"
 	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
 )
 
+// importFilePrefix names the stored uploads awaiting confirmation.
+const importFilePrefix = "budget-import-"
+
+// importSessionKey holds the pending XLSX import, kept in the upload store
+// beside its file so any replica can confirm or discard it.
+const importSessionKey = importFilePrefix + "session.json"
+
+type importSession struct {
+	File       string                  `json:"file"` // store key of the uploaded workbook
+	UploadedAt time.Time               `json:"uploaded_at"`
+	Preview    *services.ImportPreview `json:"preview"`
+}
+
 type ImportHandler struct {
-	db       DBTX
-	importer *services.XLSXImporter
-	// Store the last preview for confirmation
-	lastPreview *services.ImportPreview
-	lastFile    string
+	db          DBTX
+	importer    *services.XLSXImporter
+	csvImporter *services.CSVImporter
+	uploads     storage.Store
+	runner      *jobs.Runner // set by StartJobs; nil leaves queued jobs alone
+
+	// mu serializes this replica's uploads, confirms and sweeps of the
+	// pending import, and guards the fields below
+	mu  sync.Mutex
+	ttl time.Duration // 0 keeps a preview until confirmed or replaced
+
+	// Pending CSV statement preview, kept in memory only
+	csvPreview    *BankCSVResult
+	csvFilename   string
+	csvUploadedAt time.Time
 }
 
+// NewImportHandler keeps uploads in the OS temp dir until WithStorage says
+// otherwise.
 func NewImportHandler(db DBTX) *ImportHandler {
+	var uploads storage.Store = storage.NewMemory()
+	if local, err := storage.NewLocal(os.TempDir()); err == nil {
+		uploads = local
+	}
 	return &ImportHandler{
-		db:       db,
-		importer: services.NewXLSXImporter(),
+		db:          db,
+		importer:    services.NewXLSXImporter(),
+		csvImporter: services.NewCSVImporter(),
+		uploads:     uploads,
 	}
 }
 
+// WithStorage keeps pending uploads in s.
+func (h *ImportHandler) WithStorage(s storage.Store) *ImportHandler {
+	h.uploads = s
+	return h
+}
+
+// Upload stores a workbook and queues it to be parsed in the background,
+// answering 202 with the job to poll at /import/jobs/{id}. Once the job is
+// done its preview is the pending import, replacing any earlier one.
 func (h *ImportHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
 	// Max 10MB file
 	r.ParseMultipartForm(10 << 20)
 
@@ -37,52 +88,263 @@ func (h *ImportHandler) Upload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// Save to temp file
-	tmpDir := os.TempDir()
-	tmpPath := filepath.Join(tmpDir, "budget-import-"+header.Filename)
-	dst, err := os.Create(tmpPath)
+	data, err := io.ReadAll(file)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "FILE_ERROR", err.Error())
 		return
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
+	// Each upload gets its own key so a queued one isn't overwritten by the next
+	name := filepath.Base(header.Filename)
+	key := importFilePrefix + strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + name
+	if err := h.uploads.Put(ctx, key, data); err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "FILE_ERROR", err.Error())
 		return
 	}
-	dst.Close()
+	job, err := scanImportJob(h.db.QueryRow(ctx, `
+		INSERT INTO import_jobs (filename, file) VALUES ($1, $2)
+		RETURNING `+importJobCols, name, key))
+	if err != nil {
+		h.uploads.Delete(ctx, key)
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
 
-	// Parse the file
-	preview, err := h.importer.ParseFile(tmpPath)
+	h.kickJobs()
+	models.WriteJSON(w, http.StatusAccepted, job)
+}
+
+// loadSession returns the pending import, or nil if there is none.
+func (h *ImportHandler) loadSession(ctx context.Context) (*importSession, error) {
+	data, err := h.uploads.Get(ctx, importSessionKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s importSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("reading import session: %w", err)
+	}
+	return &s, nil
+}
+
+func (h *ImportHandler) saveSession(ctx context.Context, s importSession) error {
+	data, err := json.Marshal(s)
 	if err != nil {
-		os.Remove(tmpPath)
-		models.WriteError(w, http.StatusBadRequest, "PARSE_ERROR", err.Error())
+		return err
+	}
+	return h.uploads.Put(ctx, importSessionKey, data)
+}
+
+// takeSession claims the pending preview, so a concurrent confirm cannot import
+// it twice. It returns nil if there is none or it has expired.
+func (h *ImportHandler) takeSession(ctx context.Context) (*importSession, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, err := h.loadSession(ctx)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	if err := h.uploads.Delete(ctx, importSessionKey); err != nil {
+		return nil, err
+	}
+	if h.ttl > 0 && time.Since(s.UploadedAt) > h.ttl {
+		h.uploads.Delete(ctx, s.File)
+		return nil, nil
+	}
+	return s, nil
+}
+
+// restoreSession puts back a preview taken for a confirm that didn't go
+// ahead, unless another upload has become the pending import since.
+func (h *ImportHandler) restoreSession(ctx context.Context, s importSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	if cur, err := h.loadSession(ctx); err == nil && cur != nil {
+		h.uploads.Delete(ctx, s.File)
+		return
+	}
+	if err := h.saveSession(ctx, s); err != nil {
+		slog.Error("restoring import preview", "error", err)
+	}
+}
+
+// clearSession discards the pending preview and its file.
+func (h *ImportHandler) clearSession(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, err := h.loadSession(ctx)
+	if err != nil || s == nil {
+		return err
+	}
+	h.uploads.Delete(ctx, s.File)
+	return h.uploads.Delete(ctx, importSessionKey)
+}
+
+// sweep discards the pending preview if it is older than the TTL, along with
+// any leftover upload files (e.g. from before a restart).
+func (h *ImportHandler) sweep(ctx context.Context, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.ttl <= 0 {
+		return
+	}
+	if h.csvPreview != nil && now.Sub(h.csvUploadedAt) > h.ttl {
+		slog.Info("discarding expired csv import preview", "file", h.csvFilename)
+		h.csvPreview = nil
+		h.csvFilename = ""
+	}
+
+	s, err := h.loadSession(ctx)
+	if err != nil {
+		slog.Error("reading import session", "error", err)
+		return
+	}
+	current := ""
+	if s != nil && now.Sub(s.UploadedAt) > h.ttl {
+		slog.Info("discarding expired import preview", "file", s.File)
+		h.uploads.Delete(ctx, s.File)
+		h.uploads.Delete(ctx, importSessionKey)
+	} else if s != nil {
+		current = s.File
+	}
+
+	objects, err := h.uploads.List(ctx, importFilePrefix)
+	if err != nil {
+		slog.Error("listing import uploads", "error", err)
+		return
+	}
+	for _, o := range objects {
+		if o.Key == current || o.Key == importSessionKey {
+			continue
+		}
+		if now.Sub(o.Modified) > h.ttl {
+			h.uploads.Delete(ctx, o.Key)
+		}
+	}
+}
+
+// StartSweeper expires import previews older than ttl, checking every interval
+// until the runner shuts down.
+func (h *ImportHandler) StartSweeper(runner *jobs.Runner, ttl, interval time.Duration) {
+	h.mu.Lock()
+	h.ttl = ttl
+	h.mu.Unlock()
+	if ttl <= 0 {
 		return
 	}
 
-	h.lastPreview = preview
-	h.lastFile = tmpPath
+	runner.Every("import-sweeper", interval, func(ctx context.Context, now time.Time) {
+		h.sweep(ctx, now)
+	})
+}
 
-	models.WriteJSON(w, http.StatusOK, preview)
+// DeleteSession abandons the pending import preview.
+func (h *ImportHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	if err := h.clearSession(r.Context()); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "FILE_ERROR", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
+// Confirm creates the previewed bills. Bills whose names match existing
+// active bills need a resolution in the body: skip, merge into the existing
+// bill or create anyway. Without one for every duplicate it answers 409 with
+// the duplicates, and the preview stays pending so it can be confirmed again.
+// POST /api/v1/import/xlsx/confirm
 func (h *ImportHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	if h.lastPreview == nil {
-		models.WriteError(w, http.StatusBadRequest, "NO_PREVIEW", "no pending import to confirm. Upload a file first.")
+	var req models.ConfirmImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
 		return
 	}
+	resolutions := make(map[int]models.ImportResolution, len(req.Resolutions))
+	for _, res := range req.Resolutions {
+		switch res.Action {
+		case models.ImportSkip, models.ImportMerge, models.ImportCreate:
+		default:
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("row %d: action must be skip, merge or create", res.Row))
+			return
+		}
+		if _, dup := resolutions[res.Row]; dup {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("row %d is resolved more than once", res.Row))
+			return
+		}
+		resolutions[res.Row] = res
+	}
 
+	session, err := h.takeSession(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "FILE_ERROR", err.Error())
+		return
+	}
+	if session == nil {
+		models.WriteError(w, http.StatusBadRequest, "NO_PREVIEW", "no pending import to confirm. Upload a file first.")
+		return
+	}
+	// Until the import commits, any failure puts the preview back so the
+	// confirm can be retried; the upload goes only once the bills are in
+	committed := false
 	defer func() {
-		if h.lastFile != "" {
-			os.Remove(h.lastFile)
+		if !committed {
+			h.restoreSession(ctx, *session)
 		}
-		h.lastPreview = nil
-		h.lastFile = ""
 	}()
+	preview := session.Preview
 
+	// Bills may have been added since the upload was parsed, so duplicates
+	// are found again against what is active now
+	existing, err := loadActiveBillNames(ctx, h.db)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	active := make(map[int]bool, len(existing))
+	for _, e := range existing {
+		active[e.ID] = true
+	}
+	duplicates := services.FindDuplicates(preview.Bills, existing)
+	matched := make(map[int]int, len(duplicates))
+	for _, d := range duplicates {
+		matched[d.Row] = d.BillID
+	}
+	for _, res := range req.Resolutions {
+		msg := ""
+		switch {
+		case res.Row < 0 || res.Row >= len(preview.Bills):
+			msg = fmt.Sprintf("row %d is not in the preview", res.Row)
+		case res.Action == models.ImportMerge && res.BillID == nil && matched[res.Row] == 0:
+			msg = fmt.Sprintf("row %d: bill_id is required to merge a bill with no duplicate", res.Row)
+		case res.Action == models.ImportMerge && res.BillID != nil && !active[*res.BillID]:
+			msg = fmt.Sprintf("row %d: bill %d is not an active bill", res.Row, *res.BillID)
+		}
+		if msg != "" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", msg)
+			return
+		}
+	}
+	unresolved := []services.DuplicateMatch{}
+	for _, d := range duplicates {
+		if _, ok := resolutions[d.Row]; !ok {
+			unresolved = append(unresolved, d)
+		}
+	}
+	if len(unresolved) > 0 {
+		models.WriteErrorDetails(w, http.StatusConflict, "DUPLICATES_FOUND",
+			fmt.Sprintf("%d imported bills match existing ones; choose skip, merge or create for each", len(unresolved)),
+			map[string]interface{}{"duplicates": unresolved})
+		return
+	}
 	tx, err := h.db.Begin(ctx)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
@@ -90,8 +352,30 @@ func (h *ImportHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 	}
 	defer tx.Rollback(ctx)
 
-	imported := 0
-	for i, pb := range h.lastPreview.Bills {
+	imported, merged, skipped := 0, 0, 0
+	for i, pb := range preview.Bills {
+		res, ok := resolutions[i]
+		if ok && res.Action == models.ImportSkip {
+			skipped++
+			continue
+		}
+		if ok && res.Action == models.ImportMerge {
+			billID := matched[i]
+			if res.BillID != nil {
+				billID = *res.BillID
+			}
+			_, err := tx.Exec(ctx, `
+				UPDATE bills SET default_amount = COALESCE($2, default_amount), due_day = COALESCE($3, due_day), updated_at = NOW()
+				WHERE id = $1
+			`, billID, pb.DefaultAmt, pb.DueDay)
+			if err != nil {
+				models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+				return
+			}
+			merged++
+			continue
+		}
+
 		var billID int
 		recurrence := "monthly"
 
@@ -122,7 +406,7 @@ func (h *ImportHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 	_, err = tx.Exec(ctx, `
 		INSERT INTO import_history (filename, row_count, period_count, status)
 		VALUES ($1, $2, $3, 'completed')
-	`, filepath.Base(h.lastFile), imported, h.lastPreview.PeriodCount)
+	`, session.File, imported, preview.PeriodCount)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
@@ -132,14 +416,39 @@ func (h *ImportHandler) Confirm(w http.ResponseWriter, r *http.Request) {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
 	}
+	committed = true
+	if err := h.uploads.Delete(context.WithoutCancel(ctx), session.File); err != nil {
+		slog.Error("removing confirmed import upload", "file", session.File, "error", err)
+	}
 
 	models.WriteJSON(w, http.StatusOK, map[string]interface{}{
-		"imported_bills":   imported,
-		"period_count":     h.lastPreview.PeriodCount,
-		"status":           "completed",
+		"imported_bills": imported,
+		"merged_bills":   merged,
+		"skipped_bills":  skipped,
+		"period_count":   preview.PeriodCount,
+		"status":         "completed",
 	})
 }
 
+// loadActiveBillNames lists the active bills imports are checked against.
+func loadActiveBillNames(ctx context.Context, db DBTX) ([]services.ExistingBill, error) {
+	rows, err := db.Query(ctx, `SELECT id, name FROM bills WHERE is_active ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bills []services.ExistingBill
+	for rows.Next() {
+		var b services.ExistingBill
+		if err := rows.Scan(&b.ID, &b.Name); err != nil {
+			return nil, err
+		}
+		bills = append(bills, b)
+	}
+	return bills, rows.Err()
+}
+
 func (h *ImportHandler) History(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	rows, err := h.db.Query(ctx, `
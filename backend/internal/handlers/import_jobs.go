@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// importJobLease is how long a job may stay parsing before another
+	// worker assumes its replica died and claims it again.
+	importJobLease = 5 * time.Minute
+	// importJobMaxAttempts caps the claims of a job that keeps dying mid-parse.
+	importJobMaxAttempts = 3
+	// importProgressInterval spaces out progress writes while parsing.
+	importProgressInterval = 500 * time.Millisecond
+)
+
+const importJobCols = `id, filename, status, progress, attempts, warnings, preview, error,
+		          created_at, started_at, finished_at`
+
+func scanImportJob(row interface{ Scan(dest ...interface{}) error }) (models.ImportJob, error) {
+	var j models.ImportJob
+	var preview []byte
+	err := row.Scan(&j.ID, &j.Filename, &j.Status, &j.Progress, &j.Attempts, &j.Warnings, &preview, &j.Error,
+		&j.CreatedAt, &j.StartedAt, &j.FinishedAt)
+	if j.Warnings == nil {
+		j.Warnings = []string{}
+	}
+	if len(preview) > 0 {
+		j.Preview = preview
+	}
+	return j, err
+}
+
+// Job reports an upload's parsing progress and warnings, and its preview once
+// done.
+// GET /api/v1/import/jobs/{id}
+func (h *ImportHandler) Job(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+	job, err := scanImportJob(h.db.QueryRow(r.Context(), `
+		SELECT `+importJobCols+` FROM import_jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "import job not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, job)
+}
+
+// StartJobs parses queued uploads in the background: straight away when this
+// replica takes an upload, and every interval for ones queued elsewhere or
+// left behind by a replica that stopped mid-parse.
+func (h *ImportHandler) StartJobs(runner *jobs.Runner, interval time.Duration) {
+	h.mu.Lock()
+	h.runner = runner
+	h.mu.Unlock()
+
+	runner.Every("import-jobs", interval, func(ctx context.Context, now time.Time) {
+		h.processJobs(ctx)
+	})
+}
+
+// kickJobs starts a worker for a job just queued, if StartJobs was called.
+func (h *ImportHandler) kickJobs() {
+	h.mu.Lock()
+	runner := h.runner
+	h.mu.Unlock()
+	if runner != nil {
+		runner.Go("import-jobs", h.processJobs)
+	}
+}
+
+// processJobs parses queued jobs until there are none left to claim.
+func (h *ImportHandler) processJobs(ctx context.Context) {
+	for ctx.Err() == nil {
+		var id, attempts int
+		var file string
+		now := time.Now()
+		// Claiming a job stamps started_at, so concurrent workers skip it
+		// until its lease runs out
+		err := h.db.QueryRow(ctx, `
+			UPDATE import_jobs SET status = 'parsing', progress = 0, started_at = $1, attempts = attempts + 1
+			WHERE id = (
+				SELECT id FROM import_jobs
+				WHERE status = 'queued' OR (status = 'parsing' AND started_at < $2)
+				ORDER BY id
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, file, attempts
+		`, now, now.Add(-importJobLease)).Scan(&id, &file, &attempts)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			slog.Error("claiming import job", "error", err)
+			return
+		}
+		if err := h.runJob(ctx, id, file, attempts); err != nil {
+			// Left parsing, to be claimed again once the lease is up
+			slog.Error("running import job", "job", id, "error", err)
+			return
+		}
+	}
+}
+
+// runJob parses a claimed job's workbook and makes its preview the pending
+// import. A workbook that can't be parsed fails the job; an error returned
+// leaves it to be retried.
+func (h *ImportHandler) runJob(ctx context.Context, id int, file string, attempts int) error {
+	if attempts > importJobMaxAttempts {
+		h.uploads.Delete(ctx, file)
+		return h.failJob(ctx, id, fmt.Sprintf("gave up after %d attempts", importJobMaxAttempts))
+	}
+	data, err := h.uploads.Get(ctx, file)
+	if err != nil {
+		return h.failJob(ctx, id, "reading upload: "+err.Error())
+	}
+
+	lastWrite := time.Now()
+	preview, err := h.importer.ParseReaderProgress(bytes.NewReader(data), func(percent int) {
+		if time.Since(lastWrite) < importProgressInterval {
+			return
+		}
+		lastWrite = time.Now()
+		if _, err := h.db.Exec(ctx, `UPDATE import_jobs SET progress = $2 WHERE id = $1`, id, percent); err != nil {
+			slog.Warn("recording import progress", "job", id, "error", err)
+		}
+	})
+	if err != nil {
+		h.uploads.Delete(ctx, file)
+		return h.failJob(ctx, id, err.Error())
+	}
+
+	// Categories the user corrected before take precedence over keyword guesses
+	learned, err := loadLearnedCategories(ctx, h.db)
+	if err != nil {
+		return err
+	}
+	services.ApplyLearnedCategories(preview, learned)
+	existing, err := loadActiveBillNames(ctx, h.db)
+	if err != nil {
+		return err
+	}
+	preview.Duplicates = services.FindDuplicates(preview.Bills, existing)
+	previewJSON, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+
+	if err := h.replaceSession(ctx, importSession{File: file, UploadedAt: time.Now(), Preview: preview}); err != nil {
+		return err
+	}
+	_, err = h.db.Exec(ctx, `
+		UPDATE import_jobs SET status = 'done', progress = 100, warnings = $2, preview = $3, finished_at = NOW()
+		WHERE id = $1
+	`, id, preview.Warnings, previewJSON)
+	return err
+}
+
+// replaceSession makes s the pending import, dropping the previous one's file.
+func (h *ImportHandler) replaceSession(ctx context.Context, s importSession) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if old, err := h.loadSession(ctx); err == nil && old != nil && old.File != s.File {
+		h.uploads.Delete(ctx, old.File)
+	}
+	return h.saveSession(ctx, s)
+}
+
+func (h *ImportHandler) failJob(ctx context.Context, id int, msg string) error {
+	_, err := h.db.Exec(ctx, `
+		UPDATE import_jobs SET status = 'failed', error = $2, finished_at = NOW()
+		WHERE id = $1
+	`, id, msg)
+	return err
+}
@@ -1,38 +1,71 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
 )
 
 type IncomeHandler struct {
-	db DBTX
+	db        DBTX
+	generator *services.PeriodGenerator
 }
 
 func NewIncomeHandler(db DBTX) *IncomeHandler {
-	return &IncomeHandler{db: db}
+	return &IncomeHandler{
+		db:        db,
+		generator: services.NewPeriodGenerator(),
+	}
+}
+
+// incomeSortFields are the fields Income List accepts in ?sort=.
+var incomeSortFields = map[string]string{
+	"name":           "name",
+	"pay_schedule":   "pay_schedule",
+	"default_amount": "default_amount",
+	"effective_from": "effective_from",
+	"created_at":     "created_at",
 }
 
+// List returns income sources, optionally filtered by ?active=true and
+// ?pay_schedule=, sorted and paged per parseListParams.
 func (h *IncomeHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	activeOnly := r.URL.Query().Get("active") == "true"
+	q := r.URL.Query()
+
+	params, ok := parseListParams(w, r, incomeSortFields, "name, id")
+	if !ok {
+		return
+	}
 
 	query := `
 		SELECT id, name, pay_schedule, schedule_detail, default_amount,
-		       is_active, effective_from, created_at, updated_at
+		       is_active, effective_from, owner, created_at, updated_at, COUNT(*) OVER ()
 		FROM income_sources
 	`
-	if activeOnly {
-		query += " WHERE is_active = true"
+	var where []string
+	args := []interface{}{}
+
+	if q.Get("active") == "true" {
+		where = append(where, "is_active = true")
+	}
+	if schedule := q.Get("pay_schedule"); schedule != "" {
+		args = append(args, schedule)
+		where = append(where, "pay_schedule = $"+strconv.Itoa(len(args)))
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
 	}
-	query += " ORDER BY name"
 
-	rows, err := h.db.Query(ctx, query)
+	pageQuery, pageArgs := params.apply(query, args)
+	rows, err := h.db.Query(ctx, pageQuery, pageArgs...)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
@@ -40,21 +73,29 @@ func (h *IncomeHandler) List(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	var sources []models.IncomeSource
+	var total int
 	for rows.Next() {
 		var s models.IncomeSource
 		err := rows.Scan(&s.ID, &s.Name, &s.PaySchedule, &s.ScheduleDetail,
-			&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.CreatedAt, &s.UpdatedAt)
+			&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.Owner, &s.CreatedAt, &s.UpdatedAt, &total)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
 			return
 		}
 		sources = append(sources, s)
 	}
+	rows.Close()
+
+	total, err = listTotal(ctx, h.db, params, query, args, total, len(sources))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
 
 	if sources == nil {
 		sources = []models.IncomeSource{}
 	}
-	models.WriteJSON(w, http.StatusOK, sources)
+	models.WriteJSONList(w, sources, total, params.Limit, params.Offset)
 }
 
 func (h *IncomeHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -68,10 +109,10 @@ func (h *IncomeHandler) Get(w http.ResponseWriter, r *http.Request) {
 	var s models.IncomeSource
 	err = h.db.QueryRow(ctx, `
 		SELECT id, name, pay_schedule, schedule_detail, default_amount,
-		       is_active, effective_from, created_at, updated_at
+		       is_active, effective_from, owner, created_at, updated_at
 		FROM income_sources WHERE id = $1
 	`, id).Scan(&s.ID, &s.Name, &s.PaySchedule, &s.ScheduleDetail,
-		&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.CreatedAt, &s.UpdatedAt)
+		&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.Owner, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income source not found")
 		return
@@ -92,32 +133,23 @@ func (h *IncomeHandler) Create(w http.ResponseWriter, r *http.Request) {
 		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name is required")
 		return
 	}
-	validSchedules := map[string]bool{"weekly": true, "biweekly": true, "semimonthly": true, "one_time": true}
-	if !validSchedules[req.PaySchedule] {
-		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "pay_schedule must be weekly, biweekly, semimonthly, or one_time")
+	if !validPaySchedules[req.PaySchedule] {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "pay_schedule must be weekly, biweekly, semimonthly, monthly, one_time, or custom")
+		return
+	}
+	if err := h.generator.Validate(models.IncomeSource{PaySchedule: req.PaySchedule, ScheduleDetail: req.ScheduleDetail}); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_SCHEDULE", err.Error())
 		return
 	}
 
 	// Parse effective_from if provided
-	var effectiveFrom *time.Time
-	if req.EffectiveFrom != nil && *req.EffectiveFrom != "" {
-		parsed, err := time.ParseInLocation("2006-01-02", *req.EffectiveFrom, time.Local)
-		if err != nil {
-			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "effective_from must be in YYYY-MM-DD format")
-			return
-		}
-		effectiveFrom = &parsed
+	effectiveFrom, err := parseEffectiveFrom(req.EffectiveFrom)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "effective_from must be in YYYY-MM-DD format")
+		return
 	}
 
-	var s models.IncomeSource
-	err := h.db.QueryRow(ctx, `
-		INSERT INTO income_sources (name, pay_schedule, schedule_detail, default_amount, effective_from)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, name, pay_schedule, schedule_detail, default_amount,
-		          is_active, effective_from, created_at, updated_at
-	`, req.Name, req.PaySchedule, req.ScheduleDetail, req.DefaultAmount, effectiveFrom,
-	).Scan(&s.ID, &s.Name, &s.PaySchedule, &s.ScheduleDetail,
-		&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.CreatedAt, &s.UpdatedAt)
+	s, err := insertIncomeSource(ctx, h.db, req, effectiveFrom)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
@@ -126,6 +158,33 @@ func (h *IncomeHandler) Create(w http.ResponseWriter, r *http.Request) {
 	models.WriteJSON(w, http.StatusCreated, s)
 }
 
+var validPaySchedules = map[string]bool{"weekly": true, "biweekly": true, "semimonthly": true, "monthly": true, "one_time": true, "custom": true}
+
+// parseEffectiveFrom parses an optional YYYY-MM-DD effective_from; nil or "" means none.
+func parseEffectiveFrom(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", *value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func insertIncomeSource(ctx context.Context, db DBTX, req models.CreateIncomeSourceRequest, effectiveFrom *time.Time) (models.IncomeSource, error) {
+	var s models.IncomeSource
+	err := db.QueryRow(ctx, `
+		INSERT INTO income_sources (name, pay_schedule, schedule_detail, default_amount, effective_from, owner)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, pay_schedule, schedule_detail, default_amount,
+		          is_active, effective_from, owner, created_at, updated_at
+	`, req.Name, req.PaySchedule, req.ScheduleDetail, req.DefaultAmount, effectiveFrom, req.Owner,
+	).Scan(&s.ID, &s.Name, &s.PaySchedule, &s.ScheduleDetail,
+		&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.Owner, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
 func (h *IncomeHandler) Update(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
@@ -140,6 +199,27 @@ func (h *IncomeHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate the resulting schedule when either half of it changes
+	if req.PaySchedule != nil || req.ScheduleDetail != nil {
+		var current models.IncomeSource
+		err := h.db.QueryRow(ctx, `SELECT pay_schedule, schedule_detail FROM income_sources WHERE id = $1`, id).
+			Scan(&current.PaySchedule, &current.ScheduleDetail)
+		if err != nil {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income source not found")
+			return
+		}
+		if req.PaySchedule != nil {
+			current.PaySchedule = *req.PaySchedule
+		}
+		if req.ScheduleDetail != nil {
+			current.ScheduleDetail = req.ScheduleDetail
+		}
+		if err := h.generator.Validate(current); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_SCHEDULE", err.Error())
+			return
+		}
+	}
+
 	// Build dynamic update to avoid COALESCE issues with intentional NULLs
 	setClauses := []string{}
 	args := []interface{}{id}
@@ -170,6 +250,11 @@ func (h *IncomeHandler) Update(w http.ResponseWriter, r *http.Request) {
 		args = append(args, *req.IsActive)
 		argIdx++
 	}
+	if req.Owner != nil {
+		setClauses = append(setClauses, "owner = $"+strconv.Itoa(argIdx))
+		args = append(args, *req.Owner)
+		argIdx++
+	}
 	if req.EffectiveFrom != nil {
 		if *req.EffectiveFrom == "" {
 			// Allow clearing effective_from by passing empty string
@@ -197,11 +282,11 @@ func (h *IncomeHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 	query += `, updated_at = NOW() WHERE id = $1
 		RETURNING id, name, pay_schedule, schedule_detail, default_amount,
-		          is_active, effective_from, created_at, updated_at`
+		          is_active, effective_from, owner, created_at, updated_at`
 
 	var s models.IncomeSource
 	err = h.db.QueryRow(ctx, query, args...).Scan(&s.ID, &s.Name, &s.PaySchedule, &s.ScheduleDetail,
-		&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.CreatedAt, &s.UpdatedAt)
+		&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.Owner, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income source not found")
 		return
@@ -210,6 +295,47 @@ func (h *IncomeHandler) Update(w http.ResponseWriter, r *http.Request) {
 	models.WriteJSON(w, http.StatusOK, s)
 }
 
+// Templates lists the built-in income source templates.
+func (h *IncomeHandler) Templates(w http.ResponseWriter, r *http.Request) {
+	models.WriteJSON(w, http.StatusOK, models.IncomeSourceTemplates)
+}
+
+// Duplicate copies an income source's schedule and amount into a new source,
+// so a similar job can be set up by cloning and editing.
+func (h *IncomeHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	// The body is optional; an empty one keeps the default name
+	var req models.DuplicateIncomeSourceRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+			return
+		}
+	}
+
+	var s models.IncomeSource
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO income_sources (name, pay_schedule, schedule_detail, default_amount, effective_from, owner)
+		SELECT COALESCE(NULLIF($2, ''), name || ' (copy)'), pay_schedule, schedule_detail, default_amount, effective_from, owner
+		FROM income_sources WHERE id = $1
+		RETURNING id, name, pay_schedule, schedule_detail, default_amount,
+		          is_active, effective_from, owner, created_at, updated_at
+	`, id, req.Name).Scan(&s.ID, &s.Name, &s.PaySchedule, &s.ScheduleDetail,
+		&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.Owner, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income source not found")
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, s)
+}
+
 func (h *IncomeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
@@ -218,6 +344,20 @@ func (h *IncomeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Keep the lifetime totals for Summary before the periods go. A source
+	// with no periods left (deleted before) keeps its earlier snapshot
+	_, err = h.db.Exec(ctx, `
+		UPDATE income_sources SET archived_totals = (
+			SELECT `+incomeTotalsExpr+` || jsonb_build_object('archived_at', NOW())
+			FROM pay_periods WHERE income_source_id = $1
+		)
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM pay_periods WHERE income_source_id = $1)
+	`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
 	// Cascade: delete bill_assignments tied to this source's pay periods
 	_, err = h.db.Exec(ctx, `
 		DELETE FROM bill_assignments
@@ -248,3 +388,58 @@ func (h *IncomeHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// incomeTotalsExpr aggregates pay_periods rows into models.IncomeSourceTotals.
+const incomeTotalsExpr = `jsonb_build_object(
+	'periods_generated', COUNT(*),
+	'periods_received', COUNT(actual_amount),
+	'first_pay_date', MIN(pay_date),
+	'last_pay_date', MAX(pay_date),
+	'total_expected', COALESCE(SUM(expected_amount), 0),
+	'total_actual', COALESCE(SUM(actual_amount), 0),
+	'expected_received', COALESCE(SUM(expected_amount) FILTER (WHERE actual_amount IS NOT NULL), 0)
+)`
+
+// Summary reports an income source's lifetime totals, including after it
+// has been deactivated or deleted.
+// GET /api/v1/income-sources/{id}/summary
+func (h *IncomeHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	summary := models.IncomeSourceSummary{IncomeSourceID: id}
+	var archived []byte
+	err = h.db.QueryRow(ctx, `
+		SELECT name, pay_schedule, is_active, archived_totals FROM income_sources WHERE id = $1
+	`, id).Scan(&summary.Name, &summary.PaySchedule, &summary.IsActive, &archived)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income source not found")
+		return
+	}
+
+	var live []byte
+	err = h.db.QueryRow(ctx, `SELECT `+incomeTotalsExpr+` FROM pay_periods WHERE income_source_id = $1`, id).Scan(&live)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if err := json.Unmarshal(live, &summary.IncomeSourceTotals); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+		return
+	}
+
+	if summary.PeriodsGenerated == 0 && archived != nil {
+		if err := json.Unmarshal(archived, &summary.IncomeSourceTotals); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		summary.Archived = true
+	}
+	summary.Difference = summary.TotalActual - summary.ExpectedReceived
+
+	models.WriteJSON(w, http.StatusOK, summary)
+}
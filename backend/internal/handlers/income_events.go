@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IncomeEventHandler struct {
+	db DBTX
+}
+
+func NewIncomeEventHandler(db DBTX) *IncomeEventHandler {
+	return &IncomeEventHandler{db: db}
+}
+
+const incomeEventReturnCols = `id, name, amount, event_date, income_source_id, pay_period_id, notes, created_at, updated_at`
+
+func scanIncomeEvent(scanner interface{ Scan(dest ...interface{}) error }, e *models.IncomeEvent) error {
+	var date time.Time
+	if err := scanner.Scan(&e.ID, &e.Name, &e.Amount, &date, &e.IncomeSourceID, &e.PayPeriodID, &e.Notes, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return err
+	}
+	e.EventDate = date.Format("2006-01-02")
+	return nil
+}
+
+// attachIncomeEvents points each income event dated from..to at the paycheck
+// it lands after: the latest pay period on or before its date from an active
+// source, or from its own source when it names one. Events with no such
+// period are detached.
+func attachIncomeEvents(ctx context.Context, db DBTX, from, to time.Time) error {
+	_, err := db.Exec(ctx, `
+		UPDATE income_events e SET pay_period_id = (
+			SELECT pp.id FROM pay_periods pp
+			JOIN income_sources inc ON inc.id = pp.income_source_id
+			WHERE inc.is_active AND pp.pay_date <= e.event_date
+			  AND (e.income_source_id IS NULL OR pp.income_source_id = e.income_source_id)
+			ORDER BY pp.pay_date DESC, pp.id
+			LIMIT 1
+		)
+		WHERE e.event_date BETWEEN $1 AND $2
+	`, from, to)
+	return err
+}
+
+// loadExtraIncome sets ExtraIncome on each period to the total of the income
+// events attached to it.
+func loadExtraIncome(ctx context.Context, db DBTX, periods []models.PayPeriod) error {
+	if len(periods) == 0 {
+		return nil
+	}
+	ids := make([]int, len(periods))
+	for i, p := range periods {
+		ids[i] = p.ID
+	}
+	rows, err := db.Query(ctx, `
+		SELECT pay_period_id, SUM(amount) FROM income_events
+		WHERE pay_period_id = ANY($1)
+		GROUP BY pay_period_id
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	extra := make(map[int]float64)
+	for rows.Next() {
+		var id int
+		var amount float64
+		if err := rows.Scan(&id, &amount); err != nil {
+			return err
+		}
+		extra[id] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range periods {
+		periods[i].ExtraIncome = extra[periods[i].ID]
+	}
+	return nil
+}
+
+// writeIncomeEventSaveError maps errors from saving an event, which may name
+// an income source.
+func writeIncomeEventSaveError(w http.ResponseWriter, err error) {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income event not found")
+	case errors.As(err, &pgErr) && pgErr.Code == "23503":
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "income_source_id does not exist")
+	default:
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+	}
+}
+
+// saveIncomeEvent runs an insert or update returning one event, then attaches
+// it to its paycheck, all in one transaction.
+func (h *IncomeEventHandler) saveIncomeEvent(ctx context.Context, query string, args ...any) (models.IncomeEvent, error) {
+	var e models.IncomeEvent
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return e, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := scanIncomeEvent(tx.QueryRow(ctx, query, args...), &e); err != nil {
+		return e, err
+	}
+	date, _ := time.Parse("2006-01-02", e.EventDate)
+	if err := attachIncomeEvents(ctx, tx, date, date); err != nil {
+		return e, err
+	}
+	if err := tx.QueryRow(ctx, `SELECT pay_period_id FROM income_events WHERE id = $1`, e.ID).Scan(&e.PayPeriodID); err != nil {
+		return e, err
+	}
+	return e, tx.Commit(ctx)
+}
+
+// List returns income events between ?from and ?to (both optional), by date.
+// GET /api/v1/income-events
+func (h *IncomeEventHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	from, ok := dateQueryParam(w, r, "from", time.Time{})
+	if !ok {
+		return
+	}
+	to, ok := dateQueryParam(w, r, "to", time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC))
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT `+incomeEventReturnCols+` FROM income_events
+		WHERE event_date BETWEEN $1 AND $2
+		ORDER BY event_date, id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	events := []models.IncomeEvent{}
+	for rows.Next() {
+		var e models.IncomeEvent
+		if err := scanIncomeEvent(rows, &e); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		events = append(events, e)
+	}
+
+	models.WriteJSON(w, http.StatusOK, events)
+}
+
+// Get returns one income event.
+// GET /api/v1/income-events/{id}
+func (h *IncomeEventHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var e models.IncomeEvent
+	err = scanIncomeEvent(h.db.QueryRow(r.Context(), `
+		SELECT `+incomeEventReturnCols+` FROM income_events WHERE id = $1
+	`, id), &e)
+	if err != nil {
+		writeIncomeEventSaveError(w, err)
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, e)
+}
+
+// Create records an income event and attaches it to its paycheck.
+// POST /api/v1/income-events
+func (h *IncomeEventHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateIncomeEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name is required")
+		return
+	}
+	if req.Amount <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "amount must be positive")
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.EventDate)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "event_date must be in YYYY-MM-DD format")
+		return
+	}
+
+	e, err := h.saveIncomeEvent(r.Context(), `
+		INSERT INTO income_events (name, amount, event_date, income_source_id, notes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+incomeEventReturnCols,
+		req.Name, req.Amount, date, req.IncomeSourceID, req.Notes)
+	if err != nil {
+		writeIncomeEventSaveError(w, err)
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, e)
+}
+
+// Update changes an income event; omitted fields are left alone. A new date
+// or source moves it to the matching paycheck.
+// PUT /api/v1/income-events/{id}
+func (h *IncomeEventHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateIncomeEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name must not be empty")
+			return
+		}
+		req.Name = &trimmed
+	}
+	if req.Amount != nil && *req.Amount <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "amount must be positive")
+		return
+	}
+	var date *time.Time
+	if req.EventDate != nil {
+		d, err := time.Parse("2006-01-02", *req.EventDate)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "event_date must be in YYYY-MM-DD format")
+			return
+		}
+		date = &d
+	}
+
+	e, err := h.saveIncomeEvent(r.Context(), `
+		UPDATE income_events SET
+			name = COALESCE($2, name),
+			amount = COALESCE($3, amount),
+			event_date = COALESCE($4, event_date),
+			income_source_id = COALESCE($5, income_source_id),
+			notes = COALESCE($6, notes),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+incomeEventReturnCols,
+		id, req.Name, req.Amount, date, req.IncomeSourceID, req.Notes)
+	if err != nil {
+		writeIncomeEventSaveError(w, err)
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, e)
+}
+
+// Delete removes an income event.
+// DELETE /api/v1/income-events/{id}
+func (h *IncomeEventHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `DELETE FROM income_events WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income event not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
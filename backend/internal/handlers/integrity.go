@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// integrityCheck is one consistency rule. query returns the id and a
+// description of each row breaking it.
+type integrityCheck struct {
+	name  string
+	table string
+	fix   string
+	query string
+}
+
+var integrityChecks = []integrityCheck{
+	{
+		name:  "orphaned_assignment",
+		table: "bill_assignments",
+		fix:   "delete the assignment",
+		query: `
+			SELECT ba.id, CASE WHEN b.id IS NULL THEN 'bill ' || ba.bill_id || ' no longer exists'
+			                   ELSE 'pay period ' || ba.pay_period_id || ' no longer exists' END
+			FROM bill_assignments ba
+			LEFT JOIN bills b ON b.id = ba.bill_id
+			LEFT JOIN pay_periods pp ON pp.id = ba.pay_period_id
+			WHERE b.id IS NULL OR pp.id IS NULL
+			ORDER BY ba.id`,
+	},
+	{
+		name:  "inactive_source_period",
+		table: "pay_periods",
+		fix:   "delete the period, or reactivate its income source",
+		query: `
+			SELECT pp.id, inc.name || ' is inactive but still has a paycheck on ' || pp.pay_date ||
+			       ' with ' || (SELECT COUNT(*) FROM bill_assignments ba WHERE ba.pay_period_id = pp.id) || ' assignments'
+			FROM pay_periods pp
+			JOIN income_sources inc ON inc.id = pp.income_source_id
+			WHERE NOT inc.is_active AND pp.pay_date >= CURRENT_DATE
+			ORDER BY pp.pay_date, pp.id`,
+	},
+	{
+		name:  "broken_deferral",
+		table: "bill_assignments",
+		fix:   "set the assignment's deferred period again, or mark it pending",
+		query: `
+			SELECT ba.id, CASE WHEN ba.deferred_to_id IS NULL THEN 'deferred without a target period'
+			                   ELSE 'deferred to pay period ' || ba.deferred_to_id || ', which no longer exists' END
+			FROM bill_assignments ba
+			LEFT JOIN pay_periods pp ON pp.id = ba.deferred_to_id
+			WHERE ba.status = 'deferred' AND (ba.deferred_to_id IS NULL OR pp.id IS NULL)
+			ORDER BY ba.id`,
+	},
+	{
+		name:  "broken_sinking_fund",
+		table: "bill_assignments",
+		fix:   "delete the installment and recreate the sinking fund",
+		query: `
+			SELECT ba.id, 'sinking fund installment whose target period was deleted'
+			FROM bill_assignments ba
+			LEFT JOIN pay_periods pp ON pp.id = ba.sinking_fund_for_period_id
+			WHERE ba.is_sinking_fund AND pp.id IS NULL
+			ORDER BY ba.id`,
+	},
+	{
+		name:  "negative_amount",
+		table: "bills",
+		fix:   "edit the bill's default amount",
+		query: `
+			SELECT id, name || ' has a default amount of ' || default_amount
+			FROM bills WHERE default_amount < 0
+			ORDER BY id`,
+	},
+	{
+		name:  "negative_amount",
+		table: "bill_assignments",
+		fix:   "edit the assignment's amounts",
+		query: `
+			SELECT id, concat_ws(', ',
+			           CASE WHEN planned_amount < 0 THEN 'planned ' || planned_amount END,
+			           CASE WHEN forecast_amount < 0 THEN 'forecast ' || forecast_amount END,
+			           CASE WHEN actual_amount < 0 THEN 'actual ' || actual_amount END)
+			FROM bill_assignments
+			WHERE planned_amount < 0 OR forecast_amount < 0 OR actual_amount < 0
+			ORDER BY id`,
+	},
+	{
+		name:  "negative_amount",
+		table: "pay_periods",
+		fix:   "edit the pay period's amounts",
+		query: `
+			SELECT id, concat_ws(', ',
+			           CASE WHEN expected_amount < 0 THEN 'expected ' || expected_amount END,
+			           CASE WHEN actual_amount < 0 THEN 'actual ' || actual_amount END)
+			FROM pay_periods
+			WHERE expected_amount < 0 OR actual_amount < 0
+			ORDER BY id`,
+	},
+}
+
+// IntegrityIssue is one row breaking an integrity check, with how to fix it.
+type IntegrityIssue struct {
+	Check  string `json:"check"`
+	Table  string `json:"table"`
+	ID     int    `json:"id"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix"`
+}
+
+type IntegrityReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Counts    map[string]int   `json:"counts"` // issues per check, including checks that passed
+	Issues    []IntegrityIssue `json:"issues"`
+}
+
+// Integrity audits the data for problems the schema doesn't prevent or that
+// slipped in around it, such as through restores or manual SQL: assignments
+// whose bill or period is gone, upcoming paychecks of inactive income
+// sources, deferrals and sinking fund installments pointing at deleted
+// periods, and negative amounts. It only reports; nothing is changed.
+// GET /api/v1/admin/integrity
+func (h *AdminHandler) Integrity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	report := IntegrityReport{
+		CheckedAt: time.Now().UTC(),
+		Counts:    map[string]int{},
+		Issues:    []IntegrityIssue{},
+	}
+	for _, c := range integrityChecks {
+		if _, ok := report.Counts[c.name]; !ok {
+			report.Counts[c.name] = 0
+		}
+		rows, err := h.db.Query(ctx, c.query)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		for rows.Next() {
+			issue := IntegrityIssue{Check: c.name, Table: c.table, Fix: c.fix}
+			if err := rows.Scan(&issue.ID, &issue.Detail); err != nil {
+				rows.Close()
+				models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+				return
+			}
+			report.Issues = append(report.Issues, issue)
+			report.Counts[c.name]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
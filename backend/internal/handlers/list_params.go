@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// maxListLimit bounds the page size a List endpoint will return.
+const maxListLimit = 500
+
+// listParams is the paging and ordering requested on a List endpoint:
+// ?limit=&offset= and ?sort=field or ?sort=-field for descending.
+type listParams struct {
+	Limit   int // 0 returns every row
+	Offset  int
+	OrderBy string // SQL ORDER BY expression, without the keyword
+}
+
+// parseListParams reads limit, offset and sort. sortFields maps the public
+// field names to SQL expressions; defaultOrder is used when no sort is given
+// and as the tie-breaker when one is, so pages stay stable.
+func parseListParams(w http.ResponseWriter, r *http.Request, sortFields map[string]string, defaultOrder string) (listParams, bool) {
+	q := r.URL.Query()
+	p := listParams{OrderBy: defaultOrder}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "limit must be between 1 and "+strconv.Itoa(maxListLimit))
+			return p, false
+		}
+		p.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "offset must be a non-negative integer")
+			return p, false
+		}
+		p.Offset = n
+	}
+
+	if v := q.Get("sort"); v != "" {
+		var terms []string
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			dir := "ASC"
+			if strings.HasPrefix(field, "-") {
+				field, dir = field[1:], "DESC"
+			}
+			expr, ok := sortFields[field]
+			if !ok {
+				models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "sort must be one of: "+sortFieldNames(sortFields))
+				return p, false
+			}
+			terms = append(terms, expr+" "+dir+" NULLS LAST")
+		}
+		p.OrderBy = strings.Join(terms, ", ") + ", " + defaultOrder
+	}
+	return p, true
+}
+
+func sortFieldNames(sortFields map[string]string) string {
+	names := make([]string, 0, len(sortFields))
+	for name := range sortFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// apply appends ORDER BY, LIMIT and OFFSET to query, numbering the new
+// placeholders after args.
+func (p listParams) apply(query string, args []interface{}) (string, []interface{}) {
+	query += " ORDER BY " + p.OrderBy
+	if p.Limit > 0 {
+		args = append(args, p.Limit)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+	}
+	if p.Offset > 0 {
+		args = append(args, p.Offset)
+		query += " OFFSET $" + strconv.Itoa(len(args))
+	}
+	return query, args
+}
+
+// listTotal returns the number of rows query matches before paging. List
+// queries select COUNT(*) OVER () so total is known whenever the page has
+// rows; an empty page past the end needs its own count.
+func listTotal(ctx context.Context, db DBTX, p listParams, query string, args []interface{}, total, pageLen int) (int, error) {
+	if pageLen > 0 || p.Offset == 0 {
+		return total, nil
+	}
+	err := db.QueryRow(ctx, "SELECT COUNT(*) FROM ("+query+") AS counted", args...).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultNotificationUser owns the preferences when authentication is disabled.
+const defaultNotificationUser = "default"
+
+// NotificationHandler manages email reminder preferences and sends the daily
+// reminder digests.
+type NotificationHandler struct {
+	db     DBTX
+	mailer services.Mailer // nil when SMTP is not configured
+}
+
+func NewNotificationHandler(db DBTX, mailer services.Mailer) *NotificationHandler {
+	return &NotificationHandler{db: db, mailer: mailer}
+}
+
+func notificationUser(ctx context.Context) string {
+	if name := auth.Username(ctx); name != "" {
+		return name
+	}
+	return defaultNotificationUser
+}
+
+func defaultNotificationPreferences(username string) models.NotificationPreferences {
+	return models.NotificationPreferences{Username: username, DaysAhead: 3, IncludeOverdue: true, SendHour: 8}
+}
+
+func loadNotificationPreferences(ctx context.Context, db DBTX, username string) (models.NotificationPreferences, error) {
+	p := defaultNotificationPreferences(username)
+	var lastSent *time.Time
+	var updatedAt time.Time
+	err := db.QueryRow(ctx, `
+		SELECT email, enabled, days_ahead, include_overdue, send_hour, last_sent_on, updated_at
+		FROM notification_preferences WHERE username = $1
+	`, username).Scan(&p.Email, &p.Enabled, &p.DaysAhead, &p.IncludeOverdue, &p.SendHour, &lastSent, &updatedAt)
+	if err == nil {
+		p.UpdatedAt = &updatedAt
+		if lastSent != nil {
+			s := lastSent.Format("2006-01-02")
+			p.LastSentOn = &s
+		}
+	}
+	return p, err
+}
+
+// Preferences returns the current user's reminder settings, or the defaults
+// if they have never saved any.
+// GET /api/v1/notifications/preferences
+func (h *NotificationHandler) Preferences(w http.ResponseWriter, r *http.Request) {
+	username := notificationUser(r.Context())
+	p, err := loadNotificationPreferences(r.Context(), h.db, username)
+	if err != nil && err != pgx.ErrNoRows {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, p)
+}
+
+// UpdatePreferences changes the current user's reminder settings.
+// PUT /api/v1/notifications/preferences
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := notificationUser(ctx)
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	p, err := loadNotificationPreferences(ctx, h.db, username)
+	if err != nil && err != pgx.ErrNoRows {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if req.Email != nil {
+		p.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.Enabled != nil {
+		p.Enabled = *req.Enabled
+	}
+	if req.DaysAhead != nil {
+		p.DaysAhead = *req.DaysAhead
+	}
+	if req.IncludeOverdue != nil {
+		p.IncludeOverdue = *req.IncludeOverdue
+	}
+	if req.SendHour != nil {
+		p.SendHour = *req.SendHour
+	}
+
+	if p.Email != "" && (strings.ContainsAny(p.Email, " \r\n") || !strings.Contains(p.Email, "@")) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "email is not a valid address")
+		return
+	}
+	if p.Enabled && p.Email == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "email is required to enable reminders")
+		return
+	}
+	if p.DaysAhead < 1 || p.DaysAhead > 31 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "days_ahead must be between 1 and 31")
+		return
+	}
+	if p.SendHour < 0 || p.SendHour > 23 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "send_hour must be between 0 and 23")
+		return
+	}
+
+	var updatedAt time.Time
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO notification_preferences (username, email, enabled, days_ahead, include_overdue, send_hour)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (username) DO UPDATE SET
+			email = EXCLUDED.email,
+			enabled = EXCLUDED.enabled,
+			days_ahead = EXCLUDED.days_ahead,
+			include_overdue = EXCLUDED.include_overdue,
+			send_hour = EXCLUDED.send_hour,
+			updated_at = NOW()
+		RETURNING updated_at
+	`, username, p.Email, p.Enabled, p.DaysAhead, p.IncludeOverdue, p.SendHour).Scan(&updatedAt)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	p.UpdatedAt = &updatedAt
+
+	models.WriteJSON(w, http.StatusOK, p)
+}
+
+// SendTest emails the current user's digest now, even if reminders are off
+// or there is nothing due, so SMTP settings can be checked.
+// POST /api/v1/notifications/test
+func (h *NotificationHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.mailer == nil {
+		models.WriteError(w, http.StatusServiceUnavailable, "NOT_CONFIGURED", "set SMTP_HOST and SMTP_FROM to send email")
+		return
+	}
+
+	p, err := loadNotificationPreferences(ctx, h.db, notificationUser(ctx))
+	if err != nil && err != pgx.ErrNoRows {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if p.Email == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "save an email address first")
+		return
+	}
+
+	digest, err := loadReminderDigest(ctx, h.db, time.Now(), p.DaysAhead, p.IncludeOverdue)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	subject, body := services.FormatDigest(digest)
+	if err := h.mailer.Send(p.Email, subject, body); err != nil {
+		models.WriteError(w, http.StatusBadGateway, "SMTP_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, map[string]any{
+		"sent_to":  p.Email,
+		"upcoming": len(digest.Upcoming),
+		"overdue":  len(digest.Overdue),
+		"expiring": len(digest.Expiring),
+	})
+}
+
+// loadReminderDigest collects bills due from now through daysAhead days out,
+// and, with includeOverdue, pending assignments whose pay date has passed.
+// An occurrence is listed once, as upcoming when its due date is in range,
+// and not at all while it is disputed.
+// Documents expiring within their remind_days are listed too.
+func loadReminderDigest(ctx context.Context, db DBTX, now time.Time, daysAhead int, includeOverdue bool) (services.ReminderDigest, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	until := today.AddDate(0, 0, daysAhead)
+	digest := services.ReminderDigest{AsOf: today, DaysAhead: daysAhead}
+
+	rows, err := db.Query(ctx, `
+		SELECT ba.id, CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       ba.due_date, pp.pay_date, COALESCE(`+netPlannedAmount+`, 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status IN ('pending', 'uncertain') AND NOT `+openDispute+`
+		  AND (ba.due_date BETWEEN $1 AND $2 OR (ba.status = 'pending' AND pp.pay_date < $1))
+		ORDER BY COALESCE(ba.due_date, pp.pay_date), b.sort_order, ba.id
+	`, today, until)
+	if err != nil {
+		return digest, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var it services.ReminderItem
+		var due *time.Time
+		var payDate time.Time
+		if err := rows.Scan(&it.AssignmentID, &it.Name, &due, &payDate, &it.Amount); err != nil {
+			return digest, err
+		}
+		if due != nil && !due.Before(today) && !due.After(until) {
+			it.Date = *due
+			digest.Upcoming = append(digest.Upcoming, it)
+			continue
+		}
+		if includeOverdue {
+			it.Date = payDate
+			if due != nil {
+				it.Date = *due
+			}
+			digest.Overdue = append(digest.Overdue, it)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return digest, err
+	}
+	rows.Close()
+
+	// Documents come up once they are within their own reminder window
+	docs, err := db.Query(ctx, `
+		SELECT id, title, kind, expires_on
+		FROM documents
+		WHERE expires_on BETWEEN $1::date AND $1::date + remind_days
+		ORDER BY expires_on, title, id
+	`, today)
+	if err != nil {
+		return digest, err
+	}
+	defer docs.Close()
+	for docs.Next() {
+		var doc services.DocumentReminder
+		if err := docs.Scan(&doc.DocumentID, &doc.Title, &doc.Kind, &doc.ExpiresOn); err != nil {
+			return digest, err
+		}
+		digest.Expiring = append(digest.Expiring, doc)
+	}
+	return digest, docs.Err()
+}
+
+// sendDigests emails every enabled user whose send hour has come and who has
+// not had today's digest. Users with nothing due are marked sent without an
+// email so they are not re-checked until tomorrow.
+func (h *NotificationHandler) sendDigests(ctx context.Context, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT username, email, days_ahead, include_overdue
+		FROM notification_preferences
+		WHERE enabled = true AND email <> '' AND send_hour <= $1
+		  AND (last_sent_on IS NULL OR last_sent_on < $2)
+		ORDER BY id
+	`, now.Hour(), today)
+	if err != nil {
+		return err
+	}
+	var due []models.NotificationPreferences
+	for rows.Next() {
+		var p models.NotificationPreferences
+		if err := rows.Scan(&p.Username, &p.Email, &p.DaysAhead, &p.IncludeOverdue); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range due {
+		// Users not reached before shutdown keep last_sent_on unset and are
+		// sent on the first tick after restart
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		digest, err := loadReminderDigest(ctx, h.db, now, p.DaysAhead, p.IncludeOverdue)
+		if err != nil {
+			return err
+		}
+		if !digest.Empty() {
+			subject, body := services.FormatDigest(digest)
+			if err := h.mailer.Send(p.Email, subject, body); err != nil {
+				// Leave last_sent_on alone so the next tick retries
+				slog.Error("sending reminder digest", "username", p.Username, "error", err)
+				continue
+			}
+		}
+		if _, err := h.db.Exec(ctx, `
+			UPDATE notification_preferences SET last_sent_on = $2 WHERE username = $1
+		`, p.Username, today); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartScheduler checks for digests to send every interval until the runner
+// shuts down. Without a mailer it does nothing.
+func (h *NotificationHandler) StartScheduler(runner *jobs.Runner, interval time.Duration) {
+	if h.mailer == nil {
+		return
+	}
+
+	runner.Schedule("reminder-digests", interval, func(ctx context.Context, now time.Time) {
+		if err := h.sendDigests(ctx, now); err != nil {
+			slog.Error("sending reminder digests", "error", err)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// ObligationHandler tracks what the active bills cost per month and alerts
+// when the total climbs faster than the configured percentage.
+type ObligationHandler struct {
+	db           DBTX
+	events       *events.Bus
+	alertPercent float64 // 0 turns alerts off
+}
+
+func NewObligationHandler(db DBTX, alertPercent float64) *ObligationHandler {
+	return &ObligationHandler{db: db, alertPercent: alertPercent}
+}
+
+// WithEvents sets the bus obligation alerts are published to.
+func (h *ObligationHandler) WithEvents(bus *events.Bus) *ObligationHandler {
+	h.events = bus
+	return h
+}
+
+// currentObligations totals the monthly cost of the active bills.
+func currentObligations(ctx context.Context, db DBTX) (float64, int, error) {
+	rows, err := db.Query(ctx, `
+		SELECT recurrence, default_amount, active_months, monthly_amounts
+		FROM bills WHERE is_active = true
+	`)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var total float64
+	count := 0
+	for rows.Next() {
+		var recurrence string
+		var amount *float64
+		var activeMonths []int
+		var monthlyAmounts map[int]float64
+		if err := rows.Scan(&recurrence, &amount, &activeMonths, &monthlyAmounts); err != nil {
+			return 0, 0, err
+		}
+		total += services.MonthlyObligation(recurrence, amount, activeMonths, monthlyAmounts)
+		count++
+	}
+	return roundCents(total), count, rows.Err()
+}
+
+// loadObligationSnapshots returns the snapshots taken from through to, oldest
+// first, both for the response and as points to measure a rise against.
+func loadObligationSnapshots(ctx context.Context, db DBTX, from, to time.Time) ([]models.ObligationSnapshot, []services.ObligationPoint, error) {
+	rows, err := db.Query(ctx, `
+		SELECT snapshot_date, total, bill_count, alerted
+		FROM obligation_snapshots
+		WHERE snapshot_date BETWEEN $1 AND $2
+		ORDER BY snapshot_date
+	`, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []models.ObligationSnapshot{}
+	var points []services.ObligationPoint
+	for rows.Next() {
+		var p services.ObligationPoint
+		var count int
+		if err := rows.Scan(&p.Date, &p.Total, &count, &p.Alerted); err != nil {
+			return nil, nil, err
+		}
+		points = append(points, p)
+		snapshots = append(snapshots, models.ObligationSnapshot{
+			Date:      p.Date.Format("2006-01-02"),
+			Total:     p.Total,
+			BillCount: count,
+			Alerted:   p.Alerted,
+		})
+	}
+	return snapshots, points, rows.Err()
+}
+
+// obligationWindow is the days before today a rise is measured over.
+func obligationWindow(today time.Time) (time.Time, time.Time) {
+	return today.Add(-services.ObligationWindow), today.AddDate(0, 0, -1)
+}
+
+// Report shows the active bills' monthly total now, its change from the
+// lowest total of the last 90 days, and the daily snapshots between from
+// (default a year ago) and to (default today).
+// GET /api/v1/reports/obligations
+func (h *ObligationHandler) Report(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	from, ok := dateQueryParam(w, r, "from", today.AddDate(-1, 0, 0))
+	if !ok {
+		return
+	}
+	to, ok := dateQueryParam(w, r, "to", today)
+	if !ok {
+		return
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return
+	}
+
+	total, count, err := currentObligations(ctx, h.db)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	windowFrom, windowTo := obligationWindow(today)
+	_, points, err := loadObligationSnapshots(ctx, h.db, windowFrom, windowTo)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	history, _, err := loadObligationSnapshots(ctx, h.db, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	report := models.ObligationReport{
+		AsOf:         today.Format("2006-01-02"),
+		Total:        total,
+		BillCount:    count,
+		AlertPercent: h.alertPercent,
+		History:      history,
+	}
+	if len(points) > 0 {
+		baseline, change, alert := services.ObligationRise(points, total, h.alertPercent)
+		report.Baseline = &models.ObligationSnapshot{
+			Date:    baseline.Date.Format("2006-01-02"),
+			Total:   baseline.Total,
+			Alerted: baseline.Alerted,
+		}
+		report.PercentChange = change
+		report.Alert = alert
+		if alert {
+			report.Message = services.FormatObligationRise(change, baseline.Date, today)
+		}
+	}
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+// Record saves today's monthly total and publishes an obligations alert if it
+// has risen more than the alert percentage within the last 90 days. Running
+// it again the same day updates the snapshot; the alert is sent once.
+func (h *ObligationHandler) Record(ctx context.Context, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	total, count, err := currentObligations(ctx, h.db)
+	if err != nil {
+		return err
+	}
+	windowFrom, windowTo := obligationWindow(today)
+	_, points, err := loadObligationSnapshots(ctx, h.db, windowFrom, windowTo)
+	if err != nil {
+		return err
+	}
+	if _, err := h.db.Exec(ctx, `
+		INSERT INTO obligation_snapshots (snapshot_date, total, bill_count)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (snapshot_date) DO UPDATE SET
+			total = EXCLUDED.total, bill_count = EXCLUDED.bill_count, updated_at = NOW()
+	`, today, total, count); err != nil {
+		return err
+	}
+
+	baseline, change, alert := services.ObligationRise(points, total, h.alertPercent)
+	if !alert {
+		return nil
+	}
+	// Claim the alert so concurrent runs send it once
+	tag, err := h.db.Exec(ctx, `
+		UPDATE obligation_snapshots SET alerted = true WHERE snapshot_date = $1 AND NOT alerted
+	`, today)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+
+	a := models.ObligationAlert{
+		AsOf:          today.Format("2006-01-02"),
+		Total:         total,
+		BaselineDate:  baseline.Date.Format("2006-01-02"),
+		BaselineTotal: baseline.Total,
+		PercentChange: change,
+		Message:       services.FormatObligationRise(change, baseline.Date, today),
+	}
+	slog.Info("obligations alert", "total", a.Total, "baseline", a.BaselineTotal, "percent", a.PercentChange)
+	h.events.Publish(events.ObligationsIncreased, a)
+	return nil
+}
+
+// StartScheduler records the day's snapshot every interval until the runner
+// shuts down.
+func (h *ObligationHandler) StartScheduler(runner *jobs.Runner, interval time.Duration) {
+	runner.Schedule("obligation-snapshots", interval, func(ctx context.Context, now time.Time) {
+		if err := h.Record(ctx, now); err != nil {
+			slog.Error("recording obligations snapshot", "error", err)
+		}
+	})
+}
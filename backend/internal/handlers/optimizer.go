@@ -1,13 +1,21 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/izz-linux/budget-mgmt/backend/internal/models"
 	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type OptimizerHandler struct {
@@ -28,18 +36,48 @@ func (h *OptimizerHandler) Suggest(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req struct {
-		From     string `json:"from"`
-		To       string `json:"to"`
-		Strategy string `json:"strategy"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		Strategy  string `json:"strategy"`
+		Debug     bool   `json:"debug"`     // include the per-iteration trace
+		Aggregate bool   `json:"aggregate"` // plan same-date periods as one bucket
+		// MinBalance is the floor no suggested move may take a period below
+		MinBalance *float64 `json:"min_balance"`
+		// AllowSplits suggests paying part of a bill from another paycheck
+		// when no whole-bill move can balance the periods
+		AllowSplits bool `json:"allow_splits"`
+		// Weights scores candidate plans on several axes instead of the
+		// greedy $50-threshold search
+		Weights *services.OptWeights `json:"weights"`
+		// Mode sizes each assignment by its own planned, forecast or
+		// actual-preferred amount; without it bills count at their default
+		Mode string `json:"mode"`
+		// ColdStart ignores the layout the last applied plan left, planning
+		// every assignment afresh
+		ColdStart bool `json:"cold_start"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
 		return
 	}
+	if req.MinBalance != nil && *req.MinBalance < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "min_balance must not be negative")
+		return
+	}
+	if wt := req.Weights; wt != nil && (wt.MinBalance < 0 || wt.Variance < 0 || wt.Moves < 0 || wt.DueBuffer < 0) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "weights must not be negative")
+		return
+	}
+	mode, ok := validAmountMode(req.Mode, "")
+	if !ok {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "mode must be planned, forecast or actual_preferred")
+		return
+	}
 
 	// Fetch bills
 	billRows, err := h.db.Query(ctx, `
-		SELECT id, name, due_day, COALESCE(default_amount, 0)
+		SELECT id, name, due_day,
+		       COALESCE(default_amount, 0) * (1 - COALESCE(shared_percent, 0) / 100)
 		FROM bills WHERE is_active = true AND due_day IS NOT NULL
 	`)
 	if err != nil {
@@ -83,8 +121,14 @@ func (h *OptimizerHandler) Suggest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch current assignments (include assignment ID for apply)
+	amountMode := mode
+	if amountMode == "" {
+		amountMode = models.AmountModePlanned
+	}
 	assignRows, err := h.db.Query(ctx, `
-		SELECT ba.id, ba.bill_id, ba.pay_period_id FROM bill_assignments ba
+		SELECT ba.id, ba.bill_id, ba.pay_period_id, `+netAssignmentAmount(amountMode)+`
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
 		WHERE ba.pay_period_id IN (SELECT id FROM pay_periods WHERE pay_date >= $1 AND pay_date <= $2)
 	`, req.From, req.To)
 	if err != nil {
@@ -96,24 +140,124 @@ func (h *OptimizerHandler) Suggest(w http.ResponseWriter, r *http.Request) {
 	var currentAssignments []services.OptAssignment
 	for assignRows.Next() {
 		var a services.OptAssignment
-		if err := assignRows.Scan(&a.AssignmentID, &a.BillID, &a.PeriodID); err != nil {
+		var amount *float64
+		if err := assignRows.Scan(&a.AssignmentID, &a.BillID, &a.PeriodID, &amount); err != nil {
 			continue
 		}
+		if mode != "" {
+			a.Amount = amount
+		}
 		currentAssignments = append(currentAssignments, a)
 	}
 
-	result := h.optimizer.Optimize(bills, periods, currentAssignments)
+	var layout map[int]int
+	if !req.ColdStart {
+		layout, err = acceptedLayout(ctx, h.db, req.From, req.To)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
+	if req.Aggregate {
+		periods, currentAssignments = services.MergeSameDatePeriods(periods, currentAssignments)
+	}
+
+	result := h.optimizer.OptimizeWithOptions(bills, periods, currentAssignments, services.OptOptions{
+		Debug:       req.Debug,
+		MinBalance:  req.MinBalance,
+		AllowSplits: req.AllowSplits,
+		Weights:     req.Weights,
+		Layout:      layout,
+	})
+
+	// Save the suggestions so they can be applied later by plan_id, and the
+	// rest of the result, less the debug trace, so the plan can be exported
+	if len(result.Suggestions) > 0 {
+		suggestions, err := json.Marshal(result.Suggestions)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		saved := *result
+		saved.Trace = nil
+		full, err := json.Marshal(saved)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		err = h.db.QueryRow(ctx, `INSERT INTO optimizer_plans (suggestions, result) VALUES ($1, $2) RETURNING id`, suggestions, full).Scan(&result.PlanID)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
 	models.WriteJSON(w, http.StatusOK, result)
 }
 
-// Apply executes selected optimizer suggestions by moving assignments to new periods.
-// Each move deletes the old assignment and creates a new one in the target period,
-// marked as manually_moved since the optimizer is an explicit user action.
+// acceptedLayout returns the period the last applied plan left each
+// assignment paid from from through to in, by assignment ID. Assignments
+// moved by hand since, or no longer pending, are kept where they are now.
+func acceptedLayout(ctx context.Context, db DBTX, from, to string) (map[int]int, error) {
+	rows, err := db.Query(ctx, `
+		SELECT l.assignment_id,
+		       CASE WHEN ba.manually_moved OR ba.status <> 'pending' THEN ba.pay_period_id ELSE l.pay_period_id END
+		FROM optimizer_layouts l
+		JOIN bill_assignments ba ON ba.id = l.assignment_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	layout := map[int]int{}
+	for rows.Next() {
+		var assignmentID, periodID int
+		if err := rows.Scan(&assignmentID, &periodID); err != nil {
+			return nil, err
+		}
+		layout[assignmentID] = periodID
+	}
+	return layout, rows.Err()
+}
+
+// recordLayout saves where every assignment in the months of periodIDs now
+// sits as those months' accepted layout, replacing the one there was.
+func recordLayout(ctx context.Context, tx pgx.Tx, periodIDs []int, planID *int) error {
+	months := `SELECT date_trunc('month', pay_date)::date FROM pay_periods WHERE id = ANY($1)`
+	if _, err := tx.Exec(ctx, `DELETE FROM optimizer_layouts WHERE month IN (`+months+`)`, periodIDs); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO optimizer_layouts (assignment_id, pay_period_id, month, plan_id)
+		SELECT ba.id, ba.pay_period_id, date_trunc('month', pp.pay_date)::date, $2
+		FROM bill_assignments ba
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE date_trunc('month', pp.pay_date)::date IN (`+months+`)
+		ON CONFLICT (assignment_id) DO UPDATE SET
+			pay_period_id = EXCLUDED.pay_period_id, month = EXCLUDED.month,
+			plan_id = EXCLUDED.plan_id, accepted_at = NOW()
+	`, periodIDs, planID)
+	return err
+}
+
+// Apply moves assignments between periods in a single transaction, either
+// the moves given or every suggestion in a plan saved by Suggest. Moved
+// assignments keep their id, transactions and due date, and are not marked
+// manually_moved, so auto-assign treats them like any other placement. A
+// plan's moves are only applied while each assignment is still pending in
+// the period it was suggested from, and a plan can be applied once. The
+// layout of every month the moves touched is saved for Suggest to start from.
+// POST /api/v1/optimizer/apply
 func (h *OptimizerHandler) Apply(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req struct {
-		Moves []struct {
+		PlanID int `json:"plan_id"`
+		Moves  []struct {
 			AssignmentID int `json:"assignment_id"`
 			ToPeriodID   int `json:"to_period_id"`
 		} `json:"moves"`
@@ -122,61 +266,253 @@ func (h *OptimizerHandler) Apply(w http.ResponseWriter, r *http.Request) {
 		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
 		return
 	}
-
-	if len(req.Moves) == 0 {
+	if req.PlanID != 0 && len(req.Moves) > 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "specify either plan_id or moves, not both")
+		return
+	}
+	if req.PlanID == 0 && len(req.Moves) == 0 {
 		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "no moves specified")
 		return
 	}
 
-	var applied []models.BillAssignment
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
 
-	for _, move := range req.Moves {
-		// Look up the existing assignment
-		var billID int
-		var plannedAmount *float64
-		err := h.db.QueryRow(ctx, `
-			SELECT bill_id, planned_amount FROM bill_assignments WHERE id = $1
-		`, move.AssignmentID).Scan(&billID, &plannedAmount)
+	// Moves given directly don't name a period to check against
+	var moves []services.Suggestion
+	for _, m := range req.Moves {
+		moves = append(moves, services.Suggestion{AssignmentID: m.AssignmentID, ToPeriodID: m.ToPeriodID})
+	}
+	if req.PlanID != 0 {
+		var raw []byte
+		var appliedAt *time.Time
+		err := tx.QueryRow(ctx, `SELECT suggestions, applied_at FROM optimizer_plans WHERE id = $1 FOR UPDATE`, req.PlanID).Scan(&raw, &appliedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("plan %d not found", req.PlanID))
+			return
+		}
 		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if appliedAt != nil {
+			models.WriteError(w, http.StatusConflict, "CONFLICT", fmt.Sprintf("plan %d was already applied", req.PlanID))
+			return
+		}
+		if err := json.Unmarshal(raw, &moves); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+	}
+
+	applied := []models.BillAssignment{}
+	var touched []int // periods moved from and to
+	for _, move := range moves {
+		var periodID int
+		var status string
+		err := tx.QueryRow(ctx, `
+			SELECT pay_period_id, status FROM bill_assignments WHERE id = $1 FOR UPDATE
+		`, move.AssignmentID).Scan(&periodID, &status)
+		if errors.Is(err, pgx.ErrNoRows) {
 			models.WriteError(w, http.StatusNotFound, "NOT_FOUND",
 				fmt.Sprintf("assignment %d not found", move.AssignmentID))
 			return
 		}
-
-		// Delete the old assignment
-		_, err = h.db.Exec(ctx, `DELETE FROM bill_assignments WHERE id = $1`, move.AssignmentID)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 			return
 		}
+		if status != "pending" || (move.FromPeriodID != 0 && periodID != move.FromPeriodID) {
+			models.WriteError(w, http.StatusConflict, "CONFLICT",
+				fmt.Sprintf("assignment %d has changed since it was suggested", move.AssignmentID))
+			return
+		}
 
-		// Create new assignment in the target period, marked as manually_moved
 		var a models.BillAssignment
-		err = h.db.QueryRow(ctx, `
-			INSERT INTO bill_assignments (bill_id, pay_period_id, planned_amount, status, manually_moved)
-			VALUES ($1, $2, $3, 'pending', true)
-			ON CONFLICT (bill_id, pay_period_id) DO UPDATE SET
-				planned_amount = EXCLUDED.planned_amount,
-				manually_moved = true,
-				updated_at = NOW()
+		err = tx.QueryRow(ctx, `
+			UPDATE bill_assignments SET pay_period_id = $2, manually_moved = false, updated_at = NOW()
+			WHERE id = $1
 			RETURNING `+assignmentReturnCols+`
-		`, billID, move.ToPeriodID, plannedAmount).Scan(
-			&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount, &a.ForecastAmount,
-			&a.ActualAmount, &a.Status, &a.DeferredToID, &a.IsExtra, &a.ExtraName,
-			&a.Notes, &a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-			&a.CreatedAt, &a.UpdatedAt,
-		)
+		`, move.AssignmentID, move.ToPeriodID).Scan(assignmentScanDest(&a)...)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			models.WriteError(w, http.StatusConflict, "CONFLICT",
+				fmt.Sprintf("assignment %d's bill is already assigned to period %d", move.AssignmentID, move.ToPeriodID))
+			return
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("pay period %d not found", move.ToPeriodID))
+			return
+		}
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 			return
 		}
-
 		applied = append(applied, a)
+		touched = append(touched, periodID, move.ToPeriodID)
+	}
+
+	var planID *int
+	if req.PlanID != 0 {
+		planID = &req.PlanID
+	}
+	if err := recordLayout(ctx, tx, touched, planID); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if req.PlanID != 0 {
+		if _, err := tx.Exec(ctx, `UPDATE optimizer_plans SET applied_at = NOW() WHERE id = $1`, req.PlanID); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
 	}
 
 	models.WriteJSON(w, http.StatusOK, applied)
 }
 
+// PlanDocument is a saved optimizer plan laid out for people to read: the
+// moves and splits it suggests and every period's balance before and after.
+type PlanDocument struct {
+	PlanID              int                        `json:"plan_id"`
+	CreatedAt           time.Time                  `json:"created_at"`
+	AppliedAt           *time.Time                 `json:"applied_at"`
+	Moves               []services.Suggestion      `json:"moves"`
+	Splits              []services.SplitSuggestion `json:"splits"`
+	Balances            []services.PlanBalance     `json:"balances"` // empty for plans saved before balances were kept
+	CurrentMinBalance   float64                    `json:"current_min_balance"`
+	OptimizedMinBalance float64                    `json:"optimized_min_balance"`
+	Improvement         float64                    `json:"improvement"`
+}
+
+var planDocumentHTML = template.Must(template.New("plan").Funcs(template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"date":  func(t time.Time) string { return t.Format("January 2, 2006") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Budget plan {{.PlanID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+th, td { border-bottom: 1px solid #ccc; padding: 0.3rem 0.5rem; text-align: left; }
+td.num, th.num { text-align: right; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Budget plan {{.PlanID}}</h1>
+<p>Suggested {{date .CreatedAt}}.{{if .AppliedAt}} Applied {{date .AppliedAt}}.{{else}} Not yet applied.{{end}}</p>
+<p>Lowest balance goes from {{money .CurrentMinBalance}} to {{money .OptimizedMinBalance}} ({{money .Improvement}} better).</p>
+<h2>Moves</h2>
+<table>
+<tr><th>Bill</th><th>From</th><th>To</th><th class="num">Amount</th><th>Why</th></tr>
+{{range .Moves}}<tr><td>{{.BillName}}</td><td>{{.FromPeriod}}</td><td>{{.ToPeriod}}</td><td class="num">{{money .Amount}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>
+{{if .Splits}}<h2>Splits</h2>
+<table>
+<tr><th>Bill</th><th>Paid from</th><th class="num">Amount</th><th>Why</th></tr>
+{{range .Splits}}{{$reason := .Reason}}{{$bill := .BillName}}{{range .Parts}}<tr><td>{{$bill}}</td><td>{{.PayDate}}</td><td class="num">{{money .Amount}}</td><td>{{$reason}}</td></tr>
+{{end}}{{end}}</table>
+{{end}}{{if .Balances}}<h2>Balances</h2>
+<table>
+<tr><th>Pay date</th><th class="num">Before</th><th class="num">After</th></tr>
+{{range .Balances}}<tr><td>{{.PayDate}}</td><td class="num">{{money .Before}}</td><td class="num">{{money .After}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// ExportPlan renders a saved plan, or the newest one for "latest", as a
+// shareable document. The HTML form has a print stylesheet; print it to get
+// a PDF.
+// GET /api/v1/optimizer/plans/{id}/export?format=json|html
+func (h *OptimizerHandler) ExportPlan(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "html" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "format must be json or html")
+		return
+	}
+
+	query := `SELECT id, suggestions, result, applied_at, created_at FROM optimizer_plans`
+	var args []interface{}
+	if param := chi.URLParam(r, "id"); param == "latest" {
+		query += ` ORDER BY id DESC LIMIT 1`
+	} else {
+		id, err := strconv.Atoi(param)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_ID", `id must be an integer or "latest"`)
+			return
+		}
+		query += ` WHERE id = $1`
+		args = append(args, id)
+	}
+
+	var doc PlanDocument
+	var suggestions, raw []byte
+	err := h.db.QueryRow(r.Context(), query, args...).Scan(&doc.PlanID, &suggestions, &raw, &doc.AppliedAt, &doc.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "plan not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if raw != nil {
+		var result services.OptimizationResult
+		if err := json.Unmarshal(raw, &result); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		doc.Moves = result.Suggestions
+		doc.Splits = result.Splits
+		doc.Balances = result.Balances
+		doc.CurrentMinBalance = result.CurrentMinBalance
+		doc.OptimizedMinBalance = result.OptimizedMinBalance
+		doc.Improvement = result.Improvement
+	} else if err := json.Unmarshal(suggestions, &doc.Moves); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+		return
+	}
+	if doc.Moves == nil {
+		doc.Moves = []services.Suggestion{}
+	}
+	if doc.Splits == nil {
+		doc.Splits = []services.SplitSuggestion{}
+	}
+	if doc.Balances == nil {
+		doc.Balances = []services.PlanBalance{}
+	}
+
+	if format == "json" {
+		models.WriteJSON(w, http.StatusOK, doc)
+		return
+	}
+	var page bytes.Buffer
+	if err := planDocumentHTML.Execute(&page, doc); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="budget-plan-%d.html"`, doc.PlanID))
+	page.WriteTo(w)
+}
+
 func (h *OptimizerHandler) Surplus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -211,6 +547,7 @@ func (h *OptimizerHandler) Surplus(w http.ResponseWriter, r *http.Request) {
 		}
 		sources = append(sources, s)
 	}
+	rows.Close()
 
 	result, err := h.surplusDetector.Detect(sources, from, to)
 	if err != nil {
@@ -218,5 +555,28 @@ func (h *OptimizerHandler) Surplus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Known bonuses and refunds are surplus on top of extra paychecks
+	rows, err = h.db.Query(ctx, `
+		SELECT `+incomeEventReturnCols+` FROM income_events
+		WHERE event_date BETWEEN $1 AND $2
+		ORDER BY event_date, id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	var incomeEvents []models.IncomeEvent
+	for rows.Next() {
+		var e models.IncomeEvent
+		if err := scanIncomeEvent(rows, &e); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		incomeEvents = append(incomeEvents, e)
+	}
+	h.surplusDetector.AddIncomeEvents(result, incomeEvents)
+
 	models.WriteJSON(w, http.StatusOK, result)
 }
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// loadPayrollCalendars returns the official pay dates of sourceIDs in the
+// years from..to, by source.
+func loadPayrollCalendars(ctx context.Context, db DBTX, sourceIDs []int, from, to time.Time) (map[int][]time.Time, error) {
+	calendars := map[int][]time.Time{}
+	if len(sourceIDs) == 0 {
+		return calendars, nil
+	}
+	rows, err := db.Query(ctx, `
+		SELECT income_source_id, pay_date FROM payroll_calendar_dates
+		WHERE income_source_id = ANY($1)
+		  AND EXTRACT(YEAR FROM pay_date) BETWEEN $2 AND $3
+		ORDER BY pay_date
+	`, sourceIDs, from.Year(), to.Year())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var d time.Time
+		if err := rows.Scan(&id, &d); err != nil {
+			return nil, err
+		}
+		calendars[id] = append(calendars[id], d)
+	}
+	return calendars, rows.Err()
+}
+
+// parseCalendarYear reads the {year} URL parameter.
+func parseCalendarYear(w http.ResponseWriter, r *http.Request) (int, bool) {
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil || year < 1900 || year > 9999 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "year must be a four-digit year")
+		return 0, false
+	}
+	return year, true
+}
+
+// PayrollCalendars lists an income source's uploaded payroll calendars, one
+// per year.
+// GET /api/v1/income-sources/{id}/payroll-calendars
+func (h *IncomeHandler) PayrollCalendars(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT pay_date FROM payroll_calendar_dates
+		WHERE income_source_id = $1
+		ORDER BY pay_date
+	`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	calendars := []models.PayrollCalendar{}
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if n := len(calendars); n == 0 || calendars[n-1].Year != d.Year() {
+			calendars = append(calendars, models.PayrollCalendar{IncomeSourceID: id, Year: d.Year(), Dates: []string{}})
+		}
+		c := &calendars[len(calendars)-1]
+		c.Dates = append(c.Dates, d.Format("2006-01-02"))
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, calendars)
+}
+
+// SetPayrollCalendar replaces an income source's official pay dates for a
+// year. Later generation uses them for that year instead of the schedule;
+// periods generated before are reported as stale rather than changed.
+// PUT /api/v1/income-sources/{id}/payroll-calendars/{year}
+func (h *IncomeHandler) SetPayrollCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+	year, ok := parseCalendarYear(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SetPayrollCalendarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if len(req.Dates) == 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "dates is required; delete the calendar to go back to the schedule")
+		return
+	}
+	dates := make([]time.Time, 0, len(req.Dates))
+	seen := map[string]bool{}
+	for _, s := range req.Dates {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("invalid date %q; use YYYY-MM-DD", s))
+			return
+		}
+		if d.Year() != year {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("%s is not in %d", s, year))
+			return
+		}
+		if seen[s] {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("%s is listed twice", s))
+			return
+		}
+		seen[s] = true
+		dates = append(dates, d)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	calendar, err := h.savePayrollCalendar(ctx, id, year, dates)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income source not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, calendar)
+}
+
+// savePayrollCalendar swaps in a year's dates in one transaction and finds
+// the periods they leave stale. It returns pgx.ErrNoRows when the source
+// doesn't exist.
+func (h *IncomeHandler) savePayrollCalendar(ctx context.Context, id, year int, dates []time.Time) (models.PayrollCalendar, error) {
+	calendar := models.PayrollCalendar{IncomeSourceID: id, Year: year, Dates: []string{}, StalePayDates: []string{}}
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		return calendar, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists int
+	if err := tx.QueryRow(ctx, `SELECT 1 FROM income_sources WHERE id = $1`, id).Scan(&exists); err != nil {
+		return calendar, err
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM payroll_calendar_dates
+		WHERE income_source_id = $1 AND EXTRACT(YEAR FROM pay_date) = $2
+	`, id, year); err != nil {
+		return calendar, err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO payroll_calendar_dates (income_source_id, pay_date)
+		SELECT $1, d FROM unnest($2::date[]) AS d
+	`, id, dates); err != nil {
+		return calendar, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT pay_date FROM pay_periods
+		WHERE income_source_id = $1 AND EXTRACT(YEAR FROM pay_date) = $2
+		  AND pay_date <> ALL($3::date[])
+		ORDER BY pay_date
+	`, id, year, dates)
+	if err != nil {
+		return calendar, err
+	}
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			rows.Close()
+			return calendar, err
+		}
+		calendar.StalePayDates = append(calendar.StalePayDates, d.Format("2006-01-02"))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return calendar, err
+	}
+
+	for _, d := range dates {
+		calendar.Dates = append(calendar.Dates, d.Format("2006-01-02"))
+	}
+	return calendar, tx.Commit(ctx)
+}
+
+// DeletePayrollCalendar drops a year's official pay dates, so generation
+// goes back to the schedule for that year.
+// DELETE /api/v1/income-sources/{id}/payroll-calendars/{year}
+func (h *IncomeHandler) DeletePayrollCalendar(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+	year, ok := parseCalendarYear(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `
+		DELETE FROM payroll_calendar_dates
+		WHERE income_source_id = $1 AND EXTRACT(YEAR FROM pay_date) = $2
+	`, id, year)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "no payroll calendar for that year")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
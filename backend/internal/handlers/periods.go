@@ -1,19 +1,25 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
 	"github.com/izz-linux/budget-mgmt/backend/internal/models"
 	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/jackc/pgx/v5"
 )
 
 type PeriodHandler struct {
 	db        DBTX
 	generator *services.PeriodGenerator
+	events    *events.Bus
 }
 
 func NewPeriodHandler(db DBTX) *PeriodHandler {
@@ -23,6 +29,25 @@ func NewPeriodHandler(db DBTX) *PeriodHandler {
 	}
 }
 
+// WithEvents sets the bus that period generation is published to.
+func (h *PeriodHandler) WithEvents(bus *events.Bus) *PeriodHandler {
+	h.events = bus
+	return h
+}
+
+// periodSortFields are the fields Periods List accepts in ?sort=.
+var periodSortFields = map[string]string{
+	"pay_date":        "pp.pay_date",
+	"expected_amount": "pp.expected_amount",
+	"total_bills":     "SUM(" + netPlannedAmount + ")",
+	"remaining":       "pp.expected_amount - COALESCE(SUM(" + netPlannedAmount + "), 0)",
+	"source":          "inc.name",
+}
+
+// List returns pay periods between ?from and ?to (default: the next 3
+// months), optionally for one ?income_source_id, sorted and paged per
+// parseListParams. With ?aggregate=true same-date periods on the page are
+// merged, while the total still counts unmerged periods.
 func (h *PeriodHandler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -36,18 +61,39 @@ func (h *PeriodHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 	// Note: from/to are passed directly to SQL query which handles date comparison correctly
 
-	rows, err := h.db.Query(ctx, `
+	params, ok := parseListParams(w, r, periodSortFields, "pp.pay_date, pp.id")
+	if !ok {
+		return
+	}
+
+	query := `
 		SELECT pp.id, pp.income_source_id, pp.pay_date, pp.expected_amount,
 		       pp.actual_amount, COALESCE(pp.notes, ''), pp.created_at, inc.name,
-		       COALESCE(SUM(ba.planned_amount), 0) as total_bills
+		       COALESCE(SUM(` + netPlannedAmount + `), 0) as total_bills,
+		       COUNT(*) OVER ()
 		FROM pay_periods pp
 		JOIN income_sources inc ON inc.id = pp.income_source_id
 		LEFT JOIN bill_assignments ba ON ba.pay_period_id = pp.id
+		LEFT JOIN bills b ON b.id = ba.bill_id
 		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2 AND inc.is_active = true
+	`
+	args := []interface{}{from, to}
+
+	if sourceID := r.URL.Query().Get("income_source_id"); sourceID != "" {
+		id, err := strconv.Atoi(sourceID)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "income_source_id must be an integer")
+			return
+		}
+		args = append(args, id)
+		query += " AND pp.income_source_id = $" + strconv.Itoa(len(args))
+	}
+	query += `
 		GROUP BY pp.id, pp.income_source_id, pp.pay_date, pp.expected_amount,
-		         pp.actual_amount, pp.notes, pp.created_at, inc.name
-		ORDER BY pp.pay_date
-	`, from, to)
+		         pp.actual_amount, pp.notes, pp.created_at, inc.name`
+
+	pageQuery, pageArgs := params.apply(query, args)
+	rows, err := h.db.Query(ctx, pageQuery, pageArgs...)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 		return
@@ -55,10 +101,11 @@ func (h *PeriodHandler) List(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	var periods []models.PayPeriod
+	var total int
 	for rows.Next() {
 		var p models.PayPeriod
 		err := rows.Scan(&p.ID, &p.IncomeSourceID, &p.PayDate, &p.ExpectedAmount,
-			&p.ActualAmount, &p.Notes, &p.CreatedAt, &p.SourceName, &p.TotalBills)
+			&p.ActualAmount, &p.Notes, &p.CreatedAt, &p.SourceName, &p.TotalBills, &total)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
 			return
@@ -68,30 +115,46 @@ func (h *PeriodHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 		periods = append(periods, p)
 	}
+	rows.Close()
+
+	total, err = listTotal(ctx, h.db, params, query, args, total, len(periods))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	// Paydays from several sources on one date can be planned as one bucket
+	if r.URL.Query().Get("aggregate") == "true" {
+		periods = services.AggregatePeriods(periods)
+	}
 
 	if periods == nil {
 		periods = []models.PayPeriod{}
 	}
-	models.WriteJSON(w, http.StatusOK, periods)
+	models.WriteJSONList(w, periods, total, params.Limit, params.Offset)
 }
 
-func (h *PeriodHandler) Generate(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var req models.GeneratePeriodsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
-		return
-	}
+// plannedPeriods is the paydays generation yields for one income source.
+type plannedPeriods struct {
+	source models.IncomeSource
+	from   time.Time // the requested from, or the source's effective_from if later
+	dates  []time.Time
+}
 
+// planGeneration validates a generate request and works out the paydays of
+// each matching active income source. It writes the error response and
+// returns false on failure.
+func (h *PeriodHandler) planGeneration(w http.ResponseWriter, r *http.Request, req models.GeneratePeriodsRequest) ([]plannedPeriods, time.Time, bool) {
+	ctx := r.Context()
 	fromDate, err := time.ParseInLocation("2006-01-02", req.From, time.Local)
 	if err != nil {
 		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid from date")
-		return
+		return nil, time.Time{}, false
 	}
 	toDate, err := time.ParseInLocation("2006-01-02", req.To, time.Local)
 	if err != nil {
 		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid to date")
-		return
+		return nil, time.Time{}, false
 	}
 
 	// Get income sources
@@ -106,7 +169,7 @@ func (h *PeriodHandler) Generate(w http.ResponseWriter, r *http.Request) {
 	rows, err := h.db.Query(ctx, query, args...)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
-		return
+		return nil, time.Time{}, false
 	}
 	defer rows.Close()
 
@@ -116,13 +179,24 @@ func (h *PeriodHandler) Generate(w http.ResponseWriter, r *http.Request) {
 		if err := rows.Scan(&s.ID, &s.Name, &s.PaySchedule, &s.ScheduleDetail,
 			&s.DefaultAmount, &s.IsActive, &s.EffectiveFrom, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
-			return
+			return nil, time.Time{}, false
 		}
 		sources = append(sources, s)
 	}
+	rows.Close()
+
+	// Uploaded payroll calendars override the schedule for the years they cover
+	sourceIDs := make([]int, len(sources))
+	for i, source := range sources {
+		sourceIDs[i] = source.ID
+	}
+	calendars, err := loadPayrollCalendars(ctx, h.db, sourceIDs, fromDate, toDate)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return nil, time.Time{}, false
+	}
 
-	// Generate and insert periods
-	var created []models.PayPeriod
+	var plans []plannedPeriods
 	for _, source := range sources {
 		// Use effective_from as the start date if it's after the requested from date
 		effectiveFrom := fromDate
@@ -133,34 +207,206 @@ func (h *PeriodHandler) Generate(w http.ResponseWriter, r *http.Request) {
 		dates, err := h.generator.Generate(source, effectiveFrom, toDate)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "GENERATION_ERROR", err.Error())
+			return nil, time.Time{}, false
+		}
+		dates = services.ApplyPayrollCalendar(dates, calendars[source.ID], effectiveFrom, toDate)
+		plans = append(plans, plannedPeriods{source: source, from: effectiveFrom, dates: dates})
+	}
+	return plans, fromDate, true
+}
+
+// existingPeriods returns a source's pay periods on any of dates, by
+// YYYY-MM-DD.
+func existingPeriods(ctx context.Context, db DBTX, source models.IncomeSource, dates []time.Time) (map[string]models.PayPeriod, error) {
+	existing := map[string]models.PayPeriod{}
+	if len(dates) == 0 {
+		return existing, nil
+	}
+	rows, err := db.Query(ctx, `
+		SELECT id, income_source_id, pay_date, expected_amount, actual_amount, COALESCE(notes, ''), created_at
+		FROM pay_periods
+		WHERE income_source_id = $1 AND pay_date = ANY($2)
+	`, source.ID, dates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.PayPeriod
+		if err := rows.Scan(&p.ID, &p.IncomeSourceID, &p.PayDate, &p.ExpectedAmount,
+			&p.ActualAmount, &p.Notes, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.SourceName = source.Name
+		existing[p.PayDate.Format("2006-01-02")] = p
+	}
+	return existing, rows.Err()
+}
+
+// generateAction is what generation does with a payday: create it, or for
+// one already on file, skip it when the amounts agree, and otherwise update
+// its expected amount if asked or report the conflict and leave it be. A
+// source without a default amount never conflicts.
+func generateAction(existing models.PayPeriod, found bool, scheduled *float64, updateAmounts bool) string {
+	switch {
+	case !found:
+		return models.GenerateActionCreate
+	case scheduled == nil:
+		return models.GenerateActionSkip
+	case existing.ExpectedAmount != nil && *existing.ExpectedAmount == *scheduled:
+		return models.GenerateActionSkip
+	case updateAmounts:
+		return models.GenerateActionUpdate
+	default:
+		return models.GenerateActionConflict
+	}
+}
+
+// Generate creates the pay periods of active income sources over a range.
+// Re-running it over periods already generated is safe: each payday is
+// reported as created, updated, skipped or conflicting, and an existing
+// period's expected amount only changes with update_amounts.
+// POST /api/v1/pay-periods/generate
+func (h *PeriodHandler) Generate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.GeneratePeriodsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	plans, fromDate, ok := h.planGeneration(w, r, req)
+	if !ok {
+		return
+	}
+
+	report := models.GenerateReport{
+		Created:   []models.PayPeriod{},
+		Updated:   []models.PayPeriod{},
+		Skipped:   []models.PayPeriod{},
+		Conflicts: []models.GenerateConflict{},
+	}
+	for _, plan := range plans {
+		source := plan.source
+		existing, err := existingPeriods(ctx, h.db, source, plan.dates)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 			return
 		}
 
-		for _, date := range dates {
-			var p models.PayPeriod
-			err := h.db.QueryRow(ctx, `
-				INSERT INTO pay_periods (income_source_id, pay_date, expected_amount)
-				VALUES ($1, $2, $3)
-				ON CONFLICT (income_source_id, pay_date) DO UPDATE SET
-					expected_amount = COALESCE(EXCLUDED.expected_amount, pay_periods.expected_amount)
-				RETURNING id, income_source_id, pay_date, expected_amount, actual_amount, COALESCE(notes, ''), created_at
-			`, source.ID, date, source.DefaultAmount).Scan(
-				&p.ID, &p.IncomeSourceID, &p.PayDate, &p.ExpectedAmount,
-				&p.ActualAmount, &p.Notes, &p.CreatedAt,
-			)
-			if err != nil {
-				models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
-				return
+		for _, date := range plan.dates {
+			current, found := existing[date.Format("2006-01-02")]
+			switch generateAction(current, found, source.DefaultAmount, req.UpdateAmounts) {
+			case models.GenerateActionSkip:
+				report.Skipped = append(report.Skipped, current)
+			case models.GenerateActionConflict:
+				report.Conflicts = append(report.Conflicts, models.GenerateConflict{
+					PayPeriod:       current,
+					ScheduledAmount: *source.DefaultAmount,
+				})
+			case models.GenerateActionUpdate:
+				err := h.db.QueryRow(ctx, `
+					UPDATE pay_periods SET expected_amount = $2 WHERE id = $1
+					RETURNING expected_amount
+				`, current.ID, source.DefaultAmount).Scan(&current.ExpectedAmount)
+				if err != nil {
+					models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+					return
+				}
+				report.Updated = append(report.Updated, current)
+			case models.GenerateActionCreate:
+				var p models.PayPeriod
+				err := h.db.QueryRow(ctx, `
+					INSERT INTO pay_periods (income_source_id, pay_date, expected_amount)
+					VALUES ($1, $2, $3)
+					ON CONFLICT (income_source_id, pay_date) DO NOTHING
+					RETURNING id, income_source_id, pay_date, expected_amount, actual_amount, COALESCE(notes, ''), created_at
+				`, source.ID, date, source.DefaultAmount).Scan(
+					&p.ID, &p.IncomeSourceID, &p.PayDate, &p.ExpectedAmount,
+					&p.ActualAmount, &p.Notes, &p.CreatedAt,
+				)
+				if errors.Is(err, pgx.ErrNoRows) {
+					continue // generated concurrently by another request
+				}
+				if err != nil {
+					models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+					return
+				}
+				p.SourceName = source.Name
+				report.Created = append(report.Created, p)
 			}
-			p.SourceName = source.Name
-			created = append(created, p)
 		}
 	}
 
-	if created == nil {
-		created = []models.PayPeriod{}
+	// New paychecks can be the ones income events from here on land after
+	if err := attachIncomeEvents(ctx, h.db, fromDate, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
 	}
-	models.WriteJSON(w, http.StatusCreated, created)
+	for _, periods := range [][]models.PayPeriod{report.Created, report.Updated, report.Skipped} {
+		if err := loadExtraIncome(ctx, h.db, periods); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
+	if changed := append(append([]models.PayPeriod{}, report.Created...), report.Updated...); len(changed) > 0 {
+		h.events.Publish(events.PeriodsGenerated, changed)
+	}
+	models.WriteJSON(w, http.StatusCreated, report)
+}
+
+// Preview takes the same body as Generate and returns, per income source,
+// the paydays and expected amounts generating would write and what it would
+// do with each. Nothing is saved, so anchor dates can be checked first.
+// POST /api/v1/pay-periods/generate/preview
+func (h *PeriodHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.GeneratePeriodsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	plans, _, ok := h.planGeneration(w, r, req)
+	if !ok {
+		return
+	}
+
+	previews := []models.GeneratePreview{}
+	for _, plan := range plans {
+		source := plan.source
+		preview := models.GeneratePreview{
+			IncomeSourceID: source.ID,
+			SourceName:     source.Name,
+			EffectiveFrom:  plan.from.Format("2006-01-02"),
+			Periods:        []models.GeneratePreviewPeriod{},
+		}
+
+		existing, err := existingPeriods(ctx, h.db, source, plan.dates)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+
+		for _, date := range plan.dates {
+			day := date.Format("2006-01-02")
+			current, found := existing[day]
+			period := models.GeneratePreviewPeriod{
+				PayDate:        day,
+				ExpectedAmount: source.DefaultAmount,
+				Exists:         found,
+				Action:         generateAction(current, found, source.DefaultAmount, req.UpdateAmounts),
+			}
+			// Periods that aren't updated keep their current amount
+			if found && period.Action != models.GenerateActionUpdate {
+				period.ExpectedAmount = current.ExpectedAmount
+			}
+			preview.Periods = append(preview.Periods, period)
+		}
+		previews = append(previews, preview)
+	}
+
+	models.WriteJSON(w, http.StatusOK, previews)
 }
 
 func (h *PeriodHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -200,3 +446,353 @@ func (h *PeriodHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	models.WriteJSON(w, http.StatusOK, p)
 }
+
+// BulkUpdate sets expected_amount on every period of one income source dated
+// from (default today) onward, skipping periods that already have an actual
+// amount recorded. It returns the updated periods in pay date order.
+// PATCH /api/v1/pay-periods/bulk
+func (h *PeriodHandler) BulkUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.BulkUpdatePeriodsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.IncomeSourceID <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "income_source_id is required")
+		return
+	}
+	if req.ExpectedAmount == nil || *req.ExpectedAmount < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "expected_amount must be a non-negative amount")
+		return
+	}
+	from := time.Now()
+	if req.From != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.From, time.Local)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "from must be in YYYY-MM-DD format")
+			return
+		}
+		from = parsed
+	}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var name string
+	if err := tx.QueryRow(ctx, `SELECT name FROM income_sources WHERE id = $1`, req.IncomeSourceID).Scan(&name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "income source not found")
+			return
+		}
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if req.UpdateDefault {
+		_, err := tx.Exec(ctx, `UPDATE income_sources SET default_amount = $2, updated_at = NOW() WHERE id = $1`,
+			req.IncomeSourceID, *req.ExpectedAmount)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+		UPDATE pay_periods SET expected_amount = $2
+		WHERE income_source_id = $1 AND pay_date >= $3 AND actual_amount IS NULL
+		RETURNING id, income_source_id, pay_date, expected_amount, actual_amount, COALESCE(notes, ''), created_at
+	`, req.IncomeSourceID, *req.ExpectedAmount, from)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	periods := []models.PayPeriod{}
+	for rows.Next() {
+		var p models.PayPeriod
+		if err := rows.Scan(&p.ID, &p.IncomeSourceID, &p.PayDate, &p.ExpectedAmount,
+			&p.ActualAmount, &p.Notes, &p.CreatedAt); err != nil {
+			rows.Close()
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		p.SourceName = name
+		periods = append(periods, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	sort.Slice(periods, func(i, j int) bool { return periods[i].PayDate.Before(periods[j].PayDate) })
+	models.WriteJSON(w, http.StatusOK, periods)
+}
+
+// CopyFrom clones another period's assignments into this one, so a new plan
+// can mirror an equivalent earlier paycheck. Planned and forecast amounts and
+// extras are copied as pending; bills already assigned to the target period
+// and skipped assignments are left out. Due dates are recomputed from each
+// bill's due day relative to the target pay date.
+// POST /api/v1/pay-periods/{id}/copy-from/{other_id}
+func (h *PeriodHandler) CopyFrom(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	targetID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+	sourceID, err := strconv.Atoi(chi.URLParam(r, "other_id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "other_id must be an integer")
+		return
+	}
+	if sourceID == targetID {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "cannot copy a period into itself")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var payDate time.Time
+	if err := tx.QueryRow(ctx, `SELECT pay_date FROM pay_periods WHERE id = $1`, targetID).Scan(&payDate); err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "pay period not found")
+		return
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pay_periods WHERE id = $1)`, sourceID).Scan(&exists); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if !exists {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "source pay period not found")
+		return
+	}
+
+	type template struct {
+		billID        int
+		planned       *float64
+		forecast      *float64
+		isExtra       bool
+		extraName     string
+		notes         string
+		taxDeductible bool
+		dueDay        *int
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT ba.bill_id, ba.planned_amount, ba.forecast_amount, ba.is_extra,
+		       COALESCE(ba.extra_name, ''), COALESCE(ba.notes, ''), ba.tax_deductible, b.due_day
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		WHERE ba.pay_period_id = $1
+		  AND ba.status <> 'skipped'
+		  AND NOT ba.is_sinking_fund
+		  AND (ba.is_extra OR NOT EXISTS (
+		      SELECT 1 FROM bill_assignments t
+		      WHERE t.pay_period_id = $2 AND t.bill_id = ba.bill_id AND NOT t.is_extra
+		  ))
+		ORDER BY b.sort_order, ba.id
+	`, sourceID, targetID)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	var templates []template
+	for rows.Next() {
+		var t template
+		if err := rows.Scan(&t.billID, &t.planned, &t.forecast, &t.isExtra,
+			&t.extraName, &t.notes, &t.taxDeductible, &t.dueDay); err != nil {
+			rows.Close()
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		templates = append(templates, t)
+	}
+	rows.Close()
+
+	created := []models.BillAssignment{}
+	for _, t := range templates {
+		var dueDate *time.Time
+		if !t.isExtra && t.dueDay != nil {
+			d := nextDueDate(payDate, *t.dueDay)
+			dueDate = &d
+		}
+
+		var a models.BillAssignment
+		err := tx.QueryRow(ctx, `
+			INSERT INTO bill_assignments (bill_id, pay_period_id, planned_amount, forecast_amount,
+			                              status, is_extra, extra_name, notes, manually_moved,
+			                              tax_deductible, due_date)
+			VALUES ($1, $2, $3, $4, 'pending', $5, $6, $7, true, $8, $9)
+			RETURNING `+assignmentReturnCols+`
+		`, t.billID, targetID, t.planned, t.forecast, t.isExtra, t.extraName, t.notes,
+			t.taxDeductible, dueDate,
+		).Scan(assignmentScanDest(&a)...)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		created = append(created, a)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, created)
+}
+
+// riskHistoryMonths is how far back Risk looks for late payments.
+const riskHistoryMonths = 12
+
+// paycheckKey identifies a recurring paycheck: its income source and whether
+// it lands around the middle of the month or around its turn, so history
+// from the 1st-of-month check doesn't count against the mid-month one. A
+// check paid early on the 30th still counts with the 1st.
+type paycheckKey struct {
+	sourceID int
+	midMonth bool
+}
+
+func paycheckKeyFor(sourceID int, payDate time.Time) paycheckKey {
+	return paycheckKey{sourceID: sourceID, midMonth: payDate.Day() >= 8 && payDate.Day() <= 23}
+}
+
+// Risk scores each pay period between ?from and ?to (default: today and 90
+// days later) for how likely it is to fall behind, from the same paycheck's
+// late payments over the past year, the balance left after its bills and how
+// tightly its due dates bunch up. ?mode= picks the assignment amount counted,
+// default planned.
+// GET /api/v1/pay-periods/risk
+func (h *PeriodHandler) Risk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	from, ok := dateQueryParam(w, r, "from", today)
+	if !ok {
+		return
+	}
+	to, ok := dateQueryParam(w, r, "to", today.AddDate(0, 0, 90))
+	if !ok {
+		return
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return
+	}
+	mode, ok := amountModeParam(w, r, models.AmountModePlanned)
+	if !ok {
+		return
+	}
+
+	// Late means paid after its due date, deferred, or still unpaid past it
+	historyRows, err := h.db.Query(ctx, `
+		SELECT COALESCE(pp.income_source_id, 0), pp.pay_date,
+		       (ba.status = 'deferred'
+		        OR (ba.paid_date IS NOT NULL AND ba.due_date IS NOT NULL AND ba.paid_date > ba.due_date)
+		        OR (ba.status IN ('pending', 'uncertain') AND COALESCE(ba.due_date, pp.pay_date) < $2))
+		FROM bill_assignments ba
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE pp.pay_date >= $1 AND pp.pay_date < $2 AND ba.status <> 'skipped'
+	`, today.AddDate(0, -riskHistoryMonths, 0), today)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer historyRows.Close()
+
+	type lateCount struct{ total, late int }
+	history := map[paycheckKey]*lateCount{}
+	for historyRows.Next() {
+		var sourceID int
+		var payDate time.Time
+		var late bool
+		if err := historyRows.Scan(&sourceID, &payDate, &late); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		key := paycheckKeyFor(sourceID, payDate)
+		if history[key] == nil {
+			history[key] = &lateCount{}
+		}
+		history[key].total++
+		if late {
+			history[key].late++
+		}
+	}
+	historyRows.Close()
+
+	periodRows, err := h.db.Query(ctx, `
+		SELECT pp.id, pp.pay_date, COALESCE(pp.income_source_id, 0), COALESCE(inc.name, ''),
+		       COALESCE(pp.expected_amount, 0)
+		FROM pay_periods pp
+		LEFT JOIN income_sources inc ON inc.id = pp.income_source_id
+		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2
+		ORDER BY pp.pay_date, pp.id
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer periodRows.Close()
+
+	var periods []services.RiskPeriod
+	index := map[int]int{}
+	for periodRows.Next() {
+		var p services.RiskPeriod
+		var sourceID int
+		if err := periodRows.Scan(&p.PeriodID, &p.PayDate, &sourceID, &p.Source, &p.Income); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if c := history[paycheckKeyFor(sourceID, p.PayDate)]; c != nil {
+			p.PastAssignments, p.PastLate = c.total, c.late
+		}
+		index[p.PeriodID] = len(periods)
+		periods = append(periods, p)
+	}
+	periodRows.Close()
+
+	billRows, err := h.db.Query(ctx, `
+		SELECT ba.pay_period_id, COALESCE(`+netAssignmentAmount(mode)+`, 0), ba.due_date
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2 AND ba.status NOT IN ('skipped', 'deferred')
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer billRows.Close()
+
+	for billRows.Next() {
+		var periodID int
+		var b services.RiskBill
+		if err := billRows.Scan(&periodID, &b.Amount, &b.DueDate); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if i, ok := index[periodID]; ok {
+			periods[i].Bills = append(periods[i].Bills, b)
+		}
+	}
+
+	models.WriteJSON(w, http.StatusOK, services.ScoreRisk(periods))
+}
@@ -0,0 +1,869 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// categoryTrendTTL is how long a category trends report is served from the
+// cache before it is computed again.
+const categoryTrendTTL = time.Minute
+
+type ReportHandler struct {
+	db DBTX
+
+	mu     sync.Mutex
+	trends map[string]cachedCategoryTrends // keyed by "from|to"
+}
+
+type cachedCategoryTrends struct {
+	report  CategoryTrendReport
+	expires time.Time
+}
+
+func NewReportHandler(db DBTX) *ReportHandler {
+	return &ReportHandler{db: db, trends: map[string]cachedCategoryTrends{}}
+}
+
+// ClearCache drops cached reports so the next request sees changed assignments.
+func (h *ReportHandler) ClearCache() {
+	h.mu.Lock()
+	h.trends = map[string]cachedCategoryTrends{}
+	h.mu.Unlock()
+}
+
+type OwedItem struct {
+	AssignmentID  int     `json:"assignment_id"`
+	BillID        int     `json:"bill_id"`
+	BillName      string  `json:"bill_name"`
+	SharedWith    string  `json:"shared_with"`
+	PayDate       string  `json:"pay_date"`
+	Amount        float64 `json:"amount"`
+	SharedPercent float64 `json:"shared_percent"`
+	Owed          float64 `json:"owed"`
+}
+
+type OwedStatement struct {
+	Month     string             `json:"month"` // YYYY-MM
+	Items     []OwedItem         `json:"items"`
+	ByParty   map[string]float64 `json:"by_party"`
+	TotalOwed float64            `json:"total_owed"`
+}
+
+// OwedToMe lists the external share of every shared bill assigned in a month.
+// GET /api/v1/reports/owed-to-me?month=YYYY-MM (defaults to the current month)
+func (h *ReportHandler) OwedToMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "month must be in YYYY-MM format")
+		return
+	}
+	end := start.AddDate(0, 1, -1)
+
+	// Paid amounts take precedence over the plan; skipped bills owe nothing
+	rows, err := h.db.Query(ctx, `
+		SELECT ba.id, b.id, b.name, COALESCE(b.shared_with, ''), pp.pay_date,
+		       COALESCE(ba.actual_amount, ba.planned_amount, 0), b.shared_percent
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE pp.pay_date >= $1 AND pp.pay_date <= $2
+		  AND b.shared_percent > 0
+		  AND ba.status <> 'skipped'
+		ORDER BY pp.pay_date, b.sort_order, b.id
+	`, start, end)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	statement := OwedStatement{
+		Month:   month,
+		Items:   []OwedItem{},
+		ByParty: map[string]float64{},
+	}
+	for rows.Next() {
+		var item OwedItem
+		var payDate time.Time
+		if err := rows.Scan(&item.AssignmentID, &item.BillID, &item.BillName, &item.SharedWith,
+			&payDate, &item.Amount, &item.SharedPercent); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		item.PayDate = payDate.Format("2006-01-02")
+		item.Owed = roundCents(item.Amount * item.SharedPercent / 100)
+
+		statement.Items = append(statement.Items, item)
+		statement.ByParty[item.SharedWith] = roundCents(statement.ByParty[item.SharedWith] + item.Owed)
+		statement.TotalOwed = roundCents(statement.TotalOwed + item.Owed)
+	}
+
+	models.WriteJSON(w, http.StatusOK, statement)
+}
+
+// roundCents rounds a currency amount to two decimal places.
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+type AllowanceMonth struct {
+	Dependent string  `json:"dependent"`
+	Month     string  `json:"month"` // YYYY-MM
+	Planned   float64 `json:"planned"`
+	Given     float64 `json:"given"`
+}
+
+type AllowanceReport struct {
+	From       string             `json:"from"` // YYYY-MM
+	To         string             `json:"to"`   // YYYY-MM
+	Months     []AllowanceMonth   `json:"months"`
+	GivenTotal map[string]float64 `json:"given_total"` // keyed by dependent
+}
+
+// Allowances reports allowance amounts planned and given per dependent per month.
+// GET /api/v1/reports/allowances?from=YYYY-MM&to=YYYY-MM (defaults to the current year)
+func (h *ReportHandler) Allowances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fromStr := r.URL.Query().Get("from")
+	toStr := r.URL.Query().Get("to")
+	if fromStr == "" || toStr == "" {
+		year := time.Now().Year()
+		fromStr = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+		toStr = time.Date(year, 12, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	}
+	from, err := time.Parse("2006-01", fromStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "from must be in YYYY-MM format")
+		return
+	}
+	to, err := time.Parse("2006-01", toStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must be in YYYY-MM format")
+		return
+	}
+
+	// An allowance counts as given once its assignment is marked paid. Months
+	// follow the occurrence due date when known, else the pay date.
+	rows, err := h.db.Query(ctx, `
+		SELECT b.dependent, to_char(COALESCE(ba.due_date, pp.pay_date), 'YYYY-MM') AS month,
+		       COALESCE(SUM(ba.planned_amount), 0),
+		       COALESCE(SUM(COALESCE(ba.actual_amount, ba.planned_amount)) FILTER (WHERE ba.status = 'paid'), 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE b.bill_type = 'allowance'
+		  AND COALESCE(ba.due_date, pp.pay_date) >= $1 AND COALESCE(ba.due_date, pp.pay_date) <= $2
+		GROUP BY b.dependent, month
+		ORDER BY b.dependent, month
+	`, from, to.AddDate(0, 1, -1))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	report := AllowanceReport{
+		From:       fromStr,
+		To:         toStr,
+		Months:     []AllowanceMonth{},
+		GivenTotal: map[string]float64{},
+	}
+	for rows.Next() {
+		var m AllowanceMonth
+		if err := rows.Scan(&m.Dependent, &m.Month, &m.Planned, &m.Given); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		report.Months = append(report.Months, m)
+		report.GivenTotal[m.Dependent] = roundCents(report.GivenTotal[m.Dependent] + m.Given)
+	}
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+type ContributionMonth struct {
+	Member  string  `json:"member"` // "" when nobody was recorded
+	Account string  `json:"account"`
+	Month   string  `json:"month"` // YYYY-MM
+	Paid    float64 `json:"paid"`
+	Count   int     `json:"count"`
+}
+
+type ContributionReport struct {
+	From     string              `json:"from"` // YYYY-MM
+	To       string              `json:"to"`   // YYYY-MM
+	Months   []ContributionMonth `json:"months"`
+	ByMember map[string]float64  `json:"by_member"`
+	Share    map[string]float64  `json:"share"` // percent of Total, keyed by member
+	Total    float64             `json:"total"`
+}
+
+// Contributions totals what each household member paid per month, split by
+// the account it came from.
+// GET /api/v1/reports/contributions?from=YYYY-MM&to=YYYY-MM (defaults to the current year)
+func (h *ReportHandler) Contributions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fromStr := r.URL.Query().Get("from")
+	toStr := r.URL.Query().Get("to")
+	if fromStr == "" || toStr == "" {
+		year := time.Now().Year()
+		fromStr = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+		toStr = time.Date(year, 12, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	}
+	from, err := time.Parse("2006-01", fromStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "from must be in YYYY-MM format")
+		return
+	}
+	to, err := time.Parse("2006-01", toStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must be in YYYY-MM format")
+		return
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return
+	}
+
+	// Only paid assignments count, in the month they were paid
+	rows, err := h.db.Query(ctx, `
+		SELECT ba.paid_by, ba.paid_from, to_char(COALESCE(ba.paid_date, pp.pay_date), 'YYYY-MM') AS month,
+		       COALESCE(SUM(COALESCE(ba.actual_amount, ba.planned_amount)), 0), COUNT(*)
+		FROM bill_assignments ba
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status = 'paid'
+		  AND COALESCE(ba.paid_date, pp.pay_date) >= $1 AND COALESCE(ba.paid_date, pp.pay_date) <= $2
+		GROUP BY ba.paid_by, ba.paid_from, month
+		ORDER BY month, ba.paid_by, ba.paid_from
+	`, from, to.AddDate(0, 1, -1))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	report := ContributionReport{
+		From:     fromStr,
+		To:       toStr,
+		Months:   []ContributionMonth{},
+		ByMember: map[string]float64{},
+		Share:    map[string]float64{},
+	}
+	for rows.Next() {
+		var m ContributionMonth
+		if err := rows.Scan(&m.Member, &m.Account, &m.Month, &m.Paid, &m.Count); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		m.Paid = roundCents(m.Paid)
+		report.Months = append(report.Months, m)
+		report.ByMember[m.Member] = roundCents(report.ByMember[m.Member] + m.Paid)
+		report.Total = roundCents(report.Total + m.Paid)
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	for member, paid := range report.ByMember {
+		if report.Total > 0 {
+			report.Share[member] = math.Round(paid/report.Total*1000) / 10
+		}
+	}
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+type MonthlySummary struct {
+	Month            string  `json:"month"` // YYYY-MM
+	Paychecks        int     `json:"paychecks"`
+	ExpectedIncome   float64 `json:"expected_income"`
+	PlannedBills     float64 `json:"planned_bills"`
+	ActualPaid       float64 `json:"actual_paid"`
+	Leftover         float64 `json:"leftover"` // expected income less planned bills
+	SurplusPaychecks int     `json:"surplus_paychecks"`
+}
+
+type MonthlyReport struct {
+	Year   int              `json:"year"`
+	Months []MonthlySummary `json:"months"` // always January through December
+	Totals MonthlySummary   `json:"totals"` // Month is empty
+}
+
+// Monthly totals income and bills per month of a year. Bills count in the
+// month of the paycheck they are assigned to, so leftover is what those
+// paychecks have not been budgeted for. Surplus paychecks are the checks a
+// source paid beyond what its schedule normally pays in a month.
+// GET /api/v1/reports/monthly?year=YYYY (defaults to the current year)
+func (h *ReportHandler) Monthly(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	year := time.Now().Year()
+	if v := r.URL.Query().Get("year"); v != "" {
+		y, err := strconv.Atoi(v)
+		if err != nil || y < 1900 || y > 9999 {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "year must be a four-digit year")
+			return
+		}
+		year = y
+	}
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	report := MonthlyReport{Year: year, Months: make([]MonthlySummary, 12)}
+	for i := range report.Months {
+		report.Months[i].Month = from.AddDate(0, i, 0).Format("2006-01")
+	}
+	monthIndex := func(month string) (int, bool) {
+		t, err := time.Parse("2006-01", month)
+		if err != nil || t.Year() != year {
+			return 0, false
+		}
+		return int(t.Month()) - 1, true
+	}
+
+	// Paychecks per source, so each source is measured against its own schedule
+	rows, err := h.db.Query(ctx, `
+		SELECT to_char(pp.pay_date, 'YYYY-MM') AS month, inc.pay_schedule, inc.schedule_detail,
+		       COUNT(*), COALESCE(SUM(pp.expected_amount), 0)
+		FROM pay_periods pp
+		LEFT JOIN income_sources inc ON inc.id = pp.income_source_id
+		WHERE pp.pay_date BETWEEN $1 AND $2
+		GROUP BY month, inc.id, inc.pay_schedule, inc.schedule_detail
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var month string
+		var schedule *string
+		var source models.IncomeSource
+		var count int
+		var expected float64
+		if err := rows.Scan(&month, &schedule, &source.ScheduleDetail, &count, &expected); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		i, ok := monthIndex(month)
+		if !ok {
+			continue
+		}
+		m := &report.Months[i]
+		m.Paychecks += count
+		m.ExpectedIncome += expected
+		if schedule != nil {
+			source.PaySchedule = *schedule
+			if normal, ok := services.ExpectedChecksPerMonth(source); ok && count > normal {
+				m.SurplusPaychecks += count - normal
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	rows.Close()
+
+	// Skipped and deferred assignments are not spent from their paycheck
+	rows, err = h.db.Query(ctx, `
+		SELECT to_char(pp.pay_date, 'YYYY-MM') AS month,
+		       COALESCE(SUM(`+netPlannedAmount+`), 0),
+		       COALESCE(SUM(`+netAssignmentAmount(models.AmountModeActualPreferred)+`) FILTER (WHERE ba.status = 'paid'), 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE pp.pay_date BETWEEN $1 AND $2
+		  AND ba.status NOT IN ('skipped', 'deferred')
+		GROUP BY month
+	`, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var month string
+		var planned, paid float64
+		if err := rows.Scan(&month, &planned, &paid); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if i, ok := monthIndex(month); ok {
+			report.Months[i].PlannedBills += planned
+			report.Months[i].ActualPaid += paid
+		}
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	t := &report.Totals
+	for i := range report.Months {
+		m := &report.Months[i]
+		m.ExpectedIncome = roundCents(m.ExpectedIncome)
+		m.PlannedBills = roundCents(m.PlannedBills)
+		m.ActualPaid = roundCents(m.ActualPaid)
+		m.Leftover = roundCents(m.ExpectedIncome - m.PlannedBills)
+
+		t.Paychecks += m.Paychecks
+		t.ExpectedIncome = roundCents(t.ExpectedIncome + m.ExpectedIncome)
+		t.PlannedBills = roundCents(t.PlannedBills + m.PlannedBills)
+		t.ActualPaid = roundCents(t.ActualPaid + m.ActualPaid)
+		t.SurplusPaychecks += m.SurplusPaychecks
+	}
+	t.Leftover = roundCents(t.ExpectedIncome - t.PlannedBills)
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+type CategoryTrendMonth struct {
+	Month         string   `json:"month"` // YYYY-MM
+	Total         float64  `json:"total"`
+	PercentChange *float64 `json:"percent_change"` // from the month before; nil when that was 0 or is out of range
+}
+
+type CategoryTrend struct {
+	Category string               `json:"category"` // "" for uncategorized bills
+	Months   []CategoryTrendMonth `json:"months"`   // every month from from to to
+	Total    float64              `json:"total"`
+}
+
+type CategoryTrendReport struct {
+	From        string          `json:"from"` // YYYY-MM
+	To          string          `json:"to"`   // YYYY-MM
+	Categories  []CategoryTrend `json:"categories"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// Categories totals spending per category per month with the change from
+// the month before. Assignments count in the month they are due (else their
+// pay date) at their actual amount once known, net of shares others pay;
+// skipped and deferred ones are left out. Reports are cached briefly since
+// dashboards reload the same range often.
+// GET /api/v1/reports/categories?from=YYYY-MM&to=YYYY-MM (defaults to the last 12 months)
+func (h *ReportHandler) Categories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fromStr := r.URL.Query().Get("from")
+	toStr := r.URL.Query().Get("to")
+	if fromStr == "" || toStr == "" {
+		now := time.Now()
+		toStr = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+		fromStr = time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	}
+	from, err := time.Parse("2006-01", fromStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "from must be in YYYY-MM format")
+		return
+	}
+	to, err := time.Parse("2006-01", toStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must be in YYYY-MM format")
+		return
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return
+	}
+	if from.AddDate(5, 0, 0).Before(to) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "range must not exceed 5 years")
+		return
+	}
+
+	key := fromStr + "|" + toStr
+	now := time.Now()
+	h.mu.Lock()
+	cached, ok := h.trends[key]
+	h.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		models.WriteJSON(w, http.StatusOK, cached.report)
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT b.category, to_char(COALESCE(ba.due_date, pp.pay_date), 'YYYY-MM') AS month,
+		       COALESCE(SUM(`+netAssignmentAmount(models.AmountModeActualPreferred)+`), 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status NOT IN ('skipped', 'deferred')
+		  AND COALESCE(ba.due_date, pp.pay_date) >= $1 AND COALESCE(ba.due_date, pp.pay_date) <= $2
+		GROUP BY b.category, month
+		ORDER BY b.category, month
+	`, from, to.AddDate(0, 1, -1))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	var months []string
+	for m := from; !m.After(to); m = m.AddDate(0, 1, 0) {
+		months = append(months, m.Format("2006-01"))
+	}
+	report := CategoryTrendReport{From: fromStr, To: toStr, Categories: []CategoryTrend{}, GeneratedAt: now}
+	totals := map[string]map[string]float64{}
+	for rows.Next() {
+		var category, month string
+		var total float64
+		if err := rows.Scan(&category, &month, &total); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if totals[category] == nil {
+			totals[category] = map[string]float64{}
+			report.Categories = append(report.Categories, CategoryTrend{Category: category})
+		}
+		totals[category][month] = total
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	for i := range report.Categories {
+		c := &report.Categories[i]
+		c.Months = make([]CategoryTrendMonth, len(months))
+		for j, month := range months {
+			m := CategoryTrendMonth{Month: month, Total: roundCents(totals[c.Category][month])}
+			if j > 0 {
+				if prev := c.Months[j-1].Total; prev != 0 {
+					change := roundCents((m.Total - prev) / prev * 100)
+					m.PercentChange = &change
+				}
+			}
+			c.Months[j] = m
+			c.Total = roundCents(c.Total + m.Total)
+		}
+	}
+
+	h.mu.Lock()
+	h.trends[key] = cachedCategoryTrends{report: report, expires: now.Add(categoryTrendTTL)}
+	h.mu.Unlock()
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+type BillVariance struct {
+	BillID        int     `json:"bill_id"`
+	Name          string  `json:"name"`
+	BufferPercent float64 `json:"buffer_percent"`
+	Paid          int     `json:"paid"` // paid assignments with an actual amount
+	Planned       float64 `json:"planned"`
+	Actual        float64 `json:"actual"`
+	Buffer        float64 `json:"buffer"`        // part of Planned that was buffer
+	UnusedBuffer  float64 `json:"unused_buffer"` // buffer the actual amount didn't need
+	Overrun       float64 `json:"overrun"`       // actual beyond the planned amount
+}
+
+type PlannedVsActualReport struct {
+	From         string         `json:"from"` // YYYY-MM
+	To           string         `json:"to"`   // YYYY-MM
+	Bills        []BillVariance `json:"bills"`
+	Planned      float64        `json:"planned"`
+	Actual       float64        `json:"actual"`
+	Buffer       float64        `json:"buffer"`
+	UnusedBuffer float64        `json:"unused_buffer"`
+	Overrun      float64        `json:"overrun"`
+}
+
+// PlannedVsActual compares what was planned for each bill with what was
+// actually paid, and how much of a bill's buffer went unused. The buffer in a
+// planned amount is worked out from the bill's current buffer_percent, so
+// changing it restates past months too.
+// GET /api/v1/reports/planned-vs-actual?from=YYYY-MM&to=YYYY-MM (defaults to the current year)
+func (h *ReportHandler) PlannedVsActual(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fromStr := r.URL.Query().Get("from")
+	toStr := r.URL.Query().Get("to")
+	if fromStr == "" || toStr == "" {
+		year := time.Now().Year()
+		fromStr = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+		toStr = time.Date(year, 12, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	}
+	from, err := time.Parse("2006-01", fromStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "from must be in YYYY-MM format")
+		return
+	}
+	to, err := time.Parse("2006-01", toStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must be in YYYY-MM format")
+		return
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return
+	}
+
+	// A planned amount p with buffer b% holds p*b/(100+b) of buffer. Only
+	// the part of it the actual amount stayed under counts as unused.
+	rows, err := h.db.Query(ctx, `
+		WITH paid AS (
+			SELECT b.id, b.name, b.buffer_percent, ba.planned_amount, ba.actual_amount,
+			       ba.planned_amount * b.buffer_percent / (100 + b.buffer_percent) AS buffer
+			FROM bill_assignments ba
+			JOIN bills b ON b.id = ba.bill_id
+			JOIN pay_periods pp ON pp.id = ba.pay_period_id
+			WHERE ba.status = 'paid' AND ba.planned_amount IS NOT NULL AND ba.actual_amount IS NOT NULL
+			  AND COALESCE(ba.paid_date, pp.pay_date) >= $1 AND COALESCE(ba.paid_date, pp.pay_date) <= $2
+		)
+		SELECT id, name, buffer_percent, COUNT(*), SUM(planned_amount), SUM(actual_amount), SUM(buffer),
+		       SUM(LEAST(GREATEST(planned_amount - actual_amount, 0), buffer)),
+		       SUM(GREATEST(actual_amount - planned_amount, 0))
+		FROM paid
+		GROUP BY id, name, buffer_percent
+		ORDER BY name, id
+	`, from, to.AddDate(0, 1, -1))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	report := PlannedVsActualReport{From: fromStr, To: toStr, Bills: []BillVariance{}}
+	for rows.Next() {
+		var v BillVariance
+		if err := rows.Scan(&v.BillID, &v.Name, &v.BufferPercent, &v.Paid, &v.Planned, &v.Actual,
+			&v.Buffer, &v.UnusedBuffer, &v.Overrun); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		v.Planned = roundCents(v.Planned)
+		v.Actual = roundCents(v.Actual)
+		v.Buffer = roundCents(v.Buffer)
+		v.UnusedBuffer = roundCents(v.UnusedBuffer)
+		v.Overrun = roundCents(v.Overrun)
+		report.Bills = append(report.Bills, v)
+		report.Planned = roundCents(report.Planned + v.Planned)
+		report.Actual = roundCents(report.Actual + v.Actual)
+		report.Buffer = roundCents(report.Buffer + v.Buffer)
+		report.UnusedBuffer = roundCents(report.UnusedBuffer + v.UnusedBuffer)
+		report.Overrun = roundCents(report.Overrun + v.Overrun)
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+// DisputeLine is one disputed assignment. DaysOpen is set while it is open,
+// ResolvedOn and CreditReceived once it is resolved.
+type DisputeLine struct {
+	AssignmentID   int      `json:"assignment_id"`
+	BillID         int      `json:"bill_id"`
+	Name           string   `json:"name"`
+	PayDate        string   `json:"pay_date"`
+	Amount         float64  `json:"amount"`
+	OpenedOn       string   `json:"opened_on"`
+	Reference      string   `json:"reference"`
+	ExpectedCredit float64  `json:"expected_credit"`
+	DaysOpen       int      `json:"days_open,omitempty"`
+	ResolvedOn     *string  `json:"resolved_on,omitempty"`
+	CreditReceived *float64 `json:"credit_received,omitempty"`
+}
+
+type DisputeReport struct {
+	From            string        `json:"from"` // YYYY-MM
+	To              string        `json:"to"`   // YYYY-MM
+	Outstanding     []DisputeLine `json:"outstanding"`
+	ExpectedCredit  float64       `json:"expected_credit"` // still owed on outstanding disputes
+	Resolved        []DisputeLine `json:"resolved"`
+	CreditsReceived float64       `json:"credits_received"`
+	Shortfall       float64       `json:"shortfall"` // expected on resolved disputes but not received
+}
+
+// Disputes lists every open dispute, oldest first, and the disputes resolved
+// from through to with the credits received for them.
+// GET /api/v1/reports/disputes?from=YYYY-MM&to=YYYY-MM (defaults to the current year)
+func (h *ReportHandler) Disputes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fromStr := r.URL.Query().Get("from")
+	toStr := r.URL.Query().Get("to")
+	if fromStr == "" || toStr == "" {
+		year := time.Now().Year()
+		fromStr = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+		toStr = time.Date(year, 12, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	}
+	from, err := time.Parse("2006-01", fromStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "from must be in YYYY-MM format")
+		return
+	}
+	to, err := time.Parse("2006-01", toStr)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must be in YYYY-MM format")
+		return
+	}
+	if to.Before(from) {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "to must not be before from")
+		return
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT ba.id, ba.bill_id,
+		       CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       pp.pay_date, COALESCE(ba.actual_amount, ba.planned_amount, 0),
+		       ad.opened_on, ad.reference, ad.expected_credit, ad.resolved_on, ad.credit_received
+		FROM assignment_disputes ad
+		JOIN bill_assignments ba ON ba.id = ad.assignment_id
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ad.resolved_on IS NULL OR (ad.resolved_on >= $1 AND ad.resolved_on <= $2)
+		ORDER BY ad.opened_on, ad.id
+	`, from, to.AddDate(0, 1, -1))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	report := DisputeReport{From: fromStr, To: toStr, Outstanding: []DisputeLine{}, Resolved: []DisputeLine{}}
+	for rows.Next() {
+		var l DisputeLine
+		var payDate, openedOn time.Time
+		var resolvedOn *time.Time
+		if err := rows.Scan(&l.AssignmentID, &l.BillID, &l.Name, &payDate, &l.Amount,
+			&openedOn, &l.Reference, &l.ExpectedCredit, &resolvedOn, &l.CreditReceived); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		l.PayDate = payDate.Format("2006-01-02")
+		l.OpenedOn = openedOn.Format("2006-01-02")
+		if resolvedOn == nil {
+			l.DaysOpen = int(today.Sub(openedOn).Hours() / 24)
+			report.Outstanding = append(report.Outstanding, l)
+			report.ExpectedCredit = roundCents(report.ExpectedCredit + l.ExpectedCredit)
+			continue
+		}
+		resolved := resolvedOn.Format("2006-01-02")
+		l.ResolvedOn = &resolved
+		received := 0.0
+		if l.CreditReceived != nil {
+			received = *l.CreditReceived
+		}
+		report.Resolved = append(report.Resolved, l)
+		report.CreditsReceived = roundCents(report.CreditsReceived + received)
+		if received < l.ExpectedCredit {
+			report.Shortfall = roundCents(report.Shortfall + l.ExpectedCredit - received)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+type TaxDeductibleItem struct {
+	AssignmentID int     `json:"assignment_id"`
+	PayDate      string  `json:"pay_date"`
+	Payee        string  `json:"payee"`
+	Category     string  `json:"category"`
+	Amount       float64 `json:"amount"`
+}
+
+type TaxDeductibleReport struct {
+	Year    int                 `json:"year"`
+	Items   []TaxDeductibleItem `json:"items"`
+	ByPayee map[string]float64  `json:"by_payee"`
+	Total   float64             `json:"total"`
+}
+
+// TaxDeductible sums paid amounts for deductible bills and extras in a calendar year.
+// GET /api/v1/reports/tax-deductible/{year}?format=csv
+func (h *ReportHandler) TaxDeductible(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil || year < 1900 || year > 9999 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "year must be a four-digit year")
+		return
+	}
+
+	// Extras are named by extra_name; regular bills by the bill name
+	rows, err := h.db.Query(ctx, `
+		SELECT ba.id, pp.pay_date,
+		       CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       COALESCE(b.category, ''),
+		       COALESCE(ba.actual_amount, ba.planned_amount, 0)
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status = 'paid'
+		  AND (b.tax_deductible OR ba.tax_deductible)
+		  AND pp.pay_date >= $1 AND pp.pay_date <= $2
+		ORDER BY pp.pay_date, ba.id
+	`, time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	report := TaxDeductibleReport{
+		Year:    year,
+		Items:   []TaxDeductibleItem{},
+		ByPayee: map[string]float64{},
+	}
+	for rows.Next() {
+		var item TaxDeductibleItem
+		var payDate time.Time
+		if err := rows.Scan(&item.AssignmentID, &payDate, &item.Payee, &item.Category, &item.Amount); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		item.PayDate = payDate.Format("2006-01-02")
+		report.Items = append(report.Items, item)
+		report.ByPayee[item.Payee] = roundCents(report.ByPayee[item.Payee] + item.Amount)
+		report.Total = roundCents(report.Total + item.Amount)
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		writeTaxDeductibleCSV(w, report)
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, report)
+}
+
+func writeTaxDeductibleCSV(w http.ResponseWriter, report TaxDeductibleReport) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tax-deductible-%d.csv"`, report.Year))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "payee", "category", "amount"})
+	for _, item := range report.Items {
+		cw.Write([]string{item.PayDate, item.Payee, item.Category, strconv.FormatFloat(item.Amount, 'f', 2, 64)})
+	}
+	cw.Write([]string{"", "TOTAL", "", strconv.FormatFloat(report.Total, 'f', 2, 64)})
+	cw.Flush()
+}
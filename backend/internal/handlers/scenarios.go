@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/db"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// scenarioTables are copied into a scenario with their rows, and promoted in
+// this order so new assignments find their bills and periods. Only
+// assignments are removed on promote: the sandbox can't delete bills or
+// periods, so any missing from it were added to the live budget since.
+var scenarioTables = []string{"bills", "pay_periods", "bill_assignments"}
+
+// scenarioScratchTables are created empty in a scenario. The sandbox routes
+// write to them too, saving optimizer plans and layouts and learning
+// categories from recategorized bills, and that should stay in the sandbox.
+var scenarioScratchTables = []string{"optimizer_plans", "optimizer_layouts", "category_corrections"}
+
+// scenarioSchema names the schema holding scenario id's tables.
+func scenarioSchema(id int) string {
+	return fmt.Sprintf("scenario_%d", id)
+}
+
+// ScenarioHandler manages what-if scenarios: sandboxed copies of the budget
+// that the bill, assignment, grid and optimizer handlers work on when mounted
+// behind Sandbox.
+type ScenarioHandler struct {
+	db DBTX
+}
+
+func NewScenarioHandler(db DBTX) *ScenarioHandler {
+	return &ScenarioHandler{db: db}
+}
+
+const scenarioCols = `id, name, description, status, created_by, created_at, promoted_at`
+
+func scanScenario(row pgx.Row) (models.Scenario, error) {
+	var s models.Scenario
+	err := row.Scan(&s.ID, &s.Name, &s.Description, &s.Status, &s.CreatedBy, &s.CreatedAt, &s.PromotedAt)
+	return s, err
+}
+
+func scenarioID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "scenario_id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "scenario_id must be an integer")
+		return 0, false
+	}
+	return id, true
+}
+
+// List returns every scenario, newest first.
+// GET /api/v1/scenarios
+func (h *ScenarioHandler) List(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), `SELECT `+scenarioCols+` FROM scenarios ORDER BY id DESC`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	scenarios := []models.Scenario{}
+	for rows.Next() {
+		s, err := scanScenario(rows)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		scenarios = append(scenarios, s)
+	}
+
+	models.WriteJSON(w, http.StatusOK, scenarios)
+}
+
+// Create clones the live bills, pay periods and assignments into a new
+// scenario. Its copies share the live id sequences, so rows added in the
+// sandbox keep their ids when promoted.
+// POST /api/v1/scenarios
+func (h *ScenarioHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.CreateScenarioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "name is required")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := scanScenario(tx.QueryRow(ctx, `
+		INSERT INTO scenarios (name, description, base_audit_id, created_by)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(id), 0) FROM audit_log), $3)
+		RETURNING `+scenarioCols,
+		req.Name, req.Description, auth.Username(ctx)))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := cloneScenario(ctx, tx, scenarioSchema(s.ID)); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusCreated, s)
+}
+
+// cloneScenario creates schema with copies of the scenario tables and empty
+// scratch tables. LIKE copies defaults, checks and indexes but not triggers,
+// so sandbox changes are not audited, nor foreign keys, so the assignments'
+// are added back.
+func cloneScenario(ctx context.Context, tx pgx.Tx, schema string) error {
+	s := pgx.Identifier{schema}.Sanitize()
+	if _, err := tx.Exec(ctx, `CREATE SCHEMA `+s); err != nil {
+		return err
+	}
+	for _, t := range scenarioTables {
+		ident := pgx.Identifier{t}.Sanitize()
+		if _, err := tx.Exec(ctx, `CREATE TABLE `+s+`.`+ident+` (LIKE `+ident+` INCLUDING ALL)`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO `+s+`.`+ident+` SELECT * FROM `+ident); err != nil {
+			return err
+		}
+	}
+	for _, t := range scenarioScratchTables {
+		ident := pgx.Identifier{t}.Sanitize()
+		if _, err := tx.Exec(ctx, `CREATE TABLE `+s+`.`+ident+` (LIKE `+ident+` INCLUDING ALL)`); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(ctx, `
+		ALTER TABLE `+s+`.bill_assignments
+			ADD FOREIGN KEY (bill_id) REFERENCES `+s+`.bills (id),
+			ADD FOREIGN KEY (pay_period_id) REFERENCES `+s+`.pay_periods (id)
+	`)
+	return err
+}
+
+// Get returns a scenario and, while it is open, how many bills, periods and
+// assignments its copies hold.
+// GET /api/v1/scenarios/{scenario_id}
+func (h *ScenarioHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := scenarioID(w, r)
+	if !ok {
+		return
+	}
+
+	s, err := scanScenario(h.db.QueryRow(ctx, `SELECT `+scenarioCols+` FROM scenarios WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "scenario not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if s.Status == "open" {
+		schema := pgx.Identifier{scenarioSchema(id)}.Sanitize()
+		var bills, periods, assignments int
+		err := h.db.QueryRow(ctx, `
+			SELECT (SELECT COUNT(*) FROM `+schema+`.bills),
+			       (SELECT COUNT(*) FROM `+schema+`.pay_periods),
+			       (SELECT COUNT(*) FROM `+schema+`.bill_assignments)
+		`).Scan(&bills, &periods, &assignments)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		s.Bills, s.Periods, s.Assignments = &bills, &periods, &assignments
+	}
+
+	models.WriteJSON(w, http.StatusOK, s)
+}
+
+// Sandbox serves the routes it wraps from scenario {scenario_id}'s copies of
+// the budget tables. Only handlers that write nothing but those tables belong
+// behind it; anything else they wrote would land in the live budget.
+func (h *ScenarioHandler) Sandbox(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := scenarioID(w, r)
+		if !ok {
+			return
+		}
+
+		var status string
+		err := h.db.QueryRow(r.Context(), `SELECT status FROM scenarios WHERE id = $1`, id).Scan(&status)
+		if errors.Is(err, pgx.ErrNoRows) {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "scenario not found")
+			return
+		}
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if status != "open" {
+			models.WriteError(w, http.StatusConflict, "CONFLICT", "scenario has already been promoted")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(db.WithScenario(r.Context(), scenarioSchema(id))))
+	})
+}
+
+// Discard deletes a scenario and its copies.
+// DELETE /api/v1/scenarios/{scenario_id}
+func (h *ScenarioHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := scenarioID(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM scenarios WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "scenario not found")
+		return
+	}
+	if _, err := tx.Exec(ctx, `DROP SCHEMA IF EXISTS `+pgx.Identifier{scenarioSchema(id)}.Sanitize()+` CASCADE`); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Promote makes a scenario the live budget in one transaction: changed bills,
+// periods and assignments are updated, assignments added in the sandbox are
+// inserted and those removed there are deleted. The scenario is then marked
+// promoted and its copies dropped. If bills, periods or assignments have
+// changed in the live budget since the scenario was cloned it answers 409,
+// unless ?force=true, in which case the scenario's assignments win.
+// POST /api/v1/scenarios/{scenario_id}/promote
+func (h *ScenarioHandler) Promote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := scenarioID(w, r)
+	if !ok {
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	var baseAuditID int64
+	err = tx.QueryRow(ctx, `SELECT status, base_audit_id FROM scenarios WHERE id = $1 FOR UPDATE`, id).Scan(&status, &baseAuditID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "scenario not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if status != "open" {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "scenario has already been promoted")
+		return
+	}
+
+	if !force {
+		var changes int
+		err := tx.QueryRow(ctx, `
+			SELECT COUNT(*) FROM audit_log
+			WHERE id > $1 AND entity IN ('bill', 'period', 'assignment')
+		`, baseAuditID).Scan(&changes)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if changes > 0 {
+			models.WriteError(w, http.StatusConflict, "CONFLICT",
+				fmt.Sprintf("the live budget has had %d changes since this scenario was created; promote with ?force=true to overwrite them", changes))
+			return
+		}
+	}
+
+	schema := scenarioSchema(id)
+	result, err := promoteScenario(ctx, tx, schema)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == "23505" || pgErr.Code == "23503") {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "the scenario no longer fits the live budget: "+pgErr.Message)
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	result.ScenarioID = id
+
+	if _, err := tx.Exec(ctx, `UPDATE scenarios SET status = 'promoted', promoted_at = NOW() WHERE id = $1`, id); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if _, err := tx.Exec(ctx, `DROP SCHEMA `+pgx.Identifier{schema}.Sanitize()+` CASCADE`); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
+
+// promoteScenario copies schema's tables over the live ones. Only columns
+// both have are copied, so a scenario cloned before a migration added one
+// leaves it as the live budget has it.
+func promoteScenario(ctx context.Context, tx pgx.Tx, schema string) (*models.ScenarioPromoteResult, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT l.table_name, l.column_name
+		FROM information_schema.columns l
+		JOIN information_schema.columns s
+		  ON s.table_schema = $2 AND s.table_name = l.table_name AND s.column_name = l.column_name
+		WHERE l.table_schema = current_schema() AND l.table_name = ANY($1) AND l.column_name <> 'id'
+		ORDER BY l.table_name, l.ordinal_position
+	`, scenarioTables, schema)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string][]string)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		columns[table] = append(columns[table], column)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	s := pgx.Identifier{schema}.Sanitize()
+	result := &models.ScenarioPromoteResult{Tables: map[string]models.ScenarioTableResult{}}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM bill_assignments WHERE id NOT IN (SELECT id FROM `+s+`.bill_assignments)`)
+	if err != nil {
+		return nil, err
+	}
+	removed := int(tag.RowsAffected())
+
+	for _, t := range scenarioTables {
+		if len(columns[t]) == 0 {
+			return nil, fmt.Errorf("scenario table %s not found", t)
+		}
+		ident := pgx.Identifier{t}.Sanitize()
+		live := make([]string, len(columns[t]))
+		copied := make([]string, len(columns[t]))
+		names := make([]string, len(columns[t]))
+		for i, c := range columns[t] {
+			col := pgx.Identifier{c}.Sanitize()
+			names[i] = col
+			live[i] = "l." + col
+			copied[i] = "s." + col
+		}
+		list := strings.Join(names, ", ")
+
+		var counts models.ScenarioTableResult
+		tag, err := tx.Exec(ctx, `
+			UPDATE `+ident+` AS l SET (`+list+`) = ROW(`+strings.Join(copied, ", ")+`)
+			FROM `+s+`.`+ident+` AS s
+			WHERE l.id = s.id AND ROW(`+strings.Join(live, ", ")+`) IS DISTINCT FROM ROW(`+strings.Join(copied, ", ")+`)
+		`)
+		if err != nil {
+			return nil, err
+		}
+		counts.Updated = int(tag.RowsAffected())
+
+		tag, err = tx.Exec(ctx, `
+			INSERT INTO `+ident+` (id, `+list+`)
+			SELECT id, `+list+` FROM `+s+`.`+ident+`
+			WHERE id NOT IN (SELECT id FROM `+ident+`)
+		`)
+		if err != nil {
+			return nil, err
+		}
+		counts.Added = int(tag.RowsAffected())
+		if t == "bill_assignments" {
+			counts.Removed = removed
+		}
+		result.Tables[t] = counts
+	}
+	return result, nil
+}
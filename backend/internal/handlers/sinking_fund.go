@@ -74,12 +74,13 @@ func (h *SinkingFundHandler) Plan(w http.ResponseWriter, r *http.Request) {
 		SELECT pp.id,
 		       pp.pay_date::text,
 		       COALESCE(pp.expected_amount, 0) AS income,
-		       COALESCE(SUM(ba.planned_amount) FILTER (
+		       COALESCE(SUM(`+netPlannedAmount+`) FILTER (
 		           WHERE ba.bill_id IS NOT NULL
 		             AND NOT (ba.is_sinking_fund = true AND ba.bill_id = $3 AND ba.sinking_fund_for_period_id = $2)
 		       ), 0) AS assigned
 		FROM pay_periods pp
 		LEFT JOIN bill_assignments ba ON ba.pay_period_id = pp.id
+		LEFT JOIN bills b ON b.id = ba.bill_id
 		WHERE pp.pay_date < $1
 		GROUP BY pp.id, pp.pay_date
 		ORDER BY pp.pay_date DESC
@@ -161,12 +162,13 @@ func (h *SinkingFundHandler) Apply(w http.ResponseWriter, r *http.Request) {
 		SELECT pp.id,
 		       pp.pay_date::text,
 		       COALESCE(pp.expected_amount, 0) AS income,
-		       COALESCE(SUM(ba.planned_amount) FILTER (
+		       COALESCE(SUM(`+netPlannedAmount+`) FILTER (
 		           WHERE ba.bill_id IS NOT NULL
 		             AND NOT (ba.is_sinking_fund = true AND ba.bill_id = $3 AND ba.sinking_fund_for_period_id = $2)
 		       ), 0) AS assigned
 		FROM pay_periods pp
 		LEFT JOIN bill_assignments ba ON ba.pay_period_id = pp.id
+		LEFT JOIN bills b ON b.id = ba.bill_id
 		WHERE pp.pay_date < $1
 		GROUP BY pp.id, pp.pay_date
 		ORDER BY pp.pay_date DESC
@@ -220,23 +222,18 @@ func (h *SinkingFundHandler) Apply(w http.ResponseWriter, r *http.Request) {
 		var a models.BillAssignment
 		err = tx.QueryRow(ctx, `
 			INSERT INTO bill_assignments
-				(bill_id, pay_period_id, planned_amount, status, manually_moved, is_sinking_fund, sinking_fund_for_period_id)
-			VALUES ($1, $2, $3, 'pending', true, true, $4)
-			ON CONFLICT (bill_id, pay_period_id) DO UPDATE SET
+				(bill_id, pay_period_id, planned_amount, status, manually_moved, is_sinking_fund, sinking_fund_for_period_id, due_date)
+			VALUES ($1, $2, $3, 'pending', true, true, $4,
+			        -- take over the bill's existing assignment in this period, if any
+			        (SELECT MIN(due_date) FROM bill_assignments WHERE bill_id = $1 AND pay_period_id = $2))
+			ON CONFLICT (bill_id, pay_period_id, due_date) DO UPDATE SET
 				planned_amount = EXCLUDED.planned_amount,
 				manually_moved = true,
 				is_sinking_fund = true,
 				sinking_fund_for_period_id = EXCLUDED.sinking_fund_for_period_id,
 				updated_at = NOW()
-			RETURNING id, bill_id, pay_period_id, planned_amount, forecast_amount, actual_amount,
-			          status, deferred_to_id, is_extra, COALESCE(extra_name, ''), COALESCE(notes, ''),
-			          manually_moved, is_sinking_fund, sinking_fund_for_period_id, created_at, updated_at
-		`, billID, inst.PeriodID, amount, req.TargetPeriodID).Scan(
-			&a.ID, &a.BillID, &a.PayPeriodID, &a.PlannedAmount, &a.ForecastAmount,
-			&a.ActualAmount, &a.Status, &a.DeferredToID, &a.IsExtra, &a.ExtraName,
-			&a.Notes, &a.ManuallyMoved, &a.IsSinkingFund, &a.SinkingFundForPeriodID,
-			&a.CreatedAt, &a.UpdatedAt,
-		)
+			RETURNING `+assignmentReturnCols+`
+		`, billID, inst.PeriodID, amount, req.TargetPeriodID).Scan(assignmentScanDest(&a)...)
 		if err != nil {
 			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
 			return
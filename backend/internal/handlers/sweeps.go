@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// sweepExtraName names the extra assignment a sweep creates.
+const sweepExtraName = "Savings sweep"
+
+// SweepHandler suggests moving what is left of a closed paycheck into
+// savings, and makes the move on request.
+type SweepHandler struct {
+	db DBTX
+}
+
+func NewSweepHandler(db DBTX) *SweepHandler {
+	return &SweepHandler{db: db}
+}
+
+// sweepPeriodQuery selects a period's income and spending, and whether it has
+// closed: a later paycheck has arrived by $1. Sweeps already made count as
+// spending, so a swept period has nothing left to suggest.
+var sweepPeriodQuery = `
+	SELECT pp.id, pp.pay_date, COALESCE(inc.name, ''),
+	       COALESCE(pp.actual_amount, pp.expected_amount, 0),
+	       COALESCE((SELECT SUM(` + netAssignmentAmount(models.AmountModeActualPreferred) + `)
+	                 FROM bill_assignments ba
+	                 JOIN bills b ON b.id = ba.bill_id
+	                 WHERE ba.pay_period_id = pp.id AND ba.status NOT IN ('skipped', 'deferred')), 0),
+	       EXISTS (SELECT 1 FROM pay_periods nxt WHERE nxt.pay_date > pp.pay_date AND nxt.pay_date <= $1)
+	FROM pay_periods pp
+	LEFT JOIN income_sources inc ON inc.id = pp.income_source_id
+`
+
+// scanSweep fills s from a sweepPeriodQuery row and works out the amount above
+// threshold, reporting whether the period has closed.
+func scanSweep(row pgx.Row, threshold float64, s *models.SweepSuggestion) (bool, error) {
+	var payDate time.Time
+	var closed bool
+	if err := row.Scan(&s.PeriodID, &payDate, &s.Source, &s.Income, &s.Spent, &closed); err != nil {
+		return false, err
+	}
+	s.PayDate = payDate.Format("2006-01-02")
+	s.Income = math.Round(s.Income*100) / 100
+	s.Spent = math.Round(s.Spent*100) / 100
+	s.Leftover = math.Round((s.Income-s.Spent)*100) / 100
+	s.Threshold = threshold
+	s.Amount = math.Max(0, math.Round((s.Leftover-threshold)*100)/100)
+	return closed, nil
+}
+
+// Suggestions lists closed periods between ?from (default three months ago)
+// and ?to (default today) that ended with more than ?threshold (default 0)
+// left over, and how much could be swept from each.
+// GET /api/v1/sweeps
+func (h *SweepHandler) Suggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	today := sweepToday()
+
+	from, ok := dateQueryParam(w, r, "from", today.AddDate(0, -3, 0))
+	if !ok {
+		return
+	}
+	to, ok := dateQueryParam(w, r, "to", today)
+	if !ok {
+		return
+	}
+	threshold := 0.0
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "threshold must be a non-negative number")
+			return
+		}
+		threshold = f
+	}
+
+	rows, err := h.db.Query(ctx, sweepPeriodQuery+`
+		WHERE pp.pay_date >= $2 AND pp.pay_date <= $3
+		ORDER BY pp.pay_date, pp.id
+	`, today, from, to)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	suggestions := []models.SweepSuggestion{}
+	for rows.Next() {
+		var s models.SweepSuggestion
+		closed, err := scanSweep(rows, threshold, &s)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		if closed && s.Amount > 0 {
+			suggestions = append(suggestions, s)
+		}
+	}
+	models.WriteJSON(w, http.StatusOK, suggestions)
+}
+
+// Create sweeps a closed period's leftover above the threshold into the
+// savings bill given, as a pending extra assignment in that period to mark
+// paid once the transfer is made. It is marked manually moved so auto-assign
+// leaves it alone.
+// POST /api/v1/sweeps
+func (h *SweepHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.CreateSweepRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.PeriodID == 0 || req.BillID == 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "period_id and bill_id are required")
+		return
+	}
+	if req.Threshold < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "threshold must not be negative")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the period so two sweeps can't both see the same leftover
+	var s models.SweepSuggestion
+	closed, err := scanSweep(tx.QueryRow(ctx, sweepPeriodQuery+`WHERE pp.id = $2 FOR UPDATE OF pp`,
+		sweepToday(), req.PeriodID), req.Threshold, &s)
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "pay period not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if !closed {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "the period has not closed; sweep it once the next paycheck arrives")
+		return
+	}
+	if s.Amount <= 0 {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "nothing is left above the threshold to sweep")
+		return
+	}
+
+	a, err := insertSweep(ctx, tx, req.BillID, s)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "bill not found")
+		return
+	}
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "the period already has a sweep to that bill")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusCreated, a)
+}
+
+func insertSweep(ctx context.Context, db DBTX, billID int, s models.SweepSuggestion) (models.BillAssignment, error) {
+	var a models.BillAssignment
+	err := db.QueryRow(ctx, `
+		INSERT INTO bill_assignments (bill_id, pay_period_id, planned_amount, status, is_extra, extra_name, manually_moved, notes)
+		VALUES ($1, $2, $3, 'pending', true, $4, true, $5)
+		RETURNING `+assignmentReturnCols+`
+	`, billID, s.PeriodID, s.Amount, sweepExtraName,
+		fmt.Sprintf("Leftover above $%.2f from the %s paycheck", s.Threshold, s.PayDate),
+	).Scan(assignmentScanDest(&a)...)
+	return a, err
+}
+
+func sweepToday() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// TemplateHandler exchanges starter budgets: the shape of a household's bills
+// and paychecks with the amounts and personal details left out.
+type TemplateHandler struct {
+	db        DBTX
+	generator *services.PeriodGenerator
+}
+
+func NewTemplateHandler(db DBTX) *TemplateHandler {
+	return &TemplateHandler{
+		db:        db,
+		generator: services.NewPeriodGenerator(),
+	}
+}
+
+// templateHash is the SHA-256 of a template's JSON with its hash left out.
+// Marshalling compacts the raw schedule details, so reformatting a template
+// doesn't change its hash.
+func templateHash(t models.BudgetTemplate) (string, error) {
+	t.Hash = ""
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Export returns the active bills and income sources as a shareable template,
+// named by ?name= and ?description=. Amounts, notes, owners, sharing, vendor
+// logins and credit cards are left out, as are allowances, which are named
+// after the household's dependents; income sources are numbered instead of
+// named.
+// GET /api/v1/templates/export
+func (h *TemplateHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	t := models.BudgetTemplate{
+		Version:       models.BudgetTemplateVersion,
+		Name:          strings.TrimSpace(r.URL.Query().Get("name")),
+		Description:   strings.TrimSpace(r.URL.Query().Get("description")),
+		Categories:    []string{},
+		Bills:         []models.TemplateBill{},
+		IncomeSources: []models.TemplateIncomeSource{},
+	}
+	if t.Name == "" {
+		t.Name = "Starter budget"
+	}
+
+	billRows, err := h.db.Query(ctx, `
+		SELECT name, category, due_day, recurrence, recurrence_detail, is_autopay, active_months
+		FROM bills
+		WHERE is_active = true AND bill_type = 'bill'
+		ORDER BY sort_order, id
+	`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer billRows.Close()
+
+	seenCategories := make(map[string]bool)
+	for billRows.Next() {
+		var b models.TemplateBill
+		if err := billRows.Scan(&b.Name, &b.Category, &b.DueDay, &b.Recurrence, &b.RecurrenceDetail, &b.IsAutopay, &b.ActiveMonths); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		t.Bills = append(t.Bills, b)
+		if b.Category != "" && !seenCategories[b.Category] {
+			seenCategories[b.Category] = true
+			t.Categories = append(t.Categories, b.Category)
+		}
+	}
+	billRows.Close()
+
+	incomeRows, err := h.db.Query(ctx, `
+		SELECT pay_schedule, schedule_detail
+		FROM income_sources WHERE is_active = true
+		ORDER BY name, id
+	`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer incomeRows.Close()
+
+	for incomeRows.Next() {
+		s := models.TemplateIncomeSource{Name: fmt.Sprintf("Paycheck %d", len(t.IncomeSources)+1)}
+		if err := incomeRows.Scan(&s.PaySchedule, &s.ScheduleDetail); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		t.IncomeSources = append(t.IncomeSources, s)
+	}
+	incomeRows.Close()
+
+	t.Hash, err = templateHash(t)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="budget-template-%s.json"`, t.Hash[:12]))
+	models.WriteJSON(w, http.StatusOK, t)
+}
+
+// Import loads a template in one transaction: its bills without amounts and
+// its income sources without default pay, ready to fill in. The hash must
+// match the content, so a template edited after it was shared is refused.
+// Anything named like an active bill or income source is skipped.
+// POST /api/v1/templates/import
+func (h *TemplateHandler) Import(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var t models.BudgetTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if t.Version != models.BudgetTemplateVersion {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR",
+			fmt.Sprintf("unsupported template version %d, expected %d", t.Version, models.BudgetTemplateVersion))
+		return
+	}
+	hash, err := templateHash(t)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	if !strings.EqualFold(t.Hash, hash) {
+		models.WriteError(w, http.StatusBadRequest, "HASH_MISMATCH", "the template's hash does not match its content")
+		return
+	}
+
+	// Validate everything up front so a bad entry doesn't leave a partial import
+	bills := make([]models.CreateBillRequest, len(t.Bills))
+	for i, b := range t.Bills {
+		bills[i] = models.CreateBillRequest{
+			Name:             strings.TrimSpace(b.Name),
+			DueDay:           b.DueDay,
+			Recurrence:       b.Recurrence,
+			RecurrenceDetail: b.RecurrenceDetail,
+			IsAutopay:        b.IsAutopay,
+			Category:         b.Category,
+			ActiveMonths:     b.ActiveMonths,
+			SortOrder:        i,
+		}
+		if msg := prepareCreateBill(&bills[i]); msg != "" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("bills[%d]: %s", i, msg))
+			return
+		}
+	}
+	for i, s := range t.IncomeSources {
+		if strings.TrimSpace(s.Name) == "" || !validPaySchedules[s.PaySchedule] {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("income_sources[%d]: name and a valid pay_schedule are required", i))
+			return
+		}
+		if err := h.generator.Validate(models.IncomeSource{PaySchedule: s.PaySchedule, ScheduleDetail: s.ScheduleDetail}); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_SCHEDULE", fmt.Sprintf("income_sources[%d]: %v", i, err))
+			return
+		}
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	result := models.TemplateImportResult{Hash: hash, Skipped: []string{}}
+
+	for _, b := range bills {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM bills WHERE name = $1 AND is_active = true)`, b.Name).Scan(&exists); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if exists {
+			result.Skipped = append(result.Skipped, "bill: "+b.Name)
+			continue
+		}
+		if _, err := insertBill(ctx, tx, b); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		result.ImportedBills++
+	}
+
+	for _, s := range t.IncomeSources {
+		name := strings.TrimSpace(s.Name)
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM income_sources WHERE name = $1 AND is_active = true)`, name).Scan(&exists); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		if exists {
+			result.Skipped = append(result.Skipped, "income source: "+name)
+			continue
+		}
+		req := models.CreateIncomeSourceRequest{Name: name, PaySchedule: s.PaySchedule, ScheduleDetail: s.ScheduleDetail}
+		if _, err := insertIncomeSource(ctx, tx, req, nil); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+			return
+		}
+		result.ImportedIncomeSources++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+type TransactionHandler struct {
+	db DBTX
+}
+
+func NewTransactionHandler(db DBTX) *TransactionHandler {
+	return &TransactionHandler{db: db}
+}
+
+const transactionCols = `id, txn_date, amount, description, source, assignment_id, notes, created_at, updated_at`
+
+func transactionScanDest(t *models.Transaction) []interface{} {
+	return []interface{}{&t.ID, &t.Date, &t.Amount, &t.Description, &t.Source, &t.AssignmentID, &t.Notes, &t.CreatedAt, &t.UpdatedAt}
+}
+
+const (
+	defaultReconcileWindowDays = 5
+	defaultReconcileTolerance  = 1.00
+)
+
+// List returns ledger transactions, newest first.
+// GET /api/v1/transactions?from=&to=&unreconciled=true
+func (h *TransactionHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := `SELECT ` + transactionCols + ` FROM transactions WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if from := r.URL.Query().Get("from"); from != "" {
+		query += " AND txn_date >= $" + strconv.Itoa(argIdx)
+		args = append(args, from)
+		argIdx++
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		query += " AND txn_date <= $" + strconv.Itoa(argIdx)
+		args = append(args, to)
+		argIdx++
+	}
+	if r.URL.Query().Get("unreconciled") == "true" {
+		query += " AND assignment_id IS NULL"
+	}
+	query += " ORDER BY txn_date DESC, id DESC"
+
+	rows, err := h.db.Query(ctx, query, args...)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	txns := []models.Transaction{}
+	for rows.Next() {
+		var t models.Transaction
+		if err := rows.Scan(transactionScanDest(&t)...); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		txns = append(txns, t)
+	}
+
+	models.WriteJSON(w, http.StatusOK, txns)
+}
+
+// Get returns a single transaction.
+// GET /api/v1/transactions/{id}
+func (h *TransactionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var t models.Transaction
+	err = h.db.QueryRow(ctx, `SELECT `+transactionCols+` FROM transactions WHERE id = $1`, id).
+		Scan(transactionScanDest(&t)...)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "transaction not found")
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, t)
+}
+
+// Create records a transaction and reconciles it against unpaid assignments.
+// POST /api/v1/transactions
+func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.CreateTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "date must be in YYYY-MM-DD format")
+		return
+	}
+	if req.Amount <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "amount must be positive")
+		return
+	}
+
+	var t models.Transaction
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO transactions (txn_date, amount, description, source, notes)
+		VALUES ($1, $2, $3, 'manual', $4)
+		RETURNING `+transactionCols+`
+	`, date, req.Amount, strings.TrimSpace(req.Description), req.Notes).Scan(transactionScanDest(&t)...)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	matches, err := reconcileTransactions(ctx, h.db, []int{t.ID}, defaultReconcileWindowDays, defaultReconcileTolerance)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if len(matches) > 0 {
+		t.AssignmentID = &matches[0].AssignmentID
+	}
+
+	models.WriteJSON(w, http.StatusCreated, t)
+}
+
+// Update edits a transaction or links it to an assignment by hand.
+// PUT /api/v1/transactions/{id}
+func (h *TransactionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+
+	var date *time.Time
+	if req.Date != nil {
+		parsed, err := time.Parse("2006-01-02", *req.Date)
+		if err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "date must be in YYYY-MM-DD format")
+			return
+		}
+		date = &parsed
+	}
+	if req.Amount != nil && *req.Amount <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "amount must be positive")
+		return
+	}
+
+	// assignment_id is only touched when present; 0 unlinks
+	setAssignment := req.AssignmentID != nil
+	var assignmentID *int
+	if setAssignment && *req.AssignmentID != 0 {
+		assignmentID = req.AssignmentID
+	}
+
+	var t models.Transaction
+	err = h.db.QueryRow(ctx, `
+		UPDATE transactions SET
+			txn_date = COALESCE($2, txn_date),
+			amount = COALESCE($3, amount),
+			description = COALESCE($4, description),
+			notes = COALESCE($5, notes),
+			assignment_id = CASE WHEN $6 THEN $7::int ELSE assignment_id END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+transactionCols+`
+	`, id, date, req.Amount, req.Description, req.Notes, setAssignment, assignmentID,
+	).Scan(transactionScanDest(&t)...)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "transaction not found")
+		return
+	}
+
+	models.WriteJSON(w, http.StatusOK, t)
+}
+
+// Delete removes a transaction. A reconciled assignment keeps its paid status.
+// DELETE /api/v1/transactions/{id}
+func (h *TransactionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(ctx, `DELETE FROM transactions WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "transaction not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reconcile matches every unreconciled transaction against unpaid assignments.
+// POST /api/v1/transactions/reconcile
+func (h *TransactionHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.ReconcileRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+			return
+		}
+	}
+	windowDays, tolerance := defaultReconcileWindowDays, defaultReconcileTolerance
+	if req.WindowDays != nil {
+		windowDays = *req.WindowDays
+	}
+	if req.Tolerance != nil {
+		tolerance = *req.Tolerance
+	}
+	if windowDays < 0 || tolerance < 0 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "window_days and tolerance must not be negative")
+		return
+	}
+
+	matches, err := reconcileTransactions(ctx, h.db, nil, windowDays, tolerance)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if matches == nil {
+		matches = []services.ReconcileMatch{}
+	}
+
+	models.WriteJSON(w, http.StatusOK, matches)
+}
+
+// reconcileTransactions matches unreconciled transactions (all of them, or
+// only ids) against pending and uncertain assignments, linking each match and
+// marking the assignment paid with the transaction's amount and date.
+func reconcileTransactions(ctx context.Context, db DBTX, ids []int, windowDays int, tolerance float64) ([]services.ReconcileMatch, error) {
+	query := `SELECT id, txn_date, amount FROM transactions WHERE assignment_id IS NULL`
+	args := []interface{}{}
+	if ids != nil {
+		query += ` AND id = ANY($1)`
+		args = append(args, ids)
+	}
+
+	rows, err := db.Query(ctx, query+` ORDER BY txn_date, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txns []services.ReconcileTxn
+	var from, to time.Time
+	for rows.Next() {
+		var t services.ReconcileTxn
+		if err := rows.Scan(&t.ID, &t.Date, &t.Amount); err != nil {
+			return nil, err
+		}
+		if len(txns) == 0 || t.Date.Before(from) {
+			from = t.Date
+		}
+		if len(txns) == 0 || t.Date.After(to) {
+			to = t.Date
+		}
+		txns = append(txns, t)
+	}
+	rows.Close()
+	if len(txns) == 0 {
+		return nil, nil
+	}
+
+	candRows, err := db.Query(ctx, `
+		SELECT ba.id, b.name, COALESCE(ba.due_date, pp.pay_date), ba.planned_amount
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		JOIN pay_periods pp ON pp.id = ba.pay_period_id
+		WHERE ba.status IN ('pending', 'uncertain') AND ba.planned_amount IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.assignment_id = ba.id)
+		  AND COALESCE(ba.due_date, pp.pay_date) >= $1 AND COALESCE(ba.due_date, pp.pay_date) <= $2
+		ORDER BY COALESCE(ba.due_date, pp.pay_date), ba.id
+	`, from.AddDate(0, 0, -windowDays), to.AddDate(0, 0, windowDays))
+	if err != nil {
+		return nil, err
+	}
+	defer candRows.Close()
+
+	var candidates []services.ReconcileCandidate
+	for candRows.Next() {
+		var c services.ReconcileCandidate
+		if err := candRows.Scan(&c.AssignmentID, &c.BillName, &c.Date, &c.Amount); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	candRows.Close()
+
+	matches := services.Reconcile(txns, candidates, windowDays, tolerance)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, m := range matches {
+		if _, err := tx.Exec(ctx, `
+			UPDATE transactions SET assignment_id = $2, updated_at = NOW() WHERE id = $1
+		`, m.TransactionID, m.AssignmentID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE bill_assignments SET
+				status = 'paid',
+				actual_amount = $2,
+				paid_date = (SELECT txn_date FROM transactions WHERE id = $3),
+				updated_at = NOW()
+			WHERE id = $1
+		`, m.AssignmentID, m.Amount, m.TransactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
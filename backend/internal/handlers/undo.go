@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// auditTarget is the audit entry an undo or redo starts from. Its group is
+// every assignment entry with the same request id or, for changes made
+// outside a request, the same transaction.
+type auditTarget struct {
+	ID        int64
+	Entity    string
+	RequestID *string
+	TxID      *int64
+	Origin    string
+	UndoneAt  *time.Time
+}
+
+const auditTargetCols = `id, entity, request_id, txid, origin, undone_at`
+
+func scanAuditTarget(row pgx.Row) (auditTarget, error) {
+	var t auditTarget
+	err := row.Scan(&t.ID, &t.Entity, &t.RequestID, &t.TxID, &t.Origin, &t.UndoneAt)
+	return t, err
+}
+
+// groupCondition matches the target's group, as SQL with its one argument.
+// Entries written before requests were recorded stand alone.
+func (t auditTarget) groupCondition() (string, interface{}) {
+	switch {
+	case t.RequestID != nil:
+		return "request_id = $1", *t.RequestID
+	case t.TxID != nil:
+		return "request_id IS NULL AND txid = $1", *t.TxID
+	default:
+		return "id = $1", t.ID
+	}
+}
+
+// errRevertConflict reports an entry that can't be reverted because the
+// assignment changed again since.
+type errRevertConflict struct{ msg string }
+
+func (e errRevertConflict) Error() string { return e.msg }
+
+// Undo reverts the most recent assignment change not yet undone, or the one
+// the given audit entry belongs to, with everything else the same request
+// did to assignments: all the rows an auto-assign created or an optimizer
+// apply moved come back together. It refuses with 409 if any of them has
+// changed again since, rather than revert half of it.
+// POST /api/v1/assignments/undo
+func (h *AssignmentHandler) Undo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.UndoRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+			return
+		}
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var target auditTarget
+	if req.AuditID != 0 {
+		target, err = scanAuditTarget(tx.QueryRow(ctx, `
+			SELECT `+auditTargetCols+` FROM audit_log WHERE id = $1 FOR UPDATE
+		`, req.AuditID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "audit entry not found")
+			return
+		}
+		if err == nil && target.Entity != "assignment" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "only assignment changes can be undone")
+			return
+		}
+		if err == nil && target.UndoneAt != nil {
+			models.WriteError(w, http.StatusConflict, "CONFLICT", "that change has already been undone")
+			return
+		}
+	} else {
+		target, err = scanAuditTarget(tx.QueryRow(ctx, `
+			SELECT `+auditTargetCols+` FROM audit_log
+			WHERE entity = 'assignment' AND undone_at IS NULL AND origin <> 'undo'
+			ORDER BY id DESC
+			LIMIT 1
+			FOR UPDATE
+		`))
+		if errors.Is(err, pgx.ErrNoRows) {
+			models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "nothing to undo")
+			return
+		}
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	// Undoing an undo is a redo
+	origin := "undo"
+	if target.Origin == "undo" {
+		origin = "redo"
+	}
+	h.revertAndRespond(ctx, w, tx, target, origin)
+}
+
+// Redo reapplies the most recent undo, as long as no assignment change has
+// been made since: a new change clears what there was to redo.
+// POST /api/v1/assignments/redo
+func (h *AssignmentHandler) Redo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	target, err := scanAuditTarget(tx.QueryRow(ctx, `
+		SELECT `+auditTargetCols+` FROM audit_log
+		WHERE entity = 'assignment' AND origin = 'undo' AND undone_at IS NULL
+		  AND id > COALESCE((SELECT MAX(id) FROM audit_log WHERE entity = 'assignment' AND origin = 'change'), 0)
+		ORDER BY id DESC
+		LIMIT 1
+		FOR UPDATE
+	`))
+	if errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "nothing to redo")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	h.revertAndRespond(ctx, w, tx, target, "redo")
+}
+
+func (h *AssignmentHandler) revertAndRespond(ctx context.Context, w http.ResponseWriter, tx pgx.Tx, target auditTarget, origin string) {
+	result, err := revertAuditGroup(ctx, tx, target, origin)
+	var conflict errRevertConflict
+	if errors.As(err, &conflict) {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", conflict.msg)
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, result)
+}
+
+// revertAuditGroup reverts the target's group of assignment entries, newest
+// first, marks them undone and labels the entries the revert itself writes
+// with origin.
+func revertAuditGroup(ctx context.Context, tx pgx.Tx, target auditTarget, origin string) (*models.UndoResult, error) {
+	cond, arg := target.groupCondition()
+	rows, err := tx.Query(ctx, `
+		SELECT id, entity_id, action, before, after FROM audit_log
+		WHERE entity = 'assignment' AND undone_at IS NULL AND `+cond+`
+		ORDER BY id DESC
+		FOR UPDATE
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type entry struct {
+		id            int64
+		assignmentID  int
+		action        string
+		before, after json.RawMessage
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.id, &e.assignmentID, &e.action, &e.before, &e.after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if len(entries) == 0 {
+		return nil, errRevertConflict{"that change has already been undone"}
+	}
+
+	result := &models.UndoResult{AuditIDs: []int64{}, Assignments: []int{}}
+	touched := make(map[int]bool)
+	for _, e := range entries {
+		var err error
+		switch e.action {
+		case "create":
+			err = revertCreate(ctx, tx, e.assignmentID)
+		case "delete":
+			err = revertDelete(ctx, tx, e.assignmentID, e.before)
+		case "update":
+			err = revertUpdate(ctx, tx, e.assignmentID, e.before, e.after)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Reverted++
+		result.AuditIDs = append(result.AuditIDs, e.id)
+		if !touched[e.assignmentID] {
+			touched[e.assignmentID] = true
+			result.Assignments = append(result.Assignments, e.assignmentID)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE audit_log SET undone_at = NOW() WHERE id = ANY($1)`, result.AuditIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE audit_log SET origin = $1 WHERE txid = txid_current()`, origin); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func revertCreate(ctx context.Context, tx pgx.Tx, id int) error {
+	tag, err := tx.Exec(ctx, `DELETE FROM bill_assignments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errRevertConflict{fmt.Sprintf("assignment %d has been deleted since", id)}
+	}
+	return nil
+}
+
+func revertDelete(ctx context.Context, tx pgx.Tx, id int, before json.RawMessage) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO bill_assignments SELECT * FROM jsonb_populate_record(NULL::bill_assignments, $1)
+	`, before)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return errRevertConflict{fmt.Sprintf("assignment %d can't be restored: its bill already has another assignment there", id)}
+	}
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return errRevertConflict{fmt.Sprintf("assignment %d can't be restored: its bill or pay period has been deleted", id)}
+	}
+	return err
+}
+
+// revertUpdate sets the changed columns back, provided they still hold what
+// the change left in them.
+func revertUpdate(ctx context.Context, tx pgx.Tx, id int, before, after json.RawMessage) error {
+	var current json.RawMessage
+	err := tx.QueryRow(ctx, `SELECT to_jsonb(ba) FROM bill_assignments ba WHERE id = $1 FOR UPDATE`, id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errRevertConflict{fmt.Sprintf("assignment %d has been deleted since", id)}
+	}
+	if err != nil {
+		return err
+	}
+
+	var now, was, had map[string]interface{}
+	if err := json.Unmarshal(current, &now); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(after, &was); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(before, &had); err != nil {
+		return err
+	}
+
+	cols := make([]string, 0, len(had))
+	for col := range had {
+		if _, ok := now[col]; !ok {
+			return errRevertConflict{fmt.Sprintf("assignment %d no longer has a %s column", id, col)}
+		}
+		if !reflect.DeepEqual(now[col], was[col]) {
+			return errRevertConflict{fmt.Sprintf("assignment %d has changed again since", id)}
+		}
+		cols = append(cols, pgx.Identifier{col}.Sanitize())
+	}
+	if len(cols) == 0 {
+		return nil
+	}
+	sort.Strings(cols)
+	list := strings.Join(cols, ", ")
+
+	_, err = tx.Exec(ctx, `
+		UPDATE bill_assignments SET (`+list+`) = (SELECT `+list+` FROM jsonb_populate_record(NULL::bill_assignments, $2)),
+		       updated_at = NOW()
+		WHERE id = $1
+	`, id, before)
+	return err
+}
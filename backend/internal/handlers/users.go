@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// minPasswordLength is the shortest password a user may be given.
+const minPasswordLength = 8
+
+// UserHandler manages the accounts that can log in alongside the configured
+// administrator.
+type UserHandler struct {
+	db  DBTX
+	cfg *config.Config
+}
+
+func NewUserHandler(db DBTX, cfg *config.Config) *UserHandler {
+	return &UserHandler{db: db, cfg: cfg}
+}
+
+const userCols = `id, username, role, created_at, updated_at`
+
+func userScanDest(u *models.User) []interface{} {
+	return []interface{}{&u.ID, &u.Username, &u.Role, &u.CreatedAt, &u.UpdatedAt}
+}
+
+func validateRole(role string) error {
+	if _, ok := auth.ParseRole(role); !ok {
+		return errors.New("role must be viewer, editor or admin")
+	}
+	return nil
+}
+
+// List returns every user. Password hashes are never included.
+// GET /api/v1/users
+func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), `SELECT `+userCols+` FROM users ORDER BY username`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(userScanDest(&u)...); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		users = append(users, u)
+	}
+	models.WriteJSON(w, http.StatusOK, users)
+}
+
+// Create adds a user who can log in with the given password.
+// POST /api/v1/users
+func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "username is required")
+		return
+	}
+	if req.Role == "" {
+		req.Role = string(auth.RoleViewer)
+	}
+	if err := validateRole(req.Role); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "password must be at least 8 characters")
+		return
+	}
+	// The configured administrator always wins at login, so a stored user
+	// with the same name could never sign in
+	if req.Username == h.cfg.AuthUsername {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "a user with that username already exists")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	var u models.User
+	err = h.db.QueryRow(r.Context(), `
+		INSERT INTO users (username, password_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING `+userCols+`
+	`, req.Username, hash, req.Role).Scan(userScanDest(&u)...)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		models.WriteError(w, http.StatusConflict, "CONFLICT", "a user with that username already exists")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusCreated, u)
+}
+
+// Update changes a user's role or password. A role change applies when the
+// user's session next refreshes, within the access token lifetime.
+// PUT /api/v1/users/{id}
+func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.Role != nil {
+		if err := validateRole(*req.Role); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+	}
+	var hash *string
+	if req.Password != nil {
+		if len(*req.Password) < minPasswordLength {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "password must be at least 8 characters")
+			return
+		}
+		hashed, err := auth.HashPassword(*req.Password)
+		if err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		hash = &hashed
+	}
+
+	var u models.User
+	err = h.db.QueryRow(r.Context(), `
+		UPDATE users SET
+			password_hash = COALESCE($2, password_hash),
+			role = COALESCE($3, role),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+userCols+`
+	`, id, hash, req.Role).Scan(userScanDest(&u)...)
+	if err == pgx.ErrNoRows {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, u)
+}
+
+// Delete removes a user and ends their sessions.
+// DELETE /api/v1/users/{id}
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var username string
+	err = h.db.QueryRow(r.Context(), `DELETE FROM users WHERE id = $1 RETURNING username`, id).Scan(&username)
+	if err == pgx.ErrNoRows {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if err := revokeUserSessions(r.Context(), h.db, username); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
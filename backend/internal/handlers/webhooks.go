@@ -0,0 +1,554 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/jackc/pgx/v5"
+)
+
+// webhookTestEvent is sent by the test endpoint; webhooks cannot subscribe to it.
+const webhookTestEvent = "webhook.test"
+
+// webhookEvents are the event types a webhook can subscribe to.
+var webhookEvents = []string{
+	events.AssignmentPaid,
+	events.AssignmentStatusChanged,
+	events.PeriodsGenerated,
+	events.ForecastNegative,
+	events.CategoryBudgetAlert,
+	events.ObligationsIncreased,
+}
+
+const (
+	// webhookBatchSize bounds how many deliveries one pass sends.
+	webhookBatchSize = 50
+	// webhookLease is how long a claimed delivery is hidden from other passes.
+	// A delivery interrupted by shutdown is retried once it expires.
+	webhookLease = 5 * time.Minute
+	// maxWebhookErrorLen truncates the error kept with a failure.
+	maxWebhookErrorLen = 500
+)
+
+// WebhookHandler manages webhook subscriptions and delivers queued events.
+type WebhookHandler struct {
+	db     DBTX
+	client *http.Client
+}
+
+func NewWebhookHandler(db DBTX) *WebhookHandler {
+	return &WebhookHandler{db: db, client: newWebhookClient()}
+}
+
+// newWebhookClient returns a client that only connects to public addresses
+// and does not follow redirects. Any editor can register a webhook, so the
+// destination is checked on every connection rather than when the URL is
+// saved, where a DNS change or redirect would get around it.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: refusePrivateAddr}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivateAddr is a net.Dialer Control hook rejecting loopback, private,
+// link-local and unspecified destinations.
+func refusePrivateAddr(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("webhook destination %s is not a public address", host)
+	}
+	return nil
+}
+
+const webhookCols = `id, url, events, description, is_active, created_at, updated_at`
+
+func webhookScanDest(wh *models.Webhook) []interface{} {
+	return []interface{}{&wh.ID, &wh.URL, &wh.Events, &wh.Description, &wh.IsActive, &wh.CreatedAt, &wh.UpdatedAt}
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func isWebhookEvent(eventType string) bool {
+	for _, e := range webhookEvents {
+		if eventType == e {
+			return true
+		}
+	}
+	return false
+}
+
+func validateWebhookEvents(types []string) error {
+	for _, t := range types {
+		if !isWebhookEvent(t) {
+			return fmt.Errorf("events must be from: %s", strings.Join(webhookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// List returns every webhook. Secrets are not included.
+// GET /api/v1/webhooks
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), `SELECT `+webhookCols+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	hooks := []models.Webhook{}
+	for rows.Next() {
+		var wh models.Webhook
+		if err := rows.Scan(webhookScanDest(&wh)...); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		hooks = append(hooks, wh)
+	}
+	models.WriteJSON(w, http.StatusOK, hooks)
+}
+
+// Create registers a webhook. The response carries the signing secret, which
+// is not shown again.
+// POST /api/v1/webhooks
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if err := validateWebhookURL(req.URL); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+	if req.Secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		req.Secret = hex.EncodeToString(buf)
+	} else if len(req.Secret) < 16 || len(req.Secret) > 128 {
+		models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "secret must be 16 to 128 characters")
+		return
+	}
+
+	var wh models.Webhook
+	err := h.db.QueryRow(r.Context(), `
+		INSERT INTO webhooks (url, secret, events, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+webhookCols+`
+	`, req.URL, req.Secret, req.Events, req.Description).Scan(webhookScanDest(&wh)...)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	wh.Secret = req.Secret
+
+	models.WriteJSON(w, http.StatusCreated, wh)
+}
+
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var wh models.Webhook
+	err = h.db.QueryRow(r.Context(), `SELECT `+webhookCols+` FROM webhooks WHERE id = $1`, id).
+		Scan(webhookScanDest(&wh)...)
+	if err == pgx.ErrNoRows {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "webhook not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, wh)
+}
+
+// Update changes a webhook's URL, events, description or active flag. The
+// secret cannot be changed; create a new webhook to rotate it.
+// PUT /api/v1/webhooks/{id}
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_JSON", err.Error())
+		return
+	}
+	if req.URL != nil {
+		trimmed := strings.TrimSpace(*req.URL)
+		req.URL = &trimmed
+		if err := validateWebhookURL(trimmed); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(*req.Events); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+			return
+		}
+	}
+
+	var wh models.Webhook
+	err = h.db.QueryRow(r.Context(), `
+		UPDATE webhooks SET
+			url = COALESCE($2, url),
+			events = COALESCE($3, events),
+			description = COALESCE($4, description),
+			is_active = COALESCE($5, is_active),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+webhookCols+`
+	`, id, req.URL, req.Events, req.Description, req.IsActive).Scan(webhookScanDest(&wh)...)
+	if err == pgx.ErrNoRows {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "webhook not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, wh)
+}
+
+// Delete removes a webhook along with its delivery log.
+// DELETE /api/v1/webhooks/{id}
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	tag, err := h.db.Exec(r.Context(), `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "webhook not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var webhookDeliverySortFields = map[string]string{
+	"created_at":      "created_at",
+	"next_attempt_at": "next_attempt_at",
+	"attempts":        "attempts",
+}
+
+// Deliveries is a webhook's delivery log, newest first, optionally filtered
+// by ?status= and paged per parseListParams.
+// GET /api/v1/webhooks/{id}/deliveries?status=failed
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+	params, ok := parseListParams(w, r, webhookDeliverySortFields, "created_at DESC, id DESC")
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT id, webhook_id, event_type, payload, status, attempts, response_status,
+		       last_error, next_attempt_at, delivered_at, created_at, COUNT(*) OVER ()
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+	`
+	args := []interface{}{id}
+	if status := r.URL.Query().Get("status"); status != "" {
+		if status != "pending" && status != "delivered" && status != "failed" {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "status must be pending, delivered or failed")
+			return
+		}
+		args = append(args, status)
+		query += " AND status = $" + strconv.Itoa(len(args))
+	}
+
+	pageQuery, pageArgs := params.apply(query, args)
+	rows, err := h.db.Query(ctx, pageQuery, pageArgs...)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	var total int
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.ResponseStatus, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt, &total); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		deliveries = append(deliveries, d)
+	}
+	rows.Close()
+
+	total, err = listTotal(ctx, h.db, params, query, args, total, len(deliveries))
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	models.WriteJSONList(w, deliveries, total, params.Limit, params.Offset)
+}
+
+// Test queues a webhook.test event for one webhook, whatever it subscribes
+// to, and attempts delivery straight away. The delivery is returned as queued;
+// check the delivery log for the outcome.
+// POST /api/v1/webhooks/{id}/test
+func (h *WebhookHandler) Test(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, "INVALID_ID", "id must be an integer")
+		return
+	}
+
+	payload, err := json.Marshal(events.Event{Type: webhookTestEvent, At: time.Now(), Data: map[string]int{"webhook_id": id}})
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	var d models.WebhookDelivery
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+		SELECT id, $2, $3 FROM webhooks WHERE id = $1
+		RETURNING id, webhook_id, event_type, payload, status, attempts, response_status,
+		          last_error, next_attempt_at, delivered_at, created_at
+	`, id, webhookTestEvent, payload).Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status,
+		&d.Attempts, &d.ResponseStatus, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+	if err == pgx.ErrNoRows {
+		models.WriteError(w, http.StatusNotFound, "NOT_FOUND", "webhook not found")
+		return
+	}
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	if err := h.deliverDue(ctx, time.Now()); err != nil {
+		slog.Error("delivering webhooks", "error", err)
+	}
+	models.WriteJSON(w, http.StatusAccepted, d)
+}
+
+// Dispatch queues e for every active webhook subscribed to it, then sends
+// whatever is due.
+func (h *WebhookHandler) Dispatch(ctx context.Context, e events.Event) error {
+	if !isWebhookEvent(e.Type) {
+		return nil
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tag, err := h.db.Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+		SELECT id, $1, $2 FROM webhooks
+		WHERE is_active = true AND (cardinality(events) = 0 OR $1 = ANY(events))
+	`, e.Type, payload)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+	return h.deliverDue(ctx, time.Now())
+}
+
+// StartDelivery retries due deliveries every interval until the runner shuts
+// down.
+func (h *WebhookHandler) StartDelivery(runner *jobs.Runner, interval time.Duration) {
+	runner.Schedule("webhook-deliveries", interval, func(ctx context.Context, now time.Time) {
+		if err := h.deliverDue(ctx, now); err != nil {
+			slog.Error("delivering webhooks", "error", err)
+		}
+	})
+}
+
+type pendingDelivery struct {
+	id        int
+	eventType string
+	payload   []byte
+	attempts  int
+	url       string
+	secret    string
+	active    bool
+}
+
+// deliverDue claims up to webhookBatchSize pending deliveries whose next
+// attempt has come and POSTs each one. Claiming pushes next_attempt_at out by
+// webhookLease, so concurrent passes never send the same delivery twice.
+func (h *WebhookHandler) deliverDue(ctx context.Context, now time.Time) error {
+	rows, err := h.db.Query(ctx, `
+		UPDATE webhook_deliveries d SET next_attempt_at = $2
+		FROM webhooks wh
+		WHERE wh.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event_type, d.payload, d.attempts, wh.url, wh.secret, wh.is_active
+	`, now, now.Add(webhookLease), webhookBatchSize)
+	if err != nil {
+		return err
+	}
+	var due []pendingDelivery
+	for rows.Next() {
+		var p pendingDelivery
+		if err := rows.Scan(&p.id, &p.eventType, &p.payload, &p.attempts, &p.url, &p.secret, &p.active); err != nil {
+			rows.Close()
+			return err
+		}
+		due = append(due, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range due {
+		// Deliveries not reached before shutdown stay pending and are sent
+		// once their lease runs out
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !p.active {
+			if _, err := h.db.Exec(ctx, `
+				UPDATE webhook_deliveries SET status = 'failed', last_error = 'webhook is disabled'
+				WHERE id = $1
+			`, p.id); err != nil {
+				return err
+			}
+			continue
+		}
+
+		status, sendErr := h.send(ctx, p, time.Now())
+		if sendErr != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := h.recordAttempt(ctx, p, status, sendErr, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send POSTs one delivery, returning the response status. Non-2xx responses,
+// redirects included, are errors naming only the status; the body is not
+// kept, since the delivery log would otherwise echo what the URL returned.
+func (h *WebhookHandler) send(ctx context.Context, p pendingDelivery, now time.Time) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(p.payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "budget-mgmt-webhooks/1")
+	req.Header.Set("X-Budget-Event", p.eventType)
+	req.Header.Set("X-Budget-Delivery", strconv.Itoa(p.id))
+	req.Header.Set("X-Budget-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Budget-Signature", services.SignWebhook(p.secret, now, p.payload))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		return &status, fmt.Errorf("HTTP %d", status)
+	}
+	return &status, nil
+}
+
+// recordAttempt stores the outcome of a send: delivered, retried after
+// WebhookBackoff, or failed once WebhookMaxAttempts is reached.
+func (h *WebhookHandler) recordAttempt(ctx context.Context, p pendingDelivery, status *int, sendErr error, now time.Time) error {
+	attempts := p.attempts + 1
+	if sendErr == nil {
+		_, err := h.db.Exec(ctx, `
+			UPDATE webhook_deliveries SET
+				status = 'delivered', attempts = $2, response_status = $3, last_error = '', delivered_at = $4
+			WHERE id = $1
+		`, p.id, attempts, status, now)
+		return err
+	}
+
+	msg := sendErr.Error()
+	if len(msg) > maxWebhookErrorLen {
+		msg = msg[:maxWebhookErrorLen]
+	}
+	newStatus := "pending"
+	if attempts >= services.WebhookMaxAttempts {
+		newStatus = "failed"
+	}
+	_, err := h.db.Exec(ctx, `
+		UPDATE webhook_deliveries SET
+			status = $2, attempts = $3, response_status = $4, last_error = $5, next_attempt_at = $6
+		WHERE id = $1
+	`, p.id, newStatus, attempts, status, msg, now.Add(services.WebhookBackoff(attempts)))
+	return err
+}
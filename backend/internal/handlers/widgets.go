@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultWidgetBills = 5
+	maxWidgetBills     = 20
+
+	// widgetMaxAge is how long pollers may reuse a widget response
+	widgetMaxAge = 5 * time.Minute
+)
+
+// WidgetHandler serves small read-only summaries for home dashboards such
+// as Home Assistant or a smart mirror. API keys are checked against db, the
+// primary, so a revoked key stops working at once; the summaries come from
+// readDB, which may be a replica.
+type WidgetHandler struct {
+	db     DBTX
+	readDB DBTX
+	cfg    *config.Config
+}
+
+func NewWidgetHandler(db, readDB DBTX, cfg *config.Config) *WidgetHandler {
+	return &WidgetHandler{db: db, readDB: readDB, cfg: cfg}
+}
+
+// WidgetSummary is the week ahead at a glance.
+type WidgetSummary struct {
+	From          string   `json:"from"` // today, YYYY-MM-DD
+	To            string   `json:"to"`   // six days later
+	DueCount      int      `json:"due_count"`
+	DueTotal      float64  `json:"due_total"`
+	PaidTotal     float64  `json:"paid_total"` // paid during the week so far
+	OverdueCount  int      `json:"overdue_count"`
+	NextPayday    *string  `json:"next_payday"`
+	NextPayAmount *float64 `json:"next_pay_amount"`
+}
+
+// WidgetBill is one upcoming unpaid bill.
+type WidgetBill struct {
+	Name    string  `json:"name"`
+	Due     string  `json:"due"` // YYYY-MM-DD
+	Amount  float64 `json:"amount"`
+	Autopay bool    `json:"autopay"`
+	Overdue bool    `json:"overdue"`
+}
+
+// authorized checks the API key from the X-API-Key header, writing a 401
+// when it is missing, revoked or lacks the read:bills scope. Keys are not
+// taken from the query string, which ends up in request and proxy logs.
+func (h *WidgetHandler) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if !h.cfg.AuthEnabled() {
+		return true
+	}
+	key := r.Header.Get(auth.APIKeyHeader)
+	if key == "" {
+		models.WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", "an API key with the read:bills scope is required")
+		return false
+	}
+	session, ok, err := lookupAPIKey(r.Context(), h.db, key)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return false
+	}
+	if !ok || !session.HasScope(auth.ScopeReadBills) {
+		models.WriteError(w, http.StatusUnauthorized, "UNAUTHORIZED", "an API key with the read:bills scope is required")
+		return false
+	}
+	return true
+}
+
+// writeWidget writes data with caching headers. The ETag covers the data
+// only, so a poller gets a 304 until something it shows changes.
+func writeWidget(w http.ResponseWriter, r *http.Request, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "ENCODE_ERROR", err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(widgetMaxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	models.WriteJSON(w, http.StatusOK, data)
+}
+
+// Summary counts the unpaid bills due in the next seven days and their
+// total, what has been paid in that window, overdue bills that aren't
+// disputed, and the next paycheck.
+// GET /api/v1/widgets/summary
+func (h *WidgetHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(w, r) {
+		return
+	}
+	ctx := r.Context()
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 6)
+	s := WidgetSummary{From: from.Format("2006-01-02"), To: to.Format("2006-01-02")}
+
+	err := h.readDB.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE ba.status IN ('pending', 'uncertain') AND ba.due_date BETWEEN $1 AND $2),
+		       COALESCE(SUM(COALESCE(ba.forecast_amount, ba.planned_amount, 0))
+		                FILTER (WHERE ba.status IN ('pending', 'uncertain') AND ba.due_date BETWEEN $1 AND $2), 0),
+		       COALESCE(SUM(COALESCE(ba.actual_amount, ba.planned_amount, 0))
+		                FILTER (WHERE ba.status = 'paid' AND ba.paid_date BETWEEN $1 AND $2), 0),
+		       COUNT(*) FILTER (WHERE ba.status IN ('pending', 'uncertain') AND ba.due_date < $1 AND NOT `+openDispute+`)
+		FROM bill_assignments ba
+	`, from, to).Scan(&s.DueCount, &s.DueTotal, &s.PaidTotal, &s.OverdueCount)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	// Same-date paychecks from several sources arrive as one deposit
+	var payday *time.Time
+	err = h.readDB.QueryRow(ctx, `
+		SELECT pp.pay_date, SUM(COALESCE(pp.actual_amount, pp.expected_amount))
+		FROM pay_periods pp
+		JOIN income_sources inc ON inc.id = pp.income_source_id
+		WHERE inc.is_active AND pp.pay_date = (
+			SELECT MIN(p.pay_date) FROM pay_periods p
+			JOIN income_sources i ON i.id = p.income_source_id
+			WHERE i.is_active AND p.pay_date >= $1
+		)
+		GROUP BY pp.pay_date
+	`, from).Scan(&payday, &s.NextPayAmount)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	if payday != nil {
+		d := payday.Format("2006-01-02")
+		s.NextPayday = &d
+	}
+
+	writeWidget(w, r, s)
+}
+
+// NextBills lists the next ?limit= (default 5, max 20) unpaid bills by due
+// date, so overdue ones come first.
+// GET /api/v1/widgets/next-bills
+func (h *WidgetHandler) NextBills(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(w, r) {
+		return
+	}
+	ctx := r.Context()
+
+	limit := defaultWidgetBills
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxWidgetBills {
+			models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", "limit must be between 1 and 20")
+			return
+		}
+		limit = n
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	rows, err := h.readDB.Query(ctx, `
+		SELECT CASE WHEN ba.is_extra AND ba.extra_name <> '' THEN ba.extra_name ELSE b.name END,
+		       ba.due_date, COALESCE(ba.forecast_amount, ba.planned_amount, 0), b.is_autopay
+		FROM bill_assignments ba
+		JOIN bills b ON b.id = ba.bill_id
+		WHERE ba.status IN ('pending', 'uncertain') AND ba.due_date IS NOT NULL
+		ORDER BY ba.due_date, b.sort_order, ba.id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	bills := []WidgetBill{}
+	for rows.Next() {
+		var b WidgetBill
+		var due time.Time
+		if err := rows.Scan(&b.Name, &due, &b.Amount, &b.Autopay); err != nil {
+			models.WriteError(w, http.StatusInternalServerError, "SCAN_ERROR", err.Error())
+			return
+		}
+		b.Due = due.Format("2006-01-02")
+		b.Overdue = due.Before(today)
+		bills = append(bills, b)
+	}
+	if err := rows.Err(); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		return
+	}
+
+	writeWidget(w, r, bills)
+}
@@ -0,0 +1,220 @@
+// Package jobs runs the server's background work (periodic sweeps, reminder
+// digests, event-triggered checks) so that shutdown can stop new work, wait
+// for running jobs, and cancel whatever outlives the deadline.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Runner tracks background jobs. Jobs get a context that stays live while
+// the server drains and is cancelled only when the shutdown deadline passes,
+// at which point a job should leave its durable state as-is so the work is
+// retried after restart.
+type Runner struct {
+	ctx    context.Context // cancelled when draining gives up
+	cancel context.CancelFunc
+
+	locker       Locker // nil runs scheduled jobs without a cluster lock
+	schedulerOff bool
+
+	mu        sync.Mutex
+	stopping  bool
+	stop      chan struct{} // closed when shutdown begins
+	running   map[int]string
+	nextID    int
+	wg        sync.WaitGroup
+	scheduled []*JobStatus
+}
+
+func NewRunner() *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{ctx: ctx, cancel: cancel, stop: make(chan struct{}), running: make(map[int]string)}
+}
+
+// WithLocker makes Schedule take l's lock for each run, so across replicas
+// sharing a database only one runs a scheduled job at a time.
+func (r *Runner) WithLocker(l Locker) *Runner {
+	r.locker = l
+	return r
+}
+
+// DisableScheduler turns Schedule into a no-op, for replicas that only serve
+// requests. Every is unaffected.
+func (r *Runner) DisableScheduler() *Runner {
+	r.schedulerOff = true
+	return r
+}
+
+// SchedulerEnabled reports whether Schedule runs jobs on this instance.
+func (r *Runner) SchedulerEnabled() bool {
+	return !r.schedulerOff
+}
+
+// JobStatus describes a scheduled job as this instance last saw it.
+type JobStatus struct {
+	Name          string        `json:"name"`
+	Interval      time.Duration `json:"-"`
+	LockKey       int64         `json:"lock_key"`
+	Running       bool          `json:"running"`
+	LastRunAt     *time.Time    `json:"last_run_at"`     // last run started here
+	LastSkippedAt *time.Time    `json:"last_skipped_at"` // last tick another replica held the lock
+}
+
+// Scheduled returns a snapshot of the jobs registered with Schedule.
+func (r *Runner) Scheduled() []JobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]JobStatus, len(r.scheduled))
+	for i, s := range r.scheduled {
+		out[i] = *s
+	}
+	return out
+}
+
+// start registers a job, or reports false once shutdown has begun.
+func (r *Runner) start(name string) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopping {
+		return 0, false
+	}
+	r.nextID++
+	r.running[r.nextID] = name
+	r.wg.Add(1)
+	return r.nextID, true
+}
+
+func (r *Runner) finish(id int) {
+	r.mu.Lock()
+	delete(r.running, id)
+	r.mu.Unlock()
+	r.wg.Done()
+}
+
+// run executes fn as a tracked job in the calling goroutine.
+func (r *Runner) run(id int, name string, fn func(ctx context.Context)) {
+	defer r.finish(id)
+	defer func() {
+		if rec := recover(); rec != nil {
+			slog.Error("background job panicked", "job", name, "panic", rec)
+		}
+	}()
+	fn(r.ctx)
+}
+
+// Go runs fn in the background. It returns false, without running fn, once
+// shutdown has begun.
+func (r *Runner) Go(name string, fn func(ctx context.Context)) bool {
+	id, ok := r.start(name)
+	if !ok {
+		slog.Warn("rejected background job during shutdown", "job", name)
+		return false
+	}
+	go r.run(id, name, fn)
+	return true
+}
+
+// Every runs fn each interval until shutdown begins. Runs never overlap: a
+// tick that arrives while fn is still running is skipped.
+func (r *Runner) Every(name string, interval time.Duration, fn func(ctx context.Context, now time.Time)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case now := <-ticker.C:
+				id, ok := r.start(name)
+				if !ok {
+					return
+				}
+				r.run(id, name, func(ctx context.Context) { fn(ctx, now) })
+			}
+		}
+	}()
+}
+
+// Schedule runs fn each interval like Every, but is cluster-wide work such as
+// sending reminders: each run first takes the job's lock and is skipped if
+// another replica holds it.
+func (r *Runner) Schedule(name string, interval time.Duration, fn func(ctx context.Context, now time.Time)) {
+	if r.schedulerOff {
+		return
+	}
+	status := &JobStatus{Name: name, Interval: interval, LockKey: LockKey(name)}
+	r.mu.Lock()
+	r.scheduled = append(r.scheduled, status)
+	r.mu.Unlock()
+
+	r.Every(name, interval, func(ctx context.Context, now time.Time) {
+		if r.locker != nil {
+			unlock, ok, err := r.locker.TryLock(ctx, status.LockKey)
+			if err != nil {
+				slog.Error("taking scheduled job lock", "job", name, "error", err)
+				return
+			}
+			if !ok {
+				r.mu.Lock()
+				status.LastSkippedAt = &now
+				r.mu.Unlock()
+				return
+			}
+			defer unlock()
+		}
+
+		r.mu.Lock()
+		status.Running = true
+		status.LastRunAt = &now
+		r.mu.Unlock()
+		defer func() {
+			r.mu.Lock()
+			status.Running = false
+			r.mu.Unlock()
+		}()
+
+		fn(ctx, now)
+	})
+}
+
+// Shutdown stops accepting jobs and waits for running ones until ctx is
+// done. Jobs still running then have their context cancelled, and their
+// names are returned in the error.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.stopping {
+		r.stopping = true
+		close(r.stop)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		names := make([]string, 0, len(r.running))
+		for _, name := range r.running {
+			names = append(names, name)
+		}
+		r.mu.Unlock()
+		sort.Strings(names)
+
+		r.cancel()
+		return fmt.Errorf("abandoned %d background job(s): %s", len(names), strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdown_WaitsForRunningJobs(t *testing.T) {
+	r := NewRunner()
+	release := make(chan struct{})
+	var finished atomic.Bool
+	r.Go("slow", func(ctx context.Context) {
+		<-release
+		finished.Store(true)
+	})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Shutdown returned before the job finished")
+	}
+}
+
+func TestShutdown_RejectsNewJobs(t *testing.T) {
+	r := NewRunner()
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.Go("late", func(ctx context.Context) { t.Error("job ran after shutdown") }) {
+		t.Error("expected Go to refuse the job")
+	}
+}
+
+func TestShutdown_CancelsJobsPastDeadline(t *testing.T) {
+	r := NewRunner()
+	cancelled := make(chan struct{})
+	r.Go("stuck", func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := r.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Fatalf("expected error naming the stuck job, got %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("job context was not cancelled")
+	}
+}
+
+func TestEvery_StopsOnShutdown(t *testing.T) {
+	r := NewRunner()
+	var runs atomic.Int32
+	r.Every("tick", 5*time.Millisecond, func(ctx context.Context, now time.Time) {
+		runs.Add(1)
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	after := runs.Load()
+	if after == 0 {
+		t.Fatal("expected at least one run")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != after {
+		t.Error("job kept running after shutdown")
+	}
+}
+
+func TestGo_RecoversPanics(t *testing.T) {
+	r := NewRunner()
+	r.Go("boom", func(ctx context.Context) { panic("boom") })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type fakeLocker struct {
+	grant    bool
+	unlocked atomic.Int32
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	if !l.grant {
+		return nil, false, nil
+	}
+	return func() { l.unlocked.Add(1) }, true, nil
+}
+
+func TestSchedule_RunsOnlyWithLock(t *testing.T) {
+	for _, grant := range []bool{true, false} {
+		locker := &fakeLocker{grant: grant}
+		r := NewRunner().WithLocker(locker)
+		var runs atomic.Int32
+		r.Schedule("digests", 5*time.Millisecond, func(ctx context.Context, now time.Time) {
+			runs.Add(1)
+		})
+
+		time.Sleep(30 * time.Millisecond)
+		if err := r.Shutdown(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		status := r.Scheduled()
+		if len(status) != 1 || status[0].Name != "digests" || status[0].LockKey != LockKey("digests") {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+		if grant {
+			if runs.Load() == 0 || locker.unlocked.Load() != runs.Load() {
+				t.Errorf("runs = %d, unlocks = %d; want equal and non-zero", runs.Load(), locker.unlocked.Load())
+			}
+			if status[0].LastRunAt == nil || status[0].LastSkippedAt != nil {
+				t.Errorf("unexpected status: %+v", status[0])
+			}
+		} else {
+			if runs.Load() != 0 {
+				t.Errorf("ran %d times without the lock", runs.Load())
+			}
+			if status[0].LastRunAt != nil || status[0].LastSkippedAt == nil {
+				t.Errorf("unexpected status: %+v", status[0])
+			}
+		}
+	}
+}
+
+func TestSchedule_DisabledScheduler(t *testing.T) {
+	r := NewRunner().DisableScheduler()
+	r.Schedule("digests", time.Millisecond, func(ctx context.Context, now time.Time) {
+		t.Error("scheduled job ran with the scheduler disabled")
+	})
+	time.Sleep(10 * time.Millisecond)
+	r.Shutdown(context.Background())
+
+	if r.SchedulerEnabled() || len(r.Scheduled()) != 0 {
+		t.Error("expected no scheduled jobs")
+	}
+}
+
+func TestLockKey_StablePerName(t *testing.T) {
+	if LockKey("digests") != LockKey("digests") || LockKey("digests") == LockKey("webhooks") {
+		t.Error("lock keys must be stable and distinct per name")
+	}
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Locker grants cluster-wide exclusive access to a scheduled job.
+type Locker interface {
+	// TryLock takes the lock without waiting. When ok, unlock must be called
+	// once the job is done.
+	TryLock(ctx context.Context, key int64) (unlock func(), ok bool, err error)
+}
+
+// LockKey is the advisory lock key for a job name.
+func LockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("budget-mgmt:" + name))
+	return int64(h.Sum64())
+}
+
+// ApplicationName is the application_name a lock-holding connection reports
+// in pg_stat_activity, so holders can be traced back to an instance.
+func ApplicationName(instance string) string {
+	return "budget-mgmt:" + instance
+}
+
+// PGLocker takes Postgres session advisory locks. Each held lock pins one
+// pool connection until it is unlocked.
+type PGLocker struct {
+	pool     *pgxpool.Pool
+	instance string
+}
+
+func NewPGLocker(pool *pgxpool.Pool, instance string) *PGLocker {
+	return &PGLocker{pool: pool, instance: instance}
+}
+
+func (l *PGLocker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	err = conn.QueryRow(ctx, `
+		SELECT CASE WHEN pg_try_advisory_lock($1)
+		            THEN set_config('application_name', $2, false) IS NOT NULL
+		            ELSE false END
+	`, key, ApplicationName(l.instance)).Scan(&ok)
+	if err != nil || !ok {
+		conn.Release()
+		return nil, false, err
+	}
+
+	unlock := func() {
+		// The job's context may already be cancelled by shutdown
+		ctx := context.Background()
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1), set_config('application_name', '', false)`, key); err != nil {
+			// Closing the session is the only other way to free the lock;
+			// the pool drops closed connections on release
+			slog.Error("releasing scheduled job lock", "error", err)
+			conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// APIKey is a key scripts and dashboards send as X-API-Key. The key itself
+// is only shown once, when it is created.
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"` // read:bills, write:assignments, run:autoassign
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreatedAPIKey is a new key along with the secret to send.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry is one change to a bill, assignment, pay period or income
+// source. Before and After hold the whole row on delete and create, and only
+// the columns that changed on update.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Entity    string          `json:"entity"` // bill, assignment, period, income_source
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"` // create, update, delete
+	Actor     *string         `json:"actor"`  // nil for background jobs and with auth disabled
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID *string         `json:"request_id"` // groups the changes one request made
+	Origin    string          `json:"origin"`     // change, or undo/redo for entries those wrote
+	UndoneAt  *time.Time      `json:"undone_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// UndoRequest picks the change to undo: the group of entries AuditID belongs
+// to, or when it is 0 the most recent assignment change not yet undone.
+type UndoRequest struct {
+	AuditID int64 `json:"audit_id,omitempty"`
+}
+
+// UndoResult reports what an undo or redo reverted.
+type UndoResult struct {
+	Reverted    int     `json:"reverted"`    // assignment changes reverted
+	AuditIDs    []int64 `json:"audit_ids"`   // the entries reverted, now marked undone
+	Assignments []int   `json:"assignments"` // ids of the assignments touched
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// BackupVersion is bumped when the backup format changes incompatibly.
+const BackupVersion = 1
+
+// Backup is a full copy of a household's data, for moving it between
+// instances. Unlike ConfigExport it keeps history and ids: Tables maps each
+// table name to its rows, each row keyed by column name.
+type Backup struct {
+	Version    int                    `json:"version"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Tables     map[string][]BackupRow `json:"tables"`
+}
+
+type BackupRow map[string]any
+
+// BackupRestoreResult reports, per table, how many rows a restore inserted and
+// how many it matched to existing rows instead (merge only).
+type BackupRestoreResult struct {
+	Strategy string                       `json:"strategy"`
+	Tables   map[string]BackupTableResult `json:"tables"`
+}
+
+type BackupTableResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
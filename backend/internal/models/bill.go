@@ -19,6 +19,19 @@ type Bill struct {
 	SortOrder           int              `json:"sort_order"`
 	SinkingFundEnabled  bool             `json:"sinking_fund_enabled"`
 	SinkingFundPeriods  *int             `json:"sinking_fund_periods,omitempty"`
+	SharedWith          string           `json:"shared_with"`
+	SharedPercent       *float64         `json:"shared_percent"` // share paid by SharedWith, 0-100
+	BillType            string           `json:"bill_type"`      // bill, allowance
+	Dependent           string           `json:"dependent"`      // child/dependent an allowance is for
+	TaxDeductible       bool             `json:"tax_deductible"`
+	ActiveMonths        []int            `json:"active_months"` // 1-12; empty means every month
+	MonthlyAmounts      map[int]float64  `json:"monthly_amounts"` // month (1-12) -> amount; overrides DefaultAmount
+	Owner               string           `json:"owner"`           // household member who pays it; empty means shared
+	// Vendor login details for paying by hand, only returned to editors
+	PortalURL           string           `json:"portal_url,omitempty"`
+	UsernameHint        string           `json:"username_hint,omitempty"`
+	PasswordRotatedAt   *time.Time       `json:"password_rotated_at,omitempty"`
+	BufferPercent       float64          `json:"buffer_percent"` // headroom AutoAssign adds to the planned amount, 0-100
 	CreatedAt           time.Time        `json:"created_at"`
 	UpdatedAt           time.Time        `json:"updated_at"`
 	CreditCard          *CreditCard      `json:"credit_card,omitempty"`
@@ -34,6 +47,18 @@ type CreateBillRequest struct {
 	Category         string           `json:"category"`
 	Notes            string           `json:"notes"`
 	SortOrder        int              `json:"sort_order"`
+	SharedWith       string           `json:"shared_with"`
+	SharedPercent    *float64         `json:"shared_percent"`
+	BillType         string           `json:"bill_type"`
+	Dependent        string           `json:"dependent"`
+	TaxDeductible    bool             `json:"tax_deductible"`
+	ActiveMonths     []int            `json:"active_months"`
+	MonthlyAmounts   map[int]float64  `json:"monthly_amounts"`
+	Owner            string           `json:"owner"`
+	PortalURL        string           `json:"portal_url"`
+	UsernameHint     string           `json:"username_hint"`
+	PasswordRotatedAt *string         `json:"password_rotated_at"` // YYYY-MM-DD
+	BufferPercent    float64          `json:"buffer_percent"`
 	CreditCard       *CreateCreditCardRequest `json:"credit_card,omitempty"`
 }
 
@@ -50,6 +75,18 @@ type UpdateBillRequest struct {
 	SortOrder           *int             `json:"sort_order,omitempty"`
 	SinkingFundEnabled  *bool            `json:"sinking_fund_enabled,omitempty"`
 	SinkingFundPeriods  *int             `json:"sinking_fund_periods,omitempty"`
+	SharedWith          *string          `json:"shared_with,omitempty"`
+	SharedPercent       *float64         `json:"shared_percent,omitempty"`
+	BillType            *string          `json:"bill_type,omitempty"`
+	Dependent           *string          `json:"dependent,omitempty"`
+	TaxDeductible       *bool            `json:"tax_deductible,omitempty"`
+	ActiveMonths        []int            `json:"active_months,omitempty"`
+	MonthlyAmounts      map[int]float64  `json:"monthly_amounts,omitempty"`
+	Owner               *string          `json:"owner,omitempty"`
+	PortalURL           *string          `json:"portal_url,omitempty"`
+	UsernameHint        *string          `json:"username_hint,omitempty"`
+	PasswordRotatedAt   *string          `json:"password_rotated_at,omitempty"` // YYYY-MM-DD, "" clears
+	BufferPercent       *float64         `json:"buffer_percent,omitempty"`
 }
 
 type ReorderBillsRequest struct {
@@ -60,3 +97,18 @@ type BillOrder struct {
 	ID        int `json:"id"`
 	SortOrder int `json:"sort_order"`
 }
+
+
+// BillSkip records a month in which a bill is intentionally not paid.
+type BillSkip struct {
+	ID        int       `json:"id"`
+	BillID    int       `json:"bill_id"`
+	Month     string    `json:"month"` // YYYY-MM
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SkipBillRequest struct {
+	Month  string `json:"month"` // YYYY-MM
+	Reason string `json:"reason"`
+}
@@ -17,13 +17,35 @@ type BillAssignment struct {
 	ManuallyMoved           bool      `json:"manually_moved"`
 	IsSinkingFund           bool      `json:"is_sinking_fund"`
 	SinkingFundForPeriodID  *int      `json:"sinking_fund_for_period_id,omitempty"`
+	TaxDeductible           bool      `json:"tax_deductible"` // set on extras; bills carry their own flag
+	ScheduledDate           *time.Time `json:"scheduled_date"` // planned payment date if not the pay date
+	DueDate                 *time.Time `json:"due_date"`       // bill occurrence this assignment covers
+	PaidDate                *time.Time `json:"paid_date"`      // when it was actually paid, set by quick-pay
+	OriginalCurrency        *string   `json:"original_currency"` // ISO 4217 code of a foreign bill
+	OriginalAmount          *float64  `json:"original_amount"`   // in OriginalCurrency; planned_amount is the converted figure
+	FXNote                  string    `json:"fx_note"`           // e.g. the rate used and where it came from
+	PaidBy                  string    `json:"paid_by"`           // household member who paid it; "" when not recorded
+	PaidFrom                string    `json:"paid_from"`         // account it was paid from, e.g. "Joint checking"
 	CreatedAt               time.Time `json:"created_at"`
 	UpdatedAt               time.Time `json:"updated_at"`
 
 	// Joined fields
 	BillName        string `json:"bill_name,omitempty"`
+
+	// Computed fields (not stored): every occurrence in the same bill+period
+	// cell of the budget grid, set only when there is more than one
+	Occurrences     []BillAssignment `json:"occurrences,omitempty"`
 }
 
+// Amount modes choose which of an assignment's amounts a projection counts.
+// Planned is the conservative view; the others prefer the forecast, and
+// then what was actually paid, wherever one is recorded.
+const (
+	AmountModePlanned         = "planned"
+	AmountModeForecast        = "forecast"         // forecast_amount, else planned
+	AmountModeActualPreferred = "actual_preferred" // actual_amount, else forecast, else planned
+)
+
 type CreateAssignmentRequest struct {
 	BillID         int      `json:"bill_id"`
 	PayPeriodID    int      `json:"pay_period_id"`
@@ -34,6 +56,14 @@ type CreateAssignmentRequest struct {
 	IsExtra        bool     `json:"is_extra"`
 	ExtraName      string   `json:"extra_name"`
 	Notes          string   `json:"notes"`
+	TaxDeductible  bool     `json:"tax_deductible"`
+	ScheduledDate  *string  `json:"scheduled_date"` // YYYY-MM-DD
+	DueDate        *string  `json:"due_date"`       // YYYY-MM-DD; defaults from the bill's due_day
+
+	OriginalCurrency *string  `json:"original_currency"` // set together with original_amount
+	OriginalAmount   *float64 `json:"original_amount"`
+	FXRate           *float64 `json:"fx_rate,omitempty"` // converts original_amount into planned_amount when that is omitted
+	FXNote           string   `json:"fx_note"`
 }
 
 type UpdateAssignmentRequest struct {
@@ -43,9 +73,85 @@ type UpdateAssignmentRequest struct {
 	Status         *string  `json:"status,omitempty"`
 	DeferredToID   *int     `json:"deferred_to_id,omitempty"`
 	Notes          *string  `json:"notes,omitempty"`
+	TaxDeductible  *bool    `json:"tax_deductible,omitempty"`
+	ScheduledDate  *string  `json:"scheduled_date,omitempty"` // YYYY-MM-DD, "" clears
+
+	OriginalCurrency *string  `json:"original_currency,omitempty"` // "" clears it and original_amount
+	OriginalAmount   *float64 `json:"original_amount,omitempty"`
+	FXRate           *float64 `json:"fx_rate,omitempty"` // reprices planned_amount from original_amount, unless planned_amount is given
+	FXNote           *string  `json:"fx_note,omitempty"`
+
+	PaidBy   *string `json:"paid_by,omitempty"`
+	PaidFrom *string `json:"paid_from,omitempty"`
+}
+
+type PayAssignmentRequest struct {
+	ActualAmount *float64 `json:"actual_amount,omitempty"` // defaults to the planned amount
+	PaidDate     *string  `json:"paid_date,omitempty"`     // YYYY-MM-DD, defaults to today
+	PaidBy       *string  `json:"paid_by,omitempty"`       // defaults to who was already recorded, else the bill's owner
+	PaidFrom     *string  `json:"paid_from,omitempty"`
 }
 
 type UpdateStatusRequest struct {
 	Status         string `json:"status"`
 	DeferredToID   *int   `json:"deferred_to_id,omitempty"`
 }
+
+// DueSoonBucket is a group of unpaid assignments with the same urgency.
+type DueSoonBucket struct {
+	Assignments []BillAssignment `json:"assignments"`
+	Total       float64          `json:"total"`
+}
+
+// DueSoon groups unpaid assignments by how soon they are due.
+type DueSoon struct {
+	AsOf       string        `json:"as_of"` // YYYY-MM-DD
+	Overdue    DueSoonBucket `json:"overdue"`
+	DueIn3Days DueSoonBucket `json:"due_in_3_days"`
+	DueIn7Days DueSoonBucket `json:"due_in_7_days"` // due in 4 to 7 days
+}
+
+// DeferOption is a future pay period an assignment could be deferred to.
+type DeferOption struct {
+	PeriodID           int     `json:"period_id"`
+	PayDate            string  `json:"pay_date"` // YYYY-MM-DD
+	SourceName         string  `json:"source_name"`
+	Remaining          float64 `json:"remaining"`           // expected income less bills already assigned
+	ProjectedRemaining float64 `json:"projected_remaining"` // remaining after taking on the deferred amount
+	PaysBeforeDue      bool    `json:"pays_before_due"`
+	Recommended        bool    `json:"recommended"`
+	PromoWarning       string  `json:"promo_warning,omitempty"` // set when the move crosses a card's promo APR expiry
+}
+
+// DeferOptions lists candidate periods for deferring an assignment, best first.
+type DeferOptions struct {
+	AssignmentID int           `json:"assignment_id"`
+	Amount       float64       `json:"amount"`
+	NextDueDate  *string       `json:"next_due_date"` // YYYY-MM-DD; nil when the bill has no due day
+	Options      []DeferOption `json:"options"`
+}
+
+// DuplicateAssignmentGroup is a set of pending assignments covering the same
+// bill in the same pay period. KeepID is the proposed survivor; MergeIDs are
+// folded into it.
+type DuplicateAssignmentGroup struct {
+	BillID      int              `json:"bill_id"`
+	BillName    string           `json:"bill_name"`
+	PayPeriodID int              `json:"pay_period_id"`
+	PayDate     string           `json:"pay_date"`
+	KeepID      int              `json:"keep_id"`
+	MergeIDs    []int            `json:"merge_ids"`
+	Assignments []BillAssignment `json:"assignments"`
+}
+
+type MergeDuplicatesRequest struct {
+	Merges []struct {
+		KeepID   int   `json:"keep_id"`
+		MergeIDs []int `json:"merge_ids"`
+	} `json:"merges"`
+}
+
+type MergeDuplicatesResult struct {
+	Merged   int `json:"merged"`   // assignments deleted
+	Relinked int `json:"relinked"` // transactions moved to the kept assignment
+}
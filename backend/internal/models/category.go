@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Category is a managed bill category. Bills refer to it by name.
+type Category struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	MonthlyLimit *float64  `json:"monthly_limit"` // nil means no limit
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CreateCategoryRequest struct {
+	Name         string   `json:"name"`
+	MonthlyLimit *float64 `json:"monthly_limit"`
+}
+
+type UpdateCategoryRequest struct {
+	Name         *string  `json:"name,omitempty"`          // renames bills in the category too
+	MonthlyLimit *float64 `json:"monthly_limit,omitempty"` // 0 removes the limit
+}
+
+// CategorySpending compares a category's assignments in a month to its limit.
+type CategorySpending struct {
+	Category  string   `json:"category"`
+	Month     string   `json:"month"` // YYYY-MM
+	Limit     *float64 `json:"limit"`
+	Planned   float64  `json:"planned"`   // every assignment except skipped ones
+	Actual    float64  `json:"actual"`    // paid so far
+	Remaining *float64 `json:"remaining"` // limit less planned, when there is a limit
+	OverLimit bool     `json:"over_limit"`
+}
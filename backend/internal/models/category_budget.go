@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// CategoryBudget is a monthly spending target for a bill category.
+type CategoryBudget struct {
+	Category     string    `json:"category"`
+	MonthlyLimit float64   `json:"monthly_limit"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type SetCategoryBudgetRequest struct {
+	MonthlyLimit float64 `json:"monthly_limit"`
+}
+
+// CategoryBudgetStatus compares a category's spending in a month to its target.
+type CategoryBudgetStatus struct {
+	Category        string  `json:"category"`
+	Month           string  `json:"month"` // YYYY-MM
+	Budget          float64 `json:"budget"`
+	Planned         float64 `json:"planned"` // everything assigned, paid or not
+	Actual          float64 `json:"actual"`  // paid so far
+	PercentConsumed float64 `json:"percent_consumed"`
+	Alert           bool    `json:"alert"` // actual at or over the alert threshold
+}
@@ -0,0 +1,318 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MoneyFields are the JSON fields and query parameters holding amounts of
+// money. API v2 sends and takes them as integer cents; the handlers work in
+// decimal dollars throughout, as v1 does. Percentages, rates and optimizer
+// weights keep their units.
+var MoneyFields = map[string]bool{
+	"actual": true, "actual_amount": true, "actual_paid": true, "after": true, "allocated": true,
+	"amount": true, "amount_max": true, "amount_min": true, "annual_surplus": true, "balance": true,
+	"baseline_total": true, "before": true, "budget": true, "buffer": true, "by_member": true,
+	"by_party": true, "by_payee": true, "credit_limit": true, "credit_received": true,
+	"credits_received": true, "current_min_balance": true, "default_amount": true, "difference": true,
+	"due_total": true, "ending_balance": true, "expected_amount": true, "expected_credit": true,
+	"expected_income": true, "expected_received": true, "extra_income": true, "forecast_amount": true,
+	"given": true, "given_total": true, "improvement": true, "income": true, "interest": true,
+	"leftover": true, "limit": true, "lowest_balance": true, "min_balance": true,
+	"monthly_amounts": true, "monthly_limit": true, "monthly_payment": true, "next_pay_amount": true,
+	"optimized_min_balance": true, "original_amount": true, "outflows": true, "overrun": true,
+	"owed": true, "paid": true, "paid_total": true, "payment": true, "planned": true,
+	"planned_amount": true, "planned_bills": true, "projected_remaining": true, "promo_balance": true,
+	"remaining": true, "safe_to_spend_per_day": true, "scheduled_amount": true, "shortfall": true,
+	"spent": true, "starting_balance": true, "std_dev": true, "surplus": true, "surplus_amount": true,
+	"surplus_balance": true, "threshold": true, "tight_balance": true, "total": true,
+	"total_actual": true, "total_bills": true, "total_expected": true, "total_funded": true,
+	"total_income": true, "total_interest": true, "total_needed": true, "total_owed": true,
+	"unused_buffer": true, "variance": true,
+}
+
+// unitFields hold values in units of their own, such as paging counts and
+// optimizer scores, however their fields are named.
+var unitFields = map[string]bool{
+	"meta": true, "weights": true, "score": true, "current_score": true, "optimized_score": true,
+}
+
+// centsWriter marks a ResponseWriter whose client takes amounts in cents.
+type centsWriter struct {
+	http.ResponseWriter
+}
+
+func (w centsWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w centsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// CentAmounts wraps w so WriteJSON and the other writers here send amounts
+// as integer cents.
+func CentAmounts(w http.ResponseWriter) http.ResponseWriter {
+	return centsWriter{w}
+}
+
+// encodeBody writes v as JSON to w, in cents if w was wrapped by CentAmounts.
+func encodeBody(w http.ResponseWriter, v interface{}) {
+	if _, ok := w.(centsWriter); ok {
+		v = toCents(reflect.ValueOf(v), false)
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+// DollarsFromCents rewrites the amounts in a JSON document from integer cents
+// to decimal dollars. It fails on an amount that isn't a whole number; a
+// body that isn't JSON is returned as it is, for the handler to reject.
+func DollarsFromCents(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return body, nil
+	}
+	doc, err := convertTree(doc, "", false, centsToDollars)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// CentsToDollars turns an integer number of cents, as text, into dollars.
+func CentsToDollars(name, v string) (string, error) {
+	n, err := centsToDollars(name, json.Number(v))
+	if err != nil {
+		return "", err
+	}
+	return string(n.(json.Number)), nil
+}
+
+func centsToDollars(name string, n json.Number) (interface{}, error) {
+	cents, err := strconv.ParseInt(string(n), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a whole number of cents", name)
+	}
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return json.Number(fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)), nil
+}
+
+func dollarsToCents(_ string, n json.Number) (interface{}, error) {
+	f, err := n.Float64()
+	if err != nil {
+		return n, nil
+	}
+	return int64(math.Round(f * 100)), nil
+}
+
+// convertTree applies conv to the numbers under money fields of a decoded
+// JSON document. money carries down from a money field into its arrays and
+// objects, so the payee-keyed totals of by_payee are converted too.
+func convertTree(v interface{}, name string, money bool, conv func(string, json.Number) (interface{}, error)) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if unitFields[k] && !money {
+				continue
+			}
+			converted, err := convertTree(child, k, money || MoneyFields[k], conv)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = converted
+		}
+	case []interface{}:
+		for i, child := range t {
+			converted, err := convertTree(child, name, money, conv)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = converted
+		}
+	case json.Number:
+		if money {
+			return conv(name, t)
+		}
+	}
+	return v, nil
+}
+
+var (
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawType       = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// toCents is v as json.Marshal would see it, with floats under money fields
+// turned into integer cents. Stored JSON, such as audit rows and import
+// previews, is converted by field name.
+func toCents(v reflect.Value, money bool) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type() == rawType {
+		raw := v.Bytes()
+		if len(raw) == 0 {
+			return json.RawMessage(raw)
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return json.RawMessage(raw)
+		}
+		// Keyed by the stored document's own fields, not the one holding it
+		doc, _ = convertTree(doc, "", false, dollarsToCents)
+		return doc
+	}
+	if v.Type().Implements(marshalerType) && (v.Kind() != reflect.Pointer || !v.IsNil()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toCents(v.Elem(), money)
+	case reflect.Float32, reflect.Float64:
+		if money {
+			return int64(math.Round(v.Float() * 100))
+		}
+		return v.Interface()
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = toCents(v.Index(i), money)
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			if unitFields[k] && !money {
+				out[k] = iter.Value().Interface()
+				continue
+			}
+			out[k] = toCents(iter.Value(), money || MoneyFields[k])
+		}
+		return out
+	case reflect.Struct:
+		obj := object{}
+		structFields(v, &obj)
+		return obj
+	}
+	return v.Interface()
+}
+
+// object is a JSON object that keeps its struct's field order.
+type object []objectField
+
+type objectField struct {
+	name  string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// structFields appends v's fields to obj as encoding/json would name them,
+// flattening embedded structs.
+func structFields(v reflect.Value, obj *object) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				structFields(fv, obj)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		if unitFields[name] {
+			*obj = append(*obj, objectField{name, fv.Interface()})
+			continue
+		}
+		*obj = append(*obj, objectField{name, toCents(fv, MoneyFields[name])})
+	}
+}
+
+// isEmptyValue is encoding/json's test for omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
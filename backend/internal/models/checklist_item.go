@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ChecklistItem is a free-form task attached to a pay period.
+type ChecklistItem struct {
+	ID          int       `json:"id"`
+	PayPeriodID int       `json:"pay_period_id"`
+	Label       string    `json:"label"`
+	IsDone      bool      `json:"is_done"`
+	SortOrder   int       `json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateChecklistItemRequest struct {
+	Label     string `json:"label"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type UpdateChecklistItemRequest struct {
+	Label     *string `json:"label,omitempty"`
+	IsDone    *bool   `json:"is_done,omitempty"`
+	SortOrder *int    `json:"sort_order,omitempty"`
+}
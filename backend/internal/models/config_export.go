@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ConfigExportVersion is bumped when the configuration export format changes incompatibly.
+const ConfigExportVersion = 1
+
+// ConfigExport is a household's budget configuration without history: active
+// bills and income sources, with no ids, assignments or pay periods, so it can
+// be shared or version-controlled and loaded into another instance.
+type ConfigExport struct {
+	Version       int                  `json:"version"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	Categories    []string             `json:"categories"`
+	Bills         []BillConfig         `json:"bills"`
+	IncomeSources []IncomeSourceConfig `json:"income_sources"`
+}
+
+// BillConfig is a bill as it would be created, plus its sinking fund settings.
+type BillConfig struct {
+	CreateBillRequest
+	SinkingFundEnabled bool `json:"sinking_fund_enabled"`
+	SinkingFundPeriods *int `json:"sinking_fund_periods,omitempty"`
+}
+
+type IncomeSourceConfig struct {
+	CreateIncomeSourceRequest
+}
+
+// ConfigImportResult reports what an import created. Items whose name matches
+// an existing active bill or income source are skipped rather than duplicated.
+type ConfigImportResult struct {
+	ImportedBills         int      `json:"imported_bills"`
+	ImportedIncomeSources int      `json:"imported_income_sources"`
+	Skipped               []string `json:"skipped"`
+}
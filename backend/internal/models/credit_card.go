@@ -4,17 +4,73 @@ import "time"
 
 type CreditCard struct {
 	ID           int       `json:"id"`
-	BillID       int       `json:"bill_id"`
+	BillID       *int      `json:"bill_id"` // the bill that pays the card; nil when unlinked
 	CardLabel    string    `json:"card_label"`
 	StatementDay int       `json:"statement_day"`
 	DueDay       int       `json:"due_day"`
 	Issuer       string    `json:"issuer"`
+	APR          *float64  `json:"apr"` // standard purchase APR, percent
+	CreditLimit  *float64  `json:"credit_limit"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
 type CreateCreditCardRequest struct {
-	CardLabel    string `json:"card_label"`
-	StatementDay int    `json:"statement_day"`
-	DueDay       int    `json:"due_day"`
-	Issuer       string `json:"issuer"`
+	BillID       *int     `json:"bill_id,omitempty"` // ignored when creating the card with its bill
+	CardLabel    string   `json:"card_label"`
+	StatementDay int      `json:"statement_day"`
+	DueDay       int      `json:"due_day"`
+	Issuer       string   `json:"issuer"`
+	APR          *float64 `json:"apr"`
+	CreditLimit  *float64 `json:"credit_limit,omitempty"`
+}
+
+type UpdateCreditCardRequest struct {
+	CardLabel    *string  `json:"card_label,omitempty"`
+	StatementDay *int     `json:"statement_day,omitempty"`
+	DueDay       *int     `json:"due_day,omitempty"`
+	Issuer       *string  `json:"issuer,omitempty"`
+	APR          *float64 `json:"apr,omitempty"`
+	CreditLimit  *float64 `json:"credit_limit,omitempty"`
+}
+
+type LinkCreditCardRequest struct {
+	BillID int `json:"bill_id"`
+}
+
+// CreditCardPromo is a promotional APR on part or all of a card balance, such
+// as 0% on a balance transfer, until it expires.
+type CreditCardPromo struct {
+	ID           int       `json:"id"`
+	CreditCardID int       `json:"credit_card_id"`
+	Rate         float64   `json:"rate"`       // APR percent during the promo
+	Balance      *float64  `json:"balance"`    // amount under the promo; nil covers the whole balance
+	ExpiresOn    string    `json:"expires_on"` // YYYY-MM-DD, last day at the promo rate
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CreateCreditCardPromoRequest struct {
+	Rate        float64  `json:"rate"`
+	Balance     *float64 `json:"balance"`
+	ExpiresOn   string   `json:"expires_on"` // YYYY-MM-DD
+	Description string   `json:"description"`
+}
+
+// CreditCardCycle is one projected statement cycle of a card.
+type CreditCardCycle struct {
+	StatementDate string  `json:"statement_date"` // YYYY-MM-DD
+	DueDate       string  `json:"due_date"`       // YYYY-MM-DD
+	GraceDays     int     `json:"grace_days"`
+	PayPeriodID   *int    `json:"pay_period_id"` // the paycheck that pays it; nil when none is early enough
+	PayDate       *string `json:"pay_date"`
+	AssignmentID  *int    `json:"assignment_id"` // the card bill's assignment already due that day, if any
+}
+
+type CreditCardForecast struct {
+	CreditCardID int               `json:"credit_card_id"`
+	BillID       int               `json:"bill_id"`
+	BillName     string            `json:"bill_name"`
+	StatementDay int               `json:"statement_day"`
+	DueDay       int               `json:"due_day"`
+	Cycles       []CreditCardCycle `json:"cycles"`
 }
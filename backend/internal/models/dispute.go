@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// AssignmentDispute is a bill assignment being disputed with the biller. It
+// is open until ResolvedOn is set.
+type AssignmentDispute struct {
+	ID             int        `json:"id"`
+	AssignmentID   int        `json:"assignment_id"`
+	Status         string     `json:"status"` // open, resolved
+	OpenedOn       time.Time  `json:"opened_on"`
+	Reference      string     `json:"reference"` // the biller's case or ticket number
+	ExpectedCredit float64    `json:"expected_credit"`
+	ResolvedOn     *time.Time `json:"resolved_on"`
+	CreditReceived *float64   `json:"credit_received"`
+	Notes          string     `json:"notes"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// OpenDisputeRequest opens a dispute on an assignment, or edits the one it
+// has. OpenedOn defaults to today.
+type OpenDisputeRequest struct {
+	OpenedOn       *string  `json:"opened_on,omitempty"` // YYYY-MM-DD
+	Reference      *string  `json:"reference,omitempty"`
+	ExpectedCredit *float64 `json:"expected_credit,omitempty"`
+	Notes          *string  `json:"notes,omitempty"`
+}
+
+// ResolveDisputeRequest closes a dispute. CreditReceived defaults to 0, for a
+// dispute that was lost, and ResolvedOn to today.
+type ResolveDisputeRequest struct {
+	CreditReceived *float64 `json:"credit_received,omitempty"`
+	ResolvedOn     *string  `json:"resolved_on,omitempty"` // YYYY-MM-DD
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Document is a stored household document, such as an insurance policy or a
+// lease, with the bills it is tagged to. The file itself is downloaded
+// separately.
+type Document struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Kind        string     `json:"kind"` // insurance, lease, loan, warranty, tax, other
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int        `json:"size_bytes"`
+	ExpiresOn   *time.Time `json:"expires_on"`
+	RemindDays  int        `json:"remind_days"` // days before expires_on to start reminding
+	Notes       string     `json:"notes"`
+	BillIDs     []int      `json:"bill_ids"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// UpdateDocumentRequest edits a document's details. BillIDs, when set,
+// replaces its tags; an empty ExpiresOn clears the expiry date.
+type UpdateDocumentRequest struct {
+	Title      *string `json:"title,omitempty"`
+	Kind       *string `json:"kind,omitempty"`
+	ExpiresOn  *string `json:"expires_on,omitempty"` // YYYY-MM-DD
+	RemindDays *int    `json:"remind_days,omitempty"`
+	Notes      *string `json:"notes,omitempty"`
+	BillIDs    *[]int  `json:"bill_ids,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ImportJob is an uploaded workbook being parsed in the background. Preview
+// is set once it is done, and is then the pending import to confirm.
+type ImportJob struct {
+	ID         int             `json:"id"`
+	Filename   string          `json:"filename"`
+	Status     string          `json:"status"`   // queued, parsing, done, failed
+	Progress   int             `json:"progress"` // percent, 0-100
+	Attempts   int             `json:"attempts"`
+	Warnings   []string        `json:"warnings"`
+	Preview    json.RawMessage `json:"preview,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at"`
+}
+
+// Resolutions for an imported bill that looks like an existing one.
+const (
+	ImportSkip   = "skip"   // leave the existing bill as it is
+	ImportMerge  = "merge"  // update the existing bill's amount and due day from the import
+	ImportCreate = "create" // add the imported bill anyway
+)
+
+// ImportResolution says what to do with one row of the preview. BillID picks
+// the bill a merge updates, and defaults to the duplicate found for the row.
+type ImportResolution struct {
+	Row    int    `json:"row"` // index into the preview's bills
+	Action string `json:"action"`
+	BillID *int   `json:"bill_id,omitempty"`
+}
+
+// ConfirmImportRequest is the optional body of an XLSX import confirm. Every
+// row flagged as a duplicate needs a resolution.
+type ConfirmImportRequest struct {
+	Resolutions []ImportResolution `json:"resolutions"`
+}
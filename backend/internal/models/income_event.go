@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// IncomeEvent is a one-off inflow on a known date, such as a bonus or a tax
+// refund.
+type IncomeEvent struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Amount         float64   `json:"amount"`
+	EventDate      string    `json:"event_date"`       // YYYY-MM-DD
+	IncomeSourceID *int      `json:"income_source_id"` // restricts which source's paychecks receive it
+	PayPeriodID    *int      `json:"pay_period_id"`    // the paycheck it was attached to by generation
+	Notes          string    `json:"notes"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CreateIncomeEventRequest struct {
+	Name           string  `json:"name"`
+	Amount         float64 `json:"amount"`
+	EventDate      string  `json:"event_date"` // YYYY-MM-DD
+	IncomeSourceID *int    `json:"income_source_id"`
+	Notes          string  `json:"notes"`
+}
+
+type UpdateIncomeEventRequest struct {
+	Name           *string  `json:"name,omitempty"`
+	Amount         *float64 `json:"amount,omitempty"`
+	EventDate      *string  `json:"event_date,omitempty"` // YYYY-MM-DD
+	IncomeSourceID *int     `json:"income_source_id,omitempty"`
+	Notes          *string  `json:"notes,omitempty"`
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -13,13 +14,32 @@ type IncomeSource struct {
 	DefaultAmount  *float64        `json:"default_amount"`
 	IsActive       bool            `json:"is_active"`
 	EffectiveFrom  *time.Time      `json:"effective_from"`
+	Owner          string          `json:"owner"` // household member it pays; empty means shared
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
 }
 
+// PayDateAdjustment can be added to the schedule_detail of any recurring
+// schedule (weekly, biweekly, semimonthly, monthly) to move paydays that land
+// on a weekend or bank holiday, the way real payroll does.
+type PayDateAdjustment struct {
+	Adjust   string `json:"adjust,omitempty"`   // one of the Adjust* policies; empty means none
+	Holidays string `json:"holidays,omitempty"` // holiday calendar name, default "us_federal"; "none" for weekends only
+}
+
+const (
+	AdjustNone                = "none"
+	AdjustPreviousBusinessDay = "previous_business_day"
+	AdjustNextBusinessDay     = "next_business_day"
+)
+
 // WeeklySchedule is used when PaySchedule == "weekly"
 type WeeklySchedule struct {
 	Weekday int `json:"weekday"` // 0=Sunday, 5=Friday, etc.
+	// SkipEvery, when set, leaves out every Nth week: 3 pays two weeks and
+	// skips the third. Weeks are counted from AnchorDate, a paid week.
+	SkipEvery  int    `json:"skip_every,omitempty"`
+	AnchorDate string `json:"anchor_date,omitempty"`
 }
 
 // BiweeklySchedule is used when PaySchedule == "biweekly"
@@ -28,23 +48,86 @@ type BiweeklySchedule struct {
 	AnchorDate string `json:"anchor_date"` // a known pay date to anchor the biweekly cycle
 }
 
+// LastDayOfMonth is stored in SemiMonthlySchedule.Days for the "last" token.
+const LastDayOfMonth = -1
+
 // SemiMonthlySchedule is used when PaySchedule == "semimonthly"
 type SemiMonthlySchedule struct {
-	Days              []int `json:"days"`                // e.g. [1, 16]
+	Days              []int `json:"days"`                // e.g. [1, 16] or [15, "last"]
 	AdjustForWeekends bool  `json:"adjust_for_weekends"` // if true, move weekend dates to preceding Friday
 }
 
+// UnmarshalJSON accepts "last" alongside integers in days, decoding it as LastDayOfMonth.
+func (s *SemiMonthlySchedule) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Days              []json.RawMessage `json:"days"`
+		AdjustForWeekends bool              `json:"adjust_for_weekends"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	s.AdjustForWeekends = raw.AdjustForWeekends
+	s.Days = nil
+	for _, d := range raw.Days {
+		var token string
+		if err := json.Unmarshal(d, &token); err == nil {
+			if token != "last" {
+				return fmt.Errorf("invalid semimonthly day %q", token)
+			}
+			s.Days = append(s.Days, LastDayOfMonth)
+			continue
+		}
+		var day int
+		if err := json.Unmarshal(d, &day); err != nil {
+			return fmt.Errorf("invalid semimonthly day %s", d)
+		}
+		s.Days = append(s.Days, day)
+	}
+	return nil
+}
+
+// MarshalJSON emits LastDayOfMonth back as "last".
+func (s SemiMonthlySchedule) MarshalJSON() ([]byte, error) {
+	days := make([]interface{}, len(s.Days))
+	for i, d := range s.Days {
+		if d == LastDayOfMonth {
+			days[i] = "last"
+		} else {
+			days[i] = d
+		}
+	}
+	return json.Marshal(struct {
+		Days              []interface{} `json:"days"`
+		AdjustForWeekends bool          `json:"adjust_for_weekends"`
+	}{days, s.AdjustForWeekends})
+}
+
+// MonthlySchedule is used when PaySchedule == "monthly". Days past the end of a
+// short month (e.g. 31 in April) are paid on its last day.
+type MonthlySchedule struct {
+	Day               int  `json:"day"`                 // 1-31
+	AdjustForWeekends bool `json:"adjust_for_weekends"` // if true, move weekend dates to preceding Friday
+}
+
 // OneTimeSchedule is used when PaySchedule == "one_time" (e.g. bonus)
 type OneTimeSchedule struct {
 	Date string `json:"date"` // YYYY-MM-DD
 }
 
+// CustomSchedule is used when PaySchedule == "custom" for irregular income such
+// as contractor invoices, paid on an explicit list of dates.
+type CustomSchedule struct {
+	Dates []string `json:"dates"` // YYYY-MM-DD
+}
+
 type CreateIncomeSourceRequest struct {
 	Name           string          `json:"name"`
 	PaySchedule    string          `json:"pay_schedule"`
 	ScheduleDetail json.RawMessage `json:"schedule_detail"`
 	DefaultAmount  *float64        `json:"default_amount"`
 	EffectiveFrom  *string         `json:"effective_from"` // YYYY-MM-DD format
+	Owner          string          `json:"owner"`
 }
 
 type UpdateIncomeSourceRequest struct {
@@ -54,4 +137,79 @@ type UpdateIncomeSourceRequest struct {
 	DefaultAmount  *float64         `json:"default_amount,omitempty"`
 	IsActive       *bool            `json:"is_active,omitempty"`
 	EffectiveFrom  *string          `json:"effective_from,omitempty"` // YYYY-MM-DD format
+	Owner          *string          `json:"owner,omitempty"`
+}
+
+// DuplicateIncomeSourceRequest optionally renames the copy; it defaults to "<name> (copy)".
+type DuplicateIncomeSourceRequest struct {
+	Name string `json:"name"`
+}
+
+// IncomeSourceTemplate is a built-in starting point for a new income source.
+// Schedules that need a known pay date (biweekly anchor_date) leave it blank for the user to fill in.
+type IncomeSourceTemplate struct {
+	Key            string          `json:"key"`
+	Name           string          `json:"name"`
+	PaySchedule    string          `json:"pay_schedule"`
+	ScheduleDetail json.RawMessage `json:"schedule_detail"`
+}
+
+// IncomeSourceTemplates are the built-in templates offered when adding an income source.
+var IncomeSourceTemplates = []IncomeSourceTemplate{
+	{
+		Key:            "biweekly_friday",
+		Name:           "Biweekly (Friday)",
+		PaySchedule:    "biweekly",
+		ScheduleDetail: json.RawMessage(`{"weekday":5,"anchor_date":""}`),
+	},
+	{
+		Key:            "semimonthly_15_last",
+		Name:           "Semimonthly (15th and last day)",
+		PaySchedule:    "semimonthly",
+		ScheduleDetail: json.RawMessage(`{"days":[15,"last"],"adjust_for_weekends":true}`),
+	},
+}
+
+// IncomeSourceTotals are an income source's lifetime pay period figures.
+// ExpectedReceived is the expected income of just the periods with an actual
+// amount recorded, so it compares like for like with TotalActual.
+type IncomeSourceTotals struct {
+	PeriodsGenerated int        `json:"periods_generated"`
+	PeriodsReceived  int        `json:"periods_received"`
+	FirstPayDate     *string    `json:"first_pay_date"`
+	LastPayDate      *string    `json:"last_pay_date"`
+	TotalExpected    float64    `json:"total_expected"`
+	TotalActual      float64    `json:"total_actual"`
+	ExpectedReceived float64    `json:"expected_received"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+}
+
+// IncomeSourceSummary is a read-only view of an income source's history. For
+// a deleted source, whose pay periods are gone, Archived is set and the
+// totals are the snapshot taken when it was deleted.
+type IncomeSourceSummary struct {
+	IncomeSourceID int    `json:"income_source_id"`
+	Name           string `json:"name"`
+	PaySchedule    string `json:"pay_schedule"`
+	IsActive       bool   `json:"is_active"`
+	Archived       bool   `json:"archived"`
+	IncomeSourceTotals
+	Difference float64 `json:"difference"` // TotalActual - ExpectedReceived
+}
+
+// PayrollCalendar is an income source's official pay dates for one year, as
+// published by the employer. Generation uses them instead of the schedule.
+type PayrollCalendar struct {
+	IncomeSourceID int      `json:"income_source_id"`
+	Year           int      `json:"year"`
+	Dates          []string `json:"dates"` // YYYY-MM-DD, ascending
+
+	// Set when a calendar is uploaded: pay periods already generated for the
+	// year on dates the calendar doesn't have. They are left for the user to
+	// move or delete.
+	StalePayDates []string `json:"stale_pay_dates,omitempty"`
+}
+
+type SetPayrollCalendarRequest struct {
+	Dates []string `json:"dates"` // YYYY-MM-DD, all within the year
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// NotificationPreferences are one user's email reminder settings.
+type NotificationPreferences struct {
+	Username       string     `json:"username"`
+	Email          string     `json:"email"`
+	Enabled        bool       `json:"enabled"`
+	DaysAhead      int        `json:"days_ahead"` // remind about bills due within this many days
+	IncludeOverdue bool       `json:"include_overdue"`
+	SendHour       int        `json:"send_hour"`    // 0-23, server local time
+	LastSentOn     *string    `json:"last_sent_on"` // YYYY-MM-DD
+	UpdatedAt      *time.Time `json:"updated_at"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Email          *string `json:"email,omitempty"`
+	Enabled        *bool   `json:"enabled,omitempty"`
+	DaysAhead      *int    `json:"days_ahead,omitempty"`
+	IncludeOverdue *bool   `json:"include_overdue,omitempty"`
+	SendHour       *int    `json:"send_hour,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// ObligationSnapshot is the monthly total of active bills recorded on a day.
+type ObligationSnapshot struct {
+	Date      string  `json:"date"` // YYYY-MM-DD
+	Total     float64 `json:"total"`
+	BillCount int     `json:"bill_count"`
+	Alerted   bool    `json:"alerted"`
+}
+
+// ObligationReport is what the active bills cost per month now, how that
+// compares with the lowest total of the last 90 days, and the recorded
+// history between from and to.
+type ObligationReport struct {
+	AsOf          string               `json:"as_of"` // YYYY-MM-DD
+	Total         float64              `json:"total"`
+	BillCount     int                  `json:"bill_count"`
+	Baseline      *ObligationSnapshot  `json:"baseline"` // nil until a snapshot exists
+	PercentChange float64              `json:"percent_change"`
+	AlertPercent  float64              `json:"alert_percent"` // 0 when alerts are off
+	Alert         bool                 `json:"alert"`
+	Message       string               `json:"message,omitempty"`
+	History       []ObligationSnapshot `json:"history"`
+}
+
+// ObligationAlert is published when monthly obligations rise by more than
+// the alert percentage within 90 days.
+type ObligationAlert struct {
+	AsOf          string  `json:"as_of"` // YYYY-MM-DD
+	Total         float64 `json:"total"`
+	BaselineDate  string  `json:"baseline_date"`
+	BaselineTotal float64 `json:"baseline_total"`
+	PercentChange float64 `json:"percent_change"`
+	Message       string  `json:"message"`
+}
@@ -15,10 +15,70 @@ type PayPeriod struct {
 	SourceName     string  `json:"source_name,omitempty"`
 	TotalBills     float64 `json:"total_bills"`
 	Remaining      float64 `json:"remaining"`
+	ExtraIncome    float64 `json:"extra_income,omitempty"` // income events attached to this paycheck
+
+	// Set when same-date periods from several sources are aggregated into this one
+	MergedPeriodIDs []int `json:"merged_period_ids,omitempty"`
 }
 
 type GeneratePeriodsRequest struct {
 	From      string `json:"from"`       // YYYY-MM-DD
 	To        string `json:"to"`         // YYYY-MM-DD
 	SourceIDs []int  `json:"source_ids"` // empty = all active sources
+
+	// UpdateAmounts overwrites the expected amount of periods already
+	// generated when it differs from the source's default amount. Without
+	// it they are left alone and reported as conflicts.
+	UpdateAmounts bool `json:"update_amounts"`
+}
+
+// What generation does with each payday.
+const (
+	GenerateActionCreate   = "create"
+	GenerateActionSkip     = "skip"     // already generated with the same amount
+	GenerateActionUpdate   = "update"   // already generated; expected_amount is overwritten
+	GenerateActionConflict = "conflict" // already generated with a different amount, left as is
+)
+
+// GenerateReport sorts the paydays of a generate run by what happened to them.
+type GenerateReport struct {
+	Created   []PayPeriod        `json:"created"`
+	Updated   []PayPeriod        `json:"updated"`
+	Skipped   []PayPeriod        `json:"skipped"`
+	Conflicts []GenerateConflict `json:"conflicts"`
+}
+
+// GenerateConflict is an existing period whose expected amount differs from
+// what its income source would generate today.
+type GenerateConflict struct {
+	PayPeriod       PayPeriod `json:"pay_period"`
+	ScheduledAmount float64   `json:"scheduled_amount"` // the source's default amount
+}
+
+// GeneratePreview is what generating pay periods would do for one income
+// source, without saving anything.
+type GeneratePreview struct {
+	IncomeSourceID int                     `json:"income_source_id"`
+	SourceName     string                  `json:"source_name"`
+	EffectiveFrom  string                  `json:"effective_from"` // where generation starts for this source
+	Periods        []GeneratePreviewPeriod `json:"periods"`
+}
+
+type GeneratePreviewPeriod struct {
+	PayDate        string   `json:"pay_date"` // YYYY-MM-DD
+	ExpectedAmount *float64 `json:"expected_amount"`
+	Exists         bool     `json:"exists"` // already generated
+	Action         string   `json:"action"` // create, skip, update or conflict; see GenerateReport
+}
+
+// BulkUpdatePeriodsRequest sets the expected amount of an income source's
+// upcoming pay periods in one go, e.g. after a raise.
+type BulkUpdatePeriodsRequest struct {
+	IncomeSourceID int      `json:"income_source_id"`
+	ExpectedAmount *float64 `json:"expected_amount"`
+	From           string   `json:"from"` // YYYY-MM-DD, default today
+
+	// UpdateDefault also sets the source's default amount, so periods
+	// generated later get the new amount too.
+	UpdateDefault bool `json:"update_default"`
 }
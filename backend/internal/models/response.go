@@ -13,6 +13,11 @@ type APIResponse struct {
 
 type Meta struct {
 	Timestamp time.Time `json:"timestamp"`
+
+	// Set on paged List responses
+	Total  *int `json:"total,omitempty"` // rows matching the filters, across all pages
+	Limit  *int `json:"limit,omitempty"` // omitted when unlimited
+	Offset *int `json:"offset,omitempty"`
 }
 
 type APIError struct {
@@ -28,12 +33,24 @@ type ErrorDetail struct {
 func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(APIResponse{
+	encodeBody(w, APIResponse{
 		Data: data,
 		Meta: &Meta{Timestamp: time.Now().UTC()},
 	})
 }
 
+// WriteJSONList writes one page of a List endpoint with its paging in Meta.
+// A limit of 0 means the page is unlimited.
+func WriteJSONList(w http.ResponseWriter, data interface{}, total, limit, offset int) {
+	meta := &Meta{Timestamp: time.Now().UTC(), Total: &total, Offset: &offset}
+	if limit > 0 {
+		meta.Limit = &limit
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	encodeBody(w, APIResponse{Data: data, Meta: meta})
+}
+
 func WriteError(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -44,3 +61,17 @@ func WriteError(w http.ResponseWriter, status int, code, message string) {
 		},
 	})
 }
+
+// WriteErrorDetails is WriteError with structured details, such as the request
+// ID a 500 can be traced by.
+func WriteErrorDetails(w http.ResponseWriter, status int, code, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encodeBody(w, APIError{
+		Error: ErrorDetail{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
@@ -656,3 +656,81 @@ func TestErrorDetail_DetailsIncludedWhenPresent(t *testing.T) {
 		t.Error("expected 'details' key to be present when Details is non-nil")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Cent amounts
+// ---------------------------------------------------------------------------
+
+func TestCentAmounts_OnlyAmountsChange(t *testing.T) {
+	type base struct {
+		ID     int      `json:"id"`
+		Amount *float64 `json:"amount"`
+	}
+	amount := 12.34
+	data := struct {
+		base
+		Paid     int                `json:"paid"`
+		Note     string             `json:"note,omitempty"`
+		ByPayee  map[string]float64 `json:"by_payee"`
+		Share    float64            `json:"share"`
+		Weights  map[string]float64 `json:"weights"`
+		Stored   json.RawMessage    `json:"stored"`
+		Skipped  float64            `json:"-"`
+		Optional *float64           `json:"planned_amount"`
+	}{
+		base:    base{ID: 2, Amount: &amount},
+		Paid:    3,
+		ByPayee: map[string]float64{"City": 12.345},
+		Share:   62.5,
+		Weights: map[string]float64{"balance": 0.5},
+		Stored:  json.RawMessage(`{"bill_id": 2, "planned_amount": 80.5}`),
+		Skipped: 1,
+	}
+
+	w := httptest.NewRecorder()
+	WriteJSON(CentAmounts(w), http.StatusOK, data)
+
+	want := `{"id":2,"amount":1234,"paid":3,"by_payee":{"City":1235},"share":62.5,` +
+		`"weights":{"balance":0.5},"stored":{"bill_id":2,"planned_amount":8050},"planned_amount":null}`
+	if got := responseData(t, w); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestCentAmounts_PlainWriterKeepsDollars(t *testing.T) {
+	amount := 12.34
+	w := httptest.NewRecorder()
+	WriteJSON(w, http.StatusOK, map[string]*float64{"amount": &amount})
+
+	if got := responseData(t, w); got != `{"amount":12.34}` {
+		t.Errorf("unexpected body: %s", got)
+	}
+}
+
+// responseData returns the data member of a response body as written.
+func responseData(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return string(resp.Data)
+}
+
+func TestDollarsFromCents(t *testing.T) {
+	got, err := DollarsFromCents([]byte(`{"name":"Rent","amount":-1205,"monthly_amounts":{"3":5},"buffer_percent":10}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"amount":-12.05,"buffer_percent":10,"monthly_amounts":{"3":0.05},"name":"Rent"}` {
+		t.Errorf("unexpected body: %s", got)
+	}
+	if _, err := DollarsFromCents([]byte(`{"amount": 12.5}`)); err == nil || err.Error() != "amount must be a whole number of cents" {
+		t.Errorf("expected a whole-cents error, got %v", err)
+	}
+	if got, err := DollarsFromCents([]byte(`not json`)); err != nil || string(got) != "not json" {
+		t.Errorf("non-JSON body should pass through, got %s, %v", got, err)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Scenario is a what-if copy of the bills, pay periods and assignments that
+// can be changed and optimized without touching the live budget, then
+// promoted to it or discarded.
+type Scenario struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"` // open, promoted
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PromotedAt  *time.Time `json:"promoted_at"`
+	// Set on open scenarios: how many rows its copies hold
+	Bills       *int `json:"bills,omitempty"`
+	Periods     *int `json:"periods,omitempty"`
+	Assignments *int `json:"assignments,omitempty"`
+}
+
+type CreateScenarioRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ScenarioTableResult counts the live rows a promote changed in one table.
+type ScenarioTableResult struct {
+	Updated int `json:"updated"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// ScenarioPromoteResult is keyed by table: bills, pay_periods and
+// bill_assignments.
+type ScenarioPromoteResult struct {
+	ScenarioID int                            `json:"scenario_id"`
+	Tables     map[string]ScenarioTableResult `json:"tables"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// SweepSuggestion is a closed pay period with more left over than the
+// threshold, and the transfer that would move the excess into savings.
+type SweepSuggestion struct {
+	PeriodID  int     `json:"period_id"`
+	PayDate   string  `json:"pay_date"` // YYYY-MM-DD
+	Source    string  `json:"source"`
+	Income    float64 `json:"income"` // actual amount if received, else expected
+	Spent     float64 `json:"spent"`  // every assignment not skipped or deferred
+	Leftover  float64 `json:"leftover"`
+	Threshold float64 `json:"threshold"`
+	Amount    float64 `json:"amount"` // leftover above the threshold
+}
+
+// CreateSweepRequest moves a closed period's excess into the savings bill
+// BillID as a pending extra assignment.
+type CreateSweepRequest struct {
+	PeriodID  int     `json:"period_id"`
+	BillID    int     `json:"bill_id"`
+	Threshold float64 `json:"threshold"`
+}
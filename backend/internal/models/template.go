@@ -0,0 +1,48 @@
+package models
+
+import "encoding/json"
+
+// BudgetTemplateVersion is bumped when the template format changes incompatibly.
+const BudgetTemplateVersion = 1
+
+// BudgetTemplate is a starter budget to share: which bills to track, how they
+// recur and how paychecks arrive, without amounts or anything else personal.
+// Hash is the SHA-256 of the rest of the template, so a copy passed around can
+// be checked against the one that was published.
+type BudgetTemplate struct {
+	Version       int                    `json:"version"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Hash          string                 `json:"hash"`
+	Categories    []string               `json:"categories"`
+	Bills         []TemplateBill         `json:"bills"`
+	IncomeSources []TemplateIncomeSource `json:"income_sources"`
+}
+
+type TemplateBill struct {
+	Name             string          `json:"name"`
+	Category         string          `json:"category"`
+	DueDay           *int            `json:"due_day"`
+	Recurrence       string          `json:"recurrence"`
+	RecurrenceDetail json.RawMessage `json:"recurrence_detail,omitempty"`
+	IsAutopay        bool            `json:"is_autopay"`
+	ActiveMonths     []int           `json:"active_months,omitempty"`
+}
+
+// TemplateIncomeSource is a pay schedule. Sources are exported as "Paycheck 1",
+// "Paycheck 2" and so on rather than by employer.
+type TemplateIncomeSource struct {
+	Name           string          `json:"name"`
+	PaySchedule    string          `json:"pay_schedule"`
+	ScheduleDetail json.RawMessage `json:"schedule_detail"`
+}
+
+// TemplateImportResult reports what loading a template created. Bills and
+// income sources named like an active one are skipped, so loading the same
+// template twice adds nothing the second time.
+type TemplateImportResult struct {
+	Hash                  string   `json:"hash"`
+	ImportedBills         int      `json:"imported_bills"`
+	ImportedIncomeSources int      `json:"imported_income_sources"`
+	Skipped               []string `json:"skipped"`
+}
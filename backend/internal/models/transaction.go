@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Transaction is money actually spent, optionally reconciled against the bill
+// assignment it paid.
+type Transaction struct {
+	ID           int       `json:"id"`
+	Date         time.Time `json:"date"`
+	Amount       float64   `json:"amount"`
+	Description  string    `json:"description"`
+	Source       string    `json:"source"` // manual, csv, apple_card, google_pay
+	AssignmentID *int      `json:"assignment_id"`
+	Notes        string    `json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CreateTransactionRequest struct {
+	Date        string  `json:"date"` // YYYY-MM-DD
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+	Notes       string  `json:"notes"`
+}
+
+type UpdateTransactionRequest struct {
+	Date         *string  `json:"date,omitempty"` // YYYY-MM-DD
+	Amount       *float64 `json:"amount,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	Notes        *string  `json:"notes,omitempty"`
+	AssignmentID *int     `json:"assignment_id,omitempty"` // link by hand; 0 unlinks
+}
+
+type ReconcileRequest struct {
+	WindowDays *int     `json:"window_days,omitempty"` // default 5
+	Tolerance  *float64 `json:"tolerance,omitempty"`   // dollars, default 1.00
+}
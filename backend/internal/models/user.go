@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// User is a login account with a role: viewer, editor or admin. The
+// administrator configured by AUTH_USERNAME is not stored here.
+type User struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"` // viewer when empty
+}
+
+type UpdateUserRequest struct {
+	Password *string `json:"password,omitempty"`
+	Role     *string `json:"role,omitempty"`
+}
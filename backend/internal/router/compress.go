@@ -0,0 +1,58 @@
+package router
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression levels, picked with BenchmarkCompression against a year-sized
+// budget grid payload. Responses are generated per request, so these favour
+// speed over maximum ratio: zstd's default level beats gzip -9 on size at about
+// four times the speed, and brotli 4 matches gzip -9 at about twice the speed.
+// Higher brotli levels compress far better but are orders of magnitude slower.
+const (
+	gzipLevel   = 5
+	brotliLevel = 4
+	zstdLevel   = zstd.SpeedDefault
+	zstdWindow  = 1 << 20 // keeps pooled encoders small; responses rarely exceed a few MB
+)
+
+// compressibleTypes are the response types worth compressing: JSON, the text
+// exports (CSV, QIF, iCalendar and the HTML plan) and the Swagger UI assets.
+// XLSX and PDF are compressed already.
+var compressibleTypes = []string{
+	"application/json",
+	"text/csv",
+	"application/qif",
+	"text/calendar",
+	"text/html",
+	"text/css",
+	"text/javascript",
+}
+
+// newCompressor negotiates zstd, brotli, gzip or deflate from Accept-Encoding,
+// preferring them in that order.
+func newCompressor() *middleware.Compressor {
+	c := middleware.NewCompressor(gzipLevel, compressibleTypes...)
+	// Encoders set later take precedence
+	c.SetEncoder("br", newBrotliEncoder)
+	c.SetEncoder("zstd", newZstdEncoder)
+	return c
+}
+
+func newBrotliEncoder(w io.Writer, _ int) io.Writer {
+	return brotli.NewWriterLevel(w, brotliLevel)
+}
+
+func newZstdEncoder(w io.Writer, _ int) io.Writer {
+	// Options are constant and valid, so NewWriter cannot fail here
+	enc, _ := zstd.NewWriter(w,
+		zstd.WithEncoderLevel(zstdLevel),
+		zstd.WithEncoderConcurrency(1),
+		zstd.WithWindowSize(zstdWindow),
+	)
+	return enc
+}
@@ -0,0 +1,160 @@
+package router
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// samplePayload approximates a year of budget grid JSON: ~26 periods x 40 bills.
+func samplePayload(tb testing.TB) []byte {
+	tb.Helper()
+	type cell struct {
+		ID            int       `json:"id"`
+		BillID        int       `json:"bill_id"`
+		PayPeriodID   int       `json:"pay_period_id"`
+		BillName      string    `json:"bill_name"`
+		PlannedAmount float64   `json:"planned_amount"`
+		Status        string    `json:"status"`
+		Notes         string    `json:"notes"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+	var cells []cell
+	base := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	for p := 0; p < 26; p++ {
+		for b := 0; b < 40; b++ {
+			cells = append(cells, cell{
+				ID:            p*40 + b,
+				BillID:        b,
+				PayPeriodID:   p,
+				BillName:      fmt.Sprintf("Bill %d", b),
+				PlannedAmount: float64(b*17%500) + 0.99,
+				Status:        []string{"pending", "paid", "deferred"}[b%3],
+				CreatedAt:     base.AddDate(0, 0, p*14),
+			})
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{"data": cells})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+func serveCompressed(t *testing.T, acceptEncoding string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveCompressedType(t, acceptEncoding, "application/json", body)
+}
+
+func serveCompressedType(t *testing.T, acceptEncoding, contentType string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := newCompressor().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/budget-grid", nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCompressor_Negotiation(t *testing.T) {
+	body := samplePayload(t)
+
+	tests := []struct {
+		accept   string
+		encoding string
+		decode   func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip, deflate, br, zstd", "zstd", func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+		{"gzip, br", "br", func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+		{"gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"", "", func(r io.Reader) (io.Reader, error) { return r, nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			rr := serveCompressed(t, tt.accept, body)
+			if got := rr.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			r, err := tt.decode(rr.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, body) {
+				t.Error("decoded body does not match original")
+			}
+		})
+	}
+}
+
+func TestCompressor_TextExports(t *testing.T) {
+	body := bytes.Repeat([]byte("!Type:Bank\nD03/10/2026\nT-64.50\nPWater\n^\n"), 200)
+	for _, ct := range []string{"application/qif", "text/csv", "text/calendar; charset=utf-8", "text/html; charset=utf-8"} {
+		if got := serveCompressedType(t, "gzip", ct, body).Header().Get("Content-Encoding"); got != "gzip" {
+			t.Errorf("%s: Content-Encoding = %q, want gzip", ct, got)
+		}
+	}
+	if got := serveCompressedType(t, "gzip", "application/pdf", body).Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("application/pdf: Content-Encoding = %q, want none", got)
+	}
+}
+
+// BenchmarkCompression compares codecs and levels on a representative payload.
+// Run with: go test ./internal/router -bench Compression -run '^$'
+func BenchmarkCompression(b *testing.B) {
+	body := samplePayload(b)
+
+	codecs := []struct {
+		name string
+		new  func(io.Writer) io.WriteCloser
+	}{
+		{"gzip-1", func(w io.Writer) io.WriteCloser { z, _ := gzip.NewWriterLevel(w, 1); return z }},
+		{"gzip-5", func(w io.Writer) io.WriteCloser { z, _ := gzip.NewWriterLevel(w, 5); return z }},
+		{"gzip-9", func(w io.Writer) io.WriteCloser { z, _ := gzip.NewWriterLevel(w, 9); return z }},
+		{"br-1", func(w io.Writer) io.WriteCloser { return brotli.NewWriterLevel(w, 1) }},
+		{"br-4", func(w io.Writer) io.WriteCloser { return brotli.NewWriterLevel(w, 4) }},
+		{"br-11", func(w io.Writer) io.WriteCloser { return brotli.NewWriterLevel(w, 11) }},
+		{"zstd-fastest", func(w io.Writer) io.WriteCloser {
+			z, _ := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+			return z
+		}},
+		{"zstd-default", func(w io.Writer) io.WriteCloser {
+			z, _ := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+			return z
+		}},
+		{"zstd-best", func(w io.Writer) io.WriteCloser {
+			z, _ := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+			return z
+		}},
+	}
+
+	for _, c := range codecs {
+		b.Run(c.name, func(b *testing.B) {
+			var buf bytes.Buffer
+			b.SetBytes(int64(len(body)))
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				w := c.new(&buf)
+				w.Write(body)
+				w.Close()
+			}
+			b.ReportMetric(float64(len(body))/float64(buf.Len()), "ratio")
+		})
+	}
+}
@@ -0,0 +1,611 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/handlers"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+)
+
+// routeDoc describes one handler for the OpenAPI spec. Paths, methods, path
+// parameters and authentication come from walking the router; schemas are
+// generated from the Body and Response types.
+type routeDoc struct {
+	Summary  string
+	Query    []string // query parameters, all passed as strings
+	Paged    bool     // accepts limit, offset and sort, and reports paging in meta
+	Body     any      // JSON request body type
+	Upload   []string // multipart/form-data fields besides the "file" upload
+	Response any      // type of data in the response envelope; nil leaves it open
+	Status   int      // success status; defaults to 200, or 204 for DELETE
+	Raw      string   // content type of a non-JSON response, e.g. text/calendar
+}
+
+// fileUpload marks a multipart endpoint without extra form fields.
+var fileUpload = []string{}
+
+// routeDocs is keyed by handler method ("BillHandler.List") so v1 and v2
+// share entries, or by "METHOD /path" for inline handlers. Every route must
+// have an entry; TestOpenAPI_DocumentsEveryRoute enforces it.
+var routeDocs = map[string]routeDoc{
+	"GET /api/v1/health": {Summary: "Health check", Response: map[string]string{}},
+	"GET /api/v2/health": {Summary: "Health check", Response: map[string]string{}},
+	"GET /metrics":       {Summary: "Database query metrics in the Prometheus text format", Raw: "text/plain"},
+
+	"AuthHandler.Login": {Summary: "Log in and receive the session cookie", Body: struct {
+		Username       string `json:"username"`
+		Password       string `json:"password"`
+		TurnstileToken string `json:"turnstileToken"`
+	}{}},
+	"AuthHandler.Refresh": {Summary: "Swap the refresh token cookie for a new one and a fresh access token"},
+	"AuthHandler.Logout":  {Summary: "Revoke the session and clear its cookies"},
+	"AuthHandler.Status":  {Summary: "Whether authentication is enabled and the caller is logged in"},
+
+	"BillHandler.List":        {Summary: "List bills", Query: []string{"active", "category", "autopay"}, Paged: true, Response: []models.Bill{}},
+	"BillHandler.Create":      {Summary: "Create a bill", Body: models.CreateBillRequest{}, Response: models.Bill{}, Status: http.StatusCreated},
+	"BillHandler.Get":         {Summary: "Get a bill", Response: models.Bill{}},
+	"BillHandler.Update":      {Summary: "Update a bill", Body: models.UpdateBillRequest{}, Response: models.Bill{}},
+	"BillHandler.Delete":      {Summary: "Delete a bill"},
+	"BillHandler.Reorder":     {Summary: "Set the display order of bills", Body: models.ReorderBillsRequest{}},
+	"BillHandler.Skip":        {Summary: "Skip a bill for one month", Body: models.SkipBillRequest{}, Response: models.BillSkip{}, Status: http.StatusCreated},
+	"BillHandler.Unskip":      {Summary: "Undo a month's skip", Query: []string{"month"}},
+	"BillHandler.Skips":       {Summary: "List a bill's skipped months", Response: []models.BillSkip{}},
+	"BillHandler.Promos":      {Summary: "List a credit card bill's promotional APR windows", Response: []models.CreditCardPromo{}},
+	"BillHandler.AddPromo":    {Summary: "Add a promotional APR window", Body: models.CreateCreditCardPromoRequest{}, Response: models.CreditCardPromo{}, Status: http.StatusCreated},
+	"BillHandler.DeletePromo": {Summary: "Remove a promotional APR window"},
+	"BillHandler.Payoff":      {Summary: "Month-by-month payoff plan for a card balance", Query: []string{"balance", "payment"}, Response: services.PayoffPlan{}},
+
+	"SinkingFundHandler.Plan":  {Summary: "Preview spreading a bill across earlier pay periods", Body: sinkingFundRequest{}},
+	"SinkingFundHandler.Apply": {Summary: "Create the sinking fund assignments", Body: sinkingFundRequest{}},
+	"SinkingFundHandler.Clear": {Summary: "Remove a bill's sinking fund assignments", Query: []string{"target_period_id"}},
+
+	"CreditCardHandler.List":     {Summary: "List credit cards, linked to a bill or not", Response: []models.CreditCard{}},
+	"CreditCardHandler.Create":   {Summary: "Create a credit card, optionally linked to a bill", Body: models.CreateCreditCardRequest{}, Response: models.CreditCard{}, Status: http.StatusCreated},
+	"CreditCardHandler.Get":      {Summary: "Get a credit card", Response: models.CreditCard{}},
+	"CreditCardHandler.Update":   {Summary: "Update a credit card", Body: models.UpdateCreditCardRequest{}, Response: models.CreditCard{}},
+	"CreditCardHandler.Delete":   {Summary: "Delete a credit card and its promos, keeping its bill"},
+	"CreditCardHandler.Link":     {Summary: "Link a credit card to the bill that pays it", Body: models.LinkCreditCardRequest{}, Response: models.CreditCard{}},
+	"CreditCardHandler.Unlink":   {Summary: "Unlink a credit card from its bill", Response: models.CreditCard{}},
+	"CreditCardHandler.Forecast": {Summary: "Upcoming statement close and due dates with the paycheck paying each", Query: []string{"from", "count"}, Response: models.CreditCardForecast{}},
+	"CreditCardHandler.Assign":   {Summary: "Create the card bill's assignments from its statement cycles", Query: []string{"from", "count"}, Response: []models.BillAssignment{}, Status: http.StatusCreated},
+
+	"IncomeHandler.List":      {Summary: "List income sources", Query: []string{"active", "pay_schedule"}, Paged: true, Response: []models.IncomeSource{}},
+	"IncomeHandler.Create":    {Summary: "Create an income source", Body: models.CreateIncomeSourceRequest{}, Response: models.IncomeSource{}, Status: http.StatusCreated},
+	"IncomeHandler.Templates": {Summary: "Common pay schedule templates", Response: []models.IncomeSourceTemplate{}},
+	"IncomeHandler.Get":       {Summary: "Get an income source", Response: models.IncomeSource{}},
+	"IncomeHandler.Update":    {Summary: "Update an income source", Body: models.UpdateIncomeSourceRequest{}, Response: models.IncomeSource{}},
+	"IncomeHandler.Delete":    {Summary: "Delete an income source"},
+	"IncomeHandler.Summary":   {Summary: "Lifetime pay period totals, kept after the source is deleted", Response: models.IncomeSourceSummary{}},
+	"IncomeHandler.Duplicate": {Summary: "Copy an income source under a new name", Body: models.DuplicateIncomeSourceRequest{}, Response: models.IncomeSource{}, Status: http.StatusCreated},
+
+	"IncomeHandler.PayrollCalendars":      {Summary: "An income source's official payroll calendars by year", Response: []models.PayrollCalendar{}},
+	"IncomeHandler.SetPayrollCalendar":    {Summary: "Replace a year's official pay dates, which generation uses over the schedule", Body: models.SetPayrollCalendarRequest{}, Response: models.PayrollCalendar{}},
+	"IncomeHandler.DeletePayrollCalendar": {Summary: "Drop a year's payroll calendar and go back to the schedule"},
+
+	"IncomeEventHandler.List":   {Summary: "List one-off income events such as bonuses", Query: []string{"from", "to"}, Response: []models.IncomeEvent{}},
+	"IncomeEventHandler.Create": {Summary: "Record a one-off income event", Body: models.CreateIncomeEventRequest{}, Response: models.IncomeEvent{}, Status: http.StatusCreated},
+	"IncomeEventHandler.Get":    {Summary: "Get an income event", Response: models.IncomeEvent{}},
+	"IncomeEventHandler.Update": {Summary: "Update an income event", Body: models.UpdateIncomeEventRequest{}, Response: models.IncomeEvent{}},
+	"IncomeEventHandler.Delete": {Summary: "Delete an income event"},
+
+	"PeriodHandler.List":     {Summary: "List pay periods with their bill totals", Query: []string{"from", "to", "income_source_id", "aggregate"}, Paged: true, Response: []models.PayPeriod{}},
+	"PeriodHandler.Generate": {Summary: "Generate pay periods from income schedules, reporting created, updated, skipped and conflicting paydays", Body: models.GeneratePeriodsRequest{}, Response: models.GenerateReport{}, Status: http.StatusCreated},
+	"PeriodHandler.Preview":  {Summary: "Dry run of generation: the paydays and amounts each source would get", Body: models.GeneratePeriodsRequest{}, Response: []models.GeneratePreview{}},
+	"PeriodHandler.Update": {Summary: "Update a pay period's amounts or notes", Body: struct {
+		ExpectedAmount *float64 `json:"expected_amount"`
+		ActualAmount   *float64 `json:"actual_amount"`
+		Notes          *string  `json:"notes"`
+	}{}, Response: models.PayPeriod{}},
+	"PeriodHandler.BulkUpdate": {Summary: "Set the expected amount of an income source's upcoming periods, e.g. after a raise", Body: models.BulkUpdatePeriodsRequest{}, Response: []models.PayPeriod{}},
+	"PeriodHandler.CopyFrom":   {Summary: "Copy another period's assignments into this one", Status: http.StatusCreated},
+	"PeriodHandler.Risk":       {Summary: "Late-payment risk score for each pay period, with the reasons behind it", Query: []string{"from", "to", "mode"}, Response: []services.PeriodRisk{}},
+
+	"SweepHandler.Suggestions": {Summary: "Closed pay periods with leftover above a threshold, and how much to sweep to savings", Query: []string{"from", "to", "threshold"}, Response: []models.SweepSuggestion{}},
+	"SweepHandler.Create":      {Summary: "Sweep a closed period's leftover into a savings bill as an extra assignment", Body: models.CreateSweepRequest{}, Response: models.BillAssignment{}, Status: http.StatusCreated},
+
+	"ChecklistHandler.List":   {Summary: "List a pay period's checklist", Response: []models.ChecklistItem{}},
+	"ChecklistHandler.Create": {Summary: "Add a checklist item", Body: models.CreateChecklistItemRequest{}, Response: models.ChecklistItem{}, Status: http.StatusCreated},
+	"ChecklistHandler.Update": {Summary: "Update a checklist item", Body: models.UpdateChecklistItemRequest{}, Response: models.ChecklistItem{}},
+	"ChecklistHandler.Delete": {Summary: "Delete a checklist item"},
+
+	"AssignmentHandler.List":    {Summary: "List bill assignments", Query: []string{"period_id", "bill_id", "status", "category", "autopay", "from", "to", "amount_min", "amount_max", "paid_after", "paid_before", "overdue"}, Paged: true, Response: []models.BillAssignment{}},
+	"AssignmentHandler.DueSoon": {Summary: "Unpaid assignments bucketed by urgency", Response: models.DueSoon{}},
+	"AssignmentHandler.Create":  {Summary: "Assign a bill to a pay period", Body: models.CreateAssignmentRequest{}, Response: models.BillAssignment{}, Status: http.StatusCreated},
+	"AssignmentHandler.AutoAssign": {Summary: "Assign bills to the pay periods in a range", Body: struct {
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Force bool   `json:"force"`
+	}{}},
+	"AssignmentHandler.Undo": {Summary: "Undo the latest assignment change, or the one an audit entry belongs to, with everything its request did to assignments", Body: models.UndoRequest{}, Response: models.UndoResult{}},
+	"AssignmentHandler.Redo": {Summary: "Redo the latest undo, unless assignments have changed since", Response: models.UndoResult{}},
+	"AssignmentHandler.ResetManualMoves": {Summary: "Clear the manually moved flag so auto-assign may move assignments again", Body: struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		BillIDs []int  `json:"bill_ids"`
+	}{}},
+	"AssignmentHandler.Update":         {Summary: "Update an assignment", Body: models.UpdateAssignmentRequest{}, Response: models.BillAssignment{}},
+	"AssignmentHandler.UpdateStatus":   {Summary: "Change an assignment's status", Body: models.UpdateStatusRequest{}, Response: models.BillAssignment{}},
+	"AssignmentHandler.DeferOptions":   {Summary: "Future pay periods to defer an assignment to, best first", Response: models.DeferOptions{}},
+	"AssignmentHandler.Pay":            {Summary: "Mark an assignment paid", Body: models.PayAssignmentRequest{}, Response: models.BillAssignment{}},
+	"AssignmentHandler.Delete":         {Summary: "Delete an assignment"},
+	"AssignmentHandler.Dispute":        {Summary: "Get the dispute on an assignment", Response: models.AssignmentDispute{}},
+	"AssignmentHandler.OpenDispute":    {Summary: "Open a dispute on an assignment, or edit its details", Body: models.OpenDisputeRequest{}, Response: models.AssignmentDispute{}},
+	"AssignmentHandler.ResolveDispute": {Summary: "Resolve a dispute with the credit received", Body: models.ResolveDisputeRequest{}, Response: models.AssignmentDispute{}},
+	"AssignmentHandler.DeleteDispute":  {Summary: "Remove an assignment's dispute"},
+
+	"GridHandler.GetGrid": {Summary: "Budget grid of bills by pay period", Query: []string{"from", "to"}},
+
+	"ImportHandler.Upload":           {Summary: "Upload an XLSX budget to be parsed in the background", Upload: fileUpload, Response: models.ImportJob{}, Status: http.StatusAccepted},
+	"ImportHandler.Job":              {Summary: "Parsing progress, warnings and, when done, the preview of an XLSX upload", Response: models.ImportJob{}},
+	"ImportHandler.Confirm":          {Summary: "Apply the pending XLSX import, resolving bills that duplicate existing ones", Body: models.ConfirmImportRequest{}},
+	"ImportHandler.DeleteSession":    {Summary: "Discard the pending XLSX preview"},
+	"ImportHandler.BankCSV":          {Summary: "Backfill actual amounts from a bank CSV export", Upload: csvUploadFields},
+	"ImportHandler.UploadCSV":        {Summary: "Upload a CSV bank statement and preview the assignments it settles", Upload: csvUploadFields},
+	"ImportHandler.ConfirmCSV":       {Summary: "Apply the pending CSV preview"},
+	"ImportHandler.DeleteCSVSession": {Summary: "Discard the pending CSV preview"},
+	"ImportHandler.History":          {Summary: "Past imports"},
+
+	"OptimizerHandler.Suggest": {Summary: "Suggest moves that balance pay periods", Body: struct {
+		From        string               `json:"from"`
+		To          string               `json:"to"`
+		Strategy    string               `json:"strategy"`
+		Debug       bool                 `json:"debug"`
+		Aggregate   bool                 `json:"aggregate"`
+		MinBalance  *float64             `json:"min_balance"`
+		AllowSplits bool                 `json:"allow_splits"`
+		Weights     *services.OptWeights `json:"weights"`
+		Mode        string               `json:"mode"`
+	}{}, Response: services.OptimizationResult{}},
+	"OptimizerHandler.Apply": {Summary: "Apply suggested moves or a saved plan", Body: struct {
+		PlanID int `json:"plan_id"`
+		Moves  []struct {
+			AssignmentID int `json:"assignment_id"`
+			ToPeriodID   int `json:"to_period_id"`
+		} `json:"moves"`
+	}{}, Response: []models.BillAssignment{}},
+	"OptimizerHandler.Surplus":    {Summary: "Pay periods with money left over", Query: []string{"from", "to"}},
+	"OptimizerHandler.ExportPlan": {Summary: "A saved plan, or the latest, as a shareable JSON or HTML document", Query: []string{"format"}, Response: handlers.PlanDocument{}},
+
+	"DashboardHandler.Summary": {Summary: "Upcoming pay periods and bills", Query: []string{"mode"}},
+	"DashboardHandler.Runway":  {Summary: "Money left in the current paycheck and safe-to-spend per day", Query: []string{"mode"}},
+	"ForecastHandler.Forecast": {Summary: "Day-by-day projected balance", Query: []string{"starting_balance", "from", "to", "mode"}, Response: services.ForecastResult{}},
+
+	"ReportHandler.OwedToMe":        {Summary: "Shared bills others owe back", Query: []string{"month"}},
+	"ReportHandler.Allowances":      {Summary: "Allowance spending", Query: []string{"from", "to"}},
+	"ReportHandler.Contributions":   {Summary: "What each household member paid per month, by account", Query: []string{"from", "to"}, Response: handlers.ContributionReport{}},
+	"ReportHandler.Categories":      {Summary: "Per-category spending per month with month-over-month change (cached for a minute)", Query: []string{"from", "to"}, Response: handlers.CategoryTrendReport{}},
+	"ReportHandler.Disputes":        {Summary: "Outstanding disputes and the credits received for resolved ones", Query: []string{"from", "to"}, Response: handlers.DisputeReport{}},
+	"ReportHandler.PlannedVsActual": {Summary: "Planned vs actual per bill with the buffer that went unused", Query: []string{"from", "to"}, Response: handlers.PlannedVsActualReport{}},
+	"ReportHandler.Monthly":         {Summary: "Per-month income, bills, leftover and surplus paychecks for a year", Query: []string{"year"}, Response: handlers.MonthlyReport{}},
+	"ReportHandler.TaxDeductible":   {Summary: "Tax-deductible payments for a year", Query: []string{"format"}},
+	"CategoryBudgetHandler.Status":  {Summary: "Actual vs budget per category", Query: []string{"month"}, Response: []models.CategoryBudgetStatus{}},
+	"ObligationHandler.Report":      {Summary: "Monthly bill total over time and its rise within 90 days", Query: []string{"from", "to"}, Response: models.ObligationReport{}},
+
+	"CategoryHandler.List":     {Summary: "List categories", Response: []models.Category{}},
+	"CategoryHandler.Create":   {Summary: "Create a category", Body: models.CreateCategoryRequest{}, Response: models.Category{}, Status: http.StatusCreated},
+	"CategoryHandler.Spending": {Summary: "Planned and actual spending per category", Query: []string{"month"}, Response: []models.CategorySpending{}},
+	"CategoryHandler.Update":   {Summary: "Rename a category or change its limit", Body: models.UpdateCategoryRequest{}, Response: models.Category{}},
+	"CategoryHandler.Delete":   {Summary: "Delete a category"},
+
+	"CategoryBudgetHandler.List":   {Summary: "List category budgets", Response: []models.CategoryBudget{}},
+	"CategoryBudgetHandler.Set":    {Summary: "Set a category's monthly limit", Body: models.SetCategoryBudgetRequest{}, Response: models.CategoryBudget{}},
+	"CategoryBudgetHandler.Delete": {Summary: "Remove a category's monthly limit"},
+
+	"TransactionHandler.List":      {Summary: "List ledger transactions", Query: []string{"from", "to", "unreconciled"}, Response: []models.Transaction{}},
+	"TransactionHandler.Create":    {Summary: "Record a transaction", Body: models.CreateTransactionRequest{}, Response: models.Transaction{}, Status: http.StatusCreated},
+	"TransactionHandler.Reconcile": {Summary: "Match unreconciled transactions to pending assignments", Body: models.ReconcileRequest{}},
+	"TransactionHandler.Get":       {Summary: "Get a transaction", Response: models.Transaction{}},
+	"TransactionHandler.Update":    {Summary: "Update a transaction", Body: models.UpdateTransactionRequest{}, Response: models.Transaction{}},
+	"TransactionHandler.Delete":    {Summary: "Delete a transaction"},
+
+	"DocumentHandler.List":   {Summary: "List stored documents, soonest to expire first", Query: []string{"bill_id", "expiring_within"}, Response: []models.Document{}},
+	"DocumentHandler.Create": {Summary: "Upload a document such as a lease or insurance policy", Upload: []string{"title", "kind", "expires_on", "remind_days", "notes", "bill_ids"}, Response: models.Document{}, Status: http.StatusCreated},
+	"DocumentHandler.Get":    {Summary: "Get a document's details and bill tags", Response: models.Document{}},
+	"DocumentHandler.Update": {Summary: "Update a document's details, expiry or bill tags", Body: models.UpdateDocumentRequest{}, Response: models.Document{}},
+	"DocumentHandler.Delete": {Summary: "Delete a document"},
+	"DocumentHandler.File":   {Summary: "Download a document's file", Raw: "application/octet-stream"},
+
+	"ExportHandler.QIF":     {Summary: "Paid assignments as a QIF register", Query: []string{"from", "to"}, Raw: "application/qif"},
+	"ExportHandler.GnuCash": {Summary: "Paid assignments and paychecks as GnuCash CSV", Query: []string{"from", "to"}, Raw: "text/csv"},
+	"ExportHandler.PDF":     {Summary: "Printable budget sheet per pay period", Query: []string{"from", "to"}, Raw: "application/pdf"},
+	"ExportHandler.XLSX":    {Summary: "Budget workbook in the spreadsheet layout the importer reads", Query: []string{"from", "to"}, Raw: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+
+	"CalendarHandler.Token": {Summary: "Token and path for subscribing to the calendar feed"},
+	"CalendarHandler.Feed":  {Summary: "iCalendar feed of paydays and bill due dates", Query: []string{"token", "days"}, Raw: "text/calendar"},
+
+	"APIKeyHandler.WidgetKey": {Summary: "Issue a read:bills API key for the dashboard widgets", Response: models.CreatedAPIKey{}},
+	"WidgetHandler.Summary":   {Summary: "The week ahead for home dashboards: bills due, paid, overdue and the next payday", Response: handlers.WidgetSummary{}},
+	"WidgetHandler.NextBills": {Summary: "The next unpaid bills for home dashboards", Query: []string{"limit"}, Response: []handlers.WidgetBill{}},
+
+	"NotificationHandler.Preferences":       {Summary: "Email reminder settings", Response: models.NotificationPreferences{}},
+	"NotificationHandler.UpdatePreferences": {Summary: "Change email reminder settings", Body: models.UpdateNotificationPreferencesRequest{}, Response: models.NotificationPreferences{}},
+	"NotificationHandler.SendTest":          {Summary: "Email the reminder digest now"},
+
+	"WebhookHandler.List":       {Summary: "List webhooks", Response: []models.Webhook{}},
+	"WebhookHandler.Create":     {Summary: "Register a webhook", Body: models.CreateWebhookRequest{}, Response: models.Webhook{}, Status: http.StatusCreated},
+	"WebhookHandler.Get":        {Summary: "Get a webhook", Response: models.Webhook{}},
+	"WebhookHandler.Update":     {Summary: "Update a webhook", Body: models.UpdateWebhookRequest{}, Response: models.Webhook{}},
+	"WebhookHandler.Delete":     {Summary: "Delete a webhook and its delivery log"},
+	"WebhookHandler.Deliveries": {Summary: "A webhook's delivery log", Query: []string{"status"}, Paged: true, Response: []models.WebhookDelivery{}},
+	"WebhookHandler.Test":       {Summary: "Queue and send a test delivery", Response: models.WebhookDelivery{}, Status: http.StatusAccepted},
+
+	"AdminHandler.Stats":           {Summary: "Scheduled jobs, their lock holders and table counts", Response: handlers.AdminStats{}},
+	"AdminHandler.Duplicates":      {Summary: "Pending assignments duplicated within a bill and period, with proposed merges", Response: []models.DuplicateAssignmentGroup{}},
+	"AdminHandler.MergeDuplicates": {Summary: "Merge duplicate assignments into the kept one", Body: models.MergeDuplicatesRequest{}, Response: models.MergeDuplicatesResult{}},
+	"AdminHandler.Integrity":       {Summary: "Rows breaking data integrity checks, with how to fix each", Response: handlers.IntegrityReport{}},
+	"AdminHandler.Migrations":      {Summary: "Applied and pending migrations, with drift from the recorded checksums", Response: handlers.MigrationsReport{}},
+
+	"UserHandler.List":   {Summary: "List users and their roles", Response: []models.User{}},
+	"UserHandler.Create": {Summary: "Add a user with a role: viewer, editor or admin", Body: models.CreateUserRequest{}, Response: models.User{}, Status: http.StatusCreated},
+	"UserHandler.Update": {Summary: "Change a user's role or password", Body: models.UpdateUserRequest{}, Response: models.User{}},
+	"UserHandler.Delete": {Summary: "Delete a user"},
+
+	"APIKeyHandler.List":   {Summary: "API keys with their scopes, revoked ones included; the keys themselves are never shown", Response: []models.APIKey{}},
+	"APIKeyHandler.Create": {Summary: "Issue an API key with scopes read:bills, write:assignments and/or run:autoassign; the key is only shown here", Body: models.CreateAPIKeyRequest{}, Response: models.CreatedAPIKey{}},
+	"APIKeyHandler.Revoke": {Summary: "Revoke an API key"},
+
+	"AuditHandler.List":   {Summary: "Audit log of creates, updates and deletes on bills, assignments, periods and income sources, newest first", Query: []string{"entity", "entity_id", "action", "actor", "from", "to"}, Paged: true, Response: []models.AuditEntry{}},
+	"AuditHandler.Export": {Summary: "Download every matching audit entry, oldest first, as a JSON array or CSV (format=csv)", Query: []string{"entity", "entity_id", "action", "actor", "from", "to", "format"}, Response: []models.AuditEntry{}},
+
+	"FixtureHandler.List": {Summary: "Fixture sets for end-to-end tests (test mode only)", Response: []string{}},
+	"FixtureHandler.Load": {Summary: "Empty the database and seed it with a fixture set (test mode only)", Response: models.BackupRestoreResult{}},
+
+	"BackupHandler.Export":  {Summary: "Export every budget table as a JSON backup, or one household member's part", Query: []string{"member"}, Response: models.Backup{}},
+	"BackupHandler.Restore": {Summary: "Restore a backup; strategy merge (default) or replace", Query: []string{"strategy"}, Body: models.Backup{}, Response: models.BackupRestoreResult{}},
+	"ConfigHandler.Export":  {Summary: "Export bills and income sources as a template", Response: models.ConfigExport{}},
+	"ConfigHandler.Import":  {Summary: "Import a configuration export", Body: models.ConfigExport{}, Response: models.ConfigImportResult{}},
+
+	"TemplateHandler.Export": {Summary: "Export bills and pay schedules as an anonymized, hashed starter template without amounts", Query: []string{"name", "description"}, Response: models.BudgetTemplate{}},
+	"TemplateHandler.Import": {Summary: "Load a starter template whose hash matches its content", Body: models.BudgetTemplate{}, Response: models.TemplateImportResult{}},
+
+	"ScenarioHandler.List":    {Summary: "List what-if scenarios", Response: []models.Scenario{}},
+	"ScenarioHandler.Create":  {Summary: "Clone the bills, pay periods and assignments into a new what-if scenario", Body: models.CreateScenarioRequest{}, Response: models.Scenario{}, Status: http.StatusCreated},
+	"ScenarioHandler.Get":     {Summary: "Get a scenario and how many rows its copies hold", Response: models.Scenario{}},
+	"ScenarioHandler.Discard": {Summary: "Discard a scenario and its copies"},
+	"ScenarioHandler.Promote": {Summary: "Make a scenario's bills, periods and assignments the live budget", Query: []string{"force"}, Response: models.ScenarioPromoteResult{}},
+
+	"GET /api/v1/openapi.json":              {Summary: "This OpenAPI document"},
+	"GET /api/v2/openapi.json":              {Summary: "This OpenAPI document"},
+	"GET /api/v1/docs":                      {Summary: "Swagger UI for this API", Raw: "text/html"},
+	"GET /api/v1/docs/swagger-init.js":      {Summary: "Swagger UI start-up script", Raw: "text/javascript"},
+	"GET /api/v1/docs/swagger-ui.css":       {Summary: "Vendored Swagger UI stylesheet", Raw: "text/css"},
+	"GET /api/v1/docs/swagger-ui-bundle.js": {Summary: "Vendored Swagger UI script", Raw: "text/javascript"},
+}
+
+type sinkingFundRequest struct {
+	TargetPeriodID int `json:"target_period_id"`
+	NumPeriods     int `json:"num_periods"`
+}
+
+var csvUploadFields = []string{"date_column", "amount_column", "description_column", "date_format", "window_days", "dry_run", "format"}
+
+// errorCodes are the values of error.code across the API.
+var errorCodes = []string{
+	"INVALID_JSON", "VALIDATION_ERROR", "INVALID_ID", "INVALID_SCHEDULE", "BAD_REQUEST",
+	"NOT_FOUND", "CONFLICT", "UNAUTHORIZED", "NO_FILE", "NO_PREVIEW", "FILE_ERROR", "PARSE_ERROR",
+	"DETECTION_ERROR", "GENERATION_ERROR", "TOKEN_ERROR", "NOT_CONFIGURED", "SMTP_ERROR",
+	"HASH_MISMATCH", "RATE_LIMITED", "DB_ERROR", "SCAN_ERROR", "INTERNAL_ERROR",
+}
+
+// handlerName is "BillHandler.List" for a method value, or "" for closures.
+func handlerName(h http.Handler) string {
+	fn, ok := h.(http.HandlerFunc)
+	if !ok {
+		return ""
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	// e.g. github.com/.../handlers.(*BillHandler).List-fm
+	i := strings.Index(name, ".(*")
+	if i < 0 {
+		return ""
+	}
+	name = strings.TrimSuffix(name[i+3:], "-fm")
+	return strings.Replace(name, ").", ".", 1)
+}
+
+func lookupRouteDoc(method, path string, h http.Handler) (routeDoc, bool) {
+	if name := handlerName(h); name != "" {
+		if doc, ok := routeDocs[name]; ok {
+			return doc, true
+		}
+	}
+	doc, ok := routeDocs[method+" "+path]
+	return doc, ok
+}
+
+// hasMiddleware reports whether a route runs the middleware built by the
+// named function, e.g. "auth.RequireAuth".
+func hasMiddleware(middlewares []func(http.Handler) http.Handler, name string) bool {
+	for _, mw := range middlewares {
+		if strings.Contains(runtime.FuncForPC(reflect.ValueOf(mw).Pointer()).Name(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// buildOpenAPI describes every route mounted on routes.
+func buildOpenAPI(routes chi.Routes) (map[string]any, error) {
+	schemas := map[string]any{}
+	gen := &schemaGen{schemas: schemas}
+	paths := map[string]map[string]any{}
+
+	err := chi.Walk(routes, func(method, route string, h http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/*")
+		doc, _ := lookupRouteDoc(method, route, h)
+
+		op := map[string]any{
+			"summary":     doc.Summary,
+			"operationId": strings.ToLower(method) + operationSuffix(route),
+			"tags":        []string{routeTag(route)},
+		}
+		if hasMiddleware(middlewares, "router.deprecated") {
+			op["deprecated"] = true
+		}
+		if hasMiddleware(middlewares, "auth.RequireAuth") {
+			op["security"] = []map[string][]string{{"cookieAuth": {}}}
+		}
+
+		var params []map[string]any
+		for _, m := range pathParam.FindAllStringSubmatch(route, -1) {
+			typ := "string"
+			if m[1] == "id" || strings.HasSuffix(m[1], "_id") || m[1] == "year" {
+				typ = "integer"
+			}
+			params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": map[string]string{"type": typ}})
+		}
+		for _, q := range doc.Query {
+			params = append(params, map[string]any{"name": q, "in": "query", "schema": map[string]string{"type": "string"}})
+		}
+		if doc.Paged {
+			params = append(params,
+				map[string]any{"name": "limit", "in": "query", "schema": map[string]any{"type": "integer", "minimum": 1, "maximum": 500}},
+				map[string]any{"name": "offset", "in": "query", "schema": map[string]any{"type": "integer", "minimum": 0}},
+				map[string]any{"name": "sort", "in": "query", "description": "Comma-separated fields, - prefix for descending", "schema": map[string]string{"type": "string"}},
+			)
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		switch {
+		case doc.Body != nil:
+			op["requestBody"] = map[string]any{"required": true, "content": map[string]any{
+				"application/json": map[string]any{"schema": gen.schema(reflect.TypeOf(doc.Body))},
+			}}
+		case doc.Upload != nil:
+			props := map[string]any{"file": map[string]string{"type": "string", "format": "binary"}}
+			for _, f := range doc.Upload {
+				props[f] = map[string]string{"type": "string"}
+			}
+			op["requestBody"] = map[string]any{"required": true, "content": map[string]any{
+				"multipart/form-data": map[string]any{"schema": map[string]any{"type": "object", "properties": props, "required": []string{"file"}}},
+			}}
+		}
+
+		status := doc.Status
+		if status == 0 {
+			status = http.StatusOK
+			if method == http.MethodDelete {
+				status = http.StatusNoContent
+			}
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		switch {
+		case doc.Raw != "":
+			success["content"] = map[string]any{doc.Raw: map[string]any{"schema": map[string]string{"type": "string"}}}
+		case status != http.StatusNoContent:
+			data := map[string]any{}
+			if doc.Response != nil {
+				data = gen.schema(reflect.TypeOf(doc.Response))
+			}
+			success["content"] = map[string]any{"application/json": map[string]any{"schema": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"data": data, "meta": map[string]string{"$ref": "#/components/schemas/Meta"}},
+				"required":   []string{"data"},
+			}}}
+		}
+		op["responses"] = map[string]any{
+			strconv.Itoa(status): success,
+			"4XX":                map[string]string{"$ref": "#/components/responses/Error"},
+			"5XX":                map[string]string{"$ref": "#/components/responses/Error"},
+		}
+
+		path := pathParam.ReplaceAllString(route, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(method)] = op
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gen.schema(reflect.TypeOf(models.Meta{}))
+	gen.schema(reflect.TypeOf(models.APIError{}))
+	if detail, ok := schemas["ErrorDetail"].(map[string]any); ok {
+		props := detail["properties"].(map[string]any)
+		props["code"] = map[string]any{"type": "string", "enum": errorCodes}
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Budget Management API",
+			"version": "1",
+			"description": "Successful responses wrap their payload as {\"data\": ..., \"meta\": {\"timestamp\": ...}}; " +
+				"paged lists add total, limit and offset to meta. Errors are {\"error\": {\"code\", \"message\", \"details\"}}. " +
+				"/api/v1 is deprecated in favor of /api/v2, which is served by the same handlers but takes and returns " +
+				"amounts as integer cents where these schemas show decimal dollars.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error",
+					"content": map[string]any{"application/json": map[string]any{
+						"schema": map[string]string{"$ref": "#/components/schemas/APIError"},
+					}},
+				},
+			},
+			"securitySchemes": map[string]any{
+				"cookieAuth": map[string]string{"type": "apiKey", "in": "cookie", "name": auth.CookieName},
+			},
+		},
+	}, nil
+}
+
+// operationSuffix turns /api/v2/bills/{id}/skips into V2BillsIdSkips.
+func operationSuffix(route string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(route, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.' || r == '_'
+	}) {
+		if part == "api" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// routeTag groups operations by their first path segment after the version.
+func routeTag(route string) string {
+	parts := strings.Split(strings.Trim(route, "/"), "/")
+	if len(parts) >= 3 && parts[0] == "api" {
+		return strings.TrimSuffix(parts[2], ".ics")
+	}
+	return parts[0]
+}
+
+// schemaGen derives JSON Schemas from Go types by their json tags. Named
+// structs become components referenced by name.
+type schemaGen struct {
+	schemas map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schema(t.Elem())
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+			return s
+		}
+		return map[string]any{"anyOf": []any{s, map[string]string{"type": "null"}}}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = map[string]any{} // placeholder for recursive types
+			g.schemas[t.Name()] = g.object(t)
+		}
+		return ref
+	}
+	return map[string]any{}
+}
+
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	g.fields(t, props, &required)
+	obj := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		obj["required"] = required
+	}
+	return obj
+}
+
+// fields adds t's JSON fields to props, flattening embedded structs.
+func (g *schemaGen) fields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// openAPIHandler serves the spec for routes, built on first request so that
+// every route has been mounted by then.
+func openAPIHandler(routes chi.Routes) http.HandlerFunc {
+	var once sync.Once
+	var spec []byte
+	var specErr error
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var doc map[string]any
+			doc, specErr = buildOpenAPI(routes)
+			if specErr == nil {
+				spec, specErr = json.Marshal(doc)
+			}
+		})
+		if specErr != nil {
+			models.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", specErr.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
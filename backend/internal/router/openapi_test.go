@@ -0,0 +1,111 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
+)
+
+// newTestRouter builds the full router without a database; nothing queries
+// until a request reaches a handler.
+func newTestRouter(t *testing.T) http.Handler {
+	runner := jobs.NewRunner()
+	t.Cleanup(func() { runner.Shutdown(context.Background()) })
+	return New(nil, nil, &config.Config{MetricsEnabled: true, TestFixturesEnabled: true}, runner, storage.NewMemory())
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	routes := newTestRouter(t).(chi.Routes)
+	err := chi.Walk(routes, func(method, route string, h http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if _, ok := lookupRouteDoc(method, route, h); !ok {
+			t.Errorf("%s %s (%s) has no entry in routeDocs", method, route, handlerName(h))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAPI_ServesSpec(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestRouter(t).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+		Comp    struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", spec.OpenAPI)
+	}
+
+	var op struct {
+		Deprecated  bool `json:"deprecated"`
+		Security    []map[string][]string
+		Parameters  []struct{ Name, In string }
+		RequestBody struct {
+			Content map[string]struct {
+				Schema map[string]string `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+	}
+	raw, ok := spec.Paths["/api/v2/bills/{id}"]["patch"]
+	if !ok {
+		t.Fatal("PATCH /api/v2/bills/{id} is missing")
+	}
+	json.Unmarshal(raw, &op)
+	if op.Deprecated || len(op.Security) != 1 {
+		t.Errorf("v2 operation: deprecated=%v security=%v", op.Deprecated, op.Security)
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" {
+		t.Errorf("parameters = %+v", op.Parameters)
+	}
+	if ref := op.RequestBody.Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/UpdateBillRequest" {
+		t.Errorf("request body schema = %q", ref)
+	}
+
+	var v1 struct{ Deprecated bool }
+	json.Unmarshal(spec.Paths["/api/v1/bills/{id}"]["put"], &v1)
+	if !v1.Deprecated {
+		t.Error("v1 operations should be marked deprecated")
+	}
+	for _, name := range []string{"Bill", "UpdateBillRequest", "Meta", "APIError"} {
+		if _, ok := spec.Comp.Schemas[name]; !ok {
+			t.Errorf("schema %s is missing", name)
+		}
+	}
+	if !strings.Contains(string(spec.Comp.Schemas["ErrorDetail"]), "VALIDATION_ERROR") {
+		t.Error("error codes are not listed")
+	}
+}
+
+func TestDocs_ServesSwaggerUI(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newTestRouter(t).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "swagger-ui") {
+		t.Fatalf("expected the Swagger UI page, got %d", rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "https://") {
+		t.Error("the docs page loads assets from another origin")
+	}
+	if csp := rr.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self';") || strings.Contains(csp, "https://") {
+		t.Errorf("CSP = %q", csp)
+	}
+}
@@ -0,0 +1,156 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// rateLimitSweepInterval is how often buckets that have refilled are dropped,
+// so clients seen once don't hold memory forever.
+const rateLimitSweepInterval = time.Minute
+
+// rateLimiter is a token bucket per client: each holds up to burst requests
+// and refills at perMinute. Signed-in users get a bucket of their own,
+// everyone else one per IP address.
+type rateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns nil, which limits nothing, when perMinute is not
+// positive. A burst below 1 is raised to 1.
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from key's bucket, or reports how long until one is
+// available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= rateLimitSweepInterval {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := (1 - b.tokens) / l.rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// Handler rejects requests over the limit with 429 and a Retry-After header
+// in whole seconds. A nil limiter passes everything through.
+func (l *rateLimiter) Handler(next http.Handler) http.Handler {
+	return l.handlerBy(rateLimitKey)(next)
+}
+
+// LoginHandler also limits attempts per username, so guessing one account's
+// password from many addresses is held to the login rate too.
+func (l *rateLimiter) LoginHandler(next http.Handler) http.Handler {
+	return l.Handler(l.handlerBy(loginUsernameKey)(next))
+}
+
+// handlerBy limits requests by the bucket key returns; an empty key is not
+// limited.
+func (l *rateLimiter) handlerBy(key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if k := key(r); k != "" {
+				if ok, wait := l.allow(k); !ok {
+					seconds := int(math.Ceil(wait.Seconds()))
+					w.Header().Set("Retry-After", strconv.Itoa(seconds))
+					models.WriteError(w, http.StatusTooManyRequests, "RATE_LIMITED",
+						fmt.Sprintf("too many requests; try again in %d seconds", seconds))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey is the signed-in username, or the client IP when there is no
+// session yet. RemoteAddr is the socket address unless realIP took the
+// client's from a trusted proxy.
+func rateLimitKey(r *http.Request) string {
+	if username := auth.Username(r.Context()); username != "" {
+		return "user:" + username
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// maxLoginBody bounds how much of a login body is read for its username.
+const maxLoginBody = 64 << 10
+
+// loginUsernameKey is the username a login attempt is for, read from the
+// JSON body, which is put back for the handler.
+func loginUsernameKey(r *http.Request) string {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLoginBody))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return ""
+	}
+	var req struct {
+		Username string `json:"username"`
+	}
+	if json.Unmarshal(body, &req) != nil || strings.TrimSpace(req.Username) == "" {
+		return ""
+	}
+	return "login:" + strings.ToLower(strings.TrimSpace(req.Username))
+}
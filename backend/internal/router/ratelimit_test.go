@@ -0,0 +1,203 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
+)
+
+func TestRateLimiter_RefillsOverTime(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(60, 2) // one token a second
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("ip:1.2.3.4"); !ok {
+			t.Fatalf("request %d within the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.allow("ip:1.2.3.4")
+	if ok || wait != time.Second {
+		t.Errorf("expected a refusal with a 1s wait, got ok=%v wait=%v", ok, wait)
+	}
+	if ok, _ := l.allow("ip:5.6.7.8"); !ok {
+		t.Error("another client should have its own bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := l.allow("ip:1.2.3.4"); !ok {
+		t.Error("expected a token after refilling for a second")
+	}
+}
+
+func TestRateLimiter_SweepsFullBuckets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(60, 5)
+	l.now = func() time.Time { return now }
+	l.allow("ip:1.2.3.4")
+
+	now = now.Add(2 * rateLimitSweepInterval)
+	l.allow("ip:5.6.7.8")
+	if _, ok := l.buckets["ip:1.2.3.4"]; ok {
+		t.Error("expected the idle bucket to be dropped")
+	}
+}
+
+func TestRateLimiter_DisabledPassesThrough(t *testing.T) {
+	if l := newRateLimiter(0, 10); l != nil {
+		t.Fatalf("expected no limiter for a zero rate, got %+v", l)
+	}
+	var l *rateLimiter
+	called := false
+	l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !called {
+		t.Error("a nil limiter should call the next handler")
+	}
+}
+
+func TestRateLimitKey_PrefersUser(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	if got := rateLimitKey(req); got != "ip:10.0.0.1" {
+		t.Errorf("expected the IP key, got %q", got)
+	}
+	req = req.WithContext(auth.WithSession(req.Context(), auth.Session{Username: "sam", Role: auth.RoleViewer}))
+	if got := rateLimitKey(req); got != "user:sam" {
+		t.Errorf("expected the user key, got %q", got)
+	}
+}
+
+// The first attempt fails on its body before touching the database; the
+// second never reaches the handler.
+func TestLoginRateLimit_Returns429(t *testing.T) {
+	cfg := &config.Config{LoginRateLimitPerMinute: 1, LoginRateLimitBurst: 1}
+	runner := jobs.NewRunner()
+	t.Cleanup(func() { runner.Shutdown(context.Background()) })
+	h := New(nil, nil, cfg, runner, storage.NewMemory())
+
+	login := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader("not json"))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := login(); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected the first attempt to reach the handler, got %d", rr.Code)
+	}
+
+	rr := login()
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+	var resp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp.Error.Code != "RATE_LIMITED" {
+		t.Errorf("expected a RATE_LIMITED error envelope, got %s", rr.Body.String())
+	}
+}
+
+func TestRealIP_TrustsOnlyConfiguredProxies(t *testing.T) {
+	var got string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r.RemoteAddr })
+	tests := []struct {
+		name    string
+		trusted []string
+		remote  string
+		xff     string
+		realIP  string
+		want    string
+	}{
+		{"no proxies trusted", nil, "203.0.113.9:4000", "1.1.1.1", "", "203.0.113.9:4000"},
+		{"untrusted peer", []string{"10.0.0.0/8"}, "203.0.113.9:4000", "1.1.1.1", "2.2.2.2", "203.0.113.9:4000"},
+		{"trusted peer", []string{"10.0.0.0/8"}, "10.0.0.2:4000", "198.51.100.7", "", "198.51.100.7"},
+		// Only the hop our proxy appended counts; the client wrote the rest
+		{"spoofed leftmost hop", []string{"10.0.0.2"}, "10.0.0.2:4000", "1.1.1.1, 198.51.100.7", "", "198.51.100.7"},
+		{"proxy chain", []string{"10.0.0.0/8"}, "10.0.0.2:4000", "198.51.100.7, 10.0.0.3", "", "198.51.100.7"},
+		{"x-real-ip", []string{"10.0.0.2"}, "10.0.0.2:4000", "", "198.51.100.7", "198.51.100.7"},
+		{"invalid entry skipped", []string{"proxy.local", "10.0.0.2"}, "10.0.0.2:4000", "198.51.100.7", "", "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			realIP(parseTrustedProxies(tt.trusted))(echo).ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// loginAttempt posts a login for username from the socket address remote,
+// claiming to be forwardedFor.
+func loginAttempt(h http.Handler, remote, forwardedFor, username string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login",
+		strings.NewReader(`{"username": "`+username+`", "password": "guess"}`))
+	req.RemoteAddr = remote
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// A Turnstile secret with no token in the body rejects each attempt that
+// gets through before it reaches the database.
+func TestLoginRateLimit_SpoofedHeaderKeepsBucket(t *testing.T) {
+	cfg := &config.Config{LoginRateLimitPerMinute: 1, LoginRateLimitBurst: 1, TurnstileSecretKey: "secret"}
+	runner := jobs.NewRunner()
+	t.Cleanup(func() { runner.Shutdown(context.Background()) })
+	h := New(nil, nil, cfg, runner, storage.NewMemory())
+
+	if rr := loginAttempt(h, "203.0.113.9:4000", "1.1.1.1", "sam"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected the first attempt to reach the handler, got %d", rr.Code)
+	}
+	if rr := loginAttempt(h, "203.0.113.9:4001", "2.2.2.2", "alex"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("a new X-Forwarded-For should not buy a new bucket, got %d", rr.Code)
+	}
+}
+
+func TestLoginRateLimit_PerUsername(t *testing.T) {
+	cfg := &config.Config{
+		LoginRateLimitPerMinute: 1, LoginRateLimitBurst: 1, TurnstileSecretKey: "secret",
+		TrustedProxies: []string{"10.0.0.2"},
+	}
+	runner := jobs.NewRunner()
+	t.Cleanup(func() { runner.Shutdown(context.Background()) })
+	h := New(nil, nil, cfg, runner, storage.NewMemory())
+
+	// Through the trusted proxy each client has its own address bucket
+	if rr := loginAttempt(h, "10.0.0.2:4000", "198.51.100.7", "sam"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected the first attempt to reach the handler, got %d", rr.Code)
+	}
+	if rr := loginAttempt(h, "10.0.0.2:4000", "198.51.100.8", "alex"); rr.Code != http.StatusBadRequest {
+		t.Errorf("another client and username should not be limited, got %d", rr.Code)
+	}
+	// but the same account from a third address is
+	if rr := loginAttempt(h, "10.0.0.2:4000", "198.51.100.9", "SAM"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the username's bucket to be spent, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package router
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseTrustedProxies reads proxy addresses and CIDR ranges, skipping (and
+// logging) entries that are neither.
+func parseTrustedProxies(entries []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(e)
+			if ip == nil {
+				slog.Warn("ignoring invalid trusted proxy", "entry", e)
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			slog.Warn("ignoring invalid trusted proxy", "entry", e)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// realIP replaces RemoteAddr with the client address a trusted reverse proxy
+// forwarded. X-Forwarded-For and X-Real-IP are only believed on connections
+// from one of trusted; from anyone else they would let a client pick its
+// own address, and with it a fresh rate-limit bucket. With no trusted
+// proxies RemoteAddr is left as the socket address.
+func realIP(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	isTrusted := func(ip net.IP) bool {
+		for _, n := range trusted {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			if peer := net.ParseIP(host); peer != nil && isTrusted(peer) {
+				if client := forwardedClient(r, isTrusted); client != "" {
+					r.RemoteAddr = client
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient is the nearest untrusted hop in X-Forwarded-For, read from
+// the right since only the entries our own proxies appended can be relied
+// on, or else X-Real-IP.
+func forwardedClient(r *http.Request, isTrusted func(net.IP) bool) string {
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	first := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		first = ip.String()
+		if !isTrusted(ip) {
+			return first
+		}
+	}
+	if first != "" {
+		return first
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
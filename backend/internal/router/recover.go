@@ -0,0 +1,46 @@
+package router
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// recoverer turns a handler panic into the standard error envelope (500,
+// INTERNAL_ERROR, with the request ID in details) and logs it with the stack
+// trace, so clients get a response instead of a dropped connection.
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// net/http uses this panic to abort a response on purpose
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			reqID := middleware.GetReqID(r.Context())
+			slog.Error("panic serving request",
+				"panic", rec,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"request_id", reqID,
+				"stack", string(debug.Stack()),
+			)
+
+			// Too late for an error body once the handler has started responding
+			if ww.Status() != 0 {
+				return
+			}
+			models.WriteErrorDetails(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error",
+				map[string]string{"request_id": reqID})
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
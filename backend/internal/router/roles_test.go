@@ -0,0 +1,131 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
+)
+
+// Every request here is turned away before it reaches a handler, so the
+// router needs no database.
+func TestRoles_Enforced(t *testing.T) {
+	cfg := &config.Config{AuthUsername: "admin", AuthPasswordHash: "hash", JWTSecret: "secret"}
+	runner := jobs.NewRunner()
+	t.Cleanup(func() { runner.Shutdown(context.Background()) })
+	h := New(nil, nil, cfg, runner, storage.NewMemory())
+
+	for _, tc := range []struct {
+		role         auth.Role
+		method, path string
+	}{
+		{auth.RoleViewer, http.MethodPost, "/api/v2/bills"},
+		{auth.RoleViewer, http.MethodPatch, "/api/v2/assignments/1/status"},
+		{auth.RoleViewer, http.MethodDelete, "/api/v1/bills/1"},
+		{auth.RoleViewer, http.MethodGet, "/api/v2/users"},
+		{auth.RoleEditor, http.MethodGet, "/api/v2/admin/migrations"},
+		{auth.RoleEditor, http.MethodPost, "/api/v2/imports/xlsx"},
+		{auth.RoleEditor, http.MethodGet, "/api/v1/import/history"},
+		{auth.RoleEditor, http.MethodPost, "/api/v1/users"},
+		{auth.RoleEditor, http.MethodGet, "/api/v1/export"},
+	} {
+		token, _, err := auth.CreateToken(cfg.JWTSecret, auth.Session{Username: "someone", Role: tc.role}, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("%s %s %s: expected 403, got %d", tc.role, tc.method, tc.path, rr.Code)
+		}
+	}
+}
+
+func TestRequireAuth_RejectsRevokedSession(t *testing.T) {
+	revoked := map[int]bool{2: true}
+	check := func(_ context.Context, id int) (bool, error) { return revoked[id], nil }
+	h := auth.RequireAuth("secret", true, check, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for id, want := range map[int]int{1: http.StatusOK, 2: http.StatusUnauthorized} {
+		token, _, err := auth.CreateToken("secret", auth.Session{ID: id, Username: "me", Role: auth.RoleAdmin}, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/bills", nil)
+		req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != want {
+			t.Errorf("session %d: expected %d, got %d", id, want, rr.Code)
+		}
+	}
+}
+
+func TestRequireScope_KeysReachOnlyTheirScopes(t *testing.T) {
+	keys := map[string]auth.Session{
+		"bmk_widget": auth.KeySession(1, "widget", []auth.Scope{auth.ScopeReadBills}),
+		"bmk_script": auth.KeySession(2, "script", []auth.Scope{auth.ScopeReadBills, auth.ScopeWriteAssignments}),
+	}
+	lookup := func(_ context.Context, key string) (auth.Session, bool, error) {
+		s, ok := keys[key]
+		return s, ok, nil
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	// Laid out like the API routes: scoped groups, then login-only routes
+	r := chi.NewRouter()
+	r.Use(auth.RequireAuth("secret", true, nil, lookup))
+	r.Use(auth.RequireRoleForWrites(auth.RoleEditor))
+	r.With(auth.RequireScope(auth.ScopeReadBills)).Get("/bills/{id}", ok)
+	r.With(auth.RequireScope(auth.ScopeWriteAssignments)).Delete("/assignments/{id}", ok)
+	r.With(auth.RequireScope(auth.ScopeRunAutoAssign)).Post("/assignments/auto-assign", ok)
+	login := r.With(auth.RejectAPIKeys)
+	login.Delete("/bills/{id}", ok)
+	login.Get("/documents", ok)
+
+	for _, tc := range []struct {
+		key, method, path string
+		want              int
+	}{
+		{"bmk_widget", http.MethodGet, "/bills/1", http.StatusOK},
+		{"bmk_widget", http.MethodDelete, "/assignments/1", http.StatusForbidden},
+		{"bmk_widget", http.MethodDelete, "/bills/1", http.StatusForbidden},
+		{"bmk_widget", http.MethodGet, "/documents", http.StatusForbidden},
+		{"bmk_script", http.MethodDelete, "/assignments/1", http.StatusOK},
+		{"bmk_script", http.MethodPost, "/assignments/auto-assign", http.StatusForbidden},
+		{"bmk_revoked", http.MethodGet, "/bills/1", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set(auth.APIKeyHeader, tc.key)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != tc.want {
+			t.Errorf("%s %s %s: expected %d, got %d", tc.key, tc.method, tc.path, tc.want, rr.Code)
+		}
+	}
+
+	// A login session isn't limited by scope
+	token, _, err := auth.CreateToken("secret", auth.Session{Username: "me", Role: auth.RoleEditor}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/assignments/auto-assign", nil)
+	req.AddCookie(&http.Cookie{Name: auth.CookieName, Value: token})
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("login session: expected 200, got %d", rr.Code)
+	}
+}
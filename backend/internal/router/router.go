@@ -1,7 +1,10 @@
 package router
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -9,17 +12,37 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/izz-linux/budget-mgmt/backend/internal/auth"
 	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+	"github.com/izz-linux/budget-mgmt/backend/internal/dbmetrics"
+	"github.com/izz-linux/budget-mgmt/backend/internal/events"
 	"github.com/izz-linux/budget-mgmt/backend/internal/handlers"
+	"github.com/izz-linux/budget-mgmt/backend/internal/jobs"
+	"github.com/izz-linux/budget-mgmt/backend/internal/services"
+	"github.com/izz-linux/budget-mgmt/backend/internal/storage"
 )
 
-func New(db *pgxpool.Pool, cfg *config.Config) http.Handler {
+// New builds the HTTP handler. Background work is started on runner so main
+// can drain it at shutdown; pending import uploads are kept in uploads.
+// replica, when not nil, serves the read-only reporting handlers.
+func New(pool, replica *pgxpool.Pool, cfg *config.Config, runner *jobs.Runner, uploads storage.Store) http.Handler {
 	r := chi.NewRouter()
 
+	// Every handler queries through the instrumented pool
+	queryMetrics := dbmetrics.NewRecorder(time.Duration(cfg.SlowQueryMS) * time.Millisecond)
+	db := dbmetrics.Wrap(pool, queryMetrics)
+
+	// Reports, forecasts and dashboards only read, and can stand to lag the
+	// primary by a moment, so they go to the replica when there is one
+	readDB := db
+	if replica != nil {
+		readDB = dbmetrics.Wrap(replica, queryMetrics)
+	}
+
 	// Middleware
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(realIP(parseTrustedProxies(cfg.TrustedProxies)))
 	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(recoverer)
+	r.Use(securityHeaders(cfg))
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:*", "http://127.0.0.1:*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
@@ -34,10 +57,41 @@ func New(db *pgxpool.Pool, cfg *config.Config) http.Handler {
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 
+	// API description and Swagger UI (public)
+	spec := openAPIHandler(r)
+	r.Get("/api/v1/openapi.json", spec)
+	r.Get("/api/v2/openapi.json", spec)
+	r.Get("/api/v1/docs", serveDocsFile("static/swagger.html", "text/html; charset=utf-8"))
+	r.Get("/api/v1/docs/swagger-init.js", serveDocsFile("static/swagger-init.js", "text/javascript; charset=utf-8"))
+	r.Get("/api/v1/docs/swagger-ui.css", serveDocsFile("static/swagger-ui/swagger-ui.css", "text/css; charset=utf-8"))
+	r.Get("/api/v1/docs/swagger-ui-bundle.js", serveDocsFile("static/swagger-ui/swagger-ui-bundle.js", "text/javascript; charset=utf-8"))
+
+	if cfg.MetricsEnabled {
+		r.Get("/metrics", queryMetrics.Handler())
+	}
+
+	// Database reset and seeding for end-to-end suites (public, test mode only)
+	if cfg.TestFixturesEnabled {
+		slog.Warn("test fixture endpoints enabled; any client can wipe the database")
+		fixtureH := handlers.NewFixtureHandler(db)
+		r.Get("/api/v1/test/fixtures", fixtureH.List)
+		r.Post("/api/v1/test/fixtures/{name}", fixtureH.Load)
+		r.Get("/api/v2/test/fixtures", fixtureH.List)
+		r.Post("/api/v2/test/fixtures/{name}", fixtureH.Load)
+	}
+
+	// Rate limits, per user once signed in and per IP before; the API limit
+	// is shared by v1 and v2, and login attempts are also limited per
+	// username
+	apiLimit := newRateLimiter(cfg.RateLimitPerMinute, cfg.RateLimitBurst)
+	loginLimit := newRateLimiter(cfg.LoginRateLimitPerMinute, cfg.LoginRateLimitBurst)
+	importLimit := newRateLimiter(cfg.ImportRateLimitPerMinute, cfg.ImportRateLimitBurst)
+
 	// Auth routes (public)
-	authH := handlers.NewAuthHandler(cfg)
+	authH := handlers.NewAuthHandler(db, cfg)
 	r.Route("/api/v1/auth", func(r chi.Router) {
-		r.Post("/login", authH.Login)
+		r.With(loginLimit.LoginHandler).Post("/login", authH.Login)
+		r.Post("/refresh", authH.Refresh)
 		r.Post("/logout", authH.Logout)
 		r.Get("/status", authH.Status)
 	})
@@ -45,67 +99,605 @@ func New(db *pgxpool.Pool, cfg *config.Config) http.Handler {
 	// Handlers
 	billH := handlers.NewBillHandler(db)
 	incomeH := handlers.NewIncomeHandler(db)
-	periodH := handlers.NewPeriodHandler(db)
-	assignH := handlers.NewAssignmentHandler(db)
+	bus := events.NewBus()
+	periodH := handlers.NewPeriodHandler(db).WithEvents(bus)
+	assignH := handlers.NewAssignmentHandler(db).WithEvents(bus)
 	gridH := handlers.NewGridHandler(db)
-	importH := handlers.NewImportHandler(db)
+	importH := handlers.NewImportHandler(db).WithStorage(uploads)
+	importH.StartSweeper(runner, time.Duration(cfg.ImportSessionTTLMinutes)*time.Minute, time.Minute)
+	importH.StartJobs(runner, time.Minute)
 	optimizerH := handlers.NewOptimizerHandler(db)
-	dashboardH := handlers.NewDashboardHandler(db)
+	dashboardH := handlers.NewDashboardHandler(readDB)
+	forecastH := handlers.NewForecastHandler(readDB).WithEvents(bus)
 	sinkingFundH := handlers.NewSinkingFundHandler(db)
+	creditCardH := handlers.NewCreditCardHandler(db)
+	incomeEventH := handlers.NewIncomeEventHandler(db)
+	reportH := handlers.NewReportHandler(readDB)
+	checklistH := handlers.NewChecklistHandler(db)
+	sweepH := handlers.NewSweepHandler(db)
+	configH := handlers.NewConfigHandler(db)
+	templateH := handlers.NewTemplateHandler(db)
+	scenarioH := handlers.NewScenarioHandler(db)
+	exportH := handlers.NewExportHandler(readDB)
+	backupH := handlers.NewBackupHandler(db)
+	transactionH := handlers.NewTransactionHandler(db)
+	categoryH := handlers.NewCategoryHandler(db)
+	documentH := handlers.NewDocumentHandler(db)
+	var mailer services.Mailer
+	if cfg.SMTPEnabled() {
+		mailer = services.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	notificationH := handlers.NewNotificationHandler(db, mailer)
+	notificationH.StartScheduler(runner, 5*time.Minute)
+	categoryBudgetH := handlers.NewCategoryBudgetHandler(db).WithEvents(bus)
+	bus.Subscribe(func(e events.Event) {
+		if e.Type == events.AssignmentPaid || e.Type == events.AssignmentStatusChanged {
+			reportH.ClearCache()
+		}
+	})
+	bus.Subscribe(func(e events.Event) {
+		if e.Type != events.AssignmentPaid {
+			return
+		}
+		// Subscribers must not block the paying request
+		runner.Go("category-budget-alerts", func(ctx context.Context) {
+			if err := categoryBudgetH.CheckAlerts(ctx, time.Now()); err != nil {
+				slog.Error("checking category budget alerts", "error", err)
+			}
+		})
+	})
+	obligationH := handlers.NewObligationHandler(db, float64(cfg.ObligationAlertPercent)).WithEvents(bus)
+	obligationH.StartScheduler(runner, time.Hour)
+	webhookH := handlers.NewWebhookHandler(db)
+	webhookH.StartDelivery(runner, 30*time.Second)
+	bus.Subscribe(func(e events.Event) {
+		runner.Go("webhook-dispatch", func(ctx context.Context) {
+			if err := webhookH.Dispatch(ctx, e); err != nil {
+				slog.Error("dispatching webhooks", "event", e.Type, "error", err)
+			}
+		})
+	})
+
+	adminH := handlers.NewAdminHandler(db, runner, cfg.InstanceID)
+	userH := handlers.NewUserHandler(db, cfg)
+	auditH := handlers.NewAuditHandler(db)
+	apiKeyH := handlers.NewAPIKeyHandler(db, cfg)
+
+	// Calendar feed (public; checks its own signed token when auth is enabled)
+	calendarH := handlers.NewCalendarHandler(db, cfg)
+	r.With(apiLimit.Handler).Get("/api/v1/calendar.ics", calendarH.Feed)
+	r.With(apiLimit.Handler).Get("/api/v2/calendar.ics", calendarH.Feed)
+
+	// Dashboard widgets (public; check for a read:bills API key when auth is enabled)
+	widgetH := handlers.NewWidgetHandler(db, readDB, cfg)
+	r.With(apiLimit.Handler).Get("/api/v1/widgets/summary", widgetH.Summary)
+	r.With(apiLimit.Handler).Get("/api/v1/widgets/next-bills", widgetH.NextBills)
+	r.With(apiLimit.Handler, centAmounts).Get("/api/v2/widgets/summary", widgetH.Summary)
+	r.With(apiLimit.Handler, centAmounts).Get("/api/v2/widgets/next-bills", widgetH.NextBills)
 
 	r.Route("/api/v1", func(r chi.Router) {
 		// Protect data routes with auth middleware
-		r.Use(auth.RequireAuth(cfg.JWTSecret, cfg.AuthEnabled()))
+		r.Use(auth.RequireAuth(cfg.JWTSecret, cfg.AuthEnabled(), authH.SessionRevoked, apiKeyH.Lookup))
+		// Viewers only read; admin-only routes are grouped below
+		r.Use(auth.RequireRoleForWrites(auth.RoleEditor))
+		r.Use(apiLimit.Handler)
+		r.Use(newCompressor().Handler)
+		r.Use(deprecated("/api/v2"))
+
+		// API keys reach only the routes grouped under one of their scopes
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(auth.ScopeReadBills))
+			r.Get("/bills", billH.List)
+			r.Get("/bills/{id}", billH.Get)
+			r.Get("/pay-periods", periodH.List)
+			r.Get("/assignments", assignH.List)
+			r.Get("/assignments/due-soon", assignH.DueSoon)
+			r.Get("/budget-grid", gridH.GetGrid)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(auth.ScopeWriteAssignments))
+			r.Post("/assignments", assignH.Create)
+			r.Put("/assignments/{id}", assignH.Update)
+			r.Patch("/assignments/{id}/status", assignH.UpdateStatus)
+			r.Post("/assignments/{id}/pay", assignH.Pay)
+			r.Delete("/assignments/{id}", assignH.Delete)
+		})
+		r.With(auth.RequireScope(auth.ScopeRunAutoAssign)).Post("/assignments/auto-assign", assignH.AutoAssign)
+
+		// Everything from here on is for login sessions only
+		r = r.With(auth.RejectAPIKeys)
 
 		// Bills
-		r.Get("/bills", billH.List)
 		r.Post("/bills", billH.Create)
-		r.Get("/bills/{id}", billH.Get)
 		r.Put("/bills/{id}", billH.Update)
 		r.Delete("/bills/{id}", billH.Delete)
 		r.Patch("/bills/reorder", billH.Reorder)
+		r.Post("/bills/{id}/skip", billH.Skip)
+		r.Delete("/bills/{id}/skip", billH.Unskip)
+		r.Get("/bills/{id}/skips", billH.Skips)
+		r.Get("/bills/{id}/promos", billH.Promos)
+		r.Post("/bills/{id}/promos", billH.AddPromo)
+		r.Delete("/bills/{id}/promos/{promo_id}", billH.DeletePromo)
+		r.Get("/bills/{id}/payoff", billH.Payoff)
 
 		// Sinking fund
 		r.Post("/bills/{id}/sinking-fund/plan", sinkingFundH.Plan)
 		r.Post("/bills/{id}/sinking-fund/apply", sinkingFundH.Apply)
 		r.Delete("/bills/{id}/sinking-fund", sinkingFundH.Clear)
 
+		// Credit cards
+		r.Get("/credit-cards", creditCardH.List)
+		r.Post("/credit-cards", creditCardH.Create)
+		r.Get("/credit-cards/{id}", creditCardH.Get)
+		r.Put("/credit-cards/{id}", creditCardH.Update)
+		r.Delete("/credit-cards/{id}", creditCardH.Delete)
+		r.Put("/credit-cards/{id}/bill", creditCardH.Link)
+		r.Delete("/credit-cards/{id}/bill", creditCardH.Unlink)
+
+		// Credit card statement cycles
+		r.Get("/credit-cards/{id}/forecast", creditCardH.Forecast)
+		r.Post("/credit-cards/{id}/assign", creditCardH.Assign)
+
 		// Income sources
 		r.Get("/income-sources", incomeH.List)
 		r.Post("/income-sources", incomeH.Create)
+		r.Get("/income-sources/templates", incomeH.Templates)
 		r.Get("/income-sources/{id}", incomeH.Get)
 		r.Put("/income-sources/{id}", incomeH.Update)
 		r.Delete("/income-sources/{id}", incomeH.Delete)
+		r.Post("/income-sources/{id}/duplicate", incomeH.Duplicate)
+		r.Get("/income-sources/{id}/summary", incomeH.Summary)
+		r.Get("/income-sources/{id}/payroll-calendars", incomeH.PayrollCalendars)
+		r.Put("/income-sources/{id}/payroll-calendars/{year}", incomeH.SetPayrollCalendar)
+		r.Delete("/income-sources/{id}/payroll-calendars/{year}", incomeH.DeletePayrollCalendar)
+
+		// Income events
+		r.Get("/income-events", incomeEventH.List)
+		r.Post("/income-events", incomeEventH.Create)
+		r.Get("/income-events/{id}", incomeEventH.Get)
+		r.Put("/income-events/{id}", incomeEventH.Update)
+		r.Delete("/income-events/{id}", incomeEventH.Delete)
 
 		// Pay periods
-		r.Get("/pay-periods", periodH.List)
+		r.Get("/pay-periods/risk", periodH.Risk)
 		r.Post("/pay-periods/generate", periodH.Generate)
+		r.Post("/pay-periods/generate/preview", periodH.Preview)
+		r.Patch("/pay-periods/bulk", periodH.BulkUpdate)
 		r.Put("/pay-periods/{id}", periodH.Update)
+		r.Post("/pay-periods/{id}/copy-from/{other_id}", periodH.CopyFrom)
+
+		// Pay period checklist
+		r.Get("/pay-periods/{id}/checklist", checklistH.List)
+		r.Post("/pay-periods/{id}/checklist", checklistH.Create)
+		r.Put("/checklist-items/{id}", checklistH.Update)
+		r.Delete("/checklist-items/{id}", checklistH.Delete)
+
+		// Savings sweeps
+		r.Get("/sweeps", sweepH.Suggestions)
+		r.Post("/sweeps", sweepH.Create)
+
+		// Shareable starter budgets
+		r.Get("/templates/export", templateH.Export)
+		r.Post("/templates/import", templateH.Import)
+
+		// What-if scenarios: the sandbox routes work on a scenario's copies
+		r.Get("/scenarios", scenarioH.List)
+		r.Post("/scenarios", scenarioH.Create)
+		r.Get("/scenarios/{scenario_id}", scenarioH.Get)
+		r.Delete("/scenarios/{scenario_id}", scenarioH.Discard)
+		r.Post("/scenarios/{scenario_id}/promote", scenarioH.Promote)
+		r.Group(func(r chi.Router) {
+			r.Use(scenarioH.Sandbox)
+			r.Get("/scenarios/{scenario_id}/bills", billH.List)
+			r.Get("/scenarios/{scenario_id}/bills/{id}", billH.Get)
+			r.Put("/scenarios/{scenario_id}/bills/{id}", billH.Update)
+			r.Get("/scenarios/{scenario_id}/pay-periods", periodH.List)
+			r.Get("/scenarios/{scenario_id}/assignments", assignH.List)
+			r.Post("/scenarios/{scenario_id}/assignments", assignH.Create)
+			r.Post("/scenarios/{scenario_id}/assignments/auto-assign", assignH.AutoAssign)
+			r.Put("/scenarios/{scenario_id}/assignments/{id}", assignH.Update)
+			r.Get("/scenarios/{scenario_id}/budget-grid", gridH.GetGrid)
+			r.Post("/scenarios/{scenario_id}/optimizer/suggest", optimizerH.Suggest)
+			r.Post("/scenarios/{scenario_id}/optimizer/apply", optimizerH.Apply)
+			r.Get("/scenarios/{scenario_id}/optimizer/plans/{id}/export", optimizerH.ExportPlan)
+		})
 
 		// Bill assignments
-		r.Get("/assignments", assignH.List)
-		r.Post("/assignments", assignH.Create)
-		r.Post("/assignments/auto-assign", assignH.AutoAssign)
+		r.Post("/assignments/undo", assignH.Undo)
+		r.Post("/assignments/redo", assignH.Redo)
 		r.Post("/assignments/reset-manual-moves", assignH.ResetManualMoves)
-		r.Put("/assignments/{id}", assignH.Update)
-		r.Patch("/assignments/{id}/status", assignH.UpdateStatus)
-		r.Delete("/assignments/{id}", assignH.Delete)
-
-		// Budget grid (composite view)
-		r.Get("/budget-grid", gridH.GetGrid)
+		r.Get("/assignments/{id}/defer-options", assignH.DeferOptions)
+		r.Get("/assignments/{id}/dispute", assignH.Dispute)
+		r.Put("/assignments/{id}/dispute", assignH.OpenDispute)
+		r.Post("/assignments/{id}/dispute/resolve", assignH.ResolveDispute)
+		r.Delete("/assignments/{id}/dispute", assignH.DeleteDispute)
 
-		// Import
-		r.Post("/import/xlsx", importH.Upload)
-		r.Post("/import/xlsx/confirm", importH.Confirm)
-		r.Get("/import/history", importH.History)
+		// Import (admins only)
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireRole(auth.RoleAdmin))
+			r.Use(importLimit.Handler)
+			r.Post("/import/xlsx", importH.Upload)
+			r.Get("/import/jobs/{id}", importH.Job)
+			r.Post("/import/xlsx/confirm", importH.Confirm)
+			r.Delete("/import/xlsx/session", importH.DeleteSession)
+			r.Post("/import/bank-csv", importH.BankCSV)
+			r.Post("/import/csv", importH.UploadCSV)
+			r.Post("/import/csv/confirm", importH.ConfirmCSV)
+			r.Delete("/import/csv/session", importH.DeleteCSVSession)
+			r.Get("/import/history", importH.History)
+		})
 
 		// Optimizer
 		r.Post("/optimizer/suggest", optimizerH.Suggest)
 		r.Post("/optimizer/apply", optimizerH.Apply)
 		r.Get("/optimizer/surplus", optimizerH.Surplus)
+		r.Get("/optimizer/plans/{id}/export", optimizerH.ExportPlan)
 
 		// Dashboard
 		r.Get("/dashboard/summary", dashboardH.Summary)
+		r.Get("/runway", dashboardH.Runway)
+		r.Get("/forecast", forecastH.Forecast)
+
+		// Reports
+		r.Get("/reports/owed-to-me", reportH.OwedToMe)
+		r.Get("/reports/allowances", reportH.Allowances)
+		r.Get("/reports/contributions", reportH.Contributions)
+		r.Get("/reports/monthly", reportH.Monthly)
+		r.Get("/reports/categories", reportH.Categories)
+		r.Get("/reports/planned-vs-actual", reportH.PlannedVsActual)
+		r.Get("/reports/disputes", reportH.Disputes)
+		r.Get("/reports/tax-deductible/{year}", reportH.TaxDeductible)
+		r.Get("/reports/category-budgets", categoryBudgetH.Status)
+		r.Get("/reports/obligations", obligationH.Report)
+
+		// Categories
+		r.Get("/categories", categoryH.List)
+		r.Post("/categories", categoryH.Create)
+		r.Get("/categories/spending", categoryH.Spending)
+		r.Put("/categories/{id}", categoryH.Update)
+		r.Delete("/categories/{id}", categoryH.Delete)
+
+		// Category budgets
+		r.Get("/category-budgets", categoryBudgetH.List)
+		r.Put("/category-budgets/{category}", categoryBudgetH.Set)
+		r.Delete("/category-budgets/{category}", categoryBudgetH.Delete)
+
+		// Transactions ledger
+		r.Get("/transactions", transactionH.List)
+		r.Post("/transactions", transactionH.Create)
+		r.Post("/transactions/reconcile", transactionH.Reconcile)
+		r.Get("/transactions/{id}", transactionH.Get)
+		r.Put("/transactions/{id}", transactionH.Update)
+		r.Delete("/transactions/{id}", transactionH.Delete)
+
+		// Household documents
+		r.Get("/documents", documentH.List)
+		r.Post("/documents", documentH.Create)
+		r.Get("/documents/{id}", documentH.Get)
+		r.Put("/documents/{id}", documentH.Update)
+		r.Delete("/documents/{id}", documentH.Delete)
+		r.Get("/documents/{id}/file", documentH.File)
+
+		// Accounting exports
+		r.Get("/export/qif", exportH.QIF)
+		r.Get("/export/gnucash", exportH.GnuCash)
+		r.Get("/export/pdf", exportH.PDF)
+		r.Get("/export/xlsx", exportH.XLSX)
+
+		// Calendar subscription token
+		r.Get("/calendar/token", calendarH.Token)
+		r.Post("/widgets/key", apiKeyH.WidgetKey)
+
+		// Email reminders
+		r.Get("/notifications/preferences", notificationH.Preferences)
+		r.Put("/notifications/preferences", notificationH.UpdatePreferences)
+		r.Post("/notifications/test", notificationH.SendTest)
+
+		// Webhooks
+		r.Get("/webhooks", webhookH.List)
+		r.Post("/webhooks", webhookH.Create)
+		r.Get("/webhooks/{id}", webhookH.Get)
+		r.Put("/webhooks/{id}", webhookH.Update)
+		r.Delete("/webhooks/{id}", webhookH.Delete)
+		r.Get("/webhooks/{id}/deliveries", webhookH.Deliveries)
+		r.Post("/webhooks/{id}/test", webhookH.Test)
+
+		// Admins only from here on
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireRole(auth.RoleAdmin))
+			// Deployment stats, scheduler lock holders and maintenance
+			r.Get("/admin/stats", adminH.Stats)
+			r.Get("/admin/duplicates", adminH.Duplicates)
+			r.Post("/admin/duplicates/merge", adminH.MergeDuplicates)
+			r.Get("/admin/integrity", adminH.Integrity)
+			r.Get("/admin/migrations", adminH.Migrations)
+
+			// Full backup and restore
+			r.Get("/export", backupH.Export)
+			r.Post("/import/backup", backupH.Restore)
+
+			// Configuration export/import
+			r.Get("/config/export", configH.Export)
+			r.Post("/config/import", configH.Import)
+
+			// User accounts and roles
+			r.Get("/users", userH.List)
+			r.Post("/users", userH.Create)
+			r.Put("/users/{id}", userH.Update)
+			r.Delete("/users/{id}", userH.Delete)
+
+			// Scoped API keys
+			r.Get("/api-keys", apiKeyH.List)
+			r.Post("/api-keys", apiKeyH.Create)
+			r.Delete("/api-keys/{id}", apiKeyH.Revoke)
+
+			// Who changed what
+			r.Get("/audit", auditH.List)
+			r.Get("/audit/export", auditH.Export)
+		})
+	})
+
+	// API v2 (preview): normalized resource names, plural nouns, PATCH for
+	// partial updates and amounts in integer cents, served by the same
+	// handlers as v1 with centAmounts converting at the boundary.
+	r.Get("/api/v2/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Use(auth.RequireAuth(cfg.JWTSecret, cfg.AuthEnabled(), authH.SessionRevoked, apiKeyH.Lookup))
+		// Viewers only read; admin-only routes are grouped below
+		r.Use(auth.RequireRoleForWrites(auth.RoleEditor))
+		r.Use(apiLimit.Handler)
+		r.Use(newCompressor().Handler)
+		r.Use(centAmounts)
+
+		// API keys reach only the routes grouped under one of their scopes
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(auth.ScopeReadBills))
+			r.Get("/bills", billH.List)
+			r.Get("/bills/{id}", billH.Get)
+			r.Get("/periods", periodH.List)
+			r.Get("/assignments", assignH.List)
+			r.Get("/assignments/due-soon", assignH.DueSoon)
+			r.Get("/budget-grid", gridH.GetGrid)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(auth.ScopeWriteAssignments))
+			r.Post("/assignments", assignH.Create)
+			r.Patch("/assignments/{id}", assignH.Update)
+			r.Patch("/assignments/{id}/status", assignH.UpdateStatus)
+			r.Post("/assignments/{id}/pay", assignH.Pay)
+			r.Delete("/assignments/{id}", assignH.Delete)
+		})
+		r.With(auth.RequireScope(auth.ScopeRunAutoAssign)).Post("/assignments/auto-assign", assignH.AutoAssign)
+
+		// Everything from here on is for login sessions only
+		r = r.With(auth.RejectAPIKeys)
+
+		// Bills
+		r.Post("/bills", billH.Create)
+		r.Patch("/bills/order", billH.Reorder)
+		r.Patch("/bills/{id}", billH.Update)
+		r.Delete("/bills/{id}", billH.Delete)
+		r.Get("/bills/{id}/skips", billH.Skips)
+		r.Post("/bills/{id}/skips", billH.Skip)
+		r.Delete("/bills/{id}/skips", billH.Unskip)
+		r.Get("/bills/{id}/promos", billH.Promos)
+		r.Post("/bills/{id}/promos", billH.AddPromo)
+		r.Delete("/bills/{id}/promos/{promo_id}", billH.DeletePromo)
+		r.Get("/bills/{id}/payoff", billH.Payoff)
+		r.Post("/bills/{id}/sinking-fund/plan", sinkingFundH.Plan)
+		r.Post("/bills/{id}/sinking-fund/apply", sinkingFundH.Apply)
+		r.Delete("/bills/{id}/sinking-fund", sinkingFundH.Clear)
+
+		// Credit cards
+		r.Get("/credit-cards", creditCardH.List)
+		r.Post("/credit-cards", creditCardH.Create)
+		r.Get("/credit-cards/{id}", creditCardH.Get)
+		r.Patch("/credit-cards/{id}", creditCardH.Update)
+		r.Delete("/credit-cards/{id}", creditCardH.Delete)
+		r.Put("/credit-cards/{id}/bill", creditCardH.Link)
+		r.Delete("/credit-cards/{id}/bill", creditCardH.Unlink)
+
+		// Credit card statement cycles
+		r.Get("/credit-cards/{id}/forecast", creditCardH.Forecast)
+		r.Post("/credit-cards/{id}/assign", creditCardH.Assign)
+
+		// Income sources
+		r.Get("/income-sources", incomeH.List)
+		r.Post("/income-sources", incomeH.Create)
+		r.Get("/income-source-templates", incomeH.Templates)
+		r.Get("/income-sources/{id}", incomeH.Get)
+		r.Patch("/income-sources/{id}", incomeH.Update)
+		r.Delete("/income-sources/{id}", incomeH.Delete)
+		r.Post("/income-sources/{id}/duplicates", incomeH.Duplicate)
+		r.Get("/income-sources/{id}/summary", incomeH.Summary)
+		r.Get("/income-sources/{id}/payroll-calendars", incomeH.PayrollCalendars)
+		r.Put("/income-sources/{id}/payroll-calendars/{year}", incomeH.SetPayrollCalendar)
+		r.Delete("/income-sources/{id}/payroll-calendars/{year}", incomeH.DeletePayrollCalendar)
+
+		// Income events
+		r.Get("/income-events", incomeEventH.List)
+		r.Post("/income-events", incomeEventH.Create)
+		r.Get("/income-events/{id}", incomeEventH.Get)
+		r.Patch("/income-events/{id}", incomeEventH.Update)
+		r.Delete("/income-events/{id}", incomeEventH.Delete)
+
+		// Periods
+		r.Get("/periods/risk", periodH.Risk)
+		r.Post("/periods/generate", periodH.Generate)
+		r.Post("/periods/generate/preview", periodH.Preview)
+		r.Patch("/periods/bulk", periodH.BulkUpdate)
+		r.Patch("/periods/{id}", periodH.Update)
+		r.Post("/periods/{id}/copy-from/{other_id}", periodH.CopyFrom)
+		r.Get("/periods/{id}/checklist-items", checklistH.List)
+		r.Post("/periods/{id}/checklist-items", checklistH.Create)
+		r.Patch("/checklist-items/{id}", checklistH.Update)
+		r.Delete("/checklist-items/{id}", checklistH.Delete)
+
+		// Savings sweeps
+		r.Get("/sweeps", sweepH.Suggestions)
+		r.Post("/sweeps", sweepH.Create)
+
+		// Shareable starter budgets
+		r.Get("/templates/export", templateH.Export)
+		r.Post("/templates/import", templateH.Import)
+
+		// What-if scenarios: the sandbox routes work on a scenario's copies
+		r.Get("/scenarios", scenarioH.List)
+		r.Post("/scenarios", scenarioH.Create)
+		r.Get("/scenarios/{scenario_id}", scenarioH.Get)
+		r.Delete("/scenarios/{scenario_id}", scenarioH.Discard)
+		r.Post("/scenarios/{scenario_id}/promote", scenarioH.Promote)
+		r.Group(func(r chi.Router) {
+			r.Use(scenarioH.Sandbox)
+			r.Get("/scenarios/{scenario_id}/bills", billH.List)
+			r.Get("/scenarios/{scenario_id}/bills/{id}", billH.Get)
+			r.Patch("/scenarios/{scenario_id}/bills/{id}", billH.Update)
+			r.Get("/scenarios/{scenario_id}/periods", periodH.List)
+			r.Get("/scenarios/{scenario_id}/assignments", assignH.List)
+			r.Post("/scenarios/{scenario_id}/assignments", assignH.Create)
+			r.Post("/scenarios/{scenario_id}/assignments/auto-assign", assignH.AutoAssign)
+			r.Patch("/scenarios/{scenario_id}/assignments/{id}", assignH.Update)
+			r.Get("/scenarios/{scenario_id}/budget-grid", gridH.GetGrid)
+			r.Post("/scenarios/{scenario_id}/optimizer/suggestions", optimizerH.Suggest)
+			r.Post("/scenarios/{scenario_id}/optimizer/apply", optimizerH.Apply)
+			r.Get("/scenarios/{scenario_id}/optimizer/plans/{id}/export", optimizerH.ExportPlan)
+		})
+
+		// Assignments
+		r.Post("/assignments/undo", assignH.Undo)
+		r.Post("/assignments/redo", assignH.Redo)
+		r.Post("/assignments/reset-manual-moves", assignH.ResetManualMoves)
+		r.Get("/assignments/{id}/defer-options", assignH.DeferOptions)
+		r.Get("/assignments/{id}/dispute", assignH.Dispute)
+		r.Put("/assignments/{id}/dispute", assignH.OpenDispute)
+		r.Post("/assignments/{id}/dispute/resolve", assignH.ResolveDispute)
+		r.Delete("/assignments/{id}/dispute", assignH.DeleteDispute)
+
+		// Imports (admins only)
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireRole(auth.RoleAdmin))
+			r.Use(importLimit.Handler)
+			r.Post("/imports/xlsx", importH.Upload)
+			r.Get("/imports/jobs/{id}", importH.Job)
+			r.Post("/imports/xlsx/confirm", importH.Confirm)
+			r.Delete("/imports/xlsx/session", importH.DeleteSession)
+			r.Post("/imports/bank-csv", importH.BankCSV)
+			r.Post("/imports/csv", importH.UploadCSV)
+			r.Post("/imports/csv/confirm", importH.ConfirmCSV)
+			r.Delete("/imports/csv/session", importH.DeleteCSVSession)
+			r.Get("/imports", importH.History)
+		})
+
+		// Optimizer
+		r.Post("/optimizer/suggestions", optimizerH.Suggest)
+		r.Post("/optimizer/apply", optimizerH.Apply)
+		r.Get("/optimizer/surplus", optimizerH.Surplus)
+		r.Get("/optimizer/plans/{id}/export", optimizerH.ExportPlan)
+
+		r.Get("/dashboard/summary", dashboardH.Summary)
+		r.Get("/runway", dashboardH.Runway)
+		r.Get("/forecast", forecastH.Forecast)
+
+		// Reports
+		r.Get("/reports/owed-to-me", reportH.OwedToMe)
+		r.Get("/reports/allowances", reportH.Allowances)
+		r.Get("/reports/contributions", reportH.Contributions)
+		r.Get("/reports/monthly", reportH.Monthly)
+		r.Get("/reports/categories", reportH.Categories)
+		r.Get("/reports/planned-vs-actual", reportH.PlannedVsActual)
+		r.Get("/reports/disputes", reportH.Disputes)
+		r.Get("/reports/tax-deductible/{year}", reportH.TaxDeductible)
+		r.Get("/reports/category-budgets", categoryBudgetH.Status)
+		r.Get("/reports/obligations", obligationH.Report)
+
+		// Categories
+		r.Get("/categories", categoryH.List)
+		r.Post("/categories", categoryH.Create)
+		r.Get("/categories/spending", categoryH.Spending)
+		r.Patch("/categories/{id}", categoryH.Update)
+		r.Delete("/categories/{id}", categoryH.Delete)
+
+		// Category budgets
+		r.Get("/category-budgets", categoryBudgetH.List)
+		r.Put("/category-budgets/{category}", categoryBudgetH.Set)
+		r.Delete("/category-budgets/{category}", categoryBudgetH.Delete)
+
+		// Transactions ledger
+		r.Get("/transactions", transactionH.List)
+		r.Post("/transactions", transactionH.Create)
+		r.Post("/transactions/reconcile", transactionH.Reconcile)
+		r.Get("/transactions/{id}", transactionH.Get)
+		r.Patch("/transactions/{id}", transactionH.Update)
+		r.Delete("/transactions/{id}", transactionH.Delete)
+
+		// Household documents
+		r.Get("/documents", documentH.List)
+		r.Post("/documents", documentH.Create)
+		r.Get("/documents/{id}", documentH.Get)
+		r.Patch("/documents/{id}", documentH.Update)
+		r.Delete("/documents/{id}", documentH.Delete)
+		r.Get("/documents/{id}/file", documentH.File)
+
+		// Accounting exports
+		r.Get("/export/qif", exportH.QIF)
+		r.Get("/export/gnucash", exportH.GnuCash)
+		r.Get("/export/pdf", exportH.PDF)
+		r.Get("/export/xlsx", exportH.XLSX)
+
+		// Calendar subscription token
+		r.Get("/calendar/token", calendarH.Token)
+		r.Post("/widgets/key", apiKeyH.WidgetKey)
+
+		// Email reminders
+		r.Get("/notifications/preferences", notificationH.Preferences)
+		r.Patch("/notifications/preferences", notificationH.UpdatePreferences)
+		r.Post("/notifications/test", notificationH.SendTest)
+
+		// Webhooks
+		r.Get("/webhooks", webhookH.List)
+		r.Post("/webhooks", webhookH.Create)
+		r.Get("/webhooks/{id}", webhookH.Get)
+		r.Patch("/webhooks/{id}", webhookH.Update)
+		r.Delete("/webhooks/{id}", webhookH.Delete)
+		r.Get("/webhooks/{id}/deliveries", webhookH.Deliveries)
+		r.Post("/webhooks/{id}/test", webhookH.Test)
+
+		// Admins only from here on
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireRole(auth.RoleAdmin))
+			// Deployment stats, scheduler lock holders and maintenance
+			r.Get("/admin/stats", adminH.Stats)
+			r.Get("/admin/duplicates", adminH.Duplicates)
+			r.Post("/admin/duplicates/merge", adminH.MergeDuplicates)
+			r.Get("/admin/integrity", adminH.Integrity)
+			r.Get("/admin/migrations", adminH.Migrations)
+
+			// Full backup and restore
+			r.Get("/export", backupH.Export)
+			r.Post("/imports/backup", backupH.Restore)
+
+			// Configuration export/import
+			r.Get("/config/export", configH.Export)
+			r.Post("/config/import", configH.Import)
+
+			// User accounts and roles
+			r.Get("/users", userH.List)
+			r.Post("/users", userH.Create)
+			r.Patch("/users/{id}", userH.Update)
+			r.Delete("/users/{id}", userH.Delete)
+
+			// Scoped API keys
+			r.Get("/api-keys", apiKeyH.List)
+			r.Post("/api-keys", apiKeyH.Create)
+			r.Delete("/api-keys/{id}", apiKeyH.Revoke)
+
+			// Who changed what
+			r.Get("/audit", auditH.List)
+			r.Get("/audit/export", auditH.Export)
+		})
 	})
 
 	return r
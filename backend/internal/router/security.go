@@ -0,0 +1,31 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+)
+
+// securityHeaders sets browser hardening headers on every response. Empty
+// config values leave the corresponding header unset.
+func securityHeaders(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			if cfg.ContentSecurityPolicy != "" {
+				h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+			}
+			if cfg.ReferrerPolicy != "" {
+				h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+			}
+			// Only send HSTS when explicitly enabled, since local dev runs over plain HTTP
+			if cfg.HSTSMaxAge > 0 {
+				h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(cfg.HSTSMaxAge)+"; includeSubDomains")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/config"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := &config.Config{
+		ContentSecurityPolicy: config.DefaultContentSecurityPolicy,
+		HSTSMaxAge:            31536000,
+		ReferrerPolicy:        "no-referrer",
+	}
+	handler := securityHeaders(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := map[string]string{
+		"Content-Security-Policy":   config.DefaultContentSecurityPolicy,
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "no-referrer",
+	}
+	for header, want := range expected {
+		if got := rr.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestSecurityHeaders_HSTSDisabled(t *testing.T) {
+	handler := securityHeaders(&config.Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rr.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("expected no HSTS header when disabled, got %q", got)
+	}
+	if got := rr.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("expected no CSP header when unset, got %q", got)
+	}
+	if got := rr.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("expected nosniff, got %q", got)
+	}
+}
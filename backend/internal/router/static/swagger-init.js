@@ -0,0 +1,11 @@
+if (typeof SwaggerUIBundle === 'undefined') {
+  document.getElementById('swagger-ui').textContent =
+    'Swagger UI assets are missing from this build; run "make swagger-ui" and rebuild.';
+} else {
+  window.ui = SwaggerUIBundle({
+    url: '/api/v1/openapi.json',
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    withCredentials: true,
+  });
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// maxCentsBody caps the JSON request bodies centAmounts reads to convert.
+const maxCentsBody = 10 << 20
+
+// moneyQueryParams are the query parameters holding amounts.
+var moneyQueryParams = []string{"amount_min", "amount_max", "balance", "payment", "starting_balance", "threshold"}
+
+// deprecated marks every response as coming from a deprecated API version and
+// points clients at its successor (RFC 9745 Deprecation, RFC 8288 Link).
+func deprecated(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// centAmounts has v2 clients send and receive amounts as integer cents. The
+// amount query parameters and JSON request bodies are turned into the decimal
+// dollars the handlers share with v1, and models.WriteJSON turns responses
+// back. Uploads and other non-JSON bodies pass through untouched.
+func centAmounts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); len(q) > 0 {
+			changed := false
+			for _, name := range moneyQueryParams {
+				values := q[name]
+				for i, v := range values {
+					if v == "" {
+						continue
+					}
+					dollars, err := models.CentsToDollars(name, v)
+					if err != nil {
+						models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+						return
+					}
+					values[i] = dollars
+					changed = true
+				}
+			}
+			if changed {
+				r.URL.RawQuery = q.Encode()
+			}
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.Body != nil && r.Body != http.NoBody && (mediaType == "" || mediaType == "application/json") {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCentsBody))
+			if err != nil {
+				models.WriteError(w, http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE", err.Error())
+				return
+			}
+			if len(bytes.TrimSpace(body)) > 0 {
+				if body, err = models.DollarsFromCents(body); err != nil {
+					models.WriteError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+					return
+				}
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+
+		next.ServeHTTP(models.CentAmounts(w), r)
+	})
+}
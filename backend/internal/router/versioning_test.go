@@ -0,0 +1,112 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+func TestDeprecated(t *testing.T) {
+	handler := deprecated("/api/v2")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/bills", nil))
+
+	if got := rr.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation = %q, want true", got)
+	}
+	if got := rr.Header().Get("Link"); got != `</api/v2>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+}
+
+func TestCentAmounts_BillRoundTrip(t *testing.T) {
+	// Stands in for BillHandler.Create: decodes dollars, answers in dollars
+	var got models.CreateBillRequest
+	handler := centAmounts(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		models.WriteJSON(w, http.StatusCreated, models.Bill{
+			ID: 7, Name: got.Name, DefaultAmount: got.DefaultAmount, MonthlyAmounts: got.MonthlyAmounts,
+			SharedPercent: got.SharedPercent, BufferPercent: got.BufferPercent,
+		})
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/bills", strings.NewReader(
+		`{"name": "Rent", "default_amount": 129999, "monthly_amounts": {"12": 5}, "shared_percent": 50, "buffer_percent": 10}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got.DefaultAmount == nil || *got.DefaultAmount != 1299.99 || got.MonthlyAmounts[12] != 0.05 {
+		t.Errorf("handler saw %v and %v, want dollars", got.DefaultAmount, got.MonthlyAmounts)
+	}
+	if *got.SharedPercent != 50 || got.BufferPercent != 10 {
+		t.Errorf("percentages changed: %v, %v", *got.SharedPercent, got.BufferPercent)
+	}
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]string{
+		"id": "7", "default_amount": "129999", "monthly_amounts": `{"12":5}`, "shared_percent": "50", "buffer_percent": "10",
+	} {
+		if string(resp.Data[field]) != want {
+			t.Errorf("%s = %s, want %s", field, resp.Data[field], want)
+		}
+	}
+}
+
+func TestCentAmounts_AssignmentListAndQuery(t *testing.T) {
+	handler := centAmounts(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if min := r.URL.Query().Get("amount_min"); min != "25.50" {
+			t.Errorf("amount_min = %q, want dollars", min)
+		}
+		planned, actual := 80.1, 79.99
+		models.WriteJSONList(w, []models.BillAssignment{{ID: 3, BillID: 4, PlannedAmount: &planned, ActualAmount: &actual}}, 41, 20, 0)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v2/assignments?amount_min=2550&limit=20", nil))
+
+	var resp struct {
+		Data []map[string]json.RawMessage `json:"data"`
+		Meta models.Meta                  `json:"meta"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 1 || string(resp.Data[0]["planned_amount"]) != "8010" || string(resp.Data[0]["actual_amount"]) != "7999" ||
+		string(resp.Data[0]["bill_id"]) != "4" || string(resp.Data[0]["forecast_amount"]) != "null" {
+		t.Errorf("unexpected assignment: %v", resp.Data)
+	}
+	if resp.Meta.Total == nil || *resp.Meta.Total != 41 || *resp.Meta.Limit != 20 {
+		t.Errorf("paging changed: %+v", resp.Meta)
+	}
+}
+
+func TestCentAmounts_RejectsFractionalCents(t *testing.T) {
+	handler := centAmounts(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not run")
+	}))
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPatch, "/api/v2/assignments/3", strings.NewReader(`{"actual_amount": 79.99}`)),
+		httptest.NewRequest(http.MethodGet, "/api/v2/forecast?starting_balance=10.5", nil),
+	} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "whole number of cents") {
+			t.Errorf("%s: expected 400, got %d: %s", req.URL, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DuplicateSimilarity is the trigram similarity from which an imported bill
+// is taken for a duplicate of an existing one. pg_trgm's default of 0.3 flags
+// too many short bill names that only share a word.
+const DuplicateSimilarity = 0.4
+
+// ExistingBill is an active bill that imported bills are checked against.
+type ExistingBill struct {
+	ID   int
+	Name string
+}
+
+// DuplicateMatch pairs an imported bill, by its index in the preview, with
+// the existing bill whose name it most resembles.
+type DuplicateMatch struct {
+	Row        int     `json:"row"`
+	BillID     int     `json:"bill_id"`
+	BillName   string  `json:"bill_name"`
+	Similarity float64 `json:"similarity"` // 1 for the same name in any case
+}
+
+// FindDuplicates matches each imported bill to the most similar existing
+// bill at or above DuplicateSimilarity. Ties go to the lower bill id.
+func FindDuplicates(bills []ParsedBill, existing []ExistingBill) []DuplicateMatch {
+	matches := []DuplicateMatch{}
+	for i, b := range bills {
+		best := DuplicateMatch{Row: i}
+		for _, e := range existing {
+			sim := TrigramSimilarity(b.Name, e.Name)
+			if sim < DuplicateSimilarity {
+				continue
+			}
+			if sim > best.Similarity || (sim == best.Similarity && e.ID < best.BillID) {
+				best.BillID, best.BillName, best.Similarity = e.ID, e.Name, sim
+			}
+		}
+		if best.BillID != 0 {
+			best.Similarity = math.Round(best.Similarity*100) / 100
+			matches = append(matches, best)
+		}
+	}
+	return matches
+}
+
+// TrigramSimilarity compares two names the way pg_trgm's similarity() does:
+// the shared fraction of their lowercased words' three-letter runs, each word
+// padded with two spaces in front and one behind.
+func TrigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool)
+	for _, w := range words {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
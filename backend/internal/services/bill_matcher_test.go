@@ -0,0 +1,42 @@
+package services
+
+import "testing"
+
+func TestTrigramSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Netflix", "NETFLIX", 1},
+		{"Car Insurance", "car  insurance!", 1},
+		{"cat", "cat dog", 4.0 / 8.0},
+		{"Rent", "Water", 0},
+		{"", "Rent", 0},
+	}
+	for _, tt := range tests {
+		if got := TrigramSimilarity(tt.a, tt.b); !almostEqual(got, tt.want) {
+			t.Errorf("TrigramSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	bills := []ParsedBill{{Name: "Verizon"}, {Name: "Piano lessons"}, {Name: "netflix"}}
+	existing := []ExistingBill{
+		{ID: 2, Name: "Netflix"},
+		{ID: 5, Name: "Verizon Wireless"},
+		{ID: 9, Name: "Water"},
+	}
+
+	got := FindDuplicates(bills, existing)
+
+	if len(got) != 2 {
+		t.Fatalf("got %d matches, want 2: %+v", len(got), got)
+	}
+	if got[0].Row != 0 || got[0].BillID != 5 || got[0].Similarity != 0.47 {
+		t.Errorf("Verizon match = %+v, want bill 5 at 0.47", got[0])
+	}
+	if got[1].Row != 2 || got[1].BillID != 2 || got[1].Similarity != 1 {
+		t.Errorf("netflix match = %+v, want bill 2 at 1", got[1])
+	}
+}
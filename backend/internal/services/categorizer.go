@@ -0,0 +1,50 @@
+package services
+
+import "strings"
+
+// categoryKeywords is the built-in keyword table used when no learned
+// category matches a bill name.
+var categoryKeywords = map[string][]string{
+	"housing":        {"mortgage", "hoa", "rent"},
+	"utilities":      {"power", "spire", "gas", "water", "sewage", "h2o", "internet", "trash", "verizon", "electric"},
+	"insurance":      {"insurance"},
+	"transportation": {"car payment", "car insurance"},
+	"subscriptions":  {"hulu", "netflix", "apple", "disney", "espn", "aws"},
+	"savings":        {"saving"},
+	"debt":           {"loan", "credit", "chase", "izzcc", "anna"},
+	"personal":       {"haircut", "cleaning", "pest control", "landscaping"},
+}
+
+// CategoryKey normalizes a bill name for matching learned categories, so that
+// case and spacing differences between imports do not matter.
+func CategoryKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// GuessCategory picks a category for a bill name. A category the user taught
+// for the same name (keyed by CategoryKey) wins over the keyword table.
+func GuessCategory(name string, learned map[string]string) string {
+	if cat, ok := learned[CategoryKey(name)]; ok {
+		return cat
+	}
+
+	lower := strings.ToLower(name)
+	for cat, keywords := range categoryKeywords {
+		for _, kw := range keywords {
+			if strings.Contains(lower, kw) {
+				return cat
+			}
+		}
+	}
+	return "other"
+}
+
+// ApplyLearnedCategories overrides the category of previewed bills whose names
+// the user has categorized before.
+func ApplyLearnedCategories(preview *ImportPreview, learned map[string]string) {
+	for i := range preview.Bills {
+		if cat, ok := learned[CategoryKey(preview.Bills[i].Name)]; ok {
+			preview.Bills[i].Category = cat
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import "testing"
+
+func TestCategoryKey(t *testing.T) {
+	if got := CategoryKey("  Netflix   Premium "); got != "netflix premium" {
+		t.Errorf("CategoryKey() = %q, want %q", got, "netflix premium")
+	}
+}
+
+func TestGuessCategory_LearnedBeforeKeywords(t *testing.T) {
+	learned := map[string]string{"netflix": "entertainment"}
+
+	if got := GuessCategory("NETFLIX", learned); got != "entertainment" {
+		t.Errorf("GuessCategory(NETFLIX) = %q, want learned %q", got, "entertainment")
+	}
+	if got := GuessCategory("Hulu", learned); got != "subscriptions" {
+		t.Errorf("GuessCategory(Hulu) = %q, want keyword %q", got, "subscriptions")
+	}
+	if got := GuessCategory("Piano lessons", nil); got != "other" {
+		t.Errorf("GuessCategory(Piano lessons) = %q, want %q", got, "other")
+	}
+}
+
+func TestApplyLearnedCategories(t *testing.T) {
+	preview := &ImportPreview{Bills: []ParsedBill{
+		{Name: "Netflix", Category: "subscriptions"},
+		{Name: "Chase Freedom", Category: "debt"},
+	}}
+
+	ApplyLearnedCategories(preview, map[string]string{"netflix": "entertainment"})
+
+	if preview.Bills[0].Category != "entertainment" {
+		t.Errorf("Netflix category = %q, want %q", preview.Bills[0].Category, "entertainment")
+	}
+	if preview.Bills[1].Category != "debt" {
+		t.Errorf("Chase Freedom category = %q, want unchanged %q", preview.Bills[1].Category, "debt")
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import "time"
+
+// StatementCycle is one credit card billing cycle: the statement closes on
+// Statement and the balance it shows is due on Due.
+type StatementCycle struct {
+	Statement time.Time
+	Due       time.Time
+}
+
+// GraceDays is the time between the statement closing and the payment due.
+func (c StatementCycle) GraceDays() int {
+	return int(c.Due.Sub(c.Statement).Hours() / 24)
+}
+
+// ForecastStatementCycles projects the next count billing cycles of a card
+// whose statement closes on statementDay, starting with the first close on or
+// after from. Each cycle's payment falls on the first dueDay after its close,
+// which is the following month when dueDay is on or before statementDay. Days
+// past a month's end clamp to its last day.
+func ForecastStatementCycles(statementDay, dueDay int, from time.Time, count int) []StatementCycle {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if dayIn(month, statementDay).Before(from) {
+		month = month.AddDate(0, 1, 0)
+	}
+
+	cycles := make([]StatementCycle, 0, count)
+	for i := 0; i < count; i++ {
+		statement := dayIn(month, statementDay)
+		due := dayIn(month, dueDay)
+		if !due.After(statement) {
+			due = dayIn(month.AddDate(0, 1, 0), dueDay)
+		}
+		cycles = append(cycles, StatementCycle{Statement: statement, Due: due})
+		month = month.AddDate(0, 1, 0)
+	}
+	return cycles
+}
+
+// StatementPayDate picks which of payDates (sorted ascending) should pay a
+// cycle: the last one on or before the due date, which falls after the
+// statement closes whenever a paycheck lands in the grace period, so the
+// amount owed is known. Dates before notBefore are passed over. It returns
+// -1 when no date is early enough.
+func StatementPayDate(cycle StatementCycle, payDates []time.Time, notBefore time.Time) int {
+	best := -1
+	for i, d := range payDates {
+		if d.After(cycle.Due) {
+			break
+		}
+		if !d.Before(notBefore) {
+			best = i
+		}
+	}
+	return best
+}
+
+// dayIn returns day in month's month, clamped to the month's last day.
+func dayIn(month time.Time, day int) time.Time {
+	if last := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day(); day > last {
+		day = last
+	}
+	return time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecastStatementCycles_DueFollowingMonth(t *testing.T) {
+	// Closes on the 25th, due on the 20th of the next month
+	cycles := ForecastStatementCycles(25, 20, date(2026, time.January, 26), 3)
+
+	want := [][2]time.Time{
+		{date(2026, time.February, 25), date(2026, time.March, 20)},
+		{date(2026, time.March, 25), date(2026, time.April, 20)},
+		{date(2026, time.April, 25), date(2026, time.May, 20)},
+	}
+	if len(cycles) != len(want) {
+		t.Fatalf("expected %d cycles, got %d", len(want), len(cycles))
+	}
+	for i, c := range cycles {
+		if !c.Statement.Equal(want[i][0]) || !c.Due.Equal(want[i][1]) {
+			t.Errorf("cycle %d = %s / %s, want %s / %s", i, c.Statement.Format("2006-01-02"), c.Due.Format("2006-01-02"),
+				want[i][0].Format("2006-01-02"), want[i][1].Format("2006-01-02"))
+		}
+	}
+	if cycles[0].GraceDays() != 23 {
+		t.Errorf("grace days = %d, want 23", cycles[0].GraceDays())
+	}
+}
+
+func TestForecastStatementCycles_DueSameMonthAndStartsOnClose(t *testing.T) {
+	// A close on from itself counts, and a due day after the close stays in its month
+	cycles := ForecastStatementCycles(3, 28, date(2026, time.January, 3), 1)
+
+	if !cycles[0].Statement.Equal(date(2026, time.January, 3)) || !cycles[0].Due.Equal(date(2026, time.January, 28)) {
+		t.Errorf("unexpected cycle: %+v", cycles[0])
+	}
+}
+
+func TestForecastStatementCycles_ClampsToMonthEnd(t *testing.T) {
+	cycles := ForecastStatementCycles(31, 30, date(2026, time.February, 1), 2)
+
+	// February's close clamps to the 28th, and March's 30th comes after it
+	if !cycles[0].Statement.Equal(date(2026, time.February, 28)) || !cycles[0].Due.Equal(date(2026, time.March, 30)) {
+		t.Errorf("unexpected February cycle: %+v", cycles[0])
+	}
+	if !cycles[1].Statement.Equal(date(2026, time.March, 31)) || !cycles[1].Due.Equal(date(2026, time.April, 30)) {
+		t.Errorf("unexpected March cycle: %+v", cycles[1])
+	}
+}
+
+func TestStatementPayDate(t *testing.T) {
+	cycle := StatementCycle{Statement: date(2026, time.February, 25), Due: date(2026, time.March, 20)}
+	payDates := []time.Time{
+		date(2026, time.February, 20),
+		date(2026, time.March, 6),
+		date(2026, time.March, 20),
+		date(2026, time.April, 3),
+	}
+
+	// The paycheck on the due date itself is still in time
+	if i := StatementPayDate(cycle, payDates, date(2026, time.February, 1)); i != 2 {
+		t.Errorf("expected the March 20 paycheck, got index %d", i)
+	}
+	if i := StatementPayDate(cycle, payDates[:1], date(2026, time.February, 1)); i != 0 {
+		t.Errorf("expected a paycheck before the close when it's the only one, got index %d", i)
+	}
+	if i := StatementPayDate(cycle, payDates[3:], date(2026, time.February, 1)); i != -1 {
+		t.Errorf("expected no paycheck early enough, got index %d", i)
+	}
+	if i := StatementPayDate(cycle, payDates[:1], date(2026, time.March, 1)); i != -1 {
+		t.Errorf("expected paychecks before notBefore to be passed over, got index %d", i)
+	}
+}
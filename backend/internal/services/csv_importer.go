@@ -0,0 +1,219 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVColumnMapping says which columns of a bank export hold each field. A
+// column is named by its header text (case-insensitive) or by its 0-based index.
+type CSVColumnMapping struct {
+	Date        string `json:"date"`
+	Amount      string `json:"amount"`
+	Description string `json:"description"`
+	DateFormat  string `json:"date_format"` // Go layout; empty tries common formats
+}
+
+// BankTransaction is one parsed row of a bank export. Amount is always
+// positive; bank exports disagree on whether debits are negative.
+type BankTransaction struct {
+	Row         int       `json:"row"` // 1-based line in the file, header included
+	Date        time.Time `json:"date"`
+	Amount      float64   `json:"amount"`
+	Description string    `json:"description"`
+	Payee       string    `json:"payee,omitempty"` // normalized merchant, wallet exports only
+}
+
+var csvDateFormats = []string{"2006-01-02", "01/02/2006", "1/2/2006", "01/02/06", "1/2/06"}
+
+type CSVImporter struct{}
+
+func NewCSVImporter() *CSVImporter {
+	return &CSVImporter{}
+}
+
+// Parse reads a headed CSV bank export using the given column mapping. Rows
+// that cannot be parsed are skipped and reported as warnings.
+func (imp *CSVImporter) Parse(r io.Reader, m CSVColumnMapping) ([]BankTransaction, []string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading csv header: %w", err)
+	}
+
+	dateCol, err := resolveColumn(header, m.Date, "date")
+	if err != nil {
+		return nil, nil, err
+	}
+	amountCol, err := resolveColumn(header, m.Amount, "amount")
+	if err != nil {
+		return nil, nil, err
+	}
+	descCol, err := resolveColumn(header, m.Description, "description")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var txns []BankTransaction
+	var warnings []string
+	for line := 2; ; line++ {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading csv line %d: %w", line, err)
+		}
+		if len(rec) <= dateCol || len(rec) <= amountCol || len(rec) <= descCol {
+			warnings = append(warnings, fmt.Sprintf("line %d: too few columns", line))
+			continue
+		}
+
+		date, ok := parseCSVDate(rec[dateCol], m.DateFormat)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("line %d: unrecognized date %q", line, rec[dateCol]))
+			continue
+		}
+		amount := parseCSVAmount(rec[amountCol])
+		if amount == nil {
+			warnings = append(warnings, fmt.Sprintf("line %d: unrecognized amount %q", line, rec[amountCol]))
+			continue
+		}
+
+		txns = append(txns, BankTransaction{
+			Row:         line,
+			Date:        date,
+			Amount:      math.Abs(*amount),
+			Description: strings.TrimSpace(rec[descCol]),
+		})
+	}
+	return txns, warnings, nil
+}
+
+func resolveColumn(header []string, name, field string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = field
+	}
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(header) {
+		return i, nil
+	}
+	return 0, fmt.Errorf("%s column %q not found in csv header", field, name)
+}
+
+func parseCSVDate(s, layout string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if layout != "" {
+		t, err := time.Parse(layout, s)
+		return t, err == nil
+	}
+	for _, f := range csvDateFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseCSVAmount accepts "$1,234.56", "-45.00" and accounting-style "(45.00)".
+func parseCSVAmount(s string) *float64 {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = "-" + s[1:len(s)-1]
+	}
+	return parseNumber(s)
+}
+
+// BackfillCandidate is a past assignment without an actual amount that a bank
+// transaction may settle.
+type BackfillCandidate struct {
+	AssignmentID int
+	BillName     string
+	Date         time.Time // occurrence due date, or the pay date when unknown
+}
+
+// BackfillMatch pairs a transaction with the assignment it settles.
+type BackfillMatch struct {
+	AssignmentID int     `json:"assignment_id"`
+	BillName     string  `json:"bill_name"`
+	Row          int     `json:"row"`
+	Date         string  `json:"date"` // transaction date, YYYY-MM-DD
+	Amount       float64 `json:"amount"`
+	Description  string  `json:"description"`
+}
+
+// MatchBackfill matches transactions to candidates whose bill name appears in
+// the transaction description, or matches its normalized payee, and whose
+// date is within windowDays. Each transaction settles at most one candidate
+// and each candidate is settled at most once: the closest pairs by date are
+// matched first, so a transaction doesn't take a candidate that a later one
+// fits better. Ties go to the earlier transaction.
+func MatchBackfill(txns []BankTransaction, candidates []BackfillCandidate, windowDays int) ([]BackfillMatch, []BankTransaction) {
+	type pair struct {
+		txn, cand int
+		dist      float64
+	}
+	var pairs []pair
+	for ti, t := range txns {
+		desc := strings.ToLower(t.Description)
+		for ci, c := range candidates {
+			if c.BillName == "" {
+				continue
+			}
+			if !strings.Contains(desc, strings.ToLower(c.BillName)) && !payeeMatchesBill(t.Payee, c.BillName) {
+				continue
+			}
+			dist := math.Abs(t.Date.Sub(c.Date).Hours() / 24)
+			if dist > float64(windowDays) {
+				continue
+			}
+			pairs = append(pairs, pair{ti, ci, dist})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].dist < pairs[j].dist })
+
+	settles := make(map[int]int, len(txns)) // transaction index -> candidate index
+	used := make(map[int]bool)
+	for _, p := range pairs {
+		id := candidates[p.cand].AssignmentID
+		if _, done := settles[p.txn]; done || used[id] {
+			continue
+		}
+		settles[p.txn] = p.cand
+		used[id] = true
+	}
+
+	var matches []BackfillMatch
+	var unmatched []BankTransaction
+	for ti, t := range txns {
+		ci, ok := settles[ti]
+		if !ok {
+			unmatched = append(unmatched, t)
+			continue
+		}
+		c := candidates[ci]
+		matches = append(matches, BackfillMatch{
+			AssignmentID: c.AssignmentID,
+			BillName:     c.BillName,
+			Row:          t.Row,
+			Date:         t.Date.Format("2006-01-02"),
+			Amount:       t.Amount,
+			Description:  t.Description,
+		})
+	}
+	return matches, unmatched
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVImporterParse_HeaderMapping(t *testing.T) {
+	data := `Posted,Payee,Debit,Balance
+03/02/2026,ACH ELECTRIC CO,"$1,210.50",400.00
+03/05/2026,VERIZON WIRELESS,(85.00),315.00
+not-a-date,Other,1.00,0
+03/06/2026,Coffee,abc,0
+`
+	imp := NewCSVImporter()
+	txns, warnings, err := imp.Parse(strings.NewReader(data), CSVColumnMapping{
+		Date: "posted", Amount: "Debit", Description: "payee",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(txns))
+	}
+	if txns[0].Amount != 1210.50 || txns[0].Row != 2 || !txns[0].Date.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected first transaction: %+v", txns[0])
+	}
+	if txns[1].Amount != 85 {
+		t.Errorf("expected accounting negative to parse as 85, got %v", txns[1].Amount)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("expected 2 warnings, got %v", warnings)
+	}
+}
+
+func TestCSVImporterParse_IndexMappingAndLayout(t *testing.T) {
+	data := "a,b,c\n2026.03.02,-42.10,Water\n"
+	txns, _, err := NewCSVImporter().Parse(strings.NewReader(data), CSVColumnMapping{
+		Date: "0", Amount: "1", Description: "2", DateFormat: "2006.01.02",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 || txns[0].Amount != 42.10 || txns[0].Description != "Water" {
+		t.Errorf("unexpected transactions: %+v", txns)
+	}
+}
+
+func TestCSVImporterParse_MissingColumn(t *testing.T) {
+	_, _, err := NewCSVImporter().Parse(strings.NewReader("Date,Amount\n"), CSVColumnMapping{})
+	if err == nil || !strings.Contains(err.Error(), "description") {
+		t.Errorf("expected missing description column error, got %v", err)
+	}
+}
+
+func TestMatchBackfill_NameAndWindow(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	txns := []BankTransaction{
+		{Row: 2, Date: d(3), Amount: 120, Description: "ACH ELECTRIC CO"},
+		{Row: 3, Date: d(20), Amount: 60, Description: "Internet Provider"},
+		{Row: 4, Date: d(4), Amount: 125, Description: "electric co refund"},
+	}
+	candidates := []BackfillCandidate{
+		{AssignmentID: 1, BillName: "Electric", Date: d(1)},
+		{AssignmentID: 2, BillName: "Internet", Date: d(5)},
+	}
+
+	matches, unmatched := MatchBackfill(txns, candidates, 5)
+	if len(matches) != 1 || matches[0].AssignmentID != 1 || matches[0].Amount != 120 {
+		t.Fatalf("unexpected matches: %+v", matches)
+	}
+	// Internet is outside the window; the second electric line finds Electric already settled
+	if len(unmatched) != 2 {
+		t.Errorf("expected 2 unmatched, got %+v", unmatched)
+	}
+}
+
+func TestMatchBackfill_ClosestPairWins(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	// Both payments fit the March 10 water bill; the second is closer to it
+	// and the first also fits the March 4 one
+	txns := []BankTransaction{
+		{Row: 2, Date: d(7), Amount: 40, Description: "CITY WATER"},
+		{Row: 3, Date: d(10), Amount: 42, Description: "CITY WATER"},
+	}
+	candidates := []BackfillCandidate{
+		{AssignmentID: 1, BillName: "Water", Date: d(10)},
+		{AssignmentID: 2, BillName: "Water", Date: d(4)},
+	}
+
+	matches, unmatched := MatchBackfill(txns, candidates, 5)
+	if len(unmatched) != 0 || len(matches) != 2 {
+		t.Fatalf("expected both payments matched, got %+v, unmatched %+v", matches, unmatched)
+	}
+	if matches[0].Row != 2 || matches[0].AssignmentID != 2 || matches[1].Row != 3 || matches[1].AssignmentID != 1 {
+		t.Errorf("unexpected pairing: %+v", matches)
+	}
+}
+
+func TestMatchBackfill_CompetingTransactions(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	// Two payments compete for one assignment; the closer one settles it even
+	// though it comes later in the file
+	txns := []BankTransaction{
+		{Row: 2, Date: d(6), Amount: 55, Description: "GAS UTILITY"},
+		{Row: 3, Date: d(9), Amount: 58, Description: "GAS UTILITY"},
+	}
+	candidates := []BackfillCandidate{{AssignmentID: 7, BillName: "Gas", Date: d(10)}}
+
+	matches, unmatched := MatchBackfill(txns, candidates, 5)
+	if len(matches) != 1 || matches[0].Row != 3 || matches[0].Amount != 58 {
+		t.Fatalf("expected the closer payment to settle the bill, got %+v", matches)
+	}
+	if len(unmatched) != 1 || unmatched[0].Row != 2 {
+		t.Errorf("expected the farther payment unmatched, got %+v", unmatched)
+	}
+}
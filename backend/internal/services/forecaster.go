@@ -0,0 +1,117 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ForecastFlow is one projected movement of money: a paycheck (positive) or a
+// bill payment (negative).
+type ForecastFlow struct {
+	Date   time.Time
+	Amount float64
+	Kind   string // income, income_event, bill
+	Label  string // income source, event or bill name
+	RefID  int    // pay period, income event or assignment ID
+}
+
+// ForecastItem is a flow as reported on its day.
+type ForecastItem struct {
+	Kind   string  `json:"kind"`
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+	RefID  int     `json:"ref_id"`
+}
+
+// ForecastDay is the projected end-of-day balance for one date.
+type ForecastDay struct {
+	Date     string         `json:"date"` // YYYY-MM-DD
+	Income   float64        `json:"income"`
+	Outflows float64        `json:"outflows"` // positive total of the day's payments
+	Balance  float64        `json:"balance"`
+	Negative bool           `json:"negative"`
+	Items    []ForecastItem `json:"items,omitempty"`
+}
+
+// ForecastResult is a day-by-day balance projection.
+type ForecastResult struct {
+	StartingBalance   float64       `json:"starting_balance"`
+	EndingBalance     float64       `json:"ending_balance"`
+	LowestBalance     float64       `json:"lowest_balance"`
+	LowestDate        string        `json:"lowest_date"`
+	NegativeDays      int           `json:"negative_days"`
+	FirstNegativeDate *string       `json:"first_negative_date"`
+	Days              []ForecastDay `json:"days"`
+}
+
+// Forecast projects the balance for every day from from to to inclusive,
+// starting from startingBalance at the beginning of from. Flows outside the
+// range are ignored; a day's flows are listed income first, then by amount.
+func Forecast(startingBalance float64, from, to time.Time, flows []ForecastFlow) ForecastResult {
+	from = truncateDay(from)
+	to = truncateDay(to)
+
+	byDay := make(map[time.Time][]ForecastFlow)
+	for _, f := range flows {
+		d := truncateDay(f.Date)
+		if d.Before(from) || d.After(to) {
+			continue
+		}
+		byDay[d] = append(byDay[d], f)
+	}
+
+	result := ForecastResult{
+		StartingBalance: startingBalance,
+		LowestBalance:   startingBalance,
+		LowestDate:      from.Format("2006-01-02"),
+		Days:            []ForecastDay{},
+	}
+
+	balance := startingBalance
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := ForecastDay{Date: d.Format("2006-01-02")}
+
+		dayFlows := byDay[d]
+		sort.SliceStable(dayFlows, func(i, j int) bool { return dayFlows[i].Amount > dayFlows[j].Amount })
+		for _, f := range dayFlows {
+			if f.Amount >= 0 {
+				day.Income += f.Amount
+			} else {
+				day.Outflows -= f.Amount
+			}
+			balance += f.Amount
+			day.Items = append(day.Items, ForecastItem{Kind: f.Kind, Label: f.Label, Amount: roundMoney(f.Amount), RefID: f.RefID})
+		}
+
+		balance = roundMoney(balance)
+		day.Income = roundMoney(day.Income)
+		day.Outflows = roundMoney(day.Outflows)
+		day.Balance = balance
+		day.Negative = balance < 0
+
+		if day.Negative {
+			result.NegativeDays++
+			if result.FirstNegativeDate == nil {
+				date := day.Date
+				result.FirstNegativeDate = &date
+			}
+		}
+		if balance < result.LowestBalance {
+			result.LowestBalance = balance
+			result.LowestDate = day.Date
+		}
+		result.Days = append(result.Days, day)
+	}
+
+	result.EndingBalance = balance
+	return result
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecast_RunningBalance(t *testing.T) {
+	flows := []ForecastFlow{
+		{Date: date(2026, time.March, 2), Amount: -1200, Kind: "bill", Label: "Rent", RefID: 1},
+		{Date: date(2026, time.March, 2), Amount: 2000, Kind: "income", Label: "Acme", RefID: 10},
+		{Date: date(2026, time.March, 4), Amount: -80.25, Kind: "bill", Label: "Power", RefID: 2},
+	}
+
+	result := Forecast(100, date(2026, time.March, 1), date(2026, time.March, 4), flows)
+
+	if len(result.Days) != 4 {
+		t.Fatalf("expected 4 days, got %d", len(result.Days))
+	}
+	want := []float64{100, 900, 900, 819.75}
+	for i, d := range result.Days {
+		if d.Balance != want[i] {
+			t.Errorf("day %s balance = %.2f, want %.2f", d.Date, d.Balance, want[i])
+		}
+	}
+
+	mar2 := result.Days[1]
+	if mar2.Income != 2000 || mar2.Outflows != 1200 {
+		t.Errorf("2026-03-02 income/outflows = %.2f/%.2f, want 2000/1200", mar2.Income, mar2.Outflows)
+	}
+	if len(mar2.Items) != 2 || mar2.Items[0].Kind != "income" {
+		t.Errorf("expected income listed before bills, got %+v", mar2.Items)
+	}
+	if result.EndingBalance != 819.75 {
+		t.Errorf("ending balance = %.2f, want 819.75", result.EndingBalance)
+	}
+	if result.NegativeDays != 0 || result.FirstNegativeDate != nil {
+		t.Errorf("expected no negative days, got %d (first %v)", result.NegativeDays, result.FirstNegativeDate)
+	}
+}
+
+func TestForecast_FlagsNegativeDays(t *testing.T) {
+	flows := []ForecastFlow{
+		{Date: date(2026, time.March, 2), Amount: -500, Kind: "bill", Label: "Car"},
+		{Date: date(2026, time.March, 4), Amount: 1000, Kind: "income", Label: "Acme"},
+	}
+
+	result := Forecast(300, date(2026, time.March, 1), date(2026, time.March, 5), flows)
+
+	if result.NegativeDays != 2 {
+		t.Errorf("negative days = %d, want 2", result.NegativeDays)
+	}
+	if result.FirstNegativeDate == nil || *result.FirstNegativeDate != "2026-03-02" {
+		t.Errorf("first negative date = %v, want 2026-03-02", result.FirstNegativeDate)
+	}
+	if !result.Days[1].Negative || !result.Days[2].Negative || result.Days[3].Negative {
+		t.Errorf("unexpected negative flags: %+v", result.Days)
+	}
+	if result.LowestBalance != -200 || result.LowestDate != "2026-03-02" {
+		t.Errorf("lowest = %.2f on %s, want -200 on 2026-03-02", result.LowestBalance, result.LowestDate)
+	}
+}
+
+func TestForecast_IgnoresFlowsOutsideRange(t *testing.T) {
+	flows := []ForecastFlow{
+		{Date: date(2026, time.February, 28), Amount: -50, Kind: "bill"},
+		{Date: date(2026, time.March, 1).Add(15 * time.Hour), Amount: -25, Kind: "bill"},
+		{Date: date(2026, time.March, 3), Amount: -75, Kind: "bill"},
+	}
+
+	result := Forecast(100, date(2026, time.March, 1), date(2026, time.March, 2), flows)
+
+	if result.EndingBalance != 75 {
+		t.Errorf("ending balance = %.2f, want 75", result.EndingBalance)
+	}
+}
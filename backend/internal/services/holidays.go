@@ -0,0 +1,81 @@
+package services
+
+import "time"
+
+// HolidayCalendar reports the days payroll can't be paid on besides weekends.
+type HolidayCalendar interface {
+	IsHoliday(d time.Time) bool
+}
+
+// DefaultHolidayCalendar is used when a schedule doesn't name one.
+const DefaultHolidayCalendar = "us_federal"
+
+// NoHolidays treats every weekday as a business day.
+type NoHolidays struct{}
+
+func (NoHolidays) IsHoliday(time.Time) bool { return false }
+
+// USFederalHolidays are the days US banks close, per the Federal Reserve:
+// the eleven federal holidays, with one falling on a Sunday observed the
+// Monday after. Unlike federal offices, banks don't close the Friday before
+// a Saturday holiday.
+type USFederalHolidays struct{}
+
+func (USFederalHolidays) IsHoliday(d time.Time) bool {
+	year, month, day := d.Date()
+	for _, h := range usFederalHolidays(year) {
+		if h.month == month && h.day == day {
+			return true
+		}
+	}
+	return false
+}
+
+type monthDay struct {
+	month time.Month
+	day   int
+}
+
+// usFederalHolidays lists the observed bank holidays of a year.
+func usFederalHolidays(year int) []monthDay {
+	fixed := []monthDay{
+		{time.January, 1},
+		{time.July, 4},
+		{time.November, 11},
+		{time.December, 25},
+	}
+	if year >= 2021 {
+		fixed = append(fixed, monthDay{time.June, 19})
+	}
+
+	var days []monthDay
+	for _, h := range fixed {
+		d := time.Date(year, h.month, h.day, 0, 0, 0, 0, time.UTC)
+		if d.Weekday() == time.Sunday {
+			d = d.AddDate(0, 0, 1)
+		}
+		days = append(days, monthDay{d.Month(), d.Day()})
+	}
+	return append(days,
+		nthWeekday(year, time.January, time.Monday, 3),    // Martin Luther King Jr. Day
+		nthWeekday(year, time.February, time.Monday, 3),   // Washington's Birthday
+		lastWeekday(year, time.May, time.Monday),          // Memorial Day
+		nthWeekday(year, time.September, time.Monday, 1),  // Labor Day
+		nthWeekday(year, time.October, time.Monday, 2),    // Columbus Day
+		nthWeekday(year, time.November, time.Thursday, 4), // Thanksgiving
+	)
+}
+
+// nthWeekday is the nth (1-based) weekday of a month.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) monthDay {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return monthDay{month, 1 + offset + 7*(n-1)}
+}
+
+// lastWeekday is the last weekday of a month.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) monthDay {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return monthDay{month, last.Day() - offset}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUSFederalHolidays(t *testing.T) {
+	tests := []struct {
+		day  time.Time
+		want bool
+	}{
+		{date(2026, time.January, 1), true},
+		{date(2026, time.January, 19), true},   // MLK Day, third Monday
+		{date(2026, time.May, 25), true},       // Memorial Day, last Monday
+		{date(2026, time.November, 26), true},  // Thanksgiving, fourth Thursday
+		{date(2026, time.July, 3), false},      // July 4 is a Saturday; banks don't close the Friday
+		{date(2022, time.June, 20), true},      // Juneteenth on a Sunday, observed Monday
+		{date(2020, time.June, 19), false},     // before Juneteenth was a holiday
+		{date(2022, time.December, 26), true},  // Christmas on a Sunday, observed Monday
+		{date(2026, time.December, 24), false}, // Christmas Eve
+	}
+	cal := USFederalHolidays{}
+	for _, tt := range tests {
+		if got := cal.IsHoliday(tt.day); got != tt.want {
+			t.Errorf("IsHoliday(%s) = %v, want %v", tt.day.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
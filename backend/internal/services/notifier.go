@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReminderItem is one bill occurrence listed in a reminder digest.
+type ReminderItem struct {
+	AssignmentID int
+	Name         string
+	Date         time.Time // due date, or the pay date for overdue items without one
+	Amount       float64
+}
+
+// DocumentReminder is a stored document listed in a digest as its expiry
+// date nears.
+type DocumentReminder struct {
+	DocumentID int
+	Title      string
+	Kind       string
+	ExpiresOn  time.Time
+}
+
+// ReminderDigest is what a user is reminded about on one day.
+type ReminderDigest struct {
+	AsOf      time.Time
+	DaysAhead int
+	Upcoming  []ReminderItem     // due within DaysAhead days
+	Overdue   []ReminderItem     // still pending after their pay date
+	Expiring  []DocumentReminder // within their own remind_days of expiring
+}
+
+// Empty reports whether the digest has nothing worth sending.
+func (d ReminderDigest) Empty() bool {
+	return len(d.Upcoming) == 0 && len(d.Overdue) == 0 && len(d.Expiring) == 0
+}
+
+// FormatDigest renders a digest as a plain-text email.
+func FormatDigest(d ReminderDigest) (subject, body string) {
+	subject = fmt.Sprintf("Bills: %d due in the next %d days", len(d.Upcoming), d.DaysAhead)
+	if len(d.Overdue) > 0 {
+		subject += fmt.Sprintf(", %d overdue", len(d.Overdue))
+	}
+	if len(d.Expiring) > 0 {
+		subject += fmt.Sprintf(", %d documents expiring", len(d.Expiring))
+	}
+
+	var b strings.Builder
+	section := func(title string, items []ReminderItem) {
+		if len(items) == 0 {
+			return
+		}
+		total := 0.0
+		fmt.Fprintf(&b, "%s\n", title)
+		for _, it := range items {
+			fmt.Fprintf(&b, "  %s  %-30s $%.2f\n", it.Date.Format("Mon Jan 2"), it.Name, it.Amount)
+			total += it.Amount
+		}
+		fmt.Fprintf(&b, "  Total: $%.2f\n\n", roundMoney(total))
+	}
+	section("Overdue (still pending after their pay date):", d.Overdue)
+	section(fmt.Sprintf("Due in the next %d days:", d.DaysAhead), d.Upcoming)
+	if len(d.Expiring) > 0 {
+		fmt.Fprintf(&b, "Documents expiring soon:\n")
+		for _, doc := range d.Expiring {
+			fmt.Fprintf(&b, "  %s  %s (%s)\n", doc.ExpiresOn.Format("Mon Jan 2"), doc.Title, doc.Kind)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+	fmt.Fprintf(&b, "Sent %s. Change reminder settings under Notifications.\n", d.AsOf.Format("2006-01-02"))
+	return subject, b.String()
+}
+
+// Mailer sends a plain-text email.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends mail through an SMTP relay, authenticating with PLAIN when
+// a username is set. net/smtp upgrades to STARTTLS when the server offers it.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{Host: host, Port: port, Username: username, Password: password, From: from}
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("recipient and subject must be a single line")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	msg := "From: " + m.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	return smtp.SendMail(addr, auth, m.From, []string{to}, []byte(msg))
+}
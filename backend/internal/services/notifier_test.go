@@ -0,0 +1,49 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDigest(t *testing.T) {
+	d := ReminderDigest{
+		AsOf:      date(2026, time.March, 10),
+		DaysAhead: 3,
+		Upcoming: []ReminderItem{
+			{Name: "Phone", Date: date(2026, time.March, 11), Amount: 45.5},
+			{Name: "Power", Date: date(2026, time.March, 12), Amount: 80.25},
+		},
+		Overdue:  []ReminderItem{{Name: "Rent", Date: date(2026, time.March, 1), Amount: 1200}},
+		Expiring: []DocumentReminder{{Title: "Car insurance", Kind: "insurance", ExpiresOn: date(2026, time.April, 1)}},
+	}
+
+	subject, body := FormatDigest(d)
+
+	if subject != "Bills: 2 due in the next 3 days, 1 overdue, 1 documents expiring" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "Total: $125.75") || !strings.Contains(body, "Total: $1200.00") {
+		t.Errorf("expected section totals in body:\n%s", body)
+	}
+	if strings.Index(body, "Rent") > strings.Index(body, "Phone") {
+		t.Errorf("expected overdue bills listed first:\n%s", body)
+	}
+	if !strings.Contains(body, "Wed Apr 1  Car insurance (insurance)") {
+		t.Errorf("expected the expiring document in body:\n%s", body)
+	}
+}
+
+func TestReminderDigestEmpty(t *testing.T) {
+	d := ReminderDigest{Expiring: []DocumentReminder{{Title: "Lease"}}}
+	if d.Empty() {
+		t.Error("expected a digest with only an expiring document to be sent")
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	m := NewSMTPMailer("localhost", 25, "", "", "budget@example.com")
+	if err := m.Send("me@example.com\r\nBcc: x@example.com", "hi", "body"); err == nil {
+		t.Error("expected a multi-line recipient to be rejected")
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ObligationWindow is how far back a rise in monthly obligations is measured.
+const ObligationWindow = 90 * 24 * time.Hour
+
+// MonthlyObligation is what a recurring bill costs per month, averaged over a
+// year: a quarterly bill counts a third of its amount, a biweekly one 26/12
+// of it. A monthly bill's per-month amounts replace its default in the months
+// they name, and a seasonal bill only counts the months it is active in.
+func MonthlyObligation(recurrence string, defaultAmount *float64, activeMonths []int, monthlyAmounts map[int]float64) float64 {
+	base := 0.0
+	if defaultAmount != nil {
+		base = *defaultAmount
+	}
+
+	switch recurrence {
+	case "quarterly":
+		return base * 4 / 12
+	case "annual":
+		return base / 12
+	case "biweekly":
+		months := 12
+		if len(activeMonths) > 0 {
+			months = len(activeMonths)
+		}
+		return base * 26 / 12 * float64(months) / 12
+	}
+
+	months := activeMonths
+	if len(months) == 0 {
+		months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	}
+	var year float64
+	for _, m := range months {
+		if amt, ok := monthlyAmounts[m]; ok {
+			year += amt
+		} else {
+			year += base
+		}
+	}
+	return year / 12
+}
+
+// ObligationPoint is the monthly obligations total recorded on a day.
+type ObligationPoint struct {
+	Date    time.Time
+	Total   float64
+	Alerted bool // an alert was sent for the rise to this total
+}
+
+// ObligationRise compares the current total against the lowest total in
+// history, which should hold the snapshots of the last ObligationWindow
+// oldest first. Snapshots before the latest alerted one are ignored, so a
+// rise is alerted once and the next alert needs a further rise from the new
+// level. It reports the baseline, the percentage change and whether it
+// exceeds percent.
+func ObligationRise(history []ObligationPoint, current, percent float64) (ObligationPoint, float64, bool) {
+	start := 0
+	for i, p := range history {
+		if p.Alerted {
+			start = i
+		}
+	}
+
+	var baseline ObligationPoint
+	found := false
+	for _, p := range history[start:] {
+		if !found || p.Total < baseline.Total {
+			baseline = p
+			found = true
+		}
+	}
+	if !found || baseline.Total <= 0 {
+		return baseline, 0, false
+	}
+
+	change := (current - baseline.Total) / baseline.Total * 100
+	change = math.Round(change*100) / 100
+	return baseline, change, percent > 0 && change > percent
+}
+
+// FormatObligationRise describes a rise the way the alert states it.
+func FormatObligationRise(change float64, since, now time.Time) string {
+	when := "this quarter"
+	if days := int(now.Sub(since).Hours() / 24); days < 80 {
+		when = fmt.Sprintf("in the last %d days", days)
+	}
+	return fmt.Sprintf("Your fixed costs rose %.0f%% %s", change, when)
+}
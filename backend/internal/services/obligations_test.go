@@ -0,0 +1,77 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMonthlyObligation(t *testing.T) {
+	amt := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name       string
+		recurrence string
+		amount     *float64
+		active     []int
+		monthly    map[int]float64
+		want       float64
+	}{
+		{"monthly", "monthly", amt(100), nil, nil, 100},
+		{"quarterly", "quarterly", amt(300), nil, nil, 100},
+		{"annual", "annual", amt(1200), nil, nil, 100},
+		{"biweekly", "biweekly", amt(120), nil, nil, 260},
+		{"seasonal", "monthly", amt(120), []int{6, 7, 8}, nil, 30},
+		{"per-month amounts", "monthly", amt(100), nil, map[int]float64{1: 220, 2: 220}, 120},
+		{"no amount", "monthly", nil, nil, nil, 0},
+	}
+	for _, c := range cases {
+		got := MonthlyObligation(c.recurrence, c.amount, c.active, c.monthly)
+		if math.Abs(got-c.want) > 0.001 {
+			t.Errorf("%s: got %.2f, want %.2f", c.name, got, c.want)
+		}
+	}
+}
+
+func TestObligationRise(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC) }
+	history := []ObligationPoint{
+		{Date: d(1), Total: 2000},
+		{Date: d(5), Total: 1900},
+		{Date: d(9), Total: 2050},
+	}
+
+	baseline, change, alert := ObligationRise(history, 2128, 10)
+	if !baseline.Date.Equal(d(5)) || change != 12 || !alert {
+		t.Errorf("expected a 12%% rise from Jan 5, got %v %.2f %v", baseline.Date, change, alert)
+	}
+
+	if _, _, alert := ObligationRise(history, 2050, 10); alert {
+		t.Error("expected no alert for a rise under the threshold")
+	}
+	if _, _, alert := ObligationRise(history, 2128, 0); alert {
+		t.Error("expected a zero percentage to turn alerts off")
+	}
+
+	// After an alert the rise is measured from the alerted level
+	history[2] = ObligationPoint{Date: d(9), Total: 2128, Alerted: true}
+	baseline, change, alert = ObligationRise(history, 2200, 10)
+	if !baseline.Date.Equal(d(9)) || alert {
+		t.Errorf("expected the alerted snapshot as the baseline, got %v %.2f %v", baseline.Date, change, alert)
+	}
+}
+
+func TestObligationRise_NoHistory(t *testing.T) {
+	if _, _, alert := ObligationRise(nil, 500, 10); alert {
+		t.Error("expected no alert without history")
+	}
+}
+
+func TestFormatObligationRise(t *testing.T) {
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	if got := FormatObligationRise(12, now.AddDate(0, 0, -90), now); got != "Your fixed costs rose 12% this quarter" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if got := FormatObligationRise(15.4, now.AddDate(0, 0, -30), now); got != "Your fixed costs rose 15% in the last 30 days" {
+		t.Errorf("unexpected message %q", got)
+	}
+}
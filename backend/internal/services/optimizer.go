@@ -1,6 +1,8 @@
 package services
 
 import (
+	"fmt"
+	"math"
 	"sort"
 )
 
@@ -22,7 +24,16 @@ type OptPeriod struct {
 type OptAssignment struct {
 	BillID       int
 	PeriodID     int
-	AssignmentID int // DB ID of the bill_assignment row
+	AssignmentID int      // DB ID of the bill_assignment row
+	Amount       *float64 // this assignment's own amount; nil uses the bill's
+}
+
+// amount is what the assignment takes from its period.
+func (a OptAssignment) amount(bill OptBill) float64 {
+	if a.Amount != nil {
+		return *a.Amount
+	}
+	return bill.Amount
 }
 
 type Suggestion struct {
@@ -37,11 +48,85 @@ type Suggestion struct {
 	Reason       string  `json:"reason"`
 }
 
+// SplitSuggestion pays one assignment from two periods: Parts[0] is what
+// stays in the assignment's period and Parts[1] what moves to the other.
+type SplitSuggestion struct {
+	AssignmentID int         `json:"assignment_id"`
+	BillID       int         `json:"bill_id"`
+	BillName     string      `json:"bill_name"`
+	Amount       float64     `json:"amount"` // the whole bill
+	Parts        []SplitPart `json:"parts"`
+	Reason       string      `json:"reason"`
+}
+
+type SplitPart struct {
+	PeriodID int     `json:"period_id"`
+	PayDate  string  `json:"pay_date"`
+	Amount   float64 `json:"amount"`
+	Percent  float64 `json:"percent"` // of the whole bill, to one decimal
+}
+
 type OptimizationResult struct {
-	Suggestions         []Suggestion `json:"suggestions"`
-	CurrentMinBalance   float64      `json:"current_min_balance"`
-	OptimizedMinBalance float64      `json:"optimized_min_balance"`
-	Improvement         float64      `json:"improvement"`
+	PlanID              int               `json:"plan_id,omitempty"` // set once the handler saves the suggestions
+	Suggestions         []Suggestion      `json:"suggestions"`
+	Splits              []SplitSuggestion `json:"splits,omitempty"` // only with OptOptions.AllowSplits
+	CurrentMinBalance   float64           `json:"current_min_balance"`
+	OptimizedMinBalance float64           `json:"optimized_min_balance"`
+	Improvement         float64           `json:"improvement"`
+	Balances            []PlanBalance     `json:"balances"`                // every period before and after the plan
+	BelowFloor          []PeriodBalance   `json:"below_floor,omitempty"`   // periods left under OptOptions.MinBalance
+	CurrentScore        *PlanScore        `json:"current_score,omitempty"` // only with OptOptions.Weights
+	OptimizedScore      *PlanScore        `json:"optimized_score,omitempty"`
+	Trace               []TraceStep       `json:"trace,omitempty"` // only with OptOptions.Debug
+}
+
+// OptOptions tunes a single optimizer run.
+type OptOptions struct {
+	Debug      bool     // record a TraceStep per iteration
+	MinBalance *float64 // no move may leave its target period below this
+	// AllowSplits lets the optimizer, once no whole-bill move helps, suggest
+	// paying part of a bill from a later or earlier paycheck
+	AllowSplits bool
+	// Weights, when set, replaces the greedy $50-threshold search with one
+	// that makes whichever single move most improves the weighted PlanScore
+	Weights *OptWeights
+	// Layout warm-starts the run from the last accepted plan: the period it
+	// left each assignment in, by assignment ID. Assignments that have drifted
+	// start back there, and ones in place move only when no other move helps,
+	// so a new bill adjusts the plan instead of reshuffling it
+	Layout map[int]int
+}
+
+// TraceStep explains one optimizer iteration: the periods it compared, the move
+// it chose (nil when it stopped instead) and the balances that resulted.
+type TraceStep struct {
+	Iteration       int              `json:"iteration"`
+	TightPeriodID   int              `json:"tight_period_id"`
+	TightBalance    float64          `json:"tight_balance"`
+	SurplusPeriodID int              `json:"surplus_period_id"`
+	SurplusBalance  float64          `json:"surplus_balance"`
+	Candidates      int              `json:"candidates"`            // assignments in the tight period that could move
+	BelowFloor      int              `json:"below_floor,omitempty"` // candidates rejected for breaching the floor
+	Move            *Suggestion      `json:"move,omitempty"`
+	Split           *SplitSuggestion `json:"split,omitempty"`
+	Score           *PlanScore       `json:"score,omitempty"` // after the move, weighted runs only
+	Reason          string           `json:"reason"`
+	Balances        []PeriodBalance  `json:"balances"` // after the move
+}
+
+type PeriodBalance struct {
+	PeriodID int     `json:"period_id"`
+	PayDate  string  `json:"pay_date"`
+	Balance  float64 `json:"balance"`
+}
+
+// PlanBalance is a period's balance before and after the suggested moves
+// and splits.
+type PlanBalance struct {
+	PeriodID int     `json:"period_id"`
+	PayDate  string  `json:"pay_date"`
+	Before   float64 `json:"before"`
+	After    float64 `json:"after"`
 }
 
 type Optimizer struct{}
@@ -54,8 +139,13 @@ func NewOptimizer() *Optimizer {
 // currentAssignments is a slice of all bill-to-period assignments (a bill may appear multiple
 // times across different periods, e.g. once per month).
 func (o *Optimizer) Optimize(bills []OptBill, periods []OptPeriod, currentAssignments []OptAssignment) *OptimizationResult {
+	return o.OptimizeWithOptions(bills, periods, currentAssignments, OptOptions{})
+}
+
+// OptimizeWithOptions is Optimize with per-run options.
+func (o *Optimizer) OptimizeWithOptions(bills []OptBill, periods []OptPeriod, currentAssignments []OptAssignment, opts OptOptions) *OptimizationResult {
 	if len(bills) == 0 || len(periods) == 0 {
-		return &OptimizationResult{Suggestions: []Suggestion{}}
+		return &OptimizationResult{Suggestions: []Suggestion{}, Balances: []PlanBalance{}}
 	}
 
 	// Sort periods by pay date
@@ -68,34 +158,149 @@ func (o *Optimizer) Optimize(bills []OptBill, periods []OptPeriod, currentAssign
 	optimized := make([]OptAssignment, len(currentAssignments))
 	copy(optimized, currentAssignments)
 
+	restored := warmStart(bills, periods, optimized, opts.Layout)
+
+	var suggestions []Suggestion
+	var trace []TraceStep
+	if opts.Weights != nil {
+		// Suggests where each assignment ends up, restored ones included
+		suggestions, trace = scoredMoves(bills, periods, currentAssignments, optimized, opts)
+	} else {
+		suggestions, trace = greedyMoves(bills, periods, optimized, opts)
+		suggestions = append(restored, suggestions...)
+	}
+
+	var splits []SplitSuggestion
+	if opts.AllowSplits {
+		var splitTrace []TraceStep
+		splits, splitTrace = splitBills(bills, periods, optimized, opts, len(trace))
+		trace = append(trace, splitTrace...)
+	}
+
+	// Calculate optimized minimum balance
+	optimizedBalances := calcBalances(bills, periods, optimized)
+	applySplits(optimizedBalances, splits)
+	optimizedMin := minBalance(optimizedBalances)
+
+	currentBalances := calcBalances(bills, periods, currentAssignments)
+	balances := make([]PlanBalance, 0, len(periods))
+	for _, p := range periods {
+		balances = append(balances, PlanBalance{
+			PeriodID: p.ID, PayDate: p.PayDate, Before: currentBalances[p.ID], After: optimizedBalances[p.ID],
+		})
+	}
+
+	var belowFloor []PeriodBalance
+	if opts.MinBalance != nil {
+		for _, pb := range periodBalances(periods, optimizedBalances) {
+			if pb.Balance < *opts.MinBalance {
+				belowFloor = append(belowFloor, pb)
+			}
+		}
+	}
+
+	if suggestions == nil {
+		suggestions = []Suggestion{}
+	}
+
+	var currentScore, optimizedScore *PlanScore
+	if opts.Weights != nil {
+		cur := scorePlan(bills, periods, currentAssignments, currentAssignments, nil, *opts.Weights)
+		opt := scorePlan(bills, periods, currentAssignments, optimized, splits, *opts.Weights)
+		currentScore, optimizedScore = &cur, &opt
+	}
+
+	return &OptimizationResult{
+		Suggestions:         suggestions,
+		Splits:              splits,
+		CurrentMinBalance:   currentMin,
+		OptimizedMinBalance: optimizedMin,
+		Improvement:         optimizedMin - currentMin,
+		Balances:            balances,
+		BelowFloor:          belowFloor,
+		CurrentScore:        currentScore,
+		OptimizedScore:      optimizedScore,
+		Trace:               trace,
+	}
+}
+
+// warmStartReason explains a move back to the last accepted layout.
+const warmStartReason = "Warm start: back where the last accepted plan put it"
+
+// warmStart moves each assignment in optimized back to the period layout has
+// for it, as long as that period is in the run, can pay the bill before its
+// due day and doesn't hold the bill already.
+func warmStart(bills []OptBill, periods []OptPeriod, optimized []OptAssignment, layout map[int]int) []Suggestion {
+	var suggestions []Suggestion
+	for i, a := range optimized {
+		to, ok := layout[a.AssignmentID]
+		if !ok || to == a.PeriodID {
+			continue
+		}
+		bill := findBill(bills, a.BillID)
+		fromPeriod := findPeriod(periods, a.PeriodID)
+		toPeriod := findPeriod(periods, to)
+		if bill == nil || fromPeriod == nil || toPeriod == nil ||
+			!canPayFrom(toPeriod.PayDay, bill.DueDay) || hasBillInPeriod(optimized, a.BillID, to) {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			AssignmentID: a.AssignmentID,
+			BillID:       bill.ID,
+			BillName:     bill.Name,
+			FromPeriodID: fromPeriod.ID,
+			ToPeriodID:   toPeriod.ID,
+			FromPeriod:   fromPeriod.PayDate,
+			ToPeriod:     toPeriod.PayDate,
+			Amount:       a.amount(*bill),
+			Reason:       warmStartReason,
+		})
+		optimized[i].PeriodID = to
+	}
+	return suggestions
+}
+
+// settled reports whether a is where the last accepted layout left it.
+func settled(layout map[int]int, a OptAssignment) bool {
+	to, ok := layout[a.AssignmentID]
+	return ok && to == a.PeriodID
+}
+
+// greedyMoves repeatedly moves the largest bill it can from the tightest
+// period to the most surplus one, until they are within $50 of each other.
+// Assignments the last accepted layout settled are only moved when no other
+// can be. It updates optimized in place.
+func greedyMoves(bills []OptBill, periods []OptPeriod, optimized []OptAssignment, opts OptOptions) ([]Suggestion, []TraceStep) {
 	var suggestions []Suggestion
+	var trace []TraceStep
 
 	for iterations := 0; iterations < 100; iterations++ {
 		// Recalculate balances
 		optBalances := calcBalances(bills, periods, optimized)
 
-		// Find tightest and most surplus periods
-		tightID, surplusID := 0, 0
-		tightBal := 1e18
-		surplusBal := -1e18
-		for _, p := range periods {
-			if optBalances[p.ID] < tightBal {
-				tightBal = optBalances[p.ID]
-				tightID = p.ID
-			}
-			if optBalances[p.ID] > surplusBal {
-				surplusBal = optBalances[p.ID]
-				surplusID = p.ID
-			}
+		tightID, tightBal, surplusID, surplusBal := extremes(periods, optBalances)
+
+		step := TraceStep{
+			Iteration:       iterations + 1,
+			TightPeriodID:   tightID,
+			TightBalance:    tightBal,
+			SurplusPeriodID: surplusID,
+			SurplusBalance:  surplusBal,
 		}
 
 		if tightID == surplusID || surplusBal-tightBal < 50 {
+			if opts.Debug {
+				step.Reason = fmt.Sprintf("Stop: gap between tightest and most surplus period is $%.2f, under the $50 threshold", surplusBal-tightBal)
+				step.Balances = periodBalances(periods, optBalances)
+				trace = append(trace, step)
+			}
 			break // Already balanced enough
 		}
 
 		// Find the best assignment in the tight period that can move to surplus
 		bestImprovement := 0.0
 		bestIdx := -1
+		bestSettled := false
 		surplusPeriod := findPeriod(periods, surplusID)
 		if surplusPeriod == nil {
 			break
@@ -115,13 +320,37 @@ func (o *Optimizer) Optimize(bills []OptBill, periods []OptPeriod, currentAssign
 			if hasBillInPeriod(optimized, a.BillID, surplusID) {
 				continue
 			}
-			if bill.Amount > bestImprovement {
-				bestImprovement = bill.Amount
+			amount := a.amount(*bill)
+			// In split mode a move that can't raise the minimum, because the
+			// bill is larger than the gap, is left for splitBills instead
+			if opts.AllowSplits && surplusBal-amount <= tightBal {
+				continue
+			}
+			step.Candidates++
+			// A move that would breach the floor is rejected even if it
+			// raises the minimum, e.g. when the tight period is deeply negative
+			if opts.MinBalance != nil && surplusBal-amount < *opts.MinBalance {
+				step.BelowFloor++
+				continue
+			}
+			isSettled := settled(opts.Layout, a)
+			if amount > 0 && (bestIdx < 0 || bestSettled && !isSettled ||
+				isSettled == bestSettled && amount > bestImprovement) {
+				bestImprovement = amount
 				bestIdx = i
+				bestSettled = isSettled
 			}
 		}
 
 		if bestIdx < 0 {
+			if opts.Debug {
+				step.Reason = "Stop: no assignment in the tightest period can be paid from the surplus period before its due day"
+				if step.BelowFloor > 0 {
+					step.Reason = fmt.Sprintf("Stop: every movable assignment in the tightest period would take the surplus period below the $%.2f floor", *opts.MinBalance)
+				}
+				step.Balances = periodBalances(periods, optBalances)
+				trace = append(trace, step)
+			}
 			break // No valid moves
 		}
 
@@ -130,7 +359,7 @@ func (o *Optimizer) Optimize(bills []OptBill, periods []OptPeriod, currentAssign
 		toPeriod := surplusPeriod
 		bill := findBill(bills, optimized[bestIdx].BillID)
 
-		suggestions = append(suggestions, Suggestion{
+		move := Suggestion{
 			AssignmentID: optimized[bestIdx].AssignmentID,
 			BillID:       bill.ID,
 			BillName:     bill.Name,
@@ -138,26 +367,156 @@ func (o *Optimizer) Optimize(bills []OptBill, periods []OptPeriod, currentAssign
 			ToPeriodID:   toPeriod.ID,
 			FromPeriod:   fromPeriod.PayDate,
 			ToPeriod:     toPeriod.PayDate,
-			Amount:       bill.Amount,
+			Amount:       optimized[bestIdx].amount(*bill),
 			Reason:       "Rebalance: move from overloaded to surplus period",
-		})
+		}
+		suggestions = append(suggestions, move)
 
 		optimized[bestIdx].PeriodID = surplusID
+
+		if opts.Debug {
+			step.Move = &move
+			step.Reason = fmt.Sprintf("%s is the largest of %d movable bills in %s (balance $%.2f) payable from %s (balance $%.2f) by due day %d",
+				bill.Name, step.Candidates, fromPeriod.PayDate, tightBal, toPeriod.PayDate, surplusBal, bill.DueDay)
+			step.Balances = periodBalances(periods, calcBalances(bills, periods, optimized))
+			trace = append(trace, step)
+		}
 	}
 
-	// Calculate optimized minimum balance
-	optimizedMin := calcMinBalance(bills, periods, optimized)
+	return suggestions, trace
+}
 
-	if suggestions == nil {
-		suggestions = []Suggestion{}
+// splitBills continues where whole-bill moves stopped: while the tightest
+// and most surplus periods are still $50 or more apart, it splits the largest
+// bill in the tightest period that the surplus period can pay before its due
+// day, moving just enough to even the two out. Each assignment is split at
+// most once, and the moved part respects opts.MinBalance.
+func splitBills(bills []OptBill, periods []OptPeriod, assignments []OptAssignment, opts OptOptions, iteration int) ([]SplitSuggestion, []TraceStep) {
+	var splits []SplitSuggestion
+	var trace []TraceStep
+	split := make(map[int]bool) // index into assignments
+
+	for i := 0; i < 100; i++ {
+		balances := calcBalances(bills, periods, assignments)
+		applySplits(balances, splits)
+		tightID, tightBal, surplusID, surplusBal := extremes(periods, balances)
+
+		step := TraceStep{
+			Iteration:       iteration + i + 1,
+			TightPeriodID:   tightID,
+			TightBalance:    tightBal,
+			SurplusPeriodID: surplusID,
+			SurplusBalance:  surplusBal,
+		}
+		stop := func(reason string) {
+			if opts.Debug {
+				step.Reason = reason
+				step.Balances = periodBalances(periods, balances)
+				trace = append(trace, step)
+			}
+		}
+
+		gap := surplusBal - tightBal
+		if tightID == surplusID || gap < 50 {
+			stop(fmt.Sprintf("Stop splitting: gap between tightest and most surplus period is $%.2f, under the $50 threshold", gap))
+			break
+		}
+
+		// Move half the gap, so both periods end level, unless the floor
+		// allows less
+		share := gap / 2
+		if opts.MinBalance != nil && surplusBal-share < *opts.MinBalance {
+			share = surplusBal - *opts.MinBalance
+		}
+		share = math.Round(share*100) / 100
+
+		surplusPeriod := findPeriod(periods, surplusID)
+		bestIdx := -1
+		for j, a := range assignments {
+			if a.PeriodID != tightID || split[j] {
+				continue
+			}
+			bill := findBill(bills, a.BillID)
+			if bill == nil || share >= a.amount(*bill) {
+				continue
+			}
+			if !canPayFrom(surplusPeriod.PayDay, bill.DueDay) || hasBillInPeriod(assignments, a.BillID, surplusID) {
+				continue
+			}
+			step.Candidates++
+			if bestIdx < 0 || a.amount(*bill) > assignments[bestIdx].amount(*findBill(bills, assignments[bestIdx].BillID)) {
+				bestIdx = j
+			}
+		}
+		if share <= 0 || bestIdx < 0 {
+			stop("Stop splitting: no bill in the tightest period can be split with the surplus period")
+			break
+		}
+
+		bill := findBill(bills, assignments[bestIdx].BillID)
+		amount := assignments[bestIdx].amount(*bill)
+		fromPeriod := findPeriod(periods, tightID)
+		keep := math.Round((amount-share)*100) / 100
+		s := SplitSuggestion{
+			AssignmentID: assignments[bestIdx].AssignmentID,
+			BillID:       bill.ID,
+			BillName:     bill.Name,
+			Amount:       amount,
+			Parts: []SplitPart{
+				{PeriodID: fromPeriod.ID, PayDate: fromPeriod.PayDate, Amount: keep, Percent: math.Round(keep/amount*1000) / 10},
+				{PeriodID: surplusID, PayDate: surplusPeriod.PayDate, Amount: share, Percent: math.Round(share/amount*1000) / 10},
+			},
+			Reason: "Split: no whole bill can move, so pay part from the surplus period",
+		}
+		splits = append(splits, s)
+		split[bestIdx] = true
+
+		if opts.Debug {
+			step.Split = &s
+			step.Reason = fmt.Sprintf("%s is the largest of %d splittable bills in %s (balance $%.2f); $%.2f moves to %s (balance $%.2f)",
+				bill.Name, step.Candidates, fromPeriod.PayDate, tightBal, share, surplusPeriod.PayDate, surplusBal)
+			after := calcBalances(bills, periods, assignments)
+			applySplits(after, splits)
+			step.Balances = periodBalances(periods, after)
+			trace = append(trace, step)
+		}
 	}
 
-	return &OptimizationResult{
-		Suggestions:         suggestions,
-		CurrentMinBalance:   currentMin,
-		OptimizedMinBalance: optimizedMin,
-		Improvement:         optimizedMin - currentMin,
+	return splits, trace
+}
+
+// applySplits shifts each split's moved part between period balances.
+func applySplits(balances map[int]float64, splits []SplitSuggestion) {
+	for _, s := range splits {
+		moved := s.Parts[1]
+		balances[s.Parts[0].PeriodID] += moved.Amount
+		balances[moved.PeriodID] -= moved.Amount
+	}
+}
+
+// extremes finds the periods with the lowest and highest balance.
+func extremes(periods []OptPeriod, balances map[int]float64) (tightID int, tightBal float64, surplusID int, surplusBal float64) {
+	tightBal, surplusBal = 1e18, -1e18
+	for _, p := range periods {
+		if balances[p.ID] < tightBal {
+			tightBal = balances[p.ID]
+			tightID = p.ID
+		}
+		if balances[p.ID] > surplusBal {
+			surplusBal = balances[p.ID]
+			surplusID = p.ID
+		}
+	}
+	return tightID, tightBal, surplusID, surplusBal
+}
+
+// periodBalances lists balances in pay date order for a trace step.
+func periodBalances(periods []OptPeriod, balances map[int]float64) []PeriodBalance {
+	out := make([]PeriodBalance, 0, len(periods))
+	for _, p := range periods {
+		out = append(out, PeriodBalance{PeriodID: p.ID, PayDate: p.PayDate, Balance: balances[p.ID]})
 	}
+	return out
 }
 
 func calcBalances(bills []OptBill, periods []OptPeriod, assignments []OptAssignment) map[int]float64 {
@@ -168,7 +527,7 @@ func calcBalances(bills []OptBill, periods []OptPeriod, assignments []OptAssignm
 	for _, a := range assignments {
 		bill := findBill(bills, a.BillID)
 		if bill != nil {
-			balances[a.PeriodID] -= bill.Amount
+			balances[a.PeriodID] -= a.amount(*bill)
 		}
 	}
 	return balances
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"fmt"
+	"math"
+)
+
+// OptWeights prices each axis of a PlanScore in dollars of score per unit,
+// so a Moves weight of 25 means a move must raise the score by more than $25
+// elsewhere to be worth making. A zero weight ignores that axis.
+type OptWeights struct {
+	MinBalance float64 `json:"min_balance"` // per dollar of the lowest period balance
+	Variance   float64 `json:"variance"`    // per dollar of standard deviation across periods
+	Moves      float64 `json:"moves"`       // per assignment moved or split
+	DueBuffer  float64 `json:"due_buffer"`  // per average day between payday and due day
+}
+
+// PlanScore measures a plan on each axis the optimizer weighs. Total is the
+// weighted sum, higher being better.
+type PlanScore struct {
+	MinBalance    float64 `json:"min_balance"`
+	StdDev        float64 `json:"std_dev"`
+	Moves         int     `json:"moves"`
+	DueBufferDays float64 `json:"due_buffer_days"`
+	Total         float64 `json:"total"`
+}
+
+// planScorer scores plans against fixed bills and periods, looking them up
+// by id since a weighted search scores every candidate move.
+type planScorer struct {
+	periods  []OptPeriod
+	bills    map[int]OptBill
+	payDays  map[int]int
+	original []OptAssignment
+	weights  OptWeights
+}
+
+func newPlanScorer(bills []OptBill, periods []OptPeriod, original []OptAssignment, weights OptWeights) *planScorer {
+	s := &planScorer{
+		periods:  periods,
+		bills:    make(map[int]OptBill, len(bills)),
+		payDays:  make(map[int]int, len(periods)),
+		original: original,
+		weights:  weights,
+	}
+	for _, b := range bills {
+		s.bills[b.ID] = b
+	}
+	for _, p := range periods {
+		s.payDays[p.ID] = p.PayDay
+	}
+	return s
+}
+
+func (s *planScorer) balances(assignments []OptAssignment, splits []SplitSuggestion) map[int]float64 {
+	balances := make(map[int]float64, len(s.periods))
+	for _, p := range s.periods {
+		balances[p.ID] = p.Income - p.Assigned
+	}
+	for _, a := range assignments {
+		if b, ok := s.bills[a.BillID]; ok {
+			balances[a.PeriodID] -= a.amount(b)
+		}
+	}
+	applySplits(balances, splits)
+	return balances
+}
+
+func (s *planScorer) score(assignments []OptAssignment, splits []SplitSuggestion) PlanScore {
+	balances := s.balances(assignments, splits)
+
+	var sc PlanScore
+	sc.MinBalance = minBalance(balances)
+	mean := 0.0
+	for _, b := range balances {
+		mean += b
+	}
+	mean /= float64(len(balances))
+	for _, b := range balances {
+		sc.StdDev += (b - mean) * (b - mean)
+	}
+	sc.StdDev = math.Sqrt(sc.StdDev / float64(len(balances)))
+
+	// Moves count against the plan as given, which assignments line up with
+	// index for index
+	for i, a := range assignments {
+		if i < len(s.original) && a.PeriodID != s.original[i].PeriodID {
+			sc.Moves++
+		}
+	}
+	sc.Moves += len(splits)
+
+	// Bills without a due day have no buffer to measure; a due day before
+	// the payday falls in the following month
+	days, n := 0, 0
+	for _, a := range assignments {
+		b, ok := s.bills[a.BillID]
+		if !ok || b.DueDay == 0 {
+			continue
+		}
+		d := b.DueDay - s.payDays[a.PeriodID]
+		if d < 0 {
+			d += 30
+		}
+		days += d
+		n++
+	}
+	if n > 0 {
+		sc.DueBufferDays = float64(days) / float64(n)
+	}
+
+	w := s.weights
+	sc.Total = w.MinBalance*sc.MinBalance - w.Variance*sc.StdDev - w.Moves*float64(sc.Moves) + w.DueBuffer*sc.DueBufferDays
+	return sc
+}
+
+// scorePlan scores one plan; see planScorer.
+func scorePlan(bills []OptBill, periods []OptPeriod, original, assignments []OptAssignment, splits []SplitSuggestion, weights OptWeights) PlanScore {
+	return newPlanScorer(bills, periods, original, weights).score(assignments, splits)
+}
+
+// scoredMoves is the weighted alternative to greedyMoves: each iteration
+// tries every assignment in every other period it can be paid from and makes
+// the move that most raises the plan's score, stopping once none raises it
+// by at least a cent. It updates optimized in place and suggests the net
+// change for each assignment that ends up somewhere new.
+func scoredMoves(bills []OptBill, periods []OptPeriod, original, optimized []OptAssignment, opts OptOptions) ([]Suggestion, []TraceStep) {
+	scorer := newPlanScorer(bills, periods, original, *opts.Weights)
+	var trace []TraceStep
+
+	for iterations := 0; iterations < 100; iterations++ {
+		current := scorer.score(optimized, nil)
+		balances := scorer.balances(optimized, nil)
+		tightID, tightBal, surplusID, surplusBal := extremes(periods, balances)
+
+		step := TraceStep{
+			Iteration:       iterations + 1,
+			TightPeriodID:   tightID,
+			TightBalance:    tightBal,
+			SurplusPeriodID: surplusID,
+			SurplusBalance:  surplusBal,
+		}
+
+		// Assignments the last accepted layout settled are only moved when
+		// moving any other doesn't help
+		bestIdx, bestTo := -1, 0
+		best := current
+		settledIdx, settledTo := -1, 0
+		settledBest := current
+		for i, a := range optimized {
+			bill, ok := scorer.bills[a.BillID]
+			if !ok {
+				continue
+			}
+			from := a.PeriodID
+			if _, ok := scorer.payDays[from]; !ok {
+				continue
+			}
+			for _, p := range periods {
+				if p.ID == from || !canPayFrom(p.PayDay, bill.DueDay) || hasBillInPeriod(optimized, a.BillID, p.ID) {
+					continue
+				}
+				step.Candidates++
+				if opts.MinBalance != nil && balances[p.ID]-a.amount(bill) < *opts.MinBalance {
+					step.BelowFloor++
+					continue
+				}
+				isSettled := settled(opts.Layout, a)
+				optimized[i].PeriodID = p.ID
+				if sc := scorer.score(optimized, nil); isSettled && sc.Total > settledBest.Total {
+					settledBest, settledIdx, settledTo = sc, i, p.ID
+				} else if !isSettled && sc.Total > best.Total {
+					best, bestIdx, bestTo = sc, i, p.ID
+				}
+				optimized[i].PeriodID = from
+			}
+		}
+
+		if bestIdx < 0 || best.Total-current.Total < 0.01 {
+			best, bestIdx, bestTo = settledBest, settledIdx, settledTo
+		}
+		if bestIdx < 0 || best.Total-current.Total < 0.01 {
+			if opts.Debug {
+				step.Reason = fmt.Sprintf("Stop: none of %d candidate moves raises the plan score (%.2f) by a cent or more", step.Candidates, current.Total)
+				step.Score = &current
+				step.Balances = periodBalances(periods, balances)
+				trace = append(trace, step)
+			}
+			break
+		}
+
+		bill := scorer.bills[optimized[bestIdx].BillID]
+		fromPeriod := findPeriod(periods, optimized[bestIdx].PeriodID)
+		toPeriod := findPeriod(periods, bestTo)
+		optimized[bestIdx].PeriodID = bestTo
+
+		if opts.Debug {
+			step.Move = &Suggestion{
+				AssignmentID: optimized[bestIdx].AssignmentID,
+				BillID:       bill.ID,
+				BillName:     bill.Name,
+				FromPeriodID: fromPeriod.ID,
+				ToPeriodID:   toPeriod.ID,
+				FromPeriod:   fromPeriod.PayDate,
+				ToPeriod:     toPeriod.PayDate,
+				Amount:       optimized[bestIdx].amount(bill),
+				Reason:       "Rebalance: best-scoring move",
+			}
+			step.Reason = fmt.Sprintf("Moving %s from %s to %s raises the plan score from %.2f to %.2f, the best of %d candidate moves",
+				bill.Name, fromPeriod.PayDate, toPeriod.PayDate, current.Total, best.Total, step.Candidates)
+			step.Score = &best
+			step.Balances = periodBalances(periods, scorer.balances(optimized, nil))
+			trace = append(trace, step)
+		}
+	}
+
+	// An assignment may move more than once, so suggest only where it ends up
+	var suggestions []Suggestion
+	for i, a := range optimized {
+		if a.PeriodID == original[i].PeriodID {
+			continue
+		}
+		bill := scorer.bills[a.BillID]
+		fromPeriod := findPeriod(periods, original[i].PeriodID)
+		toPeriod := findPeriod(periods, a.PeriodID)
+		reason := "Rebalance: improves the weighted plan score"
+		if settled(opts.Layout, a) {
+			reason = warmStartReason
+		}
+		suggestions = append(suggestions, Suggestion{
+			AssignmentID: a.AssignmentID,
+			BillID:       bill.ID,
+			BillName:     bill.Name,
+			FromPeriodID: fromPeriod.ID,
+			ToPeriodID:   toPeriod.ID,
+			FromPeriod:   fromPeriod.PayDate,
+			ToPeriod:     toPeriod.PayDate,
+			Amount:       a.amount(bill),
+			Reason:       reason,
+		})
+	}
+	return suggestions, trace
+}
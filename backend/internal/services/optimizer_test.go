@@ -2,6 +2,7 @@ package services
 
 import (
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -812,6 +813,309 @@ func TestOptimize_PicksLargestBillFirst(t *testing.T) {
 	}
 }
 
+func TestOptimize_AssignmentAmountOverridesBill(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{
+		{ID: 1, Name: "Power", DueDay: 20, Amount: 100},
+		{ID: 2, Name: "Water", DueDay: 20, Amount: 300},
+	}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 2000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 2000},
+	}
+	// A winter power forecast makes it the larger bill this period
+	forecast := 600.0
+	assignments := []OptAssignment{
+		{BillID: 1, PeriodID: 10, Amount: &forecast}, {BillID: 2, PeriodID: 10},
+	}
+	result := o.Optimize(bills, periods, assignments)
+
+	if result.CurrentMinBalance != 1100 {
+		t.Errorf("expected current min balance 1100 from the forecast amount, got %.2f", result.CurrentMinBalance)
+	}
+	if len(result.Suggestions) == 0 || result.Suggestions[0].BillName != "Power" || result.Suggestions[0].Amount != 600 {
+		t.Errorf("expected Power moved at its forecast amount, got %+v", result.Suggestions)
+	}
+}
+
+func TestOptimize_ReportsBeforeAfterBalances(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{
+		{ID: 1, Name: "Rent", DueDay: 20, Amount: 900},
+		{ID: 2, Name: "Car", DueDay: 20, Amount: 300},
+	}
+	periods := []OptPeriod{
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 1000},
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 1000},
+	}
+	assignments := []OptAssignment{{BillID: 1, PeriodID: 10}, {BillID: 2, PeriodID: 10}}
+	result := o.Optimize(bills, periods, assignments)
+
+	if len(result.Balances) != 2 || result.Balances[0].PeriodID != 10 {
+		t.Fatalf("expected a balance per period in pay date order, got %+v", result.Balances)
+	}
+	first, second := result.Balances[0], result.Balances[1]
+	if first.Before != -200 || second.Before != 1000 {
+		t.Errorf("expected before balances -200 and 1000, got %.2f and %.2f", first.Before, second.Before)
+	}
+	if first.After != result.OptimizedMinBalance && second.After != result.OptimizedMinBalance {
+		t.Errorf("expected the optimized minimum among the after balances, got %+v", result.Balances)
+	}
+	if first.After+second.After != first.Before+second.Before {
+		t.Errorf("moves should not change the total, got %+v", result.Balances)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Optimize: minimum balance floor
+// ---------------------------------------------------------------------------
+
+func TestOptimize_MinBalanceFloorRejectsBreachingMoves(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{
+		{ID: 1, Name: "Rent", DueDay: 20, Amount: 900},
+		{ID: 2, Name: "Car", DueDay: 20, Amount: 300},
+	}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 1000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 1000},
+	}
+	assignments := []OptAssignment{{BillID: 1, PeriodID: 10}, {BillID: 2, PeriodID: 10}}
+
+	// Without a floor, moving Rent lifts the minimum from -200 to 100
+	if result := o.Optimize(bills, periods, assignments); len(result.Suggestions) == 0 || result.Suggestions[0].BillName != "Rent" {
+		t.Fatalf("expected Rent to move without a floor, got %+v", result.Suggestions)
+	}
+
+	// With a $200 floor Rent would leave the 15th at $100, so only Car moves
+	floor := 200.0
+	result := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{Debug: true, MinBalance: &floor})
+	if len(result.Suggestions) != 1 || result.Suggestions[0].BillName != "Car" {
+		t.Fatalf("expected only Car to move, got %+v", result.Suggestions)
+	}
+	if result.OptimizedMinBalance != 100 {
+		t.Errorf("expected optimized min 100, got %f", result.OptimizedMinBalance)
+	}
+	if len(result.BelowFloor) != 1 || result.BelowFloor[0].PeriodID != 10 || result.BelowFloor[0].Balance != 100 {
+		t.Errorf("expected the 1st to be reported below the floor, got %+v", result.BelowFloor)
+	}
+	last := result.Trace[len(result.Trace)-1]
+	if last.Move != nil || last.BelowFloor != 1 || !strings.Contains(last.Reason, "floor") {
+		t.Errorf("expected the run to stop on the floor, got %+v", last)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Optimize: splitting bills
+// ---------------------------------------------------------------------------
+
+func TestOptimize_SplitsBillLargerThanGap(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Rent", DueDay: 20, Amount: 1500}}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 1200},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 800},
+	}
+	assignments := []OptAssignment{{BillID: 1, PeriodID: 10, AssignmentID: 7}}
+
+	// Moving all of Rent would leave the 15th at -700, worse than -300 now
+	result := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{AllowSplits: true, Debug: true})
+	if len(result.Suggestions) != 0 {
+		t.Errorf("expected no whole-bill moves, got %+v", result.Suggestions)
+	}
+	if len(result.Splits) != 1 {
+		t.Fatalf("expected 1 split, got %+v", result.Splits)
+	}
+	split := result.Splits[0]
+	want := []SplitPart{
+		{PeriodID: 10, PayDate: "2025-01-01", Amount: 950, Percent: 63.3},
+		{PeriodID: 20, PayDate: "2025-01-15", Amount: 550, Percent: 36.7},
+	}
+	if split.AssignmentID != 7 || len(split.Parts) != 2 || split.Parts[0] != want[0] || split.Parts[1] != want[1] {
+		t.Errorf("unexpected split: %+v", split)
+	}
+	if result.OptimizedMinBalance != 250 || result.Improvement != 550 {
+		t.Errorf("expected both periods to end at 250, got min %f, improvement %f", result.OptimizedMinBalance, result.Improvement)
+	}
+	if len(result.Trace) == 0 || result.Trace[len(result.Trace)-2].Split == nil {
+		t.Errorf("expected the split in the trace, got %+v", result.Trace)
+	}
+}
+
+func TestOptimize_SplitRespectsFloor(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Rent", DueDay: 20, Amount: 1500}}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 1000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 1000},
+	}
+	assignments := []OptAssignment{{BillID: 1, PeriodID: 10}}
+
+	// Evening out would move 750 and leave the 15th at 250; the floor allows 600
+	floor := 400.0
+	result := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{AllowSplits: true, MinBalance: &floor})
+	if len(result.Splits) != 1 || result.Splits[0].Parts[1].Amount != 600 {
+		t.Fatalf("expected 600 to move, got %+v", result.Splits)
+	}
+	if result.OptimizedMinBalance != 100 {
+		t.Errorf("expected optimized min 100, got %f", result.OptimizedMinBalance)
+	}
+}
+
+func TestOptimize_NoSplitsUnlessAllowed(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Rent", DueDay: 20, Amount: 1500}}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 1200},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 800},
+	}
+	result := o.Optimize(bills, periods, []OptAssignment{{BillID: 1, PeriodID: 10}})
+	if result.Splits != nil {
+		t.Errorf("expected no splits, got %+v", result.Splits)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Optimize: weighted scoring
+// ---------------------------------------------------------------------------
+
+func TestOptimize_WeightedMovePenaltyOutweighsSmallGain(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Rent", DueDay: 20, Amount: 900}}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 1000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 1040},
+	}
+	assignments := []OptAssignment{{BillID: 1, PeriodID: 10, AssignmentID: 3}}
+
+	// Moving Rent lifts the minimum by $40: worth a $25 move but not a $50 one
+	cheap := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{Weights: &OptWeights{MinBalance: 1, Moves: 25}})
+	if len(cheap.Suggestions) != 1 || cheap.Suggestions[0].AssignmentID != 3 || cheap.Suggestions[0].ToPeriodID != 20 {
+		t.Fatalf("expected Rent to move to the 15th, got %+v", cheap.Suggestions)
+	}
+	if cheap.OptimizedScore.Moves != 1 || !floatEqual(cheap.OptimizedScore.Total, 115) {
+		t.Errorf("expected 1 move scoring 140 - 25, got %+v", cheap.OptimizedScore)
+	}
+
+	costly := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{Weights: &OptWeights{MinBalance: 1, Moves: 50}})
+	if len(costly.Suggestions) != 0 {
+		t.Errorf("expected no moves, got %+v", costly.Suggestions)
+	}
+	if costly.CurrentScore == nil || costly.OptimizedScore == nil || costly.CurrentScore.Total != costly.OptimizedScore.Total {
+		t.Errorf("expected equal current and optimized scores, got %+v / %+v", costly.CurrentScore, costly.OptimizedScore)
+	}
+}
+
+func TestOptimize_WeightedDueBufferMovesBillEarlier(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Phone", DueDay: 20, Amount: 100}}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 500},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 1000},
+	}
+	assignments := []OptAssignment{{BillID: 1, PeriodID: 20}}
+
+	// The greedy search has nothing to move out of the tight 1st
+	if result := o.Optimize(bills, periods, assignments); len(result.Suggestions) != 0 {
+		t.Fatalf("expected no greedy moves, got %+v", result.Suggestions)
+	}
+
+	// 14 more days of buffer at $10 a day beats $100 off the minimum
+	result := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{Debug: true, Weights: &OptWeights{MinBalance: 1, DueBuffer: 10}})
+	if len(result.Suggestions) != 1 || result.Suggestions[0].ToPeriodID != 10 {
+		t.Fatalf("expected Phone to move to the 1st, got %+v", result.Suggestions)
+	}
+	cs, opt := result.CurrentScore, result.OptimizedScore
+	if cs.DueBufferDays != 5 || !floatEqual(cs.Total, 550) {
+		t.Errorf("unexpected current score: %+v", cs)
+	}
+	if opt.DueBufferDays != 19 || opt.MinBalance != 400 || !floatEqual(opt.Total, 590) {
+		t.Errorf("unexpected optimized score: %+v", opt)
+	}
+	if len(result.Trace) != 2 || result.Trace[0].Score == nil || result.Trace[1].Move != nil {
+		t.Errorf("expected a scored move step and a stop step, got %+v", result.Trace)
+	}
+}
+
+func TestOptimize_NoScoresWithoutWeights(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Rent", DueDay: 20, Amount: 1000}}
+	periods := []OptPeriod{{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 2000}}
+	result := o.Optimize(bills, periods, []OptAssignment{{BillID: 1, PeriodID: 10}})
+	if result.CurrentScore != nil || result.OptimizedScore != nil {
+		t.Errorf("expected no scores, got %+v / %+v", result.CurrentScore, result.OptimizedScore)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Optimize: warm start from the last accepted layout
+// ---------------------------------------------------------------------------
+
+func TestOptimize_WarmStartMovesNewBillBeforeSettledOnes(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{
+		{ID: 1, Name: "Rent", DueDay: 20, Amount: 900},
+		{ID: 2, Name: "Car", DueDay: 20, Amount: 700},
+		{ID: 3, Name: "Gym", DueDay: 20, Amount: 200},
+	}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 2000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 2000},
+	}
+	assignments := []OptAssignment{
+		{AssignmentID: 1, BillID: 1, PeriodID: 10},
+		{AssignmentID: 2, BillID: 2, PeriodID: 20},
+		{AssignmentID: 3, BillID: 3, PeriodID: 10}, // added since the plan was accepted
+	}
+
+	// From scratch the largest bill, Rent, is moved
+	cold := o.Optimize(bills, periods, assignments)
+	if len(cold.Suggestions) == 0 || cold.Suggestions[0].BillName != "Rent" {
+		t.Fatalf("expected a cold start to move Rent first, got %+v", cold.Suggestions)
+	}
+
+	warm := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{Layout: map[int]int{1: 10, 2: 20}})
+	if len(warm.Suggestions) != 1 || warm.Suggestions[0].BillName != "Gym" || warm.Suggestions[0].ToPeriodID != 20 {
+		t.Fatalf("expected only Gym moved, got %+v", warm.Suggestions)
+	}
+	if warm.OptimizedMinBalance != 1100 {
+		t.Errorf("expected both periods at 1100, got min %.2f", warm.OptimizedMinBalance)
+	}
+}
+
+func TestOptimize_WarmStartRestoresDriftedAssignments(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{
+		{ID: 1, Name: "Rent", DueDay: 20, Amount: 500},
+		{ID: 2, Name: "Power", DueDay: 10, Amount: 100},
+	}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 1000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 400},
+	}
+	// Rent has since been moved by auto-assign to where it doesn't fit
+	assignments := []OptAssignment{
+		{AssignmentID: 1, BillID: 1, PeriodID: 20},
+		{AssignmentID: 2, BillID: 2, PeriodID: 10},
+	}
+	// Power's accepted period pays after its due day, so it stays put
+	layout := map[int]int{1: 10, 2: 20}
+
+	for _, weights := range []*OptWeights{nil, {MinBalance: 1}} {
+		result := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{Layout: layout, Weights: weights})
+		if len(result.Suggestions) != 1 {
+			t.Fatalf("weights %v: expected just Rent restored, got %+v", weights, result.Suggestions)
+		}
+		s := result.Suggestions[0]
+		if s.BillName != "Rent" || s.ToPeriodID != 10 || s.Reason != warmStartReason {
+			t.Errorf("weights %v: expected Rent back in period 10, got %+v", weights, s)
+		}
+		if result.OptimizedMinBalance != 400 {
+			t.Errorf("weights %v: expected both periods at 400, got min %.2f", weights, result.OptimizedMinBalance)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Optimize: no valid moves when canPayFrom blocks all bills
 // ---------------------------------------------------------------------------
@@ -1116,3 +1420,52 @@ func TestOptimize_ManuallyMovedBill(t *testing.T) {
 		t.Errorf("expected 0 suggestions for manually moved bill scenario, got %d", len(result.Suggestions))
 	}
 }
+
+// ---------------------------------------------------------------------------
+// OptimizeWithOptions: debug trace
+// ---------------------------------------------------------------------------
+
+func TestOptimize_DebugTraceRecordsMovesAndStop(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Rent", DueDay: 20, Amount: 1000}}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 2000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 500},
+	}
+	// P10: 2000, P20: -500; moving Rent to P10 gives 1000 / 500, then P20 has nothing to move
+	assignments := []OptAssignment{{BillID: 1, PeriodID: 20, AssignmentID: 5}}
+	result := o.OptimizeWithOptions(bills, periods, assignments, OptOptions{Debug: true})
+
+	if len(result.Suggestions) != 1 {
+		t.Fatalf("expected 1 suggestion, got %d", len(result.Suggestions))
+	}
+	if len(result.Trace) != 2 {
+		t.Fatalf("expected a move step and a stop step, got %d", len(result.Trace))
+	}
+	first := result.Trace[0]
+	if first.Move == nil || first.Move.AssignmentID != 5 {
+		t.Fatalf("expected first step to move assignment 5, got %+v", first.Move)
+	}
+	if first.TightPeriodID != 20 || first.TightBalance != -500 || first.Candidates != 1 {
+		t.Errorf("unexpected first step: %+v", first)
+	}
+	if len(first.Balances) != 2 || first.Balances[0].Balance != 1000 || first.Balances[1].Balance != 500 {
+		t.Errorf("unexpected balances after move: %+v", first.Balances)
+	}
+	if last := result.Trace[1]; last.Move != nil || last.Reason == "" {
+		t.Errorf("expected stop step with a reason, got %+v", last)
+	}
+}
+
+func TestOptimize_NoTraceWithoutDebug(t *testing.T) {
+	o := NewOptimizer()
+	bills := []OptBill{{ID: 1, Name: "Rent", DueDay: 20, Amount: 1000}}
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2025-01-01", PayDay: 1, Income: 2000},
+		{ID: 20, PayDate: "2025-01-15", PayDay: 15, Income: 500},
+	}
+	result := o.Optimize(bills, periods, []OptAssignment{{BillID: 1, PeriodID: 20}})
+	if result.Trace != nil {
+		t.Errorf("expected no trace, got %d steps", len(result.Trace))
+	}
+}
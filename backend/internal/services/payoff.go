@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxPayoffMonths bounds a payoff plan; a payment that never clears the
+// balance stops here with a warning.
+const maxPayoffMonths = 360
+
+// PromoWindow is a reduced APR on part or all of a card balance until it
+// expires, after which that part accrues at the card's standard APR.
+type PromoWindow struct {
+	Rate      float64   // APR percent during the promo, usually 0
+	ExpiresOn time.Time // last day the promo rate applies
+	Balance   *float64  // amount under the promo; nil covers whatever remains
+}
+
+// PayoffMonth is one month of a payoff plan.
+type PayoffMonth struct {
+	Month        string  `json:"month"` // YYYY-MM
+	Interest     float64 `json:"interest"`
+	Payment      float64 `json:"payment"`
+	Balance      float64 `json:"balance"`       // after the payment
+	PromoBalance float64 `json:"promo_balance"` // part of balance still at a promo rate
+}
+
+// PayoffPlan is the month-by-month payoff of a card balance at a fixed payment.
+type PayoffPlan struct {
+	StartingBalance float64       `json:"starting_balance"`
+	MonthlyPayment  float64       `json:"monthly_payment"`
+	TotalInterest   float64       `json:"total_interest"`
+	PaidOff         bool          `json:"paid_off"`
+	PayoffMonth     *string       `json:"payoff_month"` // YYYY-MM; nil when not paid off
+	Months          []PayoffMonth `json:"months"`
+	Warnings        []string      `json:"warnings"`
+}
+
+type promoBucket struct {
+	rate      float64
+	expiresOn time.Time
+	amount    float64
+}
+
+// PlanPayoff pays balance down by payment each month starting with start's
+// month. Each month accrues interest (apr and promo rates are annual percents,
+// charged monthly), then applies the payment to the highest-rate portion
+// first, as card issuers must. A promo portion still owed when its window
+// expires moves to the standard APR and is reported as a warning.
+func PlanPayoff(balance, apr, payment float64, promos []PromoWindow, start time.Time) PayoffPlan {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	plan := PayoffPlan{
+		StartingBalance: roundMoney(balance),
+		MonthlyPayment:  roundMoney(payment),
+		Months:          []PayoffMonth{},
+		Warnings:        []string{},
+	}
+
+	sorted := append([]PromoWindow(nil), promos...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ExpiresOn.Before(sorted[j].ExpiresOn) })
+
+	standard := balance
+	var buckets []promoBucket
+	for _, p := range sorted {
+		if p.ExpiresOn.Before(start) || standard <= 0 {
+			continue
+		}
+		amount := standard
+		if p.Balance != nil && *p.Balance < amount {
+			amount = *p.Balance
+		}
+		standard -= amount
+		buckets = append(buckets, promoBucket{rate: p.Rate, expiresOn: p.ExpiresOn, amount: amount})
+	}
+
+	for i := 0; i < maxPayoffMonths; i++ {
+		label := month.Format("2006-01")
+
+		// Promos that ended before this month roll into the standard portion
+		kept := buckets[:0]
+		for _, b := range buckets {
+			if b.expiresOn.Before(month) {
+				if b.amount >= 0.005 {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+						"$%.2f at %.2f%% is still owed when the promo expires on %s and moves to %.2f%%",
+						roundMoney(b.amount), b.rate, b.expiresOn.Format("2006-01-02"), apr))
+				}
+				standard += b.amount
+				continue
+			}
+			kept = append(kept, b)
+		}
+		buckets = kept
+
+		interest := standard * apr / 1200
+		standard += interest
+		for j := range buckets {
+			accrued := buckets[j].amount * buckets[j].rate / 1200
+			buckets[j].amount += accrued
+			interest += accrued
+		}
+
+		paid := payment
+		if owed := standard + promoTotal(buckets); paid > owed {
+			paid = owed
+		}
+		remaining := paid
+		if remaining > standard {
+			remaining -= standard
+			standard = 0
+		} else {
+			standard -= remaining
+			remaining = 0
+		}
+		// Highest rate first, then the promo that expires soonest
+		sort.SliceStable(buckets, func(a, b int) bool {
+			if buckets[a].rate != buckets[b].rate {
+				return buckets[a].rate > buckets[b].rate
+			}
+			return buckets[a].expiresOn.Before(buckets[b].expiresOn)
+		})
+		for j := range buckets {
+			if remaining <= 0 {
+				break
+			}
+			take := remaining
+			if take > buckets[j].amount {
+				take = buckets[j].amount
+			}
+			buckets[j].amount -= take
+			remaining -= take
+		}
+
+		promoLeft := promoTotal(buckets)
+		plan.TotalInterest += interest
+		plan.Months = append(plan.Months, PayoffMonth{
+			Month:        label,
+			Interest:     roundMoney(interest),
+			Payment:      roundMoney(paid),
+			Balance:      roundMoney(standard + promoLeft),
+			PromoBalance: roundMoney(promoLeft),
+		})
+
+		if standard+promoLeft < 0.005 {
+			plan.PaidOff = true
+			plan.PayoffMonth = &label
+			break
+		}
+		if i == 0 && paid <= interest {
+			plan.Warnings = append(plan.Warnings, "the monthly payment does not cover the interest charged")
+			break
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+
+	if !plan.PaidOff && len(plan.Months) == maxPayoffMonths {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("the balance is not paid off within %d months", maxPayoffMonths))
+	}
+	plan.TotalInterest = roundMoney(plan.TotalInterest)
+	return plan
+}
+
+func promoTotal(buckets []promoBucket) float64 {
+	total := 0.0
+	for _, b := range buckets {
+		total += b.amount
+	}
+	return total
+}
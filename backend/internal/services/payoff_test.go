@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanPayoff_ZeroPercentPromoClearsInTime(t *testing.T) {
+	promos := []PromoWindow{{Rate: 0, ExpiresOn: date(2026, time.June, 30)}}
+
+	plan := PlanPayoff(600, 24, 100, promos, date(2026, time.January, 15))
+
+	if !plan.PaidOff || plan.PayoffMonth == nil || *plan.PayoffMonth != "2026-06" {
+		t.Fatalf("expected payoff in 2026-06, got %v (paid off %v)", plan.PayoffMonth, plan.PaidOff)
+	}
+	if plan.TotalInterest != 0 {
+		t.Errorf("total interest = %.2f, want 0 under the promo", plan.TotalInterest)
+	}
+	if len(plan.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", plan.Warnings)
+	}
+}
+
+func TestPlanPayoff_WarnsWhenBalanceOutlivesPromo(t *testing.T) {
+	promos := []PromoWindow{{Rate: 0, ExpiresOn: date(2026, time.February, 28)}}
+
+	plan := PlanPayoff(600, 24, 100, promos, date(2026, time.January, 1))
+
+	if len(plan.Warnings) != 1 {
+		t.Fatalf("expected one promo warning, got %v", plan.Warnings)
+	}
+	// Two interest-free months, then 2% a month on the remaining 400
+	if plan.Months[1].Interest != 0 || plan.Months[2].Interest != 8 {
+		t.Errorf("interest = %.2f then %.2f, want 0 then 8", plan.Months[1].Interest, plan.Months[2].Interest)
+	}
+	if plan.Months[1].PromoBalance != 400 || plan.Months[2].PromoBalance != 0 {
+		t.Errorf("promo balance = %.2f then %.2f, want 400 then 0", plan.Months[1].PromoBalance, plan.Months[2].PromoBalance)
+	}
+}
+
+func TestPlanPayoff_PaysStandardRateBeforePromo(t *testing.T) {
+	promoBalance := 500.0
+	promos := []PromoWindow{{Rate: 0, ExpiresOn: date(2027, time.January, 31), Balance: &promoBalance}}
+
+	plan := PlanPayoff(700, 24, 200, promos, date(2026, time.January, 1))
+
+	// 200 at 24% accrues 4; the payment goes to that 204 before the promo part
+	first := plan.Months[0]
+	if first.Interest != 4 || first.PromoBalance != 500 || first.Balance != 504 {
+		t.Errorf("first month = %+v, want interest 4 and the 500 promo part untouched", first)
+	}
+	second := plan.Months[1]
+	if second.Interest != 0.08 || second.PromoBalance != 304.08 {
+		t.Errorf("second month = %+v, want 0.08 interest and 304.08 left at the promo rate", second)
+	}
+}
+
+func TestPlanPayoff_PaymentBelowInterest(t *testing.T) {
+	plan := PlanPayoff(10000, 24, 150, nil, date(2026, time.January, 1))
+
+	if plan.PaidOff || len(plan.Months) != 1 {
+		t.Fatalf("expected the plan to stop after one month, got %d months", len(plan.Months))
+	}
+	if len(plan.Warnings) != 1 {
+		t.Errorf("expected a warning, got %v", plan.Warnings)
+	}
+}
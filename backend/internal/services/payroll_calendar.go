@@ -0,0 +1,38 @@
+package services
+
+import (
+	"sort"
+	"time"
+)
+
+// ApplyPayrollCalendar replaces generated pay dates with an employer's
+// official ones. A year with any official date is taken wholly from the
+// calendar, since real payroll shifts dates around holidays and the
+// schedule math can't know about it; other years keep the generated dates.
+// Official dates outside from..to are dropped. The result is sorted.
+func ApplyPayrollCalendar(generated, official []time.Time, from, to time.Time) []time.Time {
+	if len(official) == 0 {
+		return generated
+	}
+
+	covered := make(map[int]bool)
+	for _, d := range official {
+		covered[d.Year()] = true
+	}
+
+	dates := make([]time.Time, 0, len(generated))
+	for _, d := range generated {
+		if !covered[d.Year()] {
+			dates = append(dates, d)
+		}
+	}
+	for _, d := range official {
+		day := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, from.Location())
+		if !day.Before(from) && !day.After(to) {
+			dates = append(dates, day)
+		}
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
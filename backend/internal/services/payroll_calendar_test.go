@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyPayrollCalendar_ReplacesCoveredYears(t *testing.T) {
+	generated := []time.Time{
+		date(2025, time.December, 19),
+		date(2026, time.January, 2),
+		date(2026, time.January, 16),
+	}
+	// Payroll moved both January checks a day early; the March date is out of range
+	official := []time.Time{
+		date(2026, time.January, 15),
+		date(2026, time.January, 1),
+		date(2026, time.March, 1),
+	}
+
+	got := ApplyPayrollCalendar(generated, official, date(2025, time.December, 1), date(2026, time.January, 31))
+
+	want := []time.Time{
+		date(2025, time.December, 19),
+		date(2026, time.January, 1),
+		date(2026, time.January, 15),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d dates, got %v", len(want), got)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("date %d = %s, want %s", i, got[i].Format("2006-01-02"), want[i].Format("2006-01-02"))
+		}
+	}
+}
+
+func TestApplyPayrollCalendar_NoCalendarKeepsGenerated(t *testing.T) {
+	generated := []time.Time{date(2026, time.January, 2)}
+
+	got := ApplyPayrollCalendar(generated, nil, date(2026, time.January, 1), date(2026, time.January, 31))
+	if len(got) != 1 || !got[0].Equal(generated[0]) {
+		t.Errorf("expected the generated dates back, got %v", got)
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+// AggregatePeriods merges pay periods from different income sources that share
+// a pay date into one planning bucket. The bucket keeps the first period's ID,
+// sums expected/actual income and bill totals, and lists every merged period
+// in MergedPeriodIDs. Input must be ordered by pay date.
+func AggregatePeriods(periods []models.PayPeriod) []models.PayPeriod {
+	var out []models.PayPeriod
+	for _, p := range periods {
+		n := len(out)
+		if n == 0 || !out[n-1].PayDate.Equal(p.PayDate) {
+			out = append(out, p)
+			continue
+		}
+
+		b := &out[n-1]
+		if b.MergedPeriodIDs == nil {
+			b.MergedPeriodIDs = []int{b.ID}
+		}
+		b.MergedPeriodIDs = append(b.MergedPeriodIDs, p.ID)
+		b.ExpectedAmount = addAmounts(b.ExpectedAmount, p.ExpectedAmount)
+		b.ActualAmount = addAmounts(b.ActualAmount, p.ActualAmount)
+		b.TotalBills += p.TotalBills
+		if p.SourceName != "" && !strings.Contains(b.SourceName, p.SourceName) {
+			b.SourceName += " + " + p.SourceName
+		}
+		if b.ExpectedAmount != nil {
+			b.Remaining = *b.ExpectedAmount - b.TotalBills
+		}
+	}
+	return out
+}
+
+// MergeSameDatePeriods is AggregatePeriods for the optimizer: same-date periods
+// become one with combined income, and assignments are re-pointed at it, so
+// suggestions target the first period of each bucket.
+func MergeSameDatePeriods(periods []OptPeriod, assignments []OptAssignment) ([]OptPeriod, []OptAssignment) {
+	bucket := make(map[string]int) // pay date -> index in merged
+	remap := make(map[int]int)     // period ID -> bucket period ID
+	var merged []OptPeriod
+	for _, p := range periods {
+		if i, ok := bucket[p.PayDate]; ok {
+			merged[i].Income += p.Income
+			merged[i].Assigned += p.Assigned
+			remap[p.ID] = merged[i].ID
+			continue
+		}
+		bucket[p.PayDate] = len(merged)
+		remap[p.ID] = p.ID
+		merged = append(merged, p)
+	}
+
+	remapped := make([]OptAssignment, len(assignments))
+	for i, a := range assignments {
+		if id, ok := remap[a.PeriodID]; ok {
+			a.PeriodID = id
+		}
+		remapped[i] = a
+	}
+	return merged, remapped
+}
+
+func addAmounts(a, b *float64) *float64 {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	sum := *a + *b
+	return &sum
+}
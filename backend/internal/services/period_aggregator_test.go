@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/izz-linux/budget-mgmt/backend/internal/models"
+)
+
+func TestAggregatePeriods_MergesSameDate(t *testing.T) {
+	day := time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)
+	a, b := 2000.0, 1500.0
+	periods := []models.PayPeriod{
+		{ID: 1, PayDate: day.AddDate(0, 0, -7), ExpectedAmount: &a, SourceName: "Job", TotalBills: 100},
+		{ID: 2, PayDate: day, ExpectedAmount: &a, SourceName: "Job", TotalBills: 900},
+		{ID: 3, PayDate: day, ExpectedAmount: &b, SourceName: "Spouse", TotalBills: 400},
+	}
+
+	got := AggregatePeriods(periods)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(got))
+	}
+	if got[0].MergedPeriodIDs != nil {
+		t.Errorf("single period should not list merged IDs, got %v", got[0].MergedPeriodIDs)
+	}
+	m := got[1]
+	if m.ID != 2 || len(m.MergedPeriodIDs) != 2 || m.MergedPeriodIDs[1] != 3 {
+		t.Errorf("unexpected bucket IDs: id=%d merged=%v", m.ID, m.MergedPeriodIDs)
+	}
+	if *m.ExpectedAmount != 3500 || m.TotalBills != 1300 || m.Remaining != 2200 {
+		t.Errorf("unexpected totals: expected=%v bills=%v remaining=%v", *m.ExpectedAmount, m.TotalBills, m.Remaining)
+	}
+	if m.SourceName != "Job + Spouse" {
+		t.Errorf("expected combined source name, got %q", m.SourceName)
+	}
+	// The input must not be modified through shared amount pointers
+	if a != 2000 {
+		t.Errorf("input amount was modified: %v", a)
+	}
+}
+
+func TestMergeSameDatePeriods_RepointsAssignments(t *testing.T) {
+	periods := []OptPeriod{
+		{ID: 10, PayDate: "2026-03-13", PayDay: 13, Income: 2000},
+		{ID: 11, PayDate: "2026-03-13", PayDay: 13, Income: 1500},
+		{ID: 20, PayDate: "2026-03-27", PayDay: 27, Income: 2000},
+	}
+	assignments := []OptAssignment{
+		{BillID: 1, PeriodID: 11, AssignmentID: 100},
+		{BillID: 2, PeriodID: 20, AssignmentID: 101},
+	}
+
+	merged, remapped := MergeSameDatePeriods(periods, assignments)
+	if len(merged) != 2 || merged[0].ID != 10 || merged[0].Income != 3500 {
+		t.Fatalf("unexpected merged periods: %+v", merged)
+	}
+	if remapped[0].PeriodID != 10 || remapped[1].PeriodID != 20 {
+		t.Errorf("unexpected remapped assignments: %+v", remapped)
+	}
+	if assignments[0].PeriodID != 11 {
+		t.Error("input assignments should not be modified")
+	}
+}
@@ -2,19 +2,119 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/izz-linux/budget-mgmt/backend/internal/models"
 )
 
-type PeriodGenerator struct{}
+// ErrScheduleInvalid is wrapped by Validate for schedule details that are
+// well-formed JSON but describe an inconsistent schedule.
+var ErrScheduleInvalid = errors.New("invalid schedule")
+
+type PeriodGenerator struct {
+	holidays map[string]HolidayCalendar // by the name schedules use in "holidays"
+}
 
 func NewPeriodGenerator() *PeriodGenerator {
-	return &PeriodGenerator{}
+	return &PeriodGenerator{
+		holidays: map[string]HolidayCalendar{
+			DefaultHolidayCalendar: USFederalHolidays{},
+			"none":                 NoHolidays{},
+		},
+	}
+}
+
+// WithHolidayCalendar makes a holiday calendar available to schedules under
+// name, replacing any calendar already registered under it.
+func (g *PeriodGenerator) WithHolidayCalendar(name string, cal HolidayCalendar) *PeriodGenerator {
+	g.holidays[name] = cal
+	return g
 }
 
+// adjustSlack is how far outside the requested range paydays are generated
+// so that ones adjusted into it aren't missed. It covers a weekend next to a
+// holiday with room to spare.
+const adjustSlack = 7
+
+// Generate returns a source's paydays between from and to, applying the
+// schedule's PayDateAdjustment. As with adjust_for_weekends, a payday
+// scheduled in range is kept even if adjusting moves it just outside.
 func (g *PeriodGenerator) Generate(source models.IncomeSource, from, to time.Time) ([]time.Time, error) {
+	policy, cal, err := g.adjustment(source)
+	if err != nil {
+		return nil, err
+	}
+	if policy == "" || policy == models.AdjustNone {
+		return g.generate(source, from, to)
+	}
+
+	scheduled, err := g.generate(source, from.AddDate(0, 0, -adjustSlack), to.AddDate(0, 0, adjustSlack))
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[time.Time]bool)
+	var dates []time.Time
+	for _, d := range scheduled {
+		adjusted := adjustToBusinessDay(d, policy, cal)
+		originalInRange := !d.Before(from) && !d.After(to)
+		if seen[adjusted] || !(originalInRange || (!adjusted.Before(from) && !adjusted.After(to))) {
+			continue
+		}
+		seen[adjusted] = true
+		dates = append(dates, adjusted)
+	}
+	return dates, nil
+}
+
+// adjustment reads a recurring schedule's PayDateAdjustment. One-time and
+// custom schedules list exact dates, so they are never adjusted.
+func (g *PeriodGenerator) adjustment(source models.IncomeSource) (string, HolidayCalendar, error) {
+	switch source.PaySchedule {
+	case "weekly", "biweekly", "semimonthly", "monthly":
+	default:
+		return "", nil, nil
+	}
+	var adj models.PayDateAdjustment
+	if len(source.ScheduleDetail) > 0 {
+		if err := json.Unmarshal(source.ScheduleDetail, &adj); err != nil {
+			return "", nil, fmt.Errorf("parsing pay date adjustment: %w", err)
+		}
+	}
+	switch adj.Adjust {
+	case "", models.AdjustNone, models.AdjustPreviousBusinessDay, models.AdjustNextBusinessDay:
+	default:
+		return "", nil, fmt.Errorf("adjust must be %q, %q or %q, got %q",
+			models.AdjustPreviousBusinessDay, models.AdjustNextBusinessDay, models.AdjustNone, adj.Adjust)
+	}
+	name := adj.Holidays
+	if name == "" {
+		name = DefaultHolidayCalendar
+	}
+	cal, ok := g.holidays[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown holiday calendar %q", name)
+	}
+	return adj.Adjust, cal, nil
+}
+
+// adjustToBusinessDay moves d off weekends and cal's holidays in the
+// direction policy gives.
+func adjustToBusinessDay(d time.Time, policy string, cal HolidayCalendar) time.Time {
+	step := -1
+	if policy == models.AdjustNextBusinessDay {
+		step = 1
+	}
+	for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday || cal.IsHoliday(d) {
+		d = d.AddDate(0, 0, step)
+	}
+	return d
+}
+
+func (g *PeriodGenerator) generate(source models.IncomeSource, from, to time.Time) ([]time.Time, error) {
 	switch source.PaySchedule {
 	case "weekly":
 		return g.generateWeekly(source.ScheduleDetail, from, to)
@@ -22,19 +122,150 @@ func (g *PeriodGenerator) Generate(source models.IncomeSource, from, to time.Tim
 		return g.generateBiweekly(source.ScheduleDetail, from, to)
 	case "semimonthly":
 		return g.generateSemiMonthly(source.ScheduleDetail, from, to)
+	case "monthly":
+		return g.generateMonthly(source.ScheduleDetail, from, to)
 	case "one_time":
 		return g.generateOneTime(source.ScheduleDetail, from, to)
+	case "custom":
+		return g.generateCustom(source.ScheduleDetail, from, to)
 	default:
 		return nil, fmt.Errorf("unknown pay schedule: %s", source.PaySchedule)
 	}
 }
 
+// Validate checks a source's schedule detail for consistency before it is saved,
+// so that bad schedules are rejected up front instead of producing confusing dates.
+func (g *PeriodGenerator) Validate(source models.IncomeSource) error {
+	if _, _, err := g.adjustment(source); err != nil {
+		return fmt.Errorf("%w: %v", ErrScheduleInvalid, err)
+	}
+	switch source.PaySchedule {
+	case "weekly":
+		return g.validateWeekly(source.ScheduleDetail)
+	case "biweekly":
+		return g.validateBiweekly(source.ScheduleDetail)
+	case "semimonthly":
+		return g.validateSemiMonthly(source.ScheduleDetail)
+	case "monthly":
+		return g.validateMonthly(source.ScheduleDetail)
+	case "custom":
+		return g.validateCustom(source.ScheduleDetail)
+	default:
+		return nil
+	}
+}
+
+func (g *PeriodGenerator) validateWeekly(detail json.RawMessage) error {
+	var schedule models.WeeklySchedule
+	if err := json.Unmarshal(detail, &schedule); err != nil {
+		return fmt.Errorf("%w: parsing weekly schedule: %v", ErrScheduleInvalid, err)
+	}
+	if schedule.SkipEvery == 0 {
+		return nil
+	}
+
+	if schedule.SkipEvery < 2 {
+		return fmt.Errorf("%w: skip_every must be at least 2, got %d", ErrScheduleInvalid, schedule.SkipEvery)
+	}
+	anchor, err := time.Parse("2006-01-02", schedule.AnchorDate)
+	if err != nil {
+		return fmt.Errorf("%w: anchor_date must be in YYYY-MM-DD format when skip_every is set", ErrScheduleInvalid)
+	}
+	if anchor.Weekday() != time.Weekday(schedule.Weekday) {
+		return fmt.Errorf("%w: anchor_date %s is a %s but weekday is %d (%s)",
+			ErrScheduleInvalid, schedule.AnchorDate, anchor.Weekday(),
+			schedule.Weekday, time.Weekday(schedule.Weekday))
+	}
+
+	return nil
+}
+
+func (g *PeriodGenerator) validateBiweekly(detail json.RawMessage) error {
+	var schedule models.BiweeklySchedule
+	if err := json.Unmarshal(detail, &schedule); err != nil {
+		return fmt.Errorf("%w: parsing biweekly schedule: %v", ErrScheduleInvalid, err)
+	}
+
+	anchor, err := time.Parse("2006-01-02", schedule.AnchorDate)
+	if err != nil {
+		return fmt.Errorf("%w: anchor_date must be in YYYY-MM-DD format", ErrScheduleInvalid)
+	}
+
+	if anchor.Weekday() != time.Weekday(schedule.Weekday) {
+		return fmt.Errorf("%w: anchor_date %s is a %s but weekday is %d (%s)",
+			ErrScheduleInvalid, schedule.AnchorDate, anchor.Weekday(),
+			schedule.Weekday, time.Weekday(schedule.Weekday))
+	}
+
+	return nil
+}
+
+func (g *PeriodGenerator) validateSemiMonthly(detail json.RawMessage) error {
+	var schedule models.SemiMonthlySchedule
+	if err := json.Unmarshal(detail, &schedule); err != nil {
+		return fmt.Errorf("%w: parsing semimonthly schedule: %v", ErrScheduleInvalid, err)
+	}
+
+	if len(schedule.Days) != 2 {
+		return fmt.Errorf("%w: semimonthly schedule must have exactly 2 days, got %d", ErrScheduleInvalid, len(schedule.Days))
+	}
+	for _, day := range schedule.Days {
+		if day != models.LastDayOfMonth && (day < 1 || day > 31) {
+			return fmt.Errorf("%w: semimonthly day %d must be between 1 and 31 or \"last\"", ErrScheduleInvalid, day)
+		}
+	}
+
+	return nil
+}
+
+func (g *PeriodGenerator) validateMonthly(detail json.RawMessage) error {
+	var schedule models.MonthlySchedule
+	if err := json.Unmarshal(detail, &schedule); err != nil {
+		return fmt.Errorf("%w: parsing monthly schedule: %v", ErrScheduleInvalid, err)
+	}
+
+	if schedule.Day < 1 || schedule.Day > 31 {
+		return fmt.Errorf("%w: monthly day %d must be between 1 and 31", ErrScheduleInvalid, schedule.Day)
+	}
+
+	return nil
+}
+
+func (g *PeriodGenerator) validateCustom(detail json.RawMessage) error {
+	var schedule models.CustomSchedule
+	if err := json.Unmarshal(detail, &schedule); err != nil {
+		return fmt.Errorf("%w: parsing custom schedule: %v", ErrScheduleInvalid, err)
+	}
+
+	if len(schedule.Dates) == 0 {
+		return fmt.Errorf("%w: custom schedule must list at least one date", ErrScheduleInvalid)
+	}
+	for _, d := range schedule.Dates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("%w: custom date %q must be in YYYY-MM-DD format", ErrScheduleInvalid, d)
+		}
+	}
+
+	return nil
+}
+
 func (g *PeriodGenerator) generateWeekly(detail json.RawMessage, from, to time.Time) ([]time.Time, error) {
 	var schedule models.WeeklySchedule
 	if err := json.Unmarshal(detail, &schedule); err != nil {
 		return nil, fmt.Errorf("parsing weekly schedule: %w", err)
 	}
 
+	var anchor time.Time
+	if schedule.SkipEvery > 0 {
+		if schedule.SkipEvery < 2 {
+			return nil, fmt.Errorf("skip_every must be at least 2, got %d", schedule.SkipEvery)
+		}
+		var err error
+		if anchor, err = time.Parse("2006-01-02", schedule.AnchorDate); err != nil {
+			return nil, fmt.Errorf("parsing anchor date: %w", err)
+		}
+	}
+
 	targetWeekday := time.Weekday(schedule.Weekday)
 	var dates []time.Time
 
@@ -45,13 +276,27 @@ func (g *PeriodGenerator) generateWeekly(detail json.RawMessage, from, to time.T
 	}
 
 	for !current.After(to) {
-		dates = append(dates, current)
+		if schedule.SkipEvery == 0 || !skippedWeek(anchor, current, schedule.SkipEvery) {
+			dates = append(dates, current)
+		}
 		current = current.AddDate(0, 0, 7)
 	}
 
 	return dates, nil
 }
 
+// skippedWeek reports whether d falls in the skipped week of a weekly
+// schedule that pays skipEvery-1 weeks out of every skipEvery, counting from
+// the paid week holding anchor. Dates before the anchor follow the same cycle.
+func skippedWeek(anchor, d time.Time, skipEvery int) bool {
+	days := int(math.Round(d.Sub(anchor).Hours() / 24))
+	week := days / 7
+	if days < 0 && days%7 != 0 {
+		week--
+	}
+	return ((week%skipEvery)+skipEvery)%skipEvery == skipEvery-1
+}
+
 func (g *PeriodGenerator) generateBiweekly(detail json.RawMessage, from, to time.Time) ([]time.Time, error) {
 	var schedule models.BiweeklySchedule
 	if err := json.Unmarshal(detail, &schedule); err != nil {
@@ -107,10 +352,10 @@ func (g *PeriodGenerator) generateSemiMonthly(detail json.RawMessage, from, to t
 	for !current.After(to) {
 		year, month := current.Year(), current.Month()
 		for _, day := range schedule.Days {
-			// Clamp to last day of month
+			// Clamp to last day of month ("last" always resolves there)
 			lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, current.Location()).Day()
 			actualDay := day
-			if actualDay > lastDay {
+			if actualDay > lastDay || actualDay == models.LastDayOfMonth {
 				actualDay = lastDay
 			}
 			d := time.Date(year, month, actualDay, 0, 0, 0, 0, current.Location())
@@ -138,6 +383,46 @@ func (g *PeriodGenerator) generateSemiMonthly(detail json.RawMessage, from, to t
 	return dates, nil
 }
 
+func (g *PeriodGenerator) generateMonthly(detail json.RawMessage, from, to time.Time) ([]time.Time, error) {
+	var schedule models.MonthlySchedule
+	if err := json.Unmarshal(detail, &schedule); err != nil {
+		return nil, fmt.Errorf("parsing monthly schedule: %w", err)
+	}
+
+	if schedule.Day < 1 || schedule.Day > 31 {
+		return nil, fmt.Errorf("monthly day must be between 1 and 31, got %d", schedule.Day)
+	}
+
+	var dates []time.Time
+
+	current := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
+
+	for !current.After(to) {
+		year, month := current.Year(), current.Month()
+
+		// Clamp to last day of month
+		day := schedule.Day
+		if lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, current.Location()).Day(); day > lastDay {
+			day = lastDay
+		}
+		d := time.Date(year, month, day, 0, 0, 0, 0, current.Location())
+
+		// Same range rule as semimonthly: a weekend date adjusted back before
+		// 'from' is still included
+		originalInRange := !d.Before(from) && !d.After(to)
+		if schedule.AdjustForWeekends {
+			d = adjustToWeekday(d)
+		}
+		if originalInRange || (!d.Before(from) && !d.After(to)) {
+			dates = append(dates, d)
+		}
+
+		current = current.AddDate(0, 1, 0)
+	}
+
+	return dates, nil
+}
+
 // adjustToWeekday moves weekend dates to the preceding Friday
 func adjustToWeekday(d time.Time) time.Time {
 	switch d.Weekday() {
@@ -167,3 +452,28 @@ func (g *PeriodGenerator) generateOneTime(detail json.RawMessage, from, to time.
 
 	return nil, nil
 }
+
+func (g *PeriodGenerator) generateCustom(detail json.RawMessage, from, to time.Time) ([]time.Time, error) {
+	var schedule models.CustomSchedule
+	if err := json.Unmarshal(detail, &schedule); err != nil {
+		return nil, fmt.Errorf("parsing custom schedule: %w", err)
+	}
+
+	// Dates may be listed in any order and repeat
+	seen := make(map[time.Time]bool)
+	var dates []time.Time
+	for _, s := range schedule.Dates {
+		date, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, fmt.Errorf("parsing custom date: %w", err)
+		}
+		if seen[date] || date.Before(from) || date.After(to) {
+			continue
+		}
+		seen[date] = true
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	return dates, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -274,6 +275,55 @@ func TestGenerateWeekly_SpansMultipleMonths(t *testing.T) {
 	assertDates(t, dates, expected)
 }
 
+func TestGenerateWeekly_SkipEvery(t *testing.T) {
+	gen := NewPeriodGenerator()
+	// Paid two Fridays out of three, starting with the week of Jan 3
+	source := makeSource(t, "weekly", models.WeeklySchedule{Weekday: 5, SkipEvery: 3, AnchorDate: "2025-01-03"})
+
+	dates, err := gen.Generate(source, date(2025, time.January, 1), date(2025, time.February, 28))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		date(2025, time.January, 3),
+		date(2025, time.January, 10),
+		date(2025, time.January, 24),
+		date(2025, time.January, 31),
+		date(2025, time.February, 14),
+		date(2025, time.February, 21),
+	}
+	assertDates(t, dates, expected)
+}
+
+func TestGenerateWeekly_SkipEveryBeforeAnchor(t *testing.T) {
+	gen := NewPeriodGenerator()
+	// Jan 17 is paid, so the cycle puts the skipped week before it on Jan 10
+	source := makeSource(t, "weekly", models.WeeklySchedule{Weekday: 5, SkipEvery: 3, AnchorDate: "2025-01-17"})
+
+	dates, err := gen.Generate(source, date(2024, time.December, 23), date(2025, time.January, 24))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		date(2024, time.December, 27),
+		date(2025, time.January, 3),
+		date(2025, time.January, 17),
+		date(2025, time.January, 24),
+	}
+	assertDates(t, dates, expected)
+}
+
+func TestGenerateWeekly_SkipEveryInvalidAnchor(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "weekly", models.WeeklySchedule{Weekday: 5, SkipEvery: 3})
+
+	if _, err := gen.Generate(source, date(2025, time.January, 1), date(2025, time.January, 31)); err == nil {
+		t.Fatal("expected an error for a skip rule without an anchor date")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Biweekly schedule tests
 // ---------------------------------------------------------------------------
@@ -780,7 +830,7 @@ func TestGenerateSemiMonthly_MultipleMonthlyClamping(t *testing.T) {
 func TestGenerate_UnknownSchedule(t *testing.T) {
 	gen := NewPeriodGenerator()
 	source := models.IncomeSource{
-		PaySchedule:    "monthly",
+		PaySchedule:    "quarterly",
 		ScheduleDetail: json.RawMessage(`{}`),
 	}
 
@@ -1568,3 +1618,425 @@ func TestGenerateSemiMonthly_AdjustForWeekends(t *testing.T) {
 		assertDates(t, dates, expected)
 	})
 }
+
+// ---------------------------------------------------------------------------
+// Schedule validation
+// ---------------------------------------------------------------------------
+
+func TestValidate_BiweeklyAnchorMatchesWeekday(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "biweekly", models.BiweeklySchedule{
+		Weekday:    5,
+		AnchorDate: "2025-01-10", // Friday
+	})
+
+	if err := gen.Validate(source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_BiweeklyAnchorWeekdayMismatch(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "biweekly", models.BiweeklySchedule{
+		Weekday:    1,            // Monday
+		AnchorDate: "2025-01-10", // Friday
+	})
+
+	err := gen.Validate(source)
+	if err == nil {
+		t.Fatal("expected error for mismatched anchor weekday, got nil")
+	}
+	if !errors.Is(err, ErrScheduleInvalid) {
+		t.Errorf("expected ErrScheduleInvalid, got %v", err)
+	}
+}
+
+func TestValidate_BiweeklyInvalidAnchorDate(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "biweekly", models.BiweeklySchedule{
+		Weekday:    5,
+		AnchorDate: "01/10/2025",
+	})
+
+	if err := gen.Validate(source); !errors.Is(err, ErrScheduleInvalid) {
+		t.Errorf("expected ErrScheduleInvalid, got %v", err)
+	}
+}
+
+func TestValidate_WeeklySkipEvery(t *testing.T) {
+	gen := NewPeriodGenerator()
+	tests := []struct {
+		name   string
+		detail models.WeeklySchedule
+		valid  bool
+	}{
+		{"valid", models.WeeklySchedule{Weekday: 5, SkipEvery: 3, AnchorDate: "2025-01-03"}, true},
+		{"every week skipped", models.WeeklySchedule{Weekday: 5, SkipEvery: 1, AnchorDate: "2025-01-03"}, false},
+		{"missing anchor", models.WeeklySchedule{Weekday: 5, SkipEvery: 3}, false},
+		{"anchor on wrong weekday", models.WeeklySchedule{Weekday: 5, SkipEvery: 3, AnchorDate: "2025-01-06"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gen.Validate(makeSource(t, "weekly", tt.detail))
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrScheduleInvalid) {
+				t.Errorf("expected ErrScheduleInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_OtherSchedulesPass(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "weekly", models.WeeklySchedule{Weekday: 5})
+
+	if err := gen.Validate(source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_SemiMonthlyLastToken(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := models.IncomeSource{
+		PaySchedule:    "semimonthly",
+		ScheduleDetail: json.RawMessage(`{"days":[15,"last"]}`),
+	}
+
+	if err := gen.Validate(source); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_SemiMonthlyInvalidToken(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := models.IncomeSource{
+		PaySchedule:    "semimonthly",
+		ScheduleDetail: json.RawMessage(`{"days":[15,"first"]}`),
+	}
+
+	if err := gen.Validate(source); !errors.Is(err, ErrScheduleInvalid) {
+		t.Errorf("expected ErrScheduleInvalid, got %v", err)
+	}
+}
+
+func TestValidate_SemiMonthlyDayOutOfRange(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "semimonthly", models.SemiMonthlySchedule{Days: []int{15, 32}})
+
+	if err := gen.Validate(source); !errors.Is(err, ErrScheduleInvalid) {
+		t.Errorf("expected ErrScheduleInvalid, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Monthly schedule tests
+// ---------------------------------------------------------------------------
+
+func TestGenerateMonthly_Basic(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "monthly", models.MonthlySchedule{Day: 15})
+
+	dates, err := gen.Generate(source, date(2025, time.January, 1), date(2025, time.April, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		date(2025, time.January, 15),
+		date(2025, time.February, 15),
+		date(2025, time.March, 15),
+		date(2025, time.April, 15),
+	}
+	assertDates(t, dates, expected)
+}
+
+func TestGenerateMonthly_EndOfMonthClamping(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "monthly", models.MonthlySchedule{Day: 31})
+
+	dates, err := gen.Generate(source, date(2025, time.January, 1), date(2025, time.May, 31))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		date(2025, time.January, 31),
+		date(2025, time.February, 28), // 31 clamped to 28
+		date(2025, time.March, 31),
+		date(2025, time.April, 30), // 31 clamped to 30
+		date(2025, time.May, 31),
+	}
+	assertDates(t, dates, expected)
+}
+
+func TestGenerateMonthly_FebruaryLeapVsNonLeap(t *testing.T) {
+	gen := NewPeriodGenerator()
+
+	tests := []struct {
+		day      int
+		year     int
+		expected time.Time
+	}{
+		{29, 2024, date(2024, time.February, 29)}, // leap year: 29 exists
+		{29, 2025, date(2025, time.February, 28)}, // clamped
+		{30, 2024, date(2024, time.February, 29)}, // clamped to leap day
+		{31, 2100, date(2100, time.February, 28)}, // century, not a leap year
+		{31, 2000, date(2000, time.February, 29)}, // divisible by 400, leap year
+	}
+
+	for _, tt := range tests {
+		source := makeSource(t, "monthly", models.MonthlySchedule{Day: tt.day})
+		dates, err := gen.Generate(source, date(tt.year, time.February, 1), date(tt.year, time.March, 1).AddDate(0, 0, -1))
+		if err != nil {
+			t.Fatalf("day %d, %d: unexpected error: %v", tt.day, tt.year, err)
+		}
+		assertDates(t, dates, []time.Time{tt.expected})
+	}
+}
+
+func TestGenerateMonthly_AdjustForWeekends(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "monthly", models.MonthlySchedule{Day: 1, AdjustForWeekends: true})
+
+	// 2025-03-01 is a Saturday and 2025-06-01 a Sunday
+	dates, err := gen.Generate(source, date(2025, time.March, 1), date(2025, time.June, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		date(2025, time.February, 28), // adjusted back before 'from', still included
+		date(2025, time.April, 1),
+		date(2025, time.May, 1),
+		date(2025, time.May, 30),
+	}
+	assertDates(t, dates, expected)
+}
+
+func TestGenerateMonthly_InvalidDay(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "monthly", models.MonthlySchedule{Day: 0})
+
+	if _, err := gen.Generate(source, date(2025, time.January, 1), date(2025, time.March, 31)); err == nil {
+		t.Fatal("expected error for day 0, got nil")
+	}
+}
+
+func TestValidate_MonthlyDayOutOfRange(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "monthly", models.MonthlySchedule{Day: 32})
+
+	if err := gen.Validate(source); !errors.Is(err, ErrScheduleInvalid) {
+		t.Errorf("expected ErrScheduleInvalid, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Custom schedule tests
+// ---------------------------------------------------------------------------
+
+func TestGenerateCustom_SubsetInRange(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "custom", models.CustomSchedule{
+		Dates: []string{"2025-04-20", "2025-01-10", "2025-02-03", "2025-02-03", "2025-03-15"},
+	})
+
+	dates, err := gen.Generate(source, date(2025, time.February, 1), date(2025, time.March, 31))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		date(2025, time.February, 3), // duplicate listed once
+		date(2025, time.March, 15),
+	}
+	assertDates(t, dates, expected)
+}
+
+func TestGenerateCustom_InvalidDate(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := makeSource(t, "custom", models.CustomSchedule{Dates: []string{"03/15/2025"}})
+
+	if _, err := gen.Generate(source, date(2025, time.January, 1), date(2025, time.December, 31)); err == nil {
+		t.Fatal("expected error for invalid date, got nil")
+	}
+}
+
+func TestValidate_Custom(t *testing.T) {
+	gen := NewPeriodGenerator()
+
+	tests := []struct {
+		name   string
+		detail string
+		valid  bool
+	}{
+		{"valid", `{"dates":["2025-01-10","2025-02-03"]}`, true},
+		{"empty", `{"dates":[]}`, false},
+		{"missing", `{}`, false},
+		{"bad format", `{"dates":["2025-1-10"]}`, false},
+		{"not a list", `{"dates":"2025-01-10"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := models.IncomeSource{PaySchedule: "custom", ScheduleDetail: json.RawMessage(tt.detail)}
+			err := gen.Validate(source)
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrScheduleInvalid) {
+				t.Errorf("expected ErrScheduleInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Semi-monthly: "last" day token
+// ---------------------------------------------------------------------------
+
+func TestGenerateSemiMonthly_LastToken(t *testing.T) {
+	gen := NewPeriodGenerator()
+	source := models.IncomeSource{
+		PaySchedule:    "semimonthly",
+		ScheduleDetail: json.RawMessage(`{"days":[15,"last"]}`),
+	}
+
+	dates, err := gen.Generate(source, date(2025, time.January, 1), date(2025, time.April, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []time.Time{
+		date(2025, time.January, 15),
+		date(2025, time.January, 31),
+		date(2025, time.February, 15),
+		date(2025, time.February, 28),
+		date(2025, time.March, 15),
+		date(2025, time.March, 31),
+		date(2025, time.April, 15),
+		date(2025, time.April, 30),
+	}
+	assertDates(t, dates, expected)
+}
+
+func TestSemiMonthlySchedule_LastTokenRoundTrip(t *testing.T) {
+	var schedule models.SemiMonthlySchedule
+	if err := json.Unmarshal([]byte(`{"days":[15,"last"],"adjust_for_weekends":true}`), &schedule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(schedule.Days) != 2 || schedule.Days[0] != 15 || schedule.Days[1] != models.LastDayOfMonth {
+		t.Fatalf("unexpected days: %v", schedule.Days)
+	}
+	if !schedule.AdjustForWeekends {
+		t.Error("expected adjust_for_weekends to be true")
+	}
+
+	out, err := json.Marshal(schedule)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"days":[15,"last"],"adjust_for_weekends":true}`
+	if string(out) != expected {
+		t.Errorf("marshal = %s, want %s", out, expected)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Weekend and holiday adjustment
+// ---------------------------------------------------------------------------
+
+func TestGenerate_AdjustsHolidayToPreviousOrNextBusinessDay(t *testing.T) {
+	gen := NewPeriodGenerator()
+
+	// Jan 19 2026 is Martin Luther King Jr. Day
+	prev := makeSource(t, "monthly", map[string]interface{}{"day": 19, "adjust": "previous_business_day"})
+	dates, err := gen.Generate(prev, date(2026, time.January, 1), date(2026, time.January, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, dates, []time.Time{date(2026, time.January, 16)})
+
+	next := makeSource(t, "monthly", map[string]interface{}{"day": 19, "adjust": "next_business_day"})
+	dates, err = gen.Generate(next, date(2026, time.January, 1), date(2026, time.January, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, dates, []time.Time{date(2026, time.January, 20)})
+}
+
+func TestGenerate_BiweeklyChristmasAndWeekendsOnlyCalendar(t *testing.T) {
+	gen := NewPeriodGenerator()
+	from, to := date(2026, time.December, 1), date(2026, time.December, 31)
+
+	// Christmas 2026 is a Friday payday
+	src := makeSource(t, "biweekly", map[string]interface{}{"anchor_date": "2026-12-11", "adjust": "previous_business_day"})
+	dates, err := gen.Generate(src, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, dates, []time.Time{date(2026, time.December, 11), date(2026, time.December, 24)})
+
+	weekendsOnly := makeSource(t, "biweekly", map[string]interface{}{"anchor_date": "2026-12-11", "adjust": "previous_business_day", "holidays": "none"})
+	dates, err = gen.Generate(weekendsOnly, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, dates, []time.Time{date(2026, time.December, 11), date(2026, time.December, 25)})
+}
+
+func TestGenerate_AdjustmentAtRangeEdges(t *testing.T) {
+	gen := NewPeriodGenerator()
+
+	// Feb 1 and Mar 1 2026 are Sundays. Feb 1 is scheduled in range, so it
+	// stays even though Jan 30 is not; Mar 1 is moved into the range
+	prev := makeSource(t, "monthly", map[string]interface{}{"day": 1, "adjust": "previous_business_day"})
+	dates, err := gen.Generate(prev, date(2026, time.February, 1), date(2026, time.February, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, dates, []time.Time{date(2026, time.January, 30), date(2026, time.February, 27)})
+
+	// Scheduled before the range, it moves into it
+	next := makeSource(t, "monthly", map[string]interface{}{"day": 1, "adjust": "next_business_day"})
+	dates, err = gen.Generate(next, date(2026, time.February, 2), date(2026, time.February, 28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, dates, []time.Time{date(2026, time.February, 2)})
+}
+
+type fixedHolidays map[time.Time]bool
+
+func (f fixedHolidays) IsHoliday(d time.Time) bool { return f[d] }
+
+func TestGenerate_CustomHolidayCalendar(t *testing.T) {
+	gen := NewPeriodGenerator().WithHolidayCalendar("company", fixedHolidays{date(2026, time.March, 13): true})
+
+	src := makeSource(t, "weekly", map[string]interface{}{"weekday": 5, "adjust": "next_business_day", "holidays": "company"})
+	dates, err := gen.Generate(src, date(2026, time.March, 9), date(2026, time.March, 22))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDates(t, dates, []time.Time{date(2026, time.March, 16), date(2026, time.March, 20)})
+}
+
+func TestValidate_Adjustment(t *testing.T) {
+	gen := NewPeriodGenerator()
+
+	for _, detail := range []map[string]interface{}{
+		{"day": 1, "adjust": "closest_business_day"},
+		{"day": 1, "adjust": "next_business_day", "holidays": "atlantis"},
+	} {
+		err := gen.Validate(makeSource(t, "monthly", detail))
+		if !errors.Is(err, ErrScheduleInvalid) {
+			t.Errorf("%v: expected ErrScheduleInvalid, got %v", detail, err)
+		}
+	}
+	if err := gen.Validate(makeSource(t, "monthly", map[string]interface{}{"day": 1, "adjust": "next_business_day", "holidays": "none"})); err != nil {
+		t.Errorf("expected a valid adjustment, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ReconcileTxn is an unreconciled ledger transaction.
+type ReconcileTxn struct {
+	ID     int
+	Date   time.Time
+	Amount float64
+}
+
+// ReconcileCandidate is an unpaid assignment a transaction may have paid.
+type ReconcileCandidate struct {
+	AssignmentID int
+	BillName     string
+	Date         time.Time // occurrence due date, or the pay date when unknown
+	Amount       float64   // planned amount
+}
+
+// ReconcileMatch pairs a transaction with the assignment it paid.
+type ReconcileMatch struct {
+	TransactionID int     `json:"transaction_id"`
+	AssignmentID  int     `json:"assignment_id"`
+	BillName      string  `json:"bill_name"`
+	Amount        float64 `json:"amount"`     // transaction amount, recorded as actual_amount
+	DaysApart     int     `json:"days_apart"` // between transaction and due date
+}
+
+// Reconcile matches transactions to assignments whose planned amount is
+// within tolerance and whose date is within windowDays. Transactions are taken
+// oldest first; each picks the closest unused candidate by date, then amount.
+func Reconcile(txns []ReconcileTxn, candidates []ReconcileCandidate, windowDays int, tolerance float64) []ReconcileMatch {
+	ordered := make([]ReconcileTxn, len(txns))
+	copy(ordered, txns)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Date.Before(ordered[j].Date) })
+
+	used := make(map[int]bool)
+	var matches []ReconcileMatch
+	for _, t := range ordered {
+		best := -1
+		var bestDays, bestDiff float64
+		for i, c := range candidates {
+			if used[c.AssignmentID] {
+				continue
+			}
+			diff := math.Abs(t.Amount - c.Amount)
+			if diff > tolerance+0.005 {
+				continue
+			}
+			days := math.Abs(t.Date.Sub(c.Date).Hours() / 24)
+			if days > float64(windowDays) {
+				continue
+			}
+			if best < 0 || days < bestDays || (days == bestDays && diff < bestDiff) {
+				best, bestDays, bestDiff = i, days, diff
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		c := candidates[best]
+		used[c.AssignmentID] = true
+		matches = append(matches, ReconcileMatch{
+			TransactionID: t.ID,
+			AssignmentID:  c.AssignmentID,
+			BillName:      c.BillName,
+			Amount:        t.Amount,
+			DaysApart:     int(math.Round(bestDays)),
+		})
+	}
+	return matches
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconcile_MatchesByAmountAndWindow(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	txns := []ReconcileTxn{
+		{ID: 1, Date: d(16), Amount: 60.40},  // Internet, a day late and 40 cents over
+		{ID: 2, Date: d(2), Amount: 1500},    // Rent
+		{ID: 3, Date: d(28), Amount: 60.00},  // outside the window of both $60 bills
+		{ID: 4, Date: d(3), Amount: 1499.50}, // Rent already taken
+	}
+	candidates := []ReconcileCandidate{
+		{AssignmentID: 10, BillName: "Rent", Date: d(1), Amount: 1500},
+		{AssignmentID: 11, BillName: "Internet", Date: d(15), Amount: 60},
+		{AssignmentID: 12, BillName: "Phone", Date: d(5), Amount: 60},
+	}
+
+	got := Reconcile(txns, candidates, 5, 1)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", got)
+	}
+	// Oldest transaction first
+	if got[0].TransactionID != 2 || got[0].AssignmentID != 10 || got[0].DaysApart != 1 {
+		t.Errorf("unexpected rent match: %+v", got[0])
+	}
+	if got[1].TransactionID != 1 || got[1].AssignmentID != 11 || got[1].Amount != 60.40 {
+		t.Errorf("unexpected internet match: %+v", got[1])
+	}
+}
+
+func TestReconcile_PrefersClosestDate(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	txns := []ReconcileTxn{{ID: 1, Date: d(14), Amount: 50}}
+	candidates := []ReconcileCandidate{
+		{AssignmentID: 1, Date: d(10), Amount: 50},
+		{AssignmentID: 2, Date: d(15), Amount: 50},
+	}
+	got := Reconcile(txns, candidates, 7, 0)
+	if len(got) != 1 || got[0].AssignmentID != 2 {
+		t.Errorf("expected the closer assignment, got %+v", got)
+	}
+}
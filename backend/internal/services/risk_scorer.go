@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Weights of the three risk components in the 0-100 score.
+const (
+	riskHistoryWeight = 0.4
+	riskBalanceWeight = 0.4
+	riskClusterWeight = 0.2
+)
+
+// riskClusterDays is the window within which bills count as due together.
+const riskClusterDays = 3
+
+// riskCushion is the share of a paycheck that should be left over once its
+// bills are paid; less than this starts to raise the balance risk.
+const riskCushion = 0.1
+
+// RiskPeriod is a pay period to score, with the bills assigned to it and the
+// track record of the paycheck it belongs to.
+type RiskPeriod struct {
+	PeriodID int
+	PayDate  time.Time
+	Source   string
+	Income   float64
+	Bills    []RiskBill
+	// PastAssignments and PastLate count assignments on earlier paychecks
+	// like this one, and how many of them were paid late, deferred or left
+	// overdue
+	PastAssignments int
+	PastLate        int
+}
+
+type RiskBill struct {
+	Amount  float64
+	DueDate *time.Time // nil counts as due on the pay date
+}
+
+// PeriodRisk is how likely a pay period is to fall behind, with the parts of
+// the score and the reasons behind it.
+type PeriodRisk struct {
+	PeriodID      int      `json:"period_id"`
+	PayDate       string   `json:"pay_date"` // YYYY-MM-DD
+	Source        string   `json:"source"`
+	Score         int      `json:"score"` // 0-100
+	Level         string   `json:"level"` // low, medium, high
+	LateRate      float64  `json:"late_rate"`
+	Remaining     float64  `json:"remaining"`       // income left after the period's bills
+	MaxDueCluster int      `json:"max_due_cluster"` // most bills due within riskClusterDays of each other
+	Reasons       []string `json:"reasons"`
+}
+
+// ScoreRisk scores each period from 0 (no risk) to 100. Late history on the
+// same paycheck and a thin or negative remaining balance weigh 40% each; bills
+// bunched up on the same few days weigh the other 20%.
+func ScoreRisk(periods []RiskPeriod) []PeriodRisk {
+	result := make([]PeriodRisk, 0, len(periods))
+	for _, p := range periods {
+		result = append(result, scorePeriod(p))
+	}
+	return result
+}
+
+func scorePeriod(p RiskPeriod) PeriodRisk {
+	risk := PeriodRisk{
+		PeriodID: p.PeriodID,
+		PayDate:  p.PayDate.Format("2006-01-02"),
+		Source:   p.Source,
+		Reasons:  []string{},
+	}
+
+	history := 0.0
+	if p.PastAssignments > 0 {
+		history = float64(p.PastLate) / float64(p.PastAssignments)
+		risk.LateRate = math.Round(history*1000) / 1000
+	}
+	if p.PastLate > 0 {
+		risk.Reasons = append(risk.Reasons,
+			fmt.Sprintf("%d of %d past bills on this paycheck fell behind", p.PastLate, p.PastAssignments))
+	}
+
+	total := 0.0
+	for _, b := range p.Bills {
+		total += b.Amount
+	}
+	risk.Remaining = math.Round((p.Income-total)*100) / 100
+
+	balance := 0.0
+	switch cushion := p.Income * riskCushion; {
+	case risk.Remaining < 0:
+		balance = 1
+		risk.Reasons = append(risk.Reasons, fmt.Sprintf("bills exceed the paycheck by $%.2f", -risk.Remaining))
+	case cushion > 0 && risk.Remaining < cushion:
+		balance = 1 - risk.Remaining/cushion
+		risk.Reasons = append(risk.Reasons, fmt.Sprintf("only $%.2f left after bills", risk.Remaining))
+	}
+
+	risk.MaxDueCluster = maxDueCluster(p)
+	// One bill alone is no cluster; five or more due together is the most
+	cluster := math.Max(0, math.Min(1, float64(risk.MaxDueCluster-1)/4))
+	if risk.MaxDueCluster >= 3 {
+		risk.Reasons = append(risk.Reasons,
+			fmt.Sprintf("%d bills due within %d days of each other", risk.MaxDueCluster, riskClusterDays))
+	}
+
+	risk.Score = int(math.Round(100 * (riskHistoryWeight*history + riskBalanceWeight*balance + riskClusterWeight*cluster)))
+	switch {
+	case risk.Score >= 60:
+		risk.Level = "high"
+	case risk.Score >= 30:
+		risk.Level = "medium"
+	default:
+		risk.Level = "low"
+	}
+	return risk
+}
+
+// maxDueCluster is the most bills due within any riskClusterDays window.
+func maxDueCluster(p RiskPeriod) int {
+	dates := make([]time.Time, 0, len(p.Bills))
+	for _, b := range p.Bills {
+		if b.DueDate != nil {
+			dates = append(dates, *b.DueDate)
+		} else {
+			dates = append(dates, p.PayDate)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	best, start := 0, 0
+	for end := range dates {
+		for dates[end].Sub(dates[start]) >= riskClusterDays*24*time.Hour {
+			start++
+		}
+		if n := end - start + 1; n > best {
+			best = n
+		}
+	}
+	return best
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func riskDate(s string) *time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return &d
+}
+
+func TestScoreRisk_QuietPeriodIsLow(t *testing.T) {
+	risks := ScoreRisk([]RiskPeriod{{
+		PeriodID: 1, PayDate: *riskDate("2025-03-01"), Income: 2000,
+		Bills:           []RiskBill{{Amount: 500, DueDate: riskDate("2025-03-05")}, {Amount: 300, DueDate: riskDate("2025-03-12")}},
+		PastAssignments: 20,
+	}})
+
+	if len(risks) != 1 {
+		t.Fatalf("expected one period, got %d", len(risks))
+	}
+	r := risks[0]
+	if r.Score != 0 || r.Level != "low" || r.Remaining != 1200 || len(r.Reasons) != 0 {
+		t.Errorf("expected a low score with no reasons, got %+v", r)
+	}
+}
+
+func TestScoreRisk_CombinesHistoryBalanceAndClustering(t *testing.T) {
+	risks := ScoreRisk([]RiskPeriod{{
+		PeriodID: 2, PayDate: *riskDate("2025-03-15"), Source: "Job", Income: 1000,
+		Bills: []RiskBill{
+			{Amount: 400, DueDate: riskDate("2025-03-16")},
+			{Amount: 400, DueDate: riskDate("2025-03-17")},
+			{Amount: 300}, // due on the pay date
+		},
+		PastAssignments: 10, PastLate: 5,
+	}})
+
+	r := risks[0]
+	// history 0.5, balance 1 (overdrawn), cluster (3-1)/4 = 0.5
+	if r.Score != 70 || r.Level != "high" {
+		t.Errorf("expected score 70 (high), got %d (%s)", r.Score, r.Level)
+	}
+	if r.LateRate != 0.5 || r.Remaining != -100 || r.MaxDueCluster != 3 {
+		t.Errorf("unexpected components: %+v", r)
+	}
+	if len(r.Reasons) != 3 {
+		t.Errorf("expected a reason per component, got %v", r.Reasons)
+	}
+}
+
+func TestScoreRisk_ThinCushion(t *testing.T) {
+	risks := ScoreRisk([]RiskPeriod{{
+		PeriodID: 3, PayDate: *riskDate("2025-03-01"), Income: 1000,
+		Bills: []RiskBill{{Amount: 950, DueDate: riskDate("2025-03-20")}},
+	}})
+
+	// $50 left of a $100 cushion is half the balance risk
+	if r := risks[0]; r.Score != 20 || r.Level != "low" {
+		t.Errorf("expected score 20, got %+v", r)
+	}
+}
+
+func TestMaxDueCluster_WindowSlides(t *testing.T) {
+	p := RiskPeriod{PayDate: *riskDate("2025-03-01"), Bills: []RiskBill{
+		{DueDate: riskDate("2025-03-10")}, {DueDate: riskDate("2025-03-01")},
+		{DueDate: riskDate("2025-03-11")}, {DueDate: riskDate("2025-03-12")},
+		{DueDate: riskDate("2025-03-13")},
+	}}
+	if got := maxDueCluster(p); got != 3 {
+		t.Errorf("expected 3 bills in the densest window, got %d", got)
+	}
+}
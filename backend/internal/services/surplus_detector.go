@@ -15,9 +15,16 @@ type SurplusMonth struct {
 	SurplusAmount float64 `json:"surplus_amount"`
 }
 
+// SkippedSource is an income source the detector could not evaluate.
+type SkippedSource struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
 type SurplusResult struct {
-	SurplusMonths []SurplusMonth `json:"surplus_months"`
-	AnnualSurplus float64        `json:"annual_surplus"`
+	SurplusMonths  []SurplusMonth  `json:"surplus_months"`
+	AnnualSurplus  float64         `json:"annual_surplus"`
+	SkippedSources []SkippedSource `json:"skipped_sources"`
 }
 
 type SurplusDetector struct {
@@ -32,12 +39,27 @@ func NewSurplusDetector() *SurplusDetector {
 
 func (d *SurplusDetector) Detect(sources []models.IncomeSource, from, to time.Time) (*SurplusResult, error) {
 	result := &SurplusResult{
-		SurplusMonths: []SurplusMonth{},
+		SurplusMonths:  []SurplusMonth{},
+		SkippedSources: []SkippedSource{},
 	}
 
 	for _, source := range sources {
+		// Determine expected checks per month
+		expectedPerMonth, ok := d.expectedPerMonth(source)
+		if !ok {
+			result.SkippedSources = append(result.SkippedSources, SkippedSource{
+				Source: source.Name,
+				Reason: fmt.Sprintf("unsupported pay schedule %q", source.PaySchedule),
+			})
+			continue
+		}
+
 		dates, err := d.generator.Generate(source, from, to)
 		if err != nil {
+			result.SkippedSources = append(result.SkippedSources, SkippedSource{
+				Source: source.Name,
+				Reason: err.Error(),
+			})
 			continue
 		}
 
@@ -53,9 +75,6 @@ func (d *SurplusDetector) Detect(sources []models.IncomeSource, from, to time.Ti
 			monthCounts[key]++
 		}
 
-		// Determine expected checks per month
-		expectedPerMonth := d.expectedPerMonth(source)
-
 		for month, count := range monthCounts {
 			if count > expectedPerMonth {
 				extra := count - expectedPerMonth
@@ -77,19 +96,56 @@ func (d *SurplusDetector) Detect(sources []models.IncomeSource, from, to time.Ti
 	return result, nil
 }
 
-func (d *SurplusDetector) expectedPerMonth(source models.IncomeSource) int {
+// AddIncomeEvents counts one-off income events as surplus in their month,
+// since no schedule expects them.
+func (d *SurplusDetector) AddIncomeEvents(result *SurplusResult, events []models.IncomeEvent) {
+	for _, e := range events {
+		date, err := time.Parse("2006-01-02", e.EventDate)
+		if err != nil {
+			continue
+		}
+		result.SurplusMonths = append(result.SurplusMonths, SurplusMonth{
+			Month:         date.Format("January 2006"),
+			Source:        e.Name,
+			SurplusAmount: e.Amount,
+		})
+		result.AnnualSurplus += e.Amount
+	}
+}
+
+// expectedPerMonth returns how many checks a source normally pays in a month.
+// One-time payments are never expected, so every occurrence counts as surplus.
+// It returns false for schedules the detector does not understand.
+func (d *SurplusDetector) expectedPerMonth(source models.IncomeSource) (int, bool) {
 	switch source.PaySchedule {
 	case "weekly":
-		return 4 // 4 weeks per month normally
+		// 4 weeks per month normally. With a skip rule, the fewest a month can
+		// get: 4 weeks, of which up to ceil(4/N) are skipped
+		var sched models.WeeklySchedule
+		json.Unmarshal(source.ScheduleDetail, &sched)
+		if sched.SkipEvery >= 2 {
+			return 4 - (4+sched.SkipEvery-1)/sched.SkipEvery, true
+		}
+		return 4, true
 	case "biweekly":
-		return 2 // 2 checks per month normally
+		return 2, true // 2 checks per month normally
 	case "semimonthly":
-		return 2 // always exactly 2
+		return 2, true // always exactly 2
+	case "monthly":
+		return 1, true
+	case "one_time":
+		return 0, true
 	default:
-		return 1
+		return 0, false
 	}
 }
 
+// ExpectedChecksPerMonth is how many checks a source normally pays in a
+// month, and false for schedules the detector does not understand.
+func ExpectedChecksPerMonth(source models.IncomeSource) (int, bool) {
+	return (&SurplusDetector{}).expectedPerMonth(source)
+}
+
 // GeneratePayDatesForYear is a convenience wrapper.
 func (d *SurplusDetector) GeneratePayDatesForYear(source models.IncomeSource, year int) ([]time.Time, error) {
 	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -103,6 +159,9 @@ func ScheduleDescription(source models.IncomeSource) string {
 	case "weekly":
 		var sched models.WeeklySchedule
 		json.Unmarshal(source.ScheduleDetail, &sched)
+		if sched.SkipEvery >= 2 {
+			return fmt.Sprintf("Every %s except every %s week", time.Weekday(sched.Weekday), ordinal(sched.SkipEvery))
+		}
 		return fmt.Sprintf("Every %s", time.Weekday(sched.Weekday))
 	case "biweekly":
 		var sched models.BiweeklySchedule
@@ -112,10 +171,46 @@ func ScheduleDescription(source models.IncomeSource) string {
 		var sched models.SemiMonthlySchedule
 		json.Unmarshal(source.ScheduleDetail, &sched)
 		if len(sched.Days) == 2 {
-			return fmt.Sprintf("%dth and %dth of each month", sched.Days[0], sched.Days[1])
+			return fmt.Sprintf("%s and %s of each month", semiMonthlyDayLabel(sched.Days[0]), semiMonthlyDayLabel(sched.Days[1]))
 		}
 		return "Twice monthly"
+	case "monthly":
+		var sched models.MonthlySchedule
+		json.Unmarshal(source.ScheduleDetail, &sched)
+		if sched.Day > 0 {
+			return fmt.Sprintf("%s of each month", semiMonthlyDayLabel(sched.Day))
+		}
+		return "Monthly"
+	case "custom":
+		var sched models.CustomSchedule
+		json.Unmarshal(source.ScheduleDetail, &sched)
+		if len(sched.Dates) == 1 {
+			return "1 custom date"
+		}
+		return fmt.Sprintf("%d custom dates", len(sched.Dates))
 	default:
 		return source.PaySchedule
 	}
 }
+
+func ordinal(n int) string {
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+		return fmt.Sprintf("%dth", n)
+	case n%10 == 1:
+		return fmt.Sprintf("%dst", n)
+	case n%10 == 2:
+		return fmt.Sprintf("%dnd", n)
+	case n%10 == 3:
+		return fmt.Sprintf("%drd", n)
+	default:
+		return fmt.Sprintf("%dth", n)
+	}
+}
+
+func semiMonthlyDayLabel(day int) string {
+	if day == models.LastDayOfMonth {
+		return "last day"
+	}
+	return fmt.Sprintf("%dth", day)
+}
@@ -480,26 +480,141 @@ func TestExpectedPerMonth(t *testing.T) {
 	tests := []struct {
 		schedule string
 		expected int
+		ok       bool
 	}{
-		{"weekly", 4},
-		{"biweekly", 2},
-		{"semimonthly", 2},
-		{"monthly", 1},
-		{"", 1},
-		{"unknown", 1},
+		{"weekly", 4, true},
+		{"biweekly", 2, true},
+		{"semimonthly", 2, true},
+		{"monthly", 1, true},
+		{"one_time", 0, true},
+		{"", 0, false},
+		{"unknown", 0, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.schedule, func(t *testing.T) {
 			source := models.IncomeSource{PaySchedule: tt.schedule}
-			got := d.expectedPerMonth(source)
-			if got != tt.expected {
-				t.Errorf("expectedPerMonth(%q) = %d, want %d", tt.schedule, got, tt.expected)
+			got, ok := d.expectedPerMonth(source)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("expectedPerMonth(%q) = %d, %v, want %d, %v", tt.schedule, got, ok, tt.expected, tt.ok)
 			}
 		})
 	}
 }
 
+func TestExpectedPerMonth_WeeklySkipEvery(t *testing.T) {
+	d := NewSurplusDetector()
+
+	// The fewest checks any month gets: 4 weeks less the most that can be skipped
+	for skip, expected := range map[int]int{2: 2, 3: 2, 4: 3, 5: 3} {
+		source := models.IncomeSource{
+			PaySchedule:    "weekly",
+			ScheduleDetail: mustJSON(t, models.WeeklySchedule{Weekday: 5, SkipEvery: skip, AnchorDate: "2025-01-03"}),
+		}
+		if got, ok := d.expectedPerMonth(source); got != expected || !ok {
+			t.Errorf("skip_every %d: expectedPerMonth = %d, %v, want %d, true", skip, got, ok, expected)
+		}
+	}
+}
+
+func TestDetect_WeeklySkipEverySurplus(t *testing.T) {
+	d := NewSurplusDetector()
+	source := models.IncomeSource{
+		Name:           "Agency",
+		PaySchedule:    "weekly",
+		ScheduleDetail: mustJSON(t, models.WeeklySchedule{Weekday: 5, SkipEvery: 3, AnchorDate: "2025-01-03"}),
+		DefaultAmount:  ptrFloat64(600),
+	}
+
+	// January pays on the 3rd, 10th, 24th and 31st; February only the 14th and 21st
+	result, err := d.Detect([]models.IncomeSource{source}, date(2025, time.January, 1), date(2025, time.February, 28))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.SurplusMonths) != 1 {
+		t.Fatalf("expected 1 surplus month, got %+v", result.SurplusMonths)
+	}
+	jan := findSurplusForMonth(result, "January 2025", "Agency")
+	if jan == nil || jan.ExtraChecks != 2 || !floatEqual(jan.SurplusAmount, 1200) {
+		t.Errorf("unexpected January surplus: %+v", jan)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// TestDetect_OneTimeIsSurplus — a one-time payment is entirely surplus
+// ---------------------------------------------------------------------------
+
+func TestDetect_OneTimeIsSurplus(t *testing.T) {
+	d := NewSurplusDetector()
+
+	bonus := models.IncomeSource{
+		Name:           "Bonus",
+		PaySchedule:    "one_time",
+		ScheduleDetail: mustJSON(t, models.OneTimeSchedule{Date: "2025-03-14"}),
+		DefaultAmount:  ptrFloat64(1500.0),
+		IsActive:       true,
+	}
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	result, err := d.Detect([]models.IncomeSource{bonus}, from, to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sm := findSurplusForMonth(result, "March 2025", "Bonus")
+	if sm == nil {
+		t.Fatal("expected surplus entry for March 2025")
+	}
+	if sm.ExtraChecks != 1 {
+		t.Errorf("expected 1 extra check, got %d", sm.ExtraChecks)
+	}
+	if !floatEqual(result.AnnualSurplus, 1500.0) {
+		t.Errorf("expected annual surplus 1500, got %.2f", result.AnnualSurplus)
+	}
+	if len(result.SkippedSources) != 0 {
+		t.Errorf("expected no skipped sources, got %v", result.SkippedSources)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// TestDetect_ReportsSkippedSources — unusable sources are listed, not hidden
+// ---------------------------------------------------------------------------
+
+func TestDetect_ReportsSkippedSources(t *testing.T) {
+	d := NewSurplusDetector()
+
+	sources := []models.IncomeSource{
+		{Name: "Broken", PaySchedule: "weekly", ScheduleDetail: json.RawMessage(`{invalid json`), IsActive: true},
+		{Name: "Royalties", PaySchedule: "quarterly", ScheduleDetail: json.RawMessage(`{}`), IsActive: true},
+		weeklySource(t, "Job", 5, ptrFloat64(500.0)),
+	}
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	result, err := d.Detect(sources, from, to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.SkippedSources) != 2 {
+		t.Fatalf("expected 2 skipped sources, got %d: %v", len(result.SkippedSources), result.SkippedSources)
+	}
+	if result.SkippedSources[0].Source != "Broken" || result.SkippedSources[1].Source != "Royalties" {
+		t.Errorf("unexpected skipped sources: %v", result.SkippedSources)
+	}
+	for _, skipped := range result.SkippedSources {
+		if skipped.Reason == "" {
+			t.Errorf("expected a reason for skipped source %q", skipped.Source)
+		}
+	}
+	if len(result.SurplusMonths) == 0 {
+		t.Error("expected the valid weekly source to still produce surplus months")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // TestGeneratePayDatesForYear
 // ---------------------------------------------------------------------------
@@ -648,6 +763,16 @@ func TestScheduleDescription_Weekly(t *testing.T) {
 	}
 }
 
+func TestScheduleDescription_WeeklySkipEvery(t *testing.T) {
+	source := models.IncomeSource{
+		PaySchedule:    "weekly",
+		ScheduleDetail: mustJSON(t, models.WeeklySchedule{Weekday: 5, SkipEvery: 3, AnchorDate: "2025-01-03"}),
+	}
+	if got := ScheduleDescription(source); got != "Every Friday except every 3rd week" {
+		t.Errorf("ScheduleDescription() = %q", got)
+	}
+}
+
 func TestScheduleDescription_Biweekly(t *testing.T) {
 	tests := []struct {
 		weekday  int
@@ -704,6 +829,18 @@ func TestScheduleDescription_SemiMonthly(t *testing.T) {
 		}
 	})
 
+	t.Run("last day token", func(t *testing.T) {
+		source := models.IncomeSource{
+			PaySchedule:    "semimonthly",
+			ScheduleDetail: json.RawMessage(`{"days":[15,"last"]}`),
+		}
+		got := ScheduleDescription(source)
+		expected := "15th and last day of each month"
+		if got != expected {
+			t.Errorf("ScheduleDescription() = %q, want %q", got, expected)
+		}
+	})
+
 	t.Run("not two days falls back", func(t *testing.T) {
 		source := models.IncomeSource{
 			PaySchedule: "semimonthly",
@@ -731,11 +868,25 @@ func TestScheduleDescription_SemiMonthly(t *testing.T) {
 	})
 }
 
+func TestScheduleDescription_Monthly(t *testing.T) {
+	source := models.IncomeSource{
+		PaySchedule:    "monthly",
+		ScheduleDetail: json.RawMessage(`{"day":25}`),
+	}
+	if got := ScheduleDescription(source); got != "25th of each month" {
+		t.Errorf("ScheduleDescription() = %q, want %q", got, "25th of each month")
+	}
+
+	source.ScheduleDetail = json.RawMessage(`{}`)
+	if got := ScheduleDescription(source); got != "Monthly" {
+		t.Errorf("ScheduleDescription() = %q, want %q", got, "Monthly")
+	}
+}
+
 func TestScheduleDescription_Default(t *testing.T) {
 	tests := []struct {
 		schedule string
 	}{
-		{"monthly"},
 		{"quarterly"},
 		{"annual"},
 	}
@@ -1027,3 +1178,28 @@ func TestScheduleDescription_InvalidJSON(t *testing.T) {
 		}
 	})
 }
+
+// ---------------------------------------------------------------------------
+// TestAddIncomeEvents
+// ---------------------------------------------------------------------------
+
+func TestAddIncomeEvents(t *testing.T) {
+	d := NewSurplusDetector()
+	result := &SurplusResult{SurplusMonths: []SurplusMonth{}, AnnualSurplus: 100}
+
+	d.AddIncomeEvents(result, []models.IncomeEvent{
+		{Name: "Annual bonus", Amount: 3000, EventDate: "2025-03-14"},
+		{Name: "Tax refund", Amount: 850, EventDate: "2025-04-02"},
+	})
+
+	bonus := findSurplusForMonth(result, "March 2025", "Annual bonus")
+	if bonus == nil || bonus.SurplusAmount != 3000 || bonus.ExtraChecks != 0 {
+		t.Errorf("expected a 3000 March surplus from the bonus, got %+v", bonus)
+	}
+	if findSurplusForMonth(result, "April 2025", "Tax refund") == nil {
+		t.Error("expected an April surplus from the tax refund")
+	}
+	if result.AnnualSurplus != 3950 {
+		t.Errorf("AnnualSurplus = %.2f, want 3950", result.AnnualSurplus)
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Wallet statement formats ParseWallet understands. Each is also the source
+// recorded on the ledger transactions it imports.
+const (
+	WalletAppleCard = "apple_card"
+	WalletGooglePay = "google_pay"
+)
+
+// ValidWalletFormat reports whether format is one ParseWallet reads.
+func ValidWalletFormat(format string) bool {
+	return format == WalletAppleCard || format == WalletGooglePay
+}
+
+// walletLayout is where a wallet export keeps each field. status, when set,
+// names a column whose value must be one of okStatuses for the row to count.
+type walletLayout struct {
+	date, amount, description, merchant string
+	status                              string
+	okStatuses                          []string
+	dateFormats                         []string
+}
+
+var walletLayouts = map[string]walletLayout{
+	// Transaction Date,Clearing Date,Description,Merchant,Category,Type,Amount (USD),Purchased By
+	// Purchases are positive; payments to the card are negative and typed Payment.
+	WalletAppleCard: {
+		date: "Transaction Date", amount: "Amount (USD)", description: "Description", merchant: "Merchant",
+		status: "Type", okStatuses: []string{"purchase", "debit", "installment"},
+		dateFormats: []string{"01/02/2006", "1/2/2006"},
+	},
+	// Time,Transaction ID,Description,Product,Payment method,Status,Amount
+	// Time reads like "Mar 5, 2026, 2:14:07 PM PST" and Amount like "USD 12.99".
+	WalletGooglePay: {
+		date: "Time", amount: "Amount", description: "Description",
+		status: "Status", okStatuses: []string{"complete", "completed"},
+		dateFormats: []string{"Jan 2, 2006", "2006-01-02", "01/02/2006"},
+	},
+}
+
+// ParseWallet reads an Apple Card or Google Pay statement export. Card
+// payments, refunds and declined or pending charges are skipped with a
+// warning, and each transaction carries the payee its merchant normalizes to.
+func (imp *CSVImporter) ParseWallet(r io.Reader, format string) ([]BankTransaction, []string, error) {
+	layout, ok := walletLayouts[format]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown wallet format %q", format)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading csv header: %w", err)
+	}
+	// Exports saved from a spreadsheet may start with a byte order mark
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	dateCol, err := resolveColumn(header, layout.date, "date")
+	if err != nil {
+		return nil, nil, err
+	}
+	amountCol, err := resolveColumn(header, layout.amount, "amount")
+	if err != nil {
+		return nil, nil, err
+	}
+	descCol, err := resolveColumn(header, layout.description, "description")
+	if err != nil {
+		return nil, nil, err
+	}
+	merchantCol, statusCol := -1, -1
+	if layout.merchant != "" {
+		if i, err := resolveColumn(header, layout.merchant, "merchant"); err == nil {
+			merchantCol = i
+		}
+	}
+	if layout.status != "" {
+		if i, err := resolveColumn(header, layout.status, "status"); err == nil {
+			statusCol = i
+		}
+	}
+
+	var txns []BankTransaction
+	var warnings []string
+	for line := 2; ; line++ {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading csv line %d: %w", line, err)
+		}
+		if len(rec) <= dateCol || len(rec) <= amountCol || len(rec) <= descCol {
+			warnings = append(warnings, fmt.Sprintf("line %d: too few columns", line))
+			continue
+		}
+
+		if statusCol >= 0 && statusCol < len(rec) && !containsFold(layout.okStatuses, rec[statusCol]) {
+			warnings = append(warnings, fmt.Sprintf("line %d: skipped %s", line, strings.ToLower(strings.TrimSpace(rec[statusCol]))))
+			continue
+		}
+
+		date, ok := parseWalletDate(rec[dateCol], layout.dateFormats)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("line %d: unrecognized date %q", line, rec[dateCol]))
+			continue
+		}
+		amount := parseCSVAmount(stripCurrencyCode(rec[amountCol]))
+		if amount == nil {
+			warnings = append(warnings, fmt.Sprintf("line %d: unrecognized amount %q", line, rec[amountCol]))
+			continue
+		}
+		// Apple Card lists credits as negative purchases
+		if format == WalletAppleCard && *amount < 0 {
+			warnings = append(warnings, fmt.Sprintf("line %d: skipped credit", line))
+			continue
+		}
+
+		desc := strings.TrimSpace(rec[descCol])
+		payee := desc
+		if merchantCol >= 0 && merchantCol < len(rec) && strings.TrimSpace(rec[merchantCol]) != "" {
+			payee = rec[merchantCol]
+		}
+		txns = append(txns, BankTransaction{
+			Row:         line,
+			Date:        date,
+			Amount:      math.Abs(*amount),
+			Description: desc,
+			Payee:       NormalizePayee(payee),
+		})
+	}
+	return txns, warnings, nil
+}
+
+// parseWalletDate tries each layout on the date, then on the text before a
+// second comma so "Mar 5, 2026, 2:14:07 PM PST" reads as Mar 5, 2026.
+func parseWalletDate(s string, layouts []string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	candidates := []string{s}
+	if parts := strings.SplitN(s, ",", 3); len(parts) == 3 {
+		candidates = append(candidates, parts[0]+","+parts[1])
+	}
+	if i := strings.IndexAny(s, " T"); i > 0 {
+		candidates = append(candidates, s[:i])
+	}
+	for _, c := range candidates {
+		for _, l := range layouts {
+			if t, err := time.Parse(l, strings.TrimSpace(c)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+var currencyCodeRe = regexp.MustCompile(`^\s*[A-Z]{3}\s+|\s+[A-Z]{3}\s*$`)
+
+// stripCurrencyCode drops a leading or trailing ISO code, as in "USD 12.99".
+func stripCurrencyCode(s string) string {
+	return currencyCodeRe.ReplaceAllString(s, "")
+}
+
+func containsFold(list []string, s string) bool {
+	s = strings.TrimSpace(s)
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// payeePrefixes are payment processors that card statements put in front of
+// the merchant's own name.
+var payeePrefixes = []string{"sq *", "sq*", "tst* ", "tst*", "paypal *", "pp*", "apl*", "apple.com/bill", "google *", "goog*", "amzn mktp", "ach "}
+
+var (
+	payeeStoreNumberRe = regexp.MustCompile(`\s*#\s*\S*`)
+	payeeNoiseRe       = regexp.MustCompile(`[^a-z&' ]+`)
+)
+
+// payeeTrailers are words a card statement appends after the merchant name:
+// US state codes and the like, which only ever appear last.
+var payeeTrailers = map[string]bool{
+	"al": true, "ak": true, "az": true, "ar": true, "ca": true, "co": true, "ct": true, "de": true, "fl": true,
+	"ga": true, "hi": true, "id": true, "il": true, "ia": true, "ks": true, "ky": true, "la": true, "me": true,
+	"md": true, "ma": true, "mi": true, "mn": true, "ms": true, "mo": true, "mt": true, "ne": true, "nv": true,
+	"nh": true, "nj": true, "nm": true, "ny": true, "nc": true, "nd": true, "oh": true, "ok": true, "or": true,
+	"pa": true, "ri": true, "sc": true, "sd": true, "tn": true, "tx": true, "ut": true, "vt": true, "va": true,
+	"wa": true, "wv": true, "wi": true, "wy": true, "dc": true, "us": true, "usa": true,
+	"pending": true, "inc": true, "llc": true, "com": true,
+}
+
+// NormalizePayee reduces a statement description to the merchant behind it,
+// so "SQ *JOE'S PIZZA #123 ST LOUIS MO" and "Joe's Pizza" agree. It drops
+// processor prefixes, store numbers, digits and a trailing state code, and
+// lowercases the rest. Bill names go through it too before being compared.
+func NormalizePayee(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, p := range payeePrefixes {
+		if strings.HasPrefix(s, p) {
+			s = strings.TrimSpace(s[len(p):])
+			break
+		}
+	}
+	s = payeeStoreNumberRe.ReplaceAllString(s, " ")
+	s = strings.NewReplacer(".com", " ", "*", " ", "/", " ", "-", " ", ".", "").Replace(s)
+	s = payeeNoiseRe.ReplaceAllString(s, " ")
+
+	words := strings.Fields(s)
+	for len(words) > 1 && payeeTrailers[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// payeeMatchesBill reports whether a normalized payee names the bill: the
+// bill's normalized name runs through it as whole words. The other way round
+// would let a short payee like "car" settle "Car Insurance".
+func payeeMatchesBill(payee, billName string) bool {
+	name := NormalizePayee(billName)
+	if payee == "" || name == "" {
+		return false
+	}
+	return strings.Contains(" "+payee+" ", " "+name+" ")
+}
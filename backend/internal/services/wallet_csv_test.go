@@ -0,0 +1,92 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWallet_AppleCard(t *testing.T) {
+	data := "\ufeffTransaction Date,Clearing Date,Description,Merchant,Category,Type,Amount (USD),Purchased By\n" +
+		"03/02/2026,03/03/2026,NETFLIX.COM 866-579-7172 CA,Netflix,Other,Purchase,15.49,Sam\n" +
+		"03/04/2026,03/05/2026,ACH DEPOSIT INTERNET TRANSFER,Apple Card,Payment,Payment,-500.00,Sam\n" +
+		"03/06/2026,03/07/2026,SQ *JOE'S PIZZA #123 ST LOUIS MO,,Restaurants,Purchase,22.10,Sam\n" +
+		"03/08/2026,03/08/2026,RETURN AMAZON,Amazon,Shopping,Purchase,-12.00,Sam\n"
+
+	txns, warnings, err := NewCSVImporter().ParseWallet(strings.NewReader(data), WalletAppleCard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 2 {
+		t.Fatalf("expected 2 purchases, got %+v", txns)
+	}
+	if txns[0].Payee != "netflix" || txns[0].Amount != 15.49 || !txns[0].Date.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected first transaction: %+v", txns[0])
+	}
+	// Without a merchant the description is normalized instead
+	if txns[1].Payee != "joe's pizza st louis" {
+		t.Errorf("expected the description's payee, got %q", txns[1].Payee)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("expected the payment and the credit skipped, got %v", warnings)
+	}
+}
+
+func TestParseWallet_GooglePay(t *testing.T) {
+	data := `Time,Transaction ID,Description,Product,Payment method,Status,Amount
+"Mar 5, 2026, 2:14:07 PM PST",GPA.1,Spotify USA,Google Pay,Visa ****1234,Complete,USD 10.99
+"Mar 6, 2026, 9:00:00 AM PST",GPA.2,Coffee Shop,Google Pay,Visa ****1234,Declined,USD 4.50
+`
+	txns, warnings, err := NewCSVImporter().ParseWallet(strings.NewReader(data), WalletGooglePay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 || txns[0].Amount != 10.99 || txns[0].Payee != "spotify" ||
+		!txns[0].Date.Equal(time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected transactions: %+v", txns)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "declined") {
+		t.Errorf("expected the declined charge skipped, got %v", warnings)
+	}
+}
+
+func TestParseWallet_UnknownFormat(t *testing.T) {
+	if _, _, err := NewCSVImporter().ParseWallet(strings.NewReader("a\n"), "venmo"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestNormalizePayee(t *testing.T) {
+	for in, want := range map[string]string{
+		"SQ *JOE'S PIZZA #123 ST LOUIS MO": "joe's pizza st louis",
+		"NETFLIX.COM 866-579-7172 CA":      "netflix",
+		"TST* Blue Bottle Coffee":          "blue bottle coffee",
+		"Spotify USA":                      "spotify",
+		"PAYPAL *HULU 402-935-7733":        "hulu",
+		"Electric Co":                      "electric",
+	} {
+		if got := NormalizePayee(in); got != want {
+			t.Errorf("NormalizePayee(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMatchBackfill_ByPayee(t *testing.T) {
+	d := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	txns := []BankTransaction{
+		{Row: 2, Date: d(2), Amount: 15.49, Description: "NFLX DIGITAL 866-579-7172", Payee: "netflix"},
+		{Row: 3, Date: d(2), Amount: 40, Description: "CARWASH", Payee: "car"},
+	}
+	candidates := []BackfillCandidate{
+		{AssignmentID: 1, BillName: "Netflix", Date: d(1)},
+		{AssignmentID: 2, BillName: "Car Insurance", Date: d(1)},
+	}
+
+	matches, unmatched := MatchBackfill(txns, candidates, 5)
+	if len(matches) != 1 || matches[0].AssignmentID != 1 {
+		t.Errorf("expected only Netflix matched by payee, got %+v", matches)
+	}
+	if len(unmatched) != 1 {
+		t.Errorf("expected the car wash unmatched, got %+v", unmatched)
+	}
+}
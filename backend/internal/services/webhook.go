@@ -0,0 +1,44 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// WebhookMaxAttempts is how many times a delivery is tried before it is
+// marked failed.
+const WebhookMaxAttempts = 6
+
+// SignWebhook returns the signature header value for a delivery: an
+// HMAC-SHA256 over "<unix timestamp>.<body>", so receivers can reject both
+// forged and replayed requests.
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff is the wait before retrying after the given number of failed
+// attempts: 30s doubling each time, capped at 6 hours.
+func WebhookBackoff(attempts int) time.Duration {
+	const (
+		base  = 30 * time.Second
+		limit = 6 * time.Hour
+	)
+	if attempts < 1 {
+		return 0
+	}
+	d := base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= limit {
+			return limit
+		}
+	}
+	return d
+}
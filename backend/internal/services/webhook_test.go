@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	sig := SignWebhook("secret", ts, []byte(`{"type":"assignment.paid"}`))
+	if len(sig) != len("sha256=")+64 || sig[:7] != "sha256=" {
+		t.Fatalf("unexpected signature format: %s", sig)
+	}
+	if sig != SignWebhook("secret", ts, []byte(`{"type":"assignment.paid"}`)) {
+		t.Error("signature is not deterministic")
+	}
+	if sig == SignWebhook("other", ts, []byte(`{"type":"assignment.paid"}`)) {
+		t.Error("signature does not depend on the secret")
+	}
+	if sig == SignWebhook("secret", ts.Add(time.Second), []byte(`{"type":"assignment.paid"}`)) {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := WebhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("WebhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSXBill is a bill row of an exported budget sheet. Its column A label is
+// written in the forms parseBillLabel reads back.
+type XLSXBill struct {
+	Name       string
+	DueDay     *int
+	IsAutopay  bool
+	DefaultAmt *float64
+	Biweekly   bool
+	CreditCard *ParsedCreditCard
+}
+
+// XLSXCell is what a pay period holds for one bill.
+type XLSXCell struct {
+	Amount *float64
+	Status string // pending, paid, deferred, skipped
+	Due    *time.Time
+	Note   string
+}
+
+// XLSXPeriod is one pay period's column group. Cells line up with the bills
+// passed alongside it; a nil cell leaves the bill blank in that period.
+type XLSXPeriod struct {
+	PayDate  time.Time
+	Source   string
+	Income   float64
+	Spent    float64
+	Leftover float64
+	Cells    []*XLSXCell
+}
+
+// Columns per pay period, starting at B, as the importer counts them: the
+// amount or status marker, the due date and a note.
+const xlsxGroupWidth = 3
+
+// WriteXLSX writes bills and periods as a workbook in the legacy layout the
+// importer reads: a "Budget" sheet with bill labels in column A from row 3,
+// each pay period's date heading a group of three columns in row 1, and the
+// Est. Pay, TOTAL and LEFT rows the importer skips under the bills.
+func WriteXLSX(w io.Writer, bills []XLSXBill, periods []XLSXPeriod) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Budget"
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		return err
+	}
+	set := func(col, row int, v any) error {
+		cell, err := excelize.CoordinatesToCellName(col, row)
+		if err != nil {
+			return err
+		}
+		return f.SetCellValue(sheet, cell, v)
+	}
+
+	if err := set(1, 2, "Bill"); err != nil {
+		return err
+	}
+	for i, b := range bills {
+		if err := set(1, i+3, BillLabel(b)); err != nil {
+			return err
+		}
+	}
+	totalsRow := len(bills) + 4
+	for i, label := range []string{"Est. Pay", "TOTAL", "LEFT"} {
+		if err := set(1, totalsRow+i, label); err != nil {
+			return err
+		}
+	}
+
+	for p, period := range periods {
+		col := 2 + p*xlsxGroupWidth
+		if err := set(col, 1, period.PayDate.Format("Jan 2, 2006")); err != nil {
+			return err
+		}
+		if err := set(col, 2, period.Source); err != nil {
+			return err
+		}
+		if err := set(col+1, 2, "Due"); err != nil {
+			return err
+		}
+		if err := set(col+2, 2, "Notes"); err != nil {
+			return err
+		}
+		for i, c := range period.Cells {
+			if c == nil || i >= len(bills) {
+				continue
+			}
+			if v := cellValue(*c); v != nil {
+				if err := set(col, i+3, v); err != nil {
+					return err
+				}
+			}
+			if c.Due != nil {
+				if err := set(col+1, i+3, c.Due.Format("Jan 2")); err != nil {
+					return err
+				}
+			}
+			if c.Note != "" {
+				if err := set(col+2, i+3, c.Note); err != nil {
+					return err
+				}
+			}
+		}
+		for i, v := range []float64{period.Income, period.Spent, period.Leftover} {
+			if err := set(col, totalsRow+i, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := f.SetColWidth(sheet, "A", "A", 40); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// cellValue is the inverse of ParseCellValue: a plain number for a pending
+// amount, so Excel can sum it, and the **paid and |--> markers otherwise.
+// Skipped bills are left blank.
+func cellValue(c XLSXCell) any {
+	switch c.Status {
+	case "paid":
+		if c.Amount == nil {
+			return "**paid"
+		}
+		return formatAmount(*c.Amount) + "**paid"
+	case "deferred":
+		return "|-->"
+	case "skipped":
+		return nil
+	}
+	if c.Amount == nil {
+		return nil
+	}
+	return *c.Amount
+}
+
+// BillLabel formats a bill's column A label the way the legacy spreadsheet
+// wrote it, e.g. "Verizon (16th) - Auto" or
+// "IzzCC - QS ***8186 :: (statement=7th, due=4th)".
+func BillLabel(b XLSXBill) string {
+	if cc := b.CreditCard; cc != nil && b.DueDay != nil {
+		name := b.Name
+		if cc.CardLabel != "" {
+			name += " - " + cc.CardLabel
+		}
+		return fmt.Sprintf("%s :: (statement=%s, due=%s)", name, ordinal(cc.StatementDay), ordinal(*b.DueDay))
+	}
+	if b.DueDay != nil {
+		day := ordinal(*b.DueDay)
+		if b.IsAutopay {
+			// Only whole amounts fit the "(Nth Auto - Amount)" form
+			if b.DefaultAmt != nil && *b.DefaultAmt == math.Trunc(*b.DefaultAmt) && *b.DefaultAmt >= 0 {
+				return fmt.Sprintf("%s (%s Auto - %s)", b.Name, day, formatAmount(*b.DefaultAmt))
+			}
+			return fmt.Sprintf("%s (%s) - Auto", b.Name, day)
+		}
+		return fmt.Sprintf("%s (%s)", b.Name, day)
+	}
+	if b.Biweekly && b.DefaultAmt != nil && *b.DefaultAmt == math.Trunc(*b.DefaultAmt) && *b.DefaultAmt >= 0 {
+		return fmt.Sprintf("%s ($%s bi-weekly)", b.Name, formatAmount(*b.DefaultAmt))
+	}
+	return b.Name
+}
+
+// formatAmount writes an amount without trailing zeros, e.g. 150 or 150.5.
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestBillLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		bill XLSXBill
+		want string
+	}{
+		{"plain", XLSXBill{Name: "Groceries"}, "Groceries"},
+		{"due day", XLSXBill{Name: "Hulu", DueDay: intPtr(7)}, "Hulu (7th)"},
+		{"autopay", XLSXBill{Name: "Verizon", DueDay: intPtr(16), IsAutopay: true}, "Verizon (16th) - Auto"},
+		{"autopay whole amount", XLSXBill{Name: "Saving", DueDay: intPtr(12), IsAutopay: true, DefaultAmt: floatPtr(25)}, "Saving (12th Auto - 25)"},
+		{"autopay cents", XLSXBill{Name: "Gym", DueDay: intPtr(1), IsAutopay: true, DefaultAmt: floatPtr(24.99)}, "Gym (1st) - Auto"},
+		{"biweekly", XLSXBill{Name: "House Cleaning", Biweekly: true, DefaultAmt: floatPtr(160)}, "House Cleaning ($160 bi-weekly)"},
+		{"card with label", XLSXBill{Name: "IzzCC", DueDay: intPtr(4), CreditCard: &ParsedCreditCard{CardLabel: "QS ***8186", StatementDay: 7}},
+			"IzzCC - QS ***8186 :: (statement=7th, due=4th)"},
+		{"card", XLSXBill{Name: "Chase", DueDay: intPtr(17), CreditCard: &ParsedCreditCard{StatementDay: 20}},
+			"Chase :: (statement=20th, due=17th)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BillLabel(tt.bill); got != tt.want {
+				t.Errorf("BillLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteXLSX_RoundTrip(t *testing.T) {
+	bills := []XLSXBill{
+		{Name: "Rent", DueDay: intPtr(1)},
+		{Name: "Verizon", DueDay: intPtr(16), IsAutopay: true},
+		{Name: "Chase", DueDay: intPtr(17), CreditCard: &ParsedCreditCard{StatementDay: 20}},
+		{Name: "House Cleaning", Biweekly: true, DefaultAmt: floatPtr(160)},
+	}
+	due := time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)
+	periods := []XLSXPeriod{
+		{
+			PayDate: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Source: "Acme", Income: 2000, Spent: 1300, Leftover: 700,
+			Cells: []*XLSXCell{
+				{Amount: floatPtr(1200), Status: "paid"},
+				{Amount: floatPtr(100), Status: "pending", Due: &due, Note: "new plan"},
+				{Amount: floatPtr(80), Status: "deferred"},
+				nil,
+			},
+		},
+		{
+			PayDate: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC), Source: "Acme", Income: 2000,
+			Cells: []*XLSXCell{nil, nil, nil, {Amount: floatPtr(160), Status: "skipped"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, bills, periods); err != nil {
+		t.Fatal(err)
+	}
+
+	imp := newImporter()
+	preview, err := imp.ParseReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.PeriodCount != 2 {
+		t.Errorf("PeriodCount = %d, want 2", preview.PeriodCount)
+	}
+	if len(preview.Bills) != len(bills) {
+		t.Fatalf("parsed %d bills, want %d: %+v", len(preview.Bills), len(bills), preview.Bills)
+	}
+	for i, b := range bills {
+		got := preview.Bills[i]
+		if got.Name != b.Name {
+			t.Errorf("bill %d: Name = %q, want %q", i, got.Name, b.Name)
+		}
+		if (got.DueDay == nil) != (b.DueDay == nil) || (b.DueDay != nil && *got.DueDay != *b.DueDay) {
+			t.Errorf("bill %d: DueDay = %v, want %v", i, got.DueDay, b.DueDay)
+		}
+		if got.IsAutopay != b.IsAutopay {
+			t.Errorf("bill %d: IsAutopay = %v, want %v", i, got.IsAutopay, b.IsAutopay)
+		}
+		if (got.CreditCard == nil) != (b.CreditCard == nil) {
+			t.Errorf("bill %d: CreditCard = %+v, want %+v", i, got.CreditCard, b.CreditCard)
+		}
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cells := map[string]string{"B3": "paid", "B4": "pending", "B5": "deferred"}
+	for cell, want := range cells {
+		v, _ := f.GetCellValue("Budget", cell)
+		if got := imp.ParseCellValue(v); got.Status != want {
+			t.Errorf("%s = %q parsed as %q, want %q", cell, v, got.Status, want)
+		}
+	}
+	if v, _ := f.GetCellValue("Budget", "B3"); !almostEqual(*imp.ParseCellValue(v).Amount, 1200) {
+		t.Errorf("B3 = %q, want 1200 paid", v)
+	}
+	if v, _ := f.GetCellValue("Budget", "E6"); v != "" {
+		t.Errorf("expected a skipped bill left blank, got %q", v)
+	}
+	if v, _ := f.GetCellValue("Budget", "C4"); v != "Jan 16" {
+		t.Errorf("C4 = %q, want the due date", v)
+	}
+	if v, _ := f.GetCellValue("Budget", "A10"); v != "LEFT" {
+		t.Errorf("A10 = %q, want LEFT", v)
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
@@ -41,6 +42,7 @@ type ImportPreview struct {
 	Bills       []ParsedBill  `json:"bills"`
 	PeriodCount int           `json:"period_count"`
 	Warnings    []string      `json:"warnings"`
+	Duplicates  []DuplicateMatch `json:"duplicates,omitempty"` // bills that look like ones already set up
 }
 
 type XLSXImporter struct {
@@ -82,6 +84,31 @@ func (imp *XLSXImporter) ParseFile(filePath string) (*ImportPreview, error) {
 		return nil, fmt.Errorf("opening xlsx: %w", err)
 	}
 	defer f.Close()
+	return imp.parse(f, func(int) {})
+}
+
+// ParseReader is ParseFile for a workbook already read into memory.
+func (imp *XLSXImporter) ParseReader(r io.Reader) (*ImportPreview, error) {
+	return imp.ParseReaderProgress(r, nil)
+}
+
+// ParseReaderProgress is ParseReader calling progress with the percentage
+// done, 0-100, as the workbook is opened and its rows are read. progress may
+// be nil.
+func (imp *XLSXImporter) ParseReaderProgress(r io.Reader, progress func(percent int)) (*ImportPreview, error) {
+	if progress == nil {
+		progress = func(int) {}
+	}
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("opening xlsx: %w", err)
+	}
+	defer f.Close()
+	progress(10)
+	return imp.parse(f, progress)
+}
+
+func (imp *XLSXImporter) parse(f *excelize.File, progress func(percent int)) (*ImportPreview, error) {
 
 	sheets := f.GetSheetList()
 	if len(sheets) == 0 {
@@ -106,10 +133,12 @@ func (imp *XLSXImporter) ParseFile(filePath string) (*ImportPreview, error) {
 		return nil, fmt.Errorf("sheet has too few rows")
 	}
 
-	preview := &ImportPreview{}
+	progress(30)
+	preview := &ImportPreview{Warnings: []string{}}
 
 	// Parse bills from column A (rows 3 onwards until we hit "Est. Pay" or "TOTAL")
 	for i := 2; i < len(rows); i++ { // 0-indexed, row 3 = index 2
+		progress(30 + 70*(i-1)/(len(rows)-1))
 		if len(rows[i]) == 0 {
 			continue
 		}
@@ -126,6 +155,9 @@ func (imp *XLSXImporter) ParseFile(filePath string) (*ImportPreview, error) {
 
 		bill := imp.parseBillLabel(label)
 		if bill != nil {
+			if bill.DueDay == nil && bill.DefaultAmt == nil {
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("row %d: no due day or amount found in %q", i+1, label))
+			}
 			preview.Bills = append(preview.Bills, *bill)
 		}
 	}
@@ -289,27 +321,7 @@ func (imp *XLSXImporter) ParseCellValue(value string) ParsedCellValue {
 }
 
 func (imp *XLSXImporter) guessCategory(name string) string {
-	lower := strings.ToLower(name)
-
-	categories := map[string][]string{
-		"housing":        {"mortgage", "hoa", "rent"},
-		"utilities":      {"power", "spire", "gas", "water", "sewage", "h2o", "internet", "trash", "verizon", "electric"},
-		"insurance":      {"insurance"},
-		"transportation": {"car payment", "car insurance"},
-		"subscriptions":  {"hulu", "netflix", "apple", "disney", "espn", "aws"},
-		"savings":        {"saving"},
-		"debt":           {"loan", "credit", "chase", "izzcc", "anna"},
-		"personal":       {"haircut", "cleaning", "pest control", "landscaping"},
-	}
-
-	for cat, keywords := range categories {
-		for _, kw := range keywords {
-			if strings.Contains(lower, kw) {
-				return cat
-			}
-		}
-	}
-	return "other"
+	return GuessCategory(name, nil)
 }
 
 func parseNumber(s string) *float64 {
@@ -0,0 +1,63 @@
+// Package storage keeps uploaded files somewhere every replica can reach
+// them, so a file uploaded through one replica can be read through another.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrNotFound is returned by Get for a key that doesn't exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Store holds blobs by key. Keys are flat names such as "budget-import-x.xlsx".
+// Implementations are safe for concurrent use.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrNotFound when key doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes key; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose keys start with prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Object describes a stored blob.
+type Object struct {
+	Key      string
+	Modified time.Time
+}
+
+const (
+	KindLocal  = "local"
+	KindS3     = "s3"
+	KindMemory = "memory"
+)
+
+// Config selects and configures a Store.
+type Config struct {
+	Kind string // local (the default), s3 or memory
+	Dir  string // local: directory holding the files; defaults to the OS temp dir
+	S3   S3Config
+}
+
+// New builds the Store cfg describes.
+func New(cfg Config) (Store, error) {
+	switch cfg.Kind {
+	case "", KindLocal:
+		dir := cfg.Dir
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		return NewLocal(dir)
+	case KindS3:
+		return NewS3(cfg.S3)
+	case KindMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage kind %q, expected local, s3 or memory", cfg.Kind)
+	}
+}
@@ -1,5 +1,5 @@
 import { api } from './client';
-import type { BillAssignment } from '../types';
+import type { BillAssignment, DeferOptions } from '../types';
 
 export const assignmentsApi = {
   list: (params?: { period_id?: number; bill_id?: number; status?: string }) => {
@@ -22,6 +22,9 @@ export const assignmentsApi = {
       deferred_to_id: deferredToId,
     }),
 
+  deferOptions: (id: number) =>
+    api.get<DeferOptions>(`/assignments/${id}/defer-options`),
+
   delete: (id: number) =>
     api.delete(`/assignments/${id}`),
 
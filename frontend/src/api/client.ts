@@ -1,12 +1,32 @@
 const BASE_URL = '/api/v1';
 
-async function request<T>(path: string, options?: RequestInit): Promise<T> {
+// Access tokens last 15 minutes; one refresh is shared by every request
+// that finds its token expired.
+let refreshing: Promise<boolean> | null = null;
+
+export function refreshSession(): Promise<boolean> {
+  if (!refreshing) {
+    refreshing = fetch(`${BASE_URL}/auth/refresh`, { method: 'POST', credentials: 'include' })
+      .then((res) => res.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+}
+
+async function request<T>(path: string, options?: RequestInit, retried = false): Promise<T> {
   const res = await fetch(`${BASE_URL}${path}`, {
     headers: { 'Content-Type': 'application/json', ...options?.headers },
     credentials: 'include',
     ...options,
   });
 
+  if (res.status === 401 && !path.startsWith('/auth/') && !retried && (await refreshSession())) {
+    return request<T>(path, options, true);
+  }
+
   if (res.status === 401 && !path.startsWith('/auth/')) {
     window.location.href = '/login';
     throw new Error('Unauthorized');
@@ -1,15 +1,34 @@
 import { api } from './client';
-import type { PayPeriod } from '../types';
+import type { GeneratePreview, GenerateReport, PayPeriod, PeriodRisk, SweepSuggestion } from '../types';
 
 export const periodsApi = {
   list: (from: string, to: string) =>
     api.get<PayPeriod[]>(`/pay-periods?from=${from}&to=${to}`),
 
-  generate: (from: string, to: string, sourceIds?: number[]) =>
-    api.post<PayPeriod[]>('/pay-periods/generate', {
+  generate: (from: string, to: string, sourceIds?: number[], updateAmounts = false) =>
+    api.post<GenerateReport>('/pay-periods/generate', {
+      from, to, source_ids: sourceIds || [], update_amounts: updateAmounts,
+    }),
+
+  previewGenerate: (from: string, to: string, sourceIds?: number[]) =>
+    api.post<GeneratePreview[]>('/pay-periods/generate/preview', {
       from, to, source_ids: sourceIds || [],
     }),
 
+  risk: (from: string, to: string) =>
+    api.get<PeriodRisk[]>(`/pay-periods/risk?from=${from}&to=${to}`),
+
+  sweeps: (threshold = 0) =>
+    api.get<SweepSuggestion[]>(`/sweeps?threshold=${threshold}`),
+
+  sweep: (periodId: number, billId: number, threshold = 0) =>
+    api.post('/sweeps', { period_id: periodId, bill_id: billId, threshold }),
+
   update: (id: number, data: Partial<PayPeriod>) =>
     api.put<PayPeriod>(`/pay-periods/${id}`, data),
+
+  bulkUpdate: (incomeSourceId: number, expectedAmount: number, from?: string, updateDefault = false) =>
+    api.patch<PayPeriod[]>('/pay-periods/bulk', {
+      income_source_id: incomeSourceId, expected_amount: expectedAmount, from, update_default: updateDefault,
+    }),
 };
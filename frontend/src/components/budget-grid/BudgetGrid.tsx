@@ -154,6 +154,8 @@ export function BudgetGrid() {
   const handleCellClick = (bill: Bill, period: PayPeriod) => {
     const key = `${bill.id}-${period.id}`;
     const existing = assignments[key];
+    // Cells covering several occurrences have no single amount to edit
+    if (existing?.occurrences) return;
     if (existing) {
       setEditingCell(key);
       setEditValue(String(existing.planned_amount ?? ''));
@@ -184,9 +186,16 @@ export function BudgetGrid() {
   const handleStatusToggle = (assignment: BillAssignment) => {
     const cycle = ['pending', 'paid', 'deferred', 'uncertain'];
     const nextIdx = (cycle.indexOf(assignment.status) + 1) % cycle.length;
-    statusCycle.mutate({ id: assignment.id, status: cycle[nextIdx] });
+    for (const a of assignment.occurrences ?? [assignment]) {
+      statusCycle.mutate({ id: a.id, status: cycle[nextIdx] });
+    }
   };
 
+  const plannedTotal = (assignment: BillAssignment) =>
+    assignment.occurrences
+      ? assignment.occurrences.reduce((sum, a) => sum + (a.planned_amount ?? 0), 0)
+      : assignment.planned_amount;
+
   const handlePeriodAmountClick = (period: PayPeriod) => {
     setEditingPeriod(period.id);
     setPeriodEditValue(String(period.expected_amount ?? ''));
@@ -286,7 +295,7 @@ export function BudgetGrid() {
                       {assignment.is_sinking_fund && (
                         <span className={styles.sfBadge}>SF</span>
                       )}
-                      <span className={styles.amount}>{formatAmount(assignment.planned_amount)}</span>
+                      <span className={styles.amount}>{formatAmount(plannedTotal(assignment))}</span>
                       {!assignment.is_sinking_fund && (
                         <button
                           className={styles.statusBtn}
@@ -504,7 +513,7 @@ export function BudgetGrid() {
                             {assignment.is_sinking_fund && (
                               <span className={styles.sfLabel}>SF →</span>
                             )}
-                            <span className={styles.cellAmount}>{formatAmount(assignment.planned_amount)}</span>
+                            <span className={styles.cellAmount}>{formatAmount(plannedTotal(assignment))}</span>
                             <div className={styles.cellActions}>
                               {!assignment.is_sinking_fund && (
                                 <button
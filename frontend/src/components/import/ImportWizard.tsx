@@ -17,12 +17,33 @@ interface ParsedBill {
   };
 }
 
+interface DuplicateMatch {
+  row: number;
+  bill_id: number;
+  bill_name: string;
+  similarity: number;
+}
+
+type Resolution = 'skip' | 'merge' | 'create';
+
 interface ImportPreview {
   bills: ParsedBill[];
   period_count: number;
   warnings: string[];
+  duplicates?: DuplicateMatch[];
 }
 
+interface ImportJob {
+  id: number;
+  status: 'queued' | 'parsing' | 'done' | 'failed';
+  progress: number;
+  warnings: string[];
+  preview?: ImportPreview;
+  error?: string;
+}
+
+const JOB_POLL_MS = 500;
+
 export function ImportWizard() {
   const queryClient = useQueryClient();
   const [step, setStep] = useState<'upload' | 'preview' | 'done'>('upload');
@@ -30,6 +51,9 @@ export function ImportWizard() {
   const [preview, setPreview] = useState<ImportPreview | null>(null);
   const [error, setError] = useState('');
   const [dragOver, setDragOver] = useState(false);
+  const [progress, setProgress] = useState<number | null>(null);
+  const [duplicates, setDuplicates] = useState<DuplicateMatch[]>([]);
+  const [resolutions, setResolutions] = useState<Record<number, Resolution>>({});
 
   const uploadMutation = useMutation({
     mutationFn: async (formData: FormData) => {
@@ -38,20 +62,52 @@ export function ImportWizard() {
         const err = await res.json().catch(() => ({}));
         throw new Error(err.error?.message || 'Upload failed');
       }
-      const json = await res.json();
-      return json.data as ImportPreview;
+      let job = (await res.json()).data as ImportJob;
+      // The workbook is parsed in the background; poll until it is done
+      while (job.status === 'queued' || job.status === 'parsing') {
+        setProgress(job.progress);
+        await new Promise((resolve) => setTimeout(resolve, JOB_POLL_MS));
+        const poll = await fetch(`/api/v1/import/jobs/${job.id}`);
+        if (!poll.ok) throw new Error('Checking the upload failed');
+        job = (await poll.json()).data as ImportJob;
+      }
+      if (job.status === 'failed' || !job.preview) {
+        throw new Error(job.error || 'Parsing failed');
+      }
+      return job.preview;
     },
     onSuccess: (data) => {
       setPreview(data);
+      setDuplicates(data.duplicates ?? []);
+      setResolutions({});
       setStep('preview');
     },
     onError: (err: Error) => setError(err.message),
+    onSettled: () => setProgress(null),
   });
 
   const confirmMutation = useMutation({
     mutationFn: async () => {
-      const res = await fetch('/api/v1/import/xlsx/confirm', { method: 'POST' });
-      if (!res.ok) throw new Error('Confirm failed');
+      // Duplicates default to skip, leaving the existing bill alone
+      const body = {
+        resolutions: duplicates.map((d) => ({ row: d.row, action: resolutions[d.row] ?? 'skip' })),
+      };
+      const res = await fetch('/api/v1/import/xlsx/confirm', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body),
+      });
+      if (!res.ok) {
+        const err = await res.json().catch(() => ({}));
+        // Bills added since the upload; the preview is still pending
+        if (err.error?.code === 'DUPLICATES_FOUND') {
+          setDuplicates((prev) => [
+            ...prev,
+            ...(err.error.details.duplicates as DuplicateMatch[]).filter((d) => !prev.some((p) => p.row === d.row)),
+          ]);
+        }
+        throw new Error(err.error?.message || 'Confirm failed');
+      }
       return res.json();
     },
     onSuccess: () => {
@@ -133,7 +189,9 @@ export function ImportWizard() {
               <span>{file.name}</span>
               <span className={styles.fileSize}>{(file.size / 1024).toFixed(0)} KB</span>
               <button className={styles.primaryBtn} onClick={handleUpload} disabled={uploadMutation.isPending}>
-                {uploadMutation.isPending ? 'Uploading...' : 'Upload & Parse'}
+                {uploadMutation.isPending
+                  ? progress === null ? 'Uploading...' : `Parsing... ${progress}%`
+                  : 'Upload & Parse'}
               </button>
             </div>
           )}
@@ -171,15 +229,33 @@ export function ImportWizard() {
               <span>Auto</span>
               <span>Category</span>
             </div>
-            {preview.bills.map((bill, i) => (
-              <div key={i} className={styles.tableRow}>
-                <span className={styles.billName}>{bill.name}</span>
-                <span>{bill.due_day || '-'}</span>
-                <span>{bill.default_amount ? `$${bill.default_amount}` : '-'}</span>
-                <span>{bill.is_autopay ? 'Yes' : 'No'}</span>
-                <span>{bill.category || '-'}</span>
-              </div>
-            ))}
+            {preview.bills.map((bill, i) => {
+              const dup = duplicates.find((d) => d.row === i);
+              return (
+                <div key={i} className={styles.tableRow}>
+                  <span className={styles.billName}>
+                    {bill.name}
+                    {dup && (
+                      <span className={styles.duplicate}>
+                        Matches {dup.bill_name}
+                        <select
+                          value={resolutions[i] ?? 'skip'}
+                          onChange={(e) => setResolutions({ ...resolutions, [i]: e.target.value as Resolution })}
+                        >
+                          <option value="skip">Skip</option>
+                          <option value="merge">Merge amount &amp; due day</option>
+                          <option value="create">Create anyway</option>
+                        </select>
+                      </span>
+                    )}
+                  </span>
+                  <span>{bill.due_day || '-'}</span>
+                  <span>{bill.default_amount ? `$${bill.default_amount}` : '-'}</span>
+                  <span>{bill.is_autopay ? 'Yes' : 'No'}</span>
+                  <span>{bill.category || '-'}</span>
+                </div>
+              );
+            })}
           </div>
 
           <div className={styles.previewActions}>
@@ -26,10 +26,15 @@ export function IncomeForm({ source, onClose }: IncomeFormProps) {
     default_amount: source?.default_amount ?? '',
     weekday: (detail?.weekday as number) ?? 5, // Friday
     anchor_date: (detail?.anchor_date as string) || '',
+    skip_every: (detail?.skip_every as number) ?? 0,
     semi_day1: ((detail?.days as number[]) || [1, 16])[0],
     semi_day2: ((detail?.days as number[]) || [1, 16])[1],
+    monthly_day: (detail?.day as number) ?? 1,
     adjust_for_weekends: (detail?.adjust_for_weekends as boolean) ?? true,
+    adjust: (detail?.adjust as string) || 'none',
+    holidays: (detail?.holidays as string) || '',
     one_time_date: (detail?.date as string) || new Date().toISOString().split('T')[0],
+    custom_dates: ((detail?.dates as string[]) || []).join(', '),
     effective_from: source?.effective_from || new Date().toISOString().split('T')[0],
   });
 
@@ -68,19 +73,32 @@ export function IncomeForm({ source, onClose }: IncomeFormProps) {
     },
   });
 
+  // Weekend and bank holiday adjustment, shared by the recurring schedules
+  const adjustment = () => {
+    if (form.adjust === 'none') return {};
+    return form.holidays ? { adjust: form.adjust, holidays: form.holidays } : { adjust: form.adjust };
+  };
+
   const buildScheduleDetail = () => {
     switch (form.pay_schedule) {
       case 'weekly':
-        return { weekday: Number(form.weekday) };
+        return Number(form.skip_every) >= 2
+          ? { weekday: Number(form.weekday), skip_every: Number(form.skip_every), anchor_date: form.anchor_date, ...adjustment() }
+          : { weekday: Number(form.weekday), ...adjustment() };
       case 'biweekly':
-        return { weekday: Number(form.weekday), anchor_date: form.anchor_date };
+        return { weekday: Number(form.weekday), anchor_date: form.anchor_date, ...adjustment() };
       case 'semimonthly':
         return {
           days: [Number(form.semi_day1), Number(form.semi_day2)],
           adjust_for_weekends: form.adjust_for_weekends,
+          ...adjustment(),
         };
+      case 'monthly':
+        return { day: Number(form.monthly_day), adjust_for_weekends: form.adjust_for_weekends, ...adjustment() };
       case 'one_time':
         return { date: form.one_time_date };
+      case 'custom':
+        return { dates: form.custom_dates.split(/[\s,]+/).filter(Boolean) };
       default:
         return {};
     }
@@ -139,7 +157,9 @@ export function IncomeForm({ source, onClose }: IncomeFormProps) {
                 <option value="weekly">Weekly</option>
                 <option value="biweekly">Biweekly</option>
                 <option value="semimonthly">Twice a month</option>
+                <option value="monthly">Monthly</option>
                 <option value="one_time">One-time (bonus, etc.)</option>
+                <option value="custom">Custom dates (invoices, etc.)</option>
               </select>
             </div>
             <div className={styles.field}>
@@ -165,7 +185,20 @@ export function IncomeForm({ source, onClose }: IncomeFormProps) {
             </div>
           )}
 
-          {form.pay_schedule === 'biweekly' && (
+          {form.pay_schedule === 'weekly' && (
+            <div className={styles.field}>
+              <label>Skipped Weeks</label>
+              <select value={form.skip_every} onChange={(e) => set('skip_every', e.target.value)}>
+                <option value={0}>Paid every week</option>
+                <option value={2}>Skip every 2nd week</option>
+                <option value={3}>Skip every 3rd week</option>
+                <option value={4}>Skip every 4th week</option>
+                <option value={5}>Skip every 5th week</option>
+              </select>
+            </div>
+          )}
+
+          {(form.pay_schedule === 'biweekly' || (form.pay_schedule === 'weekly' && Number(form.skip_every) >= 2)) && (
             <div className={styles.field}>
               <label>Anchor Date (a known pay date)</label>
               <input
@@ -213,6 +246,42 @@ export function IncomeForm({ source, onClose }: IncomeFormProps) {
             </>
           )}
 
+          {form.pay_schedule === 'monthly' && (
+            <>
+              <div className={styles.field}>
+                <label>Pay Day of Month</label>
+                <input
+                  type="number"
+                  min="1"
+                  max="31"
+                  value={form.monthly_day}
+                  onChange={(e) => set('monthly_day', e.target.value)}
+                />
+              </div>
+              <div className={styles.checkRow}>
+                <label className={styles.checkbox}>
+                  <input
+                    type="checkbox"
+                    checked={form.adjust_for_weekends}
+                    onChange={(e) => set('adjust_for_weekends', e.target.checked)}
+                  />
+                  Move weekend pay dates to preceding Friday
+                </label>
+              </div>
+            </>
+          )}
+
+          {form.pay_schedule !== 'one_time' && form.pay_schedule !== 'custom' && (
+            <div className={styles.field}>
+              <label>Weekend / Bank Holiday Pay Dates</label>
+              <select value={form.adjust} onChange={(e) => set('adjust', e.target.value)}>
+                <option value="none">Leave as scheduled</option>
+                <option value="previous_business_day">Pay the business day before</option>
+                <option value="next_business_day">Pay the business day after</option>
+              </select>
+            </div>
+          )}
+
           {form.pay_schedule === 'one_time' && (
             <div className={styles.field}>
               <label>Date</label>
@@ -225,6 +294,18 @@ export function IncomeForm({ source, onClose }: IncomeFormProps) {
             </div>
           )}
 
+          {form.pay_schedule === 'custom' && (
+            <div className={styles.field}>
+              <label>Pay Dates (YYYY-MM-DD, comma separated)</label>
+              <textarea
+                value={form.custom_dates}
+                onChange={(e) => set('custom_dates', e.target.value)}
+                placeholder="2025-02-03, 2025-03-15"
+                required
+              />
+            </div>
+          )}
+
           {form.pay_schedule !== 'one_time' && (
             <div className={styles.field}>
               <label>Start Date (generate periods from)</label>
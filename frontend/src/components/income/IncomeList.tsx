@@ -13,7 +13,9 @@ const scheduleLabels: Record<string, string> = {
   weekly: 'Weekly',
   biweekly: 'Every 2 weeks',
   semimonthly: 'Twice a month',
+  monthly: 'Monthly',
   one_time: 'One-time',
+  custom: 'Custom dates',
 };
 
 export function IncomeList() {
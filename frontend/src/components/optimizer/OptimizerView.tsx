@@ -25,15 +25,22 @@ interface SurplusMonth {
 }
 
 interface OptimizationResult {
+  plan_id?: number;
   suggestions: Suggestion[];
   current_min_balance: number;
   optimized_min_balance: number;
   improvement: number;
 }
 
+interface SkippedSource {
+  source: string;
+  reason: string;
+}
+
 interface SurplusResult {
   surplus_months: SurplusMonth[];
   annual_surplus: number;
+  skipped_sources: SkippedSource[];
 }
 
 export function OptimizerView() {
@@ -187,6 +194,16 @@ export function OptimizerView() {
                   <div className={styles.suggestionHeader}>
                     <h3>Proposed Changes</h3>
                     <div className={styles.suggestionActions}>
+                      {optimizeMutation.data.plan_id && (
+                        <a
+                          className={styles.selectAllBtn}
+                          href={`/api/v1/optimizer/plans/${optimizeMutation.data.plan_id}/export?format=html`}
+                          target="_blank"
+                          rel="noreferrer"
+                        >
+                          Share Plan
+                        </a>
+                      )}
                       <button className={styles.selectAllBtn} onClick={toggleAll}>
                         {selected.size === suggestions.length ? (
                           <><CheckSquare size={14} /> Deselect All</>
@@ -292,6 +309,12 @@ export function OptimizerView() {
               ) : (
                 <div className={styles.noChanges}>No surplus months found for this year.</div>
               )}
+
+              {surplusData.skipped_sources?.length > 0 && (
+                <div className={styles.noChanges}>
+                  Skipped: {surplusData.skipped_sources.map((s) => `${s.source} (${s.reason})`).join(', ')}
+                </div>
+              )}
             </>
           ) : null}
         </div>
@@ -1,8 +1,12 @@
 import { create } from 'zustand';
+import { refreshSession } from '../api/client';
+
+export type Role = 'viewer' | 'editor' | 'admin';
 
 interface AuthState {
   isAuthenticated: boolean;
   authRequired: boolean;
+  role: Role | null;
   isLoading: boolean;
   error: string | null;
   checkAuth: () => Promise<void>;
@@ -13,16 +17,23 @@ interface AuthState {
 export const useAuthStore = create<AuthState>((set) => ({
   isAuthenticated: false,
   authRequired: true,
+  role: null,
   isLoading: true,
   error: null,
 
   checkAuth: async () => {
     try {
-      const res = await fetch('/api/v1/auth/status', { credentials: 'include' });
-      const json = await res.json();
+      let res = await fetch('/api/v1/auth/status', { credentials: 'include' });
+      let json = await res.json();
+      // The access token may have run out while the refresh token is still good
+      if (json.data.authRequired && !json.data.authenticated && (await refreshSession())) {
+        res = await fetch('/api/v1/auth/status', { credentials: 'include' });
+        json = await res.json();
+      }
       set({
         isAuthenticated: json.data.authenticated,
         authRequired: json.data.authRequired,
+        role: json.data.role ?? null,
         isLoading: false,
       });
     } catch {
@@ -43,7 +54,7 @@ export const useAuthStore = create<AuthState>((set) => ({
       set({ error: json.error?.message || 'Login failed' });
       throw new Error(json.error?.message || 'Login failed');
     }
-    set({ isAuthenticated: true, error: null });
+    set({ isAuthenticated: true, role: json.data.role ?? null, error: null });
   },
 
   logout: async () => {
@@ -51,6 +62,6 @@ export const useAuthStore = create<AuthState>((set) => ({
       method: 'POST',
       credentials: 'include',
     });
-    set({ isAuthenticated: false });
+    set({ isAuthenticated: false, role: null });
   },
 }));
@@ -12,33 +12,74 @@ export interface Bill {
   sort_order: number;
   sinking_fund_enabled: boolean;
   sinking_fund_periods: number | null;
+  shared_with: string;
+  shared_percent: number | null;
+  bill_type: 'bill' | 'allowance';
+  dependent: string;
+  tax_deductible: boolean;
+  active_months: number[] | null; // 1-12; null or empty means every month
+  monthly_amounts: Record<string, number> | null; // month (1-12) -> amount, overrides default_amount
+  owner: string; // household member who pays it; empty means shared
+  buffer_percent: number; // 0-100, added to the planned amount by auto-assign
+  // Vendor login details, only returned to editors and admins
+  portal_url?: string;
+  username_hint?: string;
+  password_rotated_at?: string;
   created_at: string;
   updated_at: string;
   credit_card?: CreditCard;
 }
 
-export interface CreditCard {
+export interface BillSkip {
   id: number;
   bill_id: number;
+  month: string; // YYYY-MM
+  reason: string;
+  created_at: string;
+}
+
+export interface CreditCard {
+  id: number;
+  bill_id: number | null;
   card_label: string;
   statement_day: number;
   due_day: number;
   issuer: string;
+  apr: number | null;
+  credit_limit: number | null;
+  created_at: string;
+}
+
+export interface CreditCardPromo {
+  id: number;
+  credit_card_id: number;
+  rate: number;
+  balance: number | null;
+  expires_on: string;
+  description: string;
   created_at: string;
 }
 
 export interface IncomeSource {
   id: number;
   name: string;
-  pay_schedule: 'weekly' | 'biweekly' | 'semimonthly' | 'one_time';
+  pay_schedule: 'weekly' | 'biweekly' | 'semimonthly' | 'monthly' | 'one_time' | 'custom';
   schedule_detail: Record<string, unknown>;
   default_amount: number | null;
   is_active: boolean;
   effective_from: string | null;
+  owner: string; // household member it pays; empty means shared
   created_at: string;
   updated_at: string;
 }
 
+export interface IncomeSourceTemplate {
+  key: string;
+  name: string;
+  pay_schedule: IncomeSource['pay_schedule'];
+  schedule_detail: Record<string, unknown>;
+}
+
 export interface PayPeriod {
   id: number;
   income_source_id: number;
@@ -50,6 +91,66 @@ export interface PayPeriod {
   source_name?: string;
   total_bills: number;
   remaining: number;
+  extra_income?: number;
+}
+
+export interface PayrollCalendar {
+  income_source_id: number;
+  year: number;
+  dates: string[];
+  stale_pay_dates?: string[];
+}
+
+export interface GenerateReport {
+  created: PayPeriod[];
+  updated: PayPeriod[];
+  skipped: PayPeriod[];
+  conflicts: { pay_period: PayPeriod; scheduled_amount: number }[];
+}
+
+export interface GeneratePreview {
+  income_source_id: number;
+  source_name: string;
+  effective_from: string;
+  periods: {
+    pay_date: string;
+    expected_amount: number | null;
+    exists: boolean;
+    action: 'create' | 'skip' | 'update' | 'conflict';
+  }[];
+}
+
+export interface PeriodRisk {
+  period_id: number;
+  pay_date: string;
+  source: string;
+  score: number; // 0-100
+  level: 'low' | 'medium' | 'high';
+  late_rate: number;
+  remaining: number;
+  max_due_cluster: number;
+  reasons: string[];
+}
+
+export interface SweepSuggestion {
+  period_id: number;
+  pay_date: string;
+  source: string;
+  income: number;
+  spent: number;
+  leftover: number;
+  threshold: number;
+  amount: number; // leftover above the threshold
+}
+
+export interface ChecklistItem {
+  id: number;
+  pay_period_id: number;
+  label: string;
+  is_done: boolean;
+  sort_order: number;
+  created_at: string;
+  updated_at: string;
 }
 
 export interface BillAssignment {
@@ -67,9 +168,37 @@ export interface BillAssignment {
   manually_moved: boolean;
   is_sinking_fund: boolean;
   sinking_fund_for_period_id: number | null;
+  tax_deductible: boolean;
+  scheduled_date: string | null;
+  due_date: string | null; // bill occurrence this assignment covers
+  paid_date: string | null;
+  original_currency: string | null;
+  original_amount: number | null;
+  fx_note: string;
+  paid_by: string; // household member who paid it
+  paid_from: string; // account it was paid from
   created_at: string;
   updated_at: string;
   bill_name?: string;
+  occurrences?: BillAssignment[]; // every occurrence in the grid cell, when more than one
+}
+
+export interface DeferOption {
+  period_id: number;
+  pay_date: string;
+  source_name: string;
+  remaining: number;
+  projected_remaining: number;
+  pays_before_due: boolean;
+  recommended: boolean;
+  promo_warning?: string;
+}
+
+export interface DeferOptions {
+  assignment_id: number;
+  amount: number;
+  next_due_date: string | null;
+  options: DeferOption[];
 }
 
 export interface SinkingFundInstallment {